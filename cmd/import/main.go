@@ -0,0 +1,138 @@
+// Команда import передает оповещения CAP и файлы GeoJSON в POST /api/v1/incidents/import
+// работающего сервиса:
+//
+//	go run ./cmd/import -dry-run alert.xml
+//	go run ./cmd/import -source mchs zones.geojson
+//
+// Формат определяется по расширению файла (.xml и .cap - CAP, .json и .geojson - GeoJSON)
+// или задается флагом -format. Код возврата 1 - хотя бы один документ не импортирован.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"incident-system/internal/config"
+	"incident-system/internal/domain/models"
+)
+
+func main() {
+    cfg := config.Load()
+
+    serverURL := flag.String("url", "http://localhost:"+cfg.ServerPort, "base URL of the incident service")
+    apiKey := flag.String("api-key", cfg.APIKeyOperator, "operator API key (defaults to API_KEY_OPERATOR)")
+    format := flag.String("format", "", "document format: cap or geojson (by file extension if empty)")
+    source := flag.String("source", "", "namespace of feature ids for GeoJSON, e.g. the agency name")
+    dryRun := flag.Bool("dry-run", false, "validate documents and report planned changes without importing")
+    flag.Parse()
+
+    if flag.NArg() == 0 {
+        fmt.Fprintln(os.Stderr, "usage: import [flags] file...")
+        flag.PrintDefaults()
+        os.Exit(2)
+    }
+
+    client := &http.Client{Timeout: time.Minute}
+    failed := false
+    for _, name := range flag.Args() {
+        documentFormat := *format
+        if documentFormat == "" {
+            documentFormat = formatOf(name)
+        }
+        if err := importFile(client, *serverURL, *apiKey, name, documentFormat, *source, *dryRun); err != nil {
+            fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+            failed = true
+        }
+    }
+    if failed {
+        os.Exit(1)
+    }
+}
+
+func formatOf(name string) string {
+    switch strings.ToLower(filepath.Ext(name)) {
+    case ".xml", ".cap":
+        return models.ImportFormatCAP
+    case ".json", ".geojson":
+        return models.ImportFormatGeoJSON
+    }
+    return ""
+}
+
+func importFile(client *http.Client, serverURL, apiKey, name, format, source string, dryRun bool) error {
+    if format == "" {
+        return fmt.Errorf("unknown format, use -format")
+    }
+
+    file, err := os.Open(name)
+    if err != nil {
+        return err
+    }
+    defer file.Close()
+
+    query := url.Values{}
+    query.Set("format", format)
+    query.Set("dry_run", strconv.FormatBool(dryRun))
+    if source != "" {
+        query.Set("source", source)
+    }
+
+    req, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(serverURL, "/")+"/api/v1/incidents/import?"+query.Encode(), file)
+    if err != nil {
+        return err
+    }
+    req.Header.Set("X-API-Key", apiKey)
+
+    resp, err := client.Do(req)
+    if err != nil {
+        return err
+    }
+    defer resp.Body.Close()
+
+    body, err := io.ReadAll(resp.Body)
+    if err != nil {
+        return err
+    }
+    if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusUnprocessableEntity {
+        return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+    }
+
+    var result models.ImportResult
+    if err := json.Unmarshal(body, &result); err != nil {
+        return fmt.Errorf("invalid response: %w", err)
+    }
+
+    mode := "imported"
+    switch {
+    case result.DryRun:
+        mode = "dry run"
+    case result.Failed > 0:
+        mode = "rejected"
+    }
+    fmt.Printf("%s (%s): %d created, %d updated, %d unchanged, %d cancelled, %d failed\n",
+        name, mode, result.Created, result.Updated, result.Unchanged, result.Cancelled, result.Failed)
+    for _, item := range result.Items {
+        switch {
+        case item.Action == models.ImportActionError:
+            fmt.Printf("  #%d %s: %s\n", item.Index, item.ExternalID, item.Error)
+        case item.IncidentID != 0:
+            fmt.Printf("  #%d %s: %s incident %d %s\n", item.Index, item.ExternalID, item.Action, item.IncidentID, strings.Join(item.ChangedFields, ","))
+        default:
+            fmt.Printf("  #%d %s: %s\n", item.Index, item.ExternalID, item.Action)
+        }
+    }
+
+    if result.Failed > 0 {
+        return fmt.Errorf("not imported: %d incidents failed validation", result.Failed)
+    }
+    return nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+	_ "time/tzdata" // часовые пояса настроек оповещений в образе без zoneinfo
+
+	"incident-system/internal/config"
+	httpdelivery "incident-system/internal/delivery/http"
+	"incident-system/internal/domain/models"
+	"incident-system/internal/infrastructure/storage"
+	"incident-system/pkg/logger"
+)
+
+func main() {
+    storageFlag := flag.String("storage", "", "storage backend: postgres, postgis, sqlite or memory (overrides STORAGE_BACKEND)")
+    flag.Parse()
+
+    cfg := config.Load()
+    if *storageFlag != "" {
+        cfg.StorageBackend = *storageFlag
+    }
+
+    log := logger.NewLogger(cfg.Environment)
+
+    if !models.ValidWebhookFormat(cfg.WebhookFormat) {
+        log.Fatalf("Unknown WEBHOOK_FORMAT %q", cfg.WebhookFormat)
+    }
+    if !models.ValidSeverity(cfg.ProactiveAlertMinSeverity) {
+        log.Fatalf("Unknown PROACTIVE_ALERT_MIN_SEVERITY %q", cfg.ProactiveAlertMinSeverity)
+    }
+
+    store, err := storage.New(cfg)
+    if err != nil {
+        log.Fatalf("Failed to initialize storage: %v", err)
+    }
+    defer store.Close()
+    log.Info("Using %s storage", store.Backend)
+
+    ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+    defer stop()
+
+    router := httpdelivery.SetupRouter(ctx, cfg, store, log)
+
+    server := &http.Server{
+        Addr:    fmt.Sprintf("%s:%s", cfg.ServerHost, cfg.ServerPort),
+        Handler: router,
+    }
+
+    go func() {
+        log.Info("Server listening on %s", server.Addr)
+        if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+            log.Fatalf("Server failed: %v", err)
+        }
+    }()
+
+    <-ctx.Done()
+    log.Info("Shutting down server")
+
+    shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    if err := server.Shutdown(shutdownCtx); err != nil {
+        log.Error("Server shutdown failed: %v", err)
+    }
+}
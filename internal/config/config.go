@@ -4,6 +4,7 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -13,6 +14,12 @@ type Config struct {
     ServerPort string
     ServerHost string
     Environment string
+    PublicBaseURL string // внешний адрес сервиса для ссылок в событиях; пустой - без ссылок
+    TrustedProxies []string // прокси, чьему X-Forwarded-For верить при определении адреса клиента
+    
+    // Реализация хранилища: postgres, postgis, sqlite или memory
+    StorageBackend string
+    SQLitePath     string
     
     DBHost     string
     DBPort     string
@@ -26,15 +33,101 @@ type Config struct {
     RedisPassword string
     RedisDB       int
     
+    // Автоматы вокруг Redis и журнал вебхуков на время его недоступности
+    RedisBreakerFailures      int
+    RedisBreakerOpenTimeout   time.Duration
+    WebhookSpoolPath          string
+    WebhookSpoolDrainInterval time.Duration
+    
     WebhookURL       string
+    WebhookFormat    string // формат тела для WEBHOOK_URL
+    EventSource      string // атрибут source CloudEvents
     WebhookTimeout   time.Duration
     WebhookMaxRetries int
     WebhookRetryDelay time.Duration
     
+    // Пул воркеров вебхуков и ограничения на одного получателя
+    WebhookWorkers                int
+    WebhookDestinationConcurrency int
+    WebhookDestinationRate        float64 // запросов в секунду
+    WebhookDestinationBurst       int
+    WebhookMaxRetryDelay          time.Duration
+    WebhookRetryPollInterval      time.Duration
+    
+    // Автоматы получателей вебхуков и отключение сбоящих подписок
+    WebhookBreakerFailures          int
+    WebhookBreakerOpenTimeout       time.Duration
+    WebhookSubscriptionDisableAfter time.Duration
+    WebhookMaxHoldAge               time.Duration // 0 - по WEBHOOK_MAX_RETRIES и паузам между попытками
+    
     APIKeyOperator string
+    // Токены пользователей для их зон наблюдения и настроек оповещений
+    UserTokenSecret string
+    UserTokenTTL    time.Duration
+    // RequireIfMatch требует заголовок If-Match в PUT, PATCH и DELETE инцидента
+    RequireIfMatch bool
+    
+    OutboxPollInterval time.Duration
+    OutboxBatchSize    int
+    // Повторы событий outbox: после OutboxMaxAttempts неудач событие уходит в dead letter
+    OutboxMaxAttempts   int
+    OutboxRetryDelay    time.Duration
+    OutboxMaxRetryDelay time.Duration
+    
+    IncidentExpiryInterval time.Duration // как часто деактивировать инциденты с истекшим expires_at
+    
+    // Оповещение пользователей, уже находящихся в новой или расширенной зоне
+    ProactiveAlertFreshness   time.Duration // 0 отключает
+    ProactiveAlertMinSeverity string
+    
+    WatchZonesPerUser int // 0 - без ограничения
+    
+    TaxonomyRefreshInterval time.Duration // как часто перечитывать шкалу опасности
+    
+    // Прием сообщений жителей: лимиты частоты и оценка злоупотреблений
+    ReportRateWindow  time.Duration
+    ReportsPerReporter int // 0 - без ограничения
+    ReportsPerAddress  int // 0 - без ограничения
+    ReportAbuseWindow time.Duration
+    ReportBlockScore  float64 // 0 отключает блокировку
+    
+    // Поиск вероятных дубликатов инцидентов и сообщений
+    DuplicateDistanceKm float64
+    DuplicateTimeWindow time.Duration
+    DuplicateThreshold  float64
+    DuplicateLimit      int
+    
+    // Вложения инцидентов: хранилище файлов (local или s3), ограничения и ссылки на скачивание
+    AttachmentStore         string
+    AttachmentDir           string
+    AttachmentMaxSizeMB     int
+    AttachmentsPerIncident  int // 0 - без ограничения
+    AttachmentThumbnailSize int // 0 отключает миниатюры
+    AttachmentURLSecret     string
+    AttachmentURLTTL        time.Duration
+    
+    S3Endpoint  string
+    S3Region    string
+    S3Bucket    string
+    S3AccessKey string
+    S3SecretKey string
+    S3PathStyle bool
+    
+    // Публичные ленты GeoJSON, Atom и CAP
+    FeedTitle     string
+    FeedPublisher string // author Atom и sender CAP
+    FeedLanguage  string
+    FeedMaxItems  int           // 0 - без ограничения
+    FeedMaxAge    time.Duration // Cache-Control: max-age
+    
+    // Импорт оповещений CAP и GeoJSON
+    ImportMaxSizeMB int
+    ImportLanguage  string // предпочтительный язык блока info оповещений CAP
     
     StatsTimeWindowMinutes int
     CacheTTLMinutes       int
+    CacheStaleTTLMinutes  int
+    LocalCacheTTL         time.Duration // 0 отключает локальный снимок в памяти процесса
     LocationCheckRadiusKm float64
 }
 
@@ -48,6 +141,11 @@ func Load() *Config {
         ServerPort:  getEnv("SERVER_PORT", "8080"),
         ServerHost:  getEnv("SERVER_HOST", "0.0.0.0"),
         Environment: getEnv("ENVIRONMENT", "development"),
+        PublicBaseURL: getEnv("PUBLIC_BASE_URL", ""),
+        TrustedProxies: getEnvAsList("TRUSTED_PROXIES"),
+        
+        StorageBackend: getEnv("STORAGE_BACKEND", "postgres"),
+        SQLitePath:     getEnv("SQLITE_PATH", "incident-system.db"),
         
         DBHost:     getEnv("DB_HOST", "localhost"),
         DBPort:     getEnv("DB_PORT", "5432"),
@@ -61,15 +159,89 @@ func Load() *Config {
         RedisPassword: getEnv("REDIS_PASSWORD", ""),
         RedisDB:       getEnvAsInt("REDIS_DB", 0),
         
+        RedisBreakerFailures:      getEnvAsInt("REDIS_BREAKER_FAILURES", 5),
+        RedisBreakerOpenTimeout:   getEnvAsDuration("REDIS_BREAKER_OPEN_TIMEOUT", 10*time.Second),
+        WebhookSpoolPath:          getEnv("WEBHOOK_SPOOL_PATH", "webhook-spool.jsonl"),
+        WebhookSpoolDrainInterval: getEnvAsDuration("WEBHOOK_SPOOL_DRAIN_INTERVAL", 5*time.Second),
+        
         WebhookURL:       getEnv("WEBHOOK_URL", "http://localhost:9090/webhook"),
+        WebhookFormat:    getEnv("WEBHOOK_FORMAT", "legacy"),
+        EventSource:      getEnv("EVENT_SOURCE", "/incident-system"),
         WebhookTimeout:   getEnvAsDuration("WEBHOOK_TIMEOUT", 5*time.Second),
         WebhookMaxRetries: getEnvAsInt("WEBHOOK_MAX_RETRIES", 3),
         WebhookRetryDelay: getEnvAsDuration("WEBHOOK_RETRY_DELAY", 1*time.Second),
         
+        WebhookWorkers:                getEnvAsInt("WEBHOOK_WORKERS", 8),
+        WebhookDestinationConcurrency: getEnvAsInt("WEBHOOK_DESTINATION_CONCURRENCY", 4),
+        WebhookDestinationRate:        getEnvAsFloat("WEBHOOK_DESTINATION_RATE", 20),
+        WebhookDestinationBurst:       getEnvAsInt("WEBHOOK_DESTINATION_BURST", 20),
+        WebhookMaxRetryDelay:          getEnvAsDuration("WEBHOOK_MAX_RETRY_DELAY", 5*time.Minute),
+        WebhookRetryPollInterval:      getEnvAsDuration("WEBHOOK_RETRY_POLL_INTERVAL", 1*time.Second),
+        
+        WebhookBreakerFailures:          getEnvAsInt("WEBHOOK_BREAKER_FAILURES", 5),
+        WebhookBreakerOpenTimeout:       getEnvAsDuration("WEBHOOK_BREAKER_OPEN_TIMEOUT", 30*time.Second),
+        WebhookSubscriptionDisableAfter: getEnvAsDuration("WEBHOOK_SUBSCRIPTION_DISABLE_AFTER", 24*time.Hour),
+        WebhookMaxHoldAge:               getEnvAsDuration("WEBHOOK_MAX_HOLD_AGE", 0),
+        
         APIKeyOperator: getEnv("API_KEY_OPERATOR", "operator-key-secure-change-me"),
+        UserTokenSecret: getEnv("USER_TOKEN_SECRET", ""),
+        UserTokenTTL:    getEnvAsDuration("USER_TOKEN_TTL", 24*time.Hour),
+        RequireIfMatch: getEnvAsBool("REQUIRE_IF_MATCH", false),
+        
+        OutboxPollInterval: getEnvAsDuration("OUTBOX_POLL_INTERVAL", 1*time.Second),
+        OutboxBatchSize:    getEnvAsInt("OUTBOX_BATCH_SIZE", 100),
+        OutboxMaxAttempts:   getEnvAsInt("OUTBOX_MAX_ATTEMPTS", 10),
+        OutboxRetryDelay:    getEnvAsDuration("OUTBOX_RETRY_DELAY", 1*time.Second),
+        OutboxMaxRetryDelay: getEnvAsDuration("OUTBOX_MAX_RETRY_DELAY", 5*time.Minute),
+        
+        IncidentExpiryInterval: getEnvAsDuration("INCIDENT_EXPIRY_INTERVAL", 10*time.Second),
+        
+        ProactiveAlertFreshness:   getEnvAsDuration("PROACTIVE_ALERT_FRESHNESS", 15*time.Minute),
+        ProactiveAlertMinSeverity: getEnv("PROACTIVE_ALERT_MIN_SEVERITY", "high"),
+        
+        WatchZonesPerUser: getEnvAsInt("WATCH_ZONES_PER_USER", 20),
+        
+        TaxonomyRefreshInterval: getEnvAsDuration("TAXONOMY_REFRESH_INTERVAL", 1*time.Minute),
+        
+        ReportRateWindow:   getEnvAsDuration("REPORT_RATE_WINDOW", 1*time.Hour),
+        ReportsPerReporter: getEnvAsInt("REPORTS_PER_REPORTER", 5),
+        ReportsPerAddress:  getEnvAsInt("REPORTS_PER_ADDRESS", 20),
+        ReportAbuseWindow:  getEnvAsDuration("REPORT_ABUSE_WINDOW", 30*24*time.Hour),
+        ReportBlockScore:   getEnvAsFloat("REPORT_BLOCK_SCORE", 0.8),
+        
+        DuplicateDistanceKm: getEnvAsFloat("DUPLICATE_DISTANCE_KM", 0.5),
+        DuplicateTimeWindow: getEnvAsDuration("DUPLICATE_TIME_WINDOW", 6*time.Hour),
+        DuplicateThreshold:  getEnvAsFloat("DUPLICATE_SCORE_THRESHOLD", 0.6),
+        DuplicateLimit:      getEnvAsInt("DUPLICATE_SUGGESTIONS", 5),
+        
+        AttachmentStore:         getEnv("ATTACHMENT_STORE", "local"),
+        AttachmentDir:           getEnv("ATTACHMENT_DIR", "attachments"),
+        AttachmentMaxSizeMB:     getEnvAsInt("ATTACHMENT_MAX_SIZE_MB", 10),
+        AttachmentsPerIncident:  getEnvAsInt("ATTACHMENTS_PER_INCIDENT", 20),
+        AttachmentThumbnailSize: getEnvAsInt("ATTACHMENT_THUMBNAIL_SIZE", 320),
+        AttachmentURLSecret:     getEnv("ATTACHMENT_URL_SECRET", ""),
+        AttachmentURLTTL:        getEnvAsDuration("ATTACHMENT_URL_TTL", 15*time.Minute),
+        
+        S3Endpoint:  getEnv("S3_ENDPOINT", "http://localhost:9000"),
+        S3Region:    getEnv("S3_REGION", "us-east-1"),
+        S3Bucket:    getEnv("S3_BUCKET", "incident-attachments"),
+        S3AccessKey: getEnv("S3_ACCESS_KEY", ""),
+        S3SecretKey: getEnv("S3_SECRET_KEY", ""),
+        S3PathStyle: getEnvAsBool("S3_PATH_STYLE", true),
+        
+        FeedTitle:     getEnv("FEED_TITLE", "Активные инциденты"),
+        FeedPublisher: getEnv("FEED_PUBLISHER", "incident-system"),
+        FeedLanguage:  getEnv("FEED_LANGUAGE", "ru-RU"),
+        FeedMaxItems:  getEnvAsInt("FEED_MAX_ITEMS", 500),
+        FeedMaxAge:    getEnvAsDuration("FEED_MAX_AGE", time.Minute),
+        
+        ImportMaxSizeMB: getEnvAsInt("IMPORT_MAX_SIZE_MB", 5),
+        ImportLanguage:  getEnv("IMPORT_LANGUAGE", "ru-RU"),
         
         StatsTimeWindowMinutes: getEnvAsInt("STATS_TIME_WINDOW_MINUTES", 60),
         CacheTTLMinutes:       getEnvAsInt("CACHE_TTL_MINUTES", 5),
+        CacheStaleTTLMinutes:  getEnvAsInt("CACHE_STALE_TTL_MINUTES", 30),
+        LocalCacheTTL:         getEnvAsDuration("LOCAL_CACHE_TTL", 30*time.Second),
         LocationCheckRadiusKm: getEnvAsFloat("LOCATION_CHECK_RADIUS_KM", 10.0),
     }
 }
@@ -111,6 +283,32 @@ func getEnvAsFloat(key string, defaultValue float64) float64 {
     return floatValue
 }
 
+func getEnvAsBool(key string, defaultValue bool) bool {
+    value := getEnv(key, "")
+    if value == "" {
+        return defaultValue
+    }
+    
+    boolValue, err := strconv.ParseBool(value)
+    if err != nil {
+        log.Printf("Invalid value for %s: %v, using default", key, err)
+        return defaultValue
+    }
+    
+    return boolValue
+}
+
+// getEnvAsList разбирает список через запятую; пустая переменная - пустой список
+func getEnvAsList(key string) []string {
+    var list []string
+    for _, item := range strings.Split(getEnv(key, ""), ",") {
+        if item = strings.TrimSpace(item); item != "" {
+            list = append(list, item)
+        }
+    }
+    return list
+}
+
 func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
     value := getEnv(key, "")
     if value == "" {
@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	stderrors "errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/usecase/services"
+	"incident-system/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// multipartOverhead - запас на заголовки частей и поля формы сверх размера файла
+const multipartOverhead = 1 << 20
+
+type AttachmentHandler struct {
+    service *services.AttachmentService
+}
+
+func NewAttachmentHandler(service *services.AttachmentService) *AttachmentHandler {
+    return &AttachmentHandler{service: service}
+}
+
+// UploadAttachment принимает файл в поле file формы multipart/form-data;
+// необязательное поле uploaded_by - кто загрузил файл
+func (h *AttachmentHandler) UploadAttachment(c *gin.Context) {
+    incidentID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, errors.NewValidationError(err))
+        return
+    }
+    
+    c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.service.MaxSize()+multipartOverhead)
+    header, err := c.FormFile("file")
+    if err != nil {
+        var tooLarge *http.MaxBytesError
+        if stderrors.As(err, &tooLarge) {
+            appErr := errors.NewPayloadTooLargeError(fmt.Sprintf("attachment must be at most %d bytes", h.service.MaxSize()))
+            c.JSON(appErr.Code, appErr)
+            return
+        }
+        c.JSON(http.StatusBadRequest, errors.NewValidationError(fmt.Errorf("multipart field file is required: %w", err)))
+        return
+    }
+    
+    file, err := header.Open()
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, errors.NewInternalError(err))
+        return
+    }
+    defer file.Close()
+    
+    attachment, err := h.service.Upload(c.Request.Context(), incidentID, header.Filename, c.PostForm("uploaded_by"), file)
+    if err != nil {
+        appErr := errors.FromError(err)
+        c.JSON(appErr.Code, appErr)
+        return
+    }
+    
+    c.JSON(http.StatusCreated, attachment)
+}
+
+func (h *AttachmentHandler) ListAttachments(c *gin.Context) {
+    incidentID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, errors.NewValidationError(err))
+        return
+    }
+    
+    attachments, err := h.service.ListAttachments(c.Request.Context(), incidentID)
+    if err != nil {
+        appErr := errors.FromError(err)
+        c.JSON(appErr.Code, appErr)
+        return
+    }
+    
+    if attachments == nil {
+        attachments = []*models.Attachment{}
+    }
+    
+    c.JSON(http.StatusOK, gin.H{"data": attachments})
+}
+
+func (h *AttachmentHandler) GetAttachment(c *gin.Context) {
+    incidentID, id, ok := attachmentParams(c)
+    if !ok {
+        return
+    }
+    
+    attachment, err := h.service.GetAttachment(c.Request.Context(), incidentID, id)
+    if err != nil {
+        appErr := errors.FromError(err)
+        c.JSON(appErr.Code, appErr)
+        return
+    }
+    
+    c.JSON(http.StatusOK, attachment)
+}
+
+func (h *AttachmentHandler) DeleteAttachment(c *gin.Context) {
+    incidentID, id, ok := attachmentParams(c)
+    if !ok {
+        return
+    }
+    
+    if err := h.service.DeleteAttachment(c.Request.Context(), incidentID, id); err != nil {
+        appErr := errors.FromError(err)
+        c.JSON(appErr.Code, appErr)
+        return
+    }
+    
+    c.Status(http.StatusNoContent)
+}
+
+// DownloadAttachment отдает файл по подписанной ссылке из download_url или thumbnail_url;
+// API-ключ не нужен. Изображения открываются в браузере, документы скачиваются.
+func (h *AttachmentHandler) DownloadAttachment(c *gin.Context) {
+    id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, errors.NewValidationError(err))
+        return
+    }
+    
+    expires, err := strconv.ParseInt(c.Query("expires"), 10, 64)
+    if err != nil {
+        c.JSON(http.StatusForbidden, errors.NewForbiddenError())
+        return
+    }
+    
+    variant := c.Query("variant")
+    attachment, content, err := h.service.OpenAttachment(c.Request.Context(), id, variant, expires, c.Query("signature"))
+    if err != nil {
+        appErr := errors.FromError(err)
+        c.JSON(appErr.Code, appErr)
+        return
+    }
+    defer content.Close()
+    
+    disposition := "attachment"
+    if attachment.IsImage() {
+        disposition = "inline"
+    }
+    
+    length := int64(-1)
+    headers := map[string]string{
+        "Content-Disposition":    mime.FormatMediaType(disposition, map[string]string{"filename": attachment.FileName}),
+        "Cache-Control":          fmt.Sprintf("private, max-age=%d", max(0, expires-time.Now().Unix())),
+        "X-Content-Type-Options": "nosniff",
+    }
+    if variant != models.AttachmentThumbnail {
+        length = attachment.Size
+        headers["ETag"] = `"` + attachment.Checksum + `"`
+    }
+    
+    c.DataFromReader(http.StatusOK, length, attachment.ContentType, content, headers)
+}
+
+// attachmentParams разбирает ID инцидента и вложения; при ошибке отвечает 400
+func attachmentParams(c *gin.Context) (int64, int64, bool) {
+    incidentID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, errors.NewValidationError(err))
+        return 0, 0, false
+    }
+    
+    id, err := strconv.ParseInt(c.Param("attachment_id"), 10, 64)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, errors.NewValidationError(err))
+        return 0, 0, false
+    }
+    
+    return incidentID, id, true
+}
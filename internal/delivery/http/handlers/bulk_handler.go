@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/usecase/services"
+	"incident-system/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BulkHandler struct {
+    service *services.BulkService
+}
+
+func NewBulkHandler(service *services.BulkService) *BulkHandler {
+    return &BulkHandler{service: service}
+}
+
+// BulkCreate создает инциденты пакета
+func (h *BulkHandler) BulkCreate(c *gin.Context) {
+    var req models.BulkCreateRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, errors.NewValidationError(err))
+        return
+    }
+
+    respondBulk(c, func(ctx context.Context) (*models.BulkResult, error) {
+        return h.service.Create(ctx, req)
+    })
+}
+
+// BulkUpdate изменяет переданные поля инцидентов пакета
+func (h *BulkHandler) BulkUpdate(c *gin.Context) {
+    var req models.BulkUpdateRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, errors.NewValidationError(err))
+        return
+    }
+
+    respondBulk(c, func(ctx context.Context) (*models.BulkResult, error) {
+        return h.service.Update(ctx, req)
+    })
+}
+
+// BulkTransition переводит инциденты пакета в активное или неактивное состояние
+func (h *BulkHandler) BulkTransition(c *gin.Context) {
+    var req models.BulkTransitionRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, errors.NewValidationError(err))
+        return
+    }
+
+    respondBulk(c, func(ctx context.Context) (*models.BulkResult, error) {
+        return h.service.Transition(ctx, req)
+    })
+}
+
+// BulkDeactivate снимает активность инцидентов пакета
+func (h *BulkHandler) BulkDeactivate(c *gin.Context) {
+    var req models.BulkDeactivateRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, errors.NewValidationError(err))
+        return
+    }
+
+    respondBulk(c, func(ctx context.Context) (*models.BulkResult, error) {
+        return h.service.Deactivate(ctx, req)
+    })
+}
+
+// respondBulk выполняет операцию и отвечает 200, если пакет применен без ошибок,
+// 207 - если в режиме best_effort часть инцидентов не прошла проверку,
+// и 422 - если пакет atomic отменен
+func respondBulk(c *gin.Context, run func(ctx context.Context) (*models.BulkResult, error)) {
+    result, err := run(c.Request.Context())
+    if err != nil {
+        appErr := errors.FromError(err)
+        c.JSON(appErr.Code, appErr)
+        return
+    }
+
+    status := http.StatusOK
+    switch {
+    case !result.Applied:
+        status = http.StatusUnprocessableEntity
+    case result.Failed > 0:
+        status = http.StatusMultiStatus
+    }
+    c.JSON(status, result)
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"net/http"
+
+	"incident-system/internal/domain/eventschema"
+	"incident-system/internal/usecase/services"
+	"incident-system/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EventSchemaHandler публикует JSON-схемы данных событий вебхуков
+type EventSchemaHandler struct{}
+
+func NewEventSchemaHandler() *EventSchemaHandler {
+    return &EventSchemaHandler{}
+}
+
+func (h *EventSchemaHandler) ListSchemas(c *gin.Context) {
+    types := eventschema.Types()
+    
+    schemas := make([]gin.H, 0, len(types))
+    for _, eventType := range types {
+        schemas = append(schemas, gin.H{
+            "type":   eventType,
+            "schema": services.SchemaPath + eventType,
+        })
+    }
+    
+    c.JSON(http.StatusOK, gin.H{"data": schemas})
+}
+
+func (h *EventSchemaHandler) GetSchema(c *gin.Context) {
+    schema, ok := eventschema.Schema(c.Param("type"))
+    if !ok {
+        c.JSON(http.StatusNotFound, errors.NewNotFoundError("event schema"))
+        return
+    }
+    
+    c.Data(http.StatusOK, "application/schema+json", schema)
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/usecase/services"
+	"incident-system/pkg/errors"
+	"incident-system/pkg/geo"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FeedHandler отдает публичные ленты с поддержкой условных запросов. В ленты
+// попадают только инциденты, опубликованные оператором (public)
+type FeedHandler struct {
+    service *services.FeedService
+    maxAge  time.Duration
+}
+
+func NewFeedHandler(service *services.FeedService, maxAge time.Duration) *FeedHandler {
+    return &FeedHandler{service: service, maxAge: maxAge}
+}
+
+func (h *FeedHandler) GeoJSONFeed(c *gin.Context) {
+    h.incidentsFeed(c, models.FeedFormatGeoJSON)
+}
+
+func (h *FeedHandler) AtomFeed(c *gin.Context) {
+    h.incidentsFeed(c, models.FeedFormatAtom)
+}
+
+func (h *FeedHandler) CAPAlert(c *gin.Context) {
+    id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, errors.NewValidationError(err))
+        return
+    }
+
+    feed, err := h.service.CAPAlert(c.Request.Context(), id)
+    if err != nil {
+        appErr := errors.FromError(err)
+        c.JSON(appErr.Code, appErr)
+        return
+    }
+
+    h.writeFeed(c, feed)
+}
+
+func (h *FeedHandler) incidentsFeed(c *gin.Context, format string) {
+    filter, err := parseFeedFilter(c)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, errors.NewValidationError(err))
+        return
+    }
+
+    feed, err := h.service.IncidentsFeed(c.Request.Context(), format, filter)
+    if err != nil {
+        appErr := errors.FromError(err)
+        c.JSON(appErr.Code, appErr)
+        return
+    }
+
+    h.writeFeed(c, feed)
+}
+
+// writeFeed отвечает 304, если у клиента актуальная копия: If-None-Match проверяется
+// по ETag, а без него If-Modified-Since - по Last-Modified с точностью до секунды
+func (h *FeedHandler) writeFeed(c *gin.Context, feed *services.Feed) {
+    lastModified := feed.LastModified.UTC().Truncate(time.Second)
+
+    header := c.Writer.Header()
+    header.Set("ETag", feed.ETag)
+    header.Set("Last-Modified", lastModified.Format(http.TimeFormat))
+    header.Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(h.maxAge.Seconds())))
+    // Ленты читают картографические виджеты на сторонних сайтах
+    header.Set("Access-Control-Allow-Origin", "*")
+
+    if notModified(c.Request, feed.ETag, lastModified) {
+        c.Status(http.StatusNotModified)
+        return
+    }
+
+    c.Data(http.StatusOK, feed.ContentType, feed.Body)
+}
+
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+    if match := r.Header.Get("If-None-Match"); match != "" {
+        for _, candidate := range strings.Split(match, ",") {
+            candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+            if candidate == "*" || candidate == etag {
+                return true
+            }
+        }
+        return false
+    }
+
+    if since := r.Header.Get("If-Modified-Since"); since != "" {
+        t, err := http.ParseTime(since)
+        return err == nil && !lastModified.After(t)
+    }
+    return false
+}
+
+// parseFeedFilter дополняет фильтр категорий, тегов и опасности областью: прямоугольником
+// bbox=minLng,minLat,maxLng,maxLat или кругом lat, lng и radius (в метрах)
+func parseFeedFilter(c *gin.Context) (models.FeedFilter, error) {
+    incidentFilter, err := parseIncidentFilter(c)
+    filter := models.FeedFilter{Incident: incidentFilter}
+    if err != nil {
+        return filter, err
+    }
+
+    if bbox := c.Query("bbox"); bbox != "" {
+        parts := strings.Split(bbox, ",")
+        if len(parts) != 4 {
+            return filter, fmt.Errorf("bbox must be minLng,minLat,maxLng,maxLat")
+        }
+        var v [4]float64
+        for i, part := range parts {
+            if v[i], err = strconv.ParseFloat(strings.TrimSpace(part), 64); err != nil {
+                return filter, fmt.Errorf("invalid bbox: %w", err)
+            }
+        }
+        minLng, minLat, maxLng, maxLat := v[0], v[1], v[2], v[3]
+        if minLat < -90 || maxLat > 90 || minLng < -180 || maxLng > 180 || minLat >= maxLat || minLng >= maxLng {
+            return filter, fmt.Errorf("invalid bbox %q", bbox)
+        }
+        filter.Area = &geo.Zone{Polygon: []geo.Point{
+            {Latitude: minLat, Longitude: minLng},
+            {Latitude: minLat, Longitude: maxLng},
+            {Latitude: maxLat, Longitude: maxLng},
+            {Latitude: maxLat, Longitude: minLng},
+        }}
+        return filter, nil
+    }
+
+    latStr, lngStr, radiusStr := c.Query("lat"), c.Query("lng"), c.Query("radius")
+    if latStr == "" && lngStr == "" && radiusStr == "" {
+        return filter, nil
+    }
+    lat, errLat := strconv.ParseFloat(latStr, 64)
+    lng, errLng := strconv.ParseFloat(lngStr, 64)
+    radius, errRadius := strconv.ParseFloat(radiusStr, 64)
+    if errLat != nil || errLng != nil || errRadius != nil {
+        return filter, fmt.Errorf("lat, lng and radius must be numbers")
+    }
+    if lat < -90 || lat > 90 || lng < -180 || lng > 180 || radius <= 0 {
+        return filter, fmt.Errorf("invalid area: lat %v, lng %v, radius %v", lat, lng, radius)
+    }
+    filter.Area = &geo.Zone{Center: geo.Point{Latitude: lat, Longitude: lng}, RadiusKm: radius / 1000}
+    return filter, nil
+}
@@ -4,46 +4,83 @@ import (
 	"database/sql"
 	"net/http"
 
+	"incident-system/internal/domain/models"
+
 	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
 )
 
+// DegradationMonitor сообщает о работе без части зависимостей
+type DegradationMonitor interface {
+    Status() models.DegradationStatus
+}
+
 type HealthHandler struct {
-    db    *sql.DB
-    redis *redis.Client
+    db          *sql.DB
+    redis       *redis.Client
+    degradation DegradationMonitor
 }
 
-func NewHealthHandler(db *sql.DB, redis *redis.Client) *HealthHandler {
+// NewHealthHandler принимает подключения выбранного хранилища; nil означает,
+// что зависимость не используется (SQLite без Redis, хранилище в памяти)
+func NewHealthHandler(db *sql.DB, redis *redis.Client, degradation DegradationMonitor) *HealthHandler {
     return &HealthHandler{
-        db:    db,
-        redis: redis,
+        db:          db,
+        redis:       redis,
+        degradation: degradation,
     }
 }
 
 func (h *HealthHandler) HealthCheck(c *gin.Context) {
+    services := gin.H{}
+    status := "healthy"
+    
     // Проверка базы данных
-    if err := h.db.Ping(); err != nil {
-        c.JSON(http.StatusServiceUnavailable, gin.H{
-            "status": "unhealthy",
-            "error":  "database connection failed",
-        })
-        return
+    if h.db != nil {
+        if err := h.db.PingContext(c.Request.Context()); err != nil {
+            c.JSON(http.StatusServiceUnavailable, gin.H{
+                "status": "unhealthy",
+                "error":  "database connection failed",
+            })
+            return
+        }
+        services["database"] = "connected"
+    }
+    
+    // Проверка Redis: без него сервис продолжает работать на базе и журнале вебхуков
+    if h.redis != nil {
+        if err := h.redis.Ping(c.Request.Context()).Err(); err != nil {
+            if h.degradation == nil {
+                c.JSON(http.StatusServiceUnavailable, gin.H{
+                    "status": "unhealthy",
+                    "error":  "redis connection failed",
+                })
+                return
+            }
+            services["redis"] = "unavailable"
+            status = "degraded"
+        } else {
+            services["redis"] = "connected"
+        }
     }
     
-    // Проверка Redis
-    if err := h.redis.Ping(c.Request.Context()).Err(); err != nil {
-        c.JSON(http.StatusServiceUnavailable, gin.H{
-            "status": "unhealthy",
-            "error":  "redis connection failed",
-        })
-        return
+    // Хранилище в памяти не имеет внешних зависимостей
+    if len(services) == 0 {
+        services["storage"] = "memory"
     }
     
-    c.JSON(http.StatusOK, gin.H{
-        "status": "healthy",
-        "services": gin.H{
-            "database": "connected",
-            "redis":    "connected",
-        },
-    })
-}
\ No newline at end of file
+    response := gin.H{
+        "status":   status,
+        "services": services,
+    }
+    
+    if h.degradation != nil {
+        degradation := h.degradation.Status()
+        if degradation.Degraded {
+            response["status"] = "degraded"
+        }
+        response["degradation"] = degradation
+    }
+    
+    c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/usecase/services"
+	"incident-system/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ImportHandler struct {
+    service *services.ImportService
+}
+
+func NewImportHandler(service *services.ImportService) *ImportHandler {
+    return &ImportHandler{service: service}
+}
+
+// ImportIncidents принимает документ в теле запроса. Формат задается параметром format
+// или определяется по Content-Type; ответ 422 означает, что документ не импортирован
+// из-за ошибок в перечисленных инцидентах.
+func (h *ImportHandler) ImportIncidents(c *gin.Context) {
+    format := c.Query("format")
+    if format == "" {
+        format = importFormatOf(c.GetHeader("Content-Type"))
+    }
+    if format == "" {
+        c.JSON(http.StatusBadRequest, errors.NewValidationError(fmt.Errorf("format must be %s or %s", models.ImportFormatCAP, models.ImportFormatGeoJSON)))
+        return
+    }
+
+    dryRun := false
+    if value := c.Query("dry_run"); value != "" {
+        var err error
+        if dryRun, err = strconv.ParseBool(value); err != nil {
+            c.JSON(http.StatusBadRequest, errors.NewValidationError(fmt.Errorf("invalid dry_run %q", value)))
+            return
+        }
+    }
+
+    result, err := h.service.Import(c.Request.Context(), format, c.Request.Body, c.Query("source"), dryRun)
+    if err != nil {
+        appErr := errors.FromError(err)
+        c.JSON(appErr.Code, appErr)
+        return
+    }
+
+    status := http.StatusOK
+    if result.Failed > 0 {
+        status = http.StatusUnprocessableEntity
+    }
+    c.JSON(status, result)
+}
+
+// importFormatOf определяет формат документа по типу содержимого
+func importFormatOf(contentType string) string {
+    mediaType, _, _ := mime.ParseMediaType(contentType)
+    switch mediaType {
+    case "application/cap+xml", "application/xml", "text/xml":
+        return models.ImportFormatCAP
+    case "application/geo+json", "application/json":
+        return models.ImportFormatGeoJSON
+    }
+    return ""
+}
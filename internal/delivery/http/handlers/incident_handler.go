@@ -1,8 +1,10 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"incident-system/internal/domain/models"
 	"incident-system/internal/usecase/services"
@@ -12,11 +14,12 @@ import (
 )
 
 type IncidentHandler struct {
-    service *services.IncidentService
+    service    *services.IncidentService
+    duplicates *services.DuplicateService
 }
 
-func NewIncidentHandler(service *services.IncidentService) *IncidentHandler {
-    return &IncidentHandler{service: service}
+func NewIncidentHandler(service *services.IncidentService, duplicates *services.DuplicateService) *IncidentHandler {
+    return &IncidentHandler{service: service, duplicates: duplicates}
 }
 
 func (h *IncidentHandler) CreateIncident(c *gin.Context) {
@@ -28,11 +31,17 @@ func (h *IncidentHandler) CreateIncident(c *gin.Context) {
     
     incident, err := h.service.CreateIncident(c.Request.Context(), req)
     if err != nil {
-        c.JSON(http.StatusInternalServerError, errors.NewInternalError(err))
+        appErr := errors.FromError(err)
+        c.JSON(appErr.Code, appErr)
         return
     }
     
-    c.JSON(http.StatusCreated, incident)
+    // Оператор сразу видит инциденты, которые, вероятно, описывают то же происшествие
+    c.Header("ETag", models.IncidentETag(incident.Version))
+    c.JSON(http.StatusCreated, models.IncidentWithDuplicates{
+        Incident:           incident,
+        PossibleDuplicates: h.duplicates.Suggest(c.Request.Context(), incident),
+    })
 }
 
 func (h *IncidentHandler) GetIncident(c *gin.Context) {
@@ -54,6 +63,22 @@ func (h *IncidentHandler) GetIncident(c *gin.Context) {
         return
     }
     
+    // Присоединенный дубликат доступен по ID инцидента, в который он объединен
+    if incident.MergedInto != nil {
+        location := strings.TrimSuffix(c.Request.URL.Path, idStr) + strconv.FormatInt(*incident.MergedInto, 10)
+        c.Redirect(http.StatusPermanentRedirect, location)
+        return
+    }
+    
+    // ETag - версия инцидента: If-None-Match избавляет от повторной загрузки,
+    // а If-Match в PUT, PATCH и DELETE - от перезаписи чужих изменений
+    etag := models.IncidentETag(incident.Version)
+    c.Header("ETag", etag)
+    if notModified(c.Request, etag, incident.UpdatedAt) {
+        c.Status(http.StatusNotModified)
+        return
+    }
+    
     c.JSON(http.StatusOK, incident)
 }
 
@@ -78,9 +103,16 @@ func (h *IncidentHandler) ListIncidents(c *gin.Context) {
         activeOnly = true
     }
     
-    offset := (page - 1) * limit
+    filter, err := parseIncidentFilter(c)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, errors.NewValidationError(err))
+        return
+    }
+    filter.ActiveOnly = activeOnly
+    filter.Limit = limit
+    filter.Offset = (page - 1) * limit
     
-    incidents, total, err := h.service.ListIncidents(c.Request.Context(), limit, offset, activeOnly)
+    incidents, total, err := h.service.ListIncidents(c.Request.Context(), filter)
     if err != nil {
         c.JSON(http.StatusInternalServerError, errors.NewInternalError(err))
         return
@@ -121,17 +153,47 @@ func (h *IncidentHandler) UpdateIncident(c *gin.Context) {
         return
     }
     
-    incident, err := h.service.UpdateIncident(c.Request.Context(), id, req)
+    h.updateIncident(c, id, req)
+}
+
+// PatchIncident изменяет инцидент по JSON Merge Patch (RFC 7396): переданные поля
+// заменяются, null удаляет необязательное значение
+func (h *IncidentHandler) PatchIncident(c *gin.Context) {
+    id, err := strconv.ParseInt(c.Param("id"), 10, 64)
     if err != nil {
-        c.JSON(http.StatusInternalServerError, errors.NewInternalError(err))
+        c.JSON(http.StatusBadRequest, errors.NewValidationError(err))
         return
     }
     
-    if incident == nil {
-        c.JSON(http.StatusNotFound, errors.NewNotFoundError("incident"))
+    if contentType := c.ContentType(); contentType != models.MergePatchContentType && contentType != "application/json" {
+        appErr := errors.NewUnsupportedMediaTypeError(fmt.Sprintf("content type must be %s", models.MergePatchContentType))
+        c.JSON(appErr.Code, appErr)
+        return
+    }
+    body, err := c.GetRawData()
+    if err != nil {
+        c.JSON(http.StatusBadRequest, errors.NewValidationError(err))
+        return
+    }
+    req, err := models.ParseMergePatch(body)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, errors.NewValidationError(err))
+        return
+    }
+    
+    h.updateIncident(c, id, req)
+}
+
+// updateIncident применяет изменение с условием If-Match и возвращает новую версию в ETag
+func (h *IncidentHandler) updateIncident(c *gin.Context, id int64, req models.UpdateIncidentRequest) {
+    incident, err := h.service.UpdateIncident(c.Request.Context(), id, req, models.ParseIfMatch(c.GetHeader("If-Match")))
+    if err != nil {
+        appErr := errors.FromError(err)
+        c.JSON(appErr.Code, appErr)
         return
     }
     
+    c.Header("ETag", models.IncidentETag(incident.Version))
     c.JSON(http.StatusOK, incident)
 }
 
@@ -143,14 +205,135 @@ func (h *IncidentHandler) DeleteIncident(c *gin.Context) {
         return
     }
     
-    if err := h.service.DeleteIncident(c.Request.Context(), id); err != nil {
-        c.JSON(http.StatusInternalServerError, errors.NewInternalError(err))
+    if err := h.service.DeleteIncident(c.Request.Context(), id, models.ParseIfMatch(c.GetHeader("If-Match"))); err != nil {
+        appErr := errors.FromError(err)
+        c.JSON(appErr.Code, appErr)
         return
     }
     
     c.Status(http.StatusNoContent)
 }
 
+// GetDuplicates возвращает активные инциденты, которые, вероятно, описывают то же происшествие
+func (h *IncidentHandler) GetDuplicates(c *gin.Context) {
+    id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, errors.NewValidationError(err))
+        return
+    }
+    
+    candidates, err := h.duplicates.IncidentDuplicates(c.Request.Context(), id)
+    if err != nil {
+        appErr := errors.FromError(err)
+        c.JSON(appErr.Code, appErr)
+        return
+    }
+    
+    if candidates == nil {
+        candidates = []models.DuplicateCandidate{}
+    }
+    
+    c.JSON(http.StatusOK, gin.H{"data": candidates})
+}
+
+// MergeIncident присоединяет инцидент-дубликат к инциденту target_id и возвращает объединенный инцидент
+func (h *IncidentHandler) MergeIncident(c *gin.Context) {
+    id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, errors.NewValidationError(err))
+        return
+    }
+    
+    var req models.MergeIncidentRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, errors.NewValidationError(err))
+        return
+    }
+    
+    incident, err := h.service.MergeIncidents(c.Request.Context(), id, req.TargetID)
+    if err != nil {
+        appErr := errors.FromError(err)
+        c.JSON(appErr.Code, appErr)
+        return
+    }
+    
+    c.JSON(http.StatusOK, incident)
+}
+
+// PostUpdate добавляет сообщение в хронику инцидента
+func (h *IncidentHandler) PostUpdate(c *gin.Context) {
+    id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, errors.NewValidationError(err))
+        return
+    }
+    
+    var req models.PostUpdateRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, errors.NewValidationError(err))
+        return
+    }
+    
+    update, err := h.service.PostUpdate(c.Request.Context(), id, req)
+    if err != nil {
+        appErr := errors.FromError(err)
+        c.JSON(appErr.Code, appErr)
+        return
+    }
+    
+    c.JSON(http.StatusCreated, update)
+}
+
+// ListUpdates возвращает хронику инцидента; public=true - только публичные сообщения
+func (h *IncidentHandler) ListUpdates(c *gin.Context) {
+    id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, errors.NewValidationError(err))
+        return
+    }
+    
+    publicOnly, err := strconv.ParseBool(c.DefaultQuery("public", "false"))
+    if err != nil {
+        c.JSON(http.StatusBadRequest, errors.NewValidationError(fmt.Errorf("public must be true or false")))
+        return
+    }
+    
+    updates, err := h.service.ListUpdates(c.Request.Context(), id, publicOnly)
+    if err != nil {
+        appErr := errors.FromError(err)
+        c.JSON(appErr.Code, appErr)
+        return
+    }
+    
+    if updates == nil {
+        updates = []*models.IncidentUpdate{}
+    }
+    
+    c.JSON(http.StatusOK, gin.H{"data": updates})
+}
+
+// GetGeometryHistory возвращает прежние зоны инцидента
+func (h *IncidentHandler) GetGeometryHistory(c *gin.Context) {
+    id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, errors.NewValidationError(err))
+        return
+    }
+    
+    history, err := h.service.ListGeometryHistory(c.Request.Context(), id)
+    if err != nil {
+        appErr := errors.FromError(err)
+        c.JSON(appErr.Code, appErr)
+        return
+    }
+    
+    if history == nil {
+        history = []*models.GeometryHistoryEntry{}
+    }
+    
+    c.JSON(http.StatusOK, gin.H{"data": history})
+}
+
 func (h *IncidentHandler) GetStats(c *gin.Context) {
     minutesStr := c.DefaultQuery("minutes", "60")
     minutes, err := strconv.Atoi(minutesStr)
@@ -158,11 +341,72 @@ func (h *IncidentHandler) GetStats(c *gin.Context) {
         minutes = 60
     }
     
-    stats, err := h.service.GetStats(c.Request.Context(), minutes)
+    filter, err := parseIncidentFilter(c)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, errors.NewValidationError(err))
+        return
+    }
+    
+    stats, err := h.service.GetStats(c.Request.Context(), minutes, filter)
     if err != nil {
         c.JSON(http.StatusInternalServerError, errors.NewInternalError(err))
         return
     }
     
     c.JSON(http.StatusOK, stats)
-}
\ No newline at end of file
+}
+
+// parseIncidentFilter читает фильтры category, tag, severity и min_severity; каждый
+// параметр можно повторить или перечислить значения через запятую
+func parseIncidentFilter(c *gin.Context) (models.IncidentFilter, error) {
+    filter := models.IncidentFilter{
+        Categories: queryList(c, "category"),
+        Tags:       queryList(c, "tag"),
+        Severities: queryList(c, "severity"),
+    }
+    
+    for _, category := range filter.Categories {
+        if !models.ValidCategoryCode(category) {
+            return filter, fmt.Errorf("invalid category %q", category)
+        }
+    }
+    for i, tag := range filter.Tags {
+        filter.Tags[i] = strings.ToLower(tag)
+    }
+    
+    if min := c.Query("min_severity"); min != "" {
+        if !models.ValidSeverity(min) {
+            return filter, fmt.Errorf("unknown min_severity %q", min)
+        }
+        atLeast := models.SeveritiesAtLeast(min)
+        if len(filter.Severities) == 0 {
+            filter.Severities = atLeast
+        } else {
+            // Оба условия: перечисленные уровни не ниже min_severity
+            var severities []string
+            for _, severity := range filter.Severities {
+                if models.SeverityAtLeast(severity, min) {
+                    severities = append(severities, severity)
+                }
+            }
+            if len(severities) == 0 {
+                return filter, fmt.Errorf("severity and min_severity do not overlap")
+            }
+            filter.Severities = severities
+        }
+    }
+    
+    return filter, nil
+}
+
+func queryList(c *gin.Context, key string) []string {
+    var values []string
+    for _, raw := range c.QueryArray(key) {
+        for _, value := range strings.Split(raw, ",") {
+            if value = strings.TrimSpace(value); value != "" {
+                values = append(values, value)
+            }
+        }
+    }
+    return values
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"net/http"
+
+	"incident-system/internal/domain/repositories"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MetricsHandler struct {
+    cacheMetrics repositories.CacheMetricsProvider
+}
+
+// NewMetricsHandler принимает метрики локального снимка; nil означает,
+// что локальный уровень кеша отключен
+func NewMetricsHandler(cacheMetrics repositories.CacheMetricsProvider) *MetricsHandler {
+    return &MetricsHandler{cacheMetrics: cacheMetrics}
+}
+
+// GetMetrics отдает состояние локального снимка активных инцидентов этого экземпляра
+func (h *MetricsHandler) GetMetrics(c *gin.Context) {
+    if h.cacheMetrics == nil {
+        c.JSON(http.StatusOK, gin.H{
+            "local_cache": gin.H{"enabled": false},
+        })
+        return
+    }
+    
+    c.JSON(http.StatusOK, gin.H{
+        "local_cache": gin.H{
+            "enabled": true,
+            "metrics": h.cacheMetrics.CacheMetrics(),
+        },
+    })
+}
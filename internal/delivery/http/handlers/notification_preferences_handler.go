@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/http"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/usecase/services"
+	"incident-system/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+type NotificationPreferencesHandler struct {
+    service *services.NotificationPreferencesService
+}
+
+func NewNotificationPreferencesHandler(service *services.NotificationPreferencesService) *NotificationPreferencesHandler {
+    return &NotificationPreferencesHandler{service: service}
+}
+
+func (h *NotificationPreferencesHandler) GetPreferences(c *gin.Context) {
+    prefs, err := h.service.GetPreferences(c.Request.Context(), c.Param("user_id"))
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, errors.NewInternalError(err))
+        return
+    }
+    
+    c.JSON(http.StatusOK, prefs)
+}
+
+func (h *NotificationPreferencesHandler) UpdatePreferences(c *gin.Context) {
+    var req models.NotificationPreferencesRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, errors.NewValidationError(err))
+        return
+    }
+    
+    prefs, err := h.service.UpdatePreferences(c.Request.Context(), c.Param("user_id"), req)
+    if err != nil {
+        appErr := errors.FromError(err)
+        c.JSON(appErr.Code, appErr)
+        return
+    }
+    
+    c.JSON(http.StatusOK, prefs)
+}
+
+func (h *NotificationPreferencesHandler) ResetPreferences(c *gin.Context) {
+    if err := h.service.ResetPreferences(c.Request.Context(), c.Param("user_id")); err != nil {
+        c.JSON(http.StatusInternalServerError, errors.NewInternalError(err))
+        return
+    }
+    
+    c.Status(http.StatusNoContent)
+}
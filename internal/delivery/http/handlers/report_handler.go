@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/usecase/services"
+	"incident-system/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReportHandler struct {
+    service    *services.ReportService
+    duplicates *services.DuplicateService
+}
+
+func NewReportHandler(service *services.ReportService, duplicates *services.DuplicateService) *ReportHandler {
+    return &ReportHandler{service: service, duplicates: duplicates}
+}
+
+// SubmitReport принимает сообщение жителя; заявителю возвращается только номер и статус
+func (h *ReportHandler) SubmitReport(c *gin.Context) {
+    var req models.ReportRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, errors.NewValidationError(err))
+        return
+    }
+    
+    report, err := h.service.SubmitReport(c.Request.Context(), req, c.ClientIP())
+    if err != nil {
+        appErr := errors.FromError(err)
+        c.JSON(appErr.Code, appErr)
+        return
+    }
+    
+    c.JSON(http.StatusAccepted, models.ReportReceipt{
+        ID:        report.ID,
+        Status:    report.Status,
+        CreatedAt: report.CreatedAt,
+    })
+}
+
+// ListReports возвращает очередь модерации; status=all - сообщения в любом статусе
+func (h *ReportHandler) ListReports(c *gin.Context) {
+    limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+    if err != nil || limit < 1 || limit > 100 {
+        limit = 20
+    }
+    
+    page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+    if err != nil || page < 1 {
+        page = 1
+    }
+    
+    filter := models.ReportFilter{
+        UserID: c.Query("user_id"),
+        Limit:  limit,
+        Offset: (page - 1) * limit,
+    }
+    
+    switch status := c.DefaultQuery("status", models.ReportPending); status {
+    case "all":
+    case models.ReportPending, models.ReportApproving, models.ReportApproved, models.ReportMerged, models.ReportRejected:
+        filter.Status = status
+    default:
+        c.JSON(http.StatusBadRequest, errors.NewValidationError(
+            fmt.Errorf("status must be pending, approving, approved, merged, rejected or all")))
+        return
+    }
+    
+    if value := c.Query("incident_id"); value != "" {
+        incidentID, err := strconv.ParseInt(value, 10, 64)
+        if err != nil {
+            c.JSON(http.StatusBadRequest, errors.NewValidationError(err))
+            return
+        }
+        filter.IncidentID = &incidentID
+    }
+    
+    reports, total, err := h.service.ListReports(c.Request.Context(), filter)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, errors.NewInternalError(err))
+        return
+    }
+    
+    if reports == nil {
+        reports = []*models.IncidentReport{}
+    }
+    
+    totalPages := (total + limit - 1) / limit
+    if totalPages == 0 {
+        totalPages = 1
+    }
+    
+    c.JSON(http.StatusOK, gin.H{
+        "data": reports,
+        "meta": gin.H{
+            "page":        page,
+            "limit":       limit,
+            "total":       total,
+            "total_pages": totalPages,
+            "has_next":    page < totalPages,
+            "has_prev":    page > 1,
+        },
+    })
+}
+
+func (h *ReportHandler) GetReport(c *gin.Context) {
+    id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, errors.NewValidationError(err))
+        return
+    }
+    
+    report, err := h.service.GetReport(c.Request.Context(), id)
+    if err != nil {
+        appErr := errors.FromError(err)
+        c.JSON(appErr.Code, appErr)
+        return
+    }
+    
+    c.JSON(http.StatusOK, report)
+}
+
+func (h *ReportHandler) ApproveReport(c *gin.Context) {
+    id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, errors.NewValidationError(err))
+        return
+    }
+    
+    var req models.ApproveReportRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, errors.NewValidationError(err))
+        return
+    }
+    
+    report, incident, err := h.service.ApproveReport(c.Request.Context(), id, req)
+    if err != nil {
+        appErr := errors.FromError(err)
+        c.JSON(appErr.Code, appErr)
+        return
+    }
+    
+    c.JSON(http.StatusOK, models.ReportWithDuplicates{
+        IncidentReport:     report,
+        PossibleDuplicates: h.duplicates.Suggest(c.Request.Context(), incident),
+    })
+}
+
+// GetDuplicates возвращает инциденты, к которым, вероятно, относится сообщение,
+// и похожие сообщения в очереди модерации
+func (h *ReportHandler) GetDuplicates(c *gin.Context) {
+    id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, errors.NewValidationError(err))
+        return
+    }
+    
+    duplicates, err := h.duplicates.ReportDuplicates(c.Request.Context(), id)
+    if err != nil {
+        appErr := errors.FromError(err)
+        c.JSON(appErr.Code, appErr)
+        return
+    }
+    
+    c.JSON(http.StatusOK, duplicates)
+}
+
+func (h *ReportHandler) MergeReport(c *gin.Context) {
+    id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, errors.NewValidationError(err))
+        return
+    }
+    
+    var req models.MergeReportRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, errors.NewValidationError(err))
+        return
+    }
+    
+    report, err := h.service.MergeReport(c.Request.Context(), id, req)
+    if err != nil {
+        appErr := errors.FromError(err)
+        c.JSON(appErr.Code, appErr)
+        return
+    }
+    
+    c.JSON(http.StatusOK, report)
+}
+
+func (h *ReportHandler) RejectReport(c *gin.Context) {
+    id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, errors.NewValidationError(err))
+        return
+    }
+    
+    // Тело необязательно: без него сообщение отклоняется без комментария
+    var req models.RejectReportRequest
+    if c.Request.ContentLength != 0 {
+        if err := c.ShouldBindJSON(&req); err != nil {
+            c.JSON(http.StatusBadRequest, errors.NewValidationError(err))
+            return
+        }
+    }
+    
+    report, err := h.service.RejectReport(c.Request.Context(), id, req)
+    if err != nil {
+        appErr := errors.FromError(err)
+        c.JSON(appErr.Code, appErr)
+        return
+    }
+    
+    c.JSON(http.StatusOK, report)
+}
+
+func (h *ReportHandler) GetReporterStats(c *gin.Context) {
+    stats, err := h.service.ReporterStats(c.Request.Context(), c.Param("user_id"))
+    if err != nil {
+        appErr := errors.FromError(err)
+        c.JSON(appErr.Code, appErr)
+        return
+    }
+    
+    c.JSON(http.StatusOK, stats)
+}
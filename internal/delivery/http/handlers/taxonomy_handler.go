@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"net/http"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/usecase/services"
+	"incident-system/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TaxonomyHandler struct {
+    service *services.TaxonomyService
+}
+
+func NewTaxonomyHandler(service *services.TaxonomyService) *TaxonomyHandler {
+    return &TaxonomyHandler{service: service}
+}
+
+func (h *TaxonomyHandler) ListCategories(c *gin.Context) {
+    categories, err := h.service.ListCategories(c.Request.Context())
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, errors.NewInternalError(err))
+        return
+    }
+
+    c.JSON(http.StatusOK, categories)
+}
+
+func (h *TaxonomyHandler) CreateCategory(c *gin.Context) {
+    var req models.CategoryRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, errors.NewValidationError(err))
+        return
+    }
+
+    category, err := h.service.CreateCategory(c.Request.Context(), req)
+    if err != nil {
+        appErr := errors.FromError(err)
+        c.JSON(appErr.Code, appErr)
+        return
+    }
+
+    c.JSON(http.StatusCreated, category)
+}
+
+func (h *TaxonomyHandler) UpdateCategory(c *gin.Context) {
+    var req models.CategoryRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, errors.NewValidationError(err))
+        return
+    }
+
+    category, err := h.service.UpdateCategory(c.Request.Context(), c.Param("code"), req)
+    if err != nil {
+        appErr := errors.FromError(err)
+        c.JSON(appErr.Code, appErr)
+        return
+    }
+
+    c.JSON(http.StatusOK, category)
+}
+
+func (h *TaxonomyHandler) DeleteCategory(c *gin.Context) {
+    if err := h.service.DeleteCategory(c.Request.Context(), c.Param("code")); err != nil {
+        appErr := errors.FromError(err)
+        c.JSON(appErr.Code, appErr)
+        return
+    }
+
+    c.Status(http.StatusNoContent)
+}
+
+func (h *TaxonomyHandler) ListSeverityLevels(c *gin.Context) {
+    levels, err := h.service.ListSeverityLevels(c.Request.Context())
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, errors.NewInternalError(err))
+        return
+    }
+
+    c.JSON(http.StatusOK, levels)
+}
+
+func (h *TaxonomyHandler) SaveSeverityLevel(c *gin.Context) {
+    var req models.SeverityLevelRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, errors.NewValidationError(err))
+        return
+    }
+
+    level, err := h.service.SaveSeverityLevel(c.Request.Context(), c.Param("code"), req)
+    if err != nil {
+        appErr := errors.FromError(err)
+        c.JSON(appErr.Code, appErr)
+        return
+    }
+
+    c.JSON(http.StatusOK, level)
+}
+
+func (h *TaxonomyHandler) DeleteSeverityLevel(c *gin.Context) {
+    if err := h.service.DeleteSeverityLevel(c.Request.Context(), c.Param("code")); err != nil {
+        appErr := errors.FromError(err)
+        c.JSON(appErr.Code, appErr)
+        return
+    }
+
+    c.Status(http.StatusNoContent)
+}
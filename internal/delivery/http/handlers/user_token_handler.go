@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"incident-system/internal/usecase/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type UserTokenHandler struct {
+    service *services.UserTokenService
+}
+
+func NewUserTokenHandler(service *services.UserTokenService) *UserTokenHandler {
+    return &UserTokenHandler{service: service}
+}
+
+// IssueToken выдает токен пользователю для его зон наблюдения и настроек оповещений
+func (h *UserTokenHandler) IssueToken(c *gin.Context) {
+    c.JSON(http.StatusCreated, h.service.Issue(c.Param("user_id"), time.Now()))
+}
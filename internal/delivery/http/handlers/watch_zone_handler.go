@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/usecase/services"
+	"incident-system/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WatchZoneHandler struct {
+    service *services.WatchZoneService
+}
+
+func NewWatchZoneHandler(service *services.WatchZoneService) *WatchZoneHandler {
+    return &WatchZoneHandler{service: service}
+}
+
+func (h *WatchZoneHandler) CreateZone(c *gin.Context) {
+    var req models.WatchZoneRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, errors.NewValidationError(err))
+        return
+    }
+    
+    zone, err := h.service.CreateZone(c.Request.Context(), c.Param("user_id"), req)
+    if err != nil {
+        appErr := errors.FromError(err)
+        c.JSON(appErr.Code, appErr)
+        return
+    }
+    
+    c.JSON(http.StatusCreated, zone)
+}
+
+func (h *WatchZoneHandler) ListZones(c *gin.Context) {
+    zones, err := h.service.ListZones(c.Request.Context(), c.Param("user_id"))
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, errors.NewInternalError(err))
+        return
+    }
+    
+    if zones == nil {
+        zones = []*models.WatchZone{}
+    }
+    c.JSON(http.StatusOK, gin.H{"data": zones})
+}
+
+func (h *WatchZoneHandler) GetZone(c *gin.Context) {
+    id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, errors.NewValidationError(err))
+        return
+    }
+    
+    zone, err := h.service.GetZone(c.Request.Context(), c.Param("user_id"), id)
+    if err != nil {
+        appErr := errors.FromError(err)
+        c.JSON(appErr.Code, appErr)
+        return
+    }
+    
+    c.JSON(http.StatusOK, zone)
+}
+
+func (h *WatchZoneHandler) UpdateZone(c *gin.Context) {
+    id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, errors.NewValidationError(err))
+        return
+    }
+    
+    var req models.WatchZoneRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, errors.NewValidationError(err))
+        return
+    }
+    
+    zone, err := h.service.UpdateZone(c.Request.Context(), c.Param("user_id"), id, req)
+    if err != nil {
+        appErr := errors.FromError(err)
+        c.JSON(appErr.Code, appErr)
+        return
+    }
+    
+    c.JSON(http.StatusOK, zone)
+}
+
+func (h *WatchZoneHandler) DeleteZone(c *gin.Context) {
+    id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, errors.NewValidationError(err))
+        return
+    }
+    
+    if err := h.service.DeleteZone(c.Request.Context(), c.Param("user_id"), id); err != nil {
+        appErr := errors.FromError(err)
+        c.JSON(appErr.Code, appErr)
+        return
+    }
+    
+    c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/usecase/services"
+	"incident-system/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookHandler struct {
+    service *services.WebhookService
+}
+
+func NewWebhookHandler(service *services.WebhookService) *WebhookHandler {
+    return &WebhookHandler{service: service}
+}
+
+func (h *WebhookHandler) CreateSubscription(c *gin.Context) {
+    var req models.CreateSubscriptionRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, errors.NewValidationError(err))
+        return
+    }
+    
+    subscription, err := h.service.CreateSubscription(c.Request.Context(), req)
+    if err != nil {
+        appErr := errors.FromError(err)
+        c.JSON(appErr.Code, appErr)
+        return
+    }
+    
+    c.JSON(http.StatusCreated, subscription)
+}
+
+func (h *WebhookHandler) ListSubscriptions(c *gin.Context) {
+    subscriptions, err := h.service.ListSubscriptions(c.Request.Context())
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, errors.NewInternalError(err))
+        return
+    }
+    
+    if subscriptions == nil {
+        subscriptions = []*models.WebhookSubscription{}
+    }
+    c.JSON(http.StatusOK, gin.H{"data": subscriptions})
+}
+
+func (h *WebhookHandler) GetSubscription(c *gin.Context) {
+    id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, errors.NewValidationError(err))
+        return
+    }
+    
+    subscription, err := h.service.GetSubscription(c.Request.Context(), id)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, errors.NewInternalError(err))
+        return
+    }
+    
+    if subscription == nil {
+        c.JSON(http.StatusNotFound, errors.NewNotFoundError("subscription"))
+        return
+    }
+    
+    c.JSON(http.StatusOK, subscription)
+}
+
+func (h *WebhookHandler) DeleteSubscription(c *gin.Context) {
+    id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, errors.NewValidationError(err))
+        return
+    }
+    
+    if err := h.service.DeleteSubscription(c.Request.Context(), id); err != nil {
+        c.JSON(http.StatusInternalServerError, errors.NewInternalError(err))
+        return
+    }
+    
+    c.Status(http.StatusNoContent)
+}
+
+func (h *WebhookHandler) EnableSubscription(c *gin.Context) {
+    id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, errors.NewValidationError(err))
+        return
+    }
+    
+    subscription, err := h.service.EnableSubscription(c.Request.Context(), id)
+    if err != nil {
+        appErr := errors.FromError(err)
+        c.JSON(appErr.Code, appErr)
+        return
+    }
+    
+    c.JSON(http.StatusOK, subscription)
+}
+
+// ListDeliveries отдает журнал попыток доставки, от новых к старым.
+// Фильтры: subscription_id, delivery_id, event_type, status (success|failed),
+// from и to в RFC 3339.
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+    limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+    if err != nil || limit < 1 || limit > 500 {
+        limit = 50
+    }
+    
+    page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+    if err != nil || page < 1 {
+        page = 1
+    }
+    
+    filter := models.DeliveryFilter{
+        DeliveryID: c.Query("delivery_id"),
+        EventType:  c.Query("event_type"),
+        Limit:      limit,
+        Offset:     (page - 1) * limit,
+    }
+    
+    if value := c.Query("subscription_id"); value != "" {
+        subscriptionID, err := strconv.ParseInt(value, 10, 64)
+        if err != nil {
+            c.JSON(http.StatusBadRequest, errors.NewValidationError(err))
+            return
+        }
+        filter.SubscriptionID = &subscriptionID
+    }
+    
+    switch status := c.Query("status"); status {
+    case "":
+    case "success", "failed":
+        success := status == "success"
+        filter.Success = &success
+    default:
+        c.JSON(http.StatusBadRequest, errors.NewValidationError(fmt.Errorf("status must be success or failed")))
+        return
+    }
+    
+    if filter.From, err = parseTimeQuery(c, "from"); err != nil {
+        c.JSON(http.StatusBadRequest, errors.NewValidationError(err))
+        return
+    }
+    if filter.To, err = parseTimeQuery(c, "to"); err != nil {
+        c.JSON(http.StatusBadRequest, errors.NewValidationError(err))
+        return
+    }
+    
+    deliveries, total, err := h.service.ListDeliveries(c.Request.Context(), filter)
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, errors.NewInternalError(err))
+        return
+    }
+    
+    if deliveries == nil {
+        deliveries = []*models.WebhookDelivery{}
+    }
+    
+    totalPages := (total + limit - 1) / limit
+    if totalPages == 0 {
+        totalPages = 1
+    }
+    
+    c.JSON(http.StatusOK, gin.H{
+        "data": deliveries,
+        "meta": gin.H{
+            "page":        page,
+            "limit":       limit,
+            "total":       total,
+            "total_pages": totalPages,
+            "has_next":    page < totalPages,
+            "has_prev":    page > 1,
+        },
+    })
+}
+
+func (h *WebhookHandler) ReplayDelivery(c *gin.Context) {
+    task, err := h.service.ReplayDelivery(c.Request.Context(), c.Param("delivery_id"))
+    if err != nil {
+        appErr := errors.FromError(err)
+        c.JSON(appErr.Code, appErr)
+        return
+    }
+    
+    c.JSON(http.StatusAccepted, gin.H{
+        "delivery_id": task.ID,
+        "replay_of":   task.ReplayOf,
+    })
+}
+
+type replaySubscriptionRequest struct {
+    From time.Time `json:"from"`
+    To   time.Time `json:"to"`
+}
+
+func (h *WebhookHandler) ReplaySubscription(c *gin.Context) {
+    id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, errors.NewValidationError(err))
+        return
+    }
+    
+    var req replaySubscriptionRequest
+    if err := c.ShouldBindJSON(&req); err != nil {
+        c.JSON(http.StatusBadRequest, errors.NewValidationError(err))
+        return
+    }
+    
+    tasks, err := h.service.ReplaySubscription(c.Request.Context(), id, req.From, req.To)
+    if err != nil {
+        appErr := errors.FromError(err)
+        c.JSON(appErr.Code, appErr)
+        return
+    }
+    
+    replays := make([]gin.H, 0, len(tasks))
+    for _, task := range tasks {
+        replays = append(replays, gin.H{
+            "delivery_id": task.ID,
+            "replay_of":   task.ReplayOf,
+        })
+    }
+    
+    c.JSON(http.StatusAccepted, gin.H{
+        "replayed": len(replays),
+        "data":     replays,
+    })
+}
+
+func parseTimeQuery(c *gin.Context, name string) (time.Time, error) {
+    value := c.Query(name)
+    if value == "" {
+        return time.Time{}, nil
+    }
+    parsed, err := time.Parse(time.RFC3339, value)
+    if err != nil {
+        return time.Time{}, fmt.Errorf("%s must be an RFC 3339 timestamp: %w", name, err)
+    }
+    return parsed, nil
+}
@@ -2,6 +2,8 @@ package middleware
 
 import (
 	"net/http"
+	"strings"
+	"time"
 
 	"incident-system/internal/config"
 
@@ -26,4 +28,41 @@ func APIKeyAuth(cfg *config.Config) gin.HandlerFunc {
         
         c.Next()
     }
-}
\ No newline at end of file
+}
+
+// UserTokenVerifier проверяет токен пользователя
+type UserTokenVerifier interface {
+    Verify(userID, token string, now time.Time) bool
+}
+
+// UserAuth защищает ресурсы пользователя :user_id: доступ есть у оператора (X-API-Key)
+// и у самого пользователя по токену, выданному для этого user_id (Authorization: Bearer).
+// Токен другого пользователя отклоняется, поэтому пользователь видит только свои ресурсы.
+func UserAuth(cfg *config.Config, tokens UserTokenVerifier) gin.HandlerFunc {
+    return func(c *gin.Context) {
+        if apiKey := c.GetHeader("X-API-Key"); apiKey != "" {
+            if apiKey != cfg.APIKeyOperator {
+                c.JSON(http.StatusForbidden, gin.H{"error": "Invalid API key"})
+                c.Abort()
+                return
+            }
+            c.Next()
+            return
+        }
+        
+        token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+        if !ok || token == "" {
+            c.JSON(http.StatusUnauthorized, gin.H{"error": "API key or user token required"})
+            c.Abort()
+            return
+        }
+        
+        if !tokens.Verify(c.Param("user_id"), token, time.Now()) {
+            c.JSON(http.StatusForbidden, gin.H{"error": "Invalid user token"})
+            c.Abort()
+            return
+        }
+        
+        c.Next()
+    }
+}
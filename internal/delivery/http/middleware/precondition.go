@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"incident-system/internal/config"
+	"incident-system/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireIfMatch отклоняет изменение без заголовка If-Match, если этого требует
+// конфигурация: без него клиент может перезаписать чужие изменения
+func RequireIfMatch(cfg *config.Config) gin.HandlerFunc {
+    return func(c *gin.Context) {
+        if cfg.RequireIfMatch && c.GetHeader("If-Match") == "" {
+            appErr := errors.NewPreconditionRequiredError("If-Match header with the incident ETag is required")
+            c.JSON(appErr.Code, appErr)
+            c.Abort()
+            return
+        }
+        
+        c.Next()
+    }
+}
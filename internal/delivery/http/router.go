@@ -2,27 +2,22 @@ package http
 
 import (
 	"context"
-	"database/sql"
 
 	"incident-system/internal/config"
 	"incident-system/internal/delivery/http/handlers"
 	"incident-system/internal/delivery/http/middleware"
-	"incident-system/internal/infrastructure/cache"
-	"incident-system/internal/infrastructure/db"
-	"incident-system/internal/infrastructure/queue"
+	"incident-system/internal/infrastructure/storage"
 	"incident-system/internal/infrastructure/webhook"
 	"incident-system/internal/usecase/services"
 	"incident-system/pkg/logger"
 
 	"github.com/gin-gonic/gin"
-	"github.com/redis/go-redis/v9"
 )
 
 func SetupRouter(
     ctx context.Context, // Добавили контекст как первый параметр
     cfg *config.Config,
-    postgresDB *sql.DB,
-    redisClient *redis.Client,
+    store *storage.Storage,
     logger *logger.Logger,
 ) *gin.Engine {
     if cfg.Environment == "production" {
@@ -30,31 +25,227 @@ func SetupRouter(
     }
     
     router := gin.Default()
+    // Адрес клиента нужен для лимитов сообщений жителей: X-Forwarded-For
+    // учитывается только от перечисленных прокси
+    if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+        logger.Error("Invalid TRUSTED_PROXIES: %v", err)
+    }
     
-    // Инициализация репозиториев
-    incidentRepo := db.NewPostgresIncidentRepository(postgresDB)
-    cacheRepo, _ := cache.NewRedisCacheRepository(cfg)
-    queueRepo, _ := queue.NewRedisQueueRepository(cfg)
+    // Репозитории выбранного хранилища
+    incidentRepo := store.Incidents
+    cacheRepo := store.Cache
+    queueRepo := store.Queue
     
     // Инициализация сервисов
-    incidentService := services.NewIncidentService(incidentRepo, cacheRepo, queueRepo)
+    taxonomyService := services.NewTaxonomyService(store.Taxonomy, cfg.TaxonomyRefreshInterval, logger)
+    // Шкала опасности из хранилища нужна до первой проверки уровней; без нее
+    // действует шкала по умолчанию
+    if err := taxonomyService.LoadSeverityScale(ctx); err != nil {
+        logger.Error("%v", err)
+    }
+    taxonomyService.Start(ctx)
+    preferencesService := services.NewNotificationPreferencesService(store.Preferences, logger)
+    proactiveAlerter := services.NewProactiveAlerter(
+        incidentRepo,
+        queueRepo,
+        preferencesService,
+        services.ProactiveAlertOptions{
+            Freshness:   cfg.ProactiveAlertFreshness,
+            MinSeverity: cfg.ProactiveAlertMinSeverity,
+        },
+        logger,
+    )
+    incidentService := services.NewIncidentService(incidentRepo, cacheRepo, queueRepo, proactiveAlerter, preferencesService, taxonomyService)
+    watchZoneService := services.NewWatchZoneService(
+        store.WatchZones, queueRepo, preferencesService, cfg.WatchZonesPerUser, logger,
+    )
+    reportService := services.NewReportService(
+        store.Reports,
+        incidentService,
+        taxonomyService,
+        services.ReportOptions{
+            RateWindow:  cfg.ReportRateWindow,
+            PerReporter: cfg.ReportsPerReporter,
+            PerAddress:  cfg.ReportsPerAddress,
+            AbuseWindow: cfg.ReportAbuseWindow,
+            BlockScore:  cfg.ReportBlockScore,
+        },
+        logger,
+    )
+    duplicateService := services.NewDuplicateService(
+        incidentRepo,
+        store.Reports,
+        services.DuplicateOptions{
+            DistanceKm: cfg.DuplicateDistanceKm,
+            TimeWindow: cfg.DuplicateTimeWindow,
+            Threshold:  cfg.DuplicateThreshold,
+            Limit:      cfg.DuplicateLimit,
+        },
+        logger,
+    )
+    attachmentService := services.NewAttachmentService(
+        store.Attachments,
+        incidentRepo,
+        store.Blobs,
+        services.AttachmentOptions{
+            MaxSize:       int64(cfg.AttachmentMaxSizeMB) << 20,
+            PerIncident:   cfg.AttachmentsPerIncident,
+            ThumbnailSize: cfg.AttachmentThumbnailSize,
+            URLSecret:     []byte(cfg.AttachmentURLSecret),
+            URLTTL:        cfg.AttachmentURLTTL,
+            BaseURL:       cfg.PublicBaseURL,
+        },
+        logger,
+    )
+    feedService := services.NewFeedService(
+        incidentService,
+        taxonomyService,
+        services.FeedOptions{
+            Title:     cfg.FeedTitle,
+            Publisher: cfg.FeedPublisher,
+            Language:  cfg.FeedLanguage,
+            MaxItems:  cfg.FeedMaxItems,
+            BaseURL:   cfg.PublicBaseURL,
+        },
+        logger,
+    )
+    importService := services.NewImportService(
+        incidentService,
+        services.ImportOptions{
+            MaxSize:  int64(cfg.ImportMaxSizeMB) << 20,
+            Language: cfg.ImportLanguage,
+        },
+        logger,
+    )
+    bulkService := services.NewBulkService(incidentService, logger)
+    userTokenService := services.NewUserTokenService([]byte(cfg.UserTokenSecret), cfg.UserTokenTTL, logger)
+    webhookService := services.NewWebhookService(
+        queueRepo, store.Subscriptions, store.Deliveries, cfg.WebhookURL, cfg.WebhookFormat, logger,
+    )
     
     // Инициализация обработчиков
-    incidentHandler := handlers.NewIncidentHandler(incidentService)
+    incidentHandler := handlers.NewIncidentHandler(incidentService, duplicateService)
     locationHandler := handlers.NewLocationHandler(incidentService)
-    healthHandler := handlers.NewHealthHandler(postgresDB, redisClient)
+    watchZoneHandler := handlers.NewWatchZoneHandler(watchZoneService)
+    preferencesHandler := handlers.NewNotificationPreferencesHandler(preferencesService)
+    userTokenHandler := handlers.NewUserTokenHandler(userTokenService)
+    taxonomyHandler := handlers.NewTaxonomyHandler(taxonomyService)
+    reportHandler := handlers.NewReportHandler(reportService, duplicateService)
+    attachmentHandler := handlers.NewAttachmentHandler(attachmentService)
+    feedHandler := handlers.NewFeedHandler(feedService, cfg.FeedMaxAge)
+    importHandler := handlers.NewImportHandler(importService)
+    bulkHandler := handlers.NewBulkHandler(bulkService)
+    var degradation handlers.DegradationMonitor
+    if store.Degradation != nil {
+        degradation = store.Degradation
+    }
+    healthHandler := handlers.NewHealthHandler(store.DB, store.Redis, degradation)
+    metricsHandler := handlers.NewMetricsHandler(store.CacheMetrics)
+    webhookHandler := handlers.NewWebhookHandler(webhookService)
+    eventSchemaHandler := handlers.NewEventSchemaHandler()
     
     // Инициализация вебхук клиента
     webhookClient := webhook.NewWebhookClient(cfg, logger)
     
-    // Запуск воркера для отправки вебхуков
-    webhookClient.StartWorker(ctx, queueRepo.DequeueWebhook)
+    // Запуск пула воркеров для отправки вебхуков
+    webhookDispatcher := services.NewWebhookDispatcher(
+        queueRepo,
+        store.Subscriptions,
+        store.Deliveries,
+        webhookClient,
+        services.WebhookDispatcherOptions{
+            DefaultURL:             cfg.WebhookURL,
+            DefaultFormat:          cfg.WebhookFormat,
+            EventSource:            cfg.EventSource,
+            SchemaBaseURL:          cfg.PublicBaseURL,
+            Workers:                cfg.WebhookWorkers,
+            DestinationConcurrency: cfg.WebhookDestinationConcurrency,
+            DestinationRate:        cfg.WebhookDestinationRate,
+            DestinationBurst:       cfg.WebhookDestinationBurst,
+            MaxAttempts:            cfg.WebhookMaxRetries,
+            BaseRetryDelay:         cfg.WebhookRetryDelay,
+            MaxRetryDelay:          cfg.WebhookMaxRetryDelay,
+            RetryPollInterval:      cfg.WebhookRetryPollInterval,
+            BreakerFailures:        cfg.WebhookBreakerFailures,
+            BreakerOpenTimeout:     cfg.WebhookBreakerOpenTimeout,
+            DisableAfter:           cfg.WebhookSubscriptionDisableAfter,
+            MaxHoldAge:             cfg.WebhookMaxHoldAge,
+        },
+        logger,
+    )
+    webhookDispatcher.Start(ctx)
+    
+    // Релей outbox: доставка событий изменения инцидентов
+    outboxRelay := services.NewOutboxRelay(
+        store.Outbox,
+        []services.EventPublisher{
+            services.NewCacheInvalidationPublisher(cacheRepo),
+            services.NewWebhookEventPublisher(queueRepo),
+            services.NewStreamPublisher(store.Stream),
+            services.NewWatchZonePublisher(watchZoneService),
+        },
+        services.OutboxRelayOptions{
+            Interval:       cfg.OutboxPollInterval,
+            BatchSize:      cfg.OutboxBatchSize,
+            MaxAttempts:    cfg.OutboxMaxAttempts,
+            BaseRetryDelay: cfg.OutboxRetryDelay,
+            MaxRetryDelay:  cfg.OutboxMaxRetryDelay,
+        },
+        logger,
+    )
+    outboxRelay.Start(ctx)
+    
+    // Деактивация инцидентов по сроку действия
+    incidentExpirer := services.NewIncidentExpirer(incidentRepo, cacheRepo, cfg.IncidentExpiryInterval, logger)
+    incidentExpirer.Start(ctx)
     
     // Public routes
     public := router.Group("/api/v1")
     {
         public.POST("/location/check", locationHandler.CheckLocation)
+        
+        // Сообщения жителей о происшествиях (попадают в очередь модерации)
+        public.POST("/reports", reportHandler.SubmitReport)
+        
+        // Файлы вложений по подписанным ссылкам из download_url и thumbnail_url
+        public.GET("/attachments/:id/download", attachmentHandler.DownloadAttachment)
+        
+        // Публичные ленты действующих инцидентов для СМИ и картографических приложений
+        feeds := public.Group("/feeds")
+        {
+            feeds.GET("/incidents.geojson", feedHandler.GeoJSONFeed)
+            feeds.GET("/incidents.atom", feedHandler.AtomFeed)
+            feeds.GET("/cap/:id", feedHandler.CAPAlert)
+        }
+        
+        // Справочники категорий и шкала опасности
+        public.GET("/categories", taxonomyHandler.ListCategories)
+        public.GET("/severity-levels", taxonomyHandler.ListSeverityLevels)
+        
         public.GET("/system/health", healthHandler.HealthCheck)
+        public.GET("/system/metrics", metricsHandler.GetMetrics)
+        public.GET("/events/schemas", eventSchemaHandler.ListSchemas)
+        public.GET("/events/schemas/:type", eventSchemaHandler.GetSchema)
+    }
+    
+    // Ресурсы пользователя: оператор по API key или сам пользователь по своему токену
+    users := router.Group("/api/v1/users/:user_id")
+    users.Use(middleware.UserAuth(cfg, userTokenService))
+    {
+        // Зоны наблюдения пользователя
+        watchZones := users.Group("/watch-zones")
+        {
+            watchZones.POST("", watchZoneHandler.CreateZone)
+            watchZones.GET("", watchZoneHandler.ListZones)
+            watchZones.GET("/:id", watchZoneHandler.GetZone)
+            watchZones.PUT("/:id", watchZoneHandler.UpdateZone)
+            watchZones.DELETE("/:id", watchZoneHandler.DeleteZone)
+        }
+        
+        // Настройки оповещений пользователя
+        users.GET("/notification-preferences", preferencesHandler.GetPreferences)
+        users.PUT("/notification-preferences", preferencesHandler.UpdatePreferences)
+        users.DELETE("/notification-preferences", preferencesHandler.ResetPreferences)
     }
     
     // Protected routes (требуют API key)
@@ -66,13 +257,75 @@ func SetupRouter(
         {
             incidents.POST("", incidentHandler.CreateIncident)
             incidents.GET("", incidentHandler.ListIncidents)
+            // Импорт оповещений CAP и файлов GeoJSON других ведомств
+            incidents.POST("/import", importHandler.ImportIncidents)
+            // Пакетные операции: одна транзакция, один сброс кеша, общий batch_id событий
+            incidents.POST("/bulk/create", bulkHandler.BulkCreate)
+            incidents.POST("/bulk/update", bulkHandler.BulkUpdate)
+            incidents.POST("/bulk/transition", bulkHandler.BulkTransition)
+            incidents.POST("/bulk/deactivate", bulkHandler.BulkDeactivate)
+            // ETag - версия инцидента; If-Match защищает изменения от гонок (412)
+            requireIfMatch := middleware.RequireIfMatch(cfg)
             incidents.GET("/:id", incidentHandler.GetIncident)
-            incidents.PUT("/:id", incidentHandler.UpdateIncident)
-            incidents.DELETE("/:id", incidentHandler.DeleteIncident)
+            incidents.PUT("/:id", requireIfMatch, incidentHandler.UpdateIncident)
+            incidents.PATCH("/:id", requireIfMatch, incidentHandler.PatchIncident)
+            incidents.DELETE("/:id", requireIfMatch, incidentHandler.DeleteIncident)
+            
+            // Вероятные дубликаты и объединение с ними
+            incidents.GET("/:id/duplicates", incidentHandler.GetDuplicates)
+            incidents.POST("/:id/merge", incidentHandler.MergeIncident)
+            
+            // Хроника: сообщения о ходе происшествия, публичные видят жители
+            incidents.POST("/:id/updates", incidentHandler.PostUpdate)
+            incidents.GET("/:id/updates", incidentHandler.ListUpdates)
+            
+            // Прежние зоны перемещенного или измененного инцидента
+            incidents.GET("/:id/geometry-history", incidentHandler.GetGeometryHistory)
+            
+            // Вложения: фотографии, схемы и документы
+            incidents.POST("/:id/attachments", attachmentHandler.UploadAttachment)
+            incidents.GET("/:id/attachments", attachmentHandler.ListAttachments)
+            incidents.GET("/:id/attachments/:attachment_id", attachmentHandler.GetAttachment)
+            incidents.DELETE("/:id/attachments/:attachment_id", attachmentHandler.DeleteAttachment)
         }
         
         // Статистика
         protected.GET("/incidents/stats", incidentHandler.GetStats)
+        
+        // Управление справочниками
+        protected.POST("/categories", taxonomyHandler.CreateCategory)
+        protected.PUT("/categories/:code", taxonomyHandler.UpdateCategory)
+        protected.DELETE("/categories/:code", taxonomyHandler.DeleteCategory)
+        protected.PUT("/severity-levels/:code", taxonomyHandler.SaveSeverityLevel)
+        protected.DELETE("/severity-levels/:code", taxonomyHandler.DeleteSeverityLevel)
+        
+        // Очередь модерации сообщений жителей
+        reports := protected.Group("/reports")
+        {
+            reports.GET("", reportHandler.ListReports)
+            reports.GET("/:id", reportHandler.GetReport)
+            reports.GET("/:id/duplicates", reportHandler.GetDuplicates)
+            reports.POST("/:id/approve", reportHandler.ApproveReport)
+            reports.POST("/:id/merge", reportHandler.MergeReport)
+            reports.POST("/:id/reject", reportHandler.RejectReport)
+        }
+        protected.GET("/reporters/:user_id", reportHandler.GetReporterStats)
+        
+        // Токены пользователей для их зон наблюдения и настроек оповещений
+        protected.POST("/users/:user_id/tokens", userTokenHandler.IssueToken)
+        
+        // Подписки на вебхуки, журнал доставок и повторы
+        webhooks := protected.Group("/webhooks")
+        {
+            webhooks.POST("/subscriptions", webhookHandler.CreateSubscription)
+            webhooks.GET("/subscriptions", webhookHandler.ListSubscriptions)
+            webhooks.GET("/subscriptions/:id", webhookHandler.GetSubscription)
+            webhooks.DELETE("/subscriptions/:id", webhookHandler.DeleteSubscription)
+            webhooks.POST("/subscriptions/:id/enable", webhookHandler.EnableSubscription)
+            webhooks.POST("/subscriptions/:id/replay", webhookHandler.ReplaySubscription)
+            webhooks.GET("/deliveries", webhookHandler.ListDeliveries)
+            webhooks.POST("/deliveries/:delivery_id/replay", webhookHandler.ReplayDelivery)
+        }
     }
     
     return router
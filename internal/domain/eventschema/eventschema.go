@@ -0,0 +1,39 @@
+// Package eventschema публикует JSON-схемы данных событий, которые система
+// отправляет получателям вебхуков. Схема описывает WebhookPayload: тело в
+// формате legacy и поле data в CloudEvents.
+package eventschema
+
+import (
+	"embed"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+//go:embed schemas/*.json
+var schemas embed.FS
+
+// Types возвращает типы событий, для которых есть схема, по алфавиту
+func Types() []string {
+    names, _ := fs.Glob(schemas, "schemas/*.json")
+
+    types := make([]string, 0, len(names))
+    for _, name := range names {
+        types = append(types, strings.TrimSuffix(path.Base(name), ".json"))
+    }
+    sort.Strings(types)
+    return types
+}
+
+// Schema возвращает JSON-схему данных события; false, если тип неизвестен
+func Schema(eventType string) ([]byte, bool) {
+    if eventType == "" || strings.ContainsAny(eventType, "/\\") {
+        return nil, false
+    }
+    data, err := schemas.ReadFile("schemas/" + eventType + ".json")
+    if err != nil {
+        return nil, false
+    }
+    return data, true
+}
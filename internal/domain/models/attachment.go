@@ -0,0 +1,86 @@
+package models
+
+import (
+	"path"
+	"strings"
+	"time"
+)
+
+// Типы вложений, которые принимаются: фотографии, схемы и документы.
+// Тип определяется по содержимому файла, заявленный клиентом не учитывается.
+var AttachmentContentTypes = []string{
+    "image/jpeg",
+    "image/png",
+    "image/gif",
+    "image/webp",
+    "application/pdf",
+}
+
+// Варианты файла вложения для скачивания
+const (
+    AttachmentOriginal  = "original"
+    AttachmentThumbnail = "thumbnail" // уменьшенная копия изображения в JPEG
+)
+
+// Attachment - файл, прикрепленный к инциденту. Содержимое хранится в хранилище
+// файлов под ключами StorageKey и ThumbnailKey, здесь - только описание.
+type Attachment struct {
+    ID           int64     `json:"id" db:"id"`
+    IncidentID   int64     `json:"incident_id" db:"incident_id"`
+    FileName     string    `json:"file_name" db:"file_name"`
+    ContentType  string    `json:"content_type" db:"content_type"`
+    Size         int64     `json:"size" db:"size"`
+    Checksum     string    `json:"checksum" db:"checksum"` // SHA-256 содержимого в hex
+    Width        int       `json:"width,omitempty" db:"width"` // размеры изображения в пикселях
+    Height       int       `json:"height,omitempty" db:"height"`
+    StorageKey   string    `json:"-" db:"storage_key"`
+    ThumbnailKey string    `json:"-" db:"thumbnail_key"` // пустой - без миниатюры
+    UploadedBy   string    `json:"uploaded_by,omitempty" db:"uploaded_by"`
+    CreatedAt    time.Time `json:"created_at" db:"created_at"`
+
+    // Подписанные ссылки на скачивание; заполняются при выдаче и действуют до URLExpiresAt
+    DownloadURL  string     `json:"download_url,omitempty" db:"-"`
+    ThumbnailURL string     `json:"thumbnail_url,omitempty" db:"-"`
+    URLExpiresAt *time.Time `json:"url_expires_at,omitempty" db:"-"`
+}
+
+// AllowedAttachmentType сообщает, принимаются ли вложения типа contentType
+func AllowedAttachmentType(contentType string) bool {
+    for _, allowed := range AttachmentContentTypes {
+        if contentType == allowed {
+            return true
+        }
+    }
+    return false
+}
+
+// IsImage сообщает, является ли вложение изображением
+func (a *Attachment) IsImage() bool {
+    return strings.HasPrefix(a.ContentType, "image/")
+}
+
+// AttachmentFileName оставляет от имени файла, переданного клиентом, только
+// последний элемент пути без управляющих символов; пустое имя заменяется на fallback
+func AttachmentFileName(name, fallback string, maxLength int) string {
+    name = strings.ReplaceAll(name, `\`, "/")
+    name = strings.Map(func(r rune) rune {
+        if r < 0x20 || r == 0x7f {
+            return -1
+        }
+        return r
+    }, path.Base(name))
+    name = strings.TrimSpace(name)
+
+    if name == "" || name == "." || name == "/" || name == ".." {
+        return fallback
+    }
+    if runes := []rune(name); len(runes) > maxLength {
+        // Расширение сохраняется: по нему файл открывают после скачивания
+        ext := []rune(path.Ext(name))
+        if len(ext) >= maxLength {
+            ext = nil
+        }
+        name = string(runes[:maxLength-len(ext)]) + string(ext)
+    }
+    return name
+}
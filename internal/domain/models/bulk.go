@@ -0,0 +1,106 @@
+package models
+
+// Режимы пакетных операций
+const (
+    BulkModeAtomic     = "atomic"      // ошибка в любом инциденте отменяет весь пакет
+    BulkModeBestEffort = "best_effort" // применяются инциденты без ошибок
+)
+
+// Действия пакетной операции с инцидентом
+const (
+    BulkActionCreate     = "create"
+    BulkActionUpdate     = "update"
+    BulkActionDeactivate = "deactivate"
+    BulkActionUnchanged  = "unchanged"
+    BulkActionError      = "error"
+)
+
+// MaxBulkItems - наибольшее число инцидентов в одном пакете
+const MaxBulkItems = 500
+
+// Виды изменений инцидента в пакете IncidentRepository.ApplyBatch
+const (
+    ChangeCreate = "create"
+    ChangeUpdate = "update"
+    ChangeDelete = "delete" // деактивация, как DELETE /incidents/{id}
+)
+
+// IncidentChange - изменение инцидента в пакете. После применения Incident
+// содержит сохраненное состояние, для create - с присвоенным ID.
+type IncidentChange struct {
+    Kind     string
+    Incident *Incident
+}
+
+type BulkCreateRequest struct {
+    Mode  string                  `json:"mode"` // atomic (по умолчанию) или best_effort
+    Items []CreateIncidentRequest `json:"items"`
+}
+
+type BulkUpdateItem struct {
+    ID int64 `json:"id"`
+    UpdateIncidentRequest
+}
+
+type BulkUpdateRequest struct {
+    Mode  string           `json:"mode"`
+    Items []BulkUpdateItem `json:"items"`
+}
+
+// BulkTransitionRequest переводит инциденты в активное или неактивное состояние
+type BulkTransitionRequest struct {
+    Mode   string  `json:"mode"`
+    IDs    []int64 `json:"ids"`
+    Active *bool   `json:"active"`
+}
+
+type BulkDeactivateRequest struct {
+    Mode string  `json:"mode"`
+    IDs  []int64 `json:"ids"`
+}
+
+// BulkItemResult - итог пакетной операции с одним инцидентом
+type BulkItemResult struct {
+    Index         int       `json:"index"` // номер в запросе, с 0
+    IncidentID    int64     `json:"incident_id,omitempty"`
+    Action        string    `json:"action"`
+    ChangedFields []string  `json:"changed_fields,omitempty"`
+    Incident      *Incident `json:"incident,omitempty"` // состояние после операции
+    Error         string    `json:"error,omitempty"`
+}
+
+// BulkResult - итог пакетной операции. Изменения пакета записываются одной
+// транзакцией, а их события в outbox получают общий batch_id.
+type BulkResult struct {
+    Mode        string           `json:"mode"`
+    Applied     bool             `json:"applied"` // false - пакет отменен из-за ошибок в режиме atomic
+    BatchID     string           `json:"batch_id,omitempty"`
+    Created     int              `json:"created"`
+    Updated     int              `json:"updated"`
+    Deactivated int              `json:"deactivated"`
+    Unchanged   int              `json:"unchanged"`
+    Failed      int              `json:"failed"`
+    Items       []BulkItemResult `json:"items"`
+}
+
+// Count учитывает итог инцидента в счетчиках пакета
+func (r *BulkResult) Count(item BulkItemResult) {
+    switch item.Action {
+    case BulkActionCreate:
+        r.Created++
+    case BulkActionUpdate:
+        r.Updated++
+    case BulkActionDeactivate:
+        r.Deactivated++
+    case BulkActionUnchanged:
+        r.Unchanged++
+    case BulkActionError:
+        r.Failed++
+    }
+    r.Items = append(r.Items, item)
+}
+
+// ValidBulkMode проверяет режим пакетной операции; пустой означает atomic
+func ValidBulkMode(mode string) bool {
+    return mode == "" || mode == BulkModeAtomic || mode == BulkModeBestEffort
+}
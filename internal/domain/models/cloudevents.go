@@ -0,0 +1,71 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Форматы тела вебхука
+const (
+    WebhookFormatLegacy                = "legacy"                 // WebhookPayload как есть
+    WebhookFormatCloudEventsStructured = "cloudevents-structured" // CloudEvent целиком в теле
+    WebhookFormatCloudEventsBinary     = "cloudevents-binary"     // атрибуты в заголовках ce-*, в теле data
+)
+
+// CloudEventsSpecVersion - поддерживаемая версия спецификации CloudEvents
+const CloudEventsSpecVersion = "1.0"
+
+// ValidWebhookFormat проверяет название формата; пустое означает legacy
+func ValidWebhookFormat(format string) bool {
+    switch format {
+    case "", WebhookFormatLegacy, WebhookFormatCloudEventsStructured, WebhookFormatCloudEventsBinary:
+        return true
+    }
+    return false
+}
+
+// CloudEvent - событие в формате CloudEvents 1.0. В data лежит тот же
+// WebhookPayload, что получают подписки в формате legacy.
+type CloudEvent struct {
+    SpecVersion     string         `json:"specversion"`
+    ID              string         `json:"id"`
+    Source          string         `json:"source"`
+    Type            string         `json:"type"`
+    Subject         string         `json:"subject,omitempty"`
+    Time            time.Time      `json:"time"`
+    DataContentType string         `json:"datacontenttype"`
+    DataSchema      string         `json:"dataschema,omitempty"`
+    Data            WebhookPayload `json:"data"`
+}
+
+// NewCloudEvent оборачивает payload в CloudEvent. schemaURL - адрес JSON-схемы
+// data для типа события; пустой не указывается.
+func NewCloudEvent(payload WebhookPayload, source, schemaURL string) CloudEvent {
+    return CloudEvent{
+        SpecVersion:     CloudEventsSpecVersion,
+        ID:              payload.EventID,
+        Source:          source,
+        Type:            payload.EventType,
+        Subject:         payload.Subject(),
+        Time:            payload.Timestamp.UTC(),
+        DataContentType: "application/json",
+        DataSchema:      schemaURL,
+        Data:            payload,
+    }
+}
+
+// Subject - объект события в терминах CloudEvents: подписка, зона наблюдения, инцидент или пользователь
+func (p WebhookPayload) Subject() string {
+    switch {
+    case p.Subscription != nil:
+        return fmt.Sprintf("webhooks/subscriptions/%d", p.Subscription.ID)
+    case p.WatchZone != nil:
+        return fmt.Sprintf("users/%s/watch-zones/%d", p.WatchZone.UserID, p.WatchZone.ID)
+    case strings.HasPrefix(p.EventType, "incident.") && len(p.Incidents) == 1:
+        return fmt.Sprintf("incidents/%d", p.Incidents[0].ID)
+    case p.UserID != "":
+        return "users/" + p.UserID
+    }
+    return ""
+}
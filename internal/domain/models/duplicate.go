@@ -0,0 +1,95 @@
+package models
+
+import (
+	"time"
+
+	"incident-system/pkg/geo"
+)
+
+// Признаки, по которым инцидент или сообщение считается вероятным дубликатом
+const (
+    DuplicateZoneOverlap     = "zone_overlap"     // зоны пересекаются
+    DuplicateNearby          = "nearby"           // зоны не пересекаются, но рядом
+    DuplicateCloseInTime     = "close_in_time"
+    DuplicateSameCategory    = "same_category"
+    DuplicateRelatedCategory = "related_category" // родитель, подкатегория или общий родитель
+    DuplicateSimilarTitle    = "similar_title"
+)
+
+// DuplicateCandidate - инцидент или сообщение жителя, которые, вероятно, описывают
+// то же происшествие
+type DuplicateCandidate struct {
+    Incident *Incident       `json:"incident,omitempty"`
+    Report   *IncidentReport `json:"report,omitempty"`
+    Score    float64         `json:"score"` // от 0 до 1
+    Reasons  []string        `json:"reasons"`
+}
+
+// IncidentWithDuplicates - созданный инцидент и его вероятные дубликаты
+type IncidentWithDuplicates struct {
+    *Incident
+    PossibleDuplicates []DuplicateCandidate `json:"possible_duplicates,omitempty"`
+}
+
+// ReportWithDuplicates - рассмотренное сообщение и вероятные дубликаты созданного по нему инцидента
+type ReportWithDuplicates struct {
+    *IncidentReport
+    PossibleDuplicates []DuplicateCandidate `json:"possible_duplicates,omitempty"`
+}
+
+// ReportDuplicates - известные инциденты, к которым можно присоединить сообщение,
+// и похожие сообщения в очереди модерации
+type ReportDuplicates struct {
+    Incidents []DuplicateCandidate `json:"incidents"`
+    Reports   []DuplicateCandidate `json:"reports"`
+}
+
+// MergeIncidentRequest - присоединение инцидента-дубликата к инциденту TargetID
+type MergeIncidentRequest struct {
+    TargetID int64 `json:"target_id"`
+}
+
+// MergeIncidents возвращает состояние target после присоединения к нему source:
+// зона покрывает обе зоны, уровень опасности - наибольший, теги объединяются,
+// срок действия - более поздний. Заголовок, описание и категория остаются от target.
+func MergeIncidents(target, source *Incident) *Incident {
+    merged := *target
+
+    zone := geo.MergeZones(target.Zone(), source.Zone())
+    merged.Latitude = zone.Center.Latitude
+    merged.Longitude = zone.Center.Longitude
+    merged.Radius = zone.RadiusKm * 1000
+    merged.Polygon = zone.Polygon
+
+    if SeverityLevelOf(source.Severity) > SeverityLevelOf(target.Severity) {
+        merged.Severity = source.Severity
+    }
+
+    merged.Tags = append([]string(nil), target.Tags...)
+    for _, tag := range source.Tags {
+        if len(merged.Tags) < maxTags && !HasAnyTag(merged.Tags, []string{tag}) {
+            merged.Tags = append(merged.Tags, tag)
+        }
+    }
+
+    merged.Active = target.Active || source.Active
+    merged.ExpiresAt = laterExpiry(target, source)
+
+    return &merged
+}
+
+// laterExpiry возвращает срок действия объединенного инцидента: срок активного из двух,
+// а если активны оба - более поздний (бессрочный позднее любого)
+func laterExpiry(target, source *Incident) *time.Time {
+    switch {
+    case !source.Active && target.Active:
+        return target.ExpiresAt
+    case !target.Active && source.Active:
+        return source.ExpiresAt
+    case target.ExpiresAt == nil || source.ExpiresAt == nil:
+        return nil
+    case source.ExpiresAt.After(*target.ExpiresAt):
+        return source.ExpiresAt
+    }
+    return target.ExpiresAt
+}
@@ -0,0 +1,90 @@
+package models
+
+import (
+	"time"
+)
+
+// Типы событий изменения инцидентов
+const (
+    EventIncidentCreated = "incident.created"
+    EventIncidentUpdated = "incident.updated"
+    EventIncidentDeleted = "incident.deleted"
+    // EventIncidentResolved - оператор снял активность инцидента
+    EventIncidentResolved = "incident.resolved"
+    // EventIncidentExpired - инцидент деактивирован по истечении expires_at
+    EventIncidentExpired = "incident.expired"
+    // EventIncidentMerged - инцидент присоединен как дубликат к инциденту merged_into
+    EventIncidentMerged = "incident.merged"
+    // EventIncidentUpdatePosted - в хронику инцидента добавлено сообщение
+    EventIncidentUpdatePosted = "incident.update_posted"
+    // EventIncidentGeometryChanged - изменилась зона инцидента: центр, радиус или контур
+    EventIncidentGeometryChanged = "incident.geometry_changed"
+)
+
+// EventLocationAlert - пользователь оказался в зоне активных инцидентов
+const EventLocationAlert = "location_alert"
+
+// EventWatchZoneAlert - инцидент затронул зону наблюдения пользователя
+const EventWatchZoneAlert = "watch_zone_alert"
+
+// OutboxEvent - событие изменения инцидента, записанное в outbox в той же транзакции,
+// что и само изменение. Публикуется релеем (OutboxRelay) подписчикам.
+type OutboxEvent struct {
+    ID            int64      `json:"id" db:"id"`
+    IncidentID    int64      `json:"incident_id" db:"incident_id"`
+    EventType     string     `json:"event_type" db:"event_type"`
+    Incident      *Incident  `json:"incident" db:"payload"` // состояние инцидента после изменения
+    ChangedFields []string   `json:"changed_fields,omitempty" db:"changed_fields"` // для incident.updated, incident.resolved и incident.geometry_changed
+    Update        *IncidentUpdate `json:"update,omitempty" db:"update_payload"` // для incident.update_posted
+    Geometry      *GeometryChange `json:"geometry,omitempty" db:"geometry_payload"` // для incident.geometry_changed
+    BatchID       string     `json:"batch_id,omitempty" db:"batch_id"` // общий для событий одной пакетной операции
+    CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+    PublishedAt   *time.Time `json:"published_at,omitempty" db:"published_at"`
+    Delivered     []string   `json:"delivered,omitempty" db:"delivered"` // издатели, уже получившие событие
+    Attempts      int        `json:"attempts" db:"attempts"`
+    LastError     string     `json:"last_error,omitempty" db:"last_error"`
+    NextAttemptAt *time.Time `json:"next_attempt_at,omitempty" db:"next_attempt_at"` // повтор после сбоя не раньше
+    DeadLetteredAt *time.Time `json:"dead_lettered_at,omitempty" db:"dead_lettered_at"` // попытки исчерпаны, публикация прекращена
+}
+
+// NewOutboxEvent создает событие со снимком инцидента
+func NewOutboxEvent(eventType string, incident *Incident) *OutboxEvent {
+    snapshot := *incident
+    return &OutboxEvent{
+        IncidentID: incident.ID,
+        EventType:  eventType,
+        Incident:   &snapshot,
+        CreatedAt:  time.Now(),
+    }
+}
+
+// NewIncidentUpdateEvent создает событие изменения инцидента before -> after:
+// incident.resolved, если инцидент перестал быть активным, иначе incident.updated
+func NewIncidentUpdateEvent(before, after *Incident) *OutboxEvent {
+    eventType := EventIncidentUpdated
+    if before.Active && !after.Active {
+        eventType = EventIncidentResolved
+    }
+
+    event := NewOutboxEvent(eventType, after)
+    event.ChangedFields = ChangedFields(before, after)
+    return event
+}
+
+// NewUpdatePostedEvent создает событие incident.update_posted о сообщении хроники
+func NewUpdatePostedEvent(incident *Incident, update *IncidentUpdate) *OutboxEvent {
+    event := NewOutboxEvent(EventIncidentUpdatePosted, incident)
+    posted := *update
+    event.Update = &posted
+    return event
+}
+
+// DeliveredTo проверяет, получил ли издатель событие
+func (e *OutboxEvent) DeliveredTo(publisher string) bool {
+    for _, name := range e.Delivered {
+        if name == publisher {
+            return true
+        }
+    }
+    return false
+}
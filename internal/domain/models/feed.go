@@ -0,0 +1,121 @@
+package models
+
+import (
+	"time"
+
+	"incident-system/pkg/geo"
+)
+
+// Форматы публичной ленты инцидентов
+const (
+    FeedFormatGeoJSON = "geojson" // GeoJSON FeatureCollection
+    FeedFormatAtom    = "atom"    // Atom с координатами GeoRSS и ссылками на оповещения CAP
+    FeedFormatCAP     = "cap"     // оповещение OASIS CAP 1.2 об одном инциденте
+)
+
+// FeedFilter - условия выборки публичной ленты; пустые поля не ограничивают выборку
+type FeedFilter struct {
+    Incident   IncidentFilter // категории, теги и уровни опасности
+    Area       *geo.Zone      // область, с которой пересекается зона инцидента
+    IncidentID int64          // только инцидент с этим ID
+}
+
+// Matches проверяет инцидент по условиям фильтра
+func (f FeedFilter) Matches(incident *Incident) bool {
+    if f.IncidentID != 0 && incident.ID != f.IncidentID {
+        return false
+    }
+    if !f.Incident.Matches(incident) {
+        return false
+    }
+    return f.Area == nil || geo.ZonesIntersect(*f.Area, incident.Zone())
+}
+
+// FeedItemUpdated - время последнего изменения записи ленты: самое позднее из
+// изменения инцидента и его последнего публичного сообщения хроники
+func FeedItemUpdated(item *IncidentWithUpdate) time.Time {
+    updated := item.UpdatedAt
+    if item.LatestUpdate != nil && item.LatestUpdate.CreatedAt.After(updated) {
+        updated = item.LatestUpdate.CreatedAt
+    }
+    return updated
+}
+
+// CAPCategory сопоставляет корневой категории инцидента категорию CAP 1.2;
+// для собственных категорий справочника - Other
+func CAPCategory(category string) string {
+    for ParentCategory(category) != "" {
+        category = ParentCategory(category)
+    }
+    switch category {
+    case "chemical":
+        return "CBRNE"
+    case "crime":
+        return "Security"
+    case "fire":
+        return "Fire"
+    case "flood", "weather":
+        return "Met"
+    case "road_closure":
+        return "Transport"
+    }
+    return "Other"
+}
+
+// CAPSeverity переводит уровень шкалы опасности в severity CAP 1.2 по числовому
+// уровню: шкала по умолчанию (10, 20, 30) дает Minor, Moderate и Severe, уровень
+// от 40 - Extreme
+func CAPSeverity(severity string) string {
+    level := SeverityLevelOf(severity)
+    switch {
+    case level >= 40:
+        return "Extreme"
+    case level >= 30:
+        return "Severe"
+    case level >= 20:
+        return "Moderate"
+    case level > 0:
+        return "Minor"
+    }
+    return "Unknown"
+}
+
+// capSeverityRanks - severity CAP 1.2 по возрастанию
+var capSeverityRanks = map[string]int{"Unknown": 0, "Minor": 1, "Moderate": 2, "Severe": 3, "Extreme": 4}
+
+// CategoryFromCAP сопоставляет категории CAP 1.2 категорию справочника, обратно CAPCategory
+func CategoryFromCAP(capCategory string) string {
+    switch capCategory {
+    case "CBRNE":
+        return "chemical"
+    case "Security":
+        return "crime"
+    case "Fire":
+        return "fire"
+    case "Met":
+        return "weather"
+    case "Transport":
+        return "road_closure"
+    }
+    return DefaultCategory
+}
+
+// SeverityFromCAP подбирает severity CAP 1.2 наименьший уровень шкалы, который CAPSeverity
+// переводит не ниже нее; если такого нет - наибольший. Unknown дает наименьший уровень.
+func SeverityFromCAP(capSeverity string) (string, bool) {
+    rank, ok := capSeverityRanks[capSeverity]
+    if !ok {
+        return "", false
+    }
+
+    levels := SeveritiesAtLeast("")
+    if len(levels) == 0 {
+        return "", false
+    }
+    for _, code := range levels {
+        if capSeverityRanks[CAPSeverity(code)] >= rank {
+            return code, true
+        }
+    }
+    return levels[len(levels)-1], true
+}
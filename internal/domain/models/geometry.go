@@ -0,0 +1,77 @@
+package models
+
+import (
+	"time"
+)
+
+// IncidentGeometry - зона инцидента: круг radius вокруг центра или контур polygon
+type IncidentGeometry struct {
+    Latitude  float64    `json:"latitude"`
+    Longitude float64    `json:"longitude"`
+    Radius    float64    `json:"radius"` // в метрах; для контура - радиус описанной окружности
+    Polygon   []GeoPoint `json:"polygon,omitempty"`
+}
+
+// Geometry возвращает зону инцидента
+func (i *Incident) Geometry() IncidentGeometry {
+    return IncidentGeometry{
+        Latitude:  i.Latitude,
+        Longitude: i.Longitude,
+        Radius:    i.Radius,
+        Polygon:   append([]GeoPoint(nil), i.Polygon...),
+    }
+}
+
+// Equal сравнивает зоны
+func (g IncidentGeometry) Equal(other IncidentGeometry) bool {
+    return g.Latitude == other.Latitude && g.Longitude == other.Longitude &&
+        g.Radius == other.Radius && samePolygon(g.Polygon, other.Polygon)
+}
+
+// GeometryChange - прежняя и новая зона в событии incident.geometry_changed
+type GeometryChange struct {
+    Previous IncidentGeometry `json:"previous"`
+    Current  IncidentGeometry `json:"current"`
+}
+
+// GeometryHistoryEntry - прежняя зона инцидента: действовала в версии Version
+// до момента ReplacedAt
+type GeometryHistoryEntry struct {
+    ID         int64 `json:"id" db:"id"`
+    IncidentID int64 `json:"incident_id" db:"incident_id"`
+    Version    int64 `json:"version" db:"version"`
+    IncidentGeometry
+    ReplacedAt time.Time `json:"replaced_at" db:"replaced_at"`
+}
+
+// NewGeometryChangedEvent создает событие incident.geometry_changed об изменении
+// before -> after и запись истории с прежней зоной; nil, если зона не изменилась
+func NewGeometryChangedEvent(before, after *Incident) (*OutboxEvent, *GeometryHistoryEntry) {
+    previous, current := before.Geometry(), after.Geometry()
+    if previous.Equal(current) {
+        return nil, nil
+    }
+
+    event := NewOutboxEvent(EventIncidentGeometryChanged, after)
+    event.ChangedFields = zoneFields(ChangedFields(before, after))
+    event.Geometry = &GeometryChange{Previous: previous, Current: current}
+    entry := &GeometryHistoryEntry{
+        IncidentID:       before.ID,
+        Version:          before.Version,
+        IncidentGeometry: previous,
+        ReplacedAt:       after.UpdatedAt,
+    }
+    return event, entry
+}
+
+// zoneFields оставляет из измененных полей поля зоны
+func zoneFields(changedFields []string) []string {
+    zone := []string{}
+    for _, field := range changedFields {
+        switch field {
+        case FieldLocation, FieldRadius, FieldPolygon:
+            zone = append(zone, field)
+        }
+    }
+    return zone
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// BreakerStatus - состояние автомата, защищающего одну зависимость
+type BreakerStatus struct {
+    State    string     `json:"state"` // closed, open или half-open
+    OpenedAt *time.Time `json:"opened_at,omitempty"`
+}
+
+// DegradationStatus описывает работу сервиса без части зависимостей
+type DegradationStatus struct {
+    Degraded        bool                     `json:"degraded"`
+    Breakers        map[string]BreakerStatus `json:"breakers"`
+    SpooledWebhooks int                      `json:"spooled_webhooks"` // вебхуки, ожидающие возврата в очередь
+}
@@ -0,0 +1,101 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Форматы импорта инцидентов
+const (
+    ImportFormatCAP     = "cap"     // оповещение OASIS CAP 1.2
+    ImportFormatGeoJSON = "geojson" // GeoJSON FeatureCollection или отдельный Feature
+)
+
+// Действия импорта с инцидентом
+const (
+    ImportActionCreate    = "create"
+    ImportActionUpdate    = "update"
+    ImportActionUnchanged = "unchanged"
+    ImportActionCancel    = "cancel" // оповещение отменено, инцидент деактивирован
+    ImportActionError     = "error"
+)
+
+// Ограничения импорта и полей инцидента (см. CreateIncidentRequest.Validate)
+const (
+    MaxImportItems         = 1000
+    MaxExternalIDLength    = 512
+    MinIncidentRadius      = 10.0   // в метрах
+    MaxIncidentRadius      = 5000.0 // в метрах; большие круги импортируются многоугольником
+    MaxIncidentPolygonSize = 500
+)
+
+// ImportItem - инцидент, разобранный из внешнего документа
+type ImportItem struct {
+    ExternalID string
+    Incident   CreateIncidentRequest
+    Cancel     bool // оповещение отменено: ранее импортированный инцидент деактивируется
+}
+
+// Validate проверяет внешний ID и поля инцидента
+func (item *ImportItem) Validate() error {
+    if item.ExternalID == "" {
+        return fmt.Errorf("external id is required")
+    }
+    if len(item.ExternalID) > MaxExternalIDLength {
+        return fmt.Errorf("external id is longer than %d bytes", MaxExternalIDLength)
+    }
+    if item.Cancel {
+        return nil
+    }
+
+    return item.Incident.Validate()
+}
+
+func validCoordinates(lat, lng float64) bool {
+    return lat >= -90 && lat <= 90 && lng >= -180 && lng <= 180
+}
+
+// ImportItemResult - итог импорта одного инцидента документа
+type ImportItemResult struct {
+    Index         int      `json:"index"` // номер в документе, с 0
+    ExternalID    string   `json:"external_id,omitempty"`
+    Action        string   `json:"action"`
+    IncidentID    int64    `json:"incident_id,omitempty"` // при dry_run - только для существующих
+    ChangedFields []string `json:"changed_fields,omitempty"`
+    Error         string   `json:"error,omitempty"`
+}
+
+// ImportResult - итог импорта документа. Если хотя бы один инцидент не прошел
+// проверку, документ не импортируется целиком.
+type ImportResult struct {
+    Format    string             `json:"format"`
+    DryRun    bool               `json:"dry_run"`
+    Created   int                `json:"created"`
+    Updated   int                `json:"updated"`
+    Unchanged int                `json:"unchanged"`
+    Cancelled int                `json:"cancelled"`
+    Failed    int                `json:"failed"`
+    Items     []ImportItemResult `json:"items"`
+}
+
+// Count учитывает итог инцидента в счетчиках документа
+func (r *ImportResult) Count(item ImportItemResult) {
+    switch item.Action {
+    case ImportActionCreate:
+        r.Created++
+    case ImportActionUpdate:
+        r.Updated++
+    case ImportActionUnchanged:
+        r.Unchanged++
+    case ImportActionCancel:
+        r.Cancelled++
+    case ImportActionError:
+        r.Failed++
+    }
+    r.Items = append(r.Items, item)
+}
+
+// ExternalIDFor строит внешний ID из пространства имен поставщика и его ID записи
+func ExternalIDFor(format, namespace, id string) string {
+    return format + ":" + strings.TrimSpace(namespace) + "/" + strings.TrimSpace(id)
+}
@@ -1,9 +1,17 @@
 package models
 
 import (
+	"fmt"
+	"math"
 	"time"
+	"unicode/utf8"
+
+	"incident-system/pkg/geo"
 )
 
+// GeoPoint - вершина контура зоны
+type GeoPoint = geo.Point
+
 type Incident struct {
     ID          int64     `json:"id" db:"id"`
     UserID      string    `json:"user_id" db:"user_id"`
@@ -11,13 +19,43 @@ type Incident struct {
     Longitude   float64   `json:"longitude" db:"longitude"`
     Title       string    `json:"title" db:"title"`
     Description string    `json:"description" db:"description"`
-    Severity    string    `json:"severity" db:"severity"` // low, medium, high
+    Severity    string    `json:"severity" db:"severity"` // код уровня шкалы опасности
+    Category    string    `json:"category" db:"category"`
+    Tags        []string  `json:"tags" db:"tags"`
     Radius      float64   `json:"radius" db:"radius"` // в метрах
+    Polygon     []GeoPoint `json:"polygon,omitempty" db:"polygon"` // контур зоны; если пуст - зона является кругом
     Active      bool      `json:"active" db:"active"`
+    Public      bool      `json:"public" db:"public"` // публикуется в открытых лентах; по умолчанию нет
+    ExpiresAt   *time.Time `json:"expires_at,omitempty" db:"expires_at"` // после этого момента инцидент деактивируется
+    MergedInto  *int64    `json:"merged_into,omitempty" db:"merged_into"` // инцидент, к которому присоединен этот дубликат
+    ExternalID  *string   `json:"external_id,omitempty" db:"external_id"` // ID во внешней системе, из которой инцидент импортирован
+    Version     int64     `json:"version" db:"version"` // увеличивается при каждом изменении; ETag инцидента
     CreatedAt   time.Time `json:"created_at" db:"created_at"`
     UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
 }
 
+// ActiveIncidentsSnapshot - закешированный набор активных инцидентов
+type ActiveIncidentsSnapshot struct {
+    Incidents []*Incident `json:"incidents"`
+    Version   int64       `json:"version"`   // версия набора, из которой построен снимок
+    LoadedAt  time.Time   `json:"loaded_at"`
+    Stale     bool        `json:"-"` // набор изменился или истек срок свежести
+}
+
+// CacheMetrics - состояние локального снимка активных инцидентов экземпляра
+type CacheMetrics struct {
+    SnapshotVersion    int64      `json:"snapshot_version"`
+    SnapshotLoadedAt   *time.Time `json:"snapshot_loaded_at,omitempty"`
+    SnapshotAgeSeconds float64    `json:"snapshot_age_seconds"`        // с момента загрузки из базы
+    KnownVersion       int64      `json:"known_version"`               // последняя версия из канала инвалидации
+    Subscribed         bool       `json:"subscribed"`                  // подписка на инвалидации активна
+    LocalHits          int64      `json:"local_hits"`
+    RedisHits          int64      `json:"redis_hits"`
+    Misses             int64      `json:"misses"`
+    Invalidations      int64      `json:"invalidations"`
+    LastInvalidationAt *time.Time `json:"last_invalidation_at,omitempty"`
+}
+
 type IncidentStats struct {
     ZoneID    *int64 `json:"zone_id" db:"zone_id"` 
     UserCount int64  `json:"user_count" db:"user_count"`
@@ -29,14 +67,206 @@ type CreateIncidentRequest struct {
     Longitude   float64 `json:"longitude" validate:"required,longitude"`
     Title       string  `json:"title" validate:"required,min=3,max=255"`
     Description string  `json:"description" validate:"max=1000"`
-    Severity    string  `json:"severity" validate:"required,oneof=low medium high"`
-    Radius      float64 `json:"radius" validate:"required,min=10,max=5000"`
+    Severity    string  `json:"severity" validate:"required"`
+    Category    string   `json:"category"` // пустая - DefaultCategory
+    Tags        []string `json:"tags"`
+    Radius      float64 `json:"radius" validate:"required_without=Polygon,omitempty,min=10,max=5000"`
+    Polygon     []GeoPoint `json:"polygon" validate:"omitempty,min=3,max=500"`
+    Public      bool       `json:"public"`
+    ExpiresAt   *time.Time `json:"expires_at"`
 }
 
 type UpdateIncidentRequest struct {
     Title       *string  `json:"title" validate:"omitempty,min=3,max=255"`
     Description *string  `json:"description" validate:"omitempty,max=1000"`
-    Severity    *string  `json:"severity" validate:"omitempty,oneof=low medium high"`
+    Severity    *string  `json:"severity"`
+    Category    *string  `json:"category"`
+    Tags        *[]string `json:"tags"`
+    // Перемещение и изменение формы зоны; пустой polygon делает зону кругом radius
+    Latitude    *float64 `json:"latitude" validate:"omitempty,latitude"`
+    Longitude   *float64 `json:"longitude" validate:"omitempty,longitude"`
     Radius      *float64 `json:"radius" validate:"omitempty,min=10,max=5000"`
+    Polygon     *[]GeoPoint `json:"polygon" validate:"omitempty,max=500"`
     Active      *bool    `json:"active"`
-}
\ No newline at end of file
+    Public      *bool    `json:"public"`
+    ExpiresAt   *time.Time `json:"expires_at"`
+    // ClearExpiresAt снимает срок действия: "expires_at": null в PATCH
+    ClearExpiresAt bool `json:"-"`
+}
+
+// Validate проверяет ограничения полей, которые не проверяются привязкой запроса
+func (req *CreateIncidentRequest) Validate() error {
+    if title := utf8.RuneCountInString(req.Title); title < 3 || title > 255 {
+        return fmt.Errorf("title must be 3 to 255 characters long")
+    }
+    if utf8.RuneCountInString(req.Description) > 1000 {
+        return fmt.Errorf("description must be at most 1000 characters long")
+    }
+    if !ValidSeverity(req.Severity) {
+        return fmt.Errorf("unknown severity %q", req.Severity)
+    }
+    if !validCoordinates(req.Latitude, req.Longitude) {
+        return fmt.Errorf("invalid coordinates %v, %v", req.Latitude, req.Longitude)
+    }
+
+    if len(req.Polygon) == 0 {
+        if req.Radius < MinIncidentRadius || req.Radius > MaxIncidentRadius || math.IsNaN(req.Radius) {
+            return fmt.Errorf("radius must be %v to %v meters", MinIncidentRadius, MaxIncidentRadius)
+        }
+        return nil
+    }
+    if len(req.Polygon) < 3 || len(req.Polygon) > MaxIncidentPolygonSize {
+        return fmt.Errorf("polygon must have 3 to %d vertices", MaxIncidentPolygonSize)
+    }
+    for _, point := range req.Polygon {
+        if !validCoordinates(point.Latitude, point.Longitude) {
+            return fmt.Errorf("invalid polygon vertex %v, %v", point.Latitude, point.Longitude)
+        }
+    }
+    return nil
+}
+
+// Validate проверяет ограничения переданных полей
+func (req *UpdateIncidentRequest) Validate() error {
+    if req.Title != nil {
+        if title := utf8.RuneCountInString(*req.Title); title < 3 || title > 255 {
+            return fmt.Errorf("title must be 3 to 255 characters long")
+        }
+    }
+    if req.Description != nil && utf8.RuneCountInString(*req.Description) > 1000 {
+        return fmt.Errorf("description must be at most 1000 characters long")
+    }
+    if req.Radius != nil && (*req.Radius < MinIncidentRadius || *req.Radius > MaxIncidentRadius || math.IsNaN(*req.Radius)) {
+        return fmt.Errorf("radius must be %v to %v meters", MinIncidentRadius, MaxIncidentRadius)
+    }
+    if req.Latitude != nil && !validCoordinates(*req.Latitude, 0) {
+        return fmt.Errorf("invalid latitude %v", *req.Latitude)
+    }
+    if req.Longitude != nil && !validCoordinates(0, *req.Longitude) {
+        return fmt.Errorf("invalid longitude %v", *req.Longitude)
+    }
+    if req.Polygon != nil {
+        // пустой контур убирает контур зоны
+        polygon := *req.Polygon
+        if len(polygon) > 0 && len(polygon) < 3 || len(polygon) > MaxIncidentPolygonSize {
+            return fmt.Errorf("polygon must have 3 to %d vertices", MaxIncidentPolygonSize)
+        }
+        for _, point := range polygon {
+            if !validCoordinates(point.Latitude, point.Longitude) {
+                return fmt.Errorf("invalid polygon vertex %v, %v", point.Latitude, point.Longitude)
+            }
+        }
+    }
+    return nil
+}
+
+// Поля инцидента, изменения которых сообщаются в событии incident.updated
+const (
+    FieldTitle       = "title"
+    FieldDescription = "description"
+    FieldSeverity    = "severity"
+    FieldCategory    = "category"
+    FieldTags        = "tags"
+    FieldLocation    = "location" // центр зоны: latitude и longitude
+    FieldRadius      = "radius"
+    FieldPolygon     = "polygon"
+    FieldActive      = "active"
+    FieldPublic      = "public"
+    FieldExpiresAt   = "expires_at"
+)
+
+// ChangedFields возвращает JSON-имена полей, которые отличаются у after от before
+func ChangedFields(before, after *Incident) []string {
+    changed := []string{}
+    if before.Title != after.Title {
+        changed = append(changed, FieldTitle)
+    }
+    if before.Description != after.Description {
+        changed = append(changed, FieldDescription)
+    }
+    if before.Severity != after.Severity {
+        changed = append(changed, FieldSeverity)
+    }
+    if before.Category != after.Category {
+        changed = append(changed, FieldCategory)
+    }
+    if !sameStrings(before.Tags, after.Tags) {
+        changed = append(changed, FieldTags)
+    }
+    if before.Latitude != after.Latitude || before.Longitude != after.Longitude {
+        changed = append(changed, FieldLocation)
+    }
+    if before.Radius != after.Radius {
+        changed = append(changed, FieldRadius)
+    }
+    if !samePolygon(before.Polygon, after.Polygon) {
+        changed = append(changed, FieldPolygon)
+    }
+    if before.Active != after.Active {
+        changed = append(changed, FieldActive)
+    }
+    if before.Public != after.Public {
+        changed = append(changed, FieldPublic)
+    }
+    if !sameTime(before.ExpiresAt, after.ExpiresAt) {
+        changed = append(changed, FieldExpiresAt)
+    }
+    return changed
+}
+
+func samePolygon(a, b []GeoPoint) bool {
+    if len(a) != len(b) {
+        return false
+    }
+    for i := range a {
+        if a[i] != b[i] {
+            return false
+        }
+    }
+    return true
+}
+
+func sameStrings(a, b []string) bool {
+    if len(a) != len(b) {
+        return false
+    }
+    for i := range a {
+        if a[i] != b[i] {
+            return false
+        }
+    }
+    return true
+}
+
+func sameTime(a, b *time.Time) bool {
+    if a == nil || b == nil {
+        return a == b
+    }
+    return a.Equal(*b)
+}
+
+// Expired проверяет, истек ли срок действия инцидента к now
+func (i *Incident) Expired(now time.Time) bool {
+    return i.ExpiresAt != nil && !i.ExpiresAt.After(now)
+}
+
+// Contains проверяет, находится ли точка внутри зоны инцидента
+func (i *Incident) Contains(lat, lng float64) bool {
+    if len(i.Polygon) >= 3 {
+        return geo.PointInPolygon(lat, lng, i.Polygon)
+    }
+    return geo.DistanceKm(lat, lng, i.Latitude, i.Longitude) <= i.Radius/1000.0
+}
+
+// DistanceToZoneKm возвращает расстояние от точки до границы зоны (0, если точка внутри)
+func (i *Incident) DistanceToZoneKm(lat, lng float64) float64 {
+    if len(i.Polygon) >= 3 {
+        return geo.DistanceToPolygonKm(lat, lng, i.Polygon)
+    }
+
+    distance := geo.DistanceKm(lat, lng, i.Latitude, i.Longitude) - i.Radius/1000.0
+    if distance < 0 {
+        return 0
+    }
+    return distance
+}
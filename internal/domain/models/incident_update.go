@@ -0,0 +1,42 @@
+package models
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// MaxUpdateMessageLength - предельная длина сообщения хроники в символах
+const MaxUpdateMessageLength = 2000
+
+// IncidentUpdate - сообщение хроники инцидента о ходе происшествия
+// ("перекрытие снято в одну сторону", "эвакуация отменена"). Публичные
+// сообщения показываются жителям при проверке локации.
+type IncidentUpdate struct {
+    ID         int64     `json:"id" db:"id"`
+    IncidentID int64     `json:"incident_id" db:"incident_id"`
+    Message    string    `json:"message" db:"message"`
+    Public     bool      `json:"public" db:"public"`
+    Author     string    `json:"author,omitempty" db:"author"`
+    CreatedAt  time.Time `json:"created_at" db:"created_at"`
+}
+
+// IncidentWithUpdate - инцидент с последним публичным сообщением хроники; так
+// инциденты видят жители при проверке локации и читатели публичных лент
+type IncidentWithUpdate struct {
+    Incident
+    LatestUpdate *IncidentUpdate `json:"latest_update,omitempty"`
+}
+
+// PostUpdateRequest - новое сообщение хроники; по умолчанию видно только операторам
+type PostUpdateRequest struct {
+    Message string `json:"message" validate:"required,max=2000"`
+    Public  bool   `json:"public"`
+    Author  string `json:"author" validate:"max=255"`
+}
+
+// NormalizeUpdateMessage убирает пробелы по краям; false - сообщение пустое или длиннее MaxUpdateMessageLength
+func NormalizeUpdateMessage(message string) (string, bool) {
+    message = strings.TrimSpace(message)
+    return message, message != "" && utf8.RuneCountInString(message) <= MaxUpdateMessageLength
+}
@@ -11,7 +11,8 @@ type LocationCheck struct {
     Longitude  float64   `json:"longitude" db:"longitude"`
     Timestamp  time.Time `json:"timestamp" db:"timestamp"`
     HasAlert   bool      `json:"has_alert" db:"has_alert"`
-    IncidentID *int64    `json:"incident_id,omitempty" db:"incident_id"` // Изменено на указатель
+    // IncidentIDs - инциденты, в зоны которых попала точка (location_check_incidents)
+    IncidentIDs []int64 `json:"incident_ids,omitempty" db:"-"`
 }
 
 type LocationCheckRequest struct {
@@ -21,22 +22,47 @@ type LocationCheckRequest struct {
 }
 
 type LocationCheckResponse struct {
-    Incidents []Incident `json:"incidents"`
-    HasAlert  bool       `json:"has_alert"`
+    Incidents []IncidentWithUpdate `json:"incidents"`
+    HasAlert  bool                 `json:"has_alert"`
 }
 
 type WebhookPayload struct {
-    EventType string          `json:"event_type"` // "location_alert"
+    EventType string          `json:"event_type"` // "location_alert", "incident.created", ...
+    EventID   string          `json:"event_id,omitempty"` // ID события outbox для дедупликации получателем
     UserID    string          `json:"user_id"`
     Latitude  float64         `json:"latitude"`
     Longitude float64         `json:"longitude"`
     Incidents []IncidentShort `json:"incidents"`
     Timestamp time.Time       `json:"timestamp"`
+    Proactive bool            `json:"proactive,omitempty"` // location_alert по последней известной точке без новой проверки
+
+    Incident      *Incident `json:"incident,omitempty"` // состояние инцидента для событий incident.*
+    ChangedFields []string  `json:"changed_fields,omitempty"` // для incident.updated, incident.resolved и incident.geometry_changed
+    Update        *IncidentUpdate `json:"update,omitempty"` // для incident.update_posted
+    Geometry      *GeometryChange `json:"geometry,omitempty"` // для incident.geometry_changed: прежняя и новая зона
+    BatchID       string    `json:"batch_id,omitempty"` // события одной пакетной операции
+
+    WatchZone    *WatchZone           `json:"watch_zone,omitempty"` // для watch_zone_alert
+    Subscription *WebhookSubscription `json:"subscription,omitempty"` // для событий подписок
 }
 
 type IncidentShort struct {
-    ID       int64   `json:"id"`
-    Title    string  `json:"title"`
-    Severity string  `json:"severity"`
-    Distance float64 `json:"distance"` // расстояние в метрах
+    ID       int64    `json:"id"`
+    Title    string   `json:"title"`
+    Severity string   `json:"severity"`
+    Category string   `json:"category,omitempty"`
+    Tags     []string `json:"tags,omitempty"`
+    Distance float64  `json:"distance"` // расстояние в метрах
+}
+
+// Short возвращает краткое описание инцидента для оповещений; distance в метрах
+func (i *Incident) Short(distance float64) IncidentShort {
+    return IncidentShort{
+        ID:       i.ID,
+        Title:    i.Title,
+        Severity: i.Severity,
+        Category: i.Category,
+        Tags:     i.Tags,
+        Distance: distance,
+    }
 }
\ No newline at end of file
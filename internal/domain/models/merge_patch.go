@@ -0,0 +1,118 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// MergePatchContentType - тип тела PATCH /incidents/{id} (RFC 7396)
+const MergePatchContentType = "application/merge-patch+json"
+
+// readOnlyPatchFields - поля инцидента, которые нельзя изменить через PATCH
+var readOnlyPatchFields = map[string]bool{
+    "id":          true,
+    "user_id":     true,
+    "merged_into": true,
+    "external_id": true,
+    "version":     true,
+    "created_at":  true,
+    "updated_at":  true,
+}
+
+// ParseMergePatch разбирает JSON Merge Patch инцидента в запрос на изменение.
+// Отсутствующее поле не меняется, null удаляет значение: описание становится
+// пустым, теги - пустым списком, срок действия снимается, контур убирается
+// (зона становится кругом radius). Обязательные поля (title, severity, category,
+// latitude, longitude, radius, active, public) удалить нельзя.
+func ParseMergePatch(data []byte) (UpdateIncidentRequest, error) {
+    var req UpdateIncidentRequest
+    var patch map[string]json.RawMessage
+    if err := json.Unmarshal(data, &patch); err != nil || patch == nil {
+        return req, fmt.Errorf("merge patch must be a JSON object")
+    }
+
+    fields := make([]string, 0, len(patch))
+    for field := range patch {
+        fields = append(fields, field)
+    }
+    sort.Strings(fields)
+
+    for _, field := range fields {
+        value := patch[field]
+        if bytes.Equal(bytes.TrimSpace(value), []byte("null")) {
+            switch field {
+            case FieldDescription:
+                empty := ""
+                req.Description = &empty
+            case FieldTags:
+                req.Tags = &[]string{}
+            case FieldExpiresAt:
+                req.ClearExpiresAt = true
+            case FieldPolygon:
+                req.Polygon = &[]GeoPoint{}
+            case FieldTitle, FieldSeverity, FieldCategory, "latitude", "longitude", FieldRadius, FieldActive, FieldPublic:
+                return req, fmt.Errorf("%s is required and cannot be removed", field)
+            default:
+                return req, unpatchableField(field)
+            }
+            continue
+        }
+
+        var target interface{}
+        switch field {
+        case FieldTitle:
+            req.Title = new(string)
+            target = req.Title
+        case FieldDescription:
+            req.Description = new(string)
+            target = req.Description
+        case FieldSeverity:
+            req.Severity = new(string)
+            target = req.Severity
+        case FieldCategory:
+            req.Category = new(string)
+            target = req.Category
+        case FieldTags:
+            req.Tags = &[]string{}
+            target = req.Tags
+        case "latitude":
+            req.Latitude = new(float64)
+            target = req.Latitude
+        case "longitude":
+            req.Longitude = new(float64)
+            target = req.Longitude
+        case FieldRadius:
+            req.Radius = new(float64)
+            target = req.Radius
+        case FieldPolygon:
+            req.Polygon = &[]GeoPoint{}
+            target = req.Polygon
+        case FieldActive:
+            req.Active = new(bool)
+            target = req.Active
+        case FieldPublic:
+            req.Public = new(bool)
+            target = req.Public
+        case FieldExpiresAt:
+            req.ExpiresAt = new(time.Time)
+            target = req.ExpiresAt
+        default:
+            return req, unpatchableField(field)
+        }
+        if err := json.Unmarshal(value, target); err != nil {
+            return req, fmt.Errorf("invalid %s: %w", field, err)
+        }
+    }
+
+    return req, nil
+}
+
+func unpatchableField(field string) error {
+    if readOnlyPatchFields[field] {
+        return fmt.Errorf("%s cannot be changed with a merge patch", field)
+    }
+    return fmt.Errorf("unknown field %q", field)
+}
@@ -0,0 +1,100 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// QuietHours - ежедневный интервал тишины в часовом поясе пользователя ("22:00"-"07:00").
+// Интервал может переходить через полночь; Start == End означает отсутствие тишины.
+type QuietHours struct {
+    Start string `json:"start"`
+    End   string `json:"end"`
+}
+
+// NotificationPreferences - пожелания пользователя к оповещениям, которые
+// учитываются перед постановкой location_alert и watch_zone_alert в очередь
+type NotificationPreferences struct {
+    UserID      string   `json:"user_id" db:"user_id"`
+    MinSeverity string   `json:"min_severity" db:"min_severity"`
+    Categories  []string `json:"categories" db:"categories"` // пустой список - все категории, включая подкатегории
+    TimeZone    string   `json:"time_zone" db:"time_zone"`   // IANA, например Europe/Moscow
+
+    QuietHours *QuietHours `json:"quiet_hours,omitempty" db:"quiet_hours"`
+    // QuietHoursOverride - уровень, начиная с которого оповещения приходят и в тихие часы
+    QuietHoursOverride string `json:"quiet_hours_override" db:"quiet_hours_override"`
+
+    MaxAlertsPerHour int       `json:"max_alerts_per_hour" db:"max_alerts_per_hour"` // 0 - без ограничения
+    UpdatedAt        time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// NotificationPreferencesRequest - замена настроек оповещений пользователя
+type NotificationPreferencesRequest struct {
+    MinSeverity        string      `json:"min_severity" validate:"omitempty,oneof=low medium high"`
+    Categories         []string    `json:"categories" validate:"max=50"`
+    TimeZone           string      `json:"time_zone"`
+    QuietHours         *QuietHours `json:"quiet_hours"`
+    QuietHoursOverride string      `json:"quiet_hours_override" validate:"omitempty,oneof=low medium high"`
+    MaxAlertsPerHour   int         `json:"max_alerts_per_hour" validate:"min=0"`
+}
+
+// DefaultNotificationPreferences - настройки пользователя, который их не задавал:
+// все оповещения без ограничений
+func DefaultNotificationPreferences(userID string) *NotificationPreferences {
+    return &NotificationPreferences{
+        UserID:             userID,
+        MinSeverity:        "low",
+        Categories:         []string{},
+        TimeZone:           "UTC",
+        QuietHoursOverride: "high",
+    }
+}
+
+// ParseClock разбирает время суток "HH:MM" в минуты от полуночи
+func ParseClock(value string) (int, error) {
+    t, err := time.Parse("15:04", value)
+    if err != nil {
+        return 0, fmt.Errorf("invalid time %q, expected HH:MM", value)
+    }
+    return t.Hour()*60 + t.Minute(), nil
+}
+
+// InQuietHours проверяет, попадает ли момент now в тихие часы пользователя
+func (p *NotificationPreferences) InQuietHours(now time.Time) bool {
+    if p.QuietHours == nil {
+        return false
+    }
+    start, err := ParseClock(p.QuietHours.Start)
+    if err != nil {
+        return false
+    }
+    end, err := ParseClock(p.QuietHours.End)
+    if err != nil {
+        return false
+    }
+
+    if location, err := time.LoadLocation(p.TimeZone); err == nil {
+        now = now.In(location)
+    }
+    minute := now.Hour()*60 + now.Minute()
+
+    if start <= end {
+        return minute >= start && minute < end
+    }
+    // Интервал через полночь
+    return minute >= start || minute < end
+}
+
+// WantsCategory проверяет фильтр по категориям с учетом подкатегорий;
+// инцидент без категории проходит всегда
+func (p *NotificationPreferences) WantsCategory(category string) bool {
+    return category == "" || CategoryMatchesAny(category, p.Categories)
+}
+
+// Wants проверяет, хочет ли пользователь получить оповещение об инциденте в момент now
+func (p *NotificationPreferences) Wants(severity, category string, now time.Time) bool {
+    if !SeverityAtLeast(severity, p.MinSeverity) || !p.WantsCategory(category) {
+        return false
+    }
+    return !p.InQuietHours(now) || SeverityAtLeast(severity, p.QuietHoursOverride)
+}
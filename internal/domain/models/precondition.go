@@ -0,0 +1,56 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+)
+
+// IncidentETag возвращает сильный ETag версии инцидента
+func IncidentETag(version int64) string {
+    return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// IfMatch - условие заголовка If-Match запроса на изменение инцидента
+type IfMatch struct {
+    Present  bool    // заголовок передан; без него изменение не проверяет версию
+    Any      bool    // If-Match: * - подходит любая версия существующего инцидента
+    Versions []int64 // версии из перечисленных ETag
+}
+
+// ParseIfMatch разбирает заголовок If-Match. If-Match сравнивает ETag строго, поэтому
+// слабые ETag (W/"...") и ETag не из версий инцидента не подходят ни к одной версии.
+func ParseIfMatch(header string) IfMatch {
+    header = strings.TrimSpace(header)
+    if header == "" {
+        return IfMatch{}
+    }
+
+    condition := IfMatch{Present: true}
+    for _, tag := range strings.Split(header, ",") {
+        tag = strings.TrimSpace(tag)
+        if tag == "*" {
+            condition.Any = true
+            continue
+        }
+        if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+            continue
+        }
+        if version, err := strconv.ParseInt(tag[1:len(tag)-1], 10, 64); err == nil && version > 0 {
+            condition.Versions = append(condition.Versions, version)
+        }
+    }
+    return condition
+}
+
+// Matches сообщает, выполнено ли условие для существующего инцидента версии version
+func (m IfMatch) Matches(version int64) bool {
+    if !m.Present || m.Any {
+        return true
+    }
+    for _, expected := range m.Versions {
+        if expected == version {
+            return true
+        }
+    }
+    return false
+}
@@ -0,0 +1,150 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Статусы сообщения о происшествии в очереди модерации
+const (
+    ReportPending   = "pending"
+    ReportApproving = "approving" // оператор создает по сообщению инцидент
+    ReportApproved  = "approved"  // по сообщению создан инцидент
+    ReportMerged   = "merged"   // сообщение присоединено к существующему инциденту
+    ReportRejected = "rejected"
+)
+
+// IncidentReport - сообщение жителя о предполагаемом происшествии. Инцидентом
+// оно становится только после проверки оператором.
+type IncidentReport struct {
+    ID          int64      `json:"id" db:"id"`
+    UserID      string     `json:"user_id" db:"user_id"`
+    ReporterIP  string     `json:"reporter_ip" db:"reporter_ip"`
+    Latitude    float64    `json:"latitude" db:"latitude"`
+    Longitude   float64    `json:"longitude" db:"longitude"`
+    Description string     `json:"description" db:"description"`
+    Category    string     `json:"category" db:"category"`
+    Severity    string     `json:"severity,omitempty" db:"severity"` // оценка заявителя, может отсутствовать
+    Status      string     `json:"status" db:"status"`
+    AbuseScore  float64    `json:"abuse_score" db:"abuse_score"` // оценка заявителя в момент подачи, от 0 до 1
+    IncidentID  *int64     `json:"incident_id,omitempty" db:"incident_id"` // созданный или дополненный инцидент
+    Abusive     bool       `json:"abusive" db:"abusive"` // отклонено как ложное или спам
+    Note        string     `json:"moderation_note,omitempty" db:"moderation_note"`
+    ModeratedAt *time.Time `json:"moderated_at,omitempty" db:"moderated_at"`
+    CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+}
+
+// ReportRequest - сообщение о происшествии от жителя
+type ReportRequest struct {
+    UserID      string  `json:"user_id" validate:"required"`
+    Latitude    float64 `json:"latitude" validate:"required,latitude"`
+    Longitude   float64 `json:"longitude" validate:"required,longitude"`
+    Description string  `json:"description" validate:"required,min=10,max=1000"`
+    Category    string  `json:"category"` // пустая - DefaultCategory
+    Severity    string  `json:"severity"`
+}
+
+// ReportReceipt - ответ заявителю: модерация и оценка заявителя остаются у операторов
+type ReportReceipt struct {
+    ID        int64     `json:"id"`
+    Status    string    `json:"status"`
+    CreatedAt time.Time `json:"created_at"`
+}
+
+// ApproveReportRequest - создание инцидента по сообщению; незаданные поля
+// берутся из сообщения
+type ApproveReportRequest struct {
+    Title       string     `json:"title" validate:"omitempty,min=3,max=255"` // пустой - начало описания
+    Description *string    `json:"description" validate:"omitempty,max=1000"`
+    Severity    string     `json:"severity"`
+    Category    string     `json:"category"`
+    Tags        []string   `json:"tags"`
+    Radius      float64    `json:"radius" validate:"omitempty,min=10,max=5000"` // 0 - DefaultReportRadius
+    Polygon     []GeoPoint `json:"polygon" validate:"omitempty,min=3,max=500"`
+    Public      bool       `json:"public"`
+    ExpiresAt   *time.Time `json:"expires_at"`
+    Note        string     `json:"moderation_note" validate:"max=1000"`
+}
+
+// DefaultReportRadius - радиус зоны инцидента по сообщению, если оператор его не указал, м
+const DefaultReportRadius = 100.0
+
+// MergeReportRequest - присоединение сообщения к уже известному инциденту
+type MergeReportRequest struct {
+    IncidentID int64  `json:"incident_id" validate:"required"`
+    Note       string `json:"moderation_note" validate:"max=1000"`
+}
+
+// RejectReportRequest - отклонение сообщения; abusive ухудшает оценку заявителя
+type RejectReportRequest struct {
+    Note    string `json:"moderation_note" validate:"max=1000"`
+    Abusive bool   `json:"abusive"`
+}
+
+// ReportFilter - выборка очереди модерации; пустые поля не ограничивают выборку
+type ReportFilter struct {
+    Status     string
+    UserID     string
+    IncidentID *int64
+    Limit      int
+    Offset     int
+}
+
+// ReportRateLimit - сколько сообщений принимается за окно с Since от одного
+// заявителя и с одного адреса; 0 - без ограничения
+type ReportRateLimit struct {
+    Since       time.Time
+    PerReporter int
+    PerAddress  int
+}
+
+// ReporterStats - итоги модерации сообщений заявителя (или адреса) за окно оценки
+type ReporterStats struct {
+    UserID     string  `json:"user_id,omitempty"`
+    Pending    int     `json:"pending"`
+    Approved   int     `json:"approved"`
+    Merged     int     `json:"merged"`
+    Rejected   int     `json:"rejected"` // без отклоненных как ложные
+    Abusive    int     `json:"abusive"`
+    AbuseScore float64 `json:"abuse_score"`
+}
+
+// Add учитывает n сообщений со статусом status
+func (s *ReporterStats) Add(status string, abusive bool, n int) {
+    switch {
+    case status == ReportPending || status == ReportApproving:
+        s.Pending += n
+    case status == ReportApproved:
+        s.Approved += n
+    case status == ReportMerged:
+        s.Merged += n
+    case abusive:
+        s.Abusive += n
+    default:
+        s.Rejected += n
+    }
+}
+
+// Score вычисляет оценку злоупотреблений от 0 до 1: долю отклоненных сообщений,
+// где ложные и спам весят вдвое. Два условно принятых сообщения в знаменателе
+// не дают первому же отказу сделать оценку нового заявителя высокой.
+func (s ReporterStats) Score() float64 {
+    bad := float64(s.Rejected) + 2*float64(s.Abusive)
+    return bad / (bad + float64(s.Approved+s.Merged) + 2)
+}
+
+// ReportTitle возвращает заголовок инцидента по описанию: первую строку,
+// сокращенную до maxRunes символов
+func ReportTitle(description string, maxRunes int) string {
+    title := []rune(strings.TrimSpace(description))
+    for i, r := range title {
+        if r == '\n' || r == '\r' {
+            title = title[:i]
+            break
+        }
+    }
+    if len(title) > maxRunes {
+        title = append(title[:maxRunes-1], '…')
+    }
+    return strings.TrimSpace(string(title))
+}
@@ -0,0 +1,254 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultCategory - категория инцидентов, созданных без указания категории
+const DefaultCategory = "other"
+
+// Category - тип инцидента. Подкатегория получает код родителя с суффиксом
+// через точку (fire.wildfire), поэтому фильтр по категории включает подкатегории.
+type Category struct {
+    Code        string    `json:"code" db:"code"`
+    Parent      string    `json:"parent,omitempty" db:"parent_code"`
+    Name        string    `json:"name" db:"name"`
+    Description string    `json:"description,omitempty" db:"description"`
+    CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// DefaultCategories - исходный справочник, который заполняют миграции
+func DefaultCategories() []*Category {
+    return []*Category{
+        {Code: "chemical", Name: "Химическая опасность"},
+        {Code: "chemical.gas_leak", Parent: "chemical", Name: "Утечка газа"},
+        {Code: "crime", Name: "Преступление"},
+        {Code: "fire", Name: "Пожар"},
+        {Code: "fire.building", Parent: "fire", Name: "Пожар в здании"},
+        {Code: "fire.wildfire", Parent: "fire", Name: "Природный пожар"},
+        {Code: "flood", Name: "Наводнение"},
+        {Code: "other", Name: "Прочее"},
+        {Code: "road_closure", Name: "Перекрытие дороги"},
+        {Code: "road_closure.accident", Parent: "road_closure", Name: "ДТП"},
+        {Code: "road_closure.roadworks", Parent: "road_closure", Name: "Дорожные работы"},
+        {Code: "weather", Name: "Опасные погодные явления"},
+    }
+}
+
+// CategoryRequest - создание категории или подкатегории
+type CategoryRequest struct {
+    Code        string `json:"code" validate:"required"`
+    Name        string `json:"name" validate:"required,max=100"`
+    Description string `json:"description" validate:"max=1000"`
+}
+
+var categoryCodePattern = regexp.MustCompile(`^[a-z0-9_]+(\.[a-z0-9_]+)*$`)
+
+// ValidCategoryCode проверяет формат кода категории
+func ValidCategoryCode(code string) bool {
+    return len(code) <= 100 && categoryCodePattern.MatchString(code)
+}
+
+// ParentCategory возвращает код родительской категории или "" для корневой
+func ParentCategory(code string) string {
+    if i := strings.LastIndex(code, "."); i >= 0 {
+        return code[:i]
+    }
+    return ""
+}
+
+// CategoryMatches проверяет, относится ли категория к filter или к ее подкатегориям
+func CategoryMatches(category, filter string) bool {
+    return category == filter || strings.HasPrefix(category, filter+".")
+}
+
+// CategoryMatchesAny - CategoryMatches хотя бы для одного фильтра; пустой список пропускает все
+func CategoryMatchesAny(category string, filters []string) bool {
+    if len(filters) == 0 {
+        return true
+    }
+    for _, filter := range filters {
+        if CategoryMatches(category, filter) {
+            return true
+        }
+    }
+    return false
+}
+
+const (
+    maxTags      = 20
+    maxTagLength = 50
+)
+
+// NormalizeTags приводит теги к нижнему регистру и убирает пустые и повторы
+func NormalizeTags(tags []string) ([]string, error) {
+    normalized := []string{}
+    seen := make(map[string]bool)
+    for _, tag := range tags {
+        tag = strings.ToLower(strings.TrimSpace(tag))
+        if tag == "" || seen[tag] {
+            continue
+        }
+        if len([]rune(tag)) > maxTagLength {
+            return nil, fmt.Errorf("tag %q is longer than %d characters", tag, maxTagLength)
+        }
+        seen[tag] = true
+        normalized = append(normalized, tag)
+    }
+    if len(normalized) > maxTags {
+        return nil, fmt.Errorf("at most %d tags", maxTags)
+    }
+    return normalized, nil
+}
+
+// HasAnyTag проверяет пересечение тегов с фильтром; пустой фильтр пропускает все
+func HasAnyTag(tags, filter []string) bool {
+    if len(filter) == 0 {
+        return true
+    }
+    for _, tag := range tags {
+        for _, wanted := range filter {
+            if tag == wanted {
+                return true
+            }
+        }
+    }
+    return false
+}
+
+// SeverityLevel - уровень шкалы опасности: чем больше Level, тем опаснее
+type SeverityLevel struct {
+    Code  string `json:"code" db:"code"`
+    Name  string `json:"name" db:"name"`
+    Level int    `json:"level" db:"level"`
+    Color string `json:"color" db:"color"` // #RRGGBB
+}
+
+// SeverityLevelRequest - создание или замена уровня опасности
+type SeverityLevelRequest struct {
+    Name  string `json:"name" validate:"required,max=100"`
+    Level int    `json:"level" validate:"required,min=1"`
+    Color string `json:"color" validate:"required,hexcolor"`
+}
+
+var (
+    severityCodePattern  = regexp.MustCompile(`^[a-z0-9_]{1,50}$`)
+    severityColorPattern = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+)
+
+// ValidSeverityCode проверяет формат кода уровня опасности
+func ValidSeverityCode(code string) bool {
+    return severityCodePattern.MatchString(code)
+}
+
+// ValidSeverityColor проверяет формат цвета #RRGGBB
+func ValidSeverityColor(color string) bool {
+    return severityColorPattern.MatchString(color)
+}
+
+// DefaultSeverityScale - исходная шкала, которую заполняют миграции
+func DefaultSeverityScale() []*SeverityLevel {
+    return []*SeverityLevel{
+        {Code: "low", Name: "Низкий", Level: 10, Color: "#2E7D32"},
+        {Code: "medium", Name: "Средний", Level: 20, Color: "#F9A825"},
+        {Code: "high", Name: "Высокий", Level: 30, Color: "#C62828"},
+    }
+}
+
+// severityScale - действующая шкала опасности процесса; ее загружает из хранилища
+// TaxonomyService и обновляет при изменениях
+var severityScale = struct {
+    sync.RWMutex
+    levels map[string]int
+}{levels: scaleLevels(DefaultSeverityScale())}
+
+func scaleLevels(levels []*SeverityLevel) map[string]int {
+    m := make(map[string]int, len(levels))
+    for _, level := range levels {
+        m[level.Code] = level.Level
+    }
+    return m
+}
+
+// SetSeverityScale заменяет действующую шкалу опасности
+func SetSeverityScale(levels []*SeverityLevel) {
+    m := scaleLevels(levels)
+
+    severityScale.Lock()
+    severityScale.levels = m
+    severityScale.Unlock()
+}
+
+// SeverityLevelOf возвращает числовой уровень; 0 - уровень неизвестен
+func SeverityLevelOf(severity string) int {
+    severityScale.RLock()
+    defer severityScale.RUnlock()
+    return severityScale.levels[severity]
+}
+
+// SeveritiesAtLeast возвращает коды уровней не ниже min по возрастанию
+func SeveritiesAtLeast(min string) []string {
+    severityScale.RLock()
+    defer severityScale.RUnlock()
+
+    threshold := severityScale.levels[min]
+    var codes []string
+    for code, level := range severityScale.levels {
+        if level >= threshold {
+            codes = append(codes, code)
+        }
+    }
+    sort.Slice(codes, func(i, j int) bool {
+        return severityScale.levels[codes[i]] < severityScale.levels[codes[j]]
+    })
+    return codes
+}
+
+// ValidSeverity проверяет, что уровень опасности есть в шкале
+func ValidSeverity(severity string) bool {
+    return SeverityLevelOf(severity) > 0
+}
+
+// SeverityAtLeast проверяет, что уровень severity не ниже min
+func SeverityAtLeast(severity, min string) bool {
+    return SeverityLevelOf(severity) >= SeverityLevelOf(min)
+}
+
+// IncidentFilter - условия выборки инцидентов; пустые поля не ограничивают выборку
+type IncidentFilter struct {
+    ActiveOnly bool
+    Categories []string // включая подкатегории
+    Tags       []string // хотя бы один из тегов
+    Severities []string
+    Limit      int
+    Offset     int
+}
+
+// IsEmpty проверяет, что фильтр не ограничивает выборку (кроме пагинации)
+func (f IncidentFilter) IsEmpty() bool {
+    return !f.ActiveOnly && len(f.Categories) == 0 && len(f.Tags) == 0 && len(f.Severities) == 0
+}
+
+// Matches проверяет инцидент по условиям фильтра (кроме пагинации)
+func (f IncidentFilter) Matches(incident *Incident) bool {
+    if f.ActiveOnly && !incident.Active {
+        return false
+    }
+    if !CategoryMatchesAny(incident.Category, f.Categories) || !HasAnyTag(incident.Tags, f.Tags) {
+        return false
+    }
+    if len(f.Severities) == 0 {
+        return true
+    }
+    for _, severity := range f.Severities {
+        if incident.Severity == severity {
+            return true
+        }
+    }
+    return false
+}
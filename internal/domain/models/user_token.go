@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// UserToken - токен пользователя для его зон наблюдения и настроек оповещений.
+// Выдается оператором (или бэкендом приложения с тем же ключом USER_TOKEN_SECRET)
+// и передается в заголовке Authorization: Bearer.
+type UserToken struct {
+    UserID    string    `json:"user_id"`
+    Token     string    `json:"token"`
+    ExpiresAt time.Time `json:"expires_at"`
+}
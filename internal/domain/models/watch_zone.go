@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+
+	"incident-system/pkg/geo"
+)
+
+// WatchZone - место, об инцидентах рядом с которым пользователь хочет знать,
+// даже находясь в другом месте (дом, школа, работа)
+type WatchZone struct {
+    ID          int64      `json:"id" db:"id"`
+    UserID      string     `json:"user_id" db:"user_id"`
+    Name        string     `json:"name" db:"name"`
+    Latitude    float64    `json:"latitude" db:"latitude"`
+    Longitude   float64    `json:"longitude" db:"longitude"`
+    Radius      float64    `json:"radius" db:"radius"` // в метрах; для многоугольника - радиус описанной окружности
+    Polygon     []GeoPoint `json:"polygon,omitempty" db:"polygon"`
+    MinSeverity string     `json:"min_severity" db:"min_severity"` // минимальный уровень опасности инцидента
+    CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+    UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// WatchZoneRequest - создание или замена зоны наблюдения
+type WatchZoneRequest struct {
+    Name        string     `json:"name" validate:"required,max=100"`
+    Latitude    float64    `json:"latitude" validate:"required,latitude"`
+    Longitude   float64    `json:"longitude" validate:"required,longitude"`
+    Radius      float64    `json:"radius" validate:"required_without=Polygon,omitempty,min=10,max=50000"`
+    Polygon     []GeoPoint `json:"polygon" validate:"omitempty,min=3,max=500"`
+    MinSeverity string     `json:"min_severity" validate:"omitempty,oneof=low medium high"`
+}
+
+// Zone возвращает геометрию зоны наблюдения
+func (w *WatchZone) Zone() geo.Zone {
+    return geo.Zone{
+        Center:   geo.Point{Latitude: w.Latitude, Longitude: w.Longitude},
+        RadiusKm: w.Radius / 1000.0,
+        Polygon:  w.Polygon,
+    }
+}
+
+// Zone возвращает геометрию зоны инцидента
+func (i *Incident) Zone() geo.Zone {
+    return geo.Zone{
+        Center:   geo.Point{Latitude: i.Latitude, Longitude: i.Longitude},
+        RadiusKm: i.Radius / 1000.0,
+        Polygon:  i.Polygon,
+    }
+}
+
+// Matches проверяет, нужно ли оповестить владельца зоны об инциденте
+func (w *WatchZone) Matches(incident *Incident) bool {
+    return SeverityAtLeast(incident.Severity, w.MinSeverity) && geo.ZonesIntersect(w.Zone(), incident.Zone())
+}
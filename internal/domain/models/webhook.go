@@ -0,0 +1,175 @@
+package models
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+)
+
+// WebhookTask - доставка одного вебхука одному получателю. Повторы хранятся
+// в отложенной очереди до наступления DueAt.
+type WebhookTask struct {
+    ID             string         `json:"id"`
+    SubscriptionID int64          `json:"subscription_id"`
+    Destination    string         `json:"destination"` // URL получателя
+    ReplayOf       string         `json:"replay_of,omitempty"` // ID повторяемой доставки
+    Format         string         `json:"format,omitempty"` // формат тела; пустой - WebhookFormatLegacy
+    Payload        WebhookPayload `json:"payload"`
+    Attempt        int            `json:"attempt"` // число выполненных попыток
+    LastError      string         `json:"last_error,omitempty"`
+    DueAt          time.Time      `json:"due_at"`
+    CreatedAt      time.Time      `json:"created_at"`
+}
+
+// NewWebhookTask создает задачу доставки со случайным ID
+func NewWebhookTask(subscriptionID int64, destination string, payload WebhookPayload) WebhookTask {
+    now := time.Now()
+    return WebhookTask{
+        ID:             newTaskID(),
+        SubscriptionID: subscriptionID,
+        Destination:    destination,
+        Payload:        payload,
+        DueAt:          now,
+        CreatedAt:      now,
+    }
+}
+
+// DefaultSubscriptionID - получатель из WEBHOOK_URL; в хранилище подписок его нет
+const DefaultSubscriptionID int64 = 0
+
+// EventSubscriptionDisabled - подписка отключена из-за непрерывных сбоев доставки
+const EventSubscriptionDisabled = "webhook.subscription_disabled"
+
+// WebhookSubscription - получатель вебхуков
+type WebhookSubscription struct {
+    ID          int64     `json:"id"`
+    URL         string    `json:"url"`
+    Description string    `json:"description,omitempty"`
+    EventTypes  []string  `json:"event_types"` // пустой список - все события
+    // Фильтры по инцидентам события; пустые не ограничивают
+    Categories  []string  `json:"categories"` // включая подкатегории
+    Tags        []string  `json:"tags"`       // хотя бы один из тегов
+    MinSeverity string    `json:"min_severity,omitempty"`
+    NotifyURL   string    `json:"notify_url,omitempty"` // куда сообщить владельцу об отключении
+    Format      string    `json:"format"`
+    Active      bool      `json:"active"`
+    CreatedAt   time.Time `json:"created_at"`
+    UpdatedAt   time.Time `json:"updated_at"`
+
+    FailingSince   *time.Time `json:"failing_since,omitempty"` // начало текущей серии сбоев
+    DisabledAt     *time.Time `json:"disabled_at,omitempty"`
+    DisabledReason string     `json:"disabled_reason,omitempty"`
+}
+
+// Enable снова включает подписку и сбрасывает историю сбоев
+func (s *WebhookSubscription) Enable(now time.Time) {
+    s.Active = true
+    s.FailingSince = nil
+    s.DisabledAt = nil
+    s.DisabledReason = ""
+    s.UpdatedAt = now
+}
+
+// Accepts проверяет, подписан ли получатель на событие
+func (s *WebhookSubscription) Accepts(eventType string) bool {
+    if len(s.EventTypes) == 0 {
+        return true
+    }
+    for _, accepted := range s.EventTypes {
+        if accepted == eventType {
+            return true
+        }
+    }
+    return false
+}
+
+// Wants проверяет, нужно ли отправить событие получателю: тип события и хотя бы
+// один инцидент события должны подходить под фильтры подписки. События без
+// инцидентов (например, об отключении подписки) фильтрами не ограничиваются.
+func (s *WebhookSubscription) Wants(payload WebhookPayload) bool {
+    if !s.Accepts(payload.EventType) {
+        return false
+    }
+    if len(s.Categories) == 0 && len(s.Tags) == 0 && s.MinSeverity == "" {
+        return true
+    }
+
+    incidents := payload.Incidents
+    if payload.Incident != nil {
+        incidents = []IncidentShort{payload.Incident.Short(0)}
+    }
+    if len(incidents) == 0 {
+        return true
+    }
+    for _, incident := range incidents {
+        if CategoryMatchesAny(incident.Category, s.Categories) && HasAnyTag(incident.Tags, s.Tags) &&
+            (s.MinSeverity == "" || SeverityAtLeast(incident.Severity, s.MinSeverity)) {
+            return true
+        }
+    }
+    return false
+}
+
+type CreateSubscriptionRequest struct {
+    URL         string   `json:"url" validate:"required,url"`
+    Description string   `json:"description" validate:"max=1000"`
+    EventTypes  []string `json:"event_types"`
+    Categories  []string `json:"categories"`
+    Tags        []string `json:"tags"`
+    MinSeverity string   `json:"min_severity"`
+    NotifyURL   string   `json:"notify_url"`
+    Format      string   `json:"format" validate:"omitempty,oneof=legacy cloudevents-structured cloudevents-binary"`
+}
+
+// WebhookDelivery - запись об одной попытке доставки
+type WebhookDelivery struct {
+    ID             int64          `json:"id"`
+    DeliveryID     string         `json:"delivery_id"` // общий для всех попыток одной доставки
+    SubscriptionID int64          `json:"subscription_id"`
+    EventType      string         `json:"event_type"`
+    EventID        string         `json:"event_id,omitempty"`
+    Destination    string         `json:"destination"`
+    Attempt        int            `json:"attempt"`
+    RequestHash    string         `json:"request_hash"` // SHA-256 тела запроса
+    StatusCode     int            `json:"status_code,omitempty"` // 0 - ответа не было
+    LatencyMs      int64          `json:"latency_ms"`
+    Success        bool           `json:"success"`
+    Error          string         `json:"error,omitempty"`
+    ReplayOf       string         `json:"replay_of,omitempty"`
+    Payload        WebhookPayload `json:"payload"`
+    CreatedAt      time.Time      `json:"created_at"`
+}
+
+// DeliveryFilter - условия выборки журнала доставок; пустые поля не ограничивают выборку
+type DeliveryFilter struct {
+    SubscriptionID *int64
+    DeliveryID     string
+    EventType      string
+    Success        *bool
+    From           time.Time
+    To             time.Time
+    Limit          int
+    Offset         int
+}
+
+// WebhookResponse - результат одной попытки доставки
+type WebhookResponse struct {
+    StatusCode int
+    RetryAfter time.Duration // из заголовка Retry-After; 0, если его нет
+    Latency    time.Duration
+}
+
+// NewEventID создает ID события: он общий для всех получателей и попыток
+// и позволяет получателю отбросить дубликаты
+func NewEventID() string {
+    return newTaskID()
+}
+
+func newTaskID() string {
+    buf := make([]byte, 16)
+    if _, err := rand.Read(buf); err != nil {
+        // crypto/rand не возвращает ошибок на поддерживаемых платформах
+        panic(err)
+    }
+    return hex.EncodeToString(buf)
+}
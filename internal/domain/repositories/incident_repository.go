@@ -2,32 +2,282 @@ package repositories
 
 import (
 	"context"
+	"errors"
+	"io"
+	"time"
+
 	"incident-system/internal/domain/models"
 )
 
 type IncidentRepository interface {
-    // CRUD операции
+    // CRUD операции. Create, Update и Delete в той же транзакции
+    // записывают событие изменения в outbox (см. OutboxRepository).
+    // Create возвращает ErrExternalIDExists, если инцидент с тем же external_id уже есть.
     Create(ctx context.Context, incident *models.Incident) error
     FindByID(ctx context.Context, id int64) (*models.Incident, error)
-    FindAll(ctx context.Context, limit, offset int, activeOnly bool) ([]*models.Incident, error)
+    // FindByExternalID возвращает nil, если инцидент с таким ID во внешней системе не импортировался
+    FindByExternalID(ctx context.Context, externalID string) (*models.Incident, error)
+    // FindAll возвращает инциденты по фильтру, от новых к старым
+    FindAll(ctx context.Context, filter models.IncidentFilter) ([]*models.Incident, error)
+    // Update сохраняет все изменяемые поля инцидента, включая зону, если в хранилище
+    // та же версия, что в incident.Version, и увеличивает ее; иначе ErrVersionConflict.
+    // Если зона изменилась, прежняя сохраняется в истории и записывается событие
+    // incident.geometry_changed (так же в ApplyBatch и Merge).
     Update(ctx context.Context, incident *models.Incident) error
-    Delete(ctx context.Context, id int64) error
+    // Delete деактивирует инцидент; version > 0 - только если у него эта версия,
+    // иначе ErrVersionConflict
+    Delete(ctx context.Context, id int64, version int64) error
+    // ApplyBatch применяет изменения одной транзакцией: create, update и delete
+    // действуют как Create, Update и Delete с версией incident.Version, а их события
+    // получают batchID
+    ApplyBatch(ctx context.Context, batchID string, changes []models.IncidentChange) error
+    // ExpireDue деактивирует активные инциденты с expires_at <= now, записывая
+    // для каждого событие incident.expired, и возвращает их
+    ExpireDue(ctx context.Context, now time.Time) ([]*models.Incident, error)
+    // Merge присоединяет дубликат source к инциденту target: сохраняет объединенное
+    // состояние target (зона, уровень, теги, активность, срок), деактивирует source
+    // со ссылкой merged_into и переносит на target проверки локаций, оповещения,
+    // сообщения жителей, вложения и хронику. Записывает события для target
+    // (NewIncidentUpdateEvent) и incident.merged для source. false - один
+    // из инцидентов уже присоединен к другому или его нет.
+    Merge(ctx context.Context, source, target *models.Incident) (bool, error)
+
+    // FindGeometryHistory возвращает прежние зоны инцидента от последней замененной к первой
+    FindGeometryHistory(ctx context.Context, incidentID int64) ([]*models.GeometryHistoryEntry, error)
+
+    // Хроника инцидента
+    // PostUpdate добавляет сообщение в хронику и в той же транзакции записывает
+    // событие incident.update_posted; false - инцидента нет или он присоединен к другому
+    PostUpdate(ctx context.Context, update *models.IncidentUpdate) (bool, error)
+    // FindUpdates возвращает хронику инцидента от новых сообщений к старым;
+    // publicOnly - только публичные
+    FindUpdates(ctx context.Context, incidentID int64, publicOnly bool) ([]*models.IncidentUpdate, error)
+    // LatestPublicUpdates возвращает последнее публичное сообщение для каждого из
+    // инцидентов, у которых оно есть
+    LatestPublicUpdates(ctx context.Context, incidentIDs []int64) (map[int64]*models.IncidentUpdate, error)
     
     // Специфичные операции
+    // FindNearLocation возвращает активные инциденты, зона которых находится не дальше radiusKm от точки
     FindNearLocation(ctx context.Context, lat, lng float64, radiusKm float64) ([]*models.Incident, error)
+    // FindContainingLocation возвращает активные инциденты, зона которых содержит точку
+    FindContainingLocation(ctx context.Context, lat, lng float64) ([]*models.Incident, error)
+    // SaveLocationCheck записывает проверку одной строкой, а ее инциденты - связями с ней
     SaveLocationCheck(ctx context.Context, check *models.LocationCheck) error
-    GetStats(ctx context.Context, minutes int) ([]*models.IncidentStats, error)
+    // FindLatestLocations возвращает последнюю проверку каждого пользователя не старше since,
+    // если ее точка попадает в прямоугольник, описанный вокруг окружности radiusKm;
+    // IncidentIDs не заполняются
+    FindLatestLocations(ctx context.Context, since time.Time, lat, lng, radiusKm float64) ([]*models.LocationCheck, error)
+    // MarkAlerted запоминает, что пользователи получили оповещение об инциденте, и
+    // возвращает тех, кто его еще не получал
+    MarkAlerted(ctx context.Context, incidentID int64, userIDs []string, at time.Time) ([]string, error)
+    // UnmarkAlerted снимает отметку MarkAlerted с пользователей, оповещение которым
+    // не было поставлено в очередь
+    UnmarkAlerted(ctx context.Context, incidentID int64, userIDs []string) error
+    // GetStats считает пользователей, проверивших локацию за minutes минут, по инцидентам;
+    // с непустым фильтром учитываются только проверки с подходящим инцидентом
+    GetStats(ctx context.Context, minutes int, filter models.IncidentFilter) ([]*models.IncidentStats, error)
     GetActiveIncidents(ctx context.Context) ([]*models.Incident, error)
-    CountAll(ctx context.Context, activeOnly bool) (int, error)
+    CountAll(ctx context.Context, filter models.IncidentFilter) (int, error)
 }
 
+// CacheRepository хранит снимок активных инцидентов с версией. Каждое изменение
+// инцидентов увеличивает версию, и снимок более старой версии не может
+// перезаписать более новый.
 type CacheRepository interface {
-    GetActiveIncidents(ctx context.Context) ([]*models.Incident, error)
-    SetActiveIncidents(ctx context.Context, incidents []*models.Incident) error
+    // GetActiveIncidents возвращает снимок (nil, если его нет); устаревший снимок
+    // возвращается с флагом Stale и может отдаваться, пока идет обновление
+    GetActiveIncidents(ctx context.Context) (*models.ActiveIncidentsSnapshot, error)
+    // SetActiveIncidents сохраняет снимок, если сохраненный снимок не новее; возвращает, сохранен ли он
+    SetActiveIncidents(ctx context.Context, snapshot *models.ActiveIncidentsSnapshot) (bool, error)
+    // ActiveIncidentsVersion возвращает текущую версию набора активных инцидентов
+    ActiveIncidentsVersion(ctx context.Context) (int64, error)
+    // InvalidateActiveIncidents увеличивает версию, делая сохраненный снимок устаревшим
     InvalidateActiveIncidents(ctx context.Context) error
+    // AcquireRefreshLock захватывает блокировку на обновление снимка между экземплярами
+    AcquireRefreshLock(ctx context.Context, ttl time.Duration) (token string, acquired bool, err error)
+    ReleaseRefreshLock(ctx context.Context, token string) error
 }
 
+// CacheMetricsProvider реализуют кеши с локальным снимком в памяти процесса
+type CacheMetricsProvider interface {
+    CacheMetrics() models.CacheMetrics
+}
+
+// QueueRepository - очередь вебхуков для рассылки и отложенная очередь повторов
+// (упорядочена по времени, когда задачу пора выполнить)
 type QueueRepository interface {
     EnqueueWebhook(ctx context.Context, payload models.WebhookPayload) error
     DequeueWebhook(ctx context.Context) (*models.WebhookPayload, error)
-}
\ No newline at end of file
+    // ScheduleWebhookTask откладывает задачу доставки до task.DueAt
+    ScheduleWebhookTask(ctx context.Context, task models.WebhookTask) error
+    // DueWebhookTasks забирает до limit задач, срок которых наступил к now
+    DueWebhookTasks(ctx context.Context, now time.Time, limit int) ([]models.WebhookTask, error)
+}
+
+// WebhookSubscriptionRepository - получатели вебхуков
+type WebhookSubscriptionRepository interface {
+    Create(ctx context.Context, subscription *models.WebhookSubscription) error
+    // FindByID возвращает nil, если подписки нет
+    FindByID(ctx context.Context, id int64) (*models.WebhookSubscription, error)
+    FindAll(ctx context.Context) ([]*models.WebhookSubscription, error)
+    FindActive(ctx context.Context) ([]*models.WebhookSubscription, error)
+    Update(ctx context.Context, subscription *models.WebhookSubscription) error
+    Delete(ctx context.Context, id int64) error
+
+    // MarkFailing отмечает сбой доставки активной подписке и возвращает начало
+    // текущей серии сбоев; для отключенной или удаленной подписки возвращает at
+    MarkFailing(ctx context.Context, id int64, at time.Time) (time.Time, error)
+    // MarkHealthy завершает серию сбоев активной подписки
+    MarkHealthy(ctx context.Context, id int64) error
+    // Disable отключает активную подписку; false, если она уже отключена или удалена
+    Disable(ctx context.Context, id int64, reason string, at time.Time) (bool, error)
+}
+
+// WebhookDeliveryRepository - журнал попыток доставки вебхуков
+type WebhookDeliveryRepository interface {
+    RecordAttempt(ctx context.Context, delivery *models.WebhookDelivery) error
+    // FindDeliveries возвращает попытки от новых к старым и общее число подходящих записей
+    FindDeliveries(ctx context.Context, filter models.DeliveryFilter) ([]*models.WebhookDelivery, int, error)
+}
+
+// WatchZoneRepository - зоны наблюдения пользователей
+type WatchZoneRepository interface {
+    Create(ctx context.Context, zone *models.WatchZone) error
+    // FindByID возвращает nil, если зоны нет
+    FindByID(ctx context.Context, id int64) (*models.WatchZone, error)
+    FindByUser(ctx context.Context, userID string) ([]*models.WatchZone, error)
+    Update(ctx context.Context, zone *models.WatchZone) error
+    Delete(ctx context.Context, id int64) error
+
+    // FindIntersecting возвращает зоны, пересекающиеся с зоной инцидента (без учета
+    // min_severity). Кандидаты отбираются по пространственному индексу.
+    FindIntersecting(ctx context.Context, incident *models.Incident) ([]*models.WatchZone, error)
+    // MarkNotified запоминает, что владельцы зон оповещены об инциденте, и
+    // возвращает зоны, о которых оповещения еще не было
+    MarkNotified(ctx context.Context, incidentID int64, zoneIDs []int64, at time.Time) ([]int64, error)
+}
+
+// OutboxRepository - чтение и учет публикации событий, записанных IncidentRepository
+type OutboxRepository interface {
+    // FetchPending возвращает неопубликованные события, готовые к публикации на момент now,
+    // в порядке их создания. Пропускаются события в dead letter, события, повтор которых
+    // еще не наступил, и более поздние события их инцидентов.
+    FetchPending(ctx context.Context, now time.Time, limit int) ([]*models.OutboxEvent, error)
+    // MarkDelivered запоминает, что издатель обработал событие
+    MarkDelivered(ctx context.Context, id int64, publisher string) error
+    // MarkPublished помечает событие полностью опубликованным
+    MarkPublished(ctx context.Context, id int64) error
+    // MarkFailed увеличивает счетчик попыток, сохраняет последнюю ошибку и откладывает
+    // следующую попытку до retryAt
+    MarkFailed(ctx context.Context, id int64, reason string, retryAt time.Time) error
+    // MarkDeadLetter увеличивает счетчик попыток, сохраняет последнюю ошибку и исключает
+    // событие из публикации; следующие события инцидента публикуются без него
+    MarkDeadLetter(ctx context.Context, id int64, reason string, at time.Time) error
+    // AcquireLease выдает владельцу аренду на публикацию, чтобы события
+    // публиковал только один экземпляр сервиса. Продлевается повторным вызовом.
+    AcquireLease(ctx context.Context, owner string, ttl time.Duration) (bool, error)
+}
+
+// EventStream - журнал событий для потоковых потребителей. AppendEvent идемпотентен:
+// повторная запись события с тем же ID не создает дубликат.
+type EventStream interface {
+    AppendEvent(ctx context.Context, event *models.OutboxEvent) error
+}
+
+// NotificationPreferencesRepository хранит настройки оповещений пользователей
+// и журнал отправленных им оповещений для ограничения частоты
+type NotificationPreferencesRepository interface {
+    // Get возвращает nil, если пользователь не задавал настроек
+    Get(ctx context.Context, userID string) (*models.NotificationPreferences, error)
+    Save(ctx context.Context, prefs *models.NotificationPreferences) error
+    Delete(ctx context.Context, userID string) error
+    // ReserveAlert атомарно учитывает оповещение в момент at, если с момента since
+    // пользователю отправлено меньше limit оповещений; false - лимит исчерпан
+    ReserveAlert(ctx context.Context, userID string, since time.Time, limit int, at time.Time) (bool, error)
+}
+
+// ErrExternalIDExists - инцидент с таким ID во внешней системе уже импортирован
+var ErrExternalIDExists = errors.New("incident with this external id already exists")
+
+// ErrVersionConflict - инцидент изменен после того, как была прочитана его версия
+var ErrVersionConflict = errors.New("incident has been modified concurrently")
+
+// ErrInUse - запись нельзя удалить, пока на нее ссылаются другие
+var ErrInUse = errors.New("record is in use")
+
+// TaxonomyRepository хранит справочники категорий инцидентов и шкалу опасности
+type TaxonomyRepository interface {
+    // ListCategories возвращает все категории по коду, родитель раньше подкатегорий
+    ListCategories(ctx context.Context) ([]*models.Category, error)
+    // FindCategory возвращает nil, если категории нет
+    FindCategory(ctx context.Context, code string) (*models.Category, error)
+    // SaveCategory создает категорию или обновляет название и описание существующей
+    SaveCategory(ctx context.Context, category *models.Category) error
+    // DeleteCategory возвращает ErrInUse, если у категории есть подкатегории или инциденты
+    DeleteCategory(ctx context.Context, code string) error
+
+    // ListSeverityLevels возвращает шкалу опасности по возрастанию уровня
+    ListSeverityLevels(ctx context.Context) ([]*models.SeverityLevel, error)
+    SaveSeverityLevel(ctx context.Context, level *models.SeverityLevel) error
+    // DeleteSeverityLevel возвращает ErrInUse, если на уровень ссылаются инциденты,
+    // зоны наблюдения, настройки оповещений или подписки
+    DeleteSeverityLevel(ctx context.Context, code string) error
+}
+
+// ErrRateLimited - превышен лимит частоты запросов
+var ErrRateLimited = errors.New("rate limit exceeded")
+
+// ReportRepository хранит сообщения жителей о происшествиях и очередь их модерации
+type ReportRepository interface {
+    // Create сохраняет сообщение, если с момента limit.Since от того же заявителя
+    // и с того же адреса принято меньше разрешенного; иначе возвращает ErrRateLimited
+    Create(ctx context.Context, report *models.IncidentReport, limit models.ReportRateLimit) error
+    // FindByID возвращает nil, если сообщения нет
+    FindByID(ctx context.Context, id int64) (*models.IncidentReport, error)
+    // FindAll возвращает сообщения и общее число подходящих записей: ожидающие
+    // модерации - по возрастанию оценки злоупотреблений, затем по времени подачи;
+    // остальные - от новых к старым
+    FindAll(ctx context.Context, filter models.ReportFilter) ([]*models.IncidentReport, int, error)
+    // Claim переводит сообщение, ожидающее модерации, в approving на время создания
+    // инцидента, запоминая at в moderated_at; сообщение, которое находится в approving
+    // с момента раньше staleBefore (например, после сбоя процесса), захватывается заново.
+    // false - сообщение уже рассматривается, рассмотрено или его нет
+    Claim(ctx context.Context, id int64, at, staleBefore time.Time) (bool, error)
+    // Moderate сохраняет решение (статус, инцидент, признак злоупотребления, комментарий)
+    // по сообщению в статусе from; false - статус сообщения уже другой или его нет
+    Moderate(ctx context.Context, report *models.IncidentReport, from string) (bool, error)
+    // ReporterStats подсчитывает по статусам сообщения заявителя, поданные с момента since
+    ReporterStats(ctx context.Context, userID string, since time.Time) (*models.ReporterStats, error)
+    // AddressStats - то же для адреса, с которого подавались сообщения
+    AddressStats(ctx context.Context, ip string, since time.Time) (*models.ReporterStats, error)
+    // FindPendingNear возвращает ожидающие модерации сообщения, поданные с момента since,
+    // точка которых попадает в прямоугольник, описанный вокруг окружности radiusKm,
+    // в порядке подачи
+    FindPendingNear(ctx context.Context, lat, lng, radiusKm float64, since time.Time) ([]*models.IncidentReport, error)
+}
+
+// AttachmentRepository хранит описания вложений инцидентов; содержимое файлов - в BlobStore
+type AttachmentRepository interface {
+    Create(ctx context.Context, attachment *models.Attachment) error
+    // FindByID возвращает nil, если вложения нет
+    FindByID(ctx context.Context, id int64) (*models.Attachment, error)
+    // FindByIncident возвращает вложения инцидента в порядке загрузки
+    FindByIncident(ctx context.Context, incidentID int64) ([]*models.Attachment, error)
+    // CountByIncident возвращает число вложений инцидента
+    CountByIncident(ctx context.Context, incidentID int64) (int, error)
+    // Delete удаляет описание вложения; false - его нет
+    Delete(ctx context.Context, id int64) (bool, error)
+}
+
+// ErrBlobNotFound - в хранилище файлов нет объекта с таким ключом
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStore хранит содержимое файлов по ключам вида "incidents/12/3f9a..."
+type BlobStore interface {
+    // Put сохраняет size байт из r под ключом key, заменяя прежнее содержимое
+    Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+    // Get открывает содержимое на чтение; ErrBlobNotFound - объекта нет
+    Get(ctx context.Context, key string) (io.ReadCloser, error)
+    // Delete удаляет объект; отсутствие объекта ошибкой не считается
+    Delete(ctx context.Context, key string) error
+}
@@ -0,0 +1,87 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/domain/repositories"
+)
+
+// AttachmentRepositoryFactory возвращает пустое хранилище: репозиторий инцидентов
+// и вложений (вложения ссылаются на инциденты)
+type AttachmentRepositoryFactory func(t *testing.T) (repositories.IncidentRepository, repositories.AttachmentRepository)
+
+func newAttachment(incidentID int64, key string) *models.Attachment {
+    return &models.Attachment{
+        IncidentID:  incidentID,
+        FileName:    "схема.png",
+        ContentType: "image/png",
+        Size:        1024,
+        Checksum:    "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+        StorageKey:  key,
+        UploadedBy:  "operator",
+        CreatedAt:   time.Now().Truncate(time.Millisecond),
+    }
+}
+
+// RunAttachmentRepositorySuite проверяет контракт AttachmentRepository
+func RunAttachmentRepositorySuite(t *testing.T, newRepos AttachmentRepositoryFactory) {
+    t.Run("CRUD", func(t *testing.T) {
+        incidents, repo := newRepos(t)
+        ctx := context.Background()
+
+        incident := newIncident("Вложения", 100)
+        mustCreate(t, incidents, incident)
+
+        image := newAttachment(incident.ID, "attachments/a")
+        image.Width, image.Height = 640, 480
+        image.ThumbnailKey = "attachments/a.thumb.jpg"
+        document := newAttachment(incident.ID, "attachments/b")
+        document.ContentType = "application/pdf"
+        for _, attachment := range []*models.Attachment{image, document} {
+            if err := repo.Create(ctx, attachment); err != nil {
+                t.Fatalf("Create: %v", err)
+            }
+            if attachment.ID == 0 {
+                t.Fatal("Create did not assign ID")
+            }
+        }
+
+        found, err := repo.FindByID(ctx, image.ID)
+        if err != nil || found == nil {
+            t.Fatalf("FindByID = %+v, %v", found, err)
+        }
+        if found.IncidentID != incident.ID || found.FileName != "схема.png" || found.Size != 1024 ||
+            found.Width != 640 || found.Height != 480 || found.ThumbnailKey != image.ThumbnailKey ||
+            found.StorageKey != image.StorageKey || found.Checksum != image.Checksum ||
+            found.UploadedBy != "operator" || !found.CreatedAt.Equal(image.CreatedAt) {
+            t.Fatalf("FindByID = %+v", found)
+        }
+        if found, err := repo.FindByID(ctx, document.ID); err != nil || found.Width != 0 || found.ThumbnailKey != "" {
+            t.Fatalf("FindByID(document) = %+v, %v", found, err)
+        }
+        if missing, err := repo.FindByID(ctx, document.ID+100); err != nil || missing != nil {
+            t.Fatalf("FindByID(missing) = %+v, %v", missing, err)
+        }
+
+        list, err := repo.FindByIncident(ctx, incident.ID)
+        if err != nil || len(list) != 2 || list[0].ID != image.ID || list[1].ID != document.ID {
+            t.Fatalf("FindByIncident = %d attachments, %v", len(list), err)
+        }
+        if count, err := repo.CountByIncident(ctx, incident.ID); err != nil || count != 2 {
+            t.Fatalf("CountByIncident = %d, %v", count, err)
+        }
+
+        if deleted, err := repo.Delete(ctx, image.ID); err != nil || !deleted {
+            t.Fatalf("Delete = %v, %v", deleted, err)
+        }
+        if deleted, err := repo.Delete(ctx, image.ID); err != nil || deleted {
+            t.Fatalf("Delete(again) = %v, %v, want false", deleted, err)
+        }
+        if count, err := repo.CountByIncident(ctx, incident.ID); err != nil || count != 1 {
+            t.Fatalf("CountByIncident after Delete = %d, %v", count, err)
+        }
+    })
+}
@@ -0,0 +1,284 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/domain/repositories"
+)
+
+// CacheRepositoryFactory возвращает пустой кеш для одного подтеста
+type CacheRepositoryFactory func(t *testing.T) repositories.CacheRepository
+
+// QueueRepositoryFactory возвращает пустую очередь для одного подтеста
+type QueueRepositoryFactory func(t *testing.T) repositories.QueueRepository
+
+// RunCacheRepositorySuite проверяет контракт CacheRepository
+func RunCacheRepositorySuite(t *testing.T, newCache CacheRepositoryFactory) {
+    t.Run("MissIsNotError", func(t *testing.T) {
+        cache := newCache(t)
+
+        snapshot, err := cache.GetActiveIncidents(context.Background())
+        if err != nil {
+            t.Fatalf("GetActiveIncidents on empty cache: %v", err)
+        }
+        if snapshot != nil {
+            t.Fatalf("GetActiveIncidents on empty cache = %+v, want nil", snapshot)
+        }
+    })
+
+    t.Run("SetGetInvalidate", func(t *testing.T) {
+        cache := newCache(t)
+        ctx := context.Background()
+
+        first := newIncident("Первый", 100)
+        first.ID = 1
+        second := newIncident("Второй", 200)
+        second.ID = 2
+        second.Polygon = squareAround(baseLat, baseLng, 0.01)
+
+        version, err := cache.ActiveIncidentsVersion(ctx)
+        if err != nil {
+            t.Fatalf("ActiveIncidentsVersion: %v", err)
+        }
+        mustSetSnapshot(t, cache, version, first, second)
+
+        cached := mustGetSnapshot(t, cache)
+        if cached.Stale {
+            t.Fatal("fresh snapshot reported as stale")
+        }
+        if len(cached.Incidents) != 2 || cached.Incidents[0].ID != 1 || cached.Incidents[1].ID != 2 {
+            t.Fatalf("GetActiveIncidents = %v, want [1 2]", ids(cached.Incidents))
+        }
+        if len(cached.Incidents[1].Polygon) != 4 {
+            t.Fatalf("cached polygon has %d vertices, want 4", len(cached.Incidents[1].Polygon))
+        }
+
+        // Изменение полученных данных не должно влиять на кеш
+        cached.Incidents[0].Title = "Изменено"
+        if again := mustGetSnapshot(t, cache); again.Incidents[0].Title != "Первый" {
+            t.Fatalf("cache returned shared instance: title %q", again.Incidents[0].Title)
+        }
+
+        // Инвалидация не удаляет снимок, а делает его устаревшим
+        if err := cache.InvalidateActiveIncidents(ctx); err != nil {
+            t.Fatalf("InvalidateActiveIncidents: %v", err)
+        }
+        stale := mustGetSnapshot(t, cache)
+        if !stale.Stale || len(stale.Incidents) != 2 {
+            t.Fatalf("snapshot after invalidate: stale=%v, %d incidents", stale.Stale, len(stale.Incidents))
+        }
+
+        newVersion, _ := cache.ActiveIncidentsVersion(ctx)
+        if newVersion <= version {
+            t.Fatalf("version after invalidate = %d, want > %d", newVersion, version)
+        }
+        mustSetSnapshot(t, cache, newVersion, first)
+        if fresh := mustGetSnapshot(t, cache); fresh.Stale || len(fresh.Incidents) != 1 {
+            t.Fatalf("snapshot after refresh: stale=%v, %d incidents", fresh.Stale, len(fresh.Incidents))
+        }
+    })
+
+    t.Run("OlderSnapshotDoesNotOverwriteNewer", func(t *testing.T) {
+        cache := newCache(t)
+        ctx := context.Background()
+
+        _ = cache.InvalidateActiveIncidents(ctx)
+        current, _ := cache.ActiveIncidentsVersion(ctx)
+
+        newer := newIncident("Новый", 100)
+        newer.ID = 2
+        mustSetSnapshot(t, cache, current, newer)
+
+        older := newIncident("Старый", 100)
+        older.ID = 1
+        stored, err := cache.SetActiveIncidents(ctx, &models.ActiveIncidentsSnapshot{
+            Incidents: []*models.Incident{older},
+            Version:   current - 1,
+            LoadedAt:  time.Now(),
+        })
+        if err != nil {
+            t.Fatalf("SetActiveIncidents: %v", err)
+        }
+        if stored {
+            t.Fatal("older snapshot was stored over newer one")
+        }
+        if cached := mustGetSnapshot(t, cache); cached.Incidents[0].ID != 2 {
+            t.Fatalf("cache holds incident %d, want 2", cached.Incidents[0].ID)
+        }
+    })
+
+    t.Run("EmptySetIsHit", func(t *testing.T) {
+        cache := newCache(t)
+
+        mustSetSnapshot(t, cache, 0)
+        cached := mustGetSnapshot(t, cache)
+        if len(cached.Incidents) != 0 || cached.Stale {
+            t.Fatalf("GetActiveIncidents = %+v, want fresh empty snapshot", cached)
+        }
+    })
+
+    t.Run("RefreshLock", func(t *testing.T) {
+        cache := newCache(t)
+        ctx := context.Background()
+
+        token, acquired, err := cache.AcquireRefreshLock(ctx, time.Second)
+        if err != nil || !acquired {
+            t.Fatalf("AcquireRefreshLock = %v, %v; want acquired", acquired, err)
+        }
+        if _, again, _ := cache.AcquireRefreshLock(ctx, time.Second); again {
+            t.Fatal("refresh lock acquired twice")
+        }
+
+        // Чужой токен не снимает блокировку
+        _ = cache.ReleaseRefreshLock(ctx, "foreign")
+        if _, again, _ := cache.AcquireRefreshLock(ctx, time.Second); again {
+            t.Fatal("refresh lock released by foreign token")
+        }
+
+        if err := cache.ReleaseRefreshLock(ctx, token); err != nil {
+            t.Fatalf("ReleaseRefreshLock: %v", err)
+        }
+        if _, again, _ := cache.AcquireRefreshLock(ctx, time.Second); !again {
+            t.Fatal("refresh lock not acquired after release")
+        }
+    })
+}
+
+func mustSetSnapshot(t *testing.T, cache repositories.CacheRepository, version int64, incidents ...*models.Incident) {
+    t.Helper()
+    if incidents == nil {
+        incidents = []*models.Incident{}
+    }
+    stored, err := cache.SetActiveIncidents(context.Background(), &models.ActiveIncidentsSnapshot{
+        Incidents: incidents,
+        Version:   version,
+        LoadedAt:  time.Now(),
+    })
+    if err != nil || !stored {
+        t.Fatalf("SetActiveIncidents(version %d) = %v, %v; want stored", version, stored, err)
+    }
+}
+
+func mustGetSnapshot(t *testing.T, cache repositories.CacheRepository) *models.ActiveIncidentsSnapshot {
+    t.Helper()
+    snapshot, err := cache.GetActiveIncidents(context.Background())
+    if err != nil {
+        t.Fatalf("GetActiveIncidents: %v", err)
+    }
+    if snapshot == nil {
+        t.Fatal("GetActiveIncidents returned nil snapshot")
+    }
+    return snapshot
+}
+
+// RunQueueRepositorySuite проверяет контракт QueueRepository
+func RunQueueRepositorySuite(t *testing.T, newQueue QueueRepositoryFactory) {
+    t.Run("FIFO", func(t *testing.T) {
+        queue := newQueue(t)
+        ctx := context.Background()
+
+        for _, user := range []string{"u1", "u2", "u3"} {
+            payload := models.WebhookPayload{EventType: "location_alert", UserID: user, Timestamp: time.Now()}
+            if err := queue.EnqueueWebhook(ctx, payload); err != nil {
+                t.Fatalf("EnqueueWebhook: %v", err)
+            }
+        }
+
+        for _, want := range []string{"u1", "u2", "u3"} {
+            payload, err := dequeueWithTimeout(queue, time.Second)
+            if err != nil {
+                t.Fatalf("DequeueWebhook: %v", err)
+            }
+            if payload == nil || payload.UserID != want {
+                t.Fatalf("DequeueWebhook = %+v, want user %s", payload, want)
+            }
+        }
+    })
+
+    t.Run("DequeueBlocksUntilEnqueue", func(t *testing.T) {
+        queue := newQueue(t)
+
+        go func() {
+            time.Sleep(50 * time.Millisecond)
+            _ = queue.EnqueueWebhook(context.Background(), models.WebhookPayload{EventType: "location_alert", UserID: "late"})
+        }()
+
+        payload, err := dequeueWithTimeout(queue, 2*time.Second)
+        if err != nil {
+            t.Fatalf("DequeueWebhook: %v", err)
+        }
+        if payload == nil || payload.UserID != "late" {
+            t.Fatalf("DequeueWebhook = %+v, want user late", payload)
+        }
+    })
+
+    t.Run("DequeueHonorsContext", func(t *testing.T) {
+        queue := newQueue(t)
+
+        ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+        defer cancel()
+
+        done := make(chan error, 1)
+        go func() {
+            _, err := queue.DequeueWebhook(ctx)
+            done <- err
+        }()
+
+        select {
+        case err := <-done:
+            if err == nil {
+                t.Fatal("DequeueWebhook on empty queue returned without error after context cancel")
+            }
+        case <-time.After(2 * time.Second):
+            t.Fatal("DequeueWebhook ignored context cancellation")
+        }
+    })
+
+    t.Run("DelayedTasksByDueTime", func(t *testing.T) {
+        queue := newQueue(t)
+        ctx := context.Background()
+        now := time.Now()
+
+        for _, task := range []struct {
+            user string
+            due  time.Duration
+        }{{"later", time.Hour}, {"second", -time.Second}, {"first", -time.Minute}} {
+            webhookTask := models.NewWebhookTask(1, "http://example.com/hook", models.WebhookPayload{UserID: task.user})
+            webhookTask.Attempt = 1
+            webhookTask.DueAt = now.Add(task.due)
+            if err := queue.ScheduleWebhookTask(ctx, webhookTask); err != nil {
+                t.Fatalf("ScheduleWebhookTask: %v", err)
+            }
+        }
+
+        due, err := queue.DueWebhookTasks(ctx, now, 1)
+        if err != nil {
+            t.Fatalf("DueWebhookTasks: %v", err)
+        }
+        if len(due) != 1 || due[0].Payload.UserID != "first" || due[0].Attempt != 1 {
+            t.Fatalf("DueWebhookTasks(limit=1) = %+v, want first", due)
+        }
+
+        due, _ = queue.DueWebhookTasks(ctx, now, 10)
+        if len(due) != 1 || due[0].Payload.UserID != "second" {
+            t.Fatalf("DueWebhookTasks = %+v, want only second", due)
+        }
+
+        // Взятая задача удаляется из очереди
+        if due, _ = queue.DueWebhookTasks(ctx, now, 10); len(due) != 0 {
+            t.Fatalf("DueWebhookTasks returned %d tasks twice", len(due))
+        }
+        if due, _ = queue.DueWebhookTasks(ctx, now.Add(2*time.Hour), 10); len(due) != 1 || due[0].Payload.UserID != "later" {
+            t.Fatalf("DueWebhookTasks after due time = %+v, want later", due)
+        }
+    })
+}
+
+func dequeueWithTimeout(queue repositories.QueueRepository, timeout time.Duration) (*models.WebhookPayload, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), timeout)
+    defer cancel()
+    return queue.DequeueWebhook(ctx)
+}
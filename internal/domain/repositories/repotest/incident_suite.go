@@ -0,0 +1,759 @@
+// Package repotest содержит общий набор проверок для реализаций репозиториев.
+// Каждая реализация IncidentRepository вызывает RunIncidentRepositorySuite
+// из своих тестов, передавая фабрику чистого хранилища, например:
+//
+//	repotest.RunIncidentRepositorySuite(t, func(t *testing.T) repositories.IncidentRepository {
+//	    return db.NewPostGISIncidentRepository(openCleanDB(t))
+//	})
+package repotest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/domain/repositories"
+)
+
+// IncidentRepositoryFactory возвращает пустой репозиторий для одного подтеста
+type IncidentRepositoryFactory func(t *testing.T) repositories.IncidentRepository
+
+// Опорная точка - центр Москвы
+const (
+    baseLat = 55.7558
+    baseLng = 37.6173
+)
+
+// RunIncidentRepositorySuite проверяет контракт IncidentRepository
+func RunIncidentRepositorySuite(t *testing.T, newRepo IncidentRepositoryFactory) {
+    t.Run("CreateAndFindByID", func(t *testing.T) {
+        repo := newRepo(t)
+        ctx := context.Background()
+
+        incident := newIncident("Пожар", 500)
+        mustCreate(t, repo, incident)
+        if incident.ID == 0 {
+            t.Fatal("Create must assign ID")
+        }
+        if incident.CreatedAt.IsZero() || incident.UpdatedAt.IsZero() {
+            t.Fatal("Create must set timestamps")
+        }
+
+        found, err := repo.FindByID(ctx, incident.ID)
+        if err != nil {
+            t.Fatalf("FindByID: %v", err)
+        }
+        if found == nil {
+            t.Fatal("FindByID returned nil for existing incident")
+        }
+        if found.Title != incident.Title || found.Severity != incident.Severity || !found.Active {
+            t.Fatalf("FindByID returned %+v, want %+v", found, incident)
+        }
+
+        missing, err := repo.FindByID(ctx, incident.ID+1000)
+        if err != nil {
+            t.Fatalf("FindByID(missing): %v", err)
+        }
+        if missing != nil {
+            t.Fatalf("FindByID(missing) = %+v, want nil", missing)
+        }
+    })
+
+    t.Run("ExternalID", func(t *testing.T) {
+        repo := newRepo(t)
+        ctx := context.Background()
+
+        externalID := "cap:agency.example/alert-1"
+        incident := newIncident("Импортированный", 500)
+        incident.ExternalID = &externalID
+        mustCreate(t, repo, incident)
+        mustCreate(t, repo, newIncident("Без внешнего ID", 500))
+        mustCreate(t, repo, newIncident("Без внешнего ID", 500))
+
+        found, err := repo.FindByExternalID(ctx, externalID)
+        if err != nil {
+            t.Fatalf("FindByExternalID: %v", err)
+        }
+        if found == nil || found.ID != incident.ID || found.ExternalID == nil || *found.ExternalID != externalID {
+            t.Fatalf("FindByExternalID = %+v, want incident %d", found, incident.ID)
+        }
+
+        duplicate := newIncident("Повтор", 500)
+        duplicate.ExternalID = &externalID
+        if err := repo.Create(ctx, duplicate); !errors.Is(err, repositories.ErrExternalIDExists) {
+            t.Fatalf("Create(duplicate external id) = %v, want ErrExternalIDExists", err)
+        }
+
+        missing, err := repo.FindByExternalID(ctx, "cap:agency.example/unknown")
+        if err != nil || missing != nil {
+            t.Fatalf("FindByExternalID(missing) = %+v, %v, want nil", missing, err)
+        }
+    })
+
+    t.Run("PolygonRoundTrip", func(t *testing.T) {
+        repo := newRepo(t)
+
+        incident := newIncident("Наводнение", 1000)
+        incident.Polygon = squareAround(baseLat, baseLng, 0.01)
+        mustCreate(t, repo, incident)
+
+        found, err := repo.FindByID(context.Background(), incident.ID)
+        if err != nil {
+            t.Fatalf("FindByID: %v", err)
+        }
+        if len(found.Polygon) != len(incident.Polygon) {
+            t.Fatalf("polygon has %d vertices, want %d", len(found.Polygon), len(incident.Polygon))
+        }
+    })
+
+    t.Run("FindAllAndCount", func(t *testing.T) {
+        repo := newRepo(t)
+        ctx := context.Background()
+
+        for i := 0; i < 3; i++ {
+            mustCreate(t, repo, newIncident("Активный", 100))
+        }
+        inactive := newIncident("Закрытый", 100)
+        mustCreate(t, repo, inactive)
+        if err := repo.Delete(ctx, inactive.ID, 0); err != nil {
+            t.Fatalf("Delete: %v", err)
+        }
+
+        active, err := repo.FindAll(ctx, models.IncidentFilter{ActiveOnly: true, Limit: 10})
+        if err != nil {
+            t.Fatalf("FindAll(active): %v", err)
+        }
+        if len(active) != 3 {
+            t.Fatalf("FindAll(active) returned %d, want 3", len(active))
+        }
+
+        all, err := repo.FindAll(ctx, models.IncidentFilter{Limit: 10})
+        if err != nil {
+            t.Fatalf("FindAll(all): %v", err)
+        }
+        if len(all) != 4 {
+            t.Fatalf("FindAll(all) returned %d, want 4", len(all))
+        }
+
+        page, err := repo.FindAll(ctx, models.IncidentFilter{Limit: 2, Offset: 2})
+        if err != nil {
+            t.Fatalf("FindAll(page): %v", err)
+        }
+        if len(page) != 2 {
+            t.Fatalf("FindAll(limit=2, offset=2) returned %d, want 2", len(page))
+        }
+
+        if count, err := repo.CountAll(ctx, models.IncidentFilter{ActiveOnly: true}); err != nil || count != 3 {
+            t.Fatalf("CountAll(active) = %d, %v; want 3", count, err)
+        }
+        if count, err := repo.CountAll(ctx, models.IncidentFilter{}); err != nil || count != 4 {
+            t.Fatalf("CountAll(all) = %d, %v; want 4", count, err)
+        }
+    })
+
+    t.Run("FilterByClassification", func(t *testing.T) {
+        repo := newRepo(t)
+        ctx := context.Background()
+
+        wildfire := newIncident("Лесной пожар", 100)
+        wildfire.Category = "fire.wildfire"
+        wildfire.Tags = []string{"smoke", "evacuation"}
+        mustCreate(t, repo, wildfire)
+
+        building := newIncident("Пожар в здании", 100)
+        building.Category = "fire.building"
+        building.Severity = "medium"
+        mustCreate(t, repo, building)
+
+        flood := newIncident("Подтопление", 100)
+        flood.Category = "flood"
+        flood.Tags = []string{"evacuation"}
+        flood.Severity = "low"
+        mustCreate(t, repo, flood)
+
+        found, err := repo.FindByID(ctx, wildfire.ID)
+        if err != nil || found.Category != "fire.wildfire" || len(found.Tags) != 2 || found.Tags[0] != "smoke" {
+            t.Fatalf("classification not persisted: %+v, %v", found, err)
+        }
+
+        cases := []struct {
+            name   string
+            filter models.IncidentFilter
+            want   int
+        }{
+            {"parent category includes subcategories", models.IncidentFilter{Categories: []string{"fire"}}, 2},
+            {"subcategory", models.IncidentFilter{Categories: []string{"fire.building"}}, 1},
+            {"prefix is not a parent", models.IncidentFilter{Categories: []string{"fir"}}, 0},
+            {"any of tags", models.IncidentFilter{Tags: []string{"evacuation", "missing"}}, 2},
+            {"severity", models.IncidentFilter{Severities: []string{"medium", "high"}}, 2},
+            {"combined", models.IncidentFilter{Categories: []string{"fire", "flood"}, Tags: []string{"evacuation"}, Severities: []string{"low"}}, 1},
+        }
+        for _, tc := range cases {
+            tc.filter.Limit = 10
+            incidents, err := repo.FindAll(ctx, tc.filter)
+            if err != nil {
+                t.Fatalf("%s: FindAll: %v", tc.name, err)
+            }
+            if len(incidents) != tc.want {
+                t.Fatalf("%s: FindAll returned %v, want %d incidents", tc.name, ids(incidents), tc.want)
+            }
+            if count, err := repo.CountAll(ctx, tc.filter); err != nil || count != tc.want {
+                t.Fatalf("%s: CountAll = %d, %v; want %d", tc.name, count, err, tc.want)
+            }
+        }
+
+        for _, check := range []*models.LocationCheck{
+            {UserID: "u1", IncidentIDs: []int64{wildfire.ID}},
+            {UserID: "u2", IncidentIDs: []int64{flood.ID}},
+            {UserID: "u3"},
+        } {
+            check.Latitude, check.Longitude, check.HasAlert = baseLat, baseLng, len(check.IncidentIDs) > 0
+            check.Timestamp = time.Now()
+            if err := repo.SaveLocationCheck(ctx, check); err != nil {
+                t.Fatalf("SaveLocationCheck: %v", err)
+            }
+        }
+
+        stats, err := repo.GetStats(ctx, 60, models.IncidentFilter{Categories: []string{"fire"}})
+        if err != nil {
+            t.Fatalf("GetStats: %v", err)
+        }
+        if len(stats) != 1 || stats[0].ZoneID == nil || *stats[0].ZoneID != wildfire.ID || stats[0].UserCount != 1 {
+            t.Fatalf("GetStats(fire) = %+v, want only the wildfire zone", stats)
+        }
+    })
+
+    t.Run("UpdateAndDelete", func(t *testing.T) {
+        repo := newRepo(t)
+        ctx := context.Background()
+
+        incident := newIncident("Исходный", 100)
+        mustCreate(t, repo, incident)
+        if found, _ := repo.FindByID(ctx, incident.ID); found.Public {
+            t.Fatalf("incident created public by default: %+v", found)
+        }
+
+        incident.Title = "Обновленный"
+        incident.Severity = "low"
+        incident.Category = "crime"
+        incident.Tags = []string{"night"}
+        incident.Radius = 750
+        incident.Public = true
+        if err := repo.Update(ctx, incident); err != nil {
+            t.Fatalf("Update: %v", err)
+        }
+
+        found, _ := repo.FindByID(ctx, incident.ID)
+        if found.Title != "Обновленный" || found.Severity != "low" || found.Radius != 750 ||
+            found.Category != "crime" || len(found.Tags) != 1 || !found.Public {
+            t.Fatalf("Update not persisted: %+v", found)
+        }
+
+        // Зона обновляется вместе с остальными полями
+        incident.Latitude, incident.Longitude = baseLat+0.1, baseLng+0.1
+        incident.Polygon = squareAround(baseLat+0.1, baseLng+0.1, 0.01)
+        if err := repo.Update(ctx, incident); err != nil {
+            t.Fatalf("Update(zone): %v", err)
+        }
+        found, _ = repo.FindByID(ctx, incident.ID)
+        if found.Latitude != baseLat+0.1 || len(found.Polygon) != 4 {
+            t.Fatalf("Update(zone) not persisted: %+v", found)
+        }
+        inZone, err := repo.FindContainingLocation(ctx, baseLat+0.1, baseLng+0.1)
+        if err != nil || len(inZone) != 1 {
+            t.Fatalf("FindContainingLocation after zone update = %v, %v", ids(inZone), err)
+        }
+
+        if err := repo.Delete(ctx, incident.ID, 0); err != nil {
+            t.Fatalf("Delete: %v", err)
+        }
+        found, _ = repo.FindByID(ctx, incident.ID)
+        if found == nil || found.Active {
+            t.Fatalf("Delete must deactivate incident, got %+v", found)
+        }
+    })
+
+    t.Run("ApplyBatch", func(t *testing.T) {
+        repo := newRepo(t)
+        ctx := context.Background()
+
+        updated := newIncident("Перекрытие", 100)
+        deleted := newIncident("Подтопление", 100)
+        imported := newIncident("Импортированный", 100)
+        externalID := "cap:test/1"
+        imported.ExternalID = &externalID
+        for _, incident := range []*models.Incident{updated, deleted, imported} {
+            mustCreate(t, repo, incident)
+        }
+
+        created := newIncident("Новый", 200)
+        updated.Radius = 900
+        err := repo.ApplyBatch(ctx, "batch-1", []models.IncidentChange{
+            {Kind: models.ChangeCreate, Incident: created},
+            {Kind: models.ChangeUpdate, Incident: updated},
+            {Kind: models.ChangeDelete, Incident: &models.Incident{ID: deleted.ID}},
+        })
+        if err != nil {
+            t.Fatalf("ApplyBatch: %v", err)
+        }
+        if created.ID == 0 {
+            t.Fatal("ApplyBatch must assign ID to created incident")
+        }
+        found, _ := repo.FindByID(ctx, updated.ID)
+        if found.Radius != 900 {
+            t.Fatalf("ApplyBatch update not persisted: %+v", found)
+        }
+        found, _ = repo.FindByID(ctx, deleted.ID)
+        if found == nil || found.Active {
+            t.Fatalf("ApplyBatch delete must deactivate incident, got %+v", found)
+        }
+
+        // Конфликт внешнего ID отменяет весь пакет
+        duplicate := newIncident("Повтор", 100)
+        duplicate.ExternalID = &externalID
+        updated.Radius = 1500
+        err = repo.ApplyBatch(ctx, "batch-2", []models.IncidentChange{
+            {Kind: models.ChangeUpdate, Incident: updated},
+            {Kind: models.ChangeCreate, Incident: duplicate},
+        })
+        if !errors.Is(err, repositories.ErrExternalIDExists) {
+            t.Fatalf("ApplyBatch with duplicate external id = %v, want ErrExternalIDExists", err)
+        }
+        found, _ = repo.FindByID(ctx, updated.ID)
+        if found.Radius != 900 {
+            t.Fatalf("failed batch must not be applied, radius = %v", found.Radius)
+        }
+    })
+
+    t.Run("Versions", func(t *testing.T) {
+        repo := newRepo(t)
+        ctx := context.Background()
+
+        incident := newIncident("Версии", 100)
+        mustCreate(t, repo, incident)
+        if incident.Version != 1 {
+            t.Fatalf("created incident version = %d, want 1", incident.Version)
+        }
+
+        // Две копии одной версии: вторая запись должна заметить первую
+        stale, _ := repo.FindByID(ctx, incident.ID)
+        incident.Radius = 300
+        if err := repo.Update(ctx, incident); err != nil {
+            t.Fatalf("Update: %v", err)
+        }
+        if incident.Version != 2 {
+            t.Fatalf("updated incident version = %d, want 2", incident.Version)
+        }
+        stale.Radius = 400
+        if err := repo.Update(ctx, stale); !errors.Is(err, repositories.ErrVersionConflict) {
+            t.Fatalf("Update(stale) = %v, want ErrVersionConflict", err)
+        }
+        found, _ := repo.FindByID(ctx, incident.ID)
+        if found.Version != 2 || found.Radius != 300 {
+            t.Fatalf("stale update must not be applied: version %d, radius %v", found.Version, found.Radius)
+        }
+
+        err := repo.ApplyBatch(ctx, "batch-stale", []models.IncidentChange{
+            {Kind: models.ChangeUpdate, Incident: stale},
+        })
+        if !errors.Is(err, repositories.ErrVersionConflict) {
+            t.Fatalf("ApplyBatch(stale) = %v, want ErrVersionConflict", err)
+        }
+
+        if err := repo.Delete(ctx, incident.ID, 1); !errors.Is(err, repositories.ErrVersionConflict) {
+            t.Fatalf("Delete(stale version) = %v, want ErrVersionConflict", err)
+        }
+        if err := repo.Delete(ctx, incident.ID, 2); err != nil {
+            t.Fatalf("Delete(current version): %v", err)
+        }
+        found, _ = repo.FindByID(ctx, incident.ID)
+        if found.Active || found.Version != 3 {
+            t.Fatalf("Delete must deactivate and bump version, got active %v, version %d", found.Active, found.Version)
+        }
+
+        // Несуществующий инцидент не конфликтует
+        if err := repo.Delete(ctx, incident.ID+100, 1); err != nil {
+            t.Fatalf("Delete(missing): %v", err)
+        }
+    })
+
+    t.Run("ExpireDue", func(t *testing.T) {
+        repo := newRepo(t)
+        ctx := context.Background()
+
+        now := time.Now().Truncate(time.Millisecond)
+        past := now.Add(-time.Minute)
+        future := now.Add(time.Hour)
+
+        due := newIncident("Истек", 100)
+        due.ExpiresAt = &future
+        later := newIncident("Позже", 100)
+        later.ExpiresAt = &future
+        forever := newIncident("Бессрочный", 100)
+        mustCreate(t, repo, due)
+        mustCreate(t, repo, later)
+        mustCreate(t, repo, forever)
+
+        found, _ := repo.FindByID(ctx, due.ID)
+        if found.ExpiresAt == nil || !found.ExpiresAt.Equal(future) {
+            t.Fatalf("ExpiresAt not persisted: %v", found.ExpiresAt)
+        }
+
+        due.ExpiresAt = &past
+        if err := repo.Update(ctx, due); err != nil {
+            t.Fatalf("Update: %v", err)
+        }
+
+        expired, err := repo.ExpireDue(ctx, now)
+        if err != nil {
+            t.Fatalf("ExpireDue: %v", err)
+        }
+        if len(expired) != 1 || expired[0].ID != due.ID || expired[0].Active {
+            t.Fatalf("ExpireDue returned %+v, want inactive %d", expired, due.ID)
+        }
+
+        // Повторный вызов не находит уже деактивированный инцидент
+        if expired, err := repo.ExpireDue(ctx, now); err != nil || len(expired) != 0 {
+            t.Fatalf("second ExpireDue = %v, %v", ids(expired), err)
+        }
+
+        active, _ := repo.GetActiveIncidents(ctx)
+        if got := ids(active); len(got) != 2 || got[0] != later.ID || got[1] != forever.ID {
+            t.Fatalf("GetActiveIncidents after expiry = %v", got)
+        }
+    })
+
+    t.Run("ActiveIncidents", func(t *testing.T) {
+        repo := newRepo(t)
+        ctx := context.Background()
+
+        first := newIncident("Первый", 100)
+        second := newIncident("Второй", 100)
+        mustCreate(t, repo, first)
+        mustCreate(t, repo, second)
+        _ = repo.Delete(ctx, first.ID, 0)
+
+        active, err := repo.GetActiveIncidents(ctx)
+        if err != nil {
+            t.Fatalf("GetActiveIncidents: %v", err)
+        }
+        if len(active) != 1 || active[0].ID != second.ID {
+            t.Fatalf("GetActiveIncidents returned %v, want only %d", ids(active), second.ID)
+        }
+    })
+
+    t.Run("FindNearLocationCircle", func(t *testing.T) {
+        repo := newRepo(t)
+        ctx := context.Background()
+
+        // Зона радиусом 500 м в опорной точке
+        center := newIncident("Центр", 500)
+        mustCreate(t, repo, center)
+
+        // Зона в ~11 км к северу
+        north := newIncident("Север", 500)
+        north.Latitude = baseLat + 0.1
+        mustCreate(t, repo, north)
+
+        inactive := newIncident("Закрытый", 500)
+        mustCreate(t, repo, inactive)
+        _ = repo.Delete(ctx, inactive.ID, 0)
+
+        near, err := repo.FindNearLocation(ctx, baseLat, baseLng, 1)
+        if err != nil {
+            t.Fatalf("FindNearLocation: %v", err)
+        }
+        if len(near) != 1 || near[0].ID != center.ID {
+            t.Fatalf("FindNearLocation(1 km) returned %v, want [%d]", ids(near), center.ID)
+        }
+
+        wide, err := repo.FindNearLocation(ctx, baseLat, baseLng, 20)
+        if err != nil {
+            t.Fatalf("FindNearLocation: %v", err)
+        }
+        if len(wide) != 2 {
+            t.Fatalf("FindNearLocation(20 km) returned %v, want 2 incidents", ids(wide))
+        }
+
+        // Расстояние считается до границы зоны: точка в 1.2 км от центра
+        // находится в 0.7 км от зоны радиусом 500 м
+        edge, err := repo.FindNearLocation(ctx, baseLat+0.0108, baseLng, 0.8)
+        if err != nil {
+            t.Fatalf("FindNearLocation: %v", err)
+        }
+        if len(edge) != 1 || edge[0].ID != center.ID {
+            t.Fatalf("FindNearLocation(edge) returned %v, want [%d]", ids(edge), center.ID)
+        }
+    })
+
+    t.Run("Antimeridian", func(t *testing.T) {
+        repo := newRepo(t)
+        ctx := context.Background()
+
+        // Зона радиусом 5 км у 180-го меридиана заходит в западное полушарие
+        incident := newIncident("Чукотка", 5000)
+        incident.Latitude, incident.Longitude = 65, 179.95
+        mustCreate(t, repo, incident)
+        west := newIncident("Аляска", 5000)
+        west.Latitude, west.Longitude = 65, -179.95
+        mustCreate(t, repo, west)
+
+        // Точка по другую сторону меридиана в ~3.8 км от центра
+        containing, err := repo.FindContainingLocation(ctx, 65, -179.97)
+        if err != nil {
+            t.Fatalf("FindContainingLocation: %v", err)
+        }
+        if len(containing) != 2 {
+            t.Fatalf("FindContainingLocation(-179.97) returned %v, want both zones", ids(containing))
+        }
+
+        near, err := repo.FindNearLocation(ctx, 65, 179.9, 3)
+        if err != nil {
+            t.Fatalf("FindNearLocation: %v", err)
+        }
+        if len(near) != 2 || near[0].ID != incident.ID || near[1].ID != west.ID {
+            t.Fatalf("FindNearLocation(179.9) returned %v, want [%d %d]", ids(near), incident.ID, west.ID)
+        }
+
+        now := time.Now().Truncate(time.Millisecond)
+        check := &models.LocationCheck{UserID: "u1", Latitude: 65, Longitude: -179.98, Timestamp: now}
+        if err := repo.SaveLocationCheck(ctx, check); err != nil {
+            t.Fatalf("SaveLocationCheck: %v", err)
+        }
+        latest, err := repo.FindLatestLocations(ctx, now.Add(-time.Minute), 65, 179.95, 5)
+        if err != nil {
+            t.Fatalf("FindLatestLocations: %v", err)
+        }
+        if len(latest) != 1 || latest[0].UserID != "u1" {
+            t.Fatalf("FindLatestLocations(179.95) returned %+v, want u1 across the antimeridian", latest)
+        }
+    })
+
+    t.Run("FindNearLocationPolygon", func(t *testing.T) {
+        repo := newRepo(t)
+        ctx := context.Background()
+
+        // Вытянутый многоугольник к востоку от опорной точки
+        incident := newIncident("Полоса", 0)
+        incident.Polygon = []models.GeoPoint{
+            {Latitude: baseLat - 0.001, Longitude: baseLng},
+            {Latitude: baseLat - 0.001, Longitude: baseLng + 0.1},
+            {Latitude: baseLat + 0.001, Longitude: baseLng + 0.1},
+            {Latitude: baseLat + 0.001, Longitude: baseLng},
+        }
+        incident.Latitude, incident.Longitude = baseLat, baseLng+0.05
+        incident.Radius = 3200
+        mustCreate(t, repo, incident)
+
+        inside, err := repo.FindContainingLocation(ctx, baseLat, baseLng+0.09)
+        if err != nil {
+            t.Fatalf("FindContainingLocation: %v", err)
+        }
+        if len(inside) != 1 {
+            t.Fatalf("FindContainingLocation(inside) returned %v, want [%d]", ids(inside), incident.ID)
+        }
+
+        // Точка в ~1.1 км к северу от полосы: внутри описанной окружности, но вне контура
+        outside, err := repo.FindContainingLocation(ctx, baseLat+0.011, baseLng+0.05)
+        if err != nil {
+            t.Fatalf("FindContainingLocation: %v", err)
+        }
+        if len(outside) != 0 {
+            t.Fatalf("FindContainingLocation(outside) returned %v, want none", ids(outside))
+        }
+
+        near, err := repo.FindNearLocation(ctx, baseLat+0.011, baseLng+0.05, 1.5)
+        if err != nil {
+            t.Fatalf("FindNearLocation: %v", err)
+        }
+        if len(near) != 1 {
+            t.Fatalf("FindNearLocation(1.5 km) returned %v, want [%d]", ids(near), incident.ID)
+        }
+
+        far, err := repo.FindNearLocation(ctx, baseLat+0.011, baseLng+0.05, 0.5)
+        if err != nil {
+            t.Fatalf("FindNearLocation: %v", err)
+        }
+        if len(far) != 0 {
+            t.Fatalf("FindNearLocation(0.5 km) returned %v, want none", ids(far))
+        }
+    })
+
+    t.Run("FindContainingLocationCircle", func(t *testing.T) {
+        repo := newRepo(t)
+        ctx := context.Background()
+
+        incident := newIncident("Круг", 1000)
+        mustCreate(t, repo, incident)
+
+        inside, err := repo.FindContainingLocation(ctx, baseLat+0.005, baseLng)
+        if err != nil {
+            t.Fatalf("FindContainingLocation: %v", err)
+        }
+        if len(inside) != 1 {
+            t.Fatalf("FindContainingLocation(inside) returned %v, want [%d]", ids(inside), incident.ID)
+        }
+
+        outside, err := repo.FindContainingLocation(ctx, baseLat+0.0095, baseLng)
+        if err != nil {
+            t.Fatalf("FindContainingLocation: %v", err)
+        }
+        if len(outside) != 0 {
+            t.Fatalf("FindContainingLocation(outside) returned %v, want none", ids(outside))
+        }
+    })
+
+    t.Run("LocationChecksAndStats", func(t *testing.T) {
+        repo := newRepo(t)
+        ctx := context.Background()
+
+        incident := newIncident("Статистика", 100)
+        mustCreate(t, repo, incident)
+        nested := newIncident("Вложенная зона", 50)
+        mustCreate(t, repo, nested)
+
+        checks := []*models.LocationCheck{
+            {UserID: "u1", HasAlert: true, IncidentIDs: []int64{incident.ID}},
+            {UserID: "u1", HasAlert: true, IncidentIDs: []int64{incident.ID}},
+            // Точка в двух зонах хранится одной проверкой
+            {UserID: "u2", HasAlert: true, IncidentIDs: []int64{incident.ID, nested.ID}},
+            {UserID: "u3", HasAlert: false},
+            // Старая проверка не попадает в окно статистики
+            {UserID: "u4", HasAlert: false, Timestamp: time.Now().Add(-2 * time.Hour)},
+        }
+        for _, check := range checks {
+            check.Latitude, check.Longitude = baseLat, baseLng
+            if check.Timestamp.IsZero() {
+                check.Timestamp = time.Now()
+            }
+            if err := repo.SaveLocationCheck(ctx, check); err != nil {
+                t.Fatalf("SaveLocationCheck: %v", err)
+            }
+            if check.ID == 0 {
+                t.Fatal("SaveLocationCheck must assign ID")
+            }
+        }
+
+        stats, err := repo.GetStats(ctx, 60, models.IncidentFilter{})
+        if err != nil {
+            t.Fatalf("GetStats: %v", err)
+        }
+
+        byZone := make(map[int64]int64)
+        for _, stat := range stats {
+            var zone int64
+            if stat.ZoneID != nil {
+                zone = *stat.ZoneID
+            }
+            byZone[zone] = stat.UserCount
+        }
+        if byZone[incident.ID] != 2 {
+            t.Fatalf("stats for zone %d = %d, want 2 distinct users", incident.ID, byZone[incident.ID])
+        }
+        if byZone[nested.ID] != 1 {
+            t.Fatalf("stats for zone %d = %d, want 1", nested.ID, byZone[nested.ID])
+        }
+        if byZone[0] != 1 {
+            t.Fatalf("stats without zone = %d, want 1", byZone[0])
+        }
+
+        latest, err := repo.FindLatestLocations(ctx, time.Now().Add(-time.Hour), baseLat, baseLng, 100)
+        if err != nil {
+            t.Fatalf("FindLatestLocations: %v", err)
+        }
+        if len(latest) != 3 {
+            t.Fatalf("FindLatestLocations = %+v, want one location per user", latest)
+        }
+    })
+
+    t.Run("LatestLocationsAndAlerts", func(t *testing.T) {
+        repo := newRepo(t)
+        ctx := context.Background()
+
+        incident := newIncident("Оповещение", 1000)
+        mustCreate(t, repo, incident)
+
+        now := time.Now().Truncate(time.Millisecond)
+        far := baseLat + 1 // ~111 км к северу
+        checks := []*models.LocationCheck{
+            // u1 ушел из зоны: учитывается только последняя точка
+            {UserID: "u1", Latitude: baseLat, Timestamp: now.Add(-2 * time.Minute)},
+            {UserID: "u1", Latitude: far, Timestamp: now.Add(-time.Minute)},
+            // u2 пришел в зону
+            {UserID: "u2", Latitude: far, Timestamp: now.Add(-2 * time.Minute)},
+            {UserID: "u2", Latitude: baseLat, Timestamp: now.Add(-time.Minute)},
+            // u3 в зоне, но точка устарела
+            {UserID: "u3", Latitude: baseLat, Timestamp: now.Add(-time.Hour)},
+        }
+        for _, check := range checks {
+            check.Longitude = baseLng
+            if err := repo.SaveLocationCheck(ctx, check); err != nil {
+                t.Fatalf("SaveLocationCheck: %v", err)
+            }
+        }
+
+        latest, err := repo.FindLatestLocations(ctx, now.Add(-10*time.Minute), baseLat, baseLng, 1)
+        if err != nil {
+            t.Fatalf("FindLatestLocations: %v", err)
+        }
+        if len(latest) != 1 || latest[0].UserID != "u2" || latest[0].Latitude != baseLat {
+            t.Fatalf("FindLatestLocations = %+v, want only u2 inside", latest)
+        }
+
+        marked, err := repo.MarkAlerted(ctx, incident.ID, []string{"u1", "u2"}, now)
+        if err != nil || len(marked) != 2 {
+            t.Fatalf("MarkAlerted = %v, %v, want both users", marked, err)
+        }
+        marked, err = repo.MarkAlerted(ctx, incident.ID, []string{"u2", "u3"}, now)
+        if err != nil || len(marked) != 1 || marked[0] != "u3" {
+            t.Fatalf("second MarkAlerted = %v, %v, want only u3", marked, err)
+        }
+        if err := repo.UnmarkAlerted(ctx, incident.ID, []string{"u2"}); err != nil {
+            t.Fatalf("UnmarkAlerted: %v", err)
+        }
+        marked, err = repo.MarkAlerted(ctx, incident.ID, []string{"u1", "u2"}, now)
+        if err != nil || len(marked) != 1 || marked[0] != "u2" {
+            t.Fatalf("MarkAlerted after UnmarkAlerted = %v, %v, want only u2", marked, err)
+        }
+    })
+}
+
+func newIncident(title string, radius float64) *models.Incident {
+    return &models.Incident{
+        UserID:      "operator",
+        Latitude:    baseLat,
+        Longitude:   baseLng,
+        Title:       title,
+        Description: "repotest",
+        Severity:    "high",
+        Category:    models.DefaultCategory,
+        Radius:      radius,
+        Active:      true,
+    }
+}
+
+func mustCreate(t *testing.T, repo repositories.IncidentRepository, incident *models.Incident) {
+    t.Helper()
+    if err := repo.Create(context.Background(), incident); err != nil {
+        t.Fatalf("Create: %v", err)
+    }
+}
+
+func squareAround(lat, lng, delta float64) []models.GeoPoint {
+    return []models.GeoPoint{
+        {Latitude: lat - delta, Longitude: lng - delta},
+        {Latitude: lat - delta, Longitude: lng + delta},
+        {Latitude: lat + delta, Longitude: lng + delta},
+        {Latitude: lat + delta, Longitude: lng - delta},
+    }
+}
+
+func ids(incidents []*models.Incident) []int64 {
+    result := make([]int64, 0, len(incidents))
+    for _, incident := range incidents {
+        result = append(result, incident.ID)
+    }
+    return result
+}
@@ -0,0 +1,190 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/domain/repositories"
+)
+
+// MergeRepositories - хранилища, в которых объединение инцидентов переносит ссылки
+type MergeRepositories struct {
+    Incidents   repositories.IncidentRepository
+    Outbox      repositories.OutboxRepository
+    WatchZones  repositories.WatchZoneRepository
+    Reports     repositories.ReportRepository
+    Attachments repositories.AttachmentRepository
+}
+
+// IncidentMergeFactory возвращает пустое хранилище с общими для репозиториев данными
+type IncidentMergeFactory func(t *testing.T) MergeRepositories
+
+// RunIncidentMergeSuite проверяет IncidentRepository.Merge: инцидент-дубликат
+// ссылается на основной, а проверки, оповещения, сообщения, вложения и хроника переходят к основному
+func RunIncidentMergeSuite(t *testing.T, newRepos IncidentMergeFactory) {
+    t.Run("Merge", func(t *testing.T) {
+        repos := newRepos(t)
+        repo := repos.Incidents
+        ctx := context.Background()
+        now := time.Now().Truncate(time.Millisecond)
+
+        target := newIncident("Пожар", 300)
+        source := newIncident("Пожар на складе", 300)
+        source.Latitude += 0.002
+        mustCreate(t, repo, target)
+        mustCreate(t, repo, source)
+
+        check := &models.LocationCheck{UserID: "u1", Latitude: baseLat, Longitude: baseLng,
+            Timestamp: now, HasAlert: true, IncidentIDs: []int64{source.ID}}
+        if err := repo.SaveLocationCheck(ctx, check); err != nil {
+            t.Fatalf("SaveLocationCheck: %v", err)
+        }
+        // Проверка в зонах обоих инцидентов после объединения связана с основным один раз
+        both := &models.LocationCheck{UserID: "u2", Latitude: baseLat, Longitude: baseLng,
+            Timestamp: now, HasAlert: true, IncidentIDs: []int64{target.ID, source.ID}}
+        if err := repo.SaveLocationCheck(ctx, both); err != nil {
+            t.Fatalf("SaveLocationCheck: %v", err)
+        }
+        if _, err := repo.MarkAlerted(ctx, target.ID, []string{"u1"}, now); err != nil {
+            t.Fatalf("MarkAlerted: %v", err)
+        }
+        if _, err := repo.MarkAlerted(ctx, source.ID, []string{"u1", "u2"}, now); err != nil {
+            t.Fatalf("MarkAlerted: %v", err)
+        }
+
+        zone := newWatchZone("u1", "Дом", baseLat, baseLng, 500)
+        mustCreateZone(t, repos.WatchZones, zone)
+        if _, err := repos.WatchZones.MarkNotified(ctx, source.ID, []int64{zone.ID}, now); err != nil {
+            t.Fatalf("MarkNotified: %v", err)
+        }
+
+        report := newReport("u5", "10.0.0.5", now)
+        if err := repos.Reports.Create(ctx, report, models.ReportRateLimit{}); err != nil {
+            t.Fatalf("Create report: %v", err)
+        }
+        report.Status = models.ReportMerged
+        report.IncidentID = &source.ID
+        report.ModeratedAt = &now
+        if ok, err := repos.Reports.Moderate(ctx, report, models.ReportPending); err != nil || !ok {
+            t.Fatalf("Moderate = %v, %v", ok, err)
+        }
+
+        attachment := newAttachment(source.ID, "attachments/merge")
+        if err := repos.Attachments.Create(ctx, attachment); err != nil {
+            t.Fatalf("Create attachment: %v", err)
+        }
+
+        update := &models.IncidentUpdate{IncidentID: source.ID, Message: "Эвакуация объявлена", Public: true}
+        if ok, err := repo.PostUpdate(ctx, update); err != nil || !ok {
+            t.Fatalf("PostUpdate = %v, %v", ok, err)
+        }
+
+        merged := models.MergeIncidents(target, source)
+        ok, err := repo.Merge(ctx, source, merged)
+        if err != nil || !ok {
+            t.Fatalf("Merge = %v, %v", ok, err)
+        }
+        if source.Active || source.MergedInto == nil || *source.MergedInto != target.ID {
+            t.Fatalf("Merge left source %+v", source)
+        }
+
+        found, err := repo.FindByID(ctx, source.ID)
+        if err != nil || found == nil || found.Active || found.MergedInto == nil || *found.MergedInto != target.ID {
+            t.Fatalf("FindByID(source) = %+v, %v", found, err)
+        }
+        found, err = repo.FindByID(ctx, target.ID)
+        if err != nil || found == nil || found.MergedInto != nil || found.Radius <= target.Radius {
+            t.Fatalf("FindByID(target) = %+v, %v, want zone covering both", found, err)
+        }
+
+        events, err := repos.Outbox.FetchPending(ctx, time.Now(), 20)
+        if err != nil {
+            t.Fatalf("FetchPending: %v", err)
+        }
+        // Расширение зоны цели сообщается событием incident.geometry_changed
+        last := events[len(events)-3:]
+        if last[0].EventType != models.EventIncidentUpdated || last[0].IncidentID != target.ID ||
+            last[1].EventType != models.EventIncidentGeometryChanged || last[1].IncidentID != target.ID ||
+            last[2].EventType != models.EventIncidentMerged || last[2].IncidentID != source.ID {
+            t.Fatalf("last events = %s for %d, %s for %d, %s for %d", last[0].EventType, last[0].IncidentID,
+                last[1].EventType, last[1].IncidentID, last[2].EventType, last[2].IncidentID)
+        }
+
+        // Дубликат повторно не объединяется, а в него - не присоединяется
+        if ok, err := repo.Merge(ctx, source, merged); err != nil || ok {
+            t.Fatalf("second Merge = %v, %v, want false", ok, err)
+        }
+        other := newIncident("Другой", 100)
+        mustCreate(t, repo, other)
+        if ok, err := repo.Merge(ctx, other, source); err != nil || ok {
+            t.Fatalf("Merge into duplicate = %v, %v, want false", ok, err)
+        }
+
+        stats, err := repo.GetStats(ctx, 60, models.IncidentFilter{})
+        if err != nil {
+            t.Fatalf("GetStats: %v", err)
+        }
+        counted := false
+        for _, stat := range stats {
+            if stat.ZoneID != nil && *stat.ZoneID == source.ID {
+                t.Fatalf("GetStats still counts checks of merged incident: %+v", stat)
+            }
+            counted = counted || (stat.ZoneID != nil && *stat.ZoneID == target.ID && stat.UserCount == 2)
+        }
+        if !counted {
+            t.Fatalf("GetStats does not count moved checks for target: %+v", stats)
+        }
+
+        // Оповещенные об обоих инцидентах повторно не оповещаются
+        marked, err := repo.MarkAlerted(ctx, target.ID, []string{"u1", "u2", "u3"}, now)
+        if err != nil || len(marked) != 1 || marked[0] != "u3" {
+            t.Fatalf("MarkAlerted after Merge = %v, %v, want only u3", marked, err)
+        }
+        zones, err := repos.WatchZones.MarkNotified(ctx, target.ID, []int64{zone.ID}, now)
+        if err != nil || len(zones) != 0 {
+            t.Fatalf("MarkNotified after Merge = %v, %v, want none", zones, err)
+        }
+
+        moved, err := repos.Reports.FindByID(ctx, report.ID)
+        if err != nil || moved.IncidentID == nil || *moved.IncidentID != target.ID {
+            t.Fatalf("report after Merge = %+v, %v", moved, err)
+        }
+        attachments, err := repos.Attachments.FindByIncident(ctx, target.ID)
+        if err != nil || len(attachments) != 1 || attachments[0].ID != attachment.ID {
+            t.Fatalf("attachments after Merge = %d, %v", len(attachments), err)
+        }
+        latest, err := repo.LatestPublicUpdates(ctx, []int64{target.ID})
+        if err != nil || latest[target.ID] == nil || latest[target.ID].ID != update.ID {
+            t.Fatalf("LatestPublicUpdates after Merge = %v, %v", latest, err)
+        }
+        if ok, err := repo.PostUpdate(ctx, &models.IncidentUpdate{IncidentID: source.ID, Message: "Поздно"}); err != nil || ok {
+            t.Fatalf("PostUpdate to duplicate = %v, %v, want false", ok, err)
+        }
+    })
+
+    t.Run("FlattensRedirects", func(t *testing.T) {
+        repo := newRepos(t).Incidents
+        ctx := context.Background()
+
+        first := newIncident("Первый", 100)
+        second := newIncident("Второй", 100)
+        third := newIncident("Третий", 100)
+        mustCreate(t, repo, first)
+        mustCreate(t, repo, second)
+        mustCreate(t, repo, third)
+
+        if ok, err := repo.Merge(ctx, first, models.MergeIncidents(second, first)); err != nil || !ok {
+            t.Fatalf("Merge = %v, %v", ok, err)
+        }
+        if ok, err := repo.Merge(ctx, second, models.MergeIncidents(third, second)); err != nil || !ok {
+            t.Fatalf("Merge = %v, %v", ok, err)
+        }
+
+        found, err := repo.FindByID(ctx, first.ID)
+        if err != nil || found.MergedInto == nil || *found.MergedInto != third.ID {
+            t.Fatalf("FindByID(first) = %+v, %v, want redirect to %d", found, err, third.ID)
+        }
+    })
+}
@@ -0,0 +1,89 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/domain/repositories"
+)
+
+// NotificationPreferencesRepositoryFactory возвращает пустой репозиторий настроек оповещений
+type NotificationPreferencesRepositoryFactory func(t *testing.T) repositories.NotificationPreferencesRepository
+
+// RunNotificationPreferencesRepositorySuite проверяет контракт NotificationPreferencesRepository
+func RunNotificationPreferencesRepositorySuite(t *testing.T, newRepo NotificationPreferencesRepositoryFactory) {
+    t.Run("SaveGetDelete", func(t *testing.T) {
+        repo := newRepo(t)
+        ctx := context.Background()
+
+        if prefs, err := repo.Get(ctx, "u1"); err != nil || prefs != nil {
+            t.Fatalf("Get before Save = %v, %v", prefs, err)
+        }
+
+        prefs := models.DefaultNotificationPreferences("u1")
+        prefs.MinSeverity = "medium"
+        prefs.Categories = []string{"fire", "flood"}
+        prefs.TimeZone = "Europe/Moscow"
+        prefs.QuietHours = &models.QuietHours{Start: "22:00", End: "07:00"}
+        prefs.MaxAlertsPerHour = 5
+        prefs.UpdatedAt = time.Now().Truncate(time.Millisecond)
+        if err := repo.Save(ctx, prefs); err != nil {
+            t.Fatalf("Save: %v", err)
+        }
+
+        found, err := repo.Get(ctx, "u1")
+        if err != nil || found == nil {
+            t.Fatalf("Get = %v, %v", found, err)
+        }
+        if found.MinSeverity != "medium" || len(found.Categories) != 2 || found.TimeZone != "Europe/Moscow" ||
+            found.QuietHours == nil || found.QuietHours.Start != "22:00" || found.QuietHours.End != "07:00" ||
+            found.QuietHoursOverride != "high" || found.MaxAlertsPerHour != 5 || !found.UpdatedAt.Equal(prefs.UpdatedAt) {
+            t.Fatalf("Get returned %+v", found)
+        }
+
+        // Повторное сохранение заменяет настройки
+        prefs.Categories = nil
+        prefs.QuietHours = nil
+        if err := repo.Save(ctx, prefs); err != nil {
+            t.Fatalf("second Save: %v", err)
+        }
+        found, err = repo.Get(ctx, "u1")
+        if err != nil || found == nil || len(found.Categories) != 0 || found.QuietHours != nil {
+            t.Fatalf("Get after second Save = %+v, %v", found, err)
+        }
+
+        if err := repo.Delete(ctx, "u1"); err != nil {
+            t.Fatalf("Delete: %v", err)
+        }
+        if found, err := repo.Get(ctx, "u1"); err != nil || found != nil {
+            t.Fatalf("Get after Delete = %v, %v", found, err)
+        }
+    })
+
+    t.Run("ReserveAlert", func(t *testing.T) {
+        repo := newRepo(t)
+        ctx := context.Background()
+
+        now := time.Now().Truncate(time.Millisecond)
+        // Оповещение вне окна не учитывается
+        if ok, err := repo.ReserveAlert(ctx, "u1", now.Add(-3*time.Hour), 2, now.Add(-2*time.Hour)); err != nil || !ok {
+            t.Fatalf("ReserveAlert (old) = %v, %v", ok, err)
+        }
+
+        since := now.Add(-time.Hour)
+        for i := 0; i < 2; i++ {
+            if ok, err := repo.ReserveAlert(ctx, "u1", since, 2, now); err != nil || !ok {
+                t.Fatalf("ReserveAlert #%d = %v, %v", i+1, ok, err)
+            }
+        }
+        if ok, err := repo.ReserveAlert(ctx, "u1", since, 2, now); err != nil || ok {
+            t.Fatalf("ReserveAlert over limit = %v, %v, want false", ok, err)
+        }
+        // Лимит считается отдельно для каждого пользователя
+        if ok, err := repo.ReserveAlert(ctx, "u2", since, 2, now); err != nil || !ok {
+            t.Fatalf("ReserveAlert for another user = %v, %v", ok, err)
+        }
+    })
+}
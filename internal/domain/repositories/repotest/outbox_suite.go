@@ -0,0 +1,396 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/domain/repositories"
+)
+
+// OutboxRepositoryFactory возвращает пустое хранилище: репозиторий инцидентов
+// и outbox, в который он записывает события
+type OutboxRepositoryFactory func(t *testing.T) (repositories.IncidentRepository, repositories.OutboxRepository)
+
+// RunOutboxRepositorySuite проверяет контракт OutboxRepository
+func RunOutboxRepositorySuite(t *testing.T, newRepos OutboxRepositoryFactory) {
+    t.Run("WritesRecordEvents", func(t *testing.T) {
+        repo, outbox := newRepos(t)
+        ctx := context.Background()
+
+        incident := newIncident("Событие", 100)
+        mustCreate(t, repo, incident)
+
+        incident.Title = "Событие обновлено"
+        if err := repo.Update(ctx, incident); err != nil {
+            t.Fatalf("Update: %v", err)
+        }
+        if err := repo.Delete(ctx, incident.ID, 0); err != nil {
+            t.Fatalf("Delete: %v", err)
+        }
+
+        // Изменение несуществующего инцидента не порождает событий
+        missing := newIncident("Нет", 100)
+        missing.ID = incident.ID + 1000
+        _ = repo.Update(ctx, missing)
+        _ = repo.Delete(ctx, missing.ID, 0)
+
+        events, err := outbox.FetchPending(ctx, time.Now(), 10)
+        if err != nil {
+            t.Fatalf("FetchPending: %v", err)
+        }
+
+        want := []string{models.EventIncidentCreated, models.EventIncidentUpdated, models.EventIncidentDeleted}
+        if len(events) != len(want) {
+            t.Fatalf("FetchPending returned %d events, want %d", len(events), len(want))
+        }
+        for i, event := range events {
+            if event.EventType != want[i] || event.IncidentID != incident.ID {
+                t.Fatalf("event %d = %s for %d, want %s for %d", i, event.EventType, event.IncidentID, want[i], incident.ID)
+            }
+            if i > 0 && event.ID <= events[i-1].ID {
+                t.Fatalf("events are not ordered by ID: %d after %d", event.ID, events[i-1].ID)
+            }
+        }
+        if events[1].Incident == nil || events[1].Incident.Title != "Событие обновлено" {
+            t.Fatalf("update event has snapshot %+v", events[1].Incident)
+        }
+        if events[2].Incident == nil || events[2].Incident.Active {
+            t.Fatalf("delete event must carry inactive snapshot, got %+v", events[2].Incident)
+        }
+    })
+
+    t.Run("ChangedFieldsAndResolution", func(t *testing.T) {
+        repo, outbox := newRepos(t)
+        ctx := context.Background()
+
+        incident := newIncident("Зона", 100)
+        mustCreate(t, repo, incident)
+
+        incident.Radius = 500
+        if err := repo.Update(ctx, incident); err != nil {
+            t.Fatalf("Update: %v", err)
+        }
+        incident.Active = false
+        incident.Title = "Зона снята"
+        if err := repo.Update(ctx, incident); err != nil {
+            t.Fatalf("Update: %v", err)
+        }
+
+        expiresAt := time.Now().Add(-time.Minute)
+        expiring := newIncident("Срочный", 100)
+        mustCreate(t, repo, expiring)
+        expiring.ExpiresAt = &expiresAt
+        if err := repo.Update(ctx, expiring); err != nil {
+            t.Fatalf("Update: %v", err)
+        }
+        if _, err := repo.ExpireDue(ctx, time.Now()); err != nil {
+            t.Fatalf("ExpireDue: %v", err)
+        }
+
+        events, err := outbox.FetchPending(ctx, time.Now(), 10)
+        if err != nil {
+            t.Fatalf("FetchPending: %v", err)
+        }
+
+        want := []struct {
+            eventType string
+            changed   []string
+        }{
+            {models.EventIncidentCreated, nil},
+            {models.EventIncidentUpdated, []string{models.FieldRadius}},
+            {models.EventIncidentGeometryChanged, []string{models.FieldRadius}},
+            {models.EventIncidentResolved, []string{models.FieldTitle, models.FieldActive}},
+            {models.EventIncidentCreated, nil},
+            {models.EventIncidentUpdated, []string{models.FieldExpiresAt}},
+            {models.EventIncidentExpired, []string{models.FieldActive}},
+        }
+        if len(events) != len(want) {
+            t.Fatalf("FetchPending returned %d events, want %d", len(events), len(want))
+        }
+        for i, event := range events {
+            if event.EventType != want[i].eventType || !sameStrings(event.ChangedFields, want[i].changed) {
+                t.Fatalf("event %d = %s %v, want %s %v",
+                    i, event.EventType, event.ChangedFields, want[i].eventType, want[i].changed)
+            }
+        }
+        if events[6].Incident == nil || events[6].Incident.Active || events[6].Incident.ExpiresAt == nil {
+            t.Fatalf("expired event must carry inactive snapshot with expires_at, got %+v", events[6].Incident)
+        }
+    })
+
+    t.Run("GeometryChanges", func(t *testing.T) {
+        repo, outbox := newRepos(t)
+        ctx := context.Background()
+
+        incident := newIncident("Фронт пожара", 100)
+        mustCreate(t, repo, incident)
+
+        // Изменение без затрагивания зоны историю не пополняет
+        incident.Title = "Фронт пожара смещается"
+        if err := repo.Update(ctx, incident); err != nil {
+            t.Fatalf("Update: %v", err)
+        }
+        history, err := repo.FindGeometryHistory(ctx, incident.ID)
+        if err != nil || len(history) != 0 {
+            t.Fatalf("FindGeometryHistory = %+v, %v, want empty", history, err)
+        }
+
+        movedFrom := incident.Geometry()
+        incident.Latitude += 0.01
+        incident.Longitude += 0.01
+        if err := repo.Update(ctx, incident); err != nil {
+            t.Fatalf("Update(move): %v", err)
+        }
+        reshapedFrom := incident.Geometry()
+        incident.Polygon = squareAround(incident.Latitude, incident.Longitude, 0.005)
+        incident.Radius = 800
+        if err := repo.Update(ctx, incident); err != nil {
+            t.Fatalf("Update(reshape): %v", err)
+        }
+
+        history, err = repo.FindGeometryHistory(ctx, incident.ID)
+        if err != nil || len(history) != 2 {
+            t.Fatalf("FindGeometryHistory = %+v, %v, want 2 entries", history, err)
+        }
+        if history[0].Version != 3 || !history[0].IncidentGeometry.Equal(reshapedFrom) ||
+            history[1].Version != 2 || !history[1].IncidentGeometry.Equal(movedFrom) {
+            t.Fatalf("history = %+v, %+v, want zones of versions 3 and 2, latest first", history[0], history[1])
+        }
+        if history[0].IncidentID != incident.ID || history[0].ReplacedAt.IsZero() {
+            t.Fatalf("history entry = %+v", history[0])
+        }
+
+        events, err := outbox.FetchPending(ctx, time.Now(), 10)
+        if err != nil {
+            t.Fatalf("FetchPending: %v", err)
+        }
+        var changes []*models.OutboxEvent
+        for _, event := range events {
+            if event.EventType == models.EventIncidentGeometryChanged {
+                changes = append(changes, event)
+            }
+        }
+        if len(changes) != 2 {
+            t.Fatalf("got %d geometry_changed events, want 2", len(changes))
+        }
+        moved, reshaped := changes[0], changes[1]
+        if moved.Geometry == nil || !moved.Geometry.Previous.Equal(movedFrom) || !moved.Geometry.Current.Equal(reshapedFrom) ||
+            !sameStrings(moved.ChangedFields, []string{models.FieldLocation}) {
+            t.Fatalf("move event = %v %+v", moved.ChangedFields, moved.Geometry)
+        }
+        if reshaped.Geometry == nil || len(reshaped.Geometry.Current.Polygon) != 4 || reshaped.Geometry.Current.Radius != 800 ||
+            !sameStrings(reshaped.ChangedFields, []string{models.FieldRadius, models.FieldPolygon}) {
+            t.Fatalf("reshape event = %v %+v", reshaped.ChangedFields, reshaped.Geometry)
+        }
+        if reshaped.Incident == nil || reshaped.Incident.Version != 4 {
+            t.Fatalf("reshape event must carry the updated snapshot, got %+v", reshaped.Incident)
+        }
+    })
+
+    t.Run("IncidentUpdates", func(t *testing.T) {
+        repo, outbox := newRepos(t)
+        ctx := context.Background()
+
+        incident := newIncident("Перекрытие моста", 100)
+        other := newIncident("Без хроники", 100)
+        mustCreate(t, repo, incident)
+        mustCreate(t, repo, other)
+
+        posts := []*models.IncidentUpdate{
+            {IncidentID: incident.ID, Message: "Движение перекрыто", Public: true, Author: "operator-1"},
+            {IncidentID: incident.ID, Message: "Бригада на месте"},
+            {IncidentID: incident.ID, Message: "Открыто в сторону центра", Public: true},
+            {IncidentID: incident.ID, Message: "Ожидаем эвакуатор"},
+        }
+        for _, update := range posts {
+            if ok, err := repo.PostUpdate(ctx, update); err != nil || !ok || update.ID == 0 || update.CreatedAt.IsZero() {
+                t.Fatalf("PostUpdate = %v, %v, update %+v", ok, err, update)
+            }
+        }
+        if ok, err := repo.PostUpdate(ctx, &models.IncidentUpdate{IncidentID: other.ID + 1000, Message: "Нет"}); err != nil || ok {
+            t.Fatalf("PostUpdate for missing incident = %v, %v, want false", ok, err)
+        }
+
+        updates, err := repo.FindUpdates(ctx, incident.ID, false)
+        if err != nil || len(updates) != 4 || updates[0].ID != posts[3].ID || updates[3].ID != posts[0].ID {
+            t.Fatalf("FindUpdates = %d, %v, want 4 from newest", len(updates), err)
+        }
+        if updates[3].Message != "Движение перекрыто" || !updates[3].Public || updates[3].Author != "operator-1" {
+            t.Fatalf("FindUpdates returned %+v", updates[3])
+        }
+        public, err := repo.FindUpdates(ctx, incident.ID, true)
+        if err != nil || len(public) != 2 || public[0].ID != posts[2].ID {
+            t.Fatalf("FindUpdates(public) = %d, %v", len(public), err)
+        }
+
+        latest, err := repo.LatestPublicUpdates(ctx, []int64{incident.ID, other.ID})
+        if err != nil || len(latest) != 1 || latest[incident.ID] == nil || latest[incident.ID].ID != posts[2].ID {
+            t.Fatalf("LatestPublicUpdates = %v, %v, want only %d", latest, err, posts[2].ID)
+        }
+        if latest, err := repo.LatestPublicUpdates(ctx, nil); err != nil || len(latest) != 0 {
+            t.Fatalf("LatestPublicUpdates(nil) = %v, %v", latest, err)
+        }
+
+        events, err := outbox.FetchPending(ctx, time.Now(), 10)
+        if err != nil {
+            t.Fatalf("FetchPending: %v", err)
+        }
+        if len(events) != 6 {
+            t.Fatalf("FetchPending returned %d events, want 6", len(events))
+        }
+        posted := events[2]
+        if posted.EventType != models.EventIncidentUpdatePosted || posted.IncidentID != incident.ID ||
+            posted.Incident == nil || posted.Incident.Title != incident.Title {
+            t.Fatalf("update event = %s for %d with %+v", posted.EventType, posted.IncidentID, posted.Incident)
+        }
+        if posted.Update == nil || posted.Update.ID != posts[0].ID || posted.Update.Message != posts[0].Message || !posted.Update.Public {
+            t.Fatalf("update event carries %+v", posted.Update)
+        }
+    })
+
+    t.Run("DeliveryBookkeeping", func(t *testing.T) {
+        repo, outbox := newRepos(t)
+        ctx := context.Background()
+
+        mustCreate(t, repo, newIncident("Первый", 100))
+        mustCreate(t, repo, newIncident("Второй", 100))
+
+        events, _ := outbox.FetchPending(ctx, time.Now(), 10)
+        if len(events) != 2 {
+            t.Fatalf("FetchPending returned %d events, want 2", len(events))
+        }
+        first := events[0]
+
+        for i := 0; i < 2; i++ {
+            if err := outbox.MarkDelivered(ctx, first.ID, "cache"); err != nil {
+                t.Fatalf("MarkDelivered: %v", err)
+            }
+        }
+        if err := outbox.MarkFailed(ctx, first.ID, "webhook: timeout", time.Now()); err != nil {
+            t.Fatalf("MarkFailed: %v", err)
+        }
+
+        events, _ = outbox.FetchPending(ctx, time.Now(), 1)
+        if len(events) != 1 || events[0].ID != first.ID {
+            t.Fatalf("FetchPending(limit=1) must return the oldest event %d", first.ID)
+        }
+        if len(events[0].Delivered) != 1 || !events[0].DeliveredTo("cache") {
+            t.Fatalf("delivered = %v, want [cache]", events[0].Delivered)
+        }
+        if events[0].Attempts != 1 || events[0].LastError != "webhook: timeout" {
+            t.Fatalf("attempts = %d, last error = %q", events[0].Attempts, events[0].LastError)
+        }
+
+        if err := outbox.MarkPublished(ctx, first.ID); err != nil {
+            t.Fatalf("MarkPublished: %v", err)
+        }
+        events, _ = outbox.FetchPending(ctx, time.Now(), 10)
+        if len(events) != 1 || events[0].ID == first.ID {
+            t.Fatalf("published event is still pending: %d events", len(events))
+        }
+    })
+
+    t.Run("RetryBackoff", func(t *testing.T) {
+        repo, outbox := newRepos(t)
+        ctx := context.Background()
+
+        failing := newIncident("Сбой", 100)
+        mustCreate(t, repo, failing)
+        other := newIncident("Другой", 100)
+        mustCreate(t, repo, other)
+        failing.Title = "Сбой обновлен"
+        if err := repo.Update(ctx, failing); err != nil {
+            t.Fatalf("Update: %v", err)
+        }
+
+        now := time.Now()
+        events, _ := outbox.FetchPending(ctx, now, 10)
+        if len(events) != 3 {
+            t.Fatalf("FetchPending returned %d events, want 3", len(events))
+        }
+        first := events[0]
+        retryAt := now.Add(time.Minute)
+        if err := outbox.MarkFailed(ctx, first.ID, "webhook: timeout", retryAt); err != nil {
+            t.Fatalf("MarkFailed: %v", err)
+        }
+
+        // Пока повтор не наступил, событие и следующие события его инцидента ждут
+        events, err := outbox.FetchPending(ctx, now, 10)
+        if err != nil {
+            t.Fatalf("FetchPending: %v", err)
+        }
+        if len(events) != 1 || events[0].IncidentID != other.ID {
+            t.Fatalf("FetchPending before retry returned %d events, want only incident %d", len(events), other.ID)
+        }
+
+        events, _ = outbox.FetchPending(ctx, retryAt, 10)
+        if len(events) != 3 || events[0].ID != first.ID || events[2].IncidentID != failing.ID {
+            t.Fatalf("FetchPending at retry time returned %d events, want all 3 in order", len(events))
+        }
+        if events[0].Attempts != 1 || events[0].NextAttemptAt == nil ||
+            events[0].NextAttemptAt.Sub(retryAt).Abs() > time.Millisecond {
+            t.Fatalf("attempts = %d, next attempt = %v, want 1 at %v", events[0].Attempts, events[0].NextAttemptAt, retryAt)
+        }
+    })
+
+    t.Run("DeadLetter", func(t *testing.T) {
+        repo, outbox := newRepos(t)
+        ctx := context.Background()
+
+        incident := newIncident("Отравленное событие", 100)
+        mustCreate(t, repo, incident)
+        incident.Title = "Следующее событие"
+        if err := repo.Update(ctx, incident); err != nil {
+            t.Fatalf("Update: %v", err)
+        }
+
+        events, _ := outbox.FetchPending(ctx, time.Now(), 10)
+        if len(events) != 2 {
+            t.Fatalf("FetchPending returned %d events, want 2", len(events))
+        }
+        poison := events[0]
+        if err := outbox.MarkDeadLetter(ctx, poison.ID, "webhook: bad payload", time.Now()); err != nil {
+            t.Fatalf("MarkDeadLetter: %v", err)
+        }
+
+        // Событие в dead letter больше не публикуется и не задерживает инцидент
+        events, _ = outbox.FetchPending(ctx, time.Now().Add(time.Hour), 10)
+        if len(events) != 1 || events[0].ID == poison.ID || events[0].IncidentID != incident.ID {
+            t.Fatalf("FetchPending after dead letter returned %d events, want the next event only", len(events))
+        }
+    })
+
+    t.Run("Lease", func(t *testing.T) {
+        _, outbox := newRepos(t)
+        ctx := context.Background()
+
+        if ok, err := outbox.AcquireLease(ctx, "a", 200*time.Millisecond); err != nil || !ok {
+            t.Fatalf("AcquireLease(a) = %v, %v; want true", ok, err)
+        }
+        if ok, err := outbox.AcquireLease(ctx, "b", 200*time.Millisecond); err != nil || ok {
+            t.Fatalf("AcquireLease(b) while held by a = %v, %v; want false", ok, err)
+        }
+        if ok, err := outbox.AcquireLease(ctx, "a", 200*time.Millisecond); err != nil || !ok {
+            t.Fatalf("renewing lease by a = %v, %v; want true", ok, err)
+        }
+
+        time.Sleep(300 * time.Millisecond)
+        if ok, err := outbox.AcquireLease(ctx, "b", 200*time.Millisecond); err != nil || !ok {
+            t.Fatalf("AcquireLease(b) after expiry = %v, %v; want true", ok, err)
+        }
+    })
+}
+
+func sameStrings(a, b []string) bool {
+    if len(a) != len(b) {
+        return false
+    }
+    for i := range a {
+        if a[i] != b[i] {
+            return false
+        }
+    }
+    return true
+}
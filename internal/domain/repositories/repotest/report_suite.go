@@ -0,0 +1,277 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/domain/repositories"
+)
+
+// ReportRepositoryFactory возвращает пустое хранилище: репозиторий инцидентов
+// (на них ссылаются рассмотренные сообщения) и сообщений жителей
+type ReportRepositoryFactory func(t *testing.T) (repositories.IncidentRepository, repositories.ReportRepository)
+
+func newReport(userID, ip string, createdAt time.Time) *models.IncidentReport {
+    return &models.IncidentReport{
+        UserID:      userID,
+        ReporterIP:  ip,
+        Latitude:    55.75,
+        Longitude:   37.61,
+        Description: "Дым из окна третьего этажа",
+        Category:    "fire",
+        Status:      models.ReportPending,
+        CreatedAt:   createdAt,
+    }
+}
+
+// RunReportRepositorySuite проверяет контракт ReportRepository
+func RunReportRepositorySuite(t *testing.T, newRepos ReportRepositoryFactory) {
+    t.Run("CreateAndFind", func(t *testing.T) {
+        _, repo := newRepos(t)
+        ctx := context.Background()
+
+        createdAt := time.Now().Truncate(time.Millisecond)
+        report := newReport("u1", "10.0.0.1", createdAt)
+        report.Severity = "high"
+        report.AbuseScore = 0.25
+        if err := repo.Create(ctx, report, models.ReportRateLimit{}); err != nil {
+            t.Fatalf("Create: %v", err)
+        }
+        if report.ID == 0 {
+            t.Fatal("Create did not assign ID")
+        }
+
+        found, err := repo.FindByID(ctx, report.ID)
+        if err != nil || found == nil {
+            t.Fatalf("FindByID = %+v, %v", found, err)
+        }
+        if found.UserID != "u1" || found.ReporterIP != "10.0.0.1" || found.Category != "fire" ||
+            found.Severity != "high" || found.Status != models.ReportPending || found.AbuseScore != 0.25 ||
+            found.IncidentID != nil || found.ModeratedAt != nil || !found.CreatedAt.Equal(createdAt) {
+            t.Fatalf("FindByID = %+v", found)
+        }
+
+        if missing, err := repo.FindByID(ctx, report.ID+100); err != nil || missing != nil {
+            t.Fatalf("FindByID(missing) = %+v, %v", missing, err)
+        }
+    })
+
+    t.Run("RateLimit", func(t *testing.T) {
+        _, repo := newRepos(t)
+        ctx := context.Background()
+        now := time.Now()
+
+        // Сообщение за пределами окна не учитывается
+        if err := repo.Create(ctx, newReport("u1", "10.0.0.1", now.Add(-2*time.Hour)), models.ReportRateLimit{}); err != nil {
+            t.Fatalf("Create: %v", err)
+        }
+
+        limit := models.ReportRateLimit{Since: now.Add(-time.Hour), PerReporter: 2, PerAddress: 3}
+        for i := 0; i < 2; i++ {
+            if err := repo.Create(ctx, newReport("u1", "10.0.0.1", now), limit); err != nil {
+                t.Fatalf("Create #%d: %v", i+1, err)
+            }
+        }
+        if err := repo.Create(ctx, newReport("u1", "10.0.0.2", now), limit); !errors.Is(err, repositories.ErrRateLimited) {
+            t.Fatalf("Create over reporter limit = %v, want ErrRateLimited", err)
+        }
+
+        // Другой заявитель с того же адреса упирается в лимит адреса
+        if err := repo.Create(ctx, newReport("u2", "10.0.0.1", now), limit); err != nil {
+            t.Fatalf("Create(u2): %v", err)
+        }
+        if err := repo.Create(ctx, newReport("u3", "10.0.0.1", now), limit); !errors.Is(err, repositories.ErrRateLimited) {
+            t.Fatalf("Create over address limit = %v, want ErrRateLimited", err)
+        }
+        if err := repo.Create(ctx, newReport("u3", "10.0.0.9", now), limit); err != nil {
+            t.Fatalf("Create(u3, other address): %v", err)
+        }
+    })
+
+    t.Run("QueueOrderAndFilter", func(t *testing.T) {
+        _, repo := newRepos(t)
+        ctx := context.Background()
+        now := time.Now()
+
+        scores := []float64{0.5, 0, 0, 0.9}
+        ids := make([]int64, len(scores))
+        for i, score := range scores {
+            report := newReport("u1", "10.0.0.1", now.Add(time.Duration(i)*time.Second))
+            report.AbuseScore = score
+            if err := repo.Create(ctx, report, models.ReportRateLimit{}); err != nil {
+                t.Fatalf("Create: %v", err)
+            }
+            ids[i] = report.ID
+        }
+        other := newReport("u2", "10.0.0.2", now)
+        if err := repo.Create(ctx, other, models.ReportRateLimit{}); err != nil {
+            t.Fatalf("Create: %v", err)
+        }
+
+        queue, total, err := repo.FindAll(ctx, models.ReportFilter{Status: models.ReportPending, UserID: "u1", Limit: 10})
+        if err != nil || total != 4 || len(queue) != 4 {
+            t.Fatalf("FindAll(pending) = %d reports, total %d, %v", len(queue), total, err)
+        }
+        want := []int64{ids[1], ids[2], ids[0], ids[3]}
+        for i, report := range queue {
+            if report.ID != want[i] {
+                t.Fatalf("queue order = %v..., want %v", report.ID, want)
+            }
+        }
+
+        page, total, err := repo.FindAll(ctx, models.ReportFilter{Limit: 2, Offset: 1})
+        if err != nil || total != 5 || len(page) != 2 {
+            t.Fatalf("FindAll(page) = %d reports, total %d, %v", len(page), total, err)
+        }
+        // Без статуса - от новых к старым
+        if page[0].ID != ids[2] || page[1].ID != ids[1] {
+            t.Fatalf("FindAll(page) = %d, %d, want %d, %d", page[0].ID, page[1].ID, ids[2], ids[1])
+        }
+    })
+
+    t.Run("ModerateAndStats", func(t *testing.T) {
+        incidents, repo := newRepos(t)
+        ctx := context.Background()
+        now := time.Now()
+
+        incident := newIncident("Пожар", 100)
+        mustCreate(t, incidents, incident)
+
+        var reports []*models.IncidentReport
+        for i := 0; i < 4; i++ {
+            report := newReport("u1", "10.0.0.1", now)
+            if err := repo.Create(ctx, report, models.ReportRateLimit{}); err != nil {
+                t.Fatalf("Create: %v", err)
+            }
+            reports = append(reports, report)
+        }
+
+        moderatedAt := now.Truncate(time.Millisecond)
+        approved := reports[0]
+        approved.Status = models.ReportApproved
+        approved.IncidentID = &incident.ID
+        approved.Note = "подтверждено"
+        approved.ModeratedAt = &moderatedAt
+        if ok, err := repo.Moderate(ctx, approved, models.ReportPending); err != nil || !ok {
+            t.Fatalf("Moderate = %v, %v", ok, err)
+        }
+        // Рассмотренное сообщение повторно не модерируется
+        approved.Status = models.ReportRejected
+        if ok, err := repo.Moderate(ctx, approved, models.ReportPending); err != nil || ok {
+            t.Fatalf("Moderate(again) = %v, %v, want false", ok, err)
+        }
+
+        found, err := repo.FindByID(ctx, approved.ID)
+        if err != nil || found.Status != models.ReportApproved || found.IncidentID == nil ||
+            *found.IncidentID != incident.ID || found.Note != "подтверждено" ||
+            found.ModeratedAt == nil || !found.ModeratedAt.Equal(moderatedAt) {
+            t.Fatalf("FindByID after Moderate = %+v, %v", found, err)
+        }
+
+        byIncident, total, err := repo.FindAll(ctx, models.ReportFilter{IncidentID: &incident.ID, Limit: 10})
+        if err != nil || total != 1 || len(byIncident) != 1 || byIncident[0].ID != approved.ID {
+            t.Fatalf("FindAll(incident) = %d reports, total %d, %v", len(byIncident), total, err)
+        }
+
+        reports[1].Status = models.ReportRejected
+        reports[1].Abusive = true
+        reports[1].ModeratedAt = &moderatedAt
+        reports[2].Status = models.ReportRejected
+        reports[2].ModeratedAt = &moderatedAt
+        for _, report := range reports[1:3] {
+            if ok, err := repo.Moderate(ctx, report, models.ReportPending); err != nil || !ok {
+                t.Fatalf("Moderate = %v, %v", ok, err)
+            }
+        }
+
+        stats, err := repo.ReporterStats(ctx, "u1", now.Add(-time.Hour))
+        if err != nil {
+            t.Fatalf("ReporterStats: %v", err)
+        }
+        if stats.Pending != 1 || stats.Approved != 1 || stats.Rejected != 1 || stats.Abusive != 1 || stats.Merged != 0 {
+            t.Fatalf("ReporterStats = %+v", stats)
+        }
+        if stats, err := repo.ReporterStats(ctx, "u1", now.Add(time.Hour)); err != nil || stats.Pending+stats.Approved != 0 {
+            t.Fatalf("ReporterStats outside window = %+v, %v", stats, err)
+        }
+
+        address, err := repo.AddressStats(ctx, "10.0.0.1", now.Add(-time.Hour))
+        if err != nil || address.Abusive != 1 || address.Pending != 1 {
+            t.Fatalf("AddressStats = %+v, %v", address, err)
+        }
+    })
+
+    t.Run("Claim", func(t *testing.T) {
+        _, repo := newRepos(t)
+        ctx := context.Background()
+        now := time.Now().Truncate(time.Millisecond)
+
+        report := newReport("u1", "10.0.0.1", now)
+        if err := repo.Create(ctx, report, models.ReportRateLimit{}); err != nil {
+            t.Fatalf("Create: %v", err)
+        }
+
+        if ok, err := repo.Claim(ctx, report.ID, now, now.Add(-time.Minute)); err != nil || !ok {
+            t.Fatalf("Claim = %v, %v", ok, err)
+        }
+        // Захваченное сообщение не захватывается и не модерируется как ожидающее
+        if ok, err := repo.Claim(ctx, report.ID, now, now.Add(-time.Minute)); err != nil || ok {
+            t.Fatalf("Claim(again) = %v, %v, want false", ok, err)
+        }
+        rejected := *report
+        rejected.Status = models.ReportRejected
+        if ok, err := repo.Moderate(ctx, &rejected, models.ReportPending); err != nil || ok {
+            t.Fatalf("Moderate(pending) of a claimed report = %v, %v, want false", ok, err)
+        }
+        found, err := repo.FindByID(ctx, report.ID)
+        if err != nil || found.Status != models.ReportApproving || found.ModeratedAt == nil || !found.ModeratedAt.Equal(now) {
+            t.Fatalf("FindByID after Claim = %+v, %v", found, err)
+        }
+
+        // Захват, оставшийся после сбоя, перехватывается
+        later := now.Add(time.Hour)
+        if ok, err := repo.Claim(ctx, report.ID, later, now.Add(time.Minute)); err != nil || !ok {
+            t.Fatalf("Claim(stale) = %v, %v", ok, err)
+        }
+
+        pending := &models.IncidentReport{ID: report.ID, Status: models.ReportPending}
+        if ok, err := repo.Moderate(ctx, pending, models.ReportApproving); err != nil || !ok {
+            t.Fatalf("Moderate(approving) = %v, %v", ok, err)
+        }
+        found, err = repo.FindByID(ctx, report.ID)
+        if err != nil || found.Status != models.ReportPending || found.ModeratedAt != nil {
+            t.Fatalf("FindByID after release = %+v, %v", found, err)
+        }
+    })
+
+    t.Run("FindPendingNear", func(t *testing.T) {
+        _, repo := newRepos(t)
+        ctx := context.Background()
+        now := time.Now()
+
+        older := newReport("u1", "10.0.0.1", now.Add(-time.Minute))
+        newer := newReport("u2", "10.0.0.2", now)
+        stale := newReport("u3", "10.0.0.3", now.Add(-2*time.Hour))
+        far := newReport("u4", "10.0.0.4", now)
+        far.Latitude += 0.1
+        moderated := newReport("u5", "10.0.0.5", now)
+        for _, report := range []*models.IncidentReport{newer, older, stale, far, moderated} {
+            if err := repo.Create(ctx, report, models.ReportRateLimit{}); err != nil {
+                t.Fatalf("Create: %v", err)
+            }
+        }
+        moderated.Status = models.ReportRejected
+        moderated.ModeratedAt = &now
+        if ok, err := repo.Moderate(ctx, moderated, models.ReportPending); err != nil || !ok {
+            t.Fatalf("Moderate = %v, %v", ok, err)
+        }
+
+        found, err := repo.FindPendingNear(ctx, 55.75, 37.61, 1, now.Add(-time.Hour))
+        if err != nil || len(found) != 2 || found[0].ID != older.ID || found[1].ID != newer.ID {
+            t.Fatalf("FindPendingNear = %d reports, %v, want %d, %d", len(found), err, older.ID, newer.ID)
+        }
+    })
+}
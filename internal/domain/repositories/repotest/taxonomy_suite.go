@@ -0,0 +1,133 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/domain/repositories"
+)
+
+// TaxonomyRepositoryFactory возвращает чистое хранилище с исходными справочниками:
+// репозиторий инцидентов (ссылки на категории и уровни) и справочников
+type TaxonomyRepositoryFactory func(t *testing.T) (repositories.IncidentRepository, repositories.TaxonomyRepository)
+
+// RunTaxonomyRepositorySuite проверяет контракт TaxonomyRepository
+func RunTaxonomyRepositorySuite(t *testing.T, newRepos TaxonomyRepositoryFactory) {
+    t.Run("Defaults", func(t *testing.T) {
+        _, repo := newRepos(t)
+        ctx := context.Background()
+
+        categories, err := repo.ListCategories(ctx)
+        if err != nil {
+            t.Fatalf("ListCategories: %v", err)
+        }
+        if len(categories) != len(models.DefaultCategories()) {
+            t.Fatalf("ListCategories returned %d categories, want %d", len(categories), len(models.DefaultCategories()))
+        }
+        for i := 1; i < len(categories); i++ {
+            if categories[i-1].Code >= categories[i].Code {
+                t.Fatalf("categories are not ordered by code: %s before %s", categories[i-1].Code, categories[i].Code)
+            }
+        }
+
+        wildfire, err := repo.FindCategory(ctx, "fire.wildfire")
+        if err != nil || wildfire == nil || wildfire.Parent != "fire" || wildfire.Name == "" {
+            t.Fatalf("FindCategory(fire.wildfire) = %+v, %v", wildfire, err)
+        }
+        if missing, err := repo.FindCategory(ctx, "missing"); err != nil || missing != nil {
+            t.Fatalf("FindCategory(missing) = %+v, %v", missing, err)
+        }
+
+        levels, err := repo.ListSeverityLevels(ctx)
+        if err != nil {
+            t.Fatalf("ListSeverityLevels: %v", err)
+        }
+        if len(levels) != 3 || levels[0].Code != "low" || levels[1].Code != "medium" || levels[2].Code != "high" {
+            t.Fatalf("ListSeverityLevels = %+v, want low, medium, high", levels)
+        }
+    })
+
+    t.Run("SaveAndDeleteCategory", func(t *testing.T) {
+        incidents, repo := newRepos(t)
+        ctx := context.Background()
+
+        createdAt := time.Now().Truncate(time.Millisecond)
+        category := &models.Category{Code: "fire.forest_edge", Parent: "fire", Name: "Опушка", CreatedAt: createdAt}
+        if err := repo.SaveCategory(ctx, category); err != nil {
+            t.Fatalf("SaveCategory: %v", err)
+        }
+
+        category.Name = "Кромка леса"
+        category.Description = "Пожар на границе леса"
+        category.CreatedAt = createdAt.Add(time.Hour)
+        if err := repo.SaveCategory(ctx, category); err != nil {
+            t.Fatalf("SaveCategory(update): %v", err)
+        }
+        found, err := repo.FindCategory(ctx, "fire.forest_edge")
+        if err != nil || found == nil || found.Name != "Кромка леса" || found.Description == "" ||
+            !found.CreatedAt.Equal(createdAt) || found.Parent != "fire" {
+            t.Fatalf("FindCategory after update = %+v, %v", found, err)
+        }
+
+        // Родитель с подкатегориями и категория с инцидентами не удаляются
+        if err := repo.DeleteCategory(ctx, "fire"); !errors.Is(err, repositories.ErrInUse) {
+            t.Fatalf("DeleteCategory(fire) = %v, want ErrInUse", err)
+        }
+        incident := newIncident("Пожар на опушке", 100)
+        incident.Category = "fire.forest_edge"
+        mustCreate(t, incidents, incident)
+        if err := repo.DeleteCategory(ctx, "fire.forest_edge"); !errors.Is(err, repositories.ErrInUse) {
+            t.Fatalf("DeleteCategory(used) = %v, want ErrInUse", err)
+        }
+
+        if err := repo.DeleteCategory(ctx, "weather"); err != nil {
+            t.Fatalf("DeleteCategory(weather): %v", err)
+        }
+        if found, err := repo.FindCategory(ctx, "weather"); err != nil || found != nil {
+            t.Fatalf("FindCategory after delete = %+v, %v", found, err)
+        }
+    })
+
+    t.Run("SaveAndDeleteSeverityLevel", func(t *testing.T) {
+        incidents, repo := newRepos(t)
+        ctx := context.Background()
+
+        critical := &models.SeverityLevel{Code: "critical", Name: "Критический", Level: 40, Color: "#000000"}
+        if err := repo.SaveSeverityLevel(ctx, critical); err != nil {
+            t.Fatalf("SaveSeverityLevel: %v", err)
+        }
+        elevated := &models.SeverityLevel{Code: "elevated", Name: "Повышенный", Level: 25, Color: "#FF6F00"}
+        if err := repo.SaveSeverityLevel(ctx, elevated); err != nil {
+            t.Fatalf("SaveSeverityLevel: %v", err)
+        }
+        critical.Color = "#4A148C"
+        if err := repo.SaveSeverityLevel(ctx, critical); err != nil {
+            t.Fatalf("SaveSeverityLevel(update): %v", err)
+        }
+
+        levels, err := repo.ListSeverityLevels(ctx)
+        if err != nil || len(levels) != 5 {
+            t.Fatalf("ListSeverityLevels = %+v, %v", levels, err)
+        }
+        if levels[3].Code != "high" || levels[2].Code != "elevated" || levels[4].Code != "critical" || levels[4].Color != "#4A148C" {
+            t.Fatalf("levels are not ordered by level: %+v", levels)
+        }
+
+        incident := newIncident("Химический выброс", 100)
+        incident.Severity = "critical"
+        mustCreate(t, incidents, incident)
+        if err := repo.DeleteSeverityLevel(ctx, "critical"); !errors.Is(err, repositories.ErrInUse) {
+            t.Fatalf("DeleteSeverityLevel(used) = %v, want ErrInUse", err)
+        }
+
+        if err := repo.DeleteSeverityLevel(ctx, "elevated"); err != nil {
+            t.Fatalf("DeleteSeverityLevel(elevated): %v", err)
+        }
+        if levels, _ := repo.ListSeverityLevels(ctx); len(levels) != 4 {
+            t.Fatalf("ListSeverityLevels after delete returned %d levels, want 4", len(levels))
+        }
+    })
+}
@@ -0,0 +1,146 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/domain/repositories"
+)
+
+// WatchZoneRepositoryFactory возвращает пустое хранилище: репозиторий инцидентов
+// и зон наблюдения (отметки об оповещениях ссылаются на инциденты)
+type WatchZoneRepositoryFactory func(t *testing.T) (repositories.IncidentRepository, repositories.WatchZoneRepository)
+
+// RunWatchZoneRepositorySuite проверяет контракт WatchZoneRepository
+func RunWatchZoneRepositorySuite(t *testing.T, newRepos WatchZoneRepositoryFactory) {
+    t.Run("CRUD", func(t *testing.T) {
+        _, repo := newRepos(t)
+        ctx := context.Background()
+
+        zone := newWatchZone("u1", "Дом", baseLat, baseLng, 500)
+        zone.Polygon = []models.GeoPoint{
+            {Latitude: baseLat + 0.001, Longitude: baseLng},
+            {Latitude: baseLat, Longitude: baseLng + 0.001},
+            {Latitude: baseLat - 0.001, Longitude: baseLng},
+        }
+        if err := repo.Create(ctx, zone); err != nil {
+            t.Fatalf("Create: %v", err)
+        }
+        if zone.ID == 0 {
+            t.Fatal("Create did not assign ID")
+        }
+        mustCreateZone(t, repo, newWatchZone("u2", "Работа", baseLat, baseLng, 500))
+
+        found, err := repo.FindByID(ctx, zone.ID)
+        if err != nil || found == nil {
+            t.Fatalf("FindByID = %v, %v", found, err)
+        }
+        if found.Name != "Дом" || found.UserID != "u1" || len(found.Polygon) != 3 || found.MinSeverity != "low" {
+            t.Fatalf("FindByID returned %+v", found)
+        }
+
+        found.Name = "Школа"
+        found.Polygon = nil
+        found.MinSeverity = "high"
+        if err := repo.Update(ctx, found); err != nil {
+            t.Fatalf("Update: %v", err)
+        }
+        zones, err := repo.FindByUser(ctx, "u1")
+        if err != nil || len(zones) != 1 {
+            t.Fatalf("FindByUser = %d, %v", len(zones), err)
+        }
+        if zones[0].Name != "Школа" || zones[0].Polygon != nil || zones[0].MinSeverity != "high" {
+            t.Fatalf("FindByUser after Update = %+v", zones[0])
+        }
+
+        if err := repo.Delete(ctx, zone.ID); err != nil {
+            t.Fatalf("Delete: %v", err)
+        }
+        if found, err := repo.FindByID(ctx, zone.ID); err != nil || found != nil {
+            t.Fatalf("FindByID after Delete = %v, %v", found, err)
+        }
+    })
+
+    t.Run("FindIntersecting", func(t *testing.T) {
+        incidents, repo := newRepos(t)
+        ctx := context.Background()
+
+        // ~0.009 градуса широты - около 1 км
+        near := newWatchZone("u1", "Рядом", baseLat+0.009, baseLng, 600)     // пересекает круг 500 м
+        beyond := newWatchZone("u2", "Дальше", baseLat+0.018, baseLng, 600) // до центра ~2 км
+        far := newWatchZone("u3", "Далеко", baseLat+1, baseLng, 5000)
+        polygon := newWatchZone("u4", "Район", baseLat, baseLng+0.03, 100)
+        polygon.Polygon = []models.GeoPoint{
+            {Latitude: baseLat - 0.01, Longitude: baseLng + 0.005},
+            {Latitude: baseLat + 0.01, Longitude: baseLng + 0.005},
+            {Latitude: baseLat + 0.01, Longitude: baseLng + 0.05},
+            {Latitude: baseLat - 0.01, Longitude: baseLng + 0.05},
+        }
+        polygon.Radius = 3000
+        for _, zone := range []*models.WatchZone{near, beyond, far, polygon} {
+            mustCreateZone(t, repo, zone)
+        }
+
+        incident := newIncident("Пересечение", 500)
+        mustCreate(t, incidents, incident)
+        zones, err := repo.FindIntersecting(ctx, incident)
+        if err != nil {
+            t.Fatalf("FindIntersecting: %v", err)
+        }
+        if len(zones) != 2 || zones[0].ID != near.ID || zones[1].ID != polygon.ID {
+            t.Fatalf("FindIntersecting = %+v, want near and polygon zones", zones)
+        }
+    })
+
+    t.Run("MarkNotified", func(t *testing.T) {
+        incidents, repo := newRepos(t)
+        ctx := context.Background()
+
+        incident := newIncident("Оповещение", 500)
+        mustCreate(t, incidents, incident)
+        first := newWatchZone("u1", "Дом", baseLat, baseLng, 500)
+        second := newWatchZone("u2", "Дом", baseLat, baseLng, 500)
+        mustCreateZone(t, repo, first)
+        mustCreateZone(t, repo, second)
+
+        now := time.Now().Truncate(time.Millisecond)
+        marked, err := repo.MarkNotified(ctx, incident.ID, []int64{first.ID}, now)
+        if err != nil || len(marked) != 1 || marked[0] != first.ID {
+            t.Fatalf("MarkNotified = %v, %v, want first zone", marked, err)
+        }
+
+        if err := repo.Delete(ctx, second.ID); err != nil {
+            t.Fatalf("Delete: %v", err)
+        }
+        third := newWatchZone("u3", "Дом", baseLat, baseLng, 500)
+        mustCreateZone(t, repo, third)
+
+        marked, err = repo.MarkNotified(ctx, incident.ID, []int64{first.ID, second.ID, third.ID}, now)
+        if err != nil || len(marked) != 1 || marked[0] != third.ID {
+            t.Fatalf("second MarkNotified = %v, %v, want only third zone", marked, err)
+        }
+    })
+}
+
+func newWatchZone(userID, name string, lat, lng, radius float64) *models.WatchZone {
+    now := time.Now().Truncate(time.Millisecond)
+    return &models.WatchZone{
+        UserID:      userID,
+        Name:        name,
+        Latitude:    lat,
+        Longitude:   lng,
+        Radius:      radius,
+        MinSeverity: "low",
+        CreatedAt:   now,
+        UpdatedAt:   now,
+    }
+}
+
+func mustCreateZone(t *testing.T, repo repositories.WatchZoneRepository, zone *models.WatchZone) {
+    t.Helper()
+    if err := repo.Create(context.Background(), zone); err != nil {
+        t.Fatalf("Create watch zone: %v", err)
+    }
+}
@@ -0,0 +1,211 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/domain/repositories"
+)
+
+// WebhookSubscriptionRepositoryFactory возвращает пустой репозиторий подписок
+type WebhookSubscriptionRepositoryFactory func(t *testing.T) repositories.WebhookSubscriptionRepository
+
+// WebhookDeliveryRepositoryFactory возвращает пустой журнал доставок
+type WebhookDeliveryRepositoryFactory func(t *testing.T) repositories.WebhookDeliveryRepository
+
+// RunWebhookSubscriptionRepositorySuite проверяет контракт WebhookSubscriptionRepository
+func RunWebhookSubscriptionRepositorySuite(t *testing.T, newRepo WebhookSubscriptionRepositoryFactory) {
+    t.Run("CRUD", func(t *testing.T) {
+        repo := newRepo(t)
+        ctx := context.Background()
+
+        now := time.Now().Truncate(time.Millisecond)
+        subscription := &models.WebhookSubscription{
+            URL:        "https://example.com/hook",
+            EventTypes:  []string{models.EventIncidentCreated},
+            Categories:  []string{"fire"},
+            Tags:        []string{"smoke"},
+            MinSeverity: "medium",
+            Format:      models.WebhookFormatCloudEventsBinary,
+            Active:      true,
+            CreatedAt:   now,
+            UpdatedAt:   now,
+        }
+        if err := repo.Create(ctx, subscription); err != nil {
+            t.Fatalf("Create: %v", err)
+        }
+        if subscription.ID == 0 {
+            t.Fatal("Create did not assign ID")
+        }
+
+        found, err := repo.FindByID(ctx, subscription.ID)
+        if err != nil || found == nil {
+            t.Fatalf("FindByID = %v, %v", found, err)
+        }
+        if found.URL != subscription.URL || found.Format != subscription.Format ||
+            len(found.EventTypes) != 1 || !found.Accepts(models.EventIncidentCreated) ||
+            len(found.Categories) != 1 || len(found.Tags) != 1 || found.MinSeverity != "medium" {
+            t.Fatalf("FindByID returned %+v", found)
+        }
+
+        found.Active = false
+        found.MinSeverity = ""
+        found.UpdatedAt = now.Add(time.Minute)
+        if err := repo.Update(ctx, found); err != nil {
+            t.Fatalf("Update: %v", err)
+        }
+        active, err := repo.FindActive(ctx)
+        if err != nil || len(active) != 0 {
+            t.Fatalf("FindActive after deactivation = %d, %v", len(active), err)
+        }
+        all, err := repo.FindAll(ctx)
+        if err != nil || len(all) != 1 || all[0].Active || all[0].MinSeverity != "" {
+            t.Fatalf("FindAll = %+v, %v", all, err)
+        }
+
+        if err := repo.Delete(ctx, subscription.ID); err != nil {
+            t.Fatalf("Delete: %v", err)
+        }
+        if found, err := repo.FindByID(ctx, subscription.ID); err != nil || found != nil {
+            t.Fatalf("FindByID after Delete = %v, %v", found, err)
+        }
+    })
+
+    t.Run("FailureStreakAndDisable", func(t *testing.T) {
+        repo := newRepo(t)
+        ctx := context.Background()
+
+        now := time.Now().Truncate(time.Millisecond)
+        subscription := &models.WebhookSubscription{
+            URL:        "https://example.com/hook",
+            EventTypes: []string{},
+            Active:     true,
+            CreatedAt:  now,
+            UpdatedAt:  now,
+        }
+        if err := repo.Create(ctx, subscription); err != nil {
+            t.Fatalf("Create: %v", err)
+        }
+
+        // Серия сбоев начинается с первого и не сдвигается последующими
+        since, err := repo.MarkFailing(ctx, subscription.ID, now)
+        if err != nil || !since.Equal(now) {
+            t.Fatalf("MarkFailing = %v, %v, want %v", since, err, now)
+        }
+        since, err = repo.MarkFailing(ctx, subscription.ID, now.Add(time.Minute))
+        if err != nil || !since.Equal(now) {
+            t.Fatalf("second MarkFailing = %v, %v, want %v", since, err, now)
+        }
+
+        if err := repo.MarkHealthy(ctx, subscription.ID); err != nil {
+            t.Fatalf("MarkHealthy: %v", err)
+        }
+        since, err = repo.MarkFailing(ctx, subscription.ID, now.Add(time.Hour))
+        if err != nil || !since.Equal(now.Add(time.Hour)) {
+            t.Fatalf("MarkFailing after MarkHealthy = %v, %v", since, err)
+        }
+
+        disabled, err := repo.Disable(ctx, subscription.ID, "failing", now.Add(2*time.Hour))
+        if err != nil || !disabled {
+            t.Fatalf("Disable = %v, %v", disabled, err)
+        }
+        if disabled, err := repo.Disable(ctx, subscription.ID, "failing", now.Add(3*time.Hour)); err != nil || disabled {
+            t.Fatalf("second Disable = %v, %v, want false", disabled, err)
+        }
+
+        found, err := repo.FindByID(ctx, subscription.ID)
+        if err != nil || found == nil {
+            t.Fatalf("FindByID = %v, %v", found, err)
+        }
+        if found.Active || found.DisabledAt == nil || !found.DisabledAt.Equal(now.Add(2*time.Hour)) ||
+            found.DisabledReason != "failing" || found.FailingSince == nil {
+            t.Fatalf("disabled subscription = %+v", found)
+        }
+
+        // Отключенная подписка серию сбоев не меняет
+        if err := repo.MarkHealthy(ctx, subscription.ID); err != nil {
+            t.Fatalf("MarkHealthy: %v", err)
+        }
+        if found, _ := repo.FindByID(ctx, subscription.ID); found.FailingSince == nil {
+            t.Fatal("MarkHealthy reset failures of a disabled subscription")
+        }
+
+        found.Enable(now.Add(4 * time.Hour))
+        if err := repo.Update(ctx, found); err != nil {
+            t.Fatalf("Update: %v", err)
+        }
+        found, err = repo.FindByID(ctx, subscription.ID)
+        if err != nil || !found.Active || found.FailingSince != nil || found.DisabledAt != nil || found.DisabledReason != "" {
+            t.Fatalf("enabled subscription = %+v, %v", found, err)
+        }
+    })
+}
+
+// RunWebhookDeliveryRepositorySuite проверяет контракт WebhookDeliveryRepository
+func RunWebhookDeliveryRepositorySuite(t *testing.T, newRepo WebhookDeliveryRepositoryFactory) {
+    t.Run("FiltersAndOrdersNewestFirst", func(t *testing.T) {
+        repo := newRepo(t)
+        ctx := context.Background()
+
+        base := time.Now().Truncate(time.Millisecond)
+        record := func(deliveryID string, subscriptionID int64, attempt int, success bool, at time.Time) {
+            t.Helper()
+            delivery := &models.WebhookDelivery{
+                DeliveryID:     deliveryID,
+                SubscriptionID: subscriptionID,
+                EventType:      "location_alert",
+                Destination:    "https://example.com/hook",
+                Attempt:        attempt,
+                RequestHash:    "hash",
+                Success:        success,
+                Payload:        models.WebhookPayload{EventType: "location_alert", UserID: "user-1"},
+                CreatedAt:      at,
+            }
+            if err := repo.RecordAttempt(ctx, delivery); err != nil {
+                t.Fatalf("RecordAttempt: %v", err)
+            }
+        }
+
+        record("a", 1, 1, false, base)
+        record("a", 1, 2, true, base.Add(time.Second))
+        record("b", 2, 1, true, base.Add(2*time.Second))
+
+        deliveries, total, err := repo.FindDeliveries(ctx, models.DeliveryFilter{Limit: 10})
+        if err != nil || total != 3 || len(deliveries) != 3 {
+            t.Fatalf("FindDeliveries = %d of %d, %v", len(deliveries), total, err)
+        }
+        if deliveries[0].DeliveryID != "b" || deliveries[2].Attempt != 1 {
+            t.Fatalf("deliveries are not ordered newest first: %+v", deliveries)
+        }
+        if deliveries[0].Payload.UserID != "user-1" {
+            t.Fatalf("payload was not stored: %+v", deliveries[0].Payload)
+        }
+
+        subscriptionID := int64(1)
+        failed := false
+        deliveries, total, err = repo.FindDeliveries(ctx, models.DeliveryFilter{
+            SubscriptionID: &subscriptionID,
+            Success:        &failed,
+            Limit:          10,
+        })
+        if err != nil || total != 1 || deliveries[0].Attempt != 1 {
+            t.Fatalf("FindDeliveries by subscription and status = %+v, %d, %v", deliveries, total, err)
+        }
+
+        deliveries, total, err = repo.FindDeliveries(ctx, models.DeliveryFilter{
+            From:  base.Add(time.Second),
+            To:    base.Add(2 * time.Second),
+            Limit: 10,
+        })
+        if err != nil || total != 1 || deliveries[0].Attempt != 2 {
+            t.Fatalf("FindDeliveries by time range = %+v, %d, %v", deliveries, total, err)
+        }
+
+        deliveries, total, err = repo.FindDeliveries(ctx, models.DeliveryFilter{DeliveryID: "a", Limit: 1, Offset: 1})
+        if err != nil || total != 2 || len(deliveries) != 1 || deliveries[0].Attempt != 1 {
+            t.Fatalf("FindDeliveries page = %+v, %d, %v", deliveries, total, err)
+        }
+    })
+}
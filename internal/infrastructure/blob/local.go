@@ -0,0 +1,105 @@
+package blob
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"incident-system/internal/domain/repositories"
+)
+
+// localStore хранит файлы в каталоге на диске; ключ - относительный путь внутри него
+type localStore struct {
+    dir string
+}
+
+// NewLocalStore создает каталог dir, если его нет
+func NewLocalStore(dir string) (repositories.BlobStore, error) {
+    if err := os.MkdirAll(dir, 0o750); err != nil {
+        return nil, fmt.Errorf("failed to create attachment directory: %w", err)
+    }
+    return &localStore{dir: dir}, nil
+}
+
+// path переводит ключ в путь к файлу; ключи, выходящие за пределы каталога, отклоняются
+func (s *localStore) path(key string) (string, error) {
+    if err := validateKey(key); err != nil {
+        return "", err
+    }
+    return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
+
+func (s *localStore) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+    name, err := s.path(key)
+    if err != nil {
+        return err
+    }
+    if err := os.MkdirAll(filepath.Dir(name), 0o750); err != nil {
+        return err
+    }
+
+    // Запись во временный файл и переименование: читатели не видят файл недописанным
+    tmp, err := os.CreateTemp(filepath.Dir(name), ".upload-*")
+    if err != nil {
+        return err
+    }
+    defer os.Remove(tmp.Name())
+
+    written, err := io.Copy(tmp, r)
+    if err == nil && written != size {
+        err = fmt.Errorf("blob %s: wrote %d bytes, expected %d", key, written, size)
+    }
+    if err == nil {
+        err = tmp.Sync()
+    }
+    if closeErr := tmp.Close(); err == nil {
+        err = closeErr
+    }
+    if err != nil {
+        return err
+    }
+
+    return os.Rename(tmp.Name(), name)
+}
+
+func (s *localStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+    name, err := s.path(key)
+    if err != nil {
+        return nil, err
+    }
+
+    file, err := os.Open(name)
+    if os.IsNotExist(err) {
+        return nil, repositories.ErrBlobNotFound
+    }
+    return file, err
+}
+
+func (s *localStore) Delete(ctx context.Context, key string) error {
+    name, err := s.path(key)
+    if err != nil {
+        return err
+    }
+
+    if err := os.Remove(name); err != nil && !os.IsNotExist(err) {
+        return err
+    }
+    return nil
+}
+
+// validateKey допускает только относительные пути без "." и ".."
+func validateKey(key string) error {
+    if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, `\`) || path.Clean(key) != key {
+        return fmt.Errorf("invalid blob key %q", key)
+    }
+    for _, part := range strings.Split(key, "/") {
+        if part == "." || part == ".." {
+            return fmt.Errorf("invalid blob key %q", key)
+        }
+    }
+    return nil
+}
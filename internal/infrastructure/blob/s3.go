@@ -0,0 +1,244 @@
+package blob
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"incident-system/internal/domain/repositories"
+)
+
+// S3Options - подключение к S3-совместимому хранилищу (AWS S3, MinIO и т.п.)
+type S3Options struct {
+    Endpoint  string // например, http://localhost:9000 или https://s3.eu-central-1.amazonaws.com
+    Region    string
+    Bucket    string
+    AccessKey string
+    SecretKey string
+    PathStyle bool // адрес объекта endpoint/bucket/key вместо bucket.endpoint/key; нужен MinIO
+    Timeout   time.Duration
+}
+
+// unsignedPayload - тело запроса не входит в подпись: файл передается потоком,
+// а целостность проверяется по контрольной сумме в описании вложения
+const unsignedPayload = "UNSIGNED-PAYLOAD"
+
+// s3Store работает с объектами бакета через REST API S3 с подписью AWS Signature V4
+type s3Store struct {
+    client   *http.Client
+    endpoint *url.URL
+    opts     S3Options
+}
+
+func NewS3Store(opts S3Options) (repositories.BlobStore, error) {
+    endpoint, err := url.Parse(opts.Endpoint)
+    if err != nil || endpoint.Scheme == "" || endpoint.Host == "" {
+        return nil, fmt.Errorf("invalid S3 endpoint %q", opts.Endpoint)
+    }
+    if opts.Bucket == "" {
+        return nil, fmt.Errorf("S3 bucket is required")
+    }
+    if opts.Region == "" {
+        opts.Region = "us-east-1"
+    }
+
+    return &s3Store{
+        client:   &http.Client{Timeout: opts.Timeout},
+        endpoint: endpoint,
+        opts:     opts,
+    }, nil
+}
+
+// objectURL возвращает адрес объекта; ключ кодируется по правилам S3
+func (s *s3Store) objectURL(key string) *url.URL {
+    u := *s.endpoint
+    base := strings.TrimSuffix(u.Path, "/")
+    if s.opts.PathStyle {
+        base += "/" + s.opts.Bucket
+    } else {
+        u.Host = s.opts.Bucket + "." + u.Host
+    }
+    u.Path = base + "/" + key
+    u.RawPath = uriEncode(base, false) + "/" + uriEncode(key, false)
+    return &u
+}
+
+func (s *s3Store) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+    if err := validateKey(key); err != nil {
+        return err
+    }
+
+    req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(key).String(), r)
+    if err != nil {
+        return err
+    }
+    req.ContentLength = size
+    if contentType != "" {
+        req.Header.Set("Content-Type", contentType)
+    }
+
+    resp, err := s.do(req)
+    if err != nil {
+        return err
+    }
+    defer resp.Body.Close()
+
+    if resp.StatusCode != http.StatusOK {
+        return s3Error("put", key, resp)
+    }
+    return nil
+}
+
+func (s *s3Store) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+    if err := validateKey(key); err != nil {
+        return nil, err
+    }
+
+    req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.objectURL(key).String(), nil)
+    if err != nil {
+        return nil, err
+    }
+
+    resp, err := s.do(req)
+    if err != nil {
+        return nil, err
+    }
+    switch resp.StatusCode {
+    case http.StatusOK:
+        return resp.Body, nil
+    case http.StatusNotFound:
+        resp.Body.Close()
+        return nil, repositories.ErrBlobNotFound
+    }
+
+    defer resp.Body.Close()
+    return nil, s3Error("get", key, resp)
+}
+
+func (s *s3Store) Delete(ctx context.Context, key string) error {
+    if err := validateKey(key); err != nil {
+        return err
+    }
+
+    req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.objectURL(key).String(), nil)
+    if err != nil {
+        return err
+    }
+
+    resp, err := s.do(req)
+    if err != nil {
+        return err
+    }
+    defer resp.Body.Close()
+
+    // S3 отвечает 204 и на удаление отсутствующего объекта
+    if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+        return s3Error("delete", key, resp)
+    }
+    return nil
+}
+
+func (s *s3Store) do(req *http.Request) (*http.Response, error) {
+    req.Header.Set("X-Amz-Content-Sha256", unsignedPayload)
+    signV4(req, s.opts.AccessKey, s.opts.SecretKey, s.opts.Region, "s3", time.Now())
+    return s.client.Do(req)
+}
+
+// s3Error включает в ошибку начало ответа: в нем код ошибки S3 (NoSuchBucket, AccessDenied...)
+func s3Error(operation, key string, resp *http.Response) error {
+    body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+    return fmt.Errorf("s3 %s %s: %s: %s", operation, key, resp.Status, strings.TrimSpace(string(body)))
+}
+
+// signV4 подписывает запрос по AWS Signature Version 4. Подписываются host и все
+// заголовки, уже заданные в запросе; хеш тела берется из X-Amz-Content-Sha256.
+func signV4(req *http.Request, accessKey, secretKey, region, service string, now time.Time) {
+    now = now.UTC()
+    amzDate := now.Format("20060102T150405Z")
+    date := now.Format("20060102")
+    req.Header.Set("X-Amz-Date", amzDate)
+
+    headers := map[string]string{"host": req.URL.Host}
+    for name, values := range req.Header {
+        headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+    }
+    names := make([]string, 0, len(headers))
+    for name := range headers {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+
+    var canonicalHeaders strings.Builder
+    for _, name := range names {
+        canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+    }
+    signedHeaders := strings.Join(names, ";")
+
+    canonicalRequest := strings.Join([]string{
+        req.Method,
+        req.URL.EscapedPath(),
+        canonicalQuery(req.URL.Query()),
+        canonicalHeaders.String(),
+        signedHeaders,
+        req.Header.Get("X-Amz-Content-Sha256"),
+    }, "\n")
+
+    scope := date + "/" + region + "/" + service + "/aws4_request"
+    requestHash := sha256.Sum256([]byte(canonicalRequest))
+    stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+    key := hmacSHA256([]byte("AWS4"+secretKey), date)
+    key = hmacSHA256(key, region)
+    key = hmacSHA256(key, service)
+    key = hmacSHA256(key, "aws4_request")
+    signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+    req.Header.Set("Authorization", fmt.Sprintf(
+        "AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+        accessKey, scope, signedHeaders, signature,
+    ))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+    mac := hmac.New(sha256.New, key)
+    mac.Write([]byte(data))
+    return mac.Sum(nil)
+}
+
+func canonicalQuery(values url.Values) string {
+    pairs := make([]string, 0, len(values))
+    for name, list := range values {
+        for _, value := range list {
+            pairs = append(pairs, uriEncode(name, true)+"="+uriEncode(value, true))
+        }
+    }
+    sort.Strings(pairs)
+    return strings.Join(pairs, "&")
+}
+
+// uriEncode кодирует все, кроме незарезервированных символов; "/" кодируется
+// только в параметрах запроса
+func uriEncode(value string, encodeSlash bool) string {
+    var b strings.Builder
+    for i := 0; i < len(value); i++ {
+        c := value[i]
+        switch {
+        case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+            c == '-', c == '_', c == '.', c == '~':
+            b.WriteByte(c)
+        case c == '/' && !encodeSlash:
+            b.WriteByte(c)
+        default:
+            fmt.Fprintf(&b, "%%%02X", c)
+        }
+    }
+    return b.String()
+}
@@ -0,0 +1,295 @@
+package cache
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/domain/repositories"
+	"incident-system/pkg/circuitbreaker"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// LocalSnapshotCache - первый уровень кеша: декодированный снимок активных инцидентов
+// в памяти процесса. Вторым уровнем служит Redis, источником истины - база.
+//
+// Снимок из памяти отдается, только пока он не старше версии, объявленной в канале
+// InvalidationChannel, и не старше localTTL. Пока подписка не активна (старт,
+// переподключение), все чтения идут в Redis: сообщения могли быть пропущены.
+//
+// Пока автомат Redis не замкнут, Redis недоступен: отдается последний снимок
+// из памяти, пока он не старше ttl, а снимок, загруженный из базы, сохраняется
+// только в памяти. После восстановления подписки снимок сбрасывается.
+type LocalSnapshotCache struct {
+    repositories.CacheRepository // второй уровень (Redis за автоматом)
+
+    breaker  *circuitbreaker.Breaker
+    pubsub   *redis.PubSub
+    localTTL time.Duration // сколько снимок живет в памяти без обращения к Redis
+    ttl      time.Duration // срок свежести снимка с момента загрузки из базы
+
+    mu               sync.RWMutex
+    snapshot         *models.ActiveIncidentsSnapshot
+    fetchedAt        time.Time
+    knownVersion     int64
+    subscribed       bool
+    lastInvalidation time.Time
+
+    localHits     atomic.Int64
+    redisHits     atomic.Int64
+    misses        atomic.Int64
+    invalidations atomic.Int64
+
+    cancel context.CancelFunc
+    done   chan struct{}
+}
+
+// NewLocalSnapshotCache оборачивает кеш в Redis, защищенный автоматом breaker,
+// и подписывается на инвалидации
+func NewLocalSnapshotCache(
+    redisCache repositories.CacheRepository,
+    client *redis.Client,
+    breaker *circuitbreaker.Breaker,
+    localTTL time.Duration,
+    ttl time.Duration,
+) *LocalSnapshotCache {
+    ctx, cancel := context.WithCancel(context.Background())
+
+    c := &LocalSnapshotCache{
+        CacheRepository: redisCache,
+        breaker:         breaker,
+        pubsub:          client.Subscribe(ctx, InvalidationChannel),
+        localTTL:        localTTL,
+        ttl:             ttl,
+        cancel:          cancel,
+        done:            make(chan struct{}),
+    }
+
+    go c.listen(ctx)
+
+    return c
+}
+
+func (c *LocalSnapshotCache) GetActiveIncidents(ctx context.Context) (*models.ActiveIncidentsSnapshot, error) {
+    if snapshot := c.localSnapshot(); snapshot != nil {
+        c.localHits.Add(1)
+        return snapshot, nil
+    }
+
+    snapshot, err := c.CacheRepository.GetActiveIncidents(ctx)
+    if err != nil {
+        return nil, err
+    }
+    if snapshot == nil {
+        c.misses.Add(1)
+        return nil, nil
+    }
+
+    c.redisHits.Add(1)
+    if !snapshot.Stale {
+        c.store(snapshot)
+    }
+    return snapshot, nil
+}
+
+func (c *LocalSnapshotCache) SetActiveIncidents(ctx context.Context, snapshot *models.ActiveIncidentsSnapshot) (bool, error) {
+    stored, err := c.CacheRepository.SetActiveIncidents(ctx, snapshot)
+    if err != nil {
+        return false, err
+    }
+    if !stored {
+        // Без Redis версия снимка неизвестна, а снимок из базы - самый свежий
+        if c.degraded() {
+            c.storeDegraded(snapshot)
+        }
+        return false, nil
+    }
+
+    c.store(snapshot)
+    return true, nil
+}
+
+func (c *LocalSnapshotCache) InvalidateActiveIncidents(ctx context.Context) error {
+    // Свой снимок сбрасываем сразу, не дожидаясь сообщения из канала. Сброс нужен
+    // и при ошибке Redis: иначе процесс продолжит отдавать снимок до своего изменения.
+    c.mu.Lock()
+    c.snapshot = nil
+    c.mu.Unlock()
+
+    return c.CacheRepository.InvalidateActiveIncidents(ctx)
+}
+
+// CacheMetrics возвращает возраст локального снимка и счетчики попаданий
+func (c *LocalSnapshotCache) CacheMetrics() models.CacheMetrics {
+    c.mu.RLock()
+    defer c.mu.RUnlock()
+
+    metrics := models.CacheMetrics{
+        KnownVersion:  c.knownVersion,
+        Subscribed:    c.subscribed,
+        LocalHits:     c.localHits.Load(),
+        RedisHits:     c.redisHits.Load(),
+        Misses:        c.misses.Load(),
+        Invalidations: c.invalidations.Load(),
+    }
+    if c.snapshot != nil {
+        loadedAt := c.snapshot.LoadedAt
+        metrics.SnapshotVersion = c.snapshot.Version
+        metrics.SnapshotLoadedAt = &loadedAt
+        metrics.SnapshotAgeSeconds = time.Since(loadedAt).Seconds()
+    }
+    if !c.lastInvalidation.IsZero() {
+        lastInvalidation := c.lastInvalidation
+        metrics.LastInvalidationAt = &lastInvalidation
+    }
+    return metrics
+}
+
+// Close останавливает подписку на инвалидации
+func (c *LocalSnapshotCache) Close() error {
+    c.cancel()
+    err := c.pubsub.Close()
+    <-c.done
+    return err
+}
+
+// degraded сообщает, что автомат Redis не замкнут и кеш в Redis недоступен
+func (c *LocalSnapshotCache) degraded() bool {
+    return c.breaker != nil && c.breaker.State() != circuitbreaker.StateClosed
+}
+
+func (c *LocalSnapshotCache) localSnapshot() *models.ActiveIncidentsSnapshot {
+    degraded := c.degraded()
+
+    c.mu.RLock()
+    defer c.mu.RUnlock()
+
+    if c.snapshot == nil || time.Since(c.snapshot.LoadedAt) > c.ttl {
+        return nil
+    }
+    // Без Redis ни подписки, ни новых версий нет - отдаем последний снимок
+    if degraded {
+        return cloneSnapshot(c.snapshot)
+    }
+    if !c.subscribed || c.snapshot.Version < c.knownVersion || time.Since(c.fetchedAt) > c.localTTL {
+        return nil
+    }
+    return cloneSnapshot(c.snapshot)
+}
+
+func (c *LocalSnapshotCache) store(snapshot *models.ActiveIncidentsSnapshot) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+
+    // Инвалидация могла прийти, пока снимок читался из Redis или базы
+    if snapshot.Version < c.knownVersion {
+        return
+    }
+    if c.snapshot != nil && c.snapshot.Version > snapshot.Version {
+        return
+    }
+
+    c.snapshot = cloneSnapshot(snapshot)
+    c.snapshot.Stale = false
+    c.fetchedAt = time.Now()
+    c.knownVersion = snapshot.Version
+}
+
+// storeDegraded сохраняет снимок, загруженный из базы при недоступном Redis.
+// Версия счетчика в этот момент неизвестна, поэтому снимок получает последнюю
+// известную версию; после восстановления подписки он будет сброшен.
+func (c *LocalSnapshotCache) storeDegraded(snapshot *models.ActiveIncidentsSnapshot) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+
+    if c.snapshot != nil && c.snapshot.LoadedAt.After(snapshot.LoadedAt) {
+        return
+    }
+
+    c.snapshot = cloneSnapshot(snapshot)
+    c.snapshot.Stale = false
+    c.snapshot.Version = c.knownVersion
+    c.fetchedAt = time.Now()
+}
+
+func (c *LocalSnapshotCache) listen(ctx context.Context) {
+    defer close(c.done)
+
+    for {
+        msg, err := c.pubsub.Receive(ctx)
+        if err != nil {
+            if ctx.Err() != nil {
+                return
+            }
+            // go-redis переподключится и переподпишется при следующем Receive
+            log.Printf("Local cache: invalidation subscription failed: %v", err)
+            c.setSubscribed(false)
+
+            select {
+            case <-ctx.Done():
+                return
+            case <-time.After(time.Second):
+            }
+            continue
+        }
+
+        switch m := msg.(type) {
+        case *redis.Subscription:
+            c.setSubscribed(m.Kind == "subscribe")
+        case *redis.Message:
+            c.invalidate(m.Payload)
+        }
+    }
+}
+
+// setSubscribed сбрасывает снимок при восстановлении подписки: пока ее не было,
+// сообщения об инвалидации могли быть пропущены. При потере подписки снимок
+// остается для работы без Redis, но при замкнутом автомате не отдается.
+func (c *LocalSnapshotCache) setSubscribed(subscribed bool) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+
+    c.subscribed = subscribed
+    if subscribed {
+        c.snapshot = nil
+    }
+}
+
+func (c *LocalSnapshotCache) invalidate(payload string) {
+    c.invalidations.Add(1)
+
+    c.mu.Lock()
+    defer c.mu.Unlock()
+
+    c.lastInvalidation = time.Now()
+
+    version, err := strconv.ParseInt(payload, 10, 64)
+    if err != nil {
+        c.snapshot = nil
+        return
+    }
+    if version > c.knownVersion {
+        c.knownVersion = version
+    }
+    if c.snapshot != nil && c.snapshot.Version < c.knownVersion {
+        c.snapshot = nil
+    }
+}
+
+func cloneSnapshot(snapshot *models.ActiveIncidentsSnapshot) *models.ActiveIncidentsSnapshot {
+    c := *snapshot
+    c.Incidents = make([]*models.Incident, len(snapshot.Incidents))
+    for i, incident := range snapshot.Incidents {
+        copied := *incident
+        if incident.Polygon != nil {
+            copied.Polygon = append([]models.GeoPoint(nil), incident.Polygon...)
+        }
+        c.Incidents[i] = &copied
+    }
+    return &c
+}
@@ -2,74 +2,151 @@ package cache
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
-	"fmt"
+	"strconv"
 	"time"
 
-	"incident-system/internal/config"
 	"incident-system/internal/domain/models"
 	"incident-system/internal/domain/repositories"
 
 	"github.com/redis/go-redis/v9"
 )
 
+const (
+    snapshotKey        = "active_incidents"
+    snapshotVersionKey = "active_incidents:snapshot_version"
+    versionKey         = "active_incidents:version"
+    refreshLockKey     = "active_incidents:lock"
+
+    // InvalidationChannel - канал pub/sub, в который публикуется новая версия
+    // набора активных инцидентов после каждой инвалидации
+    InvalidationChannel = "active_incidents:invalidated"
+)
+
+// setSnapshotScript сохраняет снимок, только если сохраненный снимок не новее
+var setSnapshotScript = redis.NewScript(`
+local current = tonumber(redis.call('GET', KEYS[2]) or '-1')
+if current > tonumber(ARGV[1]) then
+    return 0
+end
+redis.call('SET', KEYS[1], ARGV[2], 'PX', ARGV[3])
+redis.call('SET', KEYS[2], ARGV[1], 'PX', ARGV[3])
+return 1
+`)
+
+// invalidateScript увеличивает версию и оповещает экземпляры с локальным снимком
+var invalidateScript = redis.NewScript(`
+local version = redis.call('INCR', KEYS[1])
+redis.call('PUBLISH', ARGV[1], version)
+return version
+`)
+
+// releaseLockScript снимает блокировку, только если она принадлежит владельцу токена
+var releaseLockScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+    return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
 type redisCacheRepository struct {
-    client *redis.Client
-    ttl    time.Duration
+    client   *redis.Client
+    ttl      time.Duration // срок свежести снимка
+    staleTTL time.Duration // сколько устаревший снимок может отдаваться во время обновления
 }
 
-func NewRedisCacheRepository(cfg *config.Config) (repositories.CacheRepository, error) {
-    client := redis.NewClient(&redis.Options{
-        Addr:     fmt.Sprintf("%s:%s", cfg.RedisHost, cfg.RedisPort),
-        Password: cfg.RedisPassword,
-        DB:       cfg.RedisDB,
-    })
-    
-    // Проверка подключения
-    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-    defer cancel()
-    
-    if err := client.Ping(ctx).Err(); err != nil {
-        return nil, fmt.Errorf("failed to connect to Redis: %w", err)
-    }
-    
+// NewRedisCacheRepository использует общее подключение хранилища; его доступность
+// проверяет вызывающий
+func NewRedisCacheRepository(client *redis.Client, ttl, staleTTL time.Duration) repositories.CacheRepository {
     return &redisCacheRepository{
-        client: client,
-        ttl:    time.Duration(cfg.CacheTTLMinutes) * time.Minute,
-    }, nil
+        client:   client,
+        ttl:      ttl,
+        staleTTL: staleTTL,
+    }
 }
 
-func (r *redisCacheRepository) GetActiveIncidents(ctx context.Context) ([]*models.Incident, error) {
-    key := "active_incidents"
-    
-    data, err := r.client.Get(ctx, key).Result()
-    if err == redis.Nil {
-        return nil, nil // Ключ не найден - это не ошибка
-    }
+func (r *redisCacheRepository) GetActiveIncidents(ctx context.Context) (*models.ActiveIncidentsSnapshot, error) {
+    values, err := r.client.MGet(ctx, snapshotKey, versionKey).Result()
     if err != nil {
         return nil, err
     }
-    
-    var incidents []*models.Incident
-    if err := json.Unmarshal([]byte(data), &incidents); err != nil {
+
+    data, ok := values[0].(string)
+    if !ok {
+        return nil, nil // Ключ не найден - это не ошибка
+    }
+
+    var snapshot models.ActiveIncidentsSnapshot
+    if err := json.Unmarshal([]byte(data), &snapshot); err != nil {
         return nil, err
     }
-    
-    return incidents, nil
+
+    var current int64
+    if version, ok := values[1].(string); ok {
+        if current, err = strconv.ParseInt(version, 10, 64); err != nil {
+            return nil, err
+        }
+    }
+
+    snapshot.Stale = snapshot.Version < current || time.Since(snapshot.LoadedAt) > r.ttl
+    return &snapshot, nil
 }
 
-func (r *redisCacheRepository) SetActiveIncidents(ctx context.Context, incidents []*models.Incident) error {
-    key := "active_incidents"
-    
-    data, err := json.Marshal(incidents)
+func (r *redisCacheRepository) SetActiveIncidents(ctx context.Context, snapshot *models.ActiveIncidentsSnapshot) (bool, error) {
+    data, err := json.Marshal(snapshot)
     if err != nil {
-        return err
+        return false, err
     }
-    
-    return r.client.Set(ctx, key, data, r.ttl).Err()
+
+    // Ключ живет дольше срока свежести, чтобы устаревший снимок можно было отдать во время обновления
+    expiration := r.ttl + r.staleTTL
+    stored, err := setSnapshotScript.Run(ctx, r.client,
+        []string{snapshotKey, snapshotVersionKey},
+        snapshot.Version, data, expiration.Milliseconds(),
+    ).Int()
+    if err != nil {
+        return false, err
+    }
+
+    return stored == 1, nil
+}
+
+func (r *redisCacheRepository) ActiveIncidentsVersion(ctx context.Context) (int64, error) {
+    version, err := r.client.Get(ctx, versionKey).Int64()
+    if err == redis.Nil {
+        return 0, nil
+    }
+    return version, err
 }
 
 func (r *redisCacheRepository) InvalidateActiveIncidents(ctx context.Context) error {
-    key := "active_incidents"
-    return r.client.Del(ctx, key).Err()
-}
\ No newline at end of file
+    return invalidateScript.Run(ctx, r.client, []string{versionKey}, InvalidationChannel).Err()
+}
+
+func (r *redisCacheRepository) AcquireRefreshLock(ctx context.Context, ttl time.Duration) (string, bool, error) {
+    token, err := newLockToken()
+    if err != nil {
+        return "", false, err
+    }
+
+    acquired, err := r.client.SetNX(ctx, refreshLockKey, token, ttl).Result()
+    if err != nil || !acquired {
+        return "", false, err
+    }
+
+    return token, true, nil
+}
+
+func (r *redisCacheRepository) ReleaseRefreshLock(ctx context.Context, token string) error {
+    return releaseLockScript.Run(ctx, r.client, []string{refreshLockKey}, token).Err()
+}
+
+func newLockToken() (string, error) {
+    buf := make([]byte, 16)
+    if _, err := rand.Read(buf); err != nil {
+        return "", err
+    }
+    return hex.EncodeToString(buf), nil
+}
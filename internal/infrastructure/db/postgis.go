@@ -0,0 +1,34 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	_ "embed"
+	"fmt"
+)
+
+//go:embed postgis.sql
+var postgisSchema string
+
+// postgisSchemaLock - ключ advisory-блокировки, чтобы несколько экземпляров
+// не создавали колонки и триггеры одновременно
+const postgisSchemaLock = 20260026
+
+// EnsurePostGISSchema создает расширение PostGIS, geography-колонки, триггеры
+// и GiST-индексы инцидентов и зон наблюдения. Выполняется только для
+// STORAGE_BACKEND=postgis; повторный вызов ничего не меняет.
+func EnsurePostGISSchema(ctx context.Context, db *sql.DB) error {
+    tx, err := db.BeginTx(ctx, nil)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, postgisSchemaLock); err != nil {
+        return err
+    }
+    if _, err := tx.ExecContext(ctx, postgisSchema); err != nil {
+        return fmt.Errorf("failed to apply PostGIS schema: %w", err)
+    }
+    return tx.Commit()
+}
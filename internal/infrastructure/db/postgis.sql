@@ -0,0 +1,56 @@
+-- Пространственные колонки для STORAGE_BACKEND=postgis. Применяется приложением
+-- при запуске с этим бэкендом (EnsurePostGISSchema) и повторно ничего не меняет,
+-- поэтому общие миграции migrations/*.sql не требуют расширения PostGIS.
+CREATE EXTENSION IF NOT EXISTS postgis;
+
+ALTER TABLE incidents ADD COLUMN IF NOT EXISTS location geography(Point, 4326);
+ALTER TABLE incidents ADD COLUMN IF NOT EXISTS zone geography(Polygon, 4326);
+ALTER TABLE watch_zones ADD COLUMN IF NOT EXISTS location geography(Point, 4326);
+ALTER TABLE watch_zones ADD COLUMN IF NOT EXISTS zone geography(Polygon, 4326);
+
+-- location - центр, zone - контур зоны:
+-- заданный многоугольник или круг радиуса radius вокруг центра
+CREATE OR REPLACE FUNCTION incidents_sync_geography()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.location := ST_SetSRID(ST_MakePoint(NEW.longitude, NEW.latitude), 4326)::geography;
+
+    IF NEW.polygon IS NOT NULL AND jsonb_array_length(NEW.polygon) >= 3 THEN
+        SELECT ST_MakePolygon(
+                   CASE WHEN ST_IsClosed(line) THEN line ELSE ST_AddPoint(line, ST_StartPoint(line)) END
+               )::geography
+        INTO NEW.zone
+        FROM (
+            SELECT ST_MakeLine(
+                       ST_SetSRID(ST_MakePoint((p->>'longitude')::float8, (p->>'latitude')::float8), 4326)
+                       ORDER BY ord
+                   ) AS line
+            FROM jsonb_array_elements(NEW.polygon) WITH ORDINALITY AS t(p, ord)
+        ) vertices;
+    ELSE
+        NEW.zone := ST_Buffer(NEW.location, NEW.radius::float8, 'quad_segs=16');
+    END IF;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS sync_incidents_geography ON incidents;
+CREATE TRIGGER sync_incidents_geography
+    BEFORE INSERT OR UPDATE OF latitude, longitude, radius, polygon ON incidents
+    FOR EACH ROW
+    EXECUTE FUNCTION incidents_sync_geography();
+
+DROP TRIGGER IF EXISTS sync_watch_zones_geography ON watch_zones;
+CREATE TRIGGER sync_watch_zones_geography
+    BEFORE INSERT OR UPDATE OF latitude, longitude, radius, polygon ON watch_zones
+    FOR EACH ROW
+    EXECUTE FUNCTION incidents_sync_geography();
+
+-- Заполнение строк, записанных без PostGIS
+UPDATE incidents SET latitude = latitude WHERE location IS NULL OR zone IS NULL;
+UPDATE watch_zones SET latitude = latitude WHERE location IS NULL OR zone IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_incidents_location_gist ON incidents USING GIST (location);
+CREATE INDEX IF NOT EXISTS idx_incidents_active_zone_gist ON incidents USING GIST (zone) WHERE active = true;
+CREATE INDEX IF NOT EXISTS idx_watch_zones_zone_gist ON watch_zones USING GIST (zone);
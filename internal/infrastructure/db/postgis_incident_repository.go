@@ -0,0 +1,57 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/domain/repositories"
+)
+
+// postgisIncidentRepository использует geography-колонки location и zone
+// (postgis.sql, см. EnsurePostGISSchema) и GiST-индексы для пространственных запросов.
+// Запись и остальные операции совпадают с postgresIncidentRepository:
+// геометрия заполняется триггером из latitude, longitude, radius и polygon.
+type postgisIncidentRepository struct {
+    *postgresIncidentRepository
+}
+
+func NewPostGISIncidentRepository(db *sql.DB) repositories.IncidentRepository {
+    return &postgisIncidentRepository{
+        postgresIncidentRepository: &postgresIncidentRepository{db: db},
+    }
+}
+
+func (r *postgisIncidentRepository) FindNearLocation(ctx context.Context, lat, lng float64, radiusKm float64) ([]*models.Incident, error) {
+    query := `
+        SELECT ` + incidentColumns + `
+        FROM incidents
+        WHERE active = true
+          AND ST_DWithin(zone, ST_SetSRID(ST_MakePoint($2, $1), 4326)::geography, $3)
+        ORDER BY ST_Distance(location, ST_SetSRID(ST_MakePoint($2, $1), 4326)::geography)
+    `
+
+    rows, err := r.db.QueryContext(ctx, query, lat, lng, radiusKm*1000)
+    if err != nil {
+        return nil, err
+    }
+
+    return scanIncidents(rows)
+}
+
+func (r *postgisIncidentRepository) FindContainingLocation(ctx context.Context, lat, lng float64) ([]*models.Incident, error) {
+    query := `
+        SELECT ` + incidentColumns + `
+        FROM incidents
+        WHERE active = true
+          AND ST_Intersects(zone, ST_SetSRID(ST_MakePoint($2, $1), 4326)::geography)
+        ORDER BY id
+    `
+
+    rows, err := r.db.QueryContext(ctx, query, lat, lng)
+    if err != nil {
+        return nil, err
+    }
+
+    return scanIncidents(rows)
+}
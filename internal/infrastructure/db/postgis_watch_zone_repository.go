@@ -0,0 +1,37 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/domain/repositories"
+)
+
+// postgisWatchZoneRepository сопоставляет зоны с инцидентом через ST_Intersects
+// по GiST-индексу на колонке zone (postgis.sql, см. EnsurePostGISSchema)
+type postgisWatchZoneRepository struct {
+    *postgresWatchZoneRepository
+}
+
+func NewPostGISWatchZoneRepository(db *sql.DB) repositories.WatchZoneRepository {
+    return &postgisWatchZoneRepository{
+        postgresWatchZoneRepository: &postgresWatchZoneRepository{db: db},
+    }
+}
+
+func (r *postgisWatchZoneRepository) FindIntersecting(ctx context.Context, incident *models.Incident) ([]*models.WatchZone, error) {
+    query := `
+        SELECT ` + watchZoneColumns + `
+        FROM watch_zones
+        WHERE ST_Intersects(zone, (SELECT zone FROM incidents WHERE id = $1))
+        ORDER BY id
+    `
+
+    rows, err := r.db.QueryContext(ctx, query, incident.ID)
+    if err != nil {
+        return nil, err
+    }
+
+    return scanWatchZones(rows)
+}
@@ -0,0 +1,122 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/domain/repositories"
+)
+
+const attachmentColumns = `id, incident_id, file_name, content_type, size, checksum, width, height,
+    storage_key, thumbnail_key, uploaded_by, created_at`
+
+type postgresAttachmentRepository struct {
+    db *sql.DB
+}
+
+func NewPostgresAttachmentRepository(db *sql.DB) repositories.AttachmentRepository {
+    return &postgresAttachmentRepository{db: db}
+}
+
+func scanAttachment(row rowScanner) (*models.Attachment, error) {
+    var attachment models.Attachment
+    var width, height sql.NullInt64
+    var thumbnailKey sql.NullString
+
+    if err := row.Scan(
+        &attachment.ID,
+        &attachment.IncidentID,
+        &attachment.FileName,
+        &attachment.ContentType,
+        &attachment.Size,
+        &attachment.Checksum,
+        &width,
+        &height,
+        &attachment.StorageKey,
+        &thumbnailKey,
+        &attachment.UploadedBy,
+        &attachment.CreatedAt,
+    ); err != nil {
+        return nil, err
+    }
+
+    attachment.Width = int(width.Int64)
+    attachment.Height = int(height.Int64)
+    attachment.ThumbnailKey = thumbnailKey.String
+    return &attachment, nil
+}
+
+func (r *postgresAttachmentRepository) Create(ctx context.Context, attachment *models.Attachment) error {
+    query := `
+        INSERT INTO incident_attachments (
+            incident_id, file_name, content_type, size, checksum, width, height,
+            storage_key, thumbnail_key, uploaded_by, created_at
+        ) VALUES ($1, $2, $3, $4, $5, NULLIF($6, 0), NULLIF($7, 0), $8, NULLIF($9, ''), $10, $11)
+        RETURNING id
+    `
+
+    return r.db.QueryRowContext(ctx, query,
+        attachment.IncidentID,
+        attachment.FileName,
+        attachment.ContentType,
+        attachment.Size,
+        attachment.Checksum,
+        attachment.Width,
+        attachment.Height,
+        attachment.StorageKey,
+        attachment.ThumbnailKey,
+        attachment.UploadedBy,
+        attachment.CreatedAt,
+    ).Scan(&attachment.ID)
+}
+
+func (r *postgresAttachmentRepository) FindByID(ctx context.Context, id int64) (*models.Attachment, error) {
+    query := `SELECT ` + attachmentColumns + ` FROM incident_attachments WHERE id = $1`
+
+    attachment, err := scanAttachment(r.db.QueryRowContext(ctx, query, id))
+    if err == sql.ErrNoRows {
+        return nil, nil
+    }
+
+    return attachment, err
+}
+
+func (r *postgresAttachmentRepository) FindByIncident(ctx context.Context, incidentID int64) ([]*models.Attachment, error) {
+    query := `SELECT ` + attachmentColumns + ` FROM incident_attachments WHERE incident_id = $1 ORDER BY id`
+
+    rows, err := r.db.QueryContext(ctx, query, incidentID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var attachments []*models.Attachment
+    for rows.Next() {
+        attachment, err := scanAttachment(rows)
+        if err != nil {
+            return nil, err
+        }
+        attachments = append(attachments, attachment)
+    }
+
+    return attachments, rows.Err()
+}
+
+func (r *postgresAttachmentRepository) CountByIncident(ctx context.Context, incidentID int64) (int, error) {
+    var count int
+    err := r.db.QueryRowContext(ctx,
+        `SELECT COUNT(*) FROM incident_attachments WHERE incident_id = $1`, incidentID,
+    ).Scan(&count)
+    return count, err
+}
+
+func (r *postgresAttachmentRepository) Delete(ctx context.Context, id int64) (bool, error) {
+    result, err := r.db.ExecContext(ctx, `DELETE FROM incident_attachments WHERE id = $1`, id)
+    if err != nil {
+        return false, err
+    }
+
+    affected, err := result.RowsAffected()
+    return affected > 0, err
+}
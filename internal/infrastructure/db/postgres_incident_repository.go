@@ -3,12 +3,24 @@ package db
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"incident-system/internal/domain/models"
 	"incident-system/internal/domain/repositories"
+	"incident-system/pkg/geo"
+
+	"github.com/lib/pq"
 )
 
+// incidentColumns - список колонок инцидента в порядке, ожидаемом scanIncident
+const incidentColumns = `id, user_id, latitude, longitude, title, description,
+               severity, category, tags, radius, polygon, active, expires_at, merged_into, created_at, updated_at, external_id, version, public`
+
 type postgresIncidentRepository struct {
     db *sql.DB
 }
@@ -17,217 +29,904 @@ func NewPostgresIncidentRepository(db *sql.DB) repositories.IncidentRepository {
     return &postgresIncidentRepository{db: db}
 }
 
+// rowScanner - общий интерфейс для *sql.Row и *sql.Rows
+type rowScanner interface {
+    Scan(dest ...interface{}) error
+}
+
+func scanIncident(row rowScanner) (*models.Incident, error) {
+    var incident models.Incident
+    var polygon []byte
+    var tags pq.StringArray
+    var expiresAt sql.NullTime
+
+    if err := row.Scan(
+        &incident.ID,
+        &incident.UserID,
+        &incident.Latitude,
+        &incident.Longitude,
+        &incident.Title,
+        &incident.Description,
+        &incident.Severity,
+        &incident.Category,
+        &tags,
+        &incident.Radius,
+        &polygon,
+        &incident.Active,
+        &expiresAt,
+        &incident.MergedInto,
+        &incident.CreatedAt,
+        &incident.UpdatedAt,
+        &incident.ExternalID,
+        &incident.Version,
+        &incident.Public,
+    ); err != nil {
+        return nil, err
+    }
+
+    incident.Tags = []string(tags)
+    if expiresAt.Valid {
+        incident.ExpiresAt = &expiresAt.Time
+    }
+
+    if len(polygon) > 0 {
+        if err := json.Unmarshal(polygon, &incident.Polygon); err != nil {
+            return nil, err
+        }
+    }
+
+    return &incident, nil
+}
+
+func scanIncidents(rows *sql.Rows) ([]*models.Incident, error) {
+    defer rows.Close()
+
+    var incidents []*models.Incident
+    for rows.Next() {
+        incident, err := scanIncident(rows)
+        if err != nil {
+            return nil, err
+        }
+        incidents = append(incidents, incident)
+    }
+
+    return incidents, rows.Err()
+}
+
+// encodePolygon сериализует контур зоны в JSONB (NULL для круглой зоны)
+func encodePolygon(polygon []models.GeoPoint) (interface{}, error) {
+    if len(polygon) == 0 {
+        return nil, nil
+    }
+    data, err := json.Marshal(polygon)
+    if err != nil {
+        return nil, err
+    }
+    return string(data), nil
+}
+
+// encodeTags передает теги в TEXT[]: пустой массив вместо NULL
+func encodeTags(tags []string) interface{} {
+    if tags == nil {
+        tags = []string{}
+    }
+    return pq.Array(tags)
+}
+
+// incidentFilterClause строит условие WHERE по фильтру; плейсхолдеры нумеруются
+// после уже переданных args
+func incidentFilterClause(filter models.IncidentFilter, args []interface{}) (string, []interface{}) {
+    conditions := []string{"TRUE"}
+    next := func(value interface{}) string {
+        args = append(args, value)
+        return "$" + strconv.Itoa(len(args))
+    }
+
+    if filter.ActiveOnly {
+        conditions = append(conditions, "active = true")
+    }
+    if len(filter.Categories) > 0 {
+        // Подкатегории: код родителя с точкой; '_' в LIKE экранируется
+        patterns := make([]string, len(filter.Categories))
+        for i, category := range filter.Categories {
+            patterns[i] = strings.ReplaceAll(category, "_", `\_`) + ".%"
+        }
+        conditions = append(conditions, "(category = ANY("+next(pq.Array(filter.Categories))+
+            ") OR category LIKE ANY("+next(pq.Array(patterns))+"))")
+    }
+    if len(filter.Tags) > 0 {
+        conditions = append(conditions, "tags && "+next(pq.Array(filter.Tags)))
+    }
+    if len(filter.Severities) > 0 {
+        conditions = append(conditions, "severity = ANY("+next(pq.Array(filter.Severities))+")")
+    }
+
+    return strings.Join(conditions, " AND "), args
+}
+
+// lngRangesClause - условие пересечения долгот строки [minColumn, maxColumn] с одним
+// из диапазонов ranges (у антимеридиана их два); для точки minColumn и maxColumn
+// совпадают. Плейсхолдеры нумеруются после уже переданных args.
+func lngRangesClause(minColumn, maxColumn string, ranges []geo.LngRange, args []interface{}) (string, []interface{}) {
+    conditions := make([]string, 0, len(ranges))
+    for _, lngRange := range ranges {
+        args = append(args, lngRange.Min, lngRange.Max)
+        conditions = append(conditions, fmt.Sprintf("(%s >= $%d AND %s <= $%d)", maxColumn, len(args)-1, minColumn, len(args)))
+    }
+    return "(" + strings.Join(conditions, " OR ") + ")", args
+}
+
+// withTx выполняет fn в транзакции: изменение инцидента и запись в outbox фиксируются вместе
+func (r *postgresIncidentRepository) withTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+    tx, err := r.db.BeginTx(ctx, nil)
+    if err != nil {
+        return err
+    }
+
+    if err := fn(tx); err != nil {
+        tx.Rollback()
+        return err
+    }
+
+    return tx.Commit()
+}
+
 func (r *postgresIncidentRepository) Create(ctx context.Context, incident *models.Incident) error {
+    return r.withTx(ctx, func(tx *sql.Tx) error {
+        return writeChange(ctx, tx, models.IncidentChange{Kind: models.ChangeCreate, Incident: incident}, "")
+    })
+}
+
+func (r *postgresIncidentRepository) FindByID(ctx context.Context, id int64) (*models.Incident, error) {
+    query := `
+        SELECT ` + incidentColumns + `
+        FROM incidents
+        WHERE id = $1
+    `
+
+    incident, err := scanIncident(r.db.QueryRowContext(ctx, query, id))
+    if err == sql.ErrNoRows {
+        return nil, nil
+    }
+
+    return incident, err
+}
+
+func (r *postgresIncidentRepository) FindByExternalID(ctx context.Context, externalID string) (*models.Incident, error) {
+    query := `
+        SELECT ` + incidentColumns + `
+        FROM incidents
+        WHERE external_id = $1
+    `
+
+    incident, err := scanIncident(r.db.QueryRowContext(ctx, query, externalID))
+    if err == sql.ErrNoRows {
+        return nil, nil
+    }
+
+    return incident, err
+}
+
+func (r *postgresIncidentRepository) FindAll(ctx context.Context, filter models.IncidentFilter) ([]*models.Incident, error) {
+    where, args := incidentFilterClause(filter, []interface{}{filter.Limit, filter.Offset})
+    query := `
+        SELECT ` + incidentColumns + `
+        FROM incidents
+        WHERE ` + where + `
+        ORDER BY created_at DESC
+        LIMIT $1 OFFSET $2
+    `
+
+    rows, err := r.db.QueryContext(ctx, query, args...)
+    if err != nil {
+        return nil, err
+    }
+
+    return scanIncidents(rows)
+}
+
+func (r *postgresIncidentRepository) Update(ctx context.Context, incident *models.Incident) error {
+    return r.withTx(ctx, func(tx *sql.Tx) error {
+        return writeChange(ctx, tx, models.IncidentChange{Kind: models.ChangeUpdate, Incident: incident}, "")
+    })
+}
+
+func (r *postgresIncidentRepository) Delete(ctx context.Context, id int64, version int64) error {
+    return r.withTx(ctx, func(tx *sql.Tx) error {
+        return writeChange(ctx, tx, models.IncidentChange{Kind: models.ChangeDelete, Incident: &models.Incident{ID: id, Version: version}}, "")
+    })
+}
+
+func (r *postgresIncidentRepository) ApplyBatch(ctx context.Context, batchID string, changes []models.IncidentChange) error {
+    return r.withTx(ctx, func(tx *sql.Tx) error {
+        for _, change := range changes {
+            if err := writeChange(ctx, tx, change, batchID); err != nil {
+                return err
+            }
+        }
+        return nil
+    })
+}
+
+// writeChange применяет изменение инцидента в транзакции и записывает его событие в outbox
+func writeChange(ctx context.Context, tx *sql.Tx, change models.IncidentChange, batchID string) error {
+    var event, geometryEvent *models.OutboxEvent
+    var err error
+    switch change.Kind {
+    case models.ChangeCreate:
+        event, err = insertIncident(ctx, tx, change.Incident)
+    case models.ChangeUpdate:
+        event, geometryEvent, err = updateIncident(ctx, tx, change.Incident)
+    case models.ChangeDelete:
+        event, err = deactivateIncident(ctx, tx, change.Incident)
+    default:
+        err = fmt.Errorf("unknown incident change %q", change.Kind)
+    }
+    if err != nil {
+        return err
+    }
+
+    for _, event := range []*models.OutboxEvent{event, geometryEvent} {
+        if event == nil {
+            continue
+        }
+        event.BatchID = batchID
+        if err := insertOutboxEvent(ctx, tx, event); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+func insertIncident(ctx context.Context, tx *sql.Tx, incident *models.Incident) (*models.OutboxEvent, error) {
     query := `
         INSERT INTO incidents (
-            user_id, latitude, longitude, title, description, 
-            severity, radius, active, created_at, updated_at
-        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+            user_id, latitude, longitude, title, description,
+            severity, category, tags, radius, polygon, active, expires_at, created_at, updated_at, external_id, public
+        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+        ON CONFLICT DO NOTHING
         RETURNING id
     `
-    
+
+    polygon, err := encodePolygon(incident.Polygon)
+    if err != nil {
+        return nil, err
+    }
+
     now := time.Now()
     incident.CreatedAt = now
     incident.UpdatedAt = now
-    
-    err := r.db.QueryRowContext(ctx, query,
+    incident.Version = 1
+
+    if err := tx.QueryRowContext(ctx, query,
         incident.UserID,
         incident.Latitude,
         incident.Longitude,
         incident.Title,
         incident.Description,
         incident.Severity,
+        incident.Category,
+        encodeTags(incident.Tags),
         incident.Radius,
+        polygon,
         incident.Active,
+        incident.ExpiresAt,
         incident.CreatedAt,
         incident.UpdatedAt,
-    ).Scan(&incident.ID)
-    
-    return err
+        incident.ExternalID,
+        incident.Public,
+    ).Scan(&incident.ID); err != nil {
+        // Единственное ограничение уникальности incidents - external_id
+        if err == sql.ErrNoRows {
+            return nil, repositories.ErrExternalIDExists
+        }
+        return nil, err
+    }
+
+    return models.NewOutboxEvent(models.EventIncidentCreated, incident), nil
 }
 
-func (r *postgresIncidentRepository) FindByID(ctx context.Context, id int64) (*models.Incident, error) {
+// updateIncident сохраняет инцидент и возвращает событие изменения и, если изменилась
+// зона, событие incident.geometry_changed
+func updateIncident(ctx context.Context, tx *sql.Tx, incident *models.Incident) (*models.OutboxEvent, *models.OutboxEvent, error) {
     query := `
-        SELECT id, user_id, latitude, longitude, title, description,
-               severity, radius, active, created_at, updated_at
-        FROM incidents
-        WHERE id = $1
+        UPDATE incidents
+        SET title = $1, description = $2, severity = $3, category = $4, tags = $5,
+            latitude = $6, longitude = $7, radius = $8, polygon = $9, active = $10, expires_at = $11, updated_at = $12,
+            public = $13, version = version + 1
+        WHERE id = $14
     `
-    
-    var incident models.Incident
-    err := r.db.QueryRowContext(ctx, query, id).Scan(
-        &incident.ID,
-        &incident.UserID,
-        &incident.Latitude,
-        &incident.Longitude,
-        &incident.Title,
-        &incident.Description,
-        &incident.Severity,
-        &incident.Radius,
-        &incident.Active,
-        &incident.CreatedAt,
-        &incident.UpdatedAt,
-    )
-    
+
+    polygon, err := encodePolygon(incident.Polygon)
+    if err != nil {
+        return nil, nil, err
+    }
+    incident.UpdatedAt = time.Now()
+
+    // Предыдущее состояние нужно для списка измененных полей в событии
+    before, err := scanIncident(tx.QueryRowContext(ctx,
+        `SELECT `+incidentColumns+` FROM incidents WHERE id = $1 FOR UPDATE`, incident.ID))
     if err == sql.ErrNoRows {
+        // Несуществующий инцидент не порождает событие
+        return nil, nil, nil
+    }
+    if err != nil {
+        return nil, nil, err
+    }
+    // Строка заблокирована до конца транзакции, версия не изменится до записи
+    if before.Version != incident.Version {
+        return nil, nil, repositories.ErrVersionConflict
+    }
+
+    if _, err := tx.ExecContext(ctx, query,
+        incident.Title,
+        incident.Description,
+        incident.Severity,
+        incident.Category,
+        encodeTags(incident.Tags),
+        incident.Latitude,
+        incident.Longitude,
+        incident.Radius,
+        polygon,
+        incident.Active,
+        incident.ExpiresAt,
+        incident.UpdatedAt,
+        incident.Public,
+        incident.ID,
+    ); err != nil {
+        return nil, nil, err
+    }
+
+    incident.Version++
+    geometryEvent, err := recordGeometryChange(ctx, tx, before, incident)
+    if err != nil {
+        return nil, nil, err
+    }
+    return models.NewIncidentUpdateEvent(before, incident), geometryEvent, nil
+}
+
+// recordGeometryChange сохраняет прежнюю зону before в истории и возвращает событие
+// incident.geometry_changed; nil, если зона after не отличается
+func recordGeometryChange(ctx context.Context, tx *sql.Tx, before, after *models.Incident) (*models.OutboxEvent, error) {
+    event, entry := models.NewGeometryChangedEvent(before, after)
+    if event == nil {
         return nil, nil
     }
-    
-    return &incident, err
+
+    polygon, err := encodePolygon(entry.Polygon)
+    if err != nil {
+        return nil, err
+    }
+    if _, err := tx.ExecContext(ctx, `
+        INSERT INTO incident_geometry_history (incident_id, version, latitude, longitude, radius, polygon, replaced_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+    `, entry.IncidentID, entry.Version, entry.Latitude, entry.Longitude, entry.Radius, polygon, entry.ReplacedAt); err != nil {
+        return nil, err
+    }
+
+    return event, nil
 }
 
-func (r *postgresIncidentRepository) FindAll(ctx context.Context, limit, offset int, activeOnly bool) ([]*models.Incident, error) {
+// deactivateIncident снимает активность инцидента incident.ID вместо удаления
+// и записывает в incident его состояние; incident.Version > 0 - ожидаемая версия
+func deactivateIncident(ctx context.Context, tx *sql.Tx, incident *models.Incident) (*models.OutboxEvent, error) {
     query := `
-        SELECT id, user_id, latitude, longitude, title, description,
-               severity, radius, active, created_at, updated_at
-        FROM incidents
-        WHERE ($1 = false OR active = $1)
-        ORDER BY created_at DESC
-        LIMIT $2 OFFSET $3
-    `
-    
-    rows, err := r.db.QueryContext(ctx, query, activeOnly, limit, offset)
+        UPDATE incidents SET active = false, updated_at = $1, version = version + 1
+        WHERE id = $2 AND ($3::bigint = 0 OR version = $3)
+        RETURNING ` + incidentColumns
+
+    deactivated, err := scanIncident(tx.QueryRowContext(ctx, query, time.Now(), incident.ID, incident.Version))
+    if err == sql.ErrNoRows {
+        return nil, versionConflict(ctx, tx, incident.ID)
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    *incident = *deactivated
+    return models.NewOutboxEvent(models.EventIncidentDeleted, incident), nil
+}
+
+// versionConflict объясняет, почему инцидент id не изменился: ErrVersionConflict,
+// если он есть, и nil, если его нет
+func versionConflict(ctx context.Context, tx *sql.Tx, id int64) error {
+    var exists bool
+    if err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM incidents WHERE id = $1)`, id).Scan(&exists); err != nil {
+        return err
+    }
+    if exists {
+        return repositories.ErrVersionConflict
+    }
+    return nil
+}
+
+func (r *postgresIncidentRepository) ExpireDue(ctx context.Context, now time.Time) ([]*models.Incident, error) {
+    query := `
+        UPDATE incidents SET active = false, updated_at = $1, version = version + 1
+        WHERE active = true AND expires_at <= $1
+        RETURNING ` + incidentColumns
+
+    var expired []*models.Incident
+    err := r.withTx(ctx, func(tx *sql.Tx) error {
+        rows, err := tx.QueryContext(ctx, query, now)
+        if err != nil {
+            return err
+        }
+        if expired, err = scanIncidents(rows); err != nil {
+            return err
+        }
+        sort.Slice(expired, func(i, j int) bool { return expired[i].ID < expired[j].ID })
+
+        for _, incident := range expired {
+            event := models.NewOutboxEvent(models.EventIncidentExpired, incident)
+            event.ChangedFields = []string{models.FieldActive}
+            if err := insertOutboxEvent(ctx, tx, event); err != nil {
+                return err
+            }
+        }
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return expired, nil
+}
+
+func (r *postgresIncidentRepository) Merge(ctx context.Context, source, target *models.Incident) (bool, error) {
+    polygon, err := encodePolygon(target.Polygon)
+    if err != nil {
+        return false, err
+    }
+
+    now := time.Now()
+
+    merged := false
+    err = r.withTx(ctx, func(tx *sql.Tx) error {
+        // Обе строки блокируются в порядке ID, чтобы встречные объединения не взаимоблокировались
+        if _, err := tx.ExecContext(ctx,
+            `SELECT id FROM incidents WHERE id IN ($1, $2) ORDER BY id FOR UPDATE`, source.ID, target.ID,
+        ); err != nil {
+            return err
+        }
+
+        before, err := scanIncident(tx.QueryRowContext(ctx, `SELECT `+incidentColumns+` FROM incidents WHERE id = $1`, target.ID))
+        if err == sql.ErrNoRows {
+            return nil
+        }
+        if err != nil {
+            return err
+        }
+        if before.MergedInto != nil {
+            // target сам успел стать дубликатом
+            return nil
+        }
+
+        duplicate, err := scanIncident(tx.QueryRowContext(ctx, `
+            UPDATE incidents SET active = false, merged_into = $1, updated_at = $2, version = version + 1
+            WHERE id = $3 AND merged_into IS NULL
+            RETURNING `+incidentColumns,
+            target.ID, now, source.ID,
+        ))
+        if err == sql.ErrNoRows {
+            return nil
+        }
+        if err != nil {
+            return err
+        }
+
+        if _, err := tx.ExecContext(ctx, `
+            UPDATE incidents
+            SET latitude = $1, longitude = $2, severity = $3, tags = $4, radius = $5, polygon = $6,
+                active = $7, expires_at = $8, updated_at = $9, version = version + 1
+            WHERE id = $10
+        `,
+            target.Latitude,
+            target.Longitude,
+            target.Severity,
+            encodeTags(target.Tags),
+            target.Radius,
+            polygon,
+            target.Active,
+            target.ExpiresAt,
+            now,
+            target.ID,
+        ); err != nil {
+            return err
+        }
+
+        // Ссылки на дубликат переходят к target, в том числе перенаправления
+        // ранее присоединенных к нему инцидентов
+        statements := []string{
+            `UPDATE incidents SET merged_into = $1, version = version + 1 WHERE merged_into = $2`,
+            `INSERT INTO location_check_incidents (check_id, incident_id)
+             SELECT check_id, $1 FROM location_check_incidents WHERE incident_id = $2
+             ON CONFLICT (check_id, incident_id) DO NOTHING`,
+            `INSERT INTO incident_alerts (incident_id, user_id, alerted_at)
+             SELECT $1, user_id, alerted_at FROM incident_alerts WHERE incident_id = $2
+             ON CONFLICT (incident_id, user_id) DO NOTHING`,
+            `INSERT INTO watch_zone_alerts (incident_id, zone_id, alerted_at)
+             SELECT $1, zone_id, alerted_at FROM watch_zone_alerts WHERE incident_id = $2
+             ON CONFLICT (incident_id, zone_id) DO NOTHING`,
+            `UPDATE incident_reports SET incident_id = $1 WHERE incident_id = $2`,
+            `UPDATE incident_attachments SET incident_id = $1 WHERE incident_id = $2`,
+            `UPDATE incident_updates SET incident_id = $1 WHERE incident_id = $2`,
+        }
+        for _, statement := range statements {
+            if _, err := tx.ExecContext(ctx, statement, target.ID, source.ID); err != nil {
+                return err
+            }
+        }
+        for _, statement := range []string{
+            `DELETE FROM location_check_incidents WHERE incident_id = $1`,
+            `DELETE FROM incident_alerts WHERE incident_id = $1`,
+            `DELETE FROM watch_zone_alerts WHERE incident_id = $1`,
+        } {
+            if _, err := tx.ExecContext(ctx, statement, source.ID); err != nil {
+                return err
+            }
+        }
+
+        target.UpdatedAt = now
+        target.Version = before.Version + 1
+        if err := insertOutboxEvent(ctx, tx, models.NewIncidentUpdateEvent(before, target)); err != nil {
+            return err
+        }
+        geometryEvent, err := recordGeometryChange(ctx, tx, before, target)
+        if err != nil {
+            return err
+        }
+        if geometryEvent != nil {
+            if err := insertOutboxEvent(ctx, tx, geometryEvent); err != nil {
+                return err
+            }
+        }
+        if err := insertOutboxEvent(ctx, tx, models.NewOutboxEvent(models.EventIncidentMerged, duplicate)); err != nil {
+            return err
+        }
+
+        *source = *duplicate
+        merged = true
+        return nil
+    })
+    if err != nil {
+        return false, err
+    }
+
+    return merged, nil
+}
+
+func (r *postgresIncidentRepository) FindGeometryHistory(ctx context.Context, incidentID int64) ([]*models.GeometryHistoryEntry, error) {
+    rows, err := r.db.QueryContext(ctx, `
+        SELECT id, incident_id, version, latitude, longitude, radius, polygon, replaced_at
+        FROM incident_geometry_history
+        WHERE incident_id = $1
+        ORDER BY id DESC
+    `, incidentID)
     if err != nil {
         return nil, err
     }
     defer rows.Close()
-    
-    var incidents []*models.Incident
+
+    var history []*models.GeometryHistoryEntry
     for rows.Next() {
-        var incident models.Incident
+        var entry models.GeometryHistoryEntry
+        var polygon []byte
         if err := rows.Scan(
-            &incident.ID,
-            &incident.UserID,
-            &incident.Latitude,
-            &incident.Longitude,
-            &incident.Title,
-            &incident.Description,
-            &incident.Severity,
-            &incident.Radius,
-            &incident.Active,
-            &incident.CreatedAt,
-            &incident.UpdatedAt,
+            &entry.ID,
+            &entry.IncidentID,
+            &entry.Version,
+            &entry.Latitude,
+            &entry.Longitude,
+            &entry.Radius,
+            &polygon,
+            &entry.ReplacedAt,
         ); err != nil {
             return nil, err
         }
-        incidents = append(incidents, &incident)
+        if len(polygon) > 0 {
+            if err := json.Unmarshal(polygon, &entry.Polygon); err != nil {
+                return nil, err
+            }
+        }
+        history = append(history, &entry)
     }
-    
-    return incidents, nil
+
+    return history, rows.Err()
 }
 
-func (r *postgresIncidentRepository) Update(ctx context.Context, incident *models.Incident) error {
+const incidentUpdateColumns = `id, incident_id, message, public, author, created_at`
+
+func scanIncidentUpdates(rows *sql.Rows) ([]*models.IncidentUpdate, error) {
+    defer rows.Close()
+
+    var updates []*models.IncidentUpdate
+    for rows.Next() {
+        var update models.IncidentUpdate
+        if err := rows.Scan(
+            &update.ID,
+            &update.IncidentID,
+            &update.Message,
+            &update.Public,
+            &update.Author,
+            &update.CreatedAt,
+        ); err != nil {
+            return nil, err
+        }
+        updates = append(updates, &update)
+    }
+
+    return updates, rows.Err()
+}
+
+func (r *postgresIncidentRepository) PostUpdate(ctx context.Context, update *models.IncidentUpdate) (bool, error) {
+    update.CreatedAt = time.Now()
+
+    var posted bool
+    err := r.withTx(ctx, func(tx *sql.Tx) error {
+        // Снимок инцидента нужен для события; блокировка не дает присоединить
+        // инцидент к другому, пока сообщение не записано
+        incident, err := scanIncident(tx.QueryRowContext(ctx,
+            `SELECT `+incidentColumns+` FROM incidents WHERE id = $1 AND merged_into IS NULL FOR SHARE`, update.IncidentID))
+        if err == sql.ErrNoRows {
+            return nil
+        }
+        if err != nil {
+            return err
+        }
+
+        if err := tx.QueryRowContext(ctx, `
+            INSERT INTO incident_updates (incident_id, message, public, author, created_at)
+            VALUES ($1, $2, $3, $4, $5)
+            RETURNING id
+        `, update.IncidentID, update.Message, update.Public, update.Author, update.CreatedAt).Scan(&update.ID); err != nil {
+            return err
+        }
+
+        if err := insertOutboxEvent(ctx, tx, models.NewUpdatePostedEvent(incident, update)); err != nil {
+            return err
+        }
+        posted = true
+        return nil
+    })
+    if err != nil {
+        return false, err
+    }
+
+    return posted, nil
+}
+
+func (r *postgresIncidentRepository) FindUpdates(ctx context.Context, incidentID int64, publicOnly bool) ([]*models.IncidentUpdate, error) {
+    query := `SELECT ` + incidentUpdateColumns + ` FROM incident_updates WHERE incident_id = $1`
+    if publicOnly {
+        query += ` AND public = true`
+    }
+    query += ` ORDER BY id DESC`
+
+    rows, err := r.db.QueryContext(ctx, query, incidentID)
+    if err != nil {
+        return nil, err
+    }
+
+    return scanIncidentUpdates(rows)
+}
+
+func (r *postgresIncidentRepository) LatestPublicUpdates(ctx context.Context, incidentIDs []int64) (map[int64]*models.IncidentUpdate, error) {
+    latest := make(map[int64]*models.IncidentUpdate)
+    if len(incidentIDs) == 0 {
+        return latest, nil
+    }
+
     query := `
-        UPDATE incidents 
-        SET title = $1, description = $2, severity = $3, 
-            radius = $4, active = $5, updated_at = $6
-        WHERE id = $7
+        SELECT DISTINCT ON (incident_id) ` + incidentUpdateColumns + `
+        FROM incident_updates
+        WHERE public = true AND incident_id = ANY($1)
+        ORDER BY incident_id, id DESC
     `
-    
-    incident.UpdatedAt = time.Now()
-    _, err := r.db.ExecContext(ctx, query,
-        incident.Title,
-        incident.Description,
-        incident.Severity,
-        incident.Radius,
-        incident.Active,
-        incident.UpdatedAt,
-        incident.ID,
-    )
-    
-    return err
-}
 
-func (r *postgresIncidentRepository) Delete(ctx context.Context, id int64) error {
-    // Деактивация вместо удаления
-    query := `UPDATE incidents SET active = false, updated_at = $1 WHERE id = $2`
-    _, err := r.db.ExecContext(ctx, query, time.Now(), id)
-    return err
+    rows, err := r.db.QueryContext(ctx, query, pq.Array(incidentIDs))
+    if err != nil {
+        return nil, err
+    }
+
+    updates, err := scanIncidentUpdates(rows)
+    if err != nil {
+        return nil, err
+    }
+    for _, update := range updates {
+        latest[update.IncidentID] = update
+    }
+    return latest, nil
 }
 
 func (r *postgresIncidentRepository) FindNearLocation(ctx context.Context, lat, lng float64, radiusKm float64) ([]*models.Incident, error) {
-    // Используем формулу гаверсинусов для расчета расстояния
+    // Предварительный отбор по описанной окружности зоны (формула гаверсинусов),
+    // точная проверка для многоугольников выполняется ниже
     query := `
-        SELECT id, user_id, latitude, longitude, title, description,
-               severity, radius, active, created_at, updated_at,
-               (6371 * acos(
-                   cos(radians($1)) * cos(radians(latitude)) * 
-                   cos(radians(longitude) - radians($2)) + 
-                   sin(radians($1)) * sin(radians(latitude))
-               )) as distance
-        FROM incidents
-        WHERE active = true
-        HAVING distance <= $3
+        SELECT ` + incidentColumns + `
+        FROM (
+            SELECT *,
+                   (6371 * acos(LEAST(1.0,
+                       cos(radians($1)) * cos(radians(latitude)) *
+                       cos(radians(longitude) - radians($2)) +
+                       sin(radians($1)) * sin(radians(latitude))
+                   ))) AS distance
+            FROM incidents
+            WHERE active = true
+        ) candidates
+        WHERE distance <= $3 + radius / 1000.0
         ORDER BY distance
     `
-    
+
     rows, err := r.db.QueryContext(ctx, query, lat, lng, radiusKm)
     if err != nil {
         return nil, err
     }
-    defer rows.Close()
-    
+
+    candidates, err := scanIncidents(rows)
+    if err != nil {
+        return nil, err
+    }
+
     var incidents []*models.Incident
-    for rows.Next() {
-        var incident models.Incident
-        var distance float64
-        
-        if err := rows.Scan(
-            &incident.ID,
-            &incident.UserID,
-            &incident.Latitude,
-            &incident.Longitude,
-            &incident.Title,
-            &incident.Description,
-            &incident.Severity,
-            &incident.Radius,
-            &incident.Active,
-            &incident.CreatedAt,
-            &incident.UpdatedAt,
-            &distance,
-        ); err != nil {
-            return nil, err
+    for _, incident := range candidates {
+        if incident.DistanceToZoneKm(lat, lng) <= radiusKm {
+            incidents = append(incidents, incident)
         }
-        incidents = append(incidents, &incident)
     }
-    
+
+    return incidents, nil
+}
+
+func (r *postgresIncidentRepository) FindContainingLocation(ctx context.Context, lat, lng float64) ([]*models.Incident, error) {
+    candidates, err := r.FindNearLocation(ctx, lat, lng, 0)
+    if err != nil {
+        return nil, err
+    }
+
+    var incidents []*models.Incident
+    for _, incident := range candidates {
+        if incident.Contains(lat, lng) {
+            incidents = append(incidents, incident)
+        }
+    }
+
     return incidents, nil
 }
 
 func (r *postgresIncidentRepository) SaveLocationCheck(ctx context.Context, check *models.LocationCheck) error {
     query := `
-        INSERT INTO location_checks (user_id, latitude, longitude, timestamp, has_alert, incident_id)
-        VALUES ($1, $2, $3, $4, $5, $6)
-        RETURNING id
+        WITH inserted AS (
+            INSERT INTO location_checks (user_id, latitude, longitude, timestamp, has_alert)
+            VALUES ($1, $2, $3, $4, $5)
+            RETURNING id
+        ), linked AS (
+            INSERT INTO location_check_incidents (check_id, incident_id)
+            SELECT inserted.id, incident_id FROM inserted, unnest($6::bigint[]) AS incident_id
+        )
+        SELECT id FROM inserted
     `
-    
+
     return r.db.QueryRowContext(ctx, query,
         check.UserID,
         check.Latitude,
         check.Longitude,
         check.Timestamp,
         check.HasAlert,
-        check.IncidentID, // Может быть NULL
+        pq.Array(check.IncidentIDs),
     ).Scan(&check.ID)
 }
 
-func (r *postgresIncidentRepository) GetStats(ctx context.Context, minutes int) ([]*models.IncidentStats, error) {
+func (r *postgresIncidentRepository) FindLatestLocations(ctx context.Context, since time.Time, lat, lng, radiusKm float64) ([]*models.LocationCheck, error) {
+    minLat, _, maxLat, _ := geo.BoundingBox(lat, lng, radiusKm)
+    lngCondition, args := lngRangesClause("longitude", "longitude", geo.LngRanges(lat, lng, radiusKm),
+        []interface{}{since, minLat, maxLat})
+    query := `
+        SELECT id, user_id, latitude, longitude, timestamp, has_alert
+        FROM (
+            SELECT DISTINCT ON (user_id) *
+            FROM location_checks
+            WHERE timestamp >= $1
+            ORDER BY user_id, timestamp DESC, id DESC
+        ) latest
+        WHERE latitude BETWEEN $2 AND $3
+          AND ` + lngCondition + `
+        ORDER BY user_id
+    `
+
+    rows, err := r.db.QueryContext(ctx, query, args...)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var checks []*models.LocationCheck
+    for rows.Next() {
+        var check models.LocationCheck
+        if err := rows.Scan(
+            &check.ID,
+            &check.UserID,
+            &check.Latitude,
+            &check.Longitude,
+            &check.Timestamp,
+            &check.HasAlert,
+        ); err != nil {
+            return nil, err
+        }
+        checks = append(checks, &check)
+    }
+
+    return checks, rows.Err()
+}
+
+func (r *postgresIncidentRepository) MarkAlerted(ctx context.Context, incidentID int64, userIDs []string, at time.Time) ([]string, error) {
+    query := `
+        INSERT INTO incident_alerts (incident_id, user_id, alerted_at)
+        SELECT $1, user_id, $3 FROM unnest($2::text[]) AS user_id
+        ON CONFLICT (incident_id, user_id) DO NOTHING
+        RETURNING user_id
+    `
+
+    rows, err := r.db.QueryContext(ctx, query, incidentID, pq.Array(userIDs), at)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var marked []string
+    for rows.Next() {
+        var userID string
+        if err := rows.Scan(&userID); err != nil {
+            return nil, err
+        }
+        marked = append(marked, userID)
+    }
+
+    return marked, rows.Err()
+}
+
+func (r *postgresIncidentRepository) UnmarkAlerted(ctx context.Context, incidentID int64, userIDs []string) error {
+    query := `DELETE FROM incident_alerts WHERE incident_id = $1 AND user_id = ANY($2::text[])`
+
+    _, err := r.db.ExecContext(ctx, query, incidentID, pq.Array(userIDs))
+    return err
+}
+
+func (r *postgresIncidentRepository) GetStats(ctx context.Context, minutes int, filter models.IncidentFilter) ([]*models.IncidentStats, error) {
     // Используем COALESCE для обработки NULL значений
     query := `
-        SELECT 
-            COALESCE(incident_id, 0) as zone_id,
-            COUNT(DISTINCT user_id) as user_count
-        FROM location_checks
-        WHERE timestamp >= NOW() - ($1 || ' minutes')::INTERVAL
-        GROUP BY COALESCE(incident_id, 0)
+        SELECT
+            COALESCE(lci.incident_id, 0) as zone_id,
+            COUNT(DISTINCT lc.user_id) as user_count
+        FROM location_checks lc
+        LEFT JOIN location_check_incidents lci ON lci.check_id = lc.id
+        WHERE lc.timestamp >= NOW() - ($1 || ' minutes')::INTERVAL
+        GROUP BY COALESCE(lci.incident_id, 0)
     `
-    
-    rows, err := r.db.QueryContext(ctx, query, minutes)
+    args := []interface{}{minutes}
+
+    if !filter.IsEmpty() {
+        var where string
+        where, args = incidentFilterClause(filter, args)
+        query = `
+            SELECT
+                lci.incident_id as zone_id,
+                COUNT(DISTINCT lc.user_id) as user_count
+            FROM location_checks lc
+            JOIN location_check_incidents lci ON lci.check_id = lc.id
+            JOIN incidents ON incidents.id = lci.incident_id
+            WHERE lc.timestamp >= NOW() - ($1 || ' minutes')::INTERVAL
+              AND ` + where + `
+            GROUP BY lci.incident_id
+        `
+    }
+
+    rows, err := r.db.QueryContext(ctx, query, args...)
     if err != nil {
         return nil, err
     }
     defer rows.Close()
-    
+
     var stats []*models.IncidentStats
     for rows.Next() {
         var stat models.IncidentStats
@@ -240,53 +939,31 @@ func (r *postgresIncidentRepository) GetStats(ctx context.Context, minutes int)
         }
         stats = append(stats, &stat)
     }
-    
+
     return stats, nil
 }
 
 func (r *postgresIncidentRepository) GetActiveIncidents(ctx context.Context) ([]*models.Incident, error) {
     query := `
-        SELECT id, user_id, latitude, longitude, title, description,
-               severity, radius, active, created_at, updated_at
+        SELECT ` + incidentColumns + `
         FROM incidents
         WHERE active = true
         ORDER BY id
     `
-    
+
     rows, err := r.db.QueryContext(ctx, query)
     if err != nil {
         return nil, err
     }
-    defer rows.Close()
-    
-    var incidents []*models.Incident
-    for rows.Next() {
-        var incident models.Incident
-        if err := rows.Scan(
-            &incident.ID,
-            &incident.UserID,
-            &incident.Latitude,
-            &incident.Longitude,
-            &incident.Title,
-            &incident.Description,
-            &incident.Severity,
-            &incident.Radius,
-            &incident.Active,
-            &incident.CreatedAt,
-            &incident.UpdatedAt,
-        ); err != nil {
-            return nil, err
-        }
-        incidents = append(incidents, &incident)
-    }
-    
-    return incidents, nil
+
+    return scanIncidents(rows)
 }
 
-func (r *postgresIncidentRepository) CountAll(ctx context.Context, activeOnly bool) (int, error) {
-    query := `SELECT COUNT(*) FROM incidents WHERE ($1 = false OR active = $1)`
-    
+func (r *postgresIncidentRepository) CountAll(ctx context.Context, filter models.IncidentFilter) (int, error) {
+    where, args := incidentFilterClause(filter, nil)
+    query := `SELECT COUNT(*) FROM incidents WHERE ` + where
+
     var count int
-    err := r.db.QueryRowContext(ctx, query, activeOnly).Scan(&count)
+    err := r.db.QueryRowContext(ctx, query, args...).Scan(&count)
     return count, err
-}
\ No newline at end of file
+}
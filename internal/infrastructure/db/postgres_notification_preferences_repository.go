@@ -0,0 +1,142 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/domain/repositories"
+
+	"github.com/lib/pq"
+)
+
+type postgresNotificationPreferencesRepository struct {
+    db *sql.DB
+}
+
+func NewPostgresNotificationPreferencesRepository(db *sql.DB) repositories.NotificationPreferencesRepository {
+    return &postgresNotificationPreferencesRepository{db: db}
+}
+
+func (r *postgresNotificationPreferencesRepository) Get(ctx context.Context, userID string) (*models.NotificationPreferences, error) {
+    query := `
+        SELECT user_id, min_severity, categories, time_zone, quiet_start, quiet_end,
+               quiet_hours_override, max_alerts_per_hour, updated_at
+        FROM notification_preferences
+        WHERE user_id = $1
+    `
+
+    var prefs models.NotificationPreferences
+    var categories pq.StringArray
+    var quietStart, quietEnd sql.NullString
+
+    err := r.db.QueryRowContext(ctx, query, userID).Scan(
+        &prefs.UserID,
+        &prefs.MinSeverity,
+        &categories,
+        &prefs.TimeZone,
+        &quietStart,
+        &quietEnd,
+        &prefs.QuietHoursOverride,
+        &prefs.MaxAlertsPerHour,
+        &prefs.UpdatedAt,
+    )
+    if err == sql.ErrNoRows {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    prefs.Categories = []string(categories)
+    if quietStart.Valid && quietEnd.Valid {
+        prefs.QuietHours = &models.QuietHours{Start: quietStart.String, End: quietEnd.String}
+    }
+
+    return &prefs, nil
+}
+
+func (r *postgresNotificationPreferencesRepository) Save(ctx context.Context, prefs *models.NotificationPreferences) error {
+    query := `
+        INSERT INTO notification_preferences (
+            user_id, min_severity, categories, time_zone, quiet_start, quiet_end,
+            quiet_hours_override, max_alerts_per_hour, updated_at
+        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+        ON CONFLICT (user_id) DO UPDATE SET
+            min_severity = EXCLUDED.min_severity,
+            categories = EXCLUDED.categories,
+            time_zone = EXCLUDED.time_zone,
+            quiet_start = EXCLUDED.quiet_start,
+            quiet_end = EXCLUDED.quiet_end,
+            quiet_hours_override = EXCLUDED.quiet_hours_override,
+            max_alerts_per_hour = EXCLUDED.max_alerts_per_hour,
+            updated_at = EXCLUDED.updated_at
+    `
+
+    categories := prefs.Categories
+    if categories == nil {
+        categories = []string{}
+    }
+    var quietStart, quietEnd sql.NullString
+    if prefs.QuietHours != nil {
+        quietStart = sql.NullString{String: prefs.QuietHours.Start, Valid: true}
+        quietEnd = sql.NullString{String: prefs.QuietHours.End, Valid: true}
+    }
+
+    _, err := r.db.ExecContext(ctx, query,
+        prefs.UserID,
+        prefs.MinSeverity,
+        pq.Array(categories),
+        prefs.TimeZone,
+        quietStart,
+        quietEnd,
+        prefs.QuietHoursOverride,
+        prefs.MaxAlertsPerHour,
+        prefs.UpdatedAt,
+    )
+    return err
+}
+
+func (r *postgresNotificationPreferencesRepository) Delete(ctx context.Context, userID string) error {
+    _, err := r.db.ExecContext(ctx, `DELETE FROM notification_preferences WHERE user_id = $1`, userID)
+    return err
+}
+
+func (r *postgresNotificationPreferencesRepository) ReserveAlert(ctx context.Context, userID string, since time.Time, limit int, at time.Time) (bool, error) {
+    tx, err := r.db.BeginTx(ctx, nil)
+    if err != nil {
+        return false, err
+    }
+    defer tx.Rollback()
+
+    // Блокировка на пользователя до конца транзакции: параллельные оповещения
+    // одного пользователя считаются по очереди
+    if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, userID); err != nil {
+        return false, err
+    }
+
+    if _, err := tx.ExecContext(ctx,
+        `DELETE FROM user_alert_log WHERE user_id = $1 AND sent_at < $2`, userID, since,
+    ); err != nil {
+        return false, err
+    }
+
+    var sent int
+    if err := tx.QueryRowContext(ctx,
+        `SELECT COUNT(*) FROM user_alert_log WHERE user_id = $1`, userID,
+    ).Scan(&sent); err != nil {
+        return false, err
+    }
+    if sent >= limit {
+        return false, nil
+    }
+
+    if _, err := tx.ExecContext(ctx,
+        `INSERT INTO user_alert_log (user_id, sent_at) VALUES ($1, $2)`, userID, at,
+    ); err != nil {
+        return false, err
+    }
+
+    return true, tx.Commit()
+}
@@ -0,0 +1,205 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/domain/repositories"
+
+	"github.com/lib/pq"
+)
+
+type postgresOutboxRepository struct {
+    db *sql.DB
+}
+
+func NewPostgresOutboxRepository(db *sql.DB) repositories.OutboxRepository {
+    return &postgresOutboxRepository{db: db}
+}
+
+// rowQuerier - общий интерфейс для *sql.DB и *sql.Tx
+type rowQuerier interface {
+    QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// insertOutboxEvent записывает событие в outbox в рамках переданной транзакции
+func insertOutboxEvent(ctx context.Context, tx rowQuerier, event *models.OutboxEvent) error {
+    query := `
+        INSERT INTO incident_outbox (incident_id, event_type, payload, changed_fields, update_payload, geometry_payload, batch_id, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), $8)
+        RETURNING id
+    `
+
+    payload, err := json.Marshal(event.Incident)
+    if err != nil {
+        return err
+    }
+
+    var changedFields interface{}
+    if event.ChangedFields != nil {
+        data, err := json.Marshal(event.ChangedFields)
+        if err != nil {
+            return err
+        }
+        changedFields = string(data)
+    }
+
+    var update interface{}
+    if event.Update != nil {
+        data, err := json.Marshal(event.Update)
+        if err != nil {
+            return err
+        }
+        update = string(data)
+    }
+
+    var geometry interface{}
+    if event.Geometry != nil {
+        data, err := json.Marshal(event.Geometry)
+        if err != nil {
+            return err
+        }
+        geometry = string(data)
+    }
+
+    return tx.QueryRowContext(ctx, query,
+        event.IncidentID,
+        event.EventType,
+        string(payload),
+        changedFields,
+        update,
+        geometry,
+        event.BatchID,
+        event.CreatedAt,
+    ).Scan(&event.ID)
+}
+
+func (r *postgresOutboxRepository) FetchPending(ctx context.Context, now time.Time, limit int) ([]*models.OutboxEvent, error) {
+    // Событие ждет, пока отложен повтор более раннего события того же инцидента
+    query := `
+        SELECT id, incident_id, event_type, payload, changed_fields, update_payload, geometry_payload,
+               COALESCE(batch_id, ''), created_at, delivered, attempts, COALESCE(last_error, ''), next_attempt_at
+        FROM incident_outbox o
+        WHERE published_at IS NULL
+          AND dead_lettered_at IS NULL
+          AND (next_attempt_at IS NULL OR next_attempt_at <= $1)
+          AND NOT EXISTS (
+              SELECT 1 FROM incident_outbox earlier
+              WHERE earlier.incident_id = o.incident_id
+                AND earlier.id < o.id
+                AND earlier.published_at IS NULL
+                AND earlier.dead_lettered_at IS NULL
+                AND earlier.next_attempt_at > $1
+          )
+        ORDER BY id
+        LIMIT $2
+    `
+
+    rows, err := r.db.QueryContext(ctx, query, now, limit)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var events []*models.OutboxEvent
+    for rows.Next() {
+        var event models.OutboxEvent
+        var payload, changedFields, update, geometry []byte
+        var nextAttemptAt sql.NullTime
+        if err := rows.Scan(
+            &event.ID,
+            &event.IncidentID,
+            &event.EventType,
+            &payload,
+            &changedFields,
+            &update,
+            &geometry,
+            &event.BatchID,
+            &event.CreatedAt,
+            pq.Array(&event.Delivered),
+            &event.Attempts,
+            &event.LastError,
+            &nextAttemptAt,
+        ); err != nil {
+            return nil, err
+        }
+        if nextAttemptAt.Valid {
+            event.NextAttemptAt = &nextAttemptAt.Time
+        }
+        if err := json.Unmarshal(payload, &event.Incident); err != nil {
+            return nil, err
+        }
+        if len(changedFields) > 0 {
+            if err := json.Unmarshal(changedFields, &event.ChangedFields); err != nil {
+                return nil, err
+            }
+        }
+        if len(update) > 0 {
+            if err := json.Unmarshal(update, &event.Update); err != nil {
+                return nil, err
+            }
+        }
+        if len(geometry) > 0 {
+            if err := json.Unmarshal(geometry, &event.Geometry); err != nil {
+                return nil, err
+            }
+        }
+        events = append(events, &event)
+    }
+
+    return events, rows.Err()
+}
+
+func (r *postgresOutboxRepository) MarkDelivered(ctx context.Context, id int64, publisher string) error {
+    query := `
+        UPDATE incident_outbox
+        SET delivered = array_append(delivered, $2)
+        WHERE id = $1 AND NOT ($2 = ANY(delivered))
+    `
+    _, err := r.db.ExecContext(ctx, query, id, publisher)
+    return err
+}
+
+func (r *postgresOutboxRepository) MarkPublished(ctx context.Context, id int64) error {
+    query := `UPDATE incident_outbox SET published_at = $1, last_error = NULL WHERE id = $2`
+    _, err := r.db.ExecContext(ctx, query, time.Now(), id)
+    return err
+}
+
+func (r *postgresOutboxRepository) MarkFailed(ctx context.Context, id int64, reason string, retryAt time.Time) error {
+    query := `UPDATE incident_outbox SET attempts = attempts + 1, last_error = $1, next_attempt_at = $2 WHERE id = $3`
+    _, err := r.db.ExecContext(ctx, query, reason, retryAt, id)
+    return err
+}
+
+func (r *postgresOutboxRepository) MarkDeadLetter(ctx context.Context, id int64, reason string, at time.Time) error {
+    query := `UPDATE incident_outbox SET attempts = attempts + 1, last_error = $1, dead_lettered_at = $2 WHERE id = $3`
+    _, err := r.db.ExecContext(ctx, query, reason, at, id)
+    return err
+}
+
+func (r *postgresOutboxRepository) AcquireLease(ctx context.Context, owner string, ttl time.Duration) (bool, error) {
+    // Аренда переходит к новому владельцу только после истечения срока
+    query := `
+        INSERT INTO outbox_lease (name, owner, expires_at)
+        VALUES ('relay', $1, NOW() + $2 * INTERVAL '1 millisecond')
+        ON CONFLICT (name) DO UPDATE
+        SET owner = EXCLUDED.owner, expires_at = EXCLUDED.expires_at
+        WHERE outbox_lease.owner = EXCLUDED.owner OR outbox_lease.expires_at < NOW()
+        RETURNING owner
+    `
+
+    var current string
+    err := r.db.QueryRowContext(ctx, query, owner, ttl.Milliseconds()).Scan(&current)
+    if err == sql.ErrNoRows {
+        return false, nil
+    }
+    if err != nil {
+        return false, err
+    }
+
+    return current == owner, nil
+}
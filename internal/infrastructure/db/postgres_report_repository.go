@@ -0,0 +1,277 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/domain/repositories"
+	"incident-system/pkg/geo"
+)
+
+const reportColumns = `id, user_id, reporter_ip, latitude, longitude, description, category, severity, status,
+    abuse_score, incident_id, abusive, moderation_note, moderated_at, created_at`
+
+type postgresReportRepository struct {
+    db *sql.DB
+}
+
+func NewPostgresReportRepository(db *sql.DB) repositories.ReportRepository {
+    return &postgresReportRepository{db: db}
+}
+
+func scanReport(row rowScanner) (*models.IncidentReport, error) {
+    var report models.IncidentReport
+    var severity sql.NullString
+
+    if err := row.Scan(
+        &report.ID,
+        &report.UserID,
+        &report.ReporterIP,
+        &report.Latitude,
+        &report.Longitude,
+        &report.Description,
+        &report.Category,
+        &severity,
+        &report.Status,
+        &report.AbuseScore,
+        &report.IncidentID,
+        &report.Abusive,
+        &report.Note,
+        &report.ModeratedAt,
+        &report.CreatedAt,
+    ); err != nil {
+        return nil, err
+    }
+
+    report.Severity = severity.String
+    return &report, nil
+}
+
+func (r *postgresReportRepository) Create(ctx context.Context, report *models.IncidentReport, limit models.ReportRateLimit) error {
+    tx, err := r.db.BeginTx(ctx, nil)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    // Блокировки на заявителя и адрес до конца транзакции: параллельные сообщения
+    // считаются по очереди. Порядок захвата всегда один, поэтому взаимоблокировок нет.
+    if _, err := tx.ExecContext(ctx,
+        `SELECT pg_advisory_xact_lock(hashtext('report_user:' || $1)), pg_advisory_xact_lock(hashtext('report_ip:' || $2))`,
+        report.UserID, report.ReporterIP,
+    ); err != nil {
+        return err
+    }
+
+    var byReporter, byAddress int
+    if err := tx.QueryRowContext(ctx, `
+        SELECT
+            (SELECT COUNT(*) FROM incident_reports WHERE user_id = $1 AND created_at >= $3),
+            (SELECT COUNT(*) FROM incident_reports WHERE reporter_ip = $2 AND reporter_ip <> '' AND created_at >= $3)
+    `, report.UserID, report.ReporterIP, limit.Since).Scan(&byReporter, &byAddress); err != nil {
+        return err
+    }
+    if (limit.PerReporter > 0 && byReporter >= limit.PerReporter) ||
+        (limit.PerAddress > 0 && byAddress >= limit.PerAddress) {
+        return repositories.ErrRateLimited
+    }
+
+    if err := tx.QueryRowContext(ctx, `
+        INSERT INTO incident_reports (
+            user_id, reporter_ip, latitude, longitude, description, category, severity,
+            status, abuse_score, created_at
+        ) VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), $8, $9, $10)
+        RETURNING id
+    `,
+        report.UserID,
+        report.ReporterIP,
+        report.Latitude,
+        report.Longitude,
+        report.Description,
+        report.Category,
+        report.Severity,
+        report.Status,
+        report.AbuseScore,
+        report.CreatedAt,
+    ).Scan(&report.ID); err != nil {
+        return err
+    }
+
+    return tx.Commit()
+}
+
+func (r *postgresReportRepository) FindByID(ctx context.Context, id int64) (*models.IncidentReport, error) {
+    query := `SELECT ` + reportColumns + ` FROM incident_reports WHERE id = $1`
+
+    report, err := scanReport(r.db.QueryRowContext(ctx, query, id))
+    if err == sql.ErrNoRows {
+        return nil, nil
+    }
+
+    return report, err
+}
+
+func (r *postgresReportRepository) FindAll(ctx context.Context, filter models.ReportFilter) ([]*models.IncidentReport, int, error) {
+    var conditions []string
+    var args []interface{}
+    where := func(condition string, value interface{}) {
+        args = append(args, value)
+        conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+    }
+
+    if filter.Status != "" {
+        where("status = $%d", filter.Status)
+    }
+    if filter.UserID != "" {
+        where("user_id = $%d", filter.UserID)
+    }
+    if filter.IncidentID != nil {
+        where("incident_id = $%d", *filter.IncidentID)
+    }
+
+    whereClause := ""
+    if len(conditions) > 0 {
+        whereClause = "WHERE " + strings.Join(conditions, " AND ")
+    }
+
+    var total int
+    countQuery := `SELECT COUNT(*) FROM incident_reports ` + whereClause
+    if err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
+        return nil, 0, err
+    }
+
+    orderBy := `created_at DESC, id DESC`
+    if filter.Status == models.ReportPending {
+        orderBy = `abuse_score, created_at, id`
+    }
+    query := fmt.Sprintf(`
+        SELECT %s
+        FROM incident_reports
+        %s
+        ORDER BY %s
+        LIMIT $%d OFFSET $%d
+    `, reportColumns, whereClause, orderBy, len(args)+1, len(args)+2)
+
+    rows, err := r.db.QueryContext(ctx, query, append(args, filter.Limit, filter.Offset)...)
+    if err != nil {
+        return nil, 0, err
+    }
+    defer rows.Close()
+
+    var reports []*models.IncidentReport
+    for rows.Next() {
+        report, err := scanReport(rows)
+        if err != nil {
+            return nil, 0, err
+        }
+        reports = append(reports, report)
+    }
+
+    return reports, total, rows.Err()
+}
+
+func (r *postgresReportRepository) Claim(ctx context.Context, id int64, at, staleBefore time.Time) (bool, error) {
+    result, err := r.db.ExecContext(ctx, `
+        UPDATE incident_reports
+        SET status = 'approving', moderated_at = $1
+        WHERE id = $2 AND (status = 'pending' OR (status = 'approving' AND moderated_at < $3))
+    `, at, id, staleBefore)
+    if err != nil {
+        return false, err
+    }
+
+    affected, err := result.RowsAffected()
+    return affected > 0, err
+}
+
+func (r *postgresReportRepository) Moderate(ctx context.Context, report *models.IncidentReport, from string) (bool, error) {
+    result, err := r.db.ExecContext(ctx, `
+        UPDATE incident_reports
+        SET status = $1, incident_id = $2, abusive = $3, moderation_note = $4, moderated_at = $5
+        WHERE id = $6 AND status = $7
+    `,
+        report.Status,
+        report.IncidentID,
+        report.Abusive,
+        report.Note,
+        report.ModeratedAt,
+        report.ID,
+        from,
+    )
+    if err != nil {
+        return false, err
+    }
+
+    affected, err := result.RowsAffected()
+    return affected > 0, err
+}
+
+func (r *postgresReportRepository) ReporterStats(ctx context.Context, userID string, since time.Time) (*models.ReporterStats, error) {
+    return r.stats(ctx, `user_id = $1`, userID, since)
+}
+
+func (r *postgresReportRepository) AddressStats(ctx context.Context, ip string, since time.Time) (*models.ReporterStats, error) {
+    return r.stats(ctx, `reporter_ip = $1`, ip, since)
+}
+
+func (r *postgresReportRepository) stats(ctx context.Context, condition string, value string, since time.Time) (*models.ReporterStats, error) {
+    rows, err := r.db.QueryContext(ctx, `
+        SELECT status, abusive, COUNT(*)
+        FROM incident_reports
+        WHERE `+condition+` AND created_at >= $2
+        GROUP BY status, abusive
+    `, value, since)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    stats := &models.ReporterStats{}
+    for rows.Next() {
+        var status string
+        var abusive bool
+        var count int
+        if err := rows.Scan(&status, &abusive, &count); err != nil {
+            return nil, err
+        }
+        stats.Add(status, abusive, count)
+    }
+
+    return stats, rows.Err()
+}
+
+func (r *postgresReportRepository) FindPendingNear(ctx context.Context, lat, lng, radiusKm float64, since time.Time) ([]*models.IncidentReport, error) {
+    minLat, _, maxLat, _ := geo.BoundingBox(lat, lng, radiusKm)
+    lngCondition, args := lngRangesClause("longitude", "longitude", geo.LngRanges(lat, lng, radiusKm),
+        []interface{}{minLat, maxLat, since})
+    query := `
+        SELECT ` + reportColumns + `
+        FROM incident_reports
+        WHERE status = 'pending'
+          AND latitude BETWEEN $1 AND $2
+          AND created_at >= $3
+          AND ` + lngCondition + `
+        ORDER BY created_at, id
+    `
+
+    rows, err := r.db.QueryContext(ctx, query, args...)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var reports []*models.IncidentReport
+    for rows.Next() {
+        report, err := scanReport(rows)
+        if err != nil {
+            return nil, err
+        }
+        reports = append(reports, report)
+    }
+
+    return reports, rows.Err()
+}
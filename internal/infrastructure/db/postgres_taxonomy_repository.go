@@ -0,0 +1,161 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/domain/repositories"
+)
+
+type postgresTaxonomyRepository struct {
+    db *sql.DB
+}
+
+func NewPostgresTaxonomyRepository(db *sql.DB) repositories.TaxonomyRepository {
+    return &postgresTaxonomyRepository{db: db}
+}
+
+func scanCategory(row rowScanner) (*models.Category, error) {
+    var category models.Category
+    var parent sql.NullString
+
+    if err := row.Scan(&category.Code, &parent, &category.Name, &category.Description, &category.CreatedAt); err != nil {
+        return nil, err
+    }
+
+    category.Parent = parent.String
+    return &category, nil
+}
+
+func (r *postgresTaxonomyRepository) ListCategories(ctx context.Context) ([]*models.Category, error) {
+    rows, err := r.db.QueryContext(ctx, `
+        SELECT code, parent_code, name, description, created_at
+        FROM incident_categories
+        ORDER BY code
+    `)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var categories []*models.Category
+    for rows.Next() {
+        category, err := scanCategory(rows)
+        if err != nil {
+            return nil, err
+        }
+        categories = append(categories, category)
+    }
+
+    return categories, rows.Err()
+}
+
+func (r *postgresTaxonomyRepository) FindCategory(ctx context.Context, code string) (*models.Category, error) {
+    category, err := scanCategory(r.db.QueryRowContext(ctx, `
+        SELECT code, parent_code, name, description, created_at
+        FROM incident_categories
+        WHERE code = $1
+    `, code))
+    if err == sql.ErrNoRows {
+        return nil, nil
+    }
+
+    return category, err
+}
+
+func (r *postgresTaxonomyRepository) SaveCategory(ctx context.Context, category *models.Category) error {
+    query := `
+        INSERT INTO incident_categories (code, parent_code, name, description, created_at)
+        VALUES ($1, NULLIF($2, ''), $3, $4, $5)
+        ON CONFLICT (code) DO UPDATE SET name = EXCLUDED.name, description = EXCLUDED.description
+        RETURNING created_at
+    `
+
+    return r.db.QueryRowContext(ctx, query,
+        category.Code,
+        category.Parent,
+        category.Name,
+        category.Description,
+        category.CreatedAt,
+    ).Scan(&category.CreatedAt)
+}
+
+func (r *postgresTaxonomyRepository) DeleteCategory(ctx context.Context, code string) error {
+    // Ссылку, появившуюся параллельно, отклонит внешний ключ
+    query := `
+        DELETE FROM incident_categories
+        WHERE code = $1
+          AND NOT EXISTS (SELECT 1 FROM incident_categories WHERE parent_code = $1)
+          AND NOT EXISTS (SELECT 1 FROM incidents WHERE category = $1)
+    `
+
+    result, err := r.db.ExecContext(ctx, query, code)
+    if err != nil {
+        return err
+    }
+    return deletedOrInUse(ctx, r.db, result, `SELECT EXISTS (SELECT 1 FROM incident_categories WHERE code = $1)`, code)
+}
+
+func (r *postgresTaxonomyRepository) ListSeverityLevels(ctx context.Context) ([]*models.SeverityLevel, error) {
+    rows, err := r.db.QueryContext(ctx, `SELECT code, name, level, color FROM severity_levels ORDER BY level`)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var levels []*models.SeverityLevel
+    for rows.Next() {
+        var level models.SeverityLevel
+        if err := rows.Scan(&level.Code, &level.Name, &level.Level, &level.Color); err != nil {
+            return nil, err
+        }
+        levels = append(levels, &level)
+    }
+
+    return levels, rows.Err()
+}
+
+func (r *postgresTaxonomyRepository) SaveSeverityLevel(ctx context.Context, level *models.SeverityLevel) error {
+    _, err := r.db.ExecContext(ctx, `
+        INSERT INTO severity_levels (code, name, level, color)
+        VALUES ($1, $2, $3, $4)
+        ON CONFLICT (code) DO UPDATE SET name = EXCLUDED.name, level = EXCLUDED.level, color = EXCLUDED.color
+    `, level.Code, level.Name, level.Level, level.Color)
+    return err
+}
+
+func (r *postgresTaxonomyRepository) DeleteSeverityLevel(ctx context.Context, code string) error {
+    query := `
+        DELETE FROM severity_levels
+        WHERE code = $1
+          AND NOT EXISTS (SELECT 1 FROM incidents WHERE severity = $1)
+          AND NOT EXISTS (SELECT 1 FROM watch_zones WHERE min_severity = $1)
+          AND NOT EXISTS (SELECT 1 FROM notification_preferences WHERE min_severity = $1 OR quiet_hours_override = $1)
+          AND NOT EXISTS (SELECT 1 FROM webhook_subscriptions WHERE min_severity = $1)
+    `
+
+    result, err := r.db.ExecContext(ctx, query, code)
+    if err != nil {
+        return err
+    }
+    return deletedOrInUse(ctx, r.db, result, `SELECT EXISTS (SELECT 1 FROM severity_levels WHERE code = $1)`, code)
+}
+
+// deletedOrInUse различает причины, по которым условное удаление не затронуло строк:
+// запись осталась - значит, на нее ссылаются; записи не было - удалять нечего
+func deletedOrInUse(ctx context.Context, db *sql.DB, result sql.Result, existsQuery, code string) error {
+    affected, err := result.RowsAffected()
+    if err != nil || affected > 0 {
+        return err
+    }
+
+    var exists bool
+    if err := db.QueryRowContext(ctx, existsQuery, code).Scan(&exists); err != nil {
+        return err
+    }
+    if exists {
+        return repositories.ErrInUse
+    }
+    return nil
+}
@@ -0,0 +1,213 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/domain/repositories"
+	"incident-system/pkg/geo"
+
+	"github.com/lib/pq"
+)
+
+const watchZoneColumns = `id, user_id, name, latitude, longitude, radius, polygon, min_severity, created_at, updated_at`
+
+type postgresWatchZoneRepository struct {
+    db *sql.DB
+}
+
+func NewPostgresWatchZoneRepository(db *sql.DB) repositories.WatchZoneRepository {
+    return &postgresWatchZoneRepository{db: db}
+}
+
+func scanWatchZone(row rowScanner) (*models.WatchZone, error) {
+    var zone models.WatchZone
+    var polygon []byte
+
+    if err := row.Scan(
+        &zone.ID,
+        &zone.UserID,
+        &zone.Name,
+        &zone.Latitude,
+        &zone.Longitude,
+        &zone.Radius,
+        &polygon,
+        &zone.MinSeverity,
+        &zone.CreatedAt,
+        &zone.UpdatedAt,
+    ); err != nil {
+        return nil, err
+    }
+
+    if len(polygon) > 0 {
+        if err := json.Unmarshal(polygon, &zone.Polygon); err != nil {
+            return nil, err
+        }
+    }
+
+    return &zone, nil
+}
+
+func scanWatchZones(rows *sql.Rows) ([]*models.WatchZone, error) {
+    defer rows.Close()
+
+    var zones []*models.WatchZone
+    for rows.Next() {
+        zone, err := scanWatchZone(rows)
+        if err != nil {
+            return nil, err
+        }
+        zones = append(zones, zone)
+    }
+
+    return zones, rows.Err()
+}
+
+func (r *postgresWatchZoneRepository) Create(ctx context.Context, zone *models.WatchZone) error {
+    query := `
+        INSERT INTO watch_zones (
+            user_id, name, latitude, longitude, radius, polygon, min_severity,
+            min_lat, min_lng, max_lat, max_lng, created_at, updated_at
+        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+        RETURNING id
+    `
+
+    polygon, err := encodePolygon(zone.Polygon)
+    if err != nil {
+        return err
+    }
+    minLat, minLng, maxLat, maxLng := geo.BoundingBox(zone.Latitude, zone.Longitude, zone.Radius/1000.0)
+
+    return r.db.QueryRowContext(ctx, query,
+        zone.UserID,
+        zone.Name,
+        zone.Latitude,
+        zone.Longitude,
+        zone.Radius,
+        polygon,
+        zone.MinSeverity,
+        minLat, minLng, maxLat, maxLng,
+        zone.CreatedAt,
+        zone.UpdatedAt,
+    ).Scan(&zone.ID)
+}
+
+func (r *postgresWatchZoneRepository) FindByID(ctx context.Context, id int64) (*models.WatchZone, error) {
+    query := `SELECT ` + watchZoneColumns + ` FROM watch_zones WHERE id = $1`
+
+    zone, err := scanWatchZone(r.db.QueryRowContext(ctx, query, id))
+    if err == sql.ErrNoRows {
+        return nil, nil
+    }
+
+    return zone, err
+}
+
+func (r *postgresWatchZoneRepository) FindByUser(ctx context.Context, userID string) ([]*models.WatchZone, error) {
+    query := `SELECT ` + watchZoneColumns + ` FROM watch_zones WHERE user_id = $1 ORDER BY id`
+
+    rows, err := r.db.QueryContext(ctx, query, userID)
+    if err != nil {
+        return nil, err
+    }
+
+    return scanWatchZones(rows)
+}
+
+func (r *postgresWatchZoneRepository) Update(ctx context.Context, zone *models.WatchZone) error {
+    query := `
+        UPDATE watch_zones
+        SET name = $1, latitude = $2, longitude = $3, radius = $4, polygon = $5, min_severity = $6,
+            min_lat = $7, min_lng = $8, max_lat = $9, max_lng = $10, updated_at = $11
+        WHERE id = $12
+    `
+
+    polygon, err := encodePolygon(zone.Polygon)
+    if err != nil {
+        return err
+    }
+    minLat, minLng, maxLat, maxLng := geo.BoundingBox(zone.Latitude, zone.Longitude, zone.Radius/1000.0)
+
+    _, err = r.db.ExecContext(ctx, query,
+        zone.Name,
+        zone.Latitude,
+        zone.Longitude,
+        zone.Radius,
+        polygon,
+        zone.MinSeverity,
+        minLat, minLng, maxLat, maxLng,
+        zone.UpdatedAt,
+        zone.ID,
+    )
+    return err
+}
+
+func (r *postgresWatchZoneRepository) Delete(ctx context.Context, id int64) error {
+    _, err := r.db.ExecContext(ctx, `DELETE FROM watch_zones WHERE id = $1`, id)
+    return err
+}
+
+func (r *postgresWatchZoneRepository) FindIntersecting(ctx context.Context, incident *models.Incident) ([]*models.WatchZone, error) {
+    // Кандидаты по описанным прямоугольникам, точная проверка - в geo.ZonesIntersect
+    radiusKm := incident.Radius / 1000.0
+    minLat, _, maxLat, _ := geo.BoundingBox(incident.Latitude, incident.Longitude, radiusKm)
+    lngCondition, args := lngRangesClause("min_lng", "max_lng", geo.LngRanges(incident.Latitude, incident.Longitude, radiusKm),
+        []interface{}{minLat, maxLat})
+    query := `
+        SELECT ` + watchZoneColumns + `
+        FROM watch_zones
+        WHERE max_lat >= $1 AND min_lat <= $2
+          AND ` + lngCondition + `
+        ORDER BY id
+    `
+
+    rows, err := r.db.QueryContext(ctx, query, args...)
+    if err != nil {
+        return nil, err
+    }
+
+    candidates, err := scanWatchZones(rows)
+    if err != nil {
+        return nil, err
+    }
+
+    incidentZone := incident.Zone()
+    var zones []*models.WatchZone
+    for _, zone := range candidates {
+        if geo.ZonesIntersect(zone.Zone(), incidentZone) {
+            zones = append(zones, zone)
+        }
+    }
+
+    return zones, nil
+}
+
+func (r *postgresWatchZoneRepository) MarkNotified(ctx context.Context, incidentID int64, zoneIDs []int64, at time.Time) ([]int64, error) {
+    // Соединение с watch_zones пропускает зоны, удаленные после отбора
+    query := `
+        INSERT INTO watch_zone_alerts (incident_id, zone_id, alerted_at)
+        SELECT $1, w.id, $3 FROM watch_zones w WHERE w.id = ANY($2::bigint[])
+        ON CONFLICT (incident_id, zone_id) DO NOTHING
+        RETURNING zone_id
+    `
+
+    rows, err := r.db.QueryContext(ctx, query, incidentID, pq.Array(zoneIDs), at)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var marked []int64
+    for rows.Next() {
+        var zoneID int64
+        if err := rows.Scan(&zoneID); err != nil {
+            return nil, err
+        }
+        marked = append(marked, zoneID)
+    }
+
+    return marked, rows.Err()
+}
@@ -0,0 +1,319 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/domain/repositories"
+
+	"github.com/lib/pq"
+)
+
+const subscriptionColumns = `id, url, description, event_types, categories, tags, min_severity, notify_url, format, active, created_at, updated_at,
+               failing_since, disabled_at, disabled_reason`
+
+type postgresSubscriptionRepository struct {
+    db *sql.DB
+}
+
+func NewPostgresSubscriptionRepository(db *sql.DB) repositories.WebhookSubscriptionRepository {
+    return &postgresSubscriptionRepository{db: db}
+}
+
+func scanSubscription(row rowScanner) (*models.WebhookSubscription, error) {
+    var subscription models.WebhookSubscription
+    var eventTypes, categories, tags pq.StringArray
+    var minSeverity sql.NullString
+
+    if err := row.Scan(
+        &subscription.ID,
+        &subscription.URL,
+        &subscription.Description,
+        &eventTypes,
+        &categories,
+        &tags,
+        &minSeverity,
+        &subscription.NotifyURL,
+        &subscription.Format,
+        &subscription.Active,
+        &subscription.CreatedAt,
+        &subscription.UpdatedAt,
+        &subscription.FailingSince,
+        &subscription.DisabledAt,
+        &subscription.DisabledReason,
+    ); err != nil {
+        return nil, err
+    }
+
+    subscription.EventTypes = []string(eventTypes)
+    subscription.Categories = []string(categories)
+    subscription.Tags = []string(tags)
+    subscription.MinSeverity = minSeverity.String
+    return &subscription, nil
+}
+
+func (r *postgresSubscriptionRepository) findMany(ctx context.Context, query string, args ...interface{}) ([]*models.WebhookSubscription, error) {
+    rows, err := r.db.QueryContext(ctx, query, args...)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var subscriptions []*models.WebhookSubscription
+    for rows.Next() {
+        subscription, err := scanSubscription(rows)
+        if err != nil {
+            return nil, err
+        }
+        subscriptions = append(subscriptions, subscription)
+    }
+
+    return subscriptions, rows.Err()
+}
+
+func (r *postgresSubscriptionRepository) Create(ctx context.Context, subscription *models.WebhookSubscription) error {
+    query := `
+        INSERT INTO webhook_subscriptions (
+            url, description, event_types, categories, tags, min_severity, notify_url, format, active, created_at, updated_at
+        ) VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, $8, $9, $10, $11)
+        RETURNING id
+    `
+
+    return r.db.QueryRowContext(ctx, query,
+        subscription.URL,
+        subscription.Description,
+        pq.Array(subscription.EventTypes),
+        encodeTags(subscription.Categories),
+        encodeTags(subscription.Tags),
+        subscription.MinSeverity,
+        subscription.NotifyURL,
+        subscription.Format,
+        subscription.Active,
+        subscription.CreatedAt,
+        subscription.UpdatedAt,
+    ).Scan(&subscription.ID)
+}
+
+func (r *postgresSubscriptionRepository) FindByID(ctx context.Context, id int64) (*models.WebhookSubscription, error) {
+    query := `SELECT ` + subscriptionColumns + ` FROM webhook_subscriptions WHERE id = $1`
+
+    subscription, err := scanSubscription(r.db.QueryRowContext(ctx, query, id))
+    if err == sql.ErrNoRows {
+        return nil, nil
+    }
+    return subscription, err
+}
+
+func (r *postgresSubscriptionRepository) FindAll(ctx context.Context) ([]*models.WebhookSubscription, error) {
+    return r.findMany(ctx, `SELECT `+subscriptionColumns+` FROM webhook_subscriptions ORDER BY id`)
+}
+
+func (r *postgresSubscriptionRepository) FindActive(ctx context.Context) ([]*models.WebhookSubscription, error) {
+    return r.findMany(ctx, `SELECT `+subscriptionColumns+` FROM webhook_subscriptions WHERE active = true ORDER BY id`)
+}
+
+func (r *postgresSubscriptionRepository) Update(ctx context.Context, subscription *models.WebhookSubscription) error {
+    query := `
+        UPDATE webhook_subscriptions
+        SET url = $1, description = $2, event_types = $3, categories = $4, tags = $5, min_severity = NULLIF($6, ''),
+            notify_url = $7, format = $8, active = $9,
+            updated_at = $10, failing_since = $11, disabled_at = $12, disabled_reason = $13
+        WHERE id = $14
+    `
+
+    _, err := r.db.ExecContext(ctx, query,
+        subscription.URL,
+        subscription.Description,
+        pq.Array(subscription.EventTypes),
+        encodeTags(subscription.Categories),
+        encodeTags(subscription.Tags),
+        subscription.MinSeverity,
+        subscription.NotifyURL,
+        subscription.Format,
+        subscription.Active,
+        subscription.UpdatedAt,
+        subscription.FailingSince,
+        subscription.DisabledAt,
+        subscription.DisabledReason,
+        subscription.ID,
+    )
+    return err
+}
+
+func (r *postgresSubscriptionRepository) Delete(ctx context.Context, id int64) error {
+    _, err := r.db.ExecContext(ctx, `DELETE FROM webhook_subscriptions WHERE id = $1`, id)
+    return err
+}
+
+func (r *postgresSubscriptionRepository) MarkFailing(ctx context.Context, id int64, at time.Time) (time.Time, error) {
+    query := `
+        UPDATE webhook_subscriptions
+        SET failing_since = COALESCE(failing_since, $1)
+        WHERE id = $2 AND active = true
+        RETURNING failing_since
+    `
+
+    var failingSince time.Time
+    err := r.db.QueryRowContext(ctx, query, at, id).Scan(&failingSince)
+    if err == sql.ErrNoRows {
+        return at, nil
+    }
+    return failingSince, err
+}
+
+func (r *postgresSubscriptionRepository) MarkHealthy(ctx context.Context, id int64) error {
+    _, err := r.db.ExecContext(ctx,
+        `UPDATE webhook_subscriptions SET failing_since = NULL WHERE id = $1 AND active = true AND failing_since IS NOT NULL`, id)
+    return err
+}
+
+func (r *postgresSubscriptionRepository) Disable(ctx context.Context, id int64, reason string, at time.Time) (bool, error) {
+    query := `
+        UPDATE webhook_subscriptions
+        SET active = false, disabled_at = $1, disabled_reason = $2, updated_at = $1
+        WHERE id = $3 AND active = true
+    `
+
+    result, err := r.db.ExecContext(ctx, query, at, reason, id)
+    if err != nil {
+        return false, err
+    }
+
+    affected, err := result.RowsAffected()
+    return affected > 0, err
+}
+
+const deliveryColumns = `id, delivery_id, subscription_id, event_type, event_id, destination,
+               attempt, request_hash, status_code, latency_ms, success, error, replay_of,
+               payload, created_at`
+
+type postgresDeliveryRepository struct {
+    db *sql.DB
+}
+
+func NewPostgresDeliveryRepository(db *sql.DB) repositories.WebhookDeliveryRepository {
+    return &postgresDeliveryRepository{db: db}
+}
+
+func (r *postgresDeliveryRepository) RecordAttempt(ctx context.Context, delivery *models.WebhookDelivery) error {
+    query := `
+        INSERT INTO webhook_deliveries (
+            delivery_id, subscription_id, event_type, event_id, destination,
+            attempt, request_hash, status_code, latency_ms, success, error, replay_of,
+            payload, created_at
+        )
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+        RETURNING id
+    `
+
+    payload, err := json.Marshal(delivery.Payload)
+    if err != nil {
+        return err
+    }
+
+    return r.db.QueryRowContext(ctx, query,
+        delivery.DeliveryID,
+        delivery.SubscriptionID,
+        delivery.EventType,
+        delivery.EventID,
+        delivery.Destination,
+        delivery.Attempt,
+        delivery.RequestHash,
+        delivery.StatusCode,
+        delivery.LatencyMs,
+        delivery.Success,
+        delivery.Error,
+        delivery.ReplayOf,
+        string(payload),
+        delivery.CreatedAt,
+    ).Scan(&delivery.ID)
+}
+
+func (r *postgresDeliveryRepository) FindDeliveries(ctx context.Context, filter models.DeliveryFilter) ([]*models.WebhookDelivery, int, error) {
+    var conditions []string
+    var args []interface{}
+    where := func(condition string, value interface{}) {
+        args = append(args, value)
+        conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+    }
+
+    if filter.SubscriptionID != nil {
+        where("subscription_id = $%d", *filter.SubscriptionID)
+    }
+    if filter.DeliveryID != "" {
+        where("delivery_id = $%d", filter.DeliveryID)
+    }
+    if filter.EventType != "" {
+        where("event_type = $%d", filter.EventType)
+    }
+    if filter.Success != nil {
+        where("success = $%d", *filter.Success)
+    }
+    if !filter.From.IsZero() {
+        where("created_at >= $%d", filter.From)
+    }
+    if !filter.To.IsZero() {
+        where("created_at < $%d", filter.To)
+    }
+
+    whereClause := ""
+    if len(conditions) > 0 {
+        whereClause = "WHERE " + strings.Join(conditions, " AND ")
+    }
+
+    var total int
+    countQuery := `SELECT COUNT(*) FROM webhook_deliveries ` + whereClause
+    if err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
+        return nil, 0, err
+    }
+
+    query := fmt.Sprintf(`
+        SELECT %s
+        FROM webhook_deliveries
+        %s
+        ORDER BY created_at DESC, id DESC
+        LIMIT $%d OFFSET $%d
+    `, deliveryColumns, whereClause, len(args)+1, len(args)+2)
+
+    rows, err := r.db.QueryContext(ctx, query, append(args, filter.Limit, filter.Offset)...)
+    if err != nil {
+        return nil, 0, err
+    }
+    defer rows.Close()
+
+    var deliveries []*models.WebhookDelivery
+    for rows.Next() {
+        var delivery models.WebhookDelivery
+        var payload string
+        if err := rows.Scan(
+            &delivery.ID,
+            &delivery.DeliveryID,
+            &delivery.SubscriptionID,
+            &delivery.EventType,
+            &delivery.EventID,
+            &delivery.Destination,
+            &delivery.Attempt,
+            &delivery.RequestHash,
+            &delivery.StatusCode,
+            &delivery.LatencyMs,
+            &delivery.Success,
+            &delivery.Error,
+            &delivery.ReplayOf,
+            &payload,
+            &delivery.CreatedAt,
+        ); err != nil {
+            return nil, 0, err
+        }
+        if err := json.Unmarshal([]byte(payload), &delivery.Payload); err != nil {
+            return nil, 0, err
+        }
+        deliveries = append(deliveries, &delivery)
+    }
+
+    return deliveries, total, rows.Err()
+}
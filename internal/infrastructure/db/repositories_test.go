@@ -0,0 +1,176 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"incident-system/internal/domain/repositories"
+	"incident-system/internal/domain/repositories/repotest"
+)
+
+// Репозитории PostgreSQL проверяются только с TEST_POSTGRES_DSN, например
+// TEST_POSTGRES_DSN="host=localhost user=postgres password=postgres dbname=incident_test sslmode=disable".
+// Каждое хранилище теста создается в отдельной схеме с примененными migrations/*.sql.
+
+var testSchemas atomic.Int64
+
+func openTestDB(t *testing.T) *sql.DB {
+    t.Helper()
+    dsn := os.Getenv("TEST_POSTGRES_DSN")
+    if dsn == "" {
+        t.Skip("TEST_POSTGRES_DSN is not set")
+    }
+
+    admin, err := sql.Open("postgres", dsn)
+    if err != nil {
+        t.Fatalf("open %s: %v", dsn, err)
+    }
+    t.Cleanup(func() { admin.Close() })
+
+    schema := fmt.Sprintf("repotest_%d_%d", os.Getpid(), testSchemas.Add(1))
+    if _, err := admin.Exec(`CREATE SCHEMA ` + schema); err != nil {
+        t.Fatalf("create schema: %v", err)
+    }
+    t.Cleanup(func() { admin.Exec(`DROP SCHEMA ` + schema + ` CASCADE`) })
+
+    db, err := sql.Open("postgres", withSearchPath(dsn, schema+",public"))
+    if err != nil {
+        t.Fatalf("open schema %s: %v", schema, err)
+    }
+    t.Cleanup(func() { db.Close() })
+
+    names, err := filepath.Glob(filepath.Join("..", "..", "..", "migrations", "*.sql"))
+    if err != nil || len(names) == 0 {
+        t.Fatalf("migrations not found: %v", err)
+    }
+    sort.Strings(names)
+    for _, name := range names {
+        script, err := os.ReadFile(name)
+        if err != nil {
+            t.Fatalf("read %s: %v", name, err)
+        }
+        if _, err := db.Exec(string(script)); err != nil {
+            t.Fatalf("apply %s: %v", filepath.Base(name), err)
+        }
+    }
+    return db
+}
+
+// openPostGISTestDB дополнительно создает схему бэкенда postgis; без расширения тест пропускается
+func openPostGISTestDB(t *testing.T) *sql.DB {
+    t.Helper()
+    db := openTestDB(t)
+    if _, err := db.Exec(`CREATE EXTENSION IF NOT EXISTS postgis SCHEMA public`); err != nil {
+        t.Skipf("PostGIS is not available: %v", err)
+    }
+    if err := EnsurePostGISSchema(context.Background(), db); err != nil {
+        t.Fatalf("EnsurePostGISSchema: %v", err)
+    }
+    // Повторный запуск при рестарте приложения ничего не меняет
+    if err := EnsurePostGISSchema(context.Background(), db); err != nil {
+        t.Fatalf("second EnsurePostGISSchema: %v", err)
+    }
+    return db
+}
+
+// withSearchPath добавляет к DSN в формате URL или key=value параметр search_path
+func withSearchPath(dsn, searchPath string) string {
+    if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+        separator := "?"
+        if strings.Contains(dsn, "?") {
+            separator = "&"
+        }
+        return dsn + separator + "search_path=" + url.QueryEscape(searchPath)
+    }
+    return dsn + " search_path='" + searchPath + "'"
+}
+
+func TestPostgresIncidentRepository(t *testing.T) {
+    repotest.RunIncidentRepositorySuite(t, func(t *testing.T) repositories.IncidentRepository {
+        return NewPostgresIncidentRepository(openTestDB(t))
+    })
+}
+
+func TestPostGISIncidentRepository(t *testing.T) {
+    repotest.RunIncidentRepositorySuite(t, func(t *testing.T) repositories.IncidentRepository {
+        return NewPostGISIncidentRepository(openPostGISTestDB(t))
+    })
+}
+
+func TestPostgresOutboxRepository(t *testing.T) {
+    repotest.RunOutboxRepositorySuite(t, func(t *testing.T) (repositories.IncidentRepository, repositories.OutboxRepository) {
+        db := openTestDB(t)
+        return NewPostgresIncidentRepository(db), NewPostgresOutboxRepository(db)
+    })
+}
+
+func TestPostgresIncidentMerge(t *testing.T) {
+    repotest.RunIncidentMergeSuite(t, func(t *testing.T) repotest.MergeRepositories {
+        db := openTestDB(t)
+        return repotest.MergeRepositories{
+            Incidents:   NewPostgresIncidentRepository(db),
+            Outbox:      NewPostgresOutboxRepository(db),
+            WatchZones:  NewPostgresWatchZoneRepository(db),
+            Reports:     NewPostgresReportRepository(db),
+            Attachments: NewPostgresAttachmentRepository(db),
+        }
+    })
+}
+
+func TestPostgresWebhookRepositories(t *testing.T) {
+    repotest.RunWebhookSubscriptionRepositorySuite(t, func(t *testing.T) repositories.WebhookSubscriptionRepository {
+        return NewPostgresSubscriptionRepository(openTestDB(t))
+    })
+    repotest.RunWebhookDeliveryRepositorySuite(t, func(t *testing.T) repositories.WebhookDeliveryRepository {
+        return NewPostgresDeliveryRepository(openTestDB(t))
+    })
+}
+
+func TestPostgresWatchZoneRepository(t *testing.T) {
+    repotest.RunWatchZoneRepositorySuite(t, func(t *testing.T) (repositories.IncidentRepository, repositories.WatchZoneRepository) {
+        db := openTestDB(t)
+        return NewPostgresIncidentRepository(db), NewPostgresWatchZoneRepository(db)
+    })
+}
+
+func TestPostGISWatchZoneRepository(t *testing.T) {
+    repotest.RunWatchZoneRepositorySuite(t, func(t *testing.T) (repositories.IncidentRepository, repositories.WatchZoneRepository) {
+        db := openPostGISTestDB(t)
+        return NewPostGISIncidentRepository(db), NewPostGISWatchZoneRepository(db)
+    })
+}
+
+func TestPostgresNotificationPreferencesRepository(t *testing.T) {
+    repotest.RunNotificationPreferencesRepositorySuite(t, func(t *testing.T) repositories.NotificationPreferencesRepository {
+        return NewPostgresNotificationPreferencesRepository(openTestDB(t))
+    })
+}
+
+func TestPostgresTaxonomyRepository(t *testing.T) {
+    repotest.RunTaxonomyRepositorySuite(t, func(t *testing.T) (repositories.IncidentRepository, repositories.TaxonomyRepository) {
+        db := openTestDB(t)
+        return NewPostgresIncidentRepository(db), NewPostgresTaxonomyRepository(db)
+    })
+}
+
+func TestPostgresReportRepository(t *testing.T) {
+    repotest.RunReportRepositorySuite(t, func(t *testing.T) (repositories.IncidentRepository, repositories.ReportRepository) {
+        db := openTestDB(t)
+        return NewPostgresIncidentRepository(db), NewPostgresReportRepository(db)
+    })
+}
+
+func TestPostgresAttachmentRepository(t *testing.T) {
+    repotest.RunAttachmentRepositorySuite(t, func(t *testing.T) (repositories.IncidentRepository, repositories.AttachmentRepository) {
+        db := openTestDB(t)
+        return NewPostgresIncidentRepository(db), NewPostgresAttachmentRepository(db)
+    })
+}
@@ -0,0 +1,102 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/domain/repositories"
+)
+
+type memoryAttachmentRepository struct {
+    mu          sync.RWMutex
+    attachments map[int64]*models.Attachment
+    nextID      int64
+}
+
+// AttachmentRepository - вложения в памяти, на которые ссылается IncidentReferrers
+type AttachmentRepository interface {
+    repositories.AttachmentRepository
+    incidentReferrer
+}
+
+func NewAttachmentRepository() AttachmentRepository {
+    return &memoryAttachmentRepository{
+        attachments: make(map[int64]*models.Attachment),
+    }
+}
+
+func (r *memoryAttachmentRepository) Create(ctx context.Context, attachment *models.Attachment) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    r.nextID++
+    attachment.ID = r.nextID
+    c := *attachment
+    r.attachments[attachment.ID] = &c
+    return nil
+}
+
+func (r *memoryAttachmentRepository) FindByID(ctx context.Context, id int64) (*models.Attachment, error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    attachment, ok := r.attachments[id]
+    if !ok {
+        return nil, nil
+    }
+    c := *attachment
+    return &c, nil
+}
+
+func (r *memoryAttachmentRepository) FindByIncident(ctx context.Context, incidentID int64) ([]*models.Attachment, error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    var result []*models.Attachment
+    for _, attachment := range r.attachments {
+        if attachment.IncidentID == incidentID {
+            c := *attachment
+            result = append(result, &c)
+        }
+    }
+    sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+    return result, nil
+}
+
+func (r *memoryAttachmentRepository) CountByIncident(ctx context.Context, incidentID int64) (int, error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    count := 0
+    for _, attachment := range r.attachments {
+        if attachment.IncidentID == incidentID {
+            count++
+        }
+    }
+    return count, nil
+}
+
+func (r *memoryAttachmentRepository) Delete(ctx context.Context, id int64) (bool, error) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    if _, ok := r.attachments[id]; !ok {
+        return false, nil
+    }
+    delete(r.attachments, id)
+    return true, nil
+}
+
+// redirectIncident переносит ссылки Merge с дубликата from на инцидент to
+func (r *memoryAttachmentRepository) redirectIncident(from, to int64) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    for _, attachment := range r.attachments {
+        if attachment.IncidentID == from {
+            attachment.IncidentID = to
+        }
+    }
+}
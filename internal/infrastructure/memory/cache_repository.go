@@ -0,0 +1,105 @@
+package memory
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/domain/repositories"
+)
+
+type memoryCacheRepository struct {
+    mu        sync.RWMutex
+    snapshot  *models.ActiveIncidentsSnapshot
+    expiresAt time.Time
+    version   int64
+    ttl       time.Duration // срок свежести снимка
+    staleTTL  time.Duration // сколько устаревший снимок может отдаваться во время обновления
+
+    lockToken   string
+    lockExpires time.Time
+    lockSeq     int64
+}
+
+func NewCacheRepository(ttl, staleTTL time.Duration) repositories.CacheRepository {
+    return &memoryCacheRepository{ttl: ttl, staleTTL: staleTTL}
+}
+
+func copySnapshot(snapshot *models.ActiveIncidentsSnapshot) *models.ActiveIncidentsSnapshot {
+    c := *snapshot
+    c.Incidents = make([]*models.Incident, len(snapshot.Incidents))
+    for i, incident := range snapshot.Incidents {
+        c.Incidents[i] = copyIncident(incident)
+    }
+    return &c
+}
+
+func (r *memoryCacheRepository) GetActiveIncidents(ctx context.Context) (*models.ActiveIncidentsSnapshot, error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    // Как и в Redis: отсутствие или истекший ключ - это не ошибка
+    if r.snapshot == nil || time.Now().After(r.expiresAt) {
+        return nil, nil
+    }
+
+    snapshot := copySnapshot(r.snapshot)
+    snapshot.Stale = snapshot.Version < r.version || time.Since(snapshot.LoadedAt) > r.ttl
+    return snapshot, nil
+}
+
+func (r *memoryCacheRepository) SetActiveIncidents(ctx context.Context, snapshot *models.ActiveIncidentsSnapshot) (bool, error) {
+    stored := copySnapshot(snapshot)
+
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    if r.snapshot != nil && time.Now().Before(r.expiresAt) && r.snapshot.Version > snapshot.Version {
+        return false, nil
+    }
+
+    r.snapshot = stored
+    r.expiresAt = time.Now().Add(r.ttl + r.staleTTL)
+    return true, nil
+}
+
+func (r *memoryCacheRepository) ActiveIncidentsVersion(ctx context.Context) (int64, error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    return r.version, nil
+}
+
+func (r *memoryCacheRepository) InvalidateActiveIncidents(ctx context.Context) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    r.version++
+    return nil
+}
+
+func (r *memoryCacheRepository) AcquireRefreshLock(ctx context.Context, ttl time.Duration) (string, bool, error) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    if r.lockToken != "" && time.Now().Before(r.lockExpires) {
+        return "", false, nil
+    }
+
+    r.lockSeq++
+    r.lockToken = strconv.FormatInt(r.lockSeq, 10)
+    r.lockExpires = time.Now().Add(ttl)
+    return r.lockToken, true, nil
+}
+
+func (r *memoryCacheRepository) ReleaseRefreshLock(ctx context.Context, token string) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    if r.lockToken == token {
+        r.lockToken = ""
+    }
+    return nil
+}
@@ -0,0 +1,52 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/domain/repositories"
+)
+
+// MemoryEventStream - журнал событий в памяти процесса
+type MemoryEventStream struct {
+    mu     sync.RWMutex
+    events []*models.OutboxEvent
+    limit  int
+}
+
+func NewEventStream(limit int) *MemoryEventStream {
+    return &MemoryEventStream{limit: limit}
+}
+
+func (s *MemoryEventStream) AppendEvent(ctx context.Context, event *models.OutboxEvent) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    // Повторная публикация события игнорируется
+    for _, existing := range s.events {
+        if existing.ID == event.ID {
+            return nil
+        }
+    }
+
+    s.events = append(s.events, copyEvent(event))
+    if s.limit > 0 && len(s.events) > s.limit {
+        s.events = s.events[len(s.events)-s.limit:]
+    }
+    return nil
+}
+
+// Events возвращает события в порядке публикации
+func (s *MemoryEventStream) Events() []*models.OutboxEvent {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+
+    events := make([]*models.OutboxEvent, len(s.events))
+    for i, event := range s.events {
+        events[i] = copyEvent(event)
+    }
+    return events
+}
+
+var _ repositories.EventStream = (*MemoryEventStream)(nil)
@@ -0,0 +1,711 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/domain/repositories"
+	"incident-system/pkg/geo"
+)
+
+// memoryIncidentRepository хранит инциденты и проверки локаций в памяти процесса.
+// Предназначен для тестов, демонстраций и запуска без внешних зависимостей.
+type memoryIncidentRepository struct {
+    mu         sync.RWMutex
+    incidents  map[int64]*models.Incident
+    checks     []*models.LocationCheck
+    nextID     int64
+    nextCheck  int64
+    alerted    map[int64]map[string]time.Time // инцидент -> пользователь -> время оповещения
+    updates    []*models.IncidentUpdate       // хроника в порядке добавления
+    nextUpdate int64
+    geometries []*models.GeometryHistoryEntry // прежние зоны в порядке замены
+    nextGeometry int64
+    outbox     *memoryOutboxRepository
+    referrers  []incidentReferrer
+}
+
+// incidentReferrer - репозиторий, записи которого ссылаются на инциденты
+type incidentReferrer interface {
+    // redirectIncident переносит ссылки с инцидента from на to
+    redirectIncident(from, to int64)
+}
+
+// IncidentReferrers - репозитории памяти, ссылки которых на дубликат Merge переносит
+// на объединенный инцидент; незаданные пропускаются
+type IncidentReferrers struct {
+    WatchZones  WatchZoneRepository
+    Reports     ReportRepository
+    Attachments AttachmentRepository
+}
+
+// IncidentRepository - инциденты в памяти, на которые ссылается TaxonomyReferrers
+type IncidentRepository interface {
+    repositories.IncidentRepository
+    taxonomyReferrer
+}
+
+func NewIncidentRepository() IncidentRepository {
+    repo, _ := NewIncidentRepositoryWithOutbox(IncidentReferrers{})
+    return repo
+}
+
+// NewIncidentRepositoryWithOutbox возвращает репозиторий инцидентов и outbox,
+// в который репозиторий записывает события изменений
+func NewIncidentRepositoryWithOutbox(referrers IncidentReferrers) (IncidentRepository, repositories.OutboxRepository) {
+    outbox := &memoryOutboxRepository{}
+    repo := &memoryIncidentRepository{
+        incidents: make(map[int64]*models.Incident),
+        alerted:   make(map[int64]map[string]time.Time),
+        outbox:    outbox,
+    }
+    if referrers.WatchZones != nil {
+        repo.referrers = append(repo.referrers, referrers.WatchZones)
+    }
+    if referrers.Reports != nil {
+        repo.referrers = append(repo.referrers, referrers.Reports)
+    }
+    if referrers.Attachments != nil {
+        repo.referrers = append(repo.referrers, referrers.Attachments)
+    }
+    return repo, outbox
+}
+
+// copyIncident возвращает независимую копию, чтобы вызывающий код не изменял хранилище
+func copyIncident(incident *models.Incident) *models.Incident {
+    c := *incident
+    if incident.Polygon != nil {
+        c.Polygon = append([]models.GeoPoint(nil), incident.Polygon...)
+    }
+    if incident.Tags != nil {
+        c.Tags = append([]string(nil), incident.Tags...)
+    }
+    if incident.ExpiresAt != nil {
+        expiresAt := *incident.ExpiresAt
+        c.ExpiresAt = &expiresAt
+    }
+    if incident.MergedInto != nil {
+        mergedInto := *incident.MergedInto
+        c.MergedInto = &mergedInto
+    }
+    if incident.ExternalID != nil {
+        externalID := *incident.ExternalID
+        c.ExternalID = &externalID
+    }
+    return &c
+}
+
+func (r *memoryIncidentRepository) Create(ctx context.Context, incident *models.Incident) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    if incident.ExternalID != nil && r.findByExternalID(*incident.ExternalID) != nil {
+        return repositories.ErrExternalIDExists
+    }
+    r.applyChange(models.IncidentChange{Kind: models.ChangeCreate, Incident: incident}, "")
+    return nil
+}
+
+func (r *memoryIncidentRepository) FindByID(ctx context.Context, id int64) (*models.Incident, error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    incident, ok := r.incidents[id]
+    if !ok {
+        return nil, nil
+    }
+    return copyIncident(incident), nil
+}
+
+func (r *memoryIncidentRepository) FindByExternalID(ctx context.Context, externalID string) (*models.Incident, error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    if incident := r.findByExternalID(externalID); incident != nil {
+        return copyIncident(incident), nil
+    }
+    return nil, nil
+}
+
+func (r *memoryIncidentRepository) findByExternalID(externalID string) *models.Incident {
+    for _, incident := range r.incidents {
+        if incident.ExternalID != nil && *incident.ExternalID == externalID {
+            return incident
+        }
+    }
+    return nil
+}
+
+func (r *memoryIncidentRepository) FindAll(ctx context.Context, filter models.IncidentFilter) ([]*models.Incident, error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    var incidents []*models.Incident
+    for _, incident := range r.incidents {
+        if !filter.Matches(incident) {
+            continue
+        }
+        incidents = append(incidents, copyIncident(incident))
+    }
+
+    // Как и в Postgres: сначала новые
+    sort.Slice(incidents, func(i, j int) bool {
+        if incidents[i].CreatedAt.Equal(incidents[j].CreatedAt) {
+            return incidents[i].ID > incidents[j].ID
+        }
+        return incidents[i].CreatedAt.After(incidents[j].CreatedAt)
+    })
+
+    if filter.Offset >= len(incidents) {
+        return nil, nil
+    }
+    incidents = incidents[filter.Offset:]
+    if filter.Limit >= 0 && filter.Limit < len(incidents) {
+        incidents = incidents[:filter.Limit]
+    }
+
+    return incidents, nil
+}
+
+func (r *memoryIncidentRepository) Update(ctx context.Context, incident *models.Incident) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    change := models.IncidentChange{Kind: models.ChangeUpdate, Incident: incident}
+    if r.versionConflict(change, nil) {
+        return repositories.ErrVersionConflict
+    }
+    r.applyChange(change, "")
+    return nil
+}
+
+func (r *memoryIncidentRepository) Delete(ctx context.Context, id int64, version int64) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    change := models.IncidentChange{Kind: models.ChangeDelete, Incident: &models.Incident{ID: id, Version: version}}
+    if r.versionConflict(change, nil) {
+        return repositories.ErrVersionConflict
+    }
+    r.applyChange(change, "")
+    return nil
+}
+
+func (r *memoryIncidentRepository) ApplyBatch(ctx context.Context, batchID string, changes []models.IncidentChange) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    // Все проверки до первого изменения, чтобы пакет применялся целиком или никак
+    externalIDs := make(map[string]bool)
+    versions := make(map[int64]int64)
+    for _, change := range changes {
+        switch change.Kind {
+        case models.ChangeCreate:
+            if externalID := change.Incident.ExternalID; externalID != nil {
+                if externalIDs[*externalID] || r.findByExternalID(*externalID) != nil {
+                    return repositories.ErrExternalIDExists
+                }
+                externalIDs[*externalID] = true
+            }
+        case models.ChangeUpdate, models.ChangeDelete:
+            if r.versionConflict(change, versions) {
+                return repositories.ErrVersionConflict
+            }
+        default:
+            return fmt.Errorf("unknown incident change %q", change.Kind)
+        }
+    }
+
+    for _, change := range changes {
+        r.applyChange(change, batchID)
+    }
+    return nil
+}
+
+// versionConflict проверяет версию, ожидаемую изменением update или delete
+// (для delete 0 - любая); versions - версии после предыдущих изменений пакета.
+// Вызывается под r.mu
+func (r *memoryIncidentRepository) versionConflict(change models.IncidentChange, versions map[int64]int64) bool {
+    stored, ok := r.incidents[change.Incident.ID]
+    if !ok {
+        return false
+    }
+
+    current, planned := versions[stored.ID]
+    if !planned {
+        current = stored.Version
+    }
+    if versions != nil {
+        versions[stored.ID] = current + 1
+    }
+    if change.Kind == models.ChangeDelete && change.Incident.Version == 0 {
+        return false
+    }
+    return change.Incident.Version != current
+}
+
+// applyChange применяет проверенное изменение и записывает его событие; вызывается под r.mu
+func (r *memoryIncidentRepository) applyChange(change models.IncidentChange, batchID string) {
+    incident := change.Incident
+    var event *models.OutboxEvent
+    var before *models.Incident // состояние до update, чтобы сравнить зоны
+
+    switch change.Kind {
+    case models.ChangeCreate:
+        r.nextID++
+        now := time.Now()
+        incident.ID = r.nextID
+        incident.CreatedAt = now
+        incident.UpdatedAt = now
+        incident.Version = 1
+
+        r.incidents[incident.ID] = copyIncident(incident)
+        event = models.NewOutboxEvent(models.EventIncidentCreated, copyIncident(incident))
+    case models.ChangeUpdate:
+        stored, ok := r.incidents[incident.ID]
+        if !ok {
+            return
+        }
+
+        before = copyIncident(stored)
+        incident.UpdatedAt = time.Now()
+        stored.Title = incident.Title
+        stored.Description = incident.Description
+        stored.Severity = incident.Severity
+        stored.Category = incident.Category
+        stored.Tags = copyIncident(incident).Tags
+        stored.Latitude = incident.Latitude
+        stored.Longitude = incident.Longitude
+        stored.Radius = incident.Radius
+        stored.Polygon = copyIncident(incident).Polygon
+        stored.Active = incident.Active
+        stored.Public = incident.Public
+        stored.ExpiresAt = copyIncident(incident).ExpiresAt
+        stored.UpdatedAt = incident.UpdatedAt
+        stored.Version++
+        incident.Version = stored.Version
+        event = models.NewIncidentUpdateEvent(before, copyIncident(stored))
+    case models.ChangeDelete:
+        // Деактивация вместо удаления
+        stored, ok := r.incidents[incident.ID]
+        if !ok {
+            return
+        }
+
+        stored.Active = false
+        stored.UpdatedAt = time.Now()
+        stored.Version++
+        *incident = *copyIncident(stored)
+        event = models.NewOutboxEvent(models.EventIncidentDeleted, copyIncident(stored))
+    default:
+        return
+    }
+
+    event.BatchID = batchID
+    r.outbox.append(event)
+    if before != nil {
+        r.recordGeometryChange(before, event.Incident, batchID)
+    }
+}
+
+// recordGeometryChange сохраняет прежнюю зону before в истории и записывает событие
+// incident.geometry_changed, если зона after отличается; вызывается под r.mu
+func (r *memoryIncidentRepository) recordGeometryChange(before, after *models.Incident, batchID string) {
+    event, entry := models.NewGeometryChangedEvent(before, after)
+    if event == nil {
+        return
+    }
+
+    r.nextGeometry++
+    entry.ID = r.nextGeometry
+    r.geometries = append(r.geometries, entry)
+    event.BatchID = batchID
+    r.outbox.append(event)
+}
+
+func (r *memoryIncidentRepository) ExpireDue(ctx context.Context, now time.Time) ([]*models.Incident, error) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    var expired []*models.Incident
+    for _, stored := range r.incidents {
+        if stored.Active && stored.Expired(now) {
+            expired = append(expired, stored)
+        }
+    }
+    sort.Slice(expired, func(i, j int) bool { return expired[i].ID < expired[j].ID })
+
+    result := make([]*models.Incident, 0, len(expired))
+    for _, stored := range expired {
+        stored.Active = false
+        stored.UpdatedAt = now
+        stored.Version++
+        event := models.NewOutboxEvent(models.EventIncidentExpired, copyIncident(stored))
+        event.ChangedFields = []string{models.FieldActive}
+        r.outbox.append(event)
+        result = append(result, copyIncident(stored))
+    }
+    return result, nil
+}
+
+func (r *memoryIncidentRepository) Merge(ctx context.Context, source, target *models.Incident) (bool, error) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    stored, ok := r.incidents[target.ID]
+    duplicate, found := r.incidents[source.ID]
+    if !ok || !found || stored.MergedInto != nil || duplicate.MergedInto != nil {
+        return false, nil
+    }
+
+    now := time.Now()
+    before := copyIncident(stored)
+    updated := copyIncident(target)
+    stored.Latitude = updated.Latitude
+    stored.Longitude = updated.Longitude
+    stored.Severity = updated.Severity
+    stored.Tags = updated.Tags
+    stored.Radius = updated.Radius
+    stored.Polygon = updated.Polygon
+    stored.Active = updated.Active
+    stored.ExpiresAt = updated.ExpiresAt
+    stored.UpdatedAt = now
+    stored.Version++
+    target.UpdatedAt = now
+    target.Version = stored.Version
+
+    targetID := target.ID
+    duplicate.Active = false
+    duplicate.MergedInto = &targetID
+    duplicate.UpdatedAt = now
+    duplicate.Version++
+
+    // Ссылки на дубликат переходят к target, в том числе перенаправления
+    // ранее присоединенных к нему инцидентов
+    for _, incident := range r.incidents {
+        if incident.MergedInto != nil && *incident.MergedInto == source.ID {
+            incident.MergedInto = &targetID
+            incident.Version++
+        }
+    }
+    for _, check := range r.checks {
+        check.IncidentIDs = replaceIncidentID(check.IncidentIDs, source.ID, targetID)
+    }
+    if users := r.alerted[source.ID]; users != nil {
+        if r.alerted[targetID] == nil {
+            r.alerted[targetID] = make(map[string]time.Time)
+        }
+        for userID, at := range users {
+            if _, ok := r.alerted[targetID][userID]; !ok {
+                r.alerted[targetID][userID] = at
+            }
+        }
+        delete(r.alerted, source.ID)
+    }
+    for _, update := range r.updates {
+        if update.IncidentID == source.ID {
+            update.IncidentID = targetID
+        }
+    }
+    for _, referrer := range r.referrers {
+        referrer.redirectIncident(source.ID, targetID)
+    }
+
+    r.outbox.append(models.NewIncidentUpdateEvent(before, copyIncident(stored)))
+    r.recordGeometryChange(before, copyIncident(stored), "")
+    r.outbox.append(models.NewOutboxEvent(models.EventIncidentMerged, copyIncident(duplicate)))
+    *source = *copyIncident(duplicate)
+    return true, nil
+}
+
+func (r *memoryIncidentRepository) FindGeometryHistory(ctx context.Context, incidentID int64) ([]*models.GeometryHistoryEntry, error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    var history []*models.GeometryHistoryEntry
+    for i := len(r.geometries) - 1; i >= 0; i-- {
+        if entry := r.geometries[i]; entry.IncidentID == incidentID {
+            c := *entry
+            c.Polygon = append([]models.GeoPoint(nil), entry.Polygon...)
+            history = append(history, &c)
+        }
+    }
+    return history, nil
+}
+
+func (r *memoryIncidentRepository) PostUpdate(ctx context.Context, update *models.IncidentUpdate) (bool, error) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    incident, ok := r.incidents[update.IncidentID]
+    if !ok || incident.MergedInto != nil {
+        return false, nil
+    }
+
+    r.nextUpdate++
+    update.ID = r.nextUpdate
+    update.CreatedAt = time.Now()
+    stored := *update
+    r.updates = append(r.updates, &stored)
+
+    r.outbox.append(models.NewUpdatePostedEvent(copyIncident(incident), update))
+    return true, nil
+}
+
+func (r *memoryIncidentRepository) FindUpdates(ctx context.Context, incidentID int64, publicOnly bool) ([]*models.IncidentUpdate, error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    var updates []*models.IncidentUpdate
+    for i := len(r.updates) - 1; i >= 0; i-- {
+        update := r.updates[i]
+        if update.IncidentID == incidentID && (update.Public || !publicOnly) {
+            c := *update
+            updates = append(updates, &c)
+        }
+    }
+    return updates, nil
+}
+
+func (r *memoryIncidentRepository) LatestPublicUpdates(ctx context.Context, incidentIDs []int64) (map[int64]*models.IncidentUpdate, error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    wanted := make(map[int64]bool, len(incidentIDs))
+    for _, id := range incidentIDs {
+        wanted[id] = true
+    }
+
+    latest := make(map[int64]*models.IncidentUpdate)
+    for _, update := range r.updates {
+        if update.Public && wanted[update.IncidentID] {
+            c := *update
+            latest[update.IncidentID] = &c
+        }
+    }
+    return latest, nil
+}
+
+func (r *memoryIncidentRepository) FindNearLocation(ctx context.Context, lat, lng float64, radiusKm float64) ([]*models.Incident, error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    type candidate struct {
+        incident *models.Incident
+        distance float64
+    }
+
+    var candidates []candidate
+    for _, incident := range r.incidents {
+        if !incident.Active {
+            continue
+        }
+        if distance := incident.DistanceToZoneKm(lat, lng); distance <= radiusKm {
+            candidates = append(candidates, candidate{incident: incident, distance: distance})
+        }
+    }
+
+    sort.Slice(candidates, func(i, j int) bool {
+        return candidates[i].distance < candidates[j].distance
+    })
+
+    incidents := make([]*models.Incident, 0, len(candidates))
+    for _, c := range candidates {
+        incidents = append(incidents, copyIncident(c.incident))
+    }
+    return incidents, nil
+}
+
+func (r *memoryIncidentRepository) FindContainingLocation(ctx context.Context, lat, lng float64) ([]*models.Incident, error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    var incidents []*models.Incident
+    for _, incident := range r.incidents {
+        if incident.Active && incident.Contains(lat, lng) {
+            incidents = append(incidents, copyIncident(incident))
+        }
+    }
+
+    sort.Slice(incidents, func(i, j int) bool { return incidents[i].ID < incidents[j].ID })
+    return incidents, nil
+}
+
+func (r *memoryIncidentRepository) SaveLocationCheck(ctx context.Context, check *models.LocationCheck) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    r.nextCheck++
+    check.ID = r.nextCheck
+
+    stored := *check
+    stored.IncidentIDs = append([]int64(nil), check.IncidentIDs...)
+    r.checks = append(r.checks, &stored)
+    return nil
+}
+
+// replaceIncidentID заменяет в ids инцидент from на to, не повторяя to
+func replaceIncidentID(ids []int64, from, to int64) []int64 {
+    if !slices.Contains(ids, from) {
+        return ids
+    }
+    replaced := make([]int64, 0, len(ids))
+    for _, id := range ids {
+        if id == from {
+            id = to
+        }
+        if !slices.Contains(replaced, id) {
+            replaced = append(replaced, id)
+        }
+    }
+    return replaced
+}
+
+func (r *memoryIncidentRepository) FindLatestLocations(ctx context.Context, since time.Time, lat, lng, radiusKm float64) ([]*models.LocationCheck, error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    latest := make(map[string]*models.LocationCheck)
+    for _, check := range r.checks {
+        if check.Timestamp.Before(since) {
+            continue
+        }
+        if prev, ok := latest[check.UserID]; !ok || !check.Timestamp.Before(prev.Timestamp) {
+            latest[check.UserID] = check
+        }
+    }
+
+    minLat, _, maxLat, _ := geo.BoundingBox(lat, lng, radiusKm)
+    lngRanges := geo.LngRanges(lat, lng, radiusKm)
+    var checks []*models.LocationCheck
+    for _, check := range latest {
+        if check.Latitude < minLat || check.Latitude > maxLat || !geo.InLngRanges(lngRanges, check.Longitude) {
+            continue
+        }
+        c := *check
+        c.IncidentIDs = nil
+        checks = append(checks, &c)
+    }
+
+    sort.Slice(checks, func(i, j int) bool { return checks[i].UserID < checks[j].UserID })
+    return checks, nil
+}
+
+func (r *memoryIncidentRepository) MarkAlerted(ctx context.Context, incidentID int64, userIDs []string, at time.Time) ([]string, error) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    users := r.alerted[incidentID]
+    if users == nil {
+        users = make(map[string]time.Time)
+        r.alerted[incidentID] = users
+    }
+
+    var marked []string
+    for _, userID := range userIDs {
+        if _, ok := users[userID]; ok {
+            continue
+        }
+        users[userID] = at
+        marked = append(marked, userID)
+    }
+    return marked, nil
+}
+
+func (r *memoryIncidentRepository) UnmarkAlerted(ctx context.Context, incidentID int64, userIDs []string) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    users := r.alerted[incidentID]
+    for _, userID := range userIDs {
+        delete(users, userID)
+    }
+    return nil
+}
+
+func (r *memoryIncidentRepository) GetStats(ctx context.Context, minutes int, filter models.IncidentFilter) ([]*models.IncidentStats, error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    since := time.Now().Add(-time.Duration(minutes) * time.Minute)
+
+    // Количество уникальных пользователей по зонам, 0 - проверки вне зон
+    users := make(map[int64]map[string]struct{})
+    for _, check := range r.checks {
+        if check.Timestamp.Before(since) {
+            continue
+        }
+        zones := check.IncidentIDs
+        if len(zones) == 0 {
+            zones = []int64{0}
+        }
+        for _, zone := range zones {
+            if !filter.IsEmpty() {
+                // С фильтром учитываются только проверки в зонах подходящих инцидентов
+                incident, ok := r.incidents[zone]
+                if !ok || !filter.Matches(incident) {
+                    continue
+                }
+            }
+            if users[zone] == nil {
+                users[zone] = make(map[string]struct{})
+            }
+            users[zone][check.UserID] = struct{}{}
+        }
+    }
+
+    stats := make([]*models.IncidentStats, 0, len(users))
+    for zone, set := range users {
+        stat := &models.IncidentStats{UserCount: int64(len(set))}
+        if zone != 0 {
+            id := zone
+            stat.ZoneID = &id
+        }
+        stats = append(stats, stat)
+    }
+
+    sort.Slice(stats, func(i, j int) bool {
+        return zoneKey(stats[i]) < zoneKey(stats[j])
+    })
+    return stats, nil
+}
+
+func (r *memoryIncidentRepository) GetActiveIncidents(ctx context.Context) ([]*models.Incident, error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    var incidents []*models.Incident
+    for _, incident := range r.incidents {
+        if incident.Active {
+            incidents = append(incidents, copyIncident(incident))
+        }
+    }
+
+    sort.Slice(incidents, func(i, j int) bool { return incidents[i].ID < incidents[j].ID })
+    return incidents, nil
+}
+
+func (r *memoryIncidentRepository) CountAll(ctx context.Context, filter models.IncidentFilter) (int, error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    count := 0
+    for _, incident := range r.incidents {
+        if filter.Matches(incident) {
+            count++
+        }
+    }
+    return count, nil
+}
+
+func zoneKey(stat *models.IncidentStats) int64 {
+    if stat.ZoneID == nil {
+        return 0
+    }
+    return *stat.ZoneID
+}
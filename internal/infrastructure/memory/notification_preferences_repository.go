@@ -0,0 +1,86 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/domain/repositories"
+)
+
+type memoryNotificationPreferencesRepository struct {
+    mu    sync.Mutex
+    prefs map[string]*models.NotificationPreferences
+    sent  map[string][]time.Time // пользователь -> время оповещений в окне
+}
+
+// NotificationPreferencesRepository - настройки оповещений в памяти, на которые
+// ссылается TaxonomyReferrers
+type NotificationPreferencesRepository interface {
+    repositories.NotificationPreferencesRepository
+    taxonomyReferrer
+}
+
+func NewNotificationPreferencesRepository() NotificationPreferencesRepository {
+    return &memoryNotificationPreferencesRepository{
+        prefs: make(map[string]*models.NotificationPreferences),
+        sent:  make(map[string][]time.Time),
+    }
+}
+
+func copyPreferences(prefs *models.NotificationPreferences) *models.NotificationPreferences {
+    c := *prefs
+    c.Categories = append([]string{}, prefs.Categories...)
+    if prefs.QuietHours != nil {
+        quiet := *prefs.QuietHours
+        c.QuietHours = &quiet
+    }
+    return &c
+}
+
+func (r *memoryNotificationPreferencesRepository) Get(ctx context.Context, userID string) (*models.NotificationPreferences, error) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    prefs, ok := r.prefs[userID]
+    if !ok {
+        return nil, nil
+    }
+    return copyPreferences(prefs), nil
+}
+
+func (r *memoryNotificationPreferencesRepository) Save(ctx context.Context, prefs *models.NotificationPreferences) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    r.prefs[prefs.UserID] = copyPreferences(prefs)
+    return nil
+}
+
+func (r *memoryNotificationPreferencesRepository) Delete(ctx context.Context, userID string) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    delete(r.prefs, userID)
+    return nil
+}
+
+func (r *memoryNotificationPreferencesRepository) ReserveAlert(ctx context.Context, userID string, since time.Time, limit int, at time.Time) (bool, error) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    var recent []time.Time
+    for _, sentAt := range r.sent[userID] {
+        if !sentAt.Before(since) {
+            recent = append(recent, sentAt)
+        }
+    }
+
+    if len(recent) >= limit {
+        r.sent[userID] = recent
+        return false, nil
+    }
+    r.sent[userID] = append(recent, at)
+    return true, nil
+}
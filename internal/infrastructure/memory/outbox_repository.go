@@ -0,0 +1,154 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/domain/repositories"
+)
+
+// memoryOutboxRepository хранит события изменения инцидентов. Запись выполняется
+// memoryIncidentRepository под его блокировкой, что аналогично общей транзакции.
+type memoryOutboxRepository struct {
+    mu     sync.Mutex
+    events []*models.OutboxEvent
+    nextID int64
+
+    leaseOwner   string
+    leaseExpires time.Time
+}
+
+func (r *memoryOutboxRepository) append(event *models.OutboxEvent) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    r.nextID++
+    event.ID = r.nextID
+    r.events = append(r.events, event)
+}
+
+func copyEvent(event *models.OutboxEvent) *models.OutboxEvent {
+    c := *event
+    if event.Incident != nil {
+        c.Incident = copyIncident(event.Incident)
+    }
+    c.ChangedFields = append([]string(nil), event.ChangedFields...)
+    if event.Update != nil {
+        update := *event.Update
+        c.Update = &update
+    }
+    if event.Geometry != nil {
+        geometry := *event.Geometry
+        geometry.Previous.Polygon = append([]models.GeoPoint(nil), geometry.Previous.Polygon...)
+        geometry.Current.Polygon = append([]models.GeoPoint(nil), geometry.Current.Polygon...)
+        c.Geometry = &geometry
+    }
+    c.Delivered = append([]string(nil), event.Delivered...)
+    return &c
+}
+
+func (r *memoryOutboxRepository) find(id int64) *models.OutboxEvent {
+    for _, event := range r.events {
+        if event.ID == id {
+            return event
+        }
+    }
+    return nil
+}
+
+func (r *memoryOutboxRepository) FetchPending(ctx context.Context, now time.Time, limit int) ([]*models.OutboxEvent, error) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    // Инциденты, более раннее событие которых ждет повтора
+    waiting := make(map[int64]bool)
+
+    var events []*models.OutboxEvent
+    for _, event := range r.events {
+        if event.PublishedAt != nil || event.DeadLetteredAt != nil || waiting[event.IncidentID] {
+            continue
+        }
+        if event.NextAttemptAt != nil && event.NextAttemptAt.After(now) {
+            waiting[event.IncidentID] = true
+            continue
+        }
+        events = append(events, copyEvent(event))
+        if len(events) == limit {
+            break
+        }
+    }
+    return events, nil
+}
+
+func (r *memoryOutboxRepository) MarkDelivered(ctx context.Context, id int64, publisher string) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    if event := r.find(id); event != nil && !event.DeliveredTo(publisher) {
+        event.Delivered = append(event.Delivered, publisher)
+    }
+    return nil
+}
+
+func (r *memoryOutboxRepository) MarkPublished(ctx context.Context, id int64) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    if event := r.find(id); event != nil {
+        now := time.Now()
+        event.PublishedAt = &now
+        event.LastError = ""
+    }
+
+    // Опубликованные события больше не нужны - не даем памяти расти
+    pending := r.events[:0]
+    for _, event := range r.events {
+        if event.PublishedAt == nil {
+            pending = append(pending, event)
+        }
+    }
+    r.events = pending
+    return nil
+}
+
+func (r *memoryOutboxRepository) MarkFailed(ctx context.Context, id int64, reason string, retryAt time.Time) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    if event := r.find(id); event != nil {
+        event.Attempts++
+        event.LastError = reason
+        event.NextAttemptAt = &retryAt
+    }
+    return nil
+}
+
+func (r *memoryOutboxRepository) MarkDeadLetter(ctx context.Context, id int64, reason string, at time.Time) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    if event := r.find(id); event != nil {
+        event.Attempts++
+        event.LastError = reason
+        event.DeadLetteredAt = &at
+    }
+    return nil
+}
+
+func (r *memoryOutboxRepository) AcquireLease(ctx context.Context, owner string, ttl time.Duration) (bool, error) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    now := time.Now()
+    if r.leaseOwner != "" && r.leaseOwner != owner && now.Before(r.leaseExpires) {
+        return false, nil
+    }
+
+    r.leaseOwner = owner
+    r.leaseExpires = now.Add(ttl)
+    return true, nil
+}
+
+var _ repositories.OutboxRepository = (*memoryOutboxRepository)(nil)
@@ -0,0 +1,98 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/domain/repositories"
+)
+
+// memoryQueueRepository - FIFO-очередь вебхуков. DequeueWebhook блокируется,
+// пока не появится задача или не будет отменен контекст (аналог BRPOP).
+type memoryQueueRepository struct {
+    mu     sync.Mutex
+    items  []models.WebhookPayload
+    notify chan struct{}
+
+    // delayed упорядочен по DueAt (аналог sorted set в Redis)
+    delayed []models.WebhookTask
+}
+
+func NewQueueRepository() repositories.QueueRepository {
+    return &memoryQueueRepository{
+        notify: make(chan struct{}, 1),
+    }
+}
+
+func (r *memoryQueueRepository) EnqueueWebhook(ctx context.Context, payload models.WebhookPayload) error {
+    r.mu.Lock()
+    r.items = append(r.items, payload)
+    r.mu.Unlock()
+
+    // Будим одного ожидающего потребителя
+    select {
+    case r.notify <- struct{}{}:
+    default:
+    }
+    return nil
+}
+
+func (r *memoryQueueRepository) DequeueWebhook(ctx context.Context) (*models.WebhookPayload, error) {
+    for {
+        r.mu.Lock()
+        if len(r.items) > 0 {
+            payload := r.items[0]
+            r.items = r.items[1:]
+            remaining := len(r.items)
+            r.mu.Unlock()
+
+            // Если в очереди остались задачи, передаем сигнал следующему потребителю
+            if remaining > 0 {
+                select {
+                case r.notify <- struct{}{}:
+                default:
+                }
+            }
+            return &payload, nil
+        }
+        r.mu.Unlock()
+
+        select {
+        case <-ctx.Done():
+            return nil, ctx.Err()
+        case <-r.notify:
+        }
+    }
+}
+
+func (r *memoryQueueRepository) ScheduleWebhookTask(ctx context.Context, task models.WebhookTask) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    // Вставляем после задач с тем же сроком, сохраняя порядок постановки
+    i := sort.Search(len(r.delayed), func(i int) bool {
+        return r.delayed[i].DueAt.After(task.DueAt)
+    })
+    r.delayed = append(r.delayed, models.WebhookTask{})
+    copy(r.delayed[i+1:], r.delayed[i:])
+    r.delayed[i] = task
+    return nil
+}
+
+func (r *memoryQueueRepository) DueWebhookTasks(ctx context.Context, now time.Time, limit int) ([]models.WebhookTask, error) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    n := 0
+    for n < len(r.delayed) && n < limit && !r.delayed[n].DueAt.After(now) {
+        n++
+    }
+
+    due := make([]models.WebhookTask, n)
+    copy(due, r.delayed[:n])
+    r.delayed = r.delayed[n:]
+    return due, nil
+}
@@ -0,0 +1,235 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/domain/repositories"
+	"incident-system/pkg/geo"
+)
+
+type memoryReportRepository struct {
+    mu      sync.RWMutex
+    reports map[int64]*models.IncidentReport
+    nextID  int64
+}
+
+// ReportRepository - сообщения жителей в памяти, на которые ссылается IncidentReferrers
+type ReportRepository interface {
+    repositories.ReportRepository
+    incidentReferrer
+}
+
+func NewReportRepository() ReportRepository {
+    return &memoryReportRepository{
+        reports: make(map[int64]*models.IncidentReport),
+    }
+}
+
+func copyReport(report *models.IncidentReport) *models.IncidentReport {
+    c := *report
+    if report.IncidentID != nil {
+        incidentID := *report.IncidentID
+        c.IncidentID = &incidentID
+    }
+    if report.ModeratedAt != nil {
+        moderatedAt := *report.ModeratedAt
+        c.ModeratedAt = &moderatedAt
+    }
+    return &c
+}
+
+func (r *memoryReportRepository) Create(ctx context.Context, report *models.IncidentReport, limit models.ReportRateLimit) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    byReporter, byAddress := 0, 0
+    for _, existing := range r.reports {
+        if existing.CreatedAt.Before(limit.Since) {
+            continue
+        }
+        if existing.UserID == report.UserID {
+            byReporter++
+        }
+        if report.ReporterIP != "" && existing.ReporterIP == report.ReporterIP {
+            byAddress++
+        }
+    }
+    if (limit.PerReporter > 0 && byReporter >= limit.PerReporter) ||
+        (limit.PerAddress > 0 && byAddress >= limit.PerAddress) {
+        return repositories.ErrRateLimited
+    }
+
+    r.nextID++
+    report.ID = r.nextID
+    r.reports[report.ID] = copyReport(report)
+    return nil
+}
+
+func (r *memoryReportRepository) FindByID(ctx context.Context, id int64) (*models.IncidentReport, error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    report, ok := r.reports[id]
+    if !ok {
+        return nil, nil
+    }
+    return copyReport(report), nil
+}
+
+func (r *memoryReportRepository) FindAll(ctx context.Context, filter models.ReportFilter) ([]*models.IncidentReport, int, error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    var reports []*models.IncidentReport
+    for _, report := range r.reports {
+        if filter.Status != "" && report.Status != filter.Status {
+            continue
+        }
+        if filter.UserID != "" && report.UserID != filter.UserID {
+            continue
+        }
+        if filter.IncidentID != nil && (report.IncidentID == nil || *report.IncidentID != *filter.IncidentID) {
+            continue
+        }
+        reports = append(reports, report)
+    }
+
+    if filter.Status == models.ReportPending {
+        sort.Slice(reports, func(i, j int) bool {
+            a, b := reports[i], reports[j]
+            if a.AbuseScore != b.AbuseScore {
+                return a.AbuseScore < b.AbuseScore
+            }
+            if !a.CreatedAt.Equal(b.CreatedAt) {
+                return a.CreatedAt.Before(b.CreatedAt)
+            }
+            return a.ID < b.ID
+        })
+    } else {
+        sort.Slice(reports, func(i, j int) bool {
+            a, b := reports[i], reports[j]
+            if !a.CreatedAt.Equal(b.CreatedAt) {
+                return a.CreatedAt.After(b.CreatedAt)
+            }
+            return a.ID > b.ID
+        })
+    }
+
+    total := len(reports)
+    start := filter.Offset
+    if start > total {
+        start = total
+    }
+    end := total
+    if filter.Limit > 0 && start+filter.Limit < end {
+        end = start + filter.Limit
+    }
+
+    page := make([]*models.IncidentReport, 0, end-start)
+    for _, report := range reports[start:end] {
+        page = append(page, copyReport(report))
+    }
+    return page, total, nil
+}
+
+func (r *memoryReportRepository) Claim(ctx context.Context, id int64, at, staleBefore time.Time) (bool, error) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    existing, ok := r.reports[id]
+    if !ok {
+        return false, nil
+    }
+    stale := existing.Status == models.ReportApproving && existing.ModeratedAt != nil && existing.ModeratedAt.Before(staleBefore)
+    if existing.Status != models.ReportPending && !stale {
+        return false, nil
+    }
+
+    existing.Status = models.ReportApproving
+    existing.ModeratedAt = &at
+    r.reports[id] = copyReport(existing)
+    return true, nil
+}
+
+func (r *memoryReportRepository) Moderate(ctx context.Context, report *models.IncidentReport, from string) (bool, error) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    existing, ok := r.reports[report.ID]
+    if !ok || existing.Status != from {
+        return false, nil
+    }
+
+    existing.Status = report.Status
+    existing.IncidentID = report.IncidentID
+    existing.Abusive = report.Abusive
+    existing.Note = report.Note
+    existing.ModeratedAt = report.ModeratedAt
+    r.reports[report.ID] = copyReport(existing)
+    return true, nil
+}
+
+func (r *memoryReportRepository) ReporterStats(ctx context.Context, userID string, since time.Time) (*models.ReporterStats, error) {
+    return r.stats(since, func(report *models.IncidentReport) bool { return report.UserID == userID }), nil
+}
+
+func (r *memoryReportRepository) AddressStats(ctx context.Context, ip string, since time.Time) (*models.ReporterStats, error) {
+    return r.stats(since, func(report *models.IncidentReport) bool { return report.ReporterIP == ip }), nil
+}
+
+func (r *memoryReportRepository) stats(since time.Time, match func(*models.IncidentReport) bool) *models.ReporterStats {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    stats := &models.ReporterStats{}
+    for _, report := range r.reports {
+        if report.CreatedAt.Before(since) || !match(report) {
+            continue
+        }
+        stats.Add(report.Status, report.Abusive, 1)
+    }
+    return stats
+}
+
+func (r *memoryReportRepository) FindPendingNear(ctx context.Context, lat, lng, radiusKm float64, since time.Time) ([]*models.IncidentReport, error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    minLat, _, maxLat, _ := geo.BoundingBox(lat, lng, radiusKm)
+    lngRanges := geo.LngRanges(lat, lng, radiusKm)
+    var reports []*models.IncidentReport
+    for _, report := range r.reports {
+        if report.Status != models.ReportPending || report.CreatedAt.Before(since) {
+            continue
+        }
+        if report.Latitude < minLat || report.Latitude > maxLat || !geo.InLngRanges(lngRanges, report.Longitude) {
+            continue
+        }
+        reports = append(reports, copyReport(report))
+    }
+
+    sort.Slice(reports, func(i, j int) bool {
+        if reports[i].CreatedAt.Equal(reports[j].CreatedAt) {
+            return reports[i].ID < reports[j].ID
+        }
+        return reports[i].CreatedAt.Before(reports[j].CreatedAt)
+    })
+    return reports, nil
+}
+
+// redirectIncident переносит ссылки Merge с дубликата from на инцидент to
+func (r *memoryReportRepository) redirectIncident(from, to int64) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    for _, report := range r.reports {
+        if report.IncidentID != nil && *report.IncidentID == from {
+            id := to
+            report.IncidentID = &id
+        }
+    }
+}
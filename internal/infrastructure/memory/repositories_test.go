@@ -0,0 +1,91 @@
+package memory
+
+import (
+	"testing"
+	"time"
+
+	"incident-system/internal/domain/repositories"
+	"incident-system/internal/domain/repositories/repotest"
+)
+
+func TestIncidentRepository(t *testing.T) {
+    repotest.RunIncidentRepositorySuite(t, func(t *testing.T) repositories.IncidentRepository {
+        return NewIncidentRepository()
+    })
+}
+
+func TestOutboxRepository(t *testing.T) {
+    repotest.RunOutboxRepositorySuite(t, func(t *testing.T) (repositories.IncidentRepository, repositories.OutboxRepository) {
+        return NewIncidentRepositoryWithOutbox(IncidentReferrers{})
+    })
+}
+
+func TestIncidentMerge(t *testing.T) {
+    repotest.RunIncidentMergeSuite(t, func(t *testing.T) repotest.MergeRepositories {
+        watchZones, reports, attachments := NewWatchZoneRepository(), NewReportRepository(), NewAttachmentRepository()
+        incidents, outbox := NewIncidentRepositoryWithOutbox(IncidentReferrers{
+            WatchZones:  watchZones,
+            Reports:     reports,
+            Attachments: attachments,
+        })
+        return repotest.MergeRepositories{
+            Incidents:   incidents,
+            Outbox:      outbox,
+            WatchZones:  watchZones,
+            Reports:     reports,
+            Attachments: attachments,
+        }
+    })
+}
+
+func TestCacheRepository(t *testing.T) {
+    repotest.RunCacheRepositorySuite(t, func(t *testing.T) repositories.CacheRepository {
+        return NewCacheRepository(time.Minute, time.Minute)
+    })
+}
+
+func TestQueueRepository(t *testing.T) {
+    repotest.RunQueueRepositorySuite(t, func(t *testing.T) repositories.QueueRepository {
+        return NewQueueRepository()
+    })
+}
+
+func TestWebhookRepositories(t *testing.T) {
+    repotest.RunWebhookSubscriptionRepositorySuite(t, func(t *testing.T) repositories.WebhookSubscriptionRepository {
+        return NewSubscriptionRepository()
+    })
+    repotest.RunWebhookDeliveryRepositorySuite(t, func(t *testing.T) repositories.WebhookDeliveryRepository {
+        return NewDeliveryRepository(100)
+    })
+}
+
+func TestWatchZoneRepository(t *testing.T) {
+    repotest.RunWatchZoneRepositorySuite(t, func(t *testing.T) (repositories.IncidentRepository, repositories.WatchZoneRepository) {
+        return NewIncidentRepository(), NewWatchZoneRepository()
+    })
+}
+
+func TestNotificationPreferencesRepository(t *testing.T) {
+    repotest.RunNotificationPreferencesRepositorySuite(t, func(t *testing.T) repositories.NotificationPreferencesRepository {
+        return NewNotificationPreferencesRepository()
+    })
+}
+
+func TestTaxonomyRepository(t *testing.T) {
+    repotest.RunTaxonomyRepositorySuite(t, func(t *testing.T) (repositories.IncidentRepository, repositories.TaxonomyRepository) {
+        incidents := NewIncidentRepository()
+        return incidents, NewTaxonomyRepository(TaxonomyReferrers{Incidents: incidents})
+    })
+}
+
+func TestReportRepository(t *testing.T) {
+    repotest.RunReportRepositorySuite(t, func(t *testing.T) (repositories.IncidentRepository, repositories.ReportRepository) {
+        return NewIncidentRepository(), NewReportRepository()
+    })
+}
+
+func TestAttachmentRepository(t *testing.T) {
+    repotest.RunAttachmentRepositorySuite(t, func(t *testing.T) (repositories.IncidentRepository, repositories.AttachmentRepository) {
+        return NewIncidentRepository(), NewAttachmentRepository()
+    })
+}
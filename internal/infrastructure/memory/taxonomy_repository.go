@@ -0,0 +1,226 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/domain/repositories"
+)
+
+// taxonomyReferrer - репозиторий, записи которого ссылаются на справочники
+type taxonomyReferrer interface {
+    usesCategory(code string) bool
+    usesSeverity(code string) bool
+}
+
+type memoryTaxonomyRepository struct {
+    mu         sync.RWMutex
+    categories map[string]*models.Category
+    levels     map[string]*models.SeverityLevel
+    referrers  []taxonomyReferrer
+}
+
+// TaxonomyReferrers - репозитории памяти, записи которых не дают удалить категорию
+// или уровень опасности; незаданные пропускаются
+type TaxonomyReferrers struct {
+    Incidents     IncidentRepository
+    WatchZones    WatchZoneRepository
+    Preferences   NotificationPreferencesRepository
+    Subscriptions SubscriptionRepository
+}
+
+// NewTaxonomyRepository создает справочники с исходными категориями и шкалой
+func NewTaxonomyRepository(referrers TaxonomyReferrers) repositories.TaxonomyRepository {
+    r := &memoryTaxonomyRepository{
+        categories: make(map[string]*models.Category),
+        levels:     make(map[string]*models.SeverityLevel),
+    }
+    for _, category := range models.DefaultCategories() {
+        r.categories[category.Code] = category
+    }
+    for _, level := range models.DefaultSeverityScale() {
+        r.levels[level.Code] = level
+    }
+    if referrers.Incidents != nil {
+        r.referrers = append(r.referrers, referrers.Incidents)
+    }
+    if referrers.WatchZones != nil {
+        r.referrers = append(r.referrers, referrers.WatchZones)
+    }
+    if referrers.Preferences != nil {
+        r.referrers = append(r.referrers, referrers.Preferences)
+    }
+    if referrers.Subscriptions != nil {
+        r.referrers = append(r.referrers, referrers.Subscriptions)
+    }
+    return r
+}
+
+func (r *memoryTaxonomyRepository) ListCategories(ctx context.Context) ([]*models.Category, error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    categories := make([]*models.Category, 0, len(r.categories))
+    for _, category := range r.categories {
+        c := *category
+        categories = append(categories, &c)
+    }
+
+    sort.Slice(categories, func(i, j int) bool { return categories[i].Code < categories[j].Code })
+    return categories, nil
+}
+
+func (r *memoryTaxonomyRepository) FindCategory(ctx context.Context, code string) (*models.Category, error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    category, ok := r.categories[code]
+    if !ok {
+        return nil, nil
+    }
+    c := *category
+    return &c, nil
+}
+
+func (r *memoryTaxonomyRepository) SaveCategory(ctx context.Context, category *models.Category) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    c := *category
+    if existing, ok := r.categories[category.Code]; ok {
+        c.CreatedAt = existing.CreatedAt
+        category.CreatedAt = existing.CreatedAt
+    }
+    r.categories[category.Code] = &c
+    return nil
+}
+
+func (r *memoryTaxonomyRepository) DeleteCategory(ctx context.Context, code string) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    for _, category := range r.categories {
+        if category.Parent == code {
+            return repositories.ErrInUse
+        }
+    }
+    for _, referrer := range r.referrers {
+        if referrer.usesCategory(code) {
+            return repositories.ErrInUse
+        }
+    }
+
+    delete(r.categories, code)
+    return nil
+}
+
+func (r *memoryTaxonomyRepository) ListSeverityLevels(ctx context.Context) ([]*models.SeverityLevel, error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    levels := make([]*models.SeverityLevel, 0, len(r.levels))
+    for _, level := range r.levels {
+        l := *level
+        levels = append(levels, &l)
+    }
+
+    sort.Slice(levels, func(i, j int) bool { return levels[i].Level < levels[j].Level })
+    return levels, nil
+}
+
+func (r *memoryTaxonomyRepository) SaveSeverityLevel(ctx context.Context, level *models.SeverityLevel) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    l := *level
+    r.levels[level.Code] = &l
+    return nil
+}
+
+func (r *memoryTaxonomyRepository) DeleteSeverityLevel(ctx context.Context, code string) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    for _, referrer := range r.referrers {
+        if referrer.usesSeverity(code) {
+            return repositories.ErrInUse
+        }
+    }
+
+    delete(r.levels, code)
+    return nil
+}
+
+func (r *memoryIncidentRepository) usesCategory(code string) bool {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    for _, incident := range r.incidents {
+        if incident.Category == code {
+            return true
+        }
+    }
+    return false
+}
+
+func (r *memoryIncidentRepository) usesSeverity(code string) bool {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    for _, incident := range r.incidents {
+        if incident.Severity == code {
+            return true
+        }
+    }
+    return false
+}
+
+func (r *memoryWatchZoneRepository) usesCategory(code string) bool {
+    return false
+}
+
+func (r *memoryWatchZoneRepository) usesSeverity(code string) bool {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    for _, zone := range r.zones {
+        if zone.MinSeverity == code {
+            return true
+        }
+    }
+    return false
+}
+
+func (r *memoryNotificationPreferencesRepository) usesCategory(code string) bool {
+    return false
+}
+
+func (r *memoryNotificationPreferencesRepository) usesSeverity(code string) bool {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    for _, prefs := range r.prefs {
+        if prefs.MinSeverity == code || prefs.QuietHoursOverride == code {
+            return true
+        }
+    }
+    return false
+}
+
+func (r *memorySubscriptionRepository) usesCategory(code string) bool {
+    return false
+}
+
+func (r *memorySubscriptionRepository) usesSeverity(code string) bool {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    for _, subscription := range r.subscriptions {
+        if subscription.MinSeverity == code {
+            return true
+        }
+    }
+    return false
+}
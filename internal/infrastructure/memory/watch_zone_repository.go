@@ -0,0 +1,180 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/domain/repositories"
+	"incident-system/pkg/geo"
+)
+
+// watchZoneBounds - описанный прямоугольник зоны для предварительного отбора
+type watchZoneBounds struct {
+    minLat, minLng, maxLat, maxLng float64
+}
+
+func boundsOf(lat, lng, radius float64) watchZoneBounds {
+    minLat, minLng, maxLat, maxLng := geo.BoundingBox(lat, lng, radius/1000.0)
+    return watchZoneBounds{minLat, minLng, maxLat, maxLng}
+}
+
+func (b watchZoneBounds) overlaps(o watchZoneBounds) bool {
+    return b.maxLat >= o.minLat && b.minLat <= o.maxLat && b.maxLng >= o.minLng && b.minLng <= o.maxLng
+}
+
+type memoryWatchZoneRepository struct {
+    mu       sync.RWMutex
+    zones    map[int64]*models.WatchZone
+    bounds   map[int64]watchZoneBounds
+    notified map[int64]map[int64]time.Time // инцидент -> зона -> время оповещения
+    nextID   int64
+}
+
+// WatchZoneRepository - зоны наблюдения в памяти, на которые ссылаются
+// IncidentReferrers и TaxonomyReferrers
+type WatchZoneRepository interface {
+    repositories.WatchZoneRepository
+    incidentReferrer
+    taxonomyReferrer
+}
+
+func NewWatchZoneRepository() WatchZoneRepository {
+    return &memoryWatchZoneRepository{
+        zones:    make(map[int64]*models.WatchZone),
+        bounds:   make(map[int64]watchZoneBounds),
+        notified: make(map[int64]map[int64]time.Time),
+    }
+}
+
+func copyWatchZone(zone *models.WatchZone) *models.WatchZone {
+    c := *zone
+    if zone.Polygon != nil {
+        c.Polygon = append([]models.GeoPoint(nil), zone.Polygon...)
+    }
+    return &c
+}
+
+func (r *memoryWatchZoneRepository) Create(ctx context.Context, zone *models.WatchZone) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    r.nextID++
+    zone.ID = r.nextID
+    r.zones[zone.ID] = copyWatchZone(zone)
+    r.bounds[zone.ID] = boundsOf(zone.Latitude, zone.Longitude, zone.Radius)
+    return nil
+}
+
+func (r *memoryWatchZoneRepository) FindByID(ctx context.Context, id int64) (*models.WatchZone, error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    zone, ok := r.zones[id]
+    if !ok {
+        return nil, nil
+    }
+    return copyWatchZone(zone), nil
+}
+
+func (r *memoryWatchZoneRepository) FindByUser(ctx context.Context, userID string) ([]*models.WatchZone, error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    var zones []*models.WatchZone
+    for _, zone := range r.zones {
+        if zone.UserID == userID {
+            zones = append(zones, copyWatchZone(zone))
+        }
+    }
+
+    sort.Slice(zones, func(i, j int) bool { return zones[i].ID < zones[j].ID })
+    return zones, nil
+}
+
+func (r *memoryWatchZoneRepository) Update(ctx context.Context, zone *models.WatchZone) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    if _, ok := r.zones[zone.ID]; ok {
+        r.zones[zone.ID] = copyWatchZone(zone)
+        r.bounds[zone.ID] = boundsOf(zone.Latitude, zone.Longitude, zone.Radius)
+    }
+    return nil
+}
+
+func (r *memoryWatchZoneRepository) Delete(ctx context.Context, id int64) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    delete(r.zones, id)
+    delete(r.bounds, id)
+    for _, zones := range r.notified {
+        delete(zones, id)
+    }
+    return nil
+}
+
+func (r *memoryWatchZoneRepository) FindIntersecting(ctx context.Context, incident *models.Incident) ([]*models.WatchZone, error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    incidentBounds := boundsOf(incident.Latitude, incident.Longitude, incident.Radius)
+    incidentZone := incident.Zone()
+
+    var zones []*models.WatchZone
+    for id, zone := range r.zones {
+        if r.bounds[id].overlaps(incidentBounds) && geo.ZonesIntersect(zone.Zone(), incidentZone) {
+            zones = append(zones, copyWatchZone(zone))
+        }
+    }
+
+    sort.Slice(zones, func(i, j int) bool { return zones[i].ID < zones[j].ID })
+    return zones, nil
+}
+
+func (r *memoryWatchZoneRepository) MarkNotified(ctx context.Context, incidentID int64, zoneIDs []int64, at time.Time) ([]int64, error) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    zones := r.notified[incidentID]
+    if zones == nil {
+        zones = make(map[int64]time.Time)
+        r.notified[incidentID] = zones
+    }
+
+    var marked []int64
+    for _, id := range zoneIDs {
+        if _, ok := zones[id]; ok {
+            continue
+        }
+        if _, exists := r.zones[id]; !exists {
+            continue
+        }
+        zones[id] = at
+        marked = append(marked, id)
+    }
+    return marked, nil
+}
+
+// redirectIncident переносит ссылки Merge с дубликата from на инцидент to
+func (r *memoryWatchZoneRepository) redirectIncident(from, to int64) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    zones := r.notified[from]
+    if zones == nil {
+        return
+    }
+    if r.notified[to] == nil {
+        r.notified[to] = make(map[int64]time.Time)
+    }
+    for id, at := range zones {
+        if _, ok := r.notified[to][id]; !ok {
+            r.notified[to][id] = at
+        }
+    }
+    delete(r.notified, from)
+}
@@ -0,0 +1,226 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/domain/repositories"
+)
+
+type memorySubscriptionRepository struct {
+    mu            sync.RWMutex
+    subscriptions map[int64]*models.WebhookSubscription
+    nextID        int64
+}
+
+// SubscriptionRepository - подписки на вебхуки в памяти, на которые ссылается TaxonomyReferrers
+type SubscriptionRepository interface {
+    repositories.WebhookSubscriptionRepository
+    taxonomyReferrer
+}
+
+func NewSubscriptionRepository() SubscriptionRepository {
+    return &memorySubscriptionRepository{
+        subscriptions: make(map[int64]*models.WebhookSubscription),
+    }
+}
+
+func copySubscription(subscription *models.WebhookSubscription) *models.WebhookSubscription {
+    c := *subscription
+    c.EventTypes = append([]string{}, subscription.EventTypes...)
+    c.Categories = append([]string{}, subscription.Categories...)
+    c.Tags = append([]string{}, subscription.Tags...)
+    return &c
+}
+
+func (r *memorySubscriptionRepository) Create(ctx context.Context, subscription *models.WebhookSubscription) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    r.nextID++
+    subscription.ID = r.nextID
+    r.subscriptions[subscription.ID] = copySubscription(subscription)
+    return nil
+}
+
+func (r *memorySubscriptionRepository) FindByID(ctx context.Context, id int64) (*models.WebhookSubscription, error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    subscription, ok := r.subscriptions[id]
+    if !ok {
+        return nil, nil
+    }
+    return copySubscription(subscription), nil
+}
+
+func (r *memorySubscriptionRepository) FindAll(ctx context.Context) ([]*models.WebhookSubscription, error) {
+    return r.find(func(*models.WebhookSubscription) bool { return true }), nil
+}
+
+func (r *memorySubscriptionRepository) FindActive(ctx context.Context) ([]*models.WebhookSubscription, error) {
+    return r.find(func(s *models.WebhookSubscription) bool { return s.Active }), nil
+}
+
+func (r *memorySubscriptionRepository) find(match func(*models.WebhookSubscription) bool) []*models.WebhookSubscription {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    var result []*models.WebhookSubscription
+    for _, subscription := range r.subscriptions {
+        if match(subscription) {
+            result = append(result, copySubscription(subscription))
+        }
+    }
+
+    sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+    return result
+}
+
+func (r *memorySubscriptionRepository) Update(ctx context.Context, subscription *models.WebhookSubscription) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    if _, ok := r.subscriptions[subscription.ID]; ok {
+        r.subscriptions[subscription.ID] = copySubscription(subscription)
+    }
+    return nil
+}
+
+func (r *memorySubscriptionRepository) Delete(ctx context.Context, id int64) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    delete(r.subscriptions, id)
+    return nil
+}
+
+func (r *memorySubscriptionRepository) MarkFailing(ctx context.Context, id int64, at time.Time) (time.Time, error) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    subscription, ok := r.subscriptions[id]
+    if !ok || !subscription.Active {
+        return at, nil
+    }
+    if subscription.FailingSince == nil {
+        subscription.FailingSince = &at
+    }
+    return *subscription.FailingSince, nil
+}
+
+func (r *memorySubscriptionRepository) MarkHealthy(ctx context.Context, id int64) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    if subscription, ok := r.subscriptions[id]; ok && subscription.Active {
+        subscription.FailingSince = nil
+    }
+    return nil
+}
+
+func (r *memorySubscriptionRepository) Disable(ctx context.Context, id int64, reason string, at time.Time) (bool, error) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    subscription, ok := r.subscriptions[id]
+    if !ok || !subscription.Active {
+        return false, nil
+    }
+    subscription.Active = false
+    subscription.DisabledAt = &at
+    subscription.DisabledReason = reason
+    subscription.UpdatedAt = at
+    return true, nil
+}
+
+// memoryDeliveryRepository хранит последние limit попыток доставки
+type memoryDeliveryRepository struct {
+    mu         sync.RWMutex
+    deliveries []*models.WebhookDelivery
+    nextID     int64
+    limit      int
+}
+
+func NewDeliveryRepository(limit int) repositories.WebhookDeliveryRepository {
+    return &memoryDeliveryRepository{limit: limit}
+}
+
+func copyDelivery(delivery *models.WebhookDelivery) *models.WebhookDelivery {
+    c := *delivery
+    c.Payload.Incidents = append([]models.IncidentShort(nil), delivery.Payload.Incidents...)
+    return &c
+}
+
+func (r *memoryDeliveryRepository) RecordAttempt(ctx context.Context, delivery *models.WebhookDelivery) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    r.nextID++
+    delivery.ID = r.nextID
+    r.deliveries = append(r.deliveries, copyDelivery(delivery))
+    if r.limit > 0 && len(r.deliveries) > r.limit {
+        r.deliveries = r.deliveries[len(r.deliveries)-r.limit:]
+    }
+    return nil
+}
+
+func (r *memoryDeliveryRepository) FindDeliveries(ctx context.Context, filter models.DeliveryFilter) ([]*models.WebhookDelivery, int, error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    var matched []*models.WebhookDelivery
+    for _, delivery := range r.deliveries {
+        if matchesDelivery(delivery, filter) {
+            matched = append(matched, delivery)
+        }
+    }
+
+    // От новых к старым, как в SQL-реализациях
+    sort.SliceStable(matched, func(i, j int) bool {
+        if !matched[i].CreatedAt.Equal(matched[j].CreatedAt) {
+            return matched[i].CreatedAt.After(matched[j].CreatedAt)
+        }
+        return matched[i].ID > matched[j].ID
+    })
+
+    total := len(matched)
+    if filter.Offset >= total {
+        return nil, total, nil
+    }
+    end := filter.Offset + filter.Limit
+    if end > total {
+        end = total
+    }
+
+    result := make([]*models.WebhookDelivery, 0, end-filter.Offset)
+    for _, delivery := range matched[filter.Offset:end] {
+        result = append(result, copyDelivery(delivery))
+    }
+    return result, total, nil
+}
+
+func matchesDelivery(delivery *models.WebhookDelivery, filter models.DeliveryFilter) bool {
+    if filter.SubscriptionID != nil && delivery.SubscriptionID != *filter.SubscriptionID {
+        return false
+    }
+    if filter.DeliveryID != "" && delivery.DeliveryID != filter.DeliveryID {
+        return false
+    }
+    if filter.EventType != "" && delivery.EventType != filter.EventType {
+        return false
+    }
+    if filter.Success != nil && delivery.Success != *filter.Success {
+        return false
+    }
+    if !filter.From.IsZero() && delivery.CreatedAt.Before(filter.From) {
+        return false
+    }
+    if !filter.To.IsZero() && !delivery.CreatedAt.Before(filter.To) {
+        return false
+    }
+    return true
+}
@@ -0,0 +1,94 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/domain/repositories"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisEventStream публикует события изменения инцидентов в Redis Stream.
+// ID записи совпадает с ID события outbox, поэтому повторная публикация
+// отклоняется самим Redis. Событие, задержанное повторами, может оказаться
+// старше вершины потока - тогда оно добавляется с автоматическим ID,
+// а потребители различают события по полю outbox_id.
+type redisEventStream struct {
+    client *redis.Client
+    stream string
+    maxLen int64
+}
+
+func NewRedisEventStream(client *redis.Client) repositories.EventStream {
+    return &redisEventStream{
+        client: client,
+        stream: "incident_events",
+        maxLen: 100000,
+    }
+}
+
+func (s *redisEventStream) AppendEvent(ctx context.Context, event *models.OutboxEvent) error {
+    payload, err := json.Marshal(event.Incident)
+    if err != nil {
+        return err
+    }
+
+    values := map[string]interface{}{
+        "outbox_id":   event.ID,
+        "event_type":  event.EventType,
+        "incident_id": event.IncidentID,
+        "created_at":  event.CreatedAt.UnixMilli(),
+        "payload":     payload,
+    }
+    if len(event.ChangedFields) > 0 {
+        // Имена полей через запятую, как в changed_fields вебхука
+        values["changed_fields"] = strings.Join(event.ChangedFields, ",")
+    }
+    if event.Update != nil {
+        update, err := json.Marshal(event.Update)
+        if err != nil {
+            return err
+        }
+        values["update"] = update
+    }
+    if event.Geometry != nil {
+        geometry, err := json.Marshal(event.Geometry)
+        if err != nil {
+            return err
+        }
+        values["geometry"] = geometry
+    }
+    if event.BatchID != "" {
+        values["batch_id"] = event.BatchID
+    }
+
+    id := fmt.Sprintf("%d-0", event.ID)
+    args := &redis.XAddArgs{
+        Stream: s.stream,
+        MaxLen: s.maxLen,
+        Approx: true,
+        ID:     id,
+        Values: values,
+    }
+
+    err = s.client.XAdd(ctx, args).Err()
+    if err == nil || !strings.Contains(err.Error(), "equal or smaller than the target stream top item") {
+        return err
+    }
+
+    // Запись с таким ID уже есть - событие было опубликовано ранее
+    existing, err := s.client.XRange(ctx, s.stream, id, id).Result()
+    if err != nil {
+        return err
+    }
+    if len(existing) > 0 {
+        return nil
+    }
+
+    args.ID = ""
+    return s.client.XAdd(ctx, args).Err()
+}
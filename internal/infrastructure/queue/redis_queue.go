@@ -6,37 +6,35 @@ import (
 	"fmt"
 	"time"
 
-	"incident-system/internal/config"
 	"incident-system/internal/domain/models"
 	"incident-system/internal/domain/repositories"
 
 	"github.com/redis/go-redis/v9"
 )
 
+// popDueScript атомарно забирает из sorted set задачи со сроком не позже ARGV[1]
+var popDueScript = redis.NewScript(`
+local tasks = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[2])
+if #tasks > 0 then
+    redis.call('ZREM', KEYS[1], unpack(tasks))
+end
+return tasks
+`)
+
 type redisQueueRepository struct {
-    client *redis.Client
-    queue  string
+    client  *redis.Client
+    queue   string
+    delayed string // sorted set задач повтора, score - срок в миллисекундах
 }
 
-func NewRedisQueueRepository(cfg *config.Config) (repositories.QueueRepository, error) {
-    client := redis.NewClient(&redis.Options{
-        Addr:     fmt.Sprintf("%s:%s", cfg.RedisHost, cfg.RedisPort),
-        Password: cfg.RedisPassword,
-        DB:       cfg.RedisDB,
-    })
-    
-    // Проверка подключения
-    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-    defer cancel()
-    
-    if err := client.Ping(ctx).Err(); err != nil {
-        return nil, fmt.Errorf("failed to connect to Redis: %w", err)
-    }
-    
+// NewRedisQueueRepository использует общее подключение хранилища; его доступность
+// проверяет вызывающий
+func NewRedisQueueRepository(client *redis.Client) repositories.QueueRepository {
     return &redisQueueRepository{
-        client: client,
-        queue:  "webhook_queue",
-    }, nil
+        client:  client,
+        queue:   "webhook_queue",
+        delayed: "webhook_retry",
+    }
 }
 
 func (r *redisQueueRepository) EnqueueWebhook(ctx context.Context, payload models.WebhookPayload) error {
@@ -64,4 +62,35 @@ func (r *redisQueueRepository) DequeueWebhook(ctx context.Context) (*models.Webh
     }
     
     return &payload, nil
+}
+
+func (r *redisQueueRepository) ScheduleWebhookTask(ctx context.Context, task models.WebhookTask) error {
+    data, err := json.Marshal(task)
+    if err != nil {
+        return err
+    }
+    
+    return r.client.ZAdd(ctx, r.delayed, redis.Z{
+        Score:  float64(task.DueAt.UnixMilli()),
+        Member: data,
+    }).Err()
+}
+
+func (r *redisQueueRepository) DueWebhookTasks(ctx context.Context, now time.Time, limit int) ([]models.WebhookTask, error) {
+    members, err := popDueScript.Run(ctx, r.client, []string{r.delayed}, now.UnixMilli(), limit).StringSlice()
+    if err != nil {
+        return nil, err
+    }
+    
+    tasks := make([]models.WebhookTask, 0, len(members))
+    for _, member := range members {
+        var task models.WebhookTask
+        if err := json.Unmarshal([]byte(member), &task); err != nil {
+            // Поврежденную задачу не выполнить - пропускаем
+            continue
+        }
+        tasks = append(tasks, task)
+    }
+    
+    return tasks, nil
 }
\ No newline at end of file
@@ -0,0 +1,105 @@
+package resilience
+
+import (
+	"context"
+	"time"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/domain/repositories"
+	"incident-system/pkg/circuitbreaker"
+)
+
+// cacheRepository защищает кеш автоматом. При сбое или разомкнутом автомате
+// кеш ведет себя как пустой: чтения уходят в базу, а блокировка обновления
+// считается захваченной, чтобы загрузка не ждала недоступный Redis.
+// Инвалидация возвращает ошибку, чтобы релей outbox повторил ее после восстановления.
+type cacheRepository struct {
+    cache   repositories.CacheRepository
+    breaker *circuitbreaker.Breaker
+}
+
+func NewCacheRepository(cache repositories.CacheRepository, breaker *circuitbreaker.Breaker) repositories.CacheRepository {
+    return &cacheRepository{
+        cache:   cache,
+        breaker: breaker,
+    }
+}
+
+func (r *cacheRepository) GetActiveIncidents(ctx context.Context) (*models.ActiveIncidentsSnapshot, error) {
+    if r.breaker.Allow() != nil {
+        return nil, nil
+    }
+
+    snapshot, err := r.cache.GetActiveIncidents(ctx)
+    if record(ctx, r.breaker, err) != nil {
+        return nil, nil
+    }
+    return snapshot, nil
+}
+
+func (r *cacheRepository) SetActiveIncidents(ctx context.Context, snapshot *models.ActiveIncidentsSnapshot) (bool, error) {
+    if r.breaker.Allow() != nil {
+        return false, nil
+    }
+
+    stored, err := r.cache.SetActiveIncidents(ctx, snapshot)
+    if record(ctx, r.breaker, err) != nil {
+        return false, nil
+    }
+    return stored, nil
+}
+
+func (r *cacheRepository) ActiveIncidentsVersion(ctx context.Context) (int64, error) {
+    if r.breaker.Allow() != nil {
+        return 0, nil
+    }
+
+    version, err := r.cache.ActiveIncidentsVersion(ctx)
+    if record(ctx, r.breaker, err) != nil {
+        return 0, nil
+    }
+    return version, nil
+}
+
+func (r *cacheRepository) InvalidateActiveIncidents(ctx context.Context) error {
+    if err := r.breaker.Allow(); err != nil {
+        return err
+    }
+
+    return record(ctx, r.breaker, r.cache.InvalidateActiveIncidents(ctx))
+}
+
+func (r *cacheRepository) AcquireRefreshLock(ctx context.Context, ttl time.Duration) (string, bool, error) {
+    if r.breaker.Allow() != nil {
+        return "", true, nil
+    }
+
+    token, acquired, err := r.cache.AcquireRefreshLock(ctx, ttl)
+    if record(ctx, r.breaker, err) != nil {
+        return "", true, nil
+    }
+    return token, acquired, nil
+}
+
+func (r *cacheRepository) ReleaseRefreshLock(ctx context.Context, token string) error {
+    // Пустой токен выдан при недоступном Redis - снимать нечего
+    if token == "" || r.breaker.Allow() != nil {
+        return nil
+    }
+
+    record(ctx, r.breaker, r.cache.ReleaseRefreshLock(ctx, token))
+    return nil
+}
+
+// record записывает результат вызова в автомат. Отмена контекста вызывающим
+// не говорит о сбое зависимости и не учитывается.
+func record(ctx context.Context, breaker *circuitbreaker.Breaker, err error) error {
+    if err == nil {
+        breaker.Success()
+        return nil
+    }
+    if ctx.Err() == nil {
+        breaker.Failure()
+    }
+    return err
+}
@@ -0,0 +1,47 @@
+package resilience
+
+import (
+	"incident-system/internal/domain/models"
+	"incident-system/pkg/circuitbreaker"
+)
+
+// Monitor собирает состояние автоматов и журнала вебхуков для проверки здоровья
+type Monitor struct {
+    breakers []*circuitbreaker.Breaker
+    spool    *FileSpool
+}
+
+func NewMonitor(spool *FileSpool, breakers ...*circuitbreaker.Breaker) *Monitor {
+    return &Monitor{
+        breakers: breakers,
+        spool:    spool,
+    }
+}
+
+// Status сообщает о деградации, если хотя бы один автомат не замкнут или
+// в журнале остались вебхуки
+func (m *Monitor) Status() models.DegradationStatus {
+    status := models.DegradationStatus{
+        Breakers: make(map[string]models.BreakerStatus, len(m.breakers)),
+    }
+
+    for _, breaker := range m.breakers {
+        state := breaker.State()
+        breakerStatus := models.BreakerStatus{State: state.String()}
+        if state != circuitbreaker.StateClosed {
+            openedAt := breaker.OpenedAt()
+            breakerStatus.OpenedAt = &openedAt
+            status.Degraded = true
+        }
+        status.Breakers[breaker.Name()] = breakerStatus
+    }
+
+    if m.spool != nil {
+        status.SpooledWebhooks = m.spool.Len()
+        if status.SpooledWebhooks > 0 {
+            status.Degraded = true
+        }
+    }
+
+    return status
+}
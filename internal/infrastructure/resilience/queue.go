@@ -0,0 +1,169 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/domain/repositories"
+	"incident-system/internal/infrastructure/memory"
+	"incident-system/pkg/circuitbreaker"
+)
+
+// dequeuePollInterval - как часто воркер проверяет, не замкнулся ли автомат
+const dequeuePollInterval = time.Second
+
+// QueueRepository защищает очередь вебхуков автоматом. Пока Redis недоступен,
+// вебхуки пишутся в журнал на диске, а после восстановления возвращаются в очередь.
+// Пока журнал не пуст, новые вебхуки тоже идут в него, чтобы сохранить порядок.
+// Отложенные повторы на это время хранятся в памяти процесса.
+type QueueRepository struct {
+    queue    repositories.QueueRepository
+    breaker  *circuitbreaker.Breaker
+    spool    *FileSpool
+    fallback repositories.QueueRepository
+
+    drainNow chan struct{}
+    cancel   context.CancelFunc
+    done     chan struct{}
+}
+
+// NewQueueRepository запускает фоновый возврат вебхуков из журнала каждые drainInterval
+func NewQueueRepository(
+    queue repositories.QueueRepository,
+    breaker *circuitbreaker.Breaker,
+    spool *FileSpool,
+    drainInterval time.Duration,
+) *QueueRepository {
+    ctx, cancel := context.WithCancel(context.Background())
+
+    q := &QueueRepository{
+        queue:    queue,
+        breaker:  breaker,
+        spool:    spool,
+        fallback: memory.NewQueueRepository(),
+        drainNow: make(chan struct{}, 1),
+        cancel:   cancel,
+        done:     make(chan struct{}),
+    }
+
+    go q.drainLoop(ctx, drainInterval)
+
+    return q
+}
+
+func (q *QueueRepository) EnqueueWebhook(ctx context.Context, payload models.WebhookPayload) error {
+    if q.spool.Len() == 0 && q.breaker.Allow() == nil {
+        err := record(ctx, q.breaker, q.queue.EnqueueWebhook(ctx, payload))
+        if err == nil || ctx.Err() != nil {
+            return err
+        }
+        log.Printf("Webhook queue unavailable, spooling to disk: %v", err)
+    }
+
+    if err := q.spool.Append(payload); err != nil {
+        return fmt.Errorf("failed to spool webhook: %w", err)
+    }
+    q.triggerDrain()
+    return nil
+}
+
+// DequeueWebhook ждет, пока автомат разомкнут: очередь в Redis все равно недоступна
+func (q *QueueRepository) DequeueWebhook(ctx context.Context) (*models.WebhookPayload, error) {
+    for q.breaker.State() == circuitbreaker.StateOpen {
+        select {
+        case <-ctx.Done():
+            return nil, ctx.Err()
+        case <-time.After(dequeuePollInterval):
+        }
+    }
+
+    payload, err := q.queue.DequeueWebhook(ctx)
+    if err := record(ctx, q.breaker, err); err != nil {
+        return nil, err
+    }
+    return payload, nil
+}
+
+func (q *QueueRepository) ScheduleWebhookTask(ctx context.Context, task models.WebhookTask) error {
+    if q.breaker.Allow() == nil {
+        err := record(ctx, q.breaker, q.queue.ScheduleWebhookTask(ctx, task))
+        if err == nil || ctx.Err() != nil {
+            return err
+        }
+    }
+
+    return q.fallback.ScheduleWebhookTask(ctx, task)
+}
+
+// DueWebhookTasks сначала отдает повторы, отложенные в памяти, затем - из Redis
+func (q *QueueRepository) DueWebhookTasks(ctx context.Context, now time.Time, limit int) ([]models.WebhookTask, error) {
+    tasks, err := q.fallback.DueWebhookTasks(ctx, now, limit)
+    if err != nil {
+        return nil, err
+    }
+    if len(tasks) >= limit || q.breaker.Allow() != nil {
+        return tasks, nil
+    }
+
+    remote, err := q.queue.DueWebhookTasks(ctx, now, limit-len(tasks))
+    if record(ctx, q.breaker, err) != nil {
+        return tasks, nil
+    }
+    return append(tasks, remote...), nil
+}
+
+// Close останавливает фоновый возврат вебхуков; журнал остается на диске
+func (q *QueueRepository) Close() error {
+    q.cancel()
+    <-q.done
+    return nil
+}
+
+func (q *QueueRepository) triggerDrain() {
+    select {
+    case q.drainNow <- struct{}{}:
+    default:
+    }
+}
+
+func (q *QueueRepository) drainLoop(ctx context.Context, interval time.Duration) {
+    defer close(q.done)
+
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+        case <-q.drainNow:
+        }
+
+        q.drain(ctx)
+    }
+}
+
+func (q *QueueRepository) drain(ctx context.Context) {
+    if q.spool.Len() == 0 {
+        return
+    }
+
+    drained, err := q.spool.Drain(func(payload models.WebhookPayload) error {
+        if err := q.breaker.Allow(); err != nil {
+            return err
+        }
+        return record(ctx, q.breaker, q.queue.EnqueueWebhook(ctx, payload))
+    })
+
+    if drained > 0 {
+        log.Printf("Webhook spool: returned %d webhooks to queue, %d left", drained, q.spool.Len())
+    }
+    if err != nil && !errors.Is(err, circuitbreaker.ErrOpen) && ctx.Err() == nil {
+        log.Printf("Webhook spool: drain stopped: %v", err)
+    }
+}
@@ -0,0 +1,156 @@
+package resilience
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"incident-system/internal/domain/models"
+)
+
+// FileSpool - журнал вебхуков на диске (JSON по строке на вебхук), куда они пишутся,
+// пока очередь в Redis недоступна. Записи переживают перезапуск сервиса.
+type FileSpool struct {
+    mu    sync.Mutex
+    path  string
+    count int
+}
+
+// NewFileSpool открывает журнал, создавая его при необходимости
+func NewFileSpool(path string) (*FileSpool, error) {
+    if dir := filepath.Dir(path); dir != "." {
+        if err := os.MkdirAll(dir, 0o755); err != nil {
+            return nil, fmt.Errorf("failed to create spool directory: %w", err)
+        }
+    }
+
+    lines, err := readLines(path)
+    if err != nil {
+        return nil, fmt.Errorf("failed to read spool: %w", err)
+    }
+
+    return &FileSpool{path: path, count: len(lines)}, nil
+}
+
+// Len возвращает число вебхуков в журнале
+func (s *FileSpool) Len() int {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    return s.count
+}
+
+// Append дописывает вебхук в журнал и сбрасывает запись на диск
+func (s *FileSpool) Append(payload models.WebhookPayload) error {
+    data, err := json.Marshal(payload)
+    if err != nil {
+        return err
+    }
+
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    file, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+    if err != nil {
+        return err
+    }
+    defer file.Close()
+
+    if _, err := file.Write(append(data, '\n')); err != nil {
+        return err
+    }
+    if err := file.Sync(); err != nil {
+        return err
+    }
+
+    s.count++
+    return nil
+}
+
+// Drain передает вебхуки в fn по порядку, пока fn не вернет ошибку. Переданные
+// вебхуки удаляются из журнала; возвращает их число и ошибку fn.
+func (s *FileSpool) Drain(fn func(models.WebhookPayload) error) (int, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    lines, err := readLines(s.path)
+    if err != nil {
+        return 0, err
+    }
+
+    drained := 0
+    var fnErr error
+    for _, line := range lines {
+        var payload models.WebhookPayload
+        if err := json.Unmarshal(line, &payload); err != nil {
+            // Поврежденную запись не повторить - пропускаем
+            log.Printf("Webhook spool: skipping corrupted record: %v", err)
+            drained++
+            continue
+        }
+        if fnErr = fn(payload); fnErr != nil {
+            break
+        }
+        drained++
+    }
+
+    if drained == 0 {
+        return 0, fnErr
+    }
+    if err := s.rewrite(lines[drained:]); err != nil {
+        return 0, err
+    }
+    s.count = len(lines) - drained
+    return drained, fnErr
+}
+
+// rewrite атомарно заменяет журнал оставшимися записями
+func (s *FileSpool) rewrite(lines [][]byte) error {
+    tmp := s.path + ".tmp"
+    file, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+    if err != nil {
+        return err
+    }
+
+    writer := bufio.NewWriter(file)
+    for _, line := range lines {
+        writer.Write(line)
+        writer.WriteByte('\n')
+    }
+    if err := writer.Flush(); err != nil {
+        file.Close()
+        return err
+    }
+    if err := file.Sync(); err != nil {
+        file.Close()
+        return err
+    }
+    if err := file.Close(); err != nil {
+        return err
+    }
+
+    return os.Rename(tmp, s.path)
+}
+
+func readLines(path string) ([][]byte, error) {
+    data, err := os.ReadFile(path)
+    if os.IsNotExist(err) {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    var lines [][]byte
+    for _, line := range bytes.Split(data, []byte{'\n'}) {
+        if len(bytes.TrimSpace(line)) > 0 {
+            lines = append(lines, line)
+        }
+    }
+    return lines, nil
+}
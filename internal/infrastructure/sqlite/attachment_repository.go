@@ -0,0 +1,128 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/domain/repositories"
+)
+
+const attachmentColumns = `id, incident_id, file_name, content_type, size, checksum, width, height,
+    storage_key, thumbnail_key, uploaded_by, created_at`
+
+type sqliteAttachmentRepository struct {
+    db *sql.DB
+}
+
+func NewAttachmentRepository(db *sql.DB) repositories.AttachmentRepository {
+    return &sqliteAttachmentRepository{db: db}
+}
+
+func scanAttachment(row rowScanner) (*models.Attachment, error) {
+    var attachment models.Attachment
+    var width, height sql.NullInt64
+    var thumbnailKey sql.NullString
+    var createdAt int64
+
+    if err := row.Scan(
+        &attachment.ID,
+        &attachment.IncidentID,
+        &attachment.FileName,
+        &attachment.ContentType,
+        &attachment.Size,
+        &attachment.Checksum,
+        &width,
+        &height,
+        &attachment.StorageKey,
+        &thumbnailKey,
+        &attachment.UploadedBy,
+        &createdAt,
+    ); err != nil {
+        return nil, err
+    }
+
+    attachment.Width = int(width.Int64)
+    attachment.Height = int(height.Int64)
+    attachment.ThumbnailKey = thumbnailKey.String
+    attachment.CreatedAt = time.UnixMicro(createdAt)
+    return &attachment, nil
+}
+
+func (r *sqliteAttachmentRepository) Create(ctx context.Context, attachment *models.Attachment) error {
+    result, err := r.db.ExecContext(ctx, `
+        INSERT INTO incident_attachments (
+            incident_id, file_name, content_type, size, checksum, width, height,
+            storage_key, thumbnail_key, uploaded_by, created_at
+        ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+    `,
+        attachment.IncidentID,
+        attachment.FileName,
+        attachment.ContentType,
+        attachment.Size,
+        attachment.Checksum,
+        toNullInt(attachment.Width),
+        toNullInt(attachment.Height),
+        attachment.StorageKey,
+        toNullString(attachment.ThumbnailKey),
+        attachment.UploadedBy,
+        attachment.CreatedAt.UnixMicro(),
+    )
+    if err != nil {
+        return err
+    }
+
+    attachment.ID, err = result.LastInsertId()
+    return err
+}
+
+func (r *sqliteAttachmentRepository) FindByID(ctx context.Context, id int64) (*models.Attachment, error) {
+    query := `SELECT ` + attachmentColumns + ` FROM incident_attachments WHERE id = ?`
+
+    attachment, err := scanAttachment(r.db.QueryRowContext(ctx, query, id))
+    if err == sql.ErrNoRows {
+        return nil, nil
+    }
+
+    return attachment, err
+}
+
+func (r *sqliteAttachmentRepository) FindByIncident(ctx context.Context, incidentID int64) ([]*models.Attachment, error) {
+    query := `SELECT ` + attachmentColumns + ` FROM incident_attachments WHERE incident_id = ? ORDER BY id`
+
+    rows, err := r.db.QueryContext(ctx, query, incidentID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var attachments []*models.Attachment
+    for rows.Next() {
+        attachment, err := scanAttachment(rows)
+        if err != nil {
+            return nil, err
+        }
+        attachments = append(attachments, attachment)
+    }
+
+    return attachments, rows.Err()
+}
+
+func (r *sqliteAttachmentRepository) CountByIncident(ctx context.Context, incidentID int64) (int, error) {
+    var count int
+    err := r.db.QueryRowContext(ctx,
+        `SELECT COUNT(*) FROM incident_attachments WHERE incident_id = ?`, incidentID,
+    ).Scan(&count)
+    return count, err
+}
+
+func (r *sqliteAttachmentRepository) Delete(ctx context.Context, id int64) (bool, error) {
+    result, err := r.db.ExecContext(ctx, `DELETE FROM incident_attachments WHERE id = ?`, id)
+    if err != nil {
+        return false, err
+    }
+
+    affected, err := result.RowsAffected()
+    return affected > 0, err
+}
@@ -0,0 +1,1029 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/domain/repositories"
+	"incident-system/pkg/geo"
+)
+
+const incidentColumns = `id, user_id, latitude, longitude, title, description,
+               severity, category, tags, radius, polygon, active, expires_at, merged_into, created_at, updated_at, external_id, version, public`
+
+type sqliteIncidentRepository struct {
+    db *sql.DB
+}
+
+func NewIncidentRepository(db *sql.DB) repositories.IncidentRepository {
+    return &sqliteIncidentRepository{db: db}
+}
+
+type rowScanner interface {
+    Scan(dest ...interface{}) error
+}
+
+func scanIncident(row rowScanner) (*models.Incident, error) {
+    var incident models.Incident
+    var polygon sql.NullString
+    var tags string
+    var expiresAt, mergedInto sql.NullInt64
+    var createdAt, updatedAt int64
+    var externalID sql.NullString
+
+    if err := row.Scan(
+        &incident.ID,
+        &incident.UserID,
+        &incident.Latitude,
+        &incident.Longitude,
+        &incident.Title,
+        &incident.Description,
+        &incident.Severity,
+        &incident.Category,
+        &tags,
+        &incident.Radius,
+        &polygon,
+        &incident.Active,
+        &expiresAt,
+        &mergedInto,
+        &createdAt,
+        &updatedAt,
+        &externalID,
+        &incident.Version,
+        &incident.Public,
+    ); err != nil {
+        return nil, err
+    }
+
+    incident.CreatedAt = time.UnixMicro(createdAt)
+    incident.UpdatedAt = time.UnixMicro(updatedAt)
+    incident.ExpiresAt = fromNullMicro(expiresAt)
+    if mergedInto.Valid {
+        incident.MergedInto = &mergedInto.Int64
+    }
+    if externalID.Valid {
+        incident.ExternalID = &externalID.String
+    }
+
+    if err := json.Unmarshal([]byte(tags), &incident.Tags); err != nil {
+        return nil, err
+    }
+
+    if polygon.Valid && polygon.String != "" {
+        if err := json.Unmarshal([]byte(polygon.String), &incident.Polygon); err != nil {
+            return nil, err
+        }
+    }
+
+    return &incident, nil
+}
+
+func scanIncidents(rows *sql.Rows) ([]*models.Incident, error) {
+    defer rows.Close()
+
+    var incidents []*models.Incident
+    for rows.Next() {
+        incident, err := scanIncident(rows)
+        if err != nil {
+            return nil, err
+        }
+        incidents = append(incidents, incident)
+    }
+
+    return incidents, rows.Err()
+}
+
+func encodePolygon(polygon []models.GeoPoint) (interface{}, error) {
+    if len(polygon) == 0 {
+        return nil, nil
+    }
+    data, err := json.Marshal(polygon)
+    if err != nil {
+        return nil, err
+    }
+    return string(data), nil
+}
+
+// incidentFilterClause строит условие WHERE по фильтру; args дополняются значениями плейсхолдеров
+func incidentFilterClause(filter models.IncidentFilter, args []interface{}) (string, []interface{}) {
+    conditions := []string{"1 = 1"}
+    placeholders := func(values []string) string {
+        marks := make([]string, len(values))
+        for i, value := range values {
+            marks[i] = "?"
+            args = append(args, value)
+        }
+        return strings.Join(marks, ", ")
+    }
+
+    if filter.ActiveOnly {
+        conditions = append(conditions, "active = 1")
+    }
+    if len(filter.Categories) > 0 {
+        // Подкатегории: код родителя с точкой; '_' в LIKE экранируется
+        var alternatives []string
+        for _, category := range filter.Categories {
+            alternatives = append(alternatives, `category = ? OR category LIKE ? ESCAPE '\'`)
+            args = append(args, category, strings.ReplaceAll(category, "_", `\_`)+".%")
+        }
+        conditions = append(conditions, "("+strings.Join(alternatives, " OR ")+")")
+    }
+    if len(filter.Tags) > 0 {
+        conditions = append(conditions,
+            "EXISTS (SELECT 1 FROM json_each(incidents.tags) WHERE json_each.value IN ("+placeholders(filter.Tags)+"))")
+    }
+    if len(filter.Severities) > 0 {
+        conditions = append(conditions, "severity IN ("+placeholders(filter.Severities)+")")
+    }
+
+    return strings.Join(conditions, " AND "), args
+}
+
+// zoneBounds возвращает прямоугольник, описанный вокруг зоны инцидента; у антимеридиана
+// он занимает весь диапазон долгот
+func zoneBounds(incident *models.Incident) (minLat, minLng, maxLat, maxLng float64) {
+    return geo.BoundingBox(incident.Latitude, incident.Longitude, incident.Radius/1000.0)
+}
+
+// lngRangesCondition - условие пересечения долгот строки [minColumn, maxColumn] с одним
+// из диапазонов ranges (у антимеридиана их два); для точки minColumn и maxColumn совпадают
+func lngRangesCondition(minColumn, maxColumn string, ranges []geo.LngRange) (string, []interface{}) {
+    conditions := make([]string, 0, len(ranges))
+    args := make([]interface{}, 0, 2*len(ranges))
+    for _, lngRange := range ranges {
+        conditions = append(conditions, "("+maxColumn+" >= ? AND "+minColumn+" <= ?)")
+        args = append(args, lngRange.Min, lngRange.Max)
+    }
+    return "(" + strings.Join(conditions, " OR ") + ")", args
+}
+
+// withTx выполняет fn в транзакции: изменение инцидента и запись в outbox фиксируются вместе
+func (r *sqliteIncidentRepository) withTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+    tx, err := r.db.BeginTx(ctx, nil)
+    if err != nil {
+        return err
+    }
+
+    if err := fn(tx); err != nil {
+        tx.Rollback()
+        return err
+    }
+
+    return tx.Commit()
+}
+
+func (r *sqliteIncidentRepository) Create(ctx context.Context, incident *models.Incident) error {
+    return r.withTx(ctx, func(tx *sql.Tx) error {
+        return writeChange(ctx, tx, models.IncidentChange{Kind: models.ChangeCreate, Incident: incident}, "")
+    })
+}
+
+func (r *sqliteIncidentRepository) FindByID(ctx context.Context, id int64) (*models.Incident, error) {
+    query := `SELECT ` + incidentColumns + ` FROM incidents WHERE id = ?`
+
+    incident, err := scanIncident(r.db.QueryRowContext(ctx, query, id))
+    if err == sql.ErrNoRows {
+        return nil, nil
+    }
+
+    return incident, err
+}
+
+func (r *sqliteIncidentRepository) FindByExternalID(ctx context.Context, externalID string) (*models.Incident, error) {
+    query := `SELECT ` + incidentColumns + ` FROM incidents WHERE external_id = ?`
+
+    incident, err := scanIncident(r.db.QueryRowContext(ctx, query, externalID))
+    if err == sql.ErrNoRows {
+        return nil, nil
+    }
+
+    return incident, err
+}
+
+func (r *sqliteIncidentRepository) FindAll(ctx context.Context, filter models.IncidentFilter) ([]*models.Incident, error) {
+    where, args := incidentFilterClause(filter, nil)
+    query := `
+        SELECT ` + incidentColumns + `
+        FROM incidents
+        WHERE ` + where + `
+        ORDER BY created_at DESC, id DESC
+        LIMIT ? OFFSET ?
+    `
+
+    rows, err := r.db.QueryContext(ctx, query, append(args, filter.Limit, filter.Offset)...)
+    if err != nil {
+        return nil, err
+    }
+
+    return scanIncidents(rows)
+}
+
+func (r *sqliteIncidentRepository) Update(ctx context.Context, incident *models.Incident) error {
+    return r.withTx(ctx, func(tx *sql.Tx) error {
+        return writeChange(ctx, tx, models.IncidentChange{Kind: models.ChangeUpdate, Incident: incident}, "")
+    })
+}
+
+func (r *sqliteIncidentRepository) Delete(ctx context.Context, id int64, version int64) error {
+    return r.withTx(ctx, func(tx *sql.Tx) error {
+        return writeChange(ctx, tx, models.IncidentChange{Kind: models.ChangeDelete, Incident: &models.Incident{ID: id, Version: version}}, "")
+    })
+}
+
+func (r *sqliteIncidentRepository) ApplyBatch(ctx context.Context, batchID string, changes []models.IncidentChange) error {
+    return r.withTx(ctx, func(tx *sql.Tx) error {
+        for _, change := range changes {
+            if err := writeChange(ctx, tx, change, batchID); err != nil {
+                return err
+            }
+        }
+        return nil
+    })
+}
+
+// writeChange применяет изменение инцидента в транзакции и записывает его событие в outbox
+func writeChange(ctx context.Context, tx *sql.Tx, change models.IncidentChange, batchID string) error {
+    var event, geometryEvent *models.OutboxEvent
+    var err error
+    switch change.Kind {
+    case models.ChangeCreate:
+        event, err = insertIncident(ctx, tx, change.Incident)
+    case models.ChangeUpdate:
+        event, geometryEvent, err = updateIncident(ctx, tx, change.Incident)
+    case models.ChangeDelete:
+        event, err = deactivateIncident(ctx, tx, change.Incident)
+    default:
+        err = fmt.Errorf("unknown incident change %q", change.Kind)
+    }
+    if err != nil {
+        return err
+    }
+
+    for _, event := range []*models.OutboxEvent{event, geometryEvent} {
+        if event == nil {
+            continue
+        }
+        event.BatchID = batchID
+        if err := insertOutboxEvent(ctx, tx, event); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+func insertIncident(ctx context.Context, tx *sql.Tx, incident *models.Incident) (*models.OutboxEvent, error) {
+    query := `
+        INSERT INTO incidents (
+            user_id, latitude, longitude, title, description,
+            severity, category, tags, radius, polygon, active, expires_at, created_at, updated_at,
+            external_id, public, min_lat, min_lng, max_lat, max_lng
+        ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+        ON CONFLICT DO NOTHING
+    `
+
+    polygon, err := encodePolygon(incident.Polygon)
+    if err != nil {
+        return nil, err
+    }
+    tags, err := encodeStrings(incident.Tags)
+    if err != nil {
+        return nil, err
+    }
+
+    now := time.Now()
+    incident.CreatedAt = now
+    incident.UpdatedAt = now
+    incident.Version = 1
+    minLat, minLng, maxLat, maxLng := zoneBounds(incident)
+
+    result, err := tx.ExecContext(ctx, query,
+        incident.UserID,
+        incident.Latitude,
+        incident.Longitude,
+        incident.Title,
+        incident.Description,
+        incident.Severity,
+        incident.Category,
+        tags,
+        incident.Radius,
+        polygon,
+        incident.Active,
+        toNullMicro(incident.ExpiresAt),
+        now.UnixMicro(),
+        now.UnixMicro(),
+        incident.ExternalID,
+        incident.Public,
+        minLat, minLng, maxLat, maxLng,
+    )
+    if err != nil {
+        return nil, err
+    }
+
+    // Единственное ограничение уникальности incidents - external_id
+    if inserted, err := result.RowsAffected(); err != nil {
+        return nil, err
+    } else if inserted == 0 {
+        return nil, repositories.ErrExternalIDExists
+    }
+    if incident.ID, err = result.LastInsertId(); err != nil {
+        return nil, err
+    }
+
+    return models.NewOutboxEvent(models.EventIncidentCreated, incident), nil
+}
+
+// updateIncident сохраняет инцидент и возвращает событие изменения и, если изменилась
+// зона, событие incident.geometry_changed
+func updateIncident(ctx context.Context, tx *sql.Tx, incident *models.Incident) (*models.OutboxEvent, *models.OutboxEvent, error) {
+    query := `
+        UPDATE incidents
+        SET title = ?, description = ?, severity = ?, category = ?, tags = ?,
+            latitude = ?, longitude = ?, radius = ?, polygon = ?, active = ?, expires_at = ?, updated_at = ?,
+            public = ?, min_lat = ?, min_lng = ?, max_lat = ?, max_lng = ?, version = version + 1
+        WHERE id = ? AND version = ?
+    `
+
+    polygon, err := encodePolygon(incident.Polygon)
+    if err != nil {
+        return nil, nil, err
+    }
+    tags, err := encodeStrings(incident.Tags)
+    if err != nil {
+        return nil, nil, err
+    }
+
+    incident.UpdatedAt = time.Now()
+    minLat, minLng, maxLat, maxLng := zoneBounds(incident)
+
+    // Предыдущее состояние нужно для списка измененных полей в событии
+    before, err := scanIncident(tx.QueryRowContext(ctx, `SELECT `+incidentColumns+` FROM incidents WHERE id = ?`, incident.ID))
+    if err == sql.ErrNoRows {
+        // Несуществующий инцидент не порождает событие
+        return nil, nil, nil
+    }
+    if err != nil {
+        return nil, nil, err
+    }
+    if before.Version != incident.Version {
+        return nil, nil, repositories.ErrVersionConflict
+    }
+
+    // Блокировки строк в SQLite нет, поэтому версия проверяется еще и в самом UPDATE
+    result, err := tx.ExecContext(ctx, query,
+        incident.Title,
+        incident.Description,
+        incident.Severity,
+        incident.Category,
+        tags,
+        incident.Latitude,
+        incident.Longitude,
+        incident.Radius,
+        polygon,
+        incident.Active,
+        toNullMicro(incident.ExpiresAt),
+        incident.UpdatedAt.UnixMicro(),
+        incident.Public,
+        minLat, minLng, maxLat, maxLng,
+        incident.ID,
+        incident.Version,
+    )
+    if err != nil {
+        return nil, nil, err
+    }
+    if updated, err := result.RowsAffected(); err != nil {
+        return nil, nil, err
+    } else if updated == 0 {
+        return nil, nil, repositories.ErrVersionConflict
+    }
+
+    incident.Version++
+    geometryEvent, err := recordGeometryChange(ctx, tx, before, incident)
+    if err != nil {
+        return nil, nil, err
+    }
+    return models.NewIncidentUpdateEvent(before, incident), geometryEvent, nil
+}
+
+// recordGeometryChange сохраняет прежнюю зону before в истории и возвращает событие
+// incident.geometry_changed; nil, если зона after не отличается
+func recordGeometryChange(ctx context.Context, tx *sql.Tx, before, after *models.Incident) (*models.OutboxEvent, error) {
+    event, entry := models.NewGeometryChangedEvent(before, after)
+    if event == nil {
+        return nil, nil
+    }
+
+    polygon, err := encodePolygon(entry.Polygon)
+    if err != nil {
+        return nil, err
+    }
+    if _, err := tx.ExecContext(ctx, `
+        INSERT INTO incident_geometry_history (incident_id, version, latitude, longitude, radius, polygon, replaced_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?)
+    `, entry.IncidentID, entry.Version, entry.Latitude, entry.Longitude, entry.Radius, polygon, entry.ReplacedAt.UnixMicro()); err != nil {
+        return nil, err
+    }
+
+    return event, nil
+}
+
+// deactivateIncident снимает активность инцидента incident.ID вместо удаления
+// и записывает в incident его состояние; incident.Version > 0 - ожидаемая версия
+func deactivateIncident(ctx context.Context, tx *sql.Tx, incident *models.Incident) (*models.OutboxEvent, error) {
+    query := `
+        UPDATE incidents SET active = 0, updated_at = ?, version = version + 1
+        WHERE id = ? AND (? = 0 OR version = ?)
+        RETURNING ` + incidentColumns
+
+    deactivated, err := scanIncident(tx.QueryRowContext(ctx, query,
+        time.Now().UnixMicro(), incident.ID, incident.Version, incident.Version))
+    if err == sql.ErrNoRows {
+        return nil, versionConflict(ctx, tx, incident.ID)
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    *incident = *deactivated
+    return models.NewOutboxEvent(models.EventIncidentDeleted, incident), nil
+}
+
+// versionConflict объясняет, почему инцидент id не изменился: ErrVersionConflict,
+// если он есть, и nil, если его нет
+func versionConflict(ctx context.Context, tx *sql.Tx, id int64) error {
+    var exists bool
+    if err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM incidents WHERE id = ?)`, id).Scan(&exists); err != nil {
+        return err
+    }
+    if exists {
+        return repositories.ErrVersionConflict
+    }
+    return nil
+}
+
+func (r *sqliteIncidentRepository) ExpireDue(ctx context.Context, now time.Time) ([]*models.Incident, error) {
+    query := `
+        UPDATE incidents SET active = 0, updated_at = ?, version = version + 1
+        WHERE active = 1 AND expires_at IS NOT NULL AND expires_at <= ?
+        RETURNING ` + incidentColumns
+
+    var expired []*models.Incident
+    err := r.withTx(ctx, func(tx *sql.Tx) error {
+        rows, err := tx.QueryContext(ctx, query, now.UnixMicro(), now.UnixMicro())
+        if err != nil {
+            return err
+        }
+        if expired, err = scanIncidents(rows); err != nil {
+            return err
+        }
+        sort.Slice(expired, func(i, j int) bool { return expired[i].ID < expired[j].ID })
+
+        for _, incident := range expired {
+            event := models.NewOutboxEvent(models.EventIncidentExpired, incident)
+            event.ChangedFields = []string{models.FieldActive}
+            if err := insertOutboxEvent(ctx, tx, event); err != nil {
+                return err
+            }
+        }
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return expired, nil
+}
+
+func (r *sqliteIncidentRepository) Merge(ctx context.Context, source, target *models.Incident) (bool, error) {
+    polygon, err := encodePolygon(target.Polygon)
+    if err != nil {
+        return false, err
+    }
+    tags, err := encodeStrings(target.Tags)
+    if err != nil {
+        return false, err
+    }
+
+    now := time.Now()
+    minLat, minLng, maxLat, maxLng := zoneBounds(target)
+
+    merged := false
+    err = r.withTx(ctx, func(tx *sql.Tx) error {
+        before, err := scanIncident(tx.QueryRowContext(ctx, `SELECT `+incidentColumns+` FROM incidents WHERE id = ?`, target.ID))
+        if err == sql.ErrNoRows {
+            return nil
+        }
+        if err != nil {
+            return err
+        }
+        if before.MergedInto != nil {
+            // target сам успел стать дубликатом
+            return nil
+        }
+
+        duplicate, err := scanIncident(tx.QueryRowContext(ctx, `
+            UPDATE incidents SET active = 0, merged_into = ?, updated_at = ?, version = version + 1
+            WHERE id = ? AND merged_into IS NULL
+            RETURNING `+incidentColumns,
+            target.ID, now.UnixMicro(), source.ID,
+        ))
+        if err == sql.ErrNoRows {
+            return nil
+        }
+        if err != nil {
+            return err
+        }
+
+        if _, err := tx.ExecContext(ctx, `
+            UPDATE incidents
+            SET latitude = ?, longitude = ?, severity = ?, tags = ?, radius = ?, polygon = ?,
+                active = ?, expires_at = ?, updated_at = ?,
+                min_lat = ?, min_lng = ?, max_lat = ?, max_lng = ?, version = version + 1
+            WHERE id = ?
+        `,
+            target.Latitude,
+            target.Longitude,
+            target.Severity,
+            tags,
+            target.Radius,
+            polygon,
+            target.Active,
+            toNullMicro(target.ExpiresAt),
+            now.UnixMicro(),
+            minLat, minLng, maxLat, maxLng,
+            target.ID,
+        ); err != nil {
+            return err
+        }
+
+        // Ссылки на дубликат переходят к target, в том числе перенаправления
+        // ранее присоединенных к нему инцидентов
+        statements := []string{
+            `UPDATE incidents SET merged_into = ?, version = version + 1 WHERE merged_into = ?`,
+            `INSERT INTO location_check_incidents (check_id, incident_id)
+             SELECT check_id, ? FROM location_check_incidents WHERE incident_id = ?
+             ON CONFLICT (check_id, incident_id) DO NOTHING`,
+            `INSERT INTO incident_alerts (incident_id, user_id, alerted_at)
+             SELECT ?, user_id, alerted_at FROM incident_alerts WHERE incident_id = ?
+             ON CONFLICT (incident_id, user_id) DO NOTHING`,
+            `INSERT INTO watch_zone_alerts (incident_id, zone_id, alerted_at)
+             SELECT ?, zone_id, alerted_at FROM watch_zone_alerts WHERE incident_id = ?
+             ON CONFLICT (incident_id, zone_id) DO NOTHING`,
+            `UPDATE incident_reports SET incident_id = ? WHERE incident_id = ?`,
+            `UPDATE incident_attachments SET incident_id = ? WHERE incident_id = ?`,
+            `UPDATE incident_updates SET incident_id = ? WHERE incident_id = ?`,
+        }
+        for _, statement := range statements {
+            if _, err := tx.ExecContext(ctx, statement, target.ID, source.ID); err != nil {
+                return err
+            }
+        }
+        for _, statement := range []string{
+            `DELETE FROM location_check_incidents WHERE incident_id = ?`,
+            `DELETE FROM incident_alerts WHERE incident_id = ?`,
+            `DELETE FROM watch_zone_alerts WHERE incident_id = ?`,
+        } {
+            if _, err := tx.ExecContext(ctx, statement, source.ID); err != nil {
+                return err
+            }
+        }
+
+        target.UpdatedAt = now
+        target.Version = before.Version + 1
+        if err := insertOutboxEvent(ctx, tx, models.NewIncidentUpdateEvent(before, target)); err != nil {
+            return err
+        }
+        geometryEvent, err := recordGeometryChange(ctx, tx, before, target)
+        if err != nil {
+            return err
+        }
+        if geometryEvent != nil {
+            if err := insertOutboxEvent(ctx, tx, geometryEvent); err != nil {
+                return err
+            }
+        }
+        if err := insertOutboxEvent(ctx, tx, models.NewOutboxEvent(models.EventIncidentMerged, duplicate)); err != nil {
+            return err
+        }
+
+        *source = *duplicate
+        merged = true
+        return nil
+    })
+    if err != nil {
+        return false, err
+    }
+
+    return merged, nil
+}
+
+func (r *sqliteIncidentRepository) FindGeometryHistory(ctx context.Context, incidentID int64) ([]*models.GeometryHistoryEntry, error) {
+    rows, err := r.db.QueryContext(ctx, `
+        SELECT id, incident_id, version, latitude, longitude, radius, polygon, replaced_at
+        FROM incident_geometry_history
+        WHERE incident_id = ?
+        ORDER BY id DESC
+    `, incidentID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var history []*models.GeometryHistoryEntry
+    for rows.Next() {
+        var entry models.GeometryHistoryEntry
+        var polygon sql.NullString
+        var replacedAt int64
+        if err := rows.Scan(
+            &entry.ID,
+            &entry.IncidentID,
+            &entry.Version,
+            &entry.Latitude,
+            &entry.Longitude,
+            &entry.Radius,
+            &polygon,
+            &replacedAt,
+        ); err != nil {
+            return nil, err
+        }
+        entry.ReplacedAt = time.UnixMicro(replacedAt)
+        if polygon.Valid && polygon.String != "" {
+            if err := json.Unmarshal([]byte(polygon.String), &entry.Polygon); err != nil {
+                return nil, err
+            }
+        }
+        history = append(history, &entry)
+    }
+
+    return history, rows.Err()
+}
+
+const incidentUpdateColumns = `id, incident_id, message, public, author, created_at`
+
+func scanIncidentUpdates(rows *sql.Rows) ([]*models.IncidentUpdate, error) {
+    defer rows.Close()
+
+    var updates []*models.IncidentUpdate
+    for rows.Next() {
+        var update models.IncidentUpdate
+        var createdAt int64
+        if err := rows.Scan(
+            &update.ID,
+            &update.IncidentID,
+            &update.Message,
+            &update.Public,
+            &update.Author,
+            &createdAt,
+        ); err != nil {
+            return nil, err
+        }
+        update.CreatedAt = time.UnixMicro(createdAt)
+        updates = append(updates, &update)
+    }
+
+    return updates, rows.Err()
+}
+
+func (r *sqliteIncidentRepository) PostUpdate(ctx context.Context, update *models.IncidentUpdate) (bool, error) {
+    update.CreatedAt = time.Now()
+
+    var posted bool
+    err := r.withTx(ctx, func(tx *sql.Tx) error {
+        // Снимок инцидента нужен для события
+        incident, err := scanIncident(tx.QueryRowContext(ctx,
+            `SELECT `+incidentColumns+` FROM incidents WHERE id = ? AND merged_into IS NULL`, update.IncidentID))
+        if err == sql.ErrNoRows {
+            return nil
+        }
+        if err != nil {
+            return err
+        }
+
+        result, err := tx.ExecContext(ctx, `
+            INSERT INTO incident_updates (incident_id, message, public, author, created_at)
+            VALUES (?, ?, ?, ?, ?)
+        `, update.IncidentID, update.Message, update.Public, update.Author, update.CreatedAt.UnixMicro())
+        if err != nil {
+            return err
+        }
+        if update.ID, err = result.LastInsertId(); err != nil {
+            return err
+        }
+
+        if err := insertOutboxEvent(ctx, tx, models.NewUpdatePostedEvent(incident, update)); err != nil {
+            return err
+        }
+        posted = true
+        return nil
+    })
+    if err != nil {
+        return false, err
+    }
+
+    return posted, nil
+}
+
+func (r *sqliteIncidentRepository) FindUpdates(ctx context.Context, incidentID int64, publicOnly bool) ([]*models.IncidentUpdate, error) {
+    query := `SELECT ` + incidentUpdateColumns + ` FROM incident_updates WHERE incident_id = ?`
+    if publicOnly {
+        query += ` AND public = 1`
+    }
+    query += ` ORDER BY id DESC`
+
+    rows, err := r.db.QueryContext(ctx, query, incidentID)
+    if err != nil {
+        return nil, err
+    }
+
+    return scanIncidentUpdates(rows)
+}
+
+func (r *sqliteIncidentRepository) LatestPublicUpdates(ctx context.Context, incidentIDs []int64) (map[int64]*models.IncidentUpdate, error) {
+    latest := make(map[int64]*models.IncidentUpdate)
+    if len(incidentIDs) == 0 {
+        return latest, nil
+    }
+
+    args := make([]interface{}, len(incidentIDs))
+    for i, id := range incidentIDs {
+        args[i] = id
+    }
+    query := `
+        SELECT ` + incidentUpdateColumns + `
+        FROM incident_updates
+        WHERE id IN (
+            SELECT MAX(id) FROM incident_updates
+            WHERE public = 1 AND incident_id IN (?` + strings.Repeat(", ?", len(incidentIDs)-1) + `)
+            GROUP BY incident_id
+        )
+    `
+
+    rows, err := r.db.QueryContext(ctx, query, args...)
+    if err != nil {
+        return nil, err
+    }
+
+    updates, err := scanIncidentUpdates(rows)
+    if err != nil {
+        return nil, err
+    }
+    for _, update := range updates {
+        latest[update.IncidentID] = update
+    }
+    return latest, nil
+}
+
+// findCandidates отбирает активные инциденты, описанный прямоугольник зоны которых
+// пересекается с прямоугольником вокруг точки
+func (r *sqliteIncidentRepository) findCandidates(ctx context.Context, lat, lng, radiusKm float64) ([]*models.Incident, error) {
+    minLat, _, maxLat, _ := geo.BoundingBox(lat, lng, radiusKm)
+    lngCondition, lngArgs := lngRangesCondition("min_lng", "max_lng", geo.LngRanges(lat, lng, radiusKm))
+    query := `
+        SELECT ` + incidentColumns + `
+        FROM incidents
+        WHERE active = 1
+          AND max_lat >= ? AND min_lat <= ?
+          AND ` + lngCondition + `
+    `
+
+    rows, err := r.db.QueryContext(ctx, query, append([]interface{}{minLat, maxLat}, lngArgs...)...)
+    if err != nil {
+        return nil, err
+    }
+
+    return scanIncidents(rows)
+}
+
+func (r *sqliteIncidentRepository) FindNearLocation(ctx context.Context, lat, lng float64, radiusKm float64) ([]*models.Incident, error) {
+    candidates, err := r.findCandidates(ctx, lat, lng, radiusKm)
+    if err != nil {
+        return nil, err
+    }
+
+    // Точное расстояние до зоны по формуле гаверсинусов
+    distances := make(map[int64]float64, len(candidates))
+    var incidents []*models.Incident
+    for _, incident := range candidates {
+        if distance := incident.DistanceToZoneKm(lat, lng); distance <= radiusKm {
+            distances[incident.ID] = distance
+            incidents = append(incidents, incident)
+        }
+    }
+
+    sort.Slice(incidents, func(i, j int) bool {
+        return distances[incidents[i].ID] < distances[incidents[j].ID]
+    })
+
+    return incidents, nil
+}
+
+func (r *sqliteIncidentRepository) FindContainingLocation(ctx context.Context, lat, lng float64) ([]*models.Incident, error) {
+    candidates, err := r.findCandidates(ctx, lat, lng, 0)
+    if err != nil {
+        return nil, err
+    }
+
+    var incidents []*models.Incident
+    for _, incident := range candidates {
+        if incident.Contains(lat, lng) {
+            incidents = append(incidents, incident)
+        }
+    }
+
+    sort.Slice(incidents, func(i, j int) bool { return incidents[i].ID < incidents[j].ID })
+    return incidents, nil
+}
+
+func (r *sqliteIncidentRepository) SaveLocationCheck(ctx context.Context, check *models.LocationCheck) error {
+    query := `
+        INSERT INTO location_checks (user_id, latitude, longitude, timestamp, has_alert)
+        VALUES (?, ?, ?, ?, ?)
+    `
+
+    return r.withTx(ctx, func(tx *sql.Tx) error {
+        result, err := tx.ExecContext(ctx, query,
+            check.UserID,
+            check.Latitude,
+            check.Longitude,
+            check.Timestamp.UnixMicro(),
+            check.HasAlert,
+        )
+        if err != nil {
+            return err
+        }
+        if check.ID, err = result.LastInsertId(); err != nil {
+            return err
+        }
+
+        for _, incidentID := range check.IncidentIDs {
+            if _, err := tx.ExecContext(ctx,
+                `INSERT INTO location_check_incidents (check_id, incident_id) VALUES (?, ?)`,
+                check.ID, incidentID,
+            ); err != nil {
+                return err
+            }
+        }
+        return nil
+    })
+}
+
+func (r *sqliteIncidentRepository) FindLatestLocations(ctx context.Context, since time.Time, lat, lng, radiusKm float64) ([]*models.LocationCheck, error) {
+    minLat, _, maxLat, _ := geo.BoundingBox(lat, lng, radiusKm)
+    lngCondition, lngArgs := lngRangesCondition("longitude", "longitude", geo.LngRanges(lat, lng, radiusKm))
+    query := `
+        SELECT id, user_id, latitude, longitude, timestamp, has_alert
+        FROM (
+            SELECT *, ROW_NUMBER() OVER (PARTITION BY user_id ORDER BY timestamp DESC, id DESC) AS rn
+            FROM location_checks
+            WHERE timestamp >= ?
+        ) latest
+        WHERE rn = 1
+          AND latitude BETWEEN ? AND ?
+          AND ` + lngCondition + `
+        ORDER BY user_id
+    `
+
+    rows, err := r.db.QueryContext(ctx, query, append([]interface{}{since.UnixMicro(), minLat, maxLat}, lngArgs...)...)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var checks []*models.LocationCheck
+    for rows.Next() {
+        var check models.LocationCheck
+        var timestamp int64
+        if err := rows.Scan(
+            &check.ID,
+            &check.UserID,
+            &check.Latitude,
+            &check.Longitude,
+            &timestamp,
+            &check.HasAlert,
+        ); err != nil {
+            return nil, err
+        }
+        check.Timestamp = time.UnixMicro(timestamp)
+        checks = append(checks, &check)
+    }
+
+    return checks, rows.Err()
+}
+
+func (r *sqliteIncidentRepository) MarkAlerted(ctx context.Context, incidentID int64, userIDs []string, at time.Time) ([]string, error) {
+    query := `
+        INSERT INTO incident_alerts (incident_id, user_id, alerted_at) VALUES (?, ?, ?)
+        ON CONFLICT (incident_id, user_id) DO NOTHING
+    `
+
+    var marked []string
+    err := r.withTx(ctx, func(tx *sql.Tx) error {
+        for _, userID := range userIDs {
+            result, err := tx.ExecContext(ctx, query, incidentID, userID, at.UnixMicro())
+            if err != nil {
+                return err
+            }
+            affected, err := result.RowsAffected()
+            if err != nil {
+                return err
+            }
+            if affected > 0 {
+                marked = append(marked, userID)
+            }
+        }
+        return nil
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return marked, nil
+}
+
+func (r *sqliteIncidentRepository) UnmarkAlerted(ctx context.Context, incidentID int64, userIDs []string) error {
+    query := `DELETE FROM incident_alerts WHERE incident_id = ? AND user_id = ?`
+
+    return r.withTx(ctx, func(tx *sql.Tx) error {
+        for _, userID := range userIDs {
+            if _, err := tx.ExecContext(ctx, query, incidentID, userID); err != nil {
+                return err
+            }
+        }
+        return nil
+    })
+}
+
+func (r *sqliteIncidentRepository) GetStats(ctx context.Context, minutes int, filter models.IncidentFilter) ([]*models.IncidentStats, error) {
+    query := `
+        SELECT
+            COALESCE(lci.incident_id, 0) as zone_id,
+            COUNT(DISTINCT lc.user_id) as user_count
+        FROM location_checks lc
+        LEFT JOIN location_check_incidents lci ON lci.check_id = lc.id
+        WHERE lc.timestamp >= ?
+        GROUP BY COALESCE(lci.incident_id, 0)
+    `
+    args := []interface{}{time.Now().Add(-time.Duration(minutes) * time.Minute).UnixMicro()}
+
+    if !filter.IsEmpty() {
+        var where string
+        where, args = incidentFilterClause(filter, args)
+        query = `
+            SELECT
+                lci.incident_id as zone_id,
+                COUNT(DISTINCT lc.user_id) as user_count
+            FROM location_checks lc
+            JOIN location_check_incidents lci ON lci.check_id = lc.id
+            JOIN incidents ON incidents.id = lci.incident_id
+            WHERE lc.timestamp >= ?
+              AND ` + where + `
+            GROUP BY lci.incident_id
+        `
+    }
+
+    rows, err := r.db.QueryContext(ctx, query, args...)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var stats []*models.IncidentStats
+    for rows.Next() {
+        var stat models.IncidentStats
+        if err := rows.Scan(&stat.ZoneID, &stat.UserCount); err != nil {
+            return nil, err
+        }
+        // Для zone_id = 0 (NULL значения) устанавливаем nil
+        if stat.ZoneID != nil && *stat.ZoneID == 0 {
+            stat.ZoneID = nil
+        }
+        stats = append(stats, &stat)
+    }
+
+    return stats, rows.Err()
+}
+
+func (r *sqliteIncidentRepository) GetActiveIncidents(ctx context.Context) ([]*models.Incident, error) {
+    query := `SELECT ` + incidentColumns + ` FROM incidents WHERE active = 1 ORDER BY id`
+
+    rows, err := r.db.QueryContext(ctx, query)
+    if err != nil {
+        return nil, err
+    }
+
+    return scanIncidents(rows)
+}
+
+func (r *sqliteIncidentRepository) CountAll(ctx context.Context, filter models.IncidentFilter) (int, error) {
+    where, args := incidentFilterClause(filter, nil)
+    query := `SELECT COUNT(*) FROM incidents WHERE ` + where
+
+    var count int
+    err := r.db.QueryRowContext(ctx, query, args...).Scan(&count)
+    return count, err
+}
@@ -0,0 +1,37 @@
+-- Схема SQLite повторяет migrations/001_init.sql и 002_incident_polygon.sql.
+-- Время хранится в микросекундах Unix (UTC).
+CREATE TABLE incidents (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id TEXT NOT NULL,
+    latitude REAL NOT NULL,
+    longitude REAL NOT NULL,
+    title TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    severity TEXT NOT NULL CHECK (severity IN ('low', 'medium', 'high')),
+    radius REAL NOT NULL, -- в метрах
+    polygon TEXT, -- JSON-массив вершин, NULL для круглой зоны
+    active INTEGER NOT NULL DEFAULT 1,
+    created_at INTEGER NOT NULL,
+    updated_at INTEGER NOT NULL,
+    -- Описанный прямоугольник зоны для предварительного отбора в FindNearLocation
+    min_lat REAL NOT NULL,
+    max_lat REAL NOT NULL,
+    min_lng REAL NOT NULL,
+    max_lng REAL NOT NULL
+);
+
+CREATE TABLE location_checks (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id TEXT NOT NULL,
+    latitude REAL NOT NULL,
+    longitude REAL NOT NULL,
+    timestamp INTEGER NOT NULL,
+    has_alert INTEGER NOT NULL,
+    incident_id INTEGER REFERENCES incidents(id) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_incidents_active ON incidents(active);
+CREATE INDEX idx_incidents_bbox ON incidents(active, min_lat, max_lat, min_lng, max_lng);
+CREATE INDEX idx_location_checks_timestamp ON location_checks(timestamp);
+CREATE INDEX idx_location_checks_user_id ON location_checks(user_id);
+CREATE INDEX idx_location_checks_incident_id ON location_checks(incident_id);
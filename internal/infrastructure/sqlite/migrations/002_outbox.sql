@@ -0,0 +1,20 @@
+-- Transactional outbox, аналог migrations/003_outbox.sql
+CREATE TABLE incident_outbox (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    incident_id INTEGER NOT NULL,
+    event_type TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    created_at INTEGER NOT NULL,
+    published_at INTEGER,
+    delivered TEXT NOT NULL DEFAULT '[]', -- JSON-массив издателей
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT
+);
+
+CREATE INDEX idx_incident_outbox_pending ON incident_outbox(published_at, id);
+
+CREATE TABLE outbox_lease (
+    name TEXT PRIMARY KEY,
+    owner TEXT NOT NULL,
+    expires_at INTEGER NOT NULL
+);
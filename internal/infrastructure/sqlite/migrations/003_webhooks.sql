@@ -0,0 +1,32 @@
+-- Подписки и журнал доставок вебхуков, аналог migrations/004_webhooks.sql
+CREATE TABLE webhook_subscriptions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    url TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    event_types TEXT NOT NULL DEFAULT '[]', -- JSON-массив; пустой - все события
+    active INTEGER NOT NULL DEFAULT 1,
+    created_at INTEGER NOT NULL,
+    updated_at INTEGER NOT NULL
+);
+
+CREATE TABLE webhook_deliveries (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    delivery_id TEXT NOT NULL,
+    subscription_id INTEGER NOT NULL,
+    event_type TEXT NOT NULL,
+    event_id TEXT NOT NULL DEFAULT '',
+    destination TEXT NOT NULL,
+    attempt INTEGER NOT NULL,
+    request_hash TEXT NOT NULL,
+    status_code INTEGER NOT NULL DEFAULT 0,
+    latency_ms INTEGER NOT NULL DEFAULT 0,
+    success INTEGER NOT NULL,
+    error TEXT NOT NULL DEFAULT '',
+    replay_of TEXT NOT NULL DEFAULT '',
+    payload TEXT NOT NULL,
+    created_at INTEGER NOT NULL
+);
+
+CREATE INDEX idx_webhook_deliveries_delivery_id ON webhook_deliveries(delivery_id);
+CREATE INDEX idx_webhook_deliveries_subscription ON webhook_deliveries(subscription_id, created_at);
+CREATE INDEX idx_webhook_deliveries_created_at ON webhook_deliveries(created_at);
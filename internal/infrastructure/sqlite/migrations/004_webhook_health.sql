@@ -0,0 +1,5 @@
+-- Состояние подписок, аналог migrations/005_webhook_health.sql
+ALTER TABLE webhook_subscriptions ADD COLUMN notify_url TEXT NOT NULL DEFAULT '';
+ALTER TABLE webhook_subscriptions ADD COLUMN failing_since INTEGER;
+ALTER TABLE webhook_subscriptions ADD COLUMN disabled_at INTEGER;
+ALTER TABLE webhook_subscriptions ADD COLUMN disabled_reason TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+-- Формат тела вебхука, аналог migrations/006_webhook_format.sql
+ALTER TABLE webhook_subscriptions ADD COLUMN format TEXT NOT NULL DEFAULT 'legacy';
@@ -0,0 +1,6 @@
+-- Срок действия инцидента и измененные поля в событиях,
+-- аналог migrations/007_incident_lifecycle.sql
+ALTER TABLE incidents ADD COLUMN expires_at INTEGER;
+ALTER TABLE incident_outbox ADD COLUMN changed_fields TEXT; -- JSON-массив имен полей
+
+CREATE INDEX idx_incidents_expires_at ON incidents(expires_at) WHERE active = 1 AND expires_at IS NOT NULL;
@@ -0,0 +1,9 @@
+-- Оповещения пользователей об инцидентах, аналог migrations/008_incident_alerts.sql
+CREATE TABLE incident_alerts (
+    incident_id INTEGER NOT NULL REFERENCES incidents(id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL,
+    alerted_at INTEGER NOT NULL,
+    PRIMARY KEY (incident_id, user_id)
+);
+
+CREATE INDEX idx_location_checks_user_timestamp ON location_checks(user_id, timestamp);
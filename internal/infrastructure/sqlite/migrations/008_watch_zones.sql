@@ -0,0 +1,27 @@
+-- Зоны наблюдения пользователей, аналог migrations/009_watch_zones.sql
+CREATE TABLE watch_zones (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    latitude REAL NOT NULL,
+    longitude REAL NOT NULL,
+    radius REAL NOT NULL, -- в метрах
+    polygon TEXT, -- JSON-массив вершин, NULL для круглой зоны
+    min_severity TEXT NOT NULL DEFAULT 'low' CHECK (min_severity IN ('low', 'medium', 'high')),
+    min_lat REAL NOT NULL,
+    max_lat REAL NOT NULL,
+    min_lng REAL NOT NULL,
+    max_lng REAL NOT NULL,
+    created_at INTEGER NOT NULL,
+    updated_at INTEGER NOT NULL
+);
+
+CREATE INDEX idx_watch_zones_user_id ON watch_zones(user_id);
+CREATE INDEX idx_watch_zones_bbox ON watch_zones(min_lat, max_lat, min_lng, max_lng);
+
+CREATE TABLE watch_zone_alerts (
+    incident_id INTEGER NOT NULL REFERENCES incidents(id) ON DELETE CASCADE,
+    zone_id INTEGER NOT NULL REFERENCES watch_zones(id) ON DELETE CASCADE,
+    alerted_at INTEGER NOT NULL,
+    PRIMARY KEY (incident_id, zone_id)
+);
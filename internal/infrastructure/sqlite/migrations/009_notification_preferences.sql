@@ -0,0 +1,19 @@
+-- Настройки оповещений пользователей, аналог migrations/010_notification_preferences.sql
+CREATE TABLE notification_preferences (
+    user_id TEXT PRIMARY KEY,
+    min_severity TEXT NOT NULL DEFAULT 'low' CHECK (min_severity IN ('low', 'medium', 'high')),
+    categories TEXT NOT NULL DEFAULT '[]', -- JSON-массив кодов категорий
+    time_zone TEXT NOT NULL DEFAULT 'UTC',
+    quiet_start TEXT,
+    quiet_end TEXT,
+    quiet_hours_override TEXT NOT NULL DEFAULT 'high' CHECK (quiet_hours_override IN ('low', 'medium', 'high')),
+    max_alerts_per_hour INTEGER NOT NULL DEFAULT 0 CHECK (max_alerts_per_hour >= 0),
+    updated_at INTEGER NOT NULL
+);
+
+CREATE TABLE user_alert_log (
+    user_id TEXT NOT NULL,
+    sent_at INTEGER NOT NULL
+);
+
+CREATE INDEX idx_user_alert_log_user_sent ON user_alert_log(user_id, sent_at);
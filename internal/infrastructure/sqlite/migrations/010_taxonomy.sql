@@ -0,0 +1,142 @@
+-- foreign_keys: off
+-- Категории, теги и настраиваемая шкала опасности, аналог migrations/011_taxonomy.sql.
+-- SQLite не умеет удалять ограничения CHECK, поэтому таблицы со старым списком
+-- уровней пересоздаются; внешние ключи на время миграции отключены.
+CREATE TABLE severity_levels (
+    code TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    level INTEGER NOT NULL UNIQUE CHECK (level > 0),
+    color TEXT NOT NULL -- #RRGGBB
+);
+
+INSERT INTO severity_levels (code, name, level, color) VALUES
+    ('low', 'Низкий', 10, '#2E7D32'),
+    ('medium', 'Средний', 20, '#F9A825'),
+    ('high', 'Высокий', 30, '#C62828');
+
+CREATE TABLE incident_categories (
+    code TEXT PRIMARY KEY,
+    parent_code TEXT REFERENCES incident_categories(code),
+    name TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    created_at INTEGER NOT NULL
+);
+
+INSERT INTO incident_categories (code, parent_code, name, created_at) VALUES
+    ('fire', NULL, 'Пожар', 0),
+    ('fire.wildfire', 'fire', 'Природный пожар', 0),
+    ('fire.building', 'fire', 'Пожар в здании', 0),
+    ('flood', NULL, 'Наводнение', 0),
+    ('crime', NULL, 'Преступление', 0),
+    ('road_closure', NULL, 'Перекрытие дороги', 0),
+    ('road_closure.accident', 'road_closure', 'ДТП', 0),
+    ('road_closure.roadworks', 'road_closure', 'Дорожные работы', 0),
+    ('chemical', NULL, 'Химическая опасность', 0),
+    ('chemical.gas_leak', 'chemical', 'Утечка газа', 0),
+    ('weather', NULL, 'Опасные погодные явления', 0),
+    ('other', NULL, 'Прочее', 0);
+
+CREATE TABLE new_incidents (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id TEXT NOT NULL,
+    latitude REAL NOT NULL,
+    longitude REAL NOT NULL,
+    title TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    severity TEXT NOT NULL REFERENCES severity_levels(code),
+    category TEXT NOT NULL DEFAULT 'other' REFERENCES incident_categories(code),
+    tags TEXT NOT NULL DEFAULT '[]', -- JSON-массив тегов
+    radius REAL NOT NULL, -- в метрах
+    polygon TEXT, -- JSON-массив вершин, NULL для круглой зоны
+    active INTEGER NOT NULL DEFAULT 1,
+    created_at INTEGER NOT NULL,
+    updated_at INTEGER NOT NULL,
+    min_lat REAL NOT NULL,
+    max_lat REAL NOT NULL,
+    min_lng REAL NOT NULL,
+    max_lng REAL NOT NULL,
+    expires_at INTEGER
+);
+
+INSERT INTO new_incidents (
+    id, user_id, latitude, longitude, title, description, severity, radius, polygon,
+    active, created_at, updated_at, min_lat, max_lat, min_lng, max_lng, expires_at
+)
+SELECT
+    id, user_id, latitude, longitude, title, description, severity, radius, polygon,
+    active, created_at, updated_at, min_lat, max_lat, min_lng, max_lng, expires_at
+FROM incidents;
+
+DROP TABLE incidents;
+ALTER TABLE new_incidents RENAME TO incidents;
+
+CREATE INDEX idx_incidents_active ON incidents(active);
+CREATE INDEX idx_incidents_bbox ON incidents(active, min_lat, max_lat, min_lng, max_lng);
+CREATE INDEX idx_incidents_expires_at ON incidents(expires_at) WHERE active = 1 AND expires_at IS NOT NULL;
+CREATE INDEX idx_incidents_category ON incidents(category);
+
+-- Существующие инциденты классифицируются по ключевым словам заголовка.
+-- LIKE в SQLite не различает регистр только для латиницы.
+UPDATE incidents SET category = 'fire'
+WHERE title LIKE '%пожар%' OR title LIKE '%Пожар%' OR title LIKE '%возгоран%' OR title LIKE '%Возгоран%'
+   OR title LIKE '%fire%';
+UPDATE incidents SET category = 'flood'
+WHERE category = 'other' AND (
+    title LIKE '%наводнен%' OR title LIKE '%Наводнен%' OR title LIKE '%подтоплен%' OR title LIKE '%Подтоплен%'
+    OR title LIKE '%паводок%' OR title LIKE '%Паводок%' OR title LIKE '%flood%');
+UPDATE incidents SET category = 'chemical'
+WHERE category = 'other' AND (
+    title LIKE '%хими%' OR title LIKE '%Хими%' OR title LIKE '%утечк%' OR title LIKE '%Утечк%'
+    OR title LIKE '%выброс%' OR title LIKE '%Выброс%' OR title LIKE '%chemical%' OR title LIKE '%gas leak%');
+UPDATE incidents SET category = 'road_closure'
+WHERE category = 'other' AND (
+    title LIKE '%перекрыт%' OR title LIKE '%Перекрыт%' OR title LIKE '%ДТП%' OR title LIKE '%авари%'
+    OR title LIKE '%Авари%' OR title LIKE '%road%');
+UPDATE incidents SET category = 'crime'
+WHERE category = 'other' AND (
+    title LIKE '%краж%' OR title LIKE '%Краж%' OR title LIKE '%ограблен%' OR title LIKE '%Ограблен%'
+    OR title LIKE '%нападен%' OR title LIKE '%Нападен%' OR title LIKE '%crime%');
+
+CREATE TABLE new_watch_zones (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    latitude REAL NOT NULL,
+    longitude REAL NOT NULL,
+    radius REAL NOT NULL, -- в метрах
+    polygon TEXT, -- JSON-массив вершин, NULL для круглой зоны
+    min_severity TEXT NOT NULL DEFAULT 'low' REFERENCES severity_levels(code),
+    min_lat REAL NOT NULL,
+    max_lat REAL NOT NULL,
+    min_lng REAL NOT NULL,
+    max_lng REAL NOT NULL,
+    created_at INTEGER NOT NULL,
+    updated_at INTEGER NOT NULL
+);
+
+INSERT INTO new_watch_zones SELECT * FROM watch_zones;
+DROP TABLE watch_zones;
+ALTER TABLE new_watch_zones RENAME TO watch_zones;
+
+CREATE INDEX idx_watch_zones_user_id ON watch_zones(user_id);
+CREATE INDEX idx_watch_zones_bbox ON watch_zones(min_lat, max_lat, min_lng, max_lng);
+
+CREATE TABLE new_notification_preferences (
+    user_id TEXT PRIMARY KEY,
+    min_severity TEXT NOT NULL DEFAULT 'low' REFERENCES severity_levels(code),
+    categories TEXT NOT NULL DEFAULT '[]', -- JSON-массив кодов категорий
+    time_zone TEXT NOT NULL DEFAULT 'UTC',
+    quiet_start TEXT,
+    quiet_end TEXT,
+    quiet_hours_override TEXT NOT NULL DEFAULT 'high' REFERENCES severity_levels(code),
+    max_alerts_per_hour INTEGER NOT NULL DEFAULT 0 CHECK (max_alerts_per_hour >= 0),
+    updated_at INTEGER NOT NULL
+);
+
+INSERT INTO new_notification_preferences SELECT * FROM notification_preferences;
+DROP TABLE notification_preferences;
+ALTER TABLE new_notification_preferences RENAME TO notification_preferences;
+
+ALTER TABLE webhook_subscriptions ADD COLUMN categories TEXT NOT NULL DEFAULT '[]'; -- JSON-массив кодов категорий
+ALTER TABLE webhook_subscriptions ADD COLUMN tags TEXT NOT NULL DEFAULT '[]'; -- JSON-массив тегов
+ALTER TABLE webhook_subscriptions ADD COLUMN min_severity TEXT REFERENCES severity_levels(code); -- NULL - любой уровень
@@ -0,0 +1,25 @@
+-- Сообщения жителей о происшествиях и очередь модерации, аналог
+-- migrations/012_incident_reports.sql. Категория и уровень в сообщении - лишь
+-- предложение заявителя, поэтому на справочники они не ссылаются.
+CREATE TABLE incident_reports (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id TEXT NOT NULL,
+    reporter_ip TEXT NOT NULL DEFAULT '',
+    latitude REAL NOT NULL,
+    longitude REAL NOT NULL,
+    description TEXT NOT NULL,
+    category TEXT NOT NULL DEFAULT 'other',
+    severity TEXT,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approving', 'approved', 'merged', 'rejected')),
+    abuse_score REAL NOT NULL DEFAULT 0,
+    incident_id INTEGER REFERENCES incidents(id) ON DELETE SET NULL,
+    abusive INTEGER NOT NULL DEFAULT 0,
+    moderation_note TEXT NOT NULL DEFAULT '',
+    moderated_at INTEGER,
+    created_at INTEGER NOT NULL
+);
+
+CREATE INDEX idx_incident_reports_queue ON incident_reports(status, abuse_score, created_at);
+CREATE INDEX idx_incident_reports_user ON incident_reports(user_id, created_at);
+CREATE INDEX idx_incident_reports_ip ON incident_reports(reporter_ip, created_at);
+CREATE INDEX idx_incident_reports_incident ON incident_reports(incident_id);
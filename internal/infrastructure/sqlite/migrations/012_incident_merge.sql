@@ -0,0 +1,8 @@
+-- Объединение дубликатов, аналог migrations/013_incident_merge.sql: присоединенный
+-- инцидент деактивируется и ссылается на тот, в который он объединен
+ALTER TABLE incidents ADD COLUMN merged_into INTEGER REFERENCES incidents(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_incidents_merged_into ON incidents(merged_into) WHERE merged_into IS NOT NULL;
+
+-- Поиск похожих сообщений в очереди модерации
+CREATE INDEX idx_incident_reports_pending_location ON incident_reports(latitude, longitude) WHERE status = 'pending';
@@ -0,0 +1,17 @@
+-- Вложения инцидентов, аналог migrations/014_incident_attachments.sql
+CREATE TABLE incident_attachments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    incident_id INTEGER NOT NULL REFERENCES incidents(id) ON DELETE CASCADE,
+    file_name TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    size INTEGER NOT NULL,
+    checksum TEXT NOT NULL,
+    width INTEGER,
+    height INTEGER,
+    storage_key TEXT NOT NULL UNIQUE,
+    thumbnail_key TEXT,
+    uploaded_by TEXT NOT NULL DEFAULT '',
+    created_at INTEGER NOT NULL
+);
+
+CREATE INDEX idx_incident_attachments_incident ON incident_attachments(incident_id, id);
@@ -0,0 +1,14 @@
+-- Хроника инцидента, аналог migrations/015_incident_updates.sql
+CREATE TABLE incident_updates (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    incident_id INTEGER NOT NULL REFERENCES incidents(id) ON DELETE CASCADE,
+    message TEXT NOT NULL,
+    public INTEGER NOT NULL DEFAULT 0,
+    author TEXT NOT NULL DEFAULT '',
+    created_at INTEGER NOT NULL
+);
+
+CREATE INDEX idx_incident_updates_incident ON incident_updates(incident_id, id);
+CREATE INDEX idx_incident_updates_public ON incident_updates(incident_id, id) WHERE public = 1;
+
+ALTER TABLE incident_outbox ADD COLUMN update_payload TEXT; -- JSON сообщения хроники
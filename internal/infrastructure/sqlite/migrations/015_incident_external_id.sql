@@ -0,0 +1,4 @@
+-- ID инцидента во внешней системе, аналог migrations/016_incident_external_id.sql
+ALTER TABLE incidents ADD COLUMN external_id TEXT;
+
+CREATE UNIQUE INDEX idx_incidents_external_id ON incidents(external_id) WHERE external_id IS NOT NULL;
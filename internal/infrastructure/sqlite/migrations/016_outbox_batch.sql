@@ -0,0 +1,2 @@
+-- Общий ID событий пакетной операции, аналог migrations/017_outbox_batch.sql
+ALTER TABLE incident_outbox ADD COLUMN batch_id TEXT;
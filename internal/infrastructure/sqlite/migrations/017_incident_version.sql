@@ -0,0 +1,2 @@
+-- Версия инцидента для оптимистичной блокировки, аналог migrations/018_incident_version.sql
+ALTER TABLE incidents ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
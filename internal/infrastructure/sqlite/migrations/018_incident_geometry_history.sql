@@ -0,0 +1,15 @@
+-- История зоны инцидента, аналог migrations/019_incident_geometry_history.sql
+CREATE TABLE incident_geometry_history (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    incident_id INTEGER NOT NULL REFERENCES incidents(id) ON DELETE CASCADE,
+    version INTEGER NOT NULL,
+    latitude REAL NOT NULL,
+    longitude REAL NOT NULL,
+    radius REAL NOT NULL,
+    polygon TEXT, -- JSON вершин
+    replaced_at INTEGER NOT NULL
+);
+
+CREATE INDEX idx_incident_geometry_history_incident ON incident_geometry_history(incident_id, id);
+
+ALTER TABLE incident_outbox ADD COLUMN geometry_payload TEXT; -- JSON прежней и новой зоны
@@ -0,0 +1,4 @@
+-- Описанный прямоугольник зоны у антимеридиана занимает весь диапазон долгот:
+-- поиск разбивает свой прямоугольник на два диапазона по обе стороны от 180
+UPDATE incidents SET min_lng = -180, max_lng = 180 WHERE min_lng < -180 OR max_lng > 180;
+UPDATE watch_zones SET min_lng = -180, max_lng = 180 WHERE min_lng < -180 OR max_lng > 180;
@@ -0,0 +1,6 @@
+-- Повторы событий outbox и dead letter, аналог migrations/021_outbox_retry.sql
+ALTER TABLE incident_outbox ADD COLUMN next_attempt_at INTEGER; -- NULL - публиковать сразу
+ALTER TABLE incident_outbox ADD COLUMN dead_lettered_at INTEGER;
+
+CREATE INDEX idx_incident_outbox_incident_pending ON incident_outbox(incident_id, id)
+    WHERE published_at IS NULL AND dead_lettered_at IS NULL;
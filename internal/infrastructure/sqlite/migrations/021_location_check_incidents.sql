@@ -0,0 +1,33 @@
+-- foreign_keys: off
+-- Инциденты проверки локации в отдельной таблице, аналог
+-- migrations/022_location_check_incidents.sql. SQLite не удаляет столбец, на который
+-- ссылается внешний ключ, поэтому location_checks пересоздается без incident_id.
+CREATE TABLE location_check_incidents (
+    check_id INTEGER NOT NULL REFERENCES location_checks(id) ON DELETE CASCADE,
+    incident_id INTEGER NOT NULL REFERENCES incidents(id) ON DELETE CASCADE,
+    PRIMARY KEY (check_id, incident_id)
+);
+
+CREATE INDEX idx_location_check_incidents_incident ON location_check_incidents(incident_id);
+
+INSERT INTO location_check_incidents (check_id, incident_id)
+SELECT id, incident_id FROM location_checks WHERE incident_id IS NOT NULL;
+
+CREATE TABLE new_location_checks (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id TEXT NOT NULL,
+    latitude REAL NOT NULL,
+    longitude REAL NOT NULL,
+    timestamp INTEGER NOT NULL,
+    has_alert INTEGER NOT NULL
+);
+
+INSERT INTO new_location_checks (id, user_id, latitude, longitude, timestamp, has_alert)
+SELECT id, user_id, latitude, longitude, timestamp, has_alert FROM location_checks;
+
+DROP TABLE location_checks;
+ALTER TABLE new_location_checks RENAME TO location_checks;
+
+CREATE INDEX idx_location_checks_timestamp ON location_checks(timestamp);
+CREATE INDEX idx_location_checks_user_id ON location_checks(user_id);
+CREATE INDEX idx_location_checks_user_timestamp ON location_checks(user_id, timestamp);
@@ -0,0 +1,2 @@
+-- Публикация инцидента в открытых лентах, аналог migrations/023_incident_public.sql
+ALTER TABLE incidents ADD COLUMN public INTEGER NOT NULL DEFAULT 0;
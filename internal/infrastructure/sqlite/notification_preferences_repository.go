@@ -0,0 +1,141 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/domain/repositories"
+)
+
+type sqliteNotificationPreferencesRepository struct {
+    db *sql.DB
+}
+
+func NewNotificationPreferencesRepository(db *sql.DB) repositories.NotificationPreferencesRepository {
+    return &sqliteNotificationPreferencesRepository{db: db}
+}
+
+func (r *sqliteNotificationPreferencesRepository) Get(ctx context.Context, userID string) (*models.NotificationPreferences, error) {
+    query := `
+        SELECT user_id, min_severity, categories, time_zone, quiet_start, quiet_end,
+               quiet_hours_override, max_alerts_per_hour, updated_at
+        FROM notification_preferences
+        WHERE user_id = ?
+    `
+
+    var prefs models.NotificationPreferences
+    var categories string
+    var quietStart, quietEnd sql.NullString
+    var updatedAt int64
+
+    err := r.db.QueryRowContext(ctx, query, userID).Scan(
+        &prefs.UserID,
+        &prefs.MinSeverity,
+        &categories,
+        &prefs.TimeZone,
+        &quietStart,
+        &quietEnd,
+        &prefs.QuietHoursOverride,
+        &prefs.MaxAlertsPerHour,
+        &updatedAt,
+    )
+    if err == sql.ErrNoRows {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    if err := json.Unmarshal([]byte(categories), &prefs.Categories); err != nil {
+        return nil, err
+    }
+    if quietStart.Valid && quietEnd.Valid {
+        prefs.QuietHours = &models.QuietHours{Start: quietStart.String, End: quietEnd.String}
+    }
+    prefs.UpdatedAt = time.UnixMicro(updatedAt)
+
+    return &prefs, nil
+}
+
+func (r *sqliteNotificationPreferencesRepository) Save(ctx context.Context, prefs *models.NotificationPreferences) error {
+    query := `
+        INSERT INTO notification_preferences (
+            user_id, min_severity, categories, time_zone, quiet_start, quiet_end,
+            quiet_hours_override, max_alerts_per_hour, updated_at
+        ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+        ON CONFLICT (user_id) DO UPDATE SET
+            min_severity = excluded.min_severity,
+            categories = excluded.categories,
+            time_zone = excluded.time_zone,
+            quiet_start = excluded.quiet_start,
+            quiet_end = excluded.quiet_end,
+            quiet_hours_override = excluded.quiet_hours_override,
+            max_alerts_per_hour = excluded.max_alerts_per_hour,
+            updated_at = excluded.updated_at
+    `
+
+    categories, err := encodeStrings(prefs.Categories)
+    if err != nil {
+        return err
+    }
+    var quietStart, quietEnd sql.NullString
+    if prefs.QuietHours != nil {
+        quietStart = sql.NullString{String: prefs.QuietHours.Start, Valid: true}
+        quietEnd = sql.NullString{String: prefs.QuietHours.End, Valid: true}
+    }
+
+    _, err = r.db.ExecContext(ctx, query,
+        prefs.UserID,
+        prefs.MinSeverity,
+        categories,
+        prefs.TimeZone,
+        quietStart,
+        quietEnd,
+        prefs.QuietHoursOverride,
+        prefs.MaxAlertsPerHour,
+        prefs.UpdatedAt.UnixMicro(),
+    )
+    return err
+}
+
+func (r *sqliteNotificationPreferencesRepository) Delete(ctx context.Context, userID string) error {
+    _, err := r.db.ExecContext(ctx, `DELETE FROM notification_preferences WHERE user_id = ?`, userID)
+    return err
+}
+
+func (r *sqliteNotificationPreferencesRepository) ReserveAlert(ctx context.Context, userID string, since time.Time, limit int, at time.Time) (bool, error) {
+    // Единственное соединение сериализует транзакции, поэтому подсчет и запись атомарны
+    tx, err := r.db.BeginTx(ctx, nil)
+    if err != nil {
+        return false, err
+    }
+    defer tx.Rollback()
+
+    // Записи за пределами окна больше не нужны
+    if _, err := tx.ExecContext(ctx,
+        `DELETE FROM user_alert_log WHERE user_id = ? AND sent_at < ?`, userID, since.UnixMicro(),
+    ); err != nil {
+        return false, err
+    }
+
+    var sent int
+    if err := tx.QueryRowContext(ctx,
+        `SELECT COUNT(*) FROM user_alert_log WHERE user_id = ?`, userID,
+    ).Scan(&sent); err != nil {
+        return false, err
+    }
+    if sent >= limit {
+        return false, nil
+    }
+
+    if _, err := tx.ExecContext(ctx,
+        `INSERT INTO user_alert_log (user_id, sent_at) VALUES (?, ?)`, userID, at.UnixMicro(),
+    ); err != nil {
+        return false, err
+    }
+
+    return true, tx.Commit()
+}
@@ -0,0 +1,192 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/domain/repositories"
+)
+
+type sqliteOutboxRepository struct {
+    db *sql.DB
+}
+
+func NewOutboxRepository(db *sql.DB) repositories.OutboxRepository {
+    return &sqliteOutboxRepository{db: db}
+}
+
+// insertOutboxEvent записывает событие в outbox в рамках транзакции изменения инцидента
+func insertOutboxEvent(ctx context.Context, tx *sql.Tx, event *models.OutboxEvent) error {
+    payload, err := json.Marshal(event.Incident)
+    if err != nil {
+        return err
+    }
+
+    var changedFields interface{}
+    if event.ChangedFields != nil {
+        data, err := json.Marshal(event.ChangedFields)
+        if err != nil {
+            return err
+        }
+        changedFields = string(data)
+    }
+
+    var update interface{}
+    if event.Update != nil {
+        data, err := json.Marshal(event.Update)
+        if err != nil {
+            return err
+        }
+        update = string(data)
+    }
+
+    var geometry interface{}
+    if event.Geometry != nil {
+        data, err := json.Marshal(event.Geometry)
+        if err != nil {
+            return err
+        }
+        geometry = string(data)
+    }
+
+    result, err := tx.ExecContext(ctx, `
+        INSERT INTO incident_outbox (incident_id, event_type, payload, changed_fields, update_payload, geometry_payload, batch_id, created_at)
+        VALUES (?, ?, ?, ?, ?, ?, NULLIF(?, ''), ?)
+    `, event.IncidentID, event.EventType, string(payload), changedFields, update, geometry, event.BatchID, event.CreatedAt.UnixMicro())
+    if err != nil {
+        return err
+    }
+
+    event.ID, err = result.LastInsertId()
+    return err
+}
+
+func (r *sqliteOutboxRepository) FetchPending(ctx context.Context, now time.Time, limit int) ([]*models.OutboxEvent, error) {
+    // Событие ждет, пока отложен повтор более раннего события того же инцидента
+    query := `
+        SELECT id, incident_id, event_type, payload, changed_fields, update_payload, geometry_payload,
+               COALESCE(batch_id, ''), created_at, delivered, attempts, COALESCE(last_error, ''), next_attempt_at
+        FROM incident_outbox o
+        WHERE published_at IS NULL
+          AND dead_lettered_at IS NULL
+          AND (next_attempt_at IS NULL OR next_attempt_at <= ?)
+          AND NOT EXISTS (
+              SELECT 1 FROM incident_outbox earlier
+              WHERE earlier.incident_id = o.incident_id
+                AND earlier.id < o.id
+                AND earlier.published_at IS NULL
+                AND earlier.dead_lettered_at IS NULL
+                AND earlier.next_attempt_at > ?
+          )
+        ORDER BY id
+        LIMIT ?
+    `
+
+    rows, err := r.db.QueryContext(ctx, query, now.UnixMicro(), now.UnixMicro(), limit)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var events []*models.OutboxEvent
+    for rows.Next() {
+        var event models.OutboxEvent
+        var payload, delivered string
+        var changedFields, update, geometry sql.NullString
+        var createdAt int64
+        var nextAttemptAt sql.NullInt64
+        if err := rows.Scan(
+            &event.ID,
+            &event.IncidentID,
+            &event.EventType,
+            &payload,
+            &changedFields,
+            &update,
+            &geometry,
+            &event.BatchID,
+            &createdAt,
+            &delivered,
+            &event.Attempts,
+            &event.LastError,
+            &nextAttemptAt,
+        ); err != nil {
+            return nil, err
+        }
+        event.CreatedAt = time.UnixMicro(createdAt)
+        event.NextAttemptAt = fromNullMicro(nextAttemptAt)
+        if err := json.Unmarshal([]byte(payload), &event.Incident); err != nil {
+            return nil, err
+        }
+        if changedFields.Valid {
+            if err := json.Unmarshal([]byte(changedFields.String), &event.ChangedFields); err != nil {
+                return nil, err
+            }
+        }
+        if update.Valid {
+            if err := json.Unmarshal([]byte(update.String), &event.Update); err != nil {
+                return nil, err
+            }
+        }
+        if geometry.Valid {
+            if err := json.Unmarshal([]byte(geometry.String), &event.Geometry); err != nil {
+                return nil, err
+            }
+        }
+        if err := json.Unmarshal([]byte(delivered), &event.Delivered); err != nil {
+            return nil, err
+        }
+        events = append(events, &event)
+    }
+
+    return events, rows.Err()
+}
+
+func (r *sqliteOutboxRepository) MarkDelivered(ctx context.Context, id int64, publisher string) error {
+    query := `
+        UPDATE incident_outbox
+        SET delivered = json_insert(delivered, '$[#]', ?)
+        WHERE id = ?
+          AND NOT EXISTS (SELECT 1 FROM json_each(delivered) WHERE value = ?)
+    `
+    _, err := r.db.ExecContext(ctx, query, publisher, id, publisher)
+    return err
+}
+
+func (r *sqliteOutboxRepository) MarkPublished(ctx context.Context, id int64) error {
+    query := `UPDATE incident_outbox SET published_at = ?, last_error = NULL WHERE id = ?`
+    _, err := r.db.ExecContext(ctx, query, time.Now().UnixMicro(), id)
+    return err
+}
+
+func (r *sqliteOutboxRepository) MarkFailed(ctx context.Context, id int64, reason string, retryAt time.Time) error {
+    query := `UPDATE incident_outbox SET attempts = attempts + 1, last_error = ?, next_attempt_at = ? WHERE id = ?`
+    _, err := r.db.ExecContext(ctx, query, reason, retryAt.UnixMicro(), id)
+    return err
+}
+
+func (r *sqliteOutboxRepository) MarkDeadLetter(ctx context.Context, id int64, reason string, at time.Time) error {
+    query := `UPDATE incident_outbox SET attempts = attempts + 1, last_error = ?, dead_lettered_at = ? WHERE id = ?`
+    _, err := r.db.ExecContext(ctx, query, reason, at.UnixMicro(), id)
+    return err
+}
+
+func (r *sqliteOutboxRepository) AcquireLease(ctx context.Context, owner string, ttl time.Duration) (bool, error) {
+    query := `
+        INSERT INTO outbox_lease (name, owner, expires_at) VALUES ('relay', ?, ?)
+        ON CONFLICT (name) DO UPDATE
+        SET owner = excluded.owner, expires_at = excluded.expires_at
+        WHERE outbox_lease.owner = excluded.owner OR outbox_lease.expires_at < ?
+    `
+
+    now := time.Now()
+    result, err := r.db.ExecContext(ctx, query, owner, now.Add(ttl).UnixMicro(), now.UnixMicro())
+    if err != nil {
+        return false, err
+    }
+
+    affected, err := result.RowsAffected()
+    return affected > 0, err
+}
@@ -0,0 +1,275 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/domain/repositories"
+	"incident-system/pkg/geo"
+)
+
+const reportColumns = `id, user_id, reporter_ip, latitude, longitude, description, category, severity, status,
+    abuse_score, incident_id, abusive, moderation_note, moderated_at, created_at`
+
+type sqliteReportRepository struct {
+    db *sql.DB
+}
+
+func NewReportRepository(db *sql.DB) repositories.ReportRepository {
+    return &sqliteReportRepository{db: db}
+}
+
+func scanReport(row rowScanner) (*models.IncidentReport, error) {
+    var report models.IncidentReport
+    var severity sql.NullString
+    var incidentID, moderatedAt sql.NullInt64
+    var createdAt int64
+
+    if err := row.Scan(
+        &report.ID,
+        &report.UserID,
+        &report.ReporterIP,
+        &report.Latitude,
+        &report.Longitude,
+        &report.Description,
+        &report.Category,
+        &severity,
+        &report.Status,
+        &report.AbuseScore,
+        &incidentID,
+        &report.Abusive,
+        &report.Note,
+        &moderatedAt,
+        &createdAt,
+    ); err != nil {
+        return nil, err
+    }
+
+    report.Severity = severity.String
+    if incidentID.Valid {
+        report.IncidentID = &incidentID.Int64
+    }
+    report.ModeratedAt = fromNullMicro(moderatedAt)
+    report.CreatedAt = time.UnixMicro(createdAt)
+    return &report, nil
+}
+
+func (r *sqliteReportRepository) Create(ctx context.Context, report *models.IncidentReport, limit models.ReportRateLimit) error {
+    // Единственное соединение сериализует транзакции, поэтому подсчет и запись атомарны
+    tx, err := r.db.BeginTx(ctx, nil)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    var byReporter, byAddress int
+    if err := tx.QueryRowContext(ctx, `
+        SELECT
+            (SELECT COUNT(*) FROM incident_reports WHERE user_id = ? AND created_at >= ?),
+            (SELECT COUNT(*) FROM incident_reports WHERE reporter_ip = ? AND reporter_ip <> '' AND created_at >= ?)
+    `, report.UserID, limit.Since.UnixMicro(), report.ReporterIP, limit.Since.UnixMicro()).Scan(&byReporter, &byAddress); err != nil {
+        return err
+    }
+    if (limit.PerReporter > 0 && byReporter >= limit.PerReporter) ||
+        (limit.PerAddress > 0 && byAddress >= limit.PerAddress) {
+        return repositories.ErrRateLimited
+    }
+
+    result, err := tx.ExecContext(ctx, `
+        INSERT INTO incident_reports (
+            user_id, reporter_ip, latitude, longitude, description, category, severity,
+            status, abuse_score, created_at
+        ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+    `,
+        report.UserID,
+        report.ReporterIP,
+        report.Latitude,
+        report.Longitude,
+        report.Description,
+        report.Category,
+        toNullString(report.Severity),
+        report.Status,
+        report.AbuseScore,
+        report.CreatedAt.UnixMicro(),
+    )
+    if err != nil {
+        return err
+    }
+
+    if report.ID, err = result.LastInsertId(); err != nil {
+        return err
+    }
+    return tx.Commit()
+}
+
+func (r *sqliteReportRepository) FindByID(ctx context.Context, id int64) (*models.IncidentReport, error) {
+    query := `SELECT ` + reportColumns + ` FROM incident_reports WHERE id = ?`
+
+    report, err := scanReport(r.db.QueryRowContext(ctx, query, id))
+    if err == sql.ErrNoRows {
+        return nil, nil
+    }
+
+    return report, err
+}
+
+func (r *sqliteReportRepository) FindAll(ctx context.Context, filter models.ReportFilter) ([]*models.IncidentReport, int, error) {
+    var conditions []string
+    var args []interface{}
+
+    if filter.Status != "" {
+        conditions = append(conditions, "status = ?")
+        args = append(args, filter.Status)
+    }
+    if filter.UserID != "" {
+        conditions = append(conditions, "user_id = ?")
+        args = append(args, filter.UserID)
+    }
+    if filter.IncidentID != nil {
+        conditions = append(conditions, "incident_id = ?")
+        args = append(args, *filter.IncidentID)
+    }
+
+    whereClause := ""
+    if len(conditions) > 0 {
+        whereClause = "WHERE " + strings.Join(conditions, " AND ")
+    }
+
+    var total int
+    if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM incident_reports `+whereClause, args...).Scan(&total); err != nil {
+        return nil, 0, err
+    }
+
+    orderBy := ` ORDER BY created_at DESC, id DESC`
+    if filter.Status == models.ReportPending {
+        orderBy = ` ORDER BY abuse_score, created_at, id`
+    }
+    query := `SELECT ` + reportColumns + ` FROM incident_reports ` + whereClause + orderBy + ` LIMIT ? OFFSET ?`
+
+    rows, err := r.db.QueryContext(ctx, query, append(args, filter.Limit, filter.Offset)...)
+    if err != nil {
+        return nil, 0, err
+    }
+    defer rows.Close()
+
+    var reports []*models.IncidentReport
+    for rows.Next() {
+        report, err := scanReport(rows)
+        if err != nil {
+            return nil, 0, err
+        }
+        reports = append(reports, report)
+    }
+
+    return reports, total, rows.Err()
+}
+
+func (r *sqliteReportRepository) Claim(ctx context.Context, id int64, at, staleBefore time.Time) (bool, error) {
+    result, err := r.db.ExecContext(ctx, `
+        UPDATE incident_reports
+        SET status = 'approving', moderated_at = ?
+        WHERE id = ? AND (status = 'pending' OR (status = 'approving' AND moderated_at < ?))
+    `, at.UnixMicro(), id, staleBefore.UnixMicro())
+    if err != nil {
+        return false, err
+    }
+
+    affected, err := result.RowsAffected()
+    return affected > 0, err
+}
+
+func (r *sqliteReportRepository) Moderate(ctx context.Context, report *models.IncidentReport, from string) (bool, error) {
+    var incidentID sql.NullInt64
+    if report.IncidentID != nil {
+        incidentID = sql.NullInt64{Int64: *report.IncidentID, Valid: true}
+    }
+
+    result, err := r.db.ExecContext(ctx, `
+        UPDATE incident_reports
+        SET status = ?, incident_id = ?, abusive = ?, moderation_note = ?, moderated_at = ?
+        WHERE id = ? AND status = ?
+    `,
+        report.Status,
+        incidentID,
+        report.Abusive,
+        report.Note,
+        toNullMicro(report.ModeratedAt),
+        report.ID,
+        from,
+    )
+    if err != nil {
+        return false, err
+    }
+
+    affected, err := result.RowsAffected()
+    return affected > 0, err
+}
+
+func (r *sqliteReportRepository) ReporterStats(ctx context.Context, userID string, since time.Time) (*models.ReporterStats, error) {
+    return r.stats(ctx, `user_id = ?`, userID, since)
+}
+
+func (r *sqliteReportRepository) AddressStats(ctx context.Context, ip string, since time.Time) (*models.ReporterStats, error) {
+    return r.stats(ctx, `reporter_ip = ?`, ip, since)
+}
+
+func (r *sqliteReportRepository) stats(ctx context.Context, condition string, value string, since time.Time) (*models.ReporterStats, error) {
+    rows, err := r.db.QueryContext(ctx, `
+        SELECT status, abusive, COUNT(*)
+        FROM incident_reports
+        WHERE `+condition+` AND created_at >= ?
+        GROUP BY status, abusive
+    `, value, since.UnixMicro())
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    stats := &models.ReporterStats{}
+    for rows.Next() {
+        var status string
+        var abusive bool
+        var count int
+        if err := rows.Scan(&status, &abusive, &count); err != nil {
+            return nil, err
+        }
+        stats.Add(status, abusive, count)
+    }
+
+    return stats, rows.Err()
+}
+
+func (r *sqliteReportRepository) FindPendingNear(ctx context.Context, lat, lng, radiusKm float64, since time.Time) ([]*models.IncidentReport, error) {
+    minLat, _, maxLat, _ := geo.BoundingBox(lat, lng, radiusKm)
+    lngCondition, lngArgs := lngRangesCondition("longitude", "longitude", geo.LngRanges(lat, lng, radiusKm))
+    query := `
+        SELECT ` + reportColumns + `
+        FROM incident_reports
+        WHERE status = 'pending'
+          AND latitude BETWEEN ? AND ?
+          AND ` + lngCondition + `
+          AND created_at >= ?
+        ORDER BY created_at, id
+    `
+
+    args := append([]interface{}{minLat, maxLat}, lngArgs...)
+    rows, err := r.db.QueryContext(ctx, query, append(args, since.UnixMicro())...)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var reports []*models.IncidentReport
+    for rows.Next() {
+        report, err := scanReport(rows)
+        if err != nil {
+            return nil, err
+        }
+        reports = append(reports, report)
+    }
+
+    return reports, rows.Err()
+}
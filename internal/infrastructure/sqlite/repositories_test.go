@@ -0,0 +1,101 @@
+package sqlite
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"incident-system/internal/domain/repositories"
+	"incident-system/internal/domain/repositories/repotest"
+)
+
+// openTestDB открывает пустую базу с примененными миграциями во временном каталоге теста
+func openTestDB(t *testing.T) *sql.DB {
+    t.Helper()
+    sqliteDB, err := NewSQLiteDB(filepath.Join(t.TempDir(), "incidents.db"))
+    if err != nil {
+        t.Fatalf("NewSQLiteDB: %v", err)
+    }
+    t.Cleanup(func() { sqliteDB.Close() })
+    return sqliteDB.GetDB()
+}
+
+func TestMigrationsAreIdempotent(t *testing.T) {
+    path := filepath.Join(t.TempDir(), "incidents.db")
+    for i := 0; i < 2; i++ {
+        sqliteDB, err := NewSQLiteDB(path)
+        if err != nil {
+            t.Fatalf("NewSQLiteDB (run %d): %v", i+1, err)
+        }
+        sqliteDB.Close()
+    }
+}
+
+func TestIncidentRepository(t *testing.T) {
+    repotest.RunIncidentRepositorySuite(t, func(t *testing.T) repositories.IncidentRepository {
+        return NewIncidentRepository(openTestDB(t))
+    })
+}
+
+func TestOutboxRepository(t *testing.T) {
+    repotest.RunOutboxRepositorySuite(t, func(t *testing.T) (repositories.IncidentRepository, repositories.OutboxRepository) {
+        db := openTestDB(t)
+        return NewIncidentRepository(db), NewOutboxRepository(db)
+    })
+}
+
+func TestIncidentMerge(t *testing.T) {
+    repotest.RunIncidentMergeSuite(t, func(t *testing.T) repotest.MergeRepositories {
+        db := openTestDB(t)
+        return repotest.MergeRepositories{
+            Incidents:   NewIncidentRepository(db),
+            Outbox:      NewOutboxRepository(db),
+            WatchZones:  NewWatchZoneRepository(db),
+            Reports:     NewReportRepository(db),
+            Attachments: NewAttachmentRepository(db),
+        }
+    })
+}
+
+func TestWebhookRepositories(t *testing.T) {
+    repotest.RunWebhookSubscriptionRepositorySuite(t, func(t *testing.T) repositories.WebhookSubscriptionRepository {
+        return NewSubscriptionRepository(openTestDB(t))
+    })
+    repotest.RunWebhookDeliveryRepositorySuite(t, func(t *testing.T) repositories.WebhookDeliveryRepository {
+        return NewDeliveryRepository(openTestDB(t))
+    })
+}
+
+func TestWatchZoneRepository(t *testing.T) {
+    repotest.RunWatchZoneRepositorySuite(t, func(t *testing.T) (repositories.IncidentRepository, repositories.WatchZoneRepository) {
+        db := openTestDB(t)
+        return NewIncidentRepository(db), NewWatchZoneRepository(db)
+    })
+}
+
+func TestNotificationPreferencesRepository(t *testing.T) {
+    repotest.RunNotificationPreferencesRepositorySuite(t, func(t *testing.T) repositories.NotificationPreferencesRepository {
+        return NewNotificationPreferencesRepository(openTestDB(t))
+    })
+}
+
+func TestTaxonomyRepository(t *testing.T) {
+    repotest.RunTaxonomyRepositorySuite(t, func(t *testing.T) (repositories.IncidentRepository, repositories.TaxonomyRepository) {
+        db := openTestDB(t)
+        return NewIncidentRepository(db), NewTaxonomyRepository(db)
+    })
+}
+
+func TestReportRepository(t *testing.T) {
+    repotest.RunReportRepositorySuite(t, func(t *testing.T) (repositories.IncidentRepository, repositories.ReportRepository) {
+        db := openTestDB(t)
+        return NewIncidentRepository(db), NewReportRepository(db)
+    })
+}
+
+func TestAttachmentRepository(t *testing.T) {
+    repotest.RunAttachmentRepositorySuite(t, func(t *testing.T) (repositories.IncidentRepository, repositories.AttachmentRepository) {
+        db := openTestDB(t)
+        return NewIncidentRepository(db), NewAttachmentRepository(db)
+    })
+}
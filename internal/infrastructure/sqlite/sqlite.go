@@ -0,0 +1,156 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+type SQLiteDB struct {
+    db *sql.DB
+}
+
+// NewSQLiteDB открывает файл базы данных и применяет недостающие миграции
+func NewSQLiteDB(path string) (*SQLiteDB, error) {
+    // WAL позволяет читать параллельно с записью, busy_timeout сглаживает конкуренцию писателей
+    dsn := fmt.Sprintf("file:%s?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)&_pragma=foreign_keys(1)", path)
+
+    db, err := sql.Open("sqlite", dsn)
+    if err != nil {
+        return nil, fmt.Errorf("failed to open database: %w", err)
+    }
+
+    // SQLite допускает только одного писателя одновременно
+    db.SetMaxOpenConns(1)
+
+    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    defer cancel()
+
+    if err := db.PingContext(ctx); err != nil {
+        db.Close()
+        return nil, fmt.Errorf("failed to ping database: %w", err)
+    }
+
+    if err := migrate(ctx, db); err != nil {
+        db.Close()
+        return nil, fmt.Errorf("failed to migrate database: %w", err)
+    }
+
+    log.Printf("Successfully opened SQLite database %s", path)
+    return &SQLiteDB{db: db}, nil
+}
+
+func (s *SQLiteDB) Close() error {
+    return s.db.Close()
+}
+
+func (s *SQLiteDB) GetDB() *sql.DB {
+    return s.db
+}
+
+// foreignKeysOffMarker в первой строке миграции отключает проверку внешних ключей
+// на время ее выполнения - это нужно для пересоздания таблиц, на которые ссылаются другие
+const foreignKeysOffMarker = "-- foreign_keys: off"
+
+// migrate применяет встроенные миграции по порядку имен файлов,
+// каждую в отдельной транзакции, и запоминает их в schema_migrations
+func migrate(ctx context.Context, db *sql.DB) error {
+    if _, err := db.ExecContext(ctx, `
+        CREATE TABLE IF NOT EXISTS schema_migrations (
+            version TEXT PRIMARY KEY,
+            applied_at INTEGER NOT NULL
+        )
+    `); err != nil {
+        return err
+    }
+
+    names, err := fs.Glob(migrations, "migrations/*.sql")
+    if err != nil {
+        return err
+    }
+    sort.Strings(names)
+
+    for _, name := range names {
+        version := strings.TrimSuffix(strings.TrimPrefix(name, "migrations/"), ".sql")
+
+        var applied int
+        if err := db.QueryRowContext(ctx,
+            `SELECT COUNT(*) FROM schema_migrations WHERE version = ?`, version,
+        ).Scan(&applied); err != nil {
+            return err
+        }
+        if applied > 0 {
+            continue
+        }
+
+        script, err := migrations.ReadFile(name)
+        if err != nil {
+            return err
+        }
+
+        if err := applyMigration(ctx, db, version, string(script)); err != nil {
+            return err
+        }
+    }
+
+    return nil
+}
+
+// applyMigration выполняет скрипт в транзакции на выделенном соединении: PRAGMA
+// foreign_keys нельзя менять внутри транзакции и он действует только на свое соединение
+func applyMigration(ctx context.Context, db *sql.DB, version, script string) error {
+    conn, err := db.Conn(ctx)
+    if err != nil {
+        return err
+    }
+    defer conn.Close()
+
+    foreignKeysOff := strings.HasPrefix(script, foreignKeysOffMarker)
+    if foreignKeysOff {
+        if _, err := conn.ExecContext(ctx, `PRAGMA foreign_keys = OFF`); err != nil {
+            return err
+        }
+        defer conn.ExecContext(context.WithoutCancel(ctx), `PRAGMA foreign_keys = ON`)
+    }
+
+    tx, err := conn.BeginTx(ctx, nil)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    if _, err := tx.ExecContext(ctx, script); err != nil {
+        return fmt.Errorf("migration %s: %w", version, err)
+    }
+    if foreignKeysOff {
+        // Пересозданные таблицы должны остаться согласованными
+        rows, err := tx.QueryContext(ctx, `PRAGMA foreign_key_check`)
+        if err != nil {
+            return err
+        }
+        violation := rows.Next()
+        rows.Close()
+        if violation {
+            return fmt.Errorf("migration %s: foreign key violation", version)
+        }
+    }
+    if _, err := tx.ExecContext(ctx,
+        `INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`,
+        version, time.Now().UnixMicro(),
+    ); err != nil {
+        return err
+    }
+
+    return tx.Commit()
+}
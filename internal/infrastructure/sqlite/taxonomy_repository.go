@@ -0,0 +1,169 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/domain/repositories"
+)
+
+type sqliteTaxonomyRepository struct {
+    db *sql.DB
+}
+
+func NewTaxonomyRepository(db *sql.DB) repositories.TaxonomyRepository {
+    return &sqliteTaxonomyRepository{db: db}
+}
+
+func scanCategory(row rowScanner) (*models.Category, error) {
+    var category models.Category
+    var parent sql.NullString
+    var createdAt int64
+
+    if err := row.Scan(&category.Code, &parent, &category.Name, &category.Description, &createdAt); err != nil {
+        return nil, err
+    }
+
+    category.Parent = parent.String
+    category.CreatedAt = time.UnixMicro(createdAt)
+    return &category, nil
+}
+
+func (r *sqliteTaxonomyRepository) ListCategories(ctx context.Context) ([]*models.Category, error) {
+    rows, err := r.db.QueryContext(ctx, `
+        SELECT code, parent_code, name, description, created_at
+        FROM incident_categories
+        ORDER BY code
+    `)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var categories []*models.Category
+    for rows.Next() {
+        category, err := scanCategory(rows)
+        if err != nil {
+            return nil, err
+        }
+        categories = append(categories, category)
+    }
+
+    return categories, rows.Err()
+}
+
+func (r *sqliteTaxonomyRepository) FindCategory(ctx context.Context, code string) (*models.Category, error) {
+    category, err := scanCategory(r.db.QueryRowContext(ctx, `
+        SELECT code, parent_code, name, description, created_at
+        FROM incident_categories
+        WHERE code = ?
+    `, code))
+    if err == sql.ErrNoRows {
+        return nil, nil
+    }
+
+    return category, err
+}
+
+func (r *sqliteTaxonomyRepository) SaveCategory(ctx context.Context, category *models.Category) error {
+    query := `
+        INSERT INTO incident_categories (code, parent_code, name, description, created_at)
+        VALUES (?, ?, ?, ?, ?)
+        ON CONFLICT (code) DO UPDATE SET name = excluded.name, description = excluded.description
+        RETURNING created_at
+    `
+
+    var createdAt int64
+    if err := r.db.QueryRowContext(ctx, query,
+        category.Code,
+        toNullString(category.Parent),
+        category.Name,
+        category.Description,
+        category.CreatedAt.UnixMicro(),
+    ).Scan(&createdAt); err != nil {
+        return err
+    }
+
+    category.CreatedAt = time.UnixMicro(createdAt)
+    return nil
+}
+
+func (r *sqliteTaxonomyRepository) DeleteCategory(ctx context.Context, code string) error {
+    tx, err := r.db.BeginTx(ctx, nil)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    var used bool
+    if err := tx.QueryRowContext(ctx, `
+        SELECT EXISTS (SELECT 1 FROM incident_categories WHERE parent_code = ?)
+            OR EXISTS (SELECT 1 FROM incidents WHERE category = ?)
+    `, code, code).Scan(&used); err != nil {
+        return err
+    }
+    if used {
+        return repositories.ErrInUse
+    }
+
+    if _, err := tx.ExecContext(ctx, `DELETE FROM incident_categories WHERE code = ?`, code); err != nil {
+        return err
+    }
+    return tx.Commit()
+}
+
+func (r *sqliteTaxonomyRepository) ListSeverityLevels(ctx context.Context) ([]*models.SeverityLevel, error) {
+    rows, err := r.db.QueryContext(ctx, `SELECT code, name, level, color FROM severity_levels ORDER BY level`)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var levels []*models.SeverityLevel
+    for rows.Next() {
+        var level models.SeverityLevel
+        if err := rows.Scan(&level.Code, &level.Name, &level.Level, &level.Color); err != nil {
+            return nil, err
+        }
+        levels = append(levels, &level)
+    }
+
+    return levels, rows.Err()
+}
+
+func (r *sqliteTaxonomyRepository) SaveSeverityLevel(ctx context.Context, level *models.SeverityLevel) error {
+    _, err := r.db.ExecContext(ctx, `
+        INSERT INTO severity_levels (code, name, level, color)
+        VALUES (?, ?, ?, ?)
+        ON CONFLICT (code) DO UPDATE SET name = excluded.name, level = excluded.level, color = excluded.color
+    `, level.Code, level.Name, level.Level, level.Color)
+    return err
+}
+
+func (r *sqliteTaxonomyRepository) DeleteSeverityLevel(ctx context.Context, code string) error {
+    tx, err := r.db.BeginTx(ctx, nil)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    var used bool
+    if err := tx.QueryRowContext(ctx, `
+        SELECT EXISTS (SELECT 1 FROM incidents WHERE severity = ?)
+            OR EXISTS (SELECT 1 FROM watch_zones WHERE min_severity = ?)
+            OR EXISTS (SELECT 1 FROM notification_preferences WHERE min_severity = ? OR quiet_hours_override = ?)
+            OR EXISTS (SELECT 1 FROM webhook_subscriptions WHERE min_severity = ?)
+    `, code, code, code, code, code).Scan(&used); err != nil {
+        return err
+    }
+    if used {
+        return repositories.ErrInUse
+    }
+
+    if _, err := tx.ExecContext(ctx, `DELETE FROM severity_levels WHERE code = ?`, code); err != nil {
+        return err
+    }
+    return tx.Commit()
+}
@@ -0,0 +1,227 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/domain/repositories"
+	"incident-system/pkg/geo"
+)
+
+const watchZoneColumns = `id, user_id, name, latitude, longitude, radius, polygon, min_severity, created_at, updated_at`
+
+type sqliteWatchZoneRepository struct {
+    db *sql.DB
+}
+
+func NewWatchZoneRepository(db *sql.DB) repositories.WatchZoneRepository {
+    return &sqliteWatchZoneRepository{db: db}
+}
+
+func scanWatchZone(row rowScanner) (*models.WatchZone, error) {
+    var zone models.WatchZone
+    var polygon sql.NullString
+    var createdAt, updatedAt int64
+
+    if err := row.Scan(
+        &zone.ID,
+        &zone.UserID,
+        &zone.Name,
+        &zone.Latitude,
+        &zone.Longitude,
+        &zone.Radius,
+        &polygon,
+        &zone.MinSeverity,
+        &createdAt,
+        &updatedAt,
+    ); err != nil {
+        return nil, err
+    }
+
+    zone.CreatedAt = time.UnixMicro(createdAt)
+    zone.UpdatedAt = time.UnixMicro(updatedAt)
+
+    if polygon.Valid && polygon.String != "" {
+        if err := json.Unmarshal([]byte(polygon.String), &zone.Polygon); err != nil {
+            return nil, err
+        }
+    }
+
+    return &zone, nil
+}
+
+func scanWatchZones(rows *sql.Rows) ([]*models.WatchZone, error) {
+    defer rows.Close()
+
+    var zones []*models.WatchZone
+    for rows.Next() {
+        zone, err := scanWatchZone(rows)
+        if err != nil {
+            return nil, err
+        }
+        zones = append(zones, zone)
+    }
+
+    return zones, rows.Err()
+}
+
+func (r *sqliteWatchZoneRepository) Create(ctx context.Context, zone *models.WatchZone) error {
+    query := `
+        INSERT INTO watch_zones (
+            user_id, name, latitude, longitude, radius, polygon, min_severity,
+            min_lat, min_lng, max_lat, max_lng, created_at, updated_at
+        ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+    `
+
+    polygon, err := encodePolygon(zone.Polygon)
+    if err != nil {
+        return err
+    }
+    minLat, minLng, maxLat, maxLng := geo.BoundingBox(zone.Latitude, zone.Longitude, zone.Radius/1000.0)
+
+    result, err := r.db.ExecContext(ctx, query,
+        zone.UserID,
+        zone.Name,
+        zone.Latitude,
+        zone.Longitude,
+        zone.Radius,
+        polygon,
+        zone.MinSeverity,
+        minLat, minLng, maxLat, maxLng,
+        zone.CreatedAt.UnixMicro(),
+        zone.UpdatedAt.UnixMicro(),
+    )
+    if err != nil {
+        return err
+    }
+
+    zone.ID, err = result.LastInsertId()
+    return err
+}
+
+func (r *sqliteWatchZoneRepository) FindByID(ctx context.Context, id int64) (*models.WatchZone, error) {
+    query := `SELECT ` + watchZoneColumns + ` FROM watch_zones WHERE id = ?`
+
+    zone, err := scanWatchZone(r.db.QueryRowContext(ctx, query, id))
+    if err == sql.ErrNoRows {
+        return nil, nil
+    }
+
+    return zone, err
+}
+
+func (r *sqliteWatchZoneRepository) FindByUser(ctx context.Context, userID string) ([]*models.WatchZone, error) {
+    query := `SELECT ` + watchZoneColumns + ` FROM watch_zones WHERE user_id = ? ORDER BY id`
+
+    rows, err := r.db.QueryContext(ctx, query, userID)
+    if err != nil {
+        return nil, err
+    }
+
+    return scanWatchZones(rows)
+}
+
+func (r *sqliteWatchZoneRepository) Update(ctx context.Context, zone *models.WatchZone) error {
+    query := `
+        UPDATE watch_zones
+        SET name = ?, latitude = ?, longitude = ?, radius = ?, polygon = ?, min_severity = ?,
+            min_lat = ?, min_lng = ?, max_lat = ?, max_lng = ?, updated_at = ?
+        WHERE id = ?
+    `
+
+    polygon, err := encodePolygon(zone.Polygon)
+    if err != nil {
+        return err
+    }
+    minLat, minLng, maxLat, maxLng := geo.BoundingBox(zone.Latitude, zone.Longitude, zone.Radius/1000.0)
+
+    _, err = r.db.ExecContext(ctx, query,
+        zone.Name,
+        zone.Latitude,
+        zone.Longitude,
+        zone.Radius,
+        polygon,
+        zone.MinSeverity,
+        minLat, minLng, maxLat, maxLng,
+        zone.UpdatedAt.UnixMicro(),
+        zone.ID,
+    )
+    return err
+}
+
+func (r *sqliteWatchZoneRepository) Delete(ctx context.Context, id int64) error {
+    _, err := r.db.ExecContext(ctx, `DELETE FROM watch_zones WHERE id = ?`, id)
+    return err
+}
+
+func (r *sqliteWatchZoneRepository) FindIntersecting(ctx context.Context, incident *models.Incident) ([]*models.WatchZone, error) {
+    // Кандидаты - зоны, описанный прямоугольник которых пересекается с прямоугольником инцидента
+    radiusKm := incident.Radius / 1000.0
+    minLat, _, maxLat, _ := geo.BoundingBox(incident.Latitude, incident.Longitude, radiusKm)
+    lngCondition, lngArgs := lngRangesCondition("min_lng", "max_lng", geo.LngRanges(incident.Latitude, incident.Longitude, radiusKm))
+    query := `
+        SELECT ` + watchZoneColumns + `
+        FROM watch_zones
+        WHERE max_lat >= ? AND min_lat <= ?
+          AND ` + lngCondition + `
+        ORDER BY id
+    `
+
+    rows, err := r.db.QueryContext(ctx, query, append([]interface{}{minLat, maxLat}, lngArgs...)...)
+    if err != nil {
+        return nil, err
+    }
+
+    candidates, err := scanWatchZones(rows)
+    if err != nil {
+        return nil, err
+    }
+
+    incidentZone := incident.Zone()
+    var zones []*models.WatchZone
+    for _, zone := range candidates {
+        if geo.ZonesIntersect(zone.Zone(), incidentZone) {
+            zones = append(zones, zone)
+        }
+    }
+
+    return zones, nil
+}
+
+func (r *sqliteWatchZoneRepository) MarkNotified(ctx context.Context, incidentID int64, zoneIDs []int64, at time.Time) ([]int64, error) {
+    // Зона могла быть удалена после отбора - такие пропускаются
+    query := `
+        INSERT INTO watch_zone_alerts (incident_id, zone_id, alerted_at)
+        SELECT ?, id, ? FROM watch_zones WHERE id = ?
+        ON CONFLICT (incident_id, zone_id) DO NOTHING
+    `
+
+    tx, err := r.db.BeginTx(ctx, nil)
+    if err != nil {
+        return nil, err
+    }
+    defer tx.Rollback()
+
+    var marked []int64
+    for _, id := range zoneIDs {
+        result, err := tx.ExecContext(ctx, query, incidentID, at.UnixMicro(), id)
+        if err != nil {
+            return nil, err
+        }
+        affected, err := result.RowsAffected()
+        if err != nil {
+            return nil, err
+        }
+        if affected > 0 {
+            marked = append(marked, id)
+        }
+    }
+
+    if err := tx.Commit(); err != nil {
+        return nil, err
+    }
+    return marked, nil
+}
@@ -0,0 +1,390 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/domain/repositories"
+)
+
+const subscriptionColumns = `id, url, description, event_types, categories, tags, min_severity, notify_url, format, active, created_at, updated_at,
+               failing_since, disabled_at, disabled_reason`
+
+type sqliteSubscriptionRepository struct {
+    db *sql.DB
+}
+
+func NewSubscriptionRepository(db *sql.DB) repositories.WebhookSubscriptionRepository {
+    return &sqliteSubscriptionRepository{db: db}
+}
+
+func scanSubscription(row rowScanner) (*models.WebhookSubscription, error) {
+    var subscription models.WebhookSubscription
+    var eventTypes, categories, tags string
+    var minSeverity sql.NullString
+    var createdAt, updatedAt int64
+    var failingSince, disabledAt sql.NullInt64
+
+    if err := row.Scan(
+        &subscription.ID,
+        &subscription.URL,
+        &subscription.Description,
+        &eventTypes,
+        &categories,
+        &tags,
+        &minSeverity,
+        &subscription.NotifyURL,
+        &subscription.Format,
+        &subscription.Active,
+        &createdAt,
+        &updatedAt,
+        &failingSince,
+        &disabledAt,
+        &subscription.DisabledReason,
+    ); err != nil {
+        return nil, err
+    }
+
+    subscription.CreatedAt = time.UnixMicro(createdAt)
+    subscription.UpdatedAt = time.UnixMicro(updatedAt)
+    subscription.FailingSince = fromNullMicro(failingSince)
+    subscription.DisabledAt = fromNullMicro(disabledAt)
+    subscription.MinSeverity = minSeverity.String
+    if err := json.Unmarshal([]byte(eventTypes), &subscription.EventTypes); err != nil {
+        return nil, err
+    }
+    if err := json.Unmarshal([]byte(categories), &subscription.Categories); err != nil {
+        return nil, err
+    }
+    if err := json.Unmarshal([]byte(tags), &subscription.Tags); err != nil {
+        return nil, err
+    }
+
+    return &subscription, nil
+}
+
+func fromNullMicro(value sql.NullInt64) *time.Time {
+    if !value.Valid {
+        return nil
+    }
+    t := time.UnixMicro(value.Int64)
+    return &t
+}
+
+// toNullString сохраняет пустую строку как NULL
+func toNullString(value string) sql.NullString {
+    return sql.NullString{String: value, Valid: value != ""}
+}
+
+// toNullInt сохраняет ноль как NULL
+func toNullInt(value int) sql.NullInt64 {
+    return sql.NullInt64{Int64: int64(value), Valid: value != 0}
+}
+
+func toNullMicro(t *time.Time) sql.NullInt64 {
+    if t == nil {
+        return sql.NullInt64{}
+    }
+    return sql.NullInt64{Int64: t.UnixMicro(), Valid: true}
+}
+
+// encodeStrings сохраняет список строк как JSON-массив; nil сохраняется как []
+func encodeStrings(values []string) (string, error) {
+    if values == nil {
+        values = []string{}
+    }
+    data, err := json.Marshal(values)
+    return string(data), err
+}
+
+func (r *sqliteSubscriptionRepository) findMany(ctx context.Context, query string, args ...interface{}) ([]*models.WebhookSubscription, error) {
+    rows, err := r.db.QueryContext(ctx, query, args...)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var subscriptions []*models.WebhookSubscription
+    for rows.Next() {
+        subscription, err := scanSubscription(rows)
+        if err != nil {
+            return nil, err
+        }
+        subscriptions = append(subscriptions, subscription)
+    }
+
+    return subscriptions, rows.Err()
+}
+
+func (r *sqliteSubscriptionRepository) Create(ctx context.Context, subscription *models.WebhookSubscription) error {
+    eventTypes, err := encodeStrings(subscription.EventTypes)
+    if err != nil {
+        return err
+    }
+    categories, err := encodeStrings(subscription.Categories)
+    if err != nil {
+        return err
+    }
+    tags, err := encodeStrings(subscription.Tags)
+    if err != nil {
+        return err
+    }
+
+    result, err := r.db.ExecContext(ctx, `
+        INSERT INTO webhook_subscriptions (
+            url, description, event_types, categories, tags, min_severity, notify_url, format, active, created_at, updated_at
+        ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+    `,
+        subscription.URL,
+        subscription.Description,
+        eventTypes,
+        categories,
+        tags,
+        toNullString(subscription.MinSeverity),
+        subscription.NotifyURL,
+        subscription.Format,
+        subscription.Active,
+        subscription.CreatedAt.UnixMicro(),
+        subscription.UpdatedAt.UnixMicro(),
+    )
+    if err != nil {
+        return err
+    }
+
+    subscription.ID, err = result.LastInsertId()
+    return err
+}
+
+func (r *sqliteSubscriptionRepository) FindByID(ctx context.Context, id int64) (*models.WebhookSubscription, error) {
+    query := `SELECT ` + subscriptionColumns + ` FROM webhook_subscriptions WHERE id = ?`
+
+    subscription, err := scanSubscription(r.db.QueryRowContext(ctx, query, id))
+    if err == sql.ErrNoRows {
+        return nil, nil
+    }
+    return subscription, err
+}
+
+func (r *sqliteSubscriptionRepository) FindAll(ctx context.Context) ([]*models.WebhookSubscription, error) {
+    return r.findMany(ctx, `SELECT `+subscriptionColumns+` FROM webhook_subscriptions ORDER BY id`)
+}
+
+func (r *sqliteSubscriptionRepository) FindActive(ctx context.Context) ([]*models.WebhookSubscription, error) {
+    return r.findMany(ctx, `SELECT `+subscriptionColumns+` FROM webhook_subscriptions WHERE active = 1 ORDER BY id`)
+}
+
+func (r *sqliteSubscriptionRepository) Update(ctx context.Context, subscription *models.WebhookSubscription) error {
+    eventTypes, err := encodeStrings(subscription.EventTypes)
+    if err != nil {
+        return err
+    }
+    categories, err := encodeStrings(subscription.Categories)
+    if err != nil {
+        return err
+    }
+    tags, err := encodeStrings(subscription.Tags)
+    if err != nil {
+        return err
+    }
+
+    _, err = r.db.ExecContext(ctx, `
+        UPDATE webhook_subscriptions
+        SET url = ?, description = ?, event_types = ?, categories = ?, tags = ?, min_severity = ?,
+            notify_url = ?, format = ?, active = ?, updated_at = ?,
+            failing_since = ?, disabled_at = ?, disabled_reason = ?
+        WHERE id = ?
+    `,
+        subscription.URL,
+        subscription.Description,
+        eventTypes,
+        categories,
+        tags,
+        toNullString(subscription.MinSeverity),
+        subscription.NotifyURL,
+        subscription.Format,
+        subscription.Active,
+        subscription.UpdatedAt.UnixMicro(),
+        toNullMicro(subscription.FailingSince),
+        toNullMicro(subscription.DisabledAt),
+        subscription.DisabledReason,
+        subscription.ID,
+    )
+    return err
+}
+
+func (r *sqliteSubscriptionRepository) Delete(ctx context.Context, id int64) error {
+    _, err := r.db.ExecContext(ctx, `DELETE FROM webhook_subscriptions WHERE id = ?`, id)
+    return err
+}
+
+func (r *sqliteSubscriptionRepository) MarkFailing(ctx context.Context, id int64, at time.Time) (time.Time, error) {
+    var failingSince sql.NullInt64
+    err := r.db.QueryRowContext(ctx, `
+        UPDATE webhook_subscriptions
+        SET failing_since = COALESCE(failing_since, ?)
+        WHERE id = ? AND active = 1
+        RETURNING failing_since
+    `, at.UnixMicro(), id).Scan(&failingSince)
+    if err == sql.ErrNoRows {
+        return at, nil
+    }
+    if err != nil {
+        return time.Time{}, err
+    }
+    return *fromNullMicro(failingSince), nil
+}
+
+func (r *sqliteSubscriptionRepository) MarkHealthy(ctx context.Context, id int64) error {
+    _, err := r.db.ExecContext(ctx,
+        `UPDATE webhook_subscriptions SET failing_since = NULL WHERE id = ? AND active = 1 AND failing_since IS NOT NULL`, id)
+    return err
+}
+
+func (r *sqliteSubscriptionRepository) Disable(ctx context.Context, id int64, reason string, at time.Time) (bool, error) {
+    result, err := r.db.ExecContext(ctx, `
+        UPDATE webhook_subscriptions
+        SET active = 0, disabled_at = ?, disabled_reason = ?, updated_at = ?
+        WHERE id = ? AND active = 1
+    `, at.UnixMicro(), reason, at.UnixMicro(), id)
+    if err != nil {
+        return false, err
+    }
+
+    affected, err := result.RowsAffected()
+    return affected > 0, err
+}
+
+const deliveryColumns = `id, delivery_id, subscription_id, event_type, event_id, destination,
+               attempt, request_hash, status_code, latency_ms, success, error, replay_of,
+               payload, created_at`
+
+type sqliteDeliveryRepository struct {
+    db *sql.DB
+}
+
+func NewDeliveryRepository(db *sql.DB) repositories.WebhookDeliveryRepository {
+    return &sqliteDeliveryRepository{db: db}
+}
+
+func (r *sqliteDeliveryRepository) RecordAttempt(ctx context.Context, delivery *models.WebhookDelivery) error {
+    payload, err := json.Marshal(delivery.Payload)
+    if err != nil {
+        return err
+    }
+
+    result, err := r.db.ExecContext(ctx, `
+        INSERT INTO webhook_deliveries (
+            delivery_id, subscription_id, event_type, event_id, destination,
+            attempt, request_hash, status_code, latency_ms, success, error, replay_of,
+            payload, created_at
+        )
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+    `,
+        delivery.DeliveryID,
+        delivery.SubscriptionID,
+        delivery.EventType,
+        delivery.EventID,
+        delivery.Destination,
+        delivery.Attempt,
+        delivery.RequestHash,
+        delivery.StatusCode,
+        delivery.LatencyMs,
+        delivery.Success,
+        delivery.Error,
+        delivery.ReplayOf,
+        string(payload),
+        delivery.CreatedAt.UnixMicro(),
+    )
+    if err != nil {
+        return err
+    }
+
+    delivery.ID, err = result.LastInsertId()
+    return err
+}
+
+func (r *sqliteDeliveryRepository) FindDeliveries(ctx context.Context, filter models.DeliveryFilter) ([]*models.WebhookDelivery, int, error) {
+    var conditions []string
+    var args []interface{}
+
+    if filter.SubscriptionID != nil {
+        conditions = append(conditions, "subscription_id = ?")
+        args = append(args, *filter.SubscriptionID)
+    }
+    if filter.DeliveryID != "" {
+        conditions = append(conditions, "delivery_id = ?")
+        args = append(args, filter.DeliveryID)
+    }
+    if filter.EventType != "" {
+        conditions = append(conditions, "event_type = ?")
+        args = append(args, filter.EventType)
+    }
+    if filter.Success != nil {
+        conditions = append(conditions, "success = ?")
+        args = append(args, *filter.Success)
+    }
+    if !filter.From.IsZero() {
+        conditions = append(conditions, "created_at >= ?")
+        args = append(args, filter.From.UnixMicro())
+    }
+    if !filter.To.IsZero() {
+        conditions = append(conditions, "created_at < ?")
+        args = append(args, filter.To.UnixMicro())
+    }
+
+    whereClause := ""
+    if len(conditions) > 0 {
+        whereClause = "WHERE " + strings.Join(conditions, " AND ")
+    }
+
+    var total int
+    if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM webhook_deliveries `+whereClause, args...).Scan(&total); err != nil {
+        return nil, 0, err
+    }
+
+    query := `SELECT ` + deliveryColumns + ` FROM webhook_deliveries ` + whereClause +
+        ` ORDER BY created_at DESC, id DESC LIMIT ? OFFSET ?`
+
+    rows, err := r.db.QueryContext(ctx, query, append(args, filter.Limit, filter.Offset)...)
+    if err != nil {
+        return nil, 0, err
+    }
+    defer rows.Close()
+
+    var deliveries []*models.WebhookDelivery
+    for rows.Next() {
+        var delivery models.WebhookDelivery
+        var payload string
+        var createdAt int64
+        if err := rows.Scan(
+            &delivery.ID,
+            &delivery.DeliveryID,
+            &delivery.SubscriptionID,
+            &delivery.EventType,
+            &delivery.EventID,
+            &delivery.Destination,
+            &delivery.Attempt,
+            &delivery.RequestHash,
+            &delivery.StatusCode,
+            &delivery.LatencyMs,
+            &delivery.Success,
+            &delivery.Error,
+            &delivery.ReplayOf,
+            &payload,
+            &createdAt,
+        ); err != nil {
+            return nil, 0, err
+        }
+        delivery.CreatedAt = time.UnixMicro(createdAt)
+        if err := json.Unmarshal([]byte(payload), &delivery.Payload); err != nil {
+            return nil, 0, err
+        }
+        deliveries = append(deliveries, &delivery)
+    }
+
+    return deliveries, total, rows.Err()
+}
@@ -0,0 +1,294 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"incident-system/internal/config"
+	"incident-system/internal/domain/repositories"
+	"incident-system/internal/infrastructure/blob"
+	"incident-system/internal/infrastructure/cache"
+	"incident-system/internal/infrastructure/db"
+	"incident-system/internal/infrastructure/memory"
+	"incident-system/internal/infrastructure/queue"
+	"incident-system/internal/infrastructure/resilience"
+	"incident-system/internal/infrastructure/sqlite"
+	"incident-system/pkg/circuitbreaker"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Storage объединяет репозитории выбранного бэкенда и связанные с ними подключения
+type Storage struct {
+    Backend   string
+    Incidents repositories.IncidentRepository
+    Cache     repositories.CacheRepository
+    Queue     repositories.QueueRepository
+    Outbox    repositories.OutboxRepository
+    Stream    repositories.EventStream
+
+    Subscriptions repositories.WebhookSubscriptionRepository
+    Deliveries    repositories.WebhookDeliveryRepository
+    WatchZones    repositories.WatchZoneRepository
+    Preferences   repositories.NotificationPreferencesRepository
+    Taxonomy      repositories.TaxonomyRepository
+    Reports       repositories.ReportRepository
+    Attachments   repositories.AttachmentRepository
+
+    // Blobs - содержимое вложений; выбирается ATTACHMENT_STORE независимо от бэкенда
+    Blobs repositories.BlobStore
+
+    // CacheMetrics - метрики локального снимка (nil, если локальный уровень отключен)
+    CacheMetrics repositories.CacheMetricsProvider
+    // Degradation - автоматы и журнал вебхуков вокруг Redis (nil без Redis)
+    Degradation *resilience.Monitor
+
+    // DB равен nil для хранилища в памяти, Redis - для sqlite и memory
+    DB    *sql.DB
+    Redis *redis.Client
+
+    closers []func() error
+}
+
+// New создает хранилище по cfg.StorageBackend: postgres, postgis, sqlite или memory
+func New(cfg *config.Config) (*Storage, error) {
+    var s *Storage
+    var err error
+    switch cfg.StorageBackend {
+    case "memory":
+        s = newMemoryStorage(cfg)
+    case "sqlite":
+        s, err = newSQLiteStorage(cfg)
+    case "postgres", "postgis", "":
+        s, err = newPostgresStorage(cfg)
+    default:
+        return nil, fmt.Errorf("unknown storage backend: %q", cfg.StorageBackend)
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    if s.Blobs, err = newBlobStore(cfg); err != nil {
+        s.Close()
+        return nil, err
+    }
+    return s, nil
+}
+
+// newBlobStore создает хранилище файлов по cfg.AttachmentStore: local или s3
+func newBlobStore(cfg *config.Config) (repositories.BlobStore, error) {
+    switch cfg.AttachmentStore {
+    case "local", "":
+        return blob.NewLocalStore(cfg.AttachmentDir)
+    case "s3":
+        return blob.NewS3Store(blob.S3Options{
+            Endpoint:  cfg.S3Endpoint,
+            Region:    cfg.S3Region,
+            Bucket:    cfg.S3Bucket,
+            AccessKey: cfg.S3AccessKey,
+            SecretKey: cfg.S3SecretKey,
+            PathStyle: cfg.S3PathStyle,
+            Timeout:   30 * time.Second,
+        })
+    default:
+        return nil, fmt.Errorf("unknown attachment store: %q", cfg.AttachmentStore)
+    }
+}
+
+const (
+    // eventStreamLimit - сколько последних событий хранит журнал в памяти
+    eventStreamLimit = 10000
+    // deliveryLogLimit - сколько последних попыток доставки хранит журнал в памяти
+    deliveryLogLimit = 10000
+)
+
+func newMemoryStorage(cfg *config.Config) *Storage {
+    subscriptions := memory.NewSubscriptionRepository()
+    watchZones := memory.NewWatchZoneRepository()
+    preferences := memory.NewNotificationPreferencesRepository()
+    reports := memory.NewReportRepository()
+    attachments := memory.NewAttachmentRepository()
+    incidents, outbox := memory.NewIncidentRepositoryWithOutbox(memory.IncidentReferrers{
+        WatchZones:  watchZones,
+        Reports:     reports,
+        Attachments: attachments,
+    })
+    taxonomy := memory.NewTaxonomyRepository(memory.TaxonomyReferrers{
+        Incidents:     incidents,
+        WatchZones:    watchZones,
+        Preferences:   preferences,
+        Subscriptions: subscriptions,
+    })
+
+    return &Storage{
+        Backend:   "memory",
+        Incidents: incidents,
+        Cache:     newMemoryCache(cfg),
+        Queue:     memory.NewQueueRepository(),
+        Outbox:    outbox,
+        Stream:    memory.NewEventStream(eventStreamLimit),
+
+        Subscriptions: subscriptions,
+        Deliveries:    memory.NewDeliveryRepository(deliveryLogLimit),
+        WatchZones:    watchZones,
+        Preferences:   preferences,
+        Taxonomy:      taxonomy,
+        Reports:       reports,
+        Attachments:   attachments,
+    }
+}
+
+// newSQLiteStorage - автономный режим для полевых серверов: инциденты в файле SQLite,
+// кеш и очередь вебхуков в памяти процесса
+func newSQLiteStorage(cfg *config.Config) (*Storage, error) {
+    sqliteDB, err := sqlite.NewSQLiteDB(cfg.SQLitePath)
+    if err != nil {
+        return nil, err
+    }
+
+    return &Storage{
+        Backend:   "sqlite",
+        Incidents: sqlite.NewIncidentRepository(sqliteDB.GetDB()),
+        Cache:     newMemoryCache(cfg),
+        Queue:     memory.NewQueueRepository(),
+        Outbox:    sqlite.NewOutboxRepository(sqliteDB.GetDB()),
+        Stream:    memory.NewEventStream(eventStreamLimit),
+
+        Subscriptions: sqlite.NewSubscriptionRepository(sqliteDB.GetDB()),
+        Deliveries:    sqlite.NewDeliveryRepository(sqliteDB.GetDB()),
+        WatchZones:    sqlite.NewWatchZoneRepository(sqliteDB.GetDB()),
+        Preferences:   sqlite.NewNotificationPreferencesRepository(sqliteDB.GetDB()),
+        Taxonomy:      sqlite.NewTaxonomyRepository(sqliteDB.GetDB()),
+        Reports:       sqlite.NewReportRepository(sqliteDB.GetDB()),
+        Attachments:   sqlite.NewAttachmentRepository(sqliteDB.GetDB()),
+
+        DB:        sqliteDB.GetDB(),
+        closers:   []func() error{sqliteDB.Close},
+    }, nil
+}
+
+func newPostgresStorage(cfg *config.Config) (*Storage, error) {
+    postgresDB, err := db.NewPostgresDB(cfg)
+    if err != nil {
+        return nil, err
+    }
+
+    s := &Storage{
+        Backend: cfg.StorageBackend,
+        DB:      postgresDB.GetDB(),
+    }
+    s.closers = append(s.closers, postgresDB.Close)
+    s.Outbox = db.NewPostgresOutboxRepository(s.DB)
+    s.Subscriptions = db.NewPostgresSubscriptionRepository(s.DB)
+    s.Deliveries = db.NewPostgresDeliveryRepository(s.DB)
+    s.Preferences = db.NewPostgresNotificationPreferencesRepository(s.DB)
+    s.Taxonomy = db.NewPostgresTaxonomyRepository(s.DB)
+    s.Reports = db.NewPostgresReportRepository(s.DB)
+    s.Attachments = db.NewPostgresAttachmentRepository(s.DB)
+
+    if cfg.StorageBackend == "postgis" {
+        // Колонки PostGIS не входят в общие миграции: без расширения работает бэкенд postgres
+        ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+        err := db.EnsurePostGISSchema(ctx, s.DB)
+        cancel()
+        if err != nil {
+            s.Close()
+            return nil, err
+        }
+        s.Incidents = db.NewPostGISIncidentRepository(s.DB)
+        s.WatchZones = db.NewPostGISWatchZoneRepository(s.DB)
+    } else {
+        s.Backend = "postgres"
+        s.Incidents = db.NewPostgresIncidentRepository(s.DB)
+        s.WatchZones = db.NewPostgresWatchZoneRepository(s.DB)
+    }
+
+    s.Redis = redis.NewClient(&redis.Options{
+        Addr:     fmt.Sprintf("%s:%s", cfg.RedisHost, cfg.RedisPort),
+        Password: cfg.RedisPassword,
+        DB:       cfg.RedisDB,
+    })
+    s.closers = append(s.closers, s.Redis.Close)
+
+    // Без Redis сервис стартует в режиме деградации: автоматы разомкнутся на первых
+    // запросах, чтения пойдут в базу, а вебхуки - в журнал на диске
+    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    defer cancel()
+    if err := s.Redis.Ping(ctx).Err(); err != nil {
+        log.Printf("Redis is unavailable, starting in degraded mode: %v", err)
+    }
+
+    s.Stream = queue.NewRedisEventStream(s.Redis)
+
+    redisCache := cache.NewRedisCacheRepository(
+        s.Redis,
+        time.Duration(cfg.CacheTTLMinutes)*time.Minute,
+        time.Duration(cfg.CacheStaleTTLMinutes)*time.Minute,
+    )
+
+    if err := s.wrapRedisWithBreakers(cfg, redisCache, queue.NewRedisQueueRepository(s.Redis)); err != nil {
+        s.Close()
+        return nil, err
+    }
+
+    return s, nil
+}
+
+// wrapRedisWithBreakers защищает кеш и очередь автоматами и подключает журнал
+// вебхуков, который возвращает их в очередь после восстановления Redis.
+// Локальный уровень кеша лежит поверх автомата, поэтому при разомкнутом автомате
+// отдается последний снимок из памяти процесса.
+func (s *Storage) wrapRedisWithBreakers(cfg *config.Config, redisCache repositories.CacheRepository, redisQueue repositories.QueueRepository) error {
+    spool, err := resilience.NewFileSpool(cfg.WebhookSpoolPath)
+    if err != nil {
+        return err
+    }
+
+    cacheBreaker := circuitbreaker.New("redis_cache", cfg.RedisBreakerFailures, cfg.RedisBreakerOpenTimeout)
+    queueBreaker := circuitbreaker.New("redis_queue", cfg.RedisBreakerFailures, cfg.RedisBreakerOpenTimeout)
+    for _, breaker := range []*circuitbreaker.Breaker{cacheBreaker, queueBreaker} {
+        breaker.OnStateChange(func(name string, from, to circuitbreaker.State) {
+            log.Printf("Circuit breaker %s: %s -> %s", name, from, to)
+        })
+    }
+
+    s.Cache = resilience.NewCacheRepository(redisCache, cacheBreaker)
+
+    // Первый уровень кеша: снимок в памяти процесса, сбрасываемый через pub/sub
+    if cfg.LocalCacheTTL > 0 {
+        ttl := time.Duration(cfg.CacheTTLMinutes) * time.Minute
+        local := cache.NewLocalSnapshotCache(s.Cache, s.Redis, cacheBreaker, cfg.LocalCacheTTL, ttl)
+        s.Cache = local
+        s.CacheMetrics = local
+        s.closers = append(s.closers, local.Close)
+    }
+
+    resilientQueue := resilience.NewQueueRepository(redisQueue, queueBreaker, spool, cfg.WebhookSpoolDrainInterval)
+    s.Queue = resilientQueue
+    s.closers = append(s.closers, resilientQueue.Close)
+
+    s.Degradation = resilience.NewMonitor(spool, cacheBreaker, queueBreaker)
+    return nil
+}
+
+// Close закрывает подключения в обратном порядке
+func (s *Storage) Close() error {
+    var firstErr error
+    for i := len(s.closers) - 1; i >= 0; i-- {
+        if err := s.closers[i](); err != nil && firstErr == nil {
+            firstErr = err
+        }
+    }
+    s.closers = nil
+    return firstErr
+}
+
+func newMemoryCache(cfg *config.Config) repositories.CacheRepository {
+    return memory.NewCacheRepository(
+        time.Duration(cfg.CacheTTLMinutes)*time.Minute,
+        time.Duration(cfg.CacheStaleTTLMinutes)*time.Minute,
+    )
+}
@@ -3,9 +3,10 @@ package webhook
 import (
 	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"strconv"
 	"time"
 
 	"incident-system/internal/config"
@@ -13,12 +14,11 @@ import (
 	"incident-system/pkg/logger"
 )
 
+// WebhookClient выполняет одну попытку доставки. Повторы, ограничения частоты
+// и параллельности по получателям - забота services.WebhookDispatcher.
 type WebhookClient struct {
-    client      *http.Client
-    url         string
-    maxRetries  int
-    retryDelay  time.Duration
-    logger      *logger.Logger
+    client *http.Client
+    logger *logger.Logger
 }
 
 func NewWebhookClient(cfg *config.Config, logger *logger.Logger) *WebhookClient {
@@ -26,75 +26,56 @@ func NewWebhookClient(cfg *config.Config, logger *logger.Logger) *WebhookClient
         client: &http.Client{
             Timeout: cfg.WebhookTimeout,
         },
-        url:        cfg.WebhookURL,
-        maxRetries: cfg.WebhookMaxRetries,
-        retryDelay: cfg.WebhookRetryDelay,
-        logger:     logger,
+        logger: logger,
     }
 }
 
-func (w *WebhookClient) Send(payload models.WebhookPayload) error {
-    data, err := json.Marshal(payload)
+// Send отправляет тело на url. Ошибка возвращается только при сбое транспорта;
+// код ответа оценивает вызывающий.
+func (w *WebhookClient) Send(ctx context.Context, url string, body []byte, headers map[string]string) (*models.WebhookResponse, error) {
+    req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
     if err != nil {
-        return fmt.Errorf("failed to marshal payload: %w", err)
+        return nil, fmt.Errorf("failed to create request: %w", err)
     }
-    
-    var lastErr error
-    for attempt := 1; attempt <= w.maxRetries; attempt++ {
-        w.logger.Info("Sending webhook attempt %d/%d", attempt, w.maxRetries) // Изменено с Infof на Info
-        
-        req, err := http.NewRequest("POST", w.url, bytes.NewBuffer(data))
-        if err != nil {
-            lastErr = err
-            continue
-        }
-        
-        req.Header.Set("Content-Type", "application/json")
-        
-        resp, err := w.client.Do(req)
-        if err != nil {
-            lastErr = err
-            w.logger.Error("Webhook attempt %d failed: %v", attempt, err) // Изменено с Errorf на Error
-            time.Sleep(w.retryDelay * time.Duration(attempt))
-            continue
-        }
-        
-        defer resp.Body.Close()
-        
-        if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-            w.logger.Info("Webhook sent successfully") // Изменено с Infof на Info
-            return nil
-        }
-        
-        lastErr = fmt.Errorf("unexpected status code: %d", resp.StatusCode)
-        w.logger.Error("Webhook attempt %d failed with status: %d", attempt, resp.StatusCode) // Изменено с Errorf на Error
-        time.Sleep(w.retryDelay * time.Duration(attempt))
+
+    req.Header.Set("Content-Type", "application/json")
+    for name, value := range headers {
+        req.Header.Set(name, value)
     }
-    
-    return fmt.Errorf("failed to send webhook after %d attempts: %w", w.maxRetries, lastErr)
+
+    started := time.Now()
+    resp, err := w.client.Do(req)
+    if err != nil {
+        return nil, err
+    }
+    defer resp.Body.Close()
+
+    // Дочитываем тело, чтобы соединение вернулось в пул
+    io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+    return &models.WebhookResponse{
+        StatusCode: resp.StatusCode,
+        RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+        Latency:    time.Since(started),
+    }, nil
 }
 
-func (w *WebhookClient) StartWorker(ctx context.Context, dequeueFunc func(context.Context) (*models.WebhookPayload, error)) {
-    go func() {
-        for {
-            select {
-            case <-ctx.Done():
-                w.logger.Info("Webhook worker stopped")
-                return
-            default:
-                payload, err := dequeueFunc(ctx)
-                if err != nil {
-                    w.logger.Error("Failed to dequeue webhook: %v", err) // Изменено с Errorf на Error
-                    time.Sleep(time.Second)
-                    continue
-                }
-                
-                if payload != nil {
-                    if err := w.Send(*payload); err != nil {
-                        w.logger.Error("Failed to send webhook: %v", err) // Изменено с Errorf на Error
-                    }
-                }
-            }
+// parseRetryAfter разбирает Retry-After в секундах или в виде HTTP-даты
+func parseRetryAfter(value string, now time.Time) time.Duration {
+    if value == "" {
+        return 0
+    }
+
+    if seconds, err := strconv.Atoi(value); err == nil {
+        if seconds < 0 {
+            return 0
         }
-    }()
-}
\ No newline at end of file
+        return time.Duration(seconds) * time.Second
+    }
+
+    if at, err := http.ParseTime(value); err == nil && at.After(now) {
+        return at.Sub(now)
+    }
+
+    return 0
+}
@@ -0,0 +1,154 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/domain/repositories"
+
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+    // snapshotKey - ключ singleflight для загрузки активных инцидентов
+    snapshotKey = "active_incidents"
+    // refreshLockTTL ограничивает время, на которое один экземпляр забирает обновление кеша
+    refreshLockTTL = 10 * time.Second
+    // refreshWaitTimeout - сколько ждать снимок, загружаемый другим экземпляром
+    refreshWaitTimeout = 2 * time.Second
+    refreshPollInterval = 50 * time.Millisecond
+)
+
+// activeIncidentsLoader защищает Postgres от лавины запросов при промахе кеша:
+//   - в пределах процесса загрузку выполняет одна горутина (singleflight);
+//   - между экземплярами - владелец блокировки в Redis, остальные ждут его снимок;
+//   - пока идет обновление, устаревший снимок отдается без ожидания;
+//   - снимок сохраняется с версией, прочитанной до запроса к базе, поэтому
+//     более старые данные не перезапишут более новые.
+type activeIncidentsLoader struct {
+    incidentRepo repositories.IncidentRepository
+    cacheRepo    repositories.CacheRepository
+    group        singleflight.Group
+    refreshing   atomic.Bool
+}
+
+func newActiveIncidentsLoader(
+    incidentRepo repositories.IncidentRepository,
+    cacheRepo repositories.CacheRepository,
+) *activeIncidentsLoader {
+    return &activeIncidentsLoader{
+        incidentRepo: incidentRepo,
+        cacheRepo:    cacheRepo,
+    }
+}
+
+// Load возвращает активные инциденты из кеша, при необходимости обновляя его
+func (l *activeIncidentsLoader) Load(ctx context.Context) ([]*models.Incident, error) {
+    snapshot, err := l.LoadSnapshot(ctx)
+    if err != nil {
+        return nil, err
+    }
+    return snapshot.Incidents, nil
+}
+
+// LoadSnapshot возвращает снимок активных инцидентов вместе с моментом его загрузки из базы
+func (l *activeIncidentsLoader) LoadSnapshot(ctx context.Context) (*models.ActiveIncidentsSnapshot, error) {
+    snapshot, err := l.cacheRepo.GetActiveIncidents(ctx)
+    if err != nil {
+        return nil, fmt.Errorf("failed to get cached incidents: %w", err)
+    }
+
+    if snapshot != nil && !snapshot.Stale {
+        return snapshot, nil
+    }
+
+    // Устаревший снимок отдаем сразу, если его уже обновляет другая горутина
+    if snapshot != nil && l.refreshing.Load() {
+        return snapshot, nil
+    }
+
+    // Загрузка не должна прерываться отменой запроса, который ее начал:
+    // результат ждут и другие вызывающие
+    loadCtx := context.WithoutCancel(ctx)
+    result, err, _ := l.group.Do(snapshotKey, func() (interface{}, error) {
+        l.refreshing.Store(true)
+        defer l.refreshing.Store(false)
+        return l.refresh(loadCtx, snapshot)
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return result.(*models.ActiveIncidentsSnapshot), nil
+}
+
+func (l *activeIncidentsLoader) refresh(ctx context.Context, stale *models.ActiveIncidentsSnapshot) (*models.ActiveIncidentsSnapshot, error) {
+    token, acquired, err := l.cacheRepo.AcquireRefreshLock(ctx, refreshLockTTL)
+    if err != nil {
+        return nil, fmt.Errorf("failed to acquire refresh lock: %w", err)
+    }
+
+    if !acquired {
+        // Обновлением занимается другой экземпляр
+        if stale != nil {
+            return stale, nil
+        }
+        if snapshot, ok := l.waitForSnapshot(ctx); ok {
+            return snapshot, nil
+        }
+        // Не дождались - загружаем сами, но без блокировки
+        return l.loadFromDB(ctx)
+    }
+    defer l.cacheRepo.ReleaseRefreshLock(ctx, token)
+
+    return l.loadFromDB(ctx)
+}
+
+func (l *activeIncidentsLoader) loadFromDB(ctx context.Context) (*models.ActiveIncidentsSnapshot, error) {
+    // Версию читаем до запроса: изменения после нее сделают снимок устаревшим
+    version, err := l.cacheRepo.ActiveIncidentsVersion(ctx)
+    if err != nil {
+        return nil, fmt.Errorf("failed to get cache version: %w", err)
+    }
+
+    incidents, err := l.incidentRepo.GetActiveIncidents(ctx)
+    if err != nil {
+        return nil, fmt.Errorf("failed to get active incidents: %w", err)
+    }
+
+    snapshot := &models.ActiveIncidentsSnapshot{
+        Incidents: incidents,
+        Version:   version,
+        LoadedAt:  time.Now(),
+    }
+    if _, err := l.cacheRepo.SetActiveIncidents(ctx, snapshot); err != nil {
+        // Логируем ошибку, но продолжаем работу
+        fmt.Printf("Failed to cache incidents: %v\n", err)
+    }
+
+    return snapshot, nil
+}
+
+// waitForSnapshot ждет свежий снимок, загружаемый другим экземпляром
+func (l *activeIncidentsLoader) waitForSnapshot(ctx context.Context) (*models.ActiveIncidentsSnapshot, bool) {
+    deadline := time.Now().Add(refreshWaitTimeout)
+    for time.Now().Before(deadline) {
+        select {
+        case <-ctx.Done():
+            return nil, false
+        case <-time.After(refreshPollInterval):
+        }
+
+        snapshot, err := l.cacheRepo.GetActiveIncidents(ctx)
+        if err != nil {
+            return nil, false
+        }
+        if snapshot != nil && !snapshot.Stale {
+            return snapshot, true
+        }
+    }
+    return nil, false
+}
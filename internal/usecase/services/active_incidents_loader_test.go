@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/domain/repositories"
+	"incident-system/internal/infrastructure/memory"
+)
+
+// blockingRepository считает загрузки активных инцидентов и держит их до release
+type blockingRepository struct {
+    repositories.IncidentRepository
+    loads   atomic.Int32
+    started chan struct{}
+    release chan struct{}
+}
+
+func (r *blockingRepository) GetActiveIncidents(ctx context.Context) ([]*models.Incident, error) {
+    r.loads.Add(1)
+    r.started <- struct{}{}
+    <-r.release
+    return r.IncidentRepository.GetActiveIncidents(ctx)
+}
+
+func newLoaderFixture(t *testing.T) (*activeIncidentsLoader, *blockingRepository, repositories.CacheRepository) {
+    t.Helper()
+    repo := &blockingRepository{
+        IncidentRepository: memory.NewIncidentRepository(),
+        started:            make(chan struct{}, 100),
+        release:            make(chan struct{}),
+    }
+    createActive(t, repo)
+    cache := memory.NewCacheRepository(time.Minute, time.Minute)
+    return newActiveIncidentsLoader(repo, cache), repo, cache
+}
+
+func createActive(t *testing.T, repo repositories.IncidentRepository) {
+    t.Helper()
+    incident := &models.Incident{UserID: "operator", Title: "Пожар", Severity: "high", Radius: 1000, Active: true}
+    if err := repo.Create(context.Background(), incident); err != nil {
+        t.Fatalf("Create: %v", err)
+    }
+}
+
+// loadConcurrently вызывает Load из n горутин и возвращает число полученных инцидентов
+func loadConcurrently(t *testing.T, loader *activeIncidentsLoader, n int) <-chan int {
+    t.Helper()
+    results := make(chan int, n)
+    var wg sync.WaitGroup
+    for i := 0; i < n; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            incidents, err := loader.Load(context.Background())
+            if err != nil {
+                t.Errorf("Load: %v", err)
+            }
+            results <- len(incidents)
+        }()
+    }
+    go func() {
+        wg.Wait()
+        close(results)
+    }()
+    return results
+}
+
+func TestActiveIncidentsLoaderSharesColdLoad(t *testing.T) {
+    loader, repo, _ := newLoaderFixture(t)
+
+    results := loadConcurrently(t, loader, 20)
+    <-repo.started
+    time.Sleep(20 * time.Millisecond)
+    close(repo.release)
+
+    for count := range results {
+        if count != 1 {
+            t.Fatalf("Load returned %d incidents, want 1", count)
+        }
+    }
+    if loads := repo.loads.Load(); loads != 1 {
+        t.Fatalf("GetActiveIncidents called %d times, want one shared load", loads)
+    }
+}
+
+func TestActiveIncidentsLoaderServesStaleSnapshotDuringRefresh(t *testing.T) {
+    ctx := context.Background()
+    loader, repo, cache := newLoaderFixture(t)
+
+    warm := loadConcurrently(t, loader, 1)
+    <-repo.started
+    repo.release <- struct{}{}
+    <-warm
+
+    createActive(t, repo)
+    if err := cache.InvalidateActiveIncidents(ctx); err != nil {
+        t.Fatalf("InvalidateActiveIncidents: %v", err)
+    }
+
+    // Первая загрузка после устаревания обновляет снимок, остальные не ждут ее
+    refresh := loadConcurrently(t, loader, 1)
+    <-repo.started
+    for count := range loadConcurrently(t, loader, 20) {
+        if count != 1 {
+            t.Fatalf("Load during refresh returned %d incidents, want the stale snapshot", count)
+        }
+    }
+    close(repo.release)
+    if count := <-refresh; count != 2 {
+        t.Fatalf("refreshing Load returned %d incidents, want 2", count)
+    }
+
+    incidents, err := loader.Load(ctx)
+    if err != nil || len(incidents) != 2 {
+        t.Fatalf("Load after refresh = %d incidents, %v", len(incidents), err)
+    }
+    if loads := repo.loads.Load(); loads != 2 {
+        t.Fatalf("GetActiveIncidents called %d times, want the warm-up and one refresh", loads)
+    }
+}
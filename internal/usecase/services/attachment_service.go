@@ -0,0 +1,397 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif" // декодеры форматов для миниатюр
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/domain/repositories"
+	apperrors "incident-system/pkg/errors"
+	"incident-system/pkg/logger"
+)
+
+// AttachmentPath - путь, по которому HTTP API отдает файлы вложений по подписанным ссылкам
+const AttachmentPath = "/api/v1/attachments/"
+
+const (
+    maxAttachmentFileName = 255
+    // maxThumbnailPixels - изображения больше не декодируются: миниатюра такого
+    // файла заняла бы сотни мегабайт памяти
+    maxThumbnailPixels = 50_000_000
+    thumbnailQuality   = 80
+)
+
+// AttachmentOptions - ограничения на вложения и параметры ссылок на скачивание
+type AttachmentOptions struct {
+    MaxSize       int64         // наибольший размер файла в байтах
+    PerIncident   int           // вложений у одного инцидента; 0 - без ограничения
+    ThumbnailSize int           // наибольшая сторона миниатюры в пикселях; 0 - без миниатюр
+    URLSecret     []byte        // ключ подписи ссылок
+    URLTTL        time.Duration // срок действия ссылки
+    BaseURL       string        // внешний адрес сервиса; пустой - ссылки от корня сайта
+}
+
+// AttachmentService принимает файлы к инцидентам: определяет тип по содержимому,
+// проверяет размер, строит миниатюры изображений и выдает подписанные ссылки
+// на скачивание, по которым файл доступен без API-ключа до истечения срока.
+type AttachmentService struct {
+    attachmentRepo repositories.AttachmentRepository
+    incidentRepo   repositories.IncidentRepository
+    blobs          repositories.BlobStore
+    options        AttachmentOptions
+    logger         *logger.Logger
+}
+
+func NewAttachmentService(
+    attachmentRepo repositories.AttachmentRepository,
+    incidentRepo repositories.IncidentRepository,
+    blobs repositories.BlobStore,
+    options AttachmentOptions,
+    logger *logger.Logger,
+) *AttachmentService {
+    if len(options.URLSecret) == 0 {
+        // Без заданного ключа ссылки действуют только до перезапуска и только на этом экземпляре
+        options.URLSecret = make([]byte, 32)
+        rand.Read(options.URLSecret)
+        logger.Warn("ATTACHMENT_URL_SECRET is not set: download links will not survive a restart")
+    }
+
+    return &AttachmentService{
+        attachmentRepo: attachmentRepo,
+        incidentRepo:   incidentRepo,
+        blobs:          blobs,
+        options:        options,
+        logger:         logger,
+    }
+}
+
+// MaxSize - наибольший размер файла вложения в байтах
+func (s *AttachmentService) MaxSize() int64 {
+    return s.options.MaxSize
+}
+
+// Upload сохраняет файл content как вложение инцидента. Файл читается в память
+// целиком (не больше MaxSize): по нему считается контрольная сумма и строится миниатюра.
+func (s *AttachmentService) Upload(ctx context.Context, incidentID int64, fileName, uploadedBy string, content io.Reader) (*models.Attachment, error) {
+    if _, err := s.writableIncident(ctx, incidentID); err != nil {
+        return nil, err
+    }
+    if s.options.PerIncident > 0 {
+        count, err := s.attachmentRepo.CountByIncident(ctx, incidentID)
+        if err != nil {
+            return nil, fmt.Errorf("failed to count attachments: %w", err)
+        }
+        if count >= s.options.PerIncident {
+            return nil, apperrors.NewValidationError(fmt.Errorf("at most %d attachments per incident", s.options.PerIncident))
+        }
+    }
+
+    data, err := io.ReadAll(io.LimitReader(content, s.options.MaxSize+1))
+    if err != nil {
+        return nil, fmt.Errorf("failed to read attachment: %w", err)
+    }
+    if int64(len(data)) > s.options.MaxSize {
+        return nil, apperrors.NewPayloadTooLargeError(fmt.Sprintf("attachment must be at most %d bytes", s.options.MaxSize))
+    }
+    if len(data) == 0 {
+        return nil, apperrors.NewValidationError(fmt.Errorf("attachment is empty"))
+    }
+
+    contentType, _, _ := mime.ParseMediaType(http.DetectContentType(data))
+    if !models.AllowedAttachmentType(contentType) {
+        return nil, apperrors.NewUnsupportedMediaTypeError(fmt.Sprintf(
+            "unsupported attachment type %s; allowed: %s", contentType, strings.Join(models.AttachmentContentTypes, ", ")))
+    }
+
+    checksum := sha256.Sum256(data)
+    attachment := &models.Attachment{
+        IncidentID:  incidentID,
+        FileName:    models.AttachmentFileName(fileName, "attachment", maxAttachmentFileName),
+        ContentType: contentType,
+        Size:        int64(len(data)),
+        Checksum:    hex.EncodeToString(checksum[:]),
+        StorageKey:  "attachments/" + randomKey(),
+        UploadedBy:  strings.TrimSpace(uploadedBy),
+        CreatedAt:   time.Now(),
+    }
+
+    if err := s.blobs.Put(ctx, attachment.StorageKey, bytes.NewReader(data), attachment.Size, contentType); err != nil {
+        return nil, fmt.Errorf("failed to store attachment: %w", err)
+    }
+    if attachment.IsImage() {
+        s.storeThumbnail(ctx, attachment, data)
+    }
+
+    if err := s.attachmentRepo.Create(ctx, attachment); err != nil {
+        s.deleteBlobs(ctx, attachment)
+        return nil, fmt.Errorf("failed to create attachment: %w", err)
+    }
+
+    s.sign(attachment, time.Now())
+    return attachment, nil
+}
+
+// storeThumbnail сохраняет размеры изображения и его миниатюру. Без миниатюры
+// вложение остается полноценным, поэтому сбой только записывается в журнал.
+func (s *AttachmentService) storeThumbnail(ctx context.Context, attachment *models.Attachment, data []byte) {
+    config, _, err := image.DecodeConfig(bytes.NewReader(data))
+    if err != nil {
+        // Формат без декодера в стандартной библиотеке (WebP) - без размеров и миниатюры
+        return
+    }
+    attachment.Width, attachment.Height = config.Width, config.Height
+    if s.options.ThumbnailSize <= 0 || config.Width*config.Height > maxThumbnailPixels {
+        return
+    }
+
+    thumbnail, err := makeThumbnail(data, s.options.ThumbnailSize)
+    if err != nil {
+        s.logger.Warn("Failed to make thumbnail for %s: %v", attachment.StorageKey, err)
+        return
+    }
+
+    key := attachment.StorageKey + ".thumb.jpg"
+    if err := s.blobs.Put(ctx, key, bytes.NewReader(thumbnail), int64(len(thumbnail)), "image/jpeg"); err != nil {
+        s.logger.Warn("Failed to store thumbnail for %s: %v", attachment.StorageKey, err)
+        return
+    }
+    attachment.ThumbnailKey = key
+}
+
+// writableIncident возвращает инцидент, к которому можно прикреплять файлы
+func (s *AttachmentService) writableIncident(ctx context.Context, id int64) (*models.Incident, error) {
+    incident, err := s.incidentRepo.FindByID(ctx, id)
+    if err != nil {
+        return nil, fmt.Errorf("failed to find incident: %w", err)
+    }
+    if incident == nil {
+        return nil, apperrors.NewNotFoundError("Incident")
+    }
+    if incident.MergedInto != nil {
+        return nil, apperrors.NewConflictError(fmt.Sprintf("incident %d has been merged into %d", id, *incident.MergedInto))
+    }
+    return incident, nil
+}
+
+// ListAttachments возвращает вложения инцидента со ссылками на скачивание
+func (s *AttachmentService) ListAttachments(ctx context.Context, incidentID int64) ([]*models.Attachment, error) {
+    incident, err := s.incidentRepo.FindByID(ctx, incidentID)
+    if err != nil {
+        return nil, fmt.Errorf("failed to find incident: %w", err)
+    }
+    if incident == nil {
+        return nil, apperrors.NewNotFoundError("Incident")
+    }
+
+    attachments, err := s.attachmentRepo.FindByIncident(ctx, incidentID)
+    if err != nil {
+        return nil, fmt.Errorf("failed to find attachments: %w", err)
+    }
+
+    now := time.Now()
+    for _, attachment := range attachments {
+        s.sign(attachment, now)
+    }
+    return attachments, nil
+}
+
+// GetAttachment возвращает вложение инцидента со свежими ссылками на скачивание
+func (s *AttachmentService) GetAttachment(ctx context.Context, incidentID, id int64) (*models.Attachment, error) {
+    attachment, err := s.incidentAttachment(ctx, incidentID, id)
+    if err != nil {
+        return nil, err
+    }
+    s.sign(attachment, time.Now())
+    return attachment, nil
+}
+
+func (s *AttachmentService) incidentAttachment(ctx context.Context, incidentID, id int64) (*models.Attachment, error) {
+    attachment, err := s.attachmentRepo.FindByID(ctx, id)
+    if err != nil {
+        return nil, fmt.Errorf("failed to find attachment: %w", err)
+    }
+    if attachment == nil || attachment.IncidentID != incidentID {
+        return nil, apperrors.NewNotFoundError("Attachment")
+    }
+    return attachment, nil
+}
+
+// DeleteAttachment удаляет вложение; файлы удаляются после описания, и сбой
+// их удаления только записывается в журнал
+func (s *AttachmentService) DeleteAttachment(ctx context.Context, incidentID, id int64) error {
+    attachment, err := s.incidentAttachment(ctx, incidentID, id)
+    if err != nil {
+        return err
+    }
+
+    deleted, err := s.attachmentRepo.Delete(ctx, id)
+    if err != nil {
+        return fmt.Errorf("failed to delete attachment: %w", err)
+    }
+    if !deleted {
+        return apperrors.NewNotFoundError("Attachment")
+    }
+
+    s.deleteBlobs(ctx, attachment)
+    return nil
+}
+
+func (s *AttachmentService) deleteBlobs(ctx context.Context, attachment *models.Attachment) {
+    for _, key := range []string{attachment.StorageKey, attachment.ThumbnailKey} {
+        if key == "" {
+            continue
+        }
+        if err := s.blobs.Delete(ctx, key); err != nil {
+            s.logger.Error("Failed to delete blob %s: %v", key, err)
+        }
+    }
+}
+
+// OpenAttachment проверяет подписанную ссылку и открывает файл варианта variant.
+// Просроченная или поддельная ссылка - ошибка 403.
+func (s *AttachmentService) OpenAttachment(ctx context.Context, id int64, variant string, expires int64, signature string) (*models.Attachment, io.ReadCloser, error) {
+    if variant == "" {
+        variant = models.AttachmentOriginal
+    }
+    if time.Now().Unix() > expires || !hmac.Equal([]byte(signature), []byte(s.signature(id, variant, expires))) {
+        return nil, nil, apperrors.NewForbiddenError()
+    }
+
+    attachment, err := s.attachmentRepo.FindByID(ctx, id)
+    if err != nil {
+        return nil, nil, fmt.Errorf("failed to find attachment: %w", err)
+    }
+    if attachment == nil {
+        return nil, nil, apperrors.NewNotFoundError("Attachment")
+    }
+
+    key := attachment.StorageKey
+    if variant == models.AttachmentThumbnail {
+        if attachment.ThumbnailKey == "" {
+            return nil, nil, apperrors.NewNotFoundError("Thumbnail")
+        }
+        key = attachment.ThumbnailKey
+        attachment.ContentType = "image/jpeg"
+    }
+
+    content, err := s.blobs.Get(ctx, key)
+    if errors.Is(err, repositories.ErrBlobNotFound) {
+        return nil, nil, apperrors.NewNotFoundError("Attachment content")
+    }
+    if err != nil {
+        return nil, nil, fmt.Errorf("failed to open attachment: %w", err)
+    }
+    return attachment, content, nil
+}
+
+// sign заполняет ссылки на скачивание, действующие URLTTL с момента now
+func (s *AttachmentService) sign(attachment *models.Attachment, now time.Time) {
+    expiresAt := now.Add(s.options.URLTTL).Truncate(time.Second)
+    attachment.URLExpiresAt = &expiresAt
+    attachment.DownloadURL = s.downloadURL(attachment.ID, models.AttachmentOriginal, expiresAt.Unix())
+    if attachment.ThumbnailKey != "" {
+        attachment.ThumbnailURL = s.downloadURL(attachment.ID, models.AttachmentThumbnail, expiresAt.Unix())
+    }
+}
+
+func (s *AttachmentService) downloadURL(id int64, variant string, expires int64) string {
+    query := url.Values{}
+    if variant != models.AttachmentOriginal {
+        query.Set("variant", variant)
+    }
+    query.Set("expires", strconv.FormatInt(expires, 10))
+    query.Set("signature", s.signature(id, variant, expires))
+    return fmt.Sprintf("%s%s%d/download?%s", strings.TrimSuffix(s.options.BaseURL, "/"), AttachmentPath, id, query.Encode())
+}
+
+// signature - HMAC-SHA256 от вложения, варианта и срока действия ссылки
+func (s *AttachmentService) signature(id int64, variant string, expires int64) string {
+    mac := hmac.New(sha256.New, s.options.URLSecret)
+    fmt.Fprintf(mac, "%d\n%s\n%d", id, variant, expires)
+    return hex.EncodeToString(mac.Sum(nil))
+}
+
+// randomKey - случайный ключ, который нельзя угадать: имя файла вложения в хранилище,
+// ID пакетной операции
+func randomKey() string {
+    buf := make([]byte, 16)
+    rand.Read(buf)
+    return hex.EncodeToString(buf)
+}
+
+// makeThumbnail уменьшает изображение так, чтобы большая сторона была не больше
+// size, усредняя пиксели, и кодирует в JPEG. Прозрачные области становятся белыми.
+func makeThumbnail(data []byte, size int) ([]byte, error) {
+    src, _, err := image.Decode(bytes.NewReader(data))
+    if err != nil {
+        return nil, err
+    }
+
+    bounds := src.Bounds()
+    width, height := bounds.Dx(), bounds.Dy()
+    if width == 0 || height == 0 {
+        return nil, fmt.Errorf("empty image")
+    }
+    dstWidth, dstHeight := width, height
+    if width > size || height > size {
+        if width >= height {
+            dstWidth, dstHeight = size, max(1, height*size/width)
+        } else {
+            dstWidth, dstHeight = max(1, width*size/height), size
+        }
+    }
+
+    // Приводим к RGBA на белом фоне: так усреднение работает с одним форматом пикселей
+    rgba := image.NewRGBA(image.Rect(0, 0, width, height))
+    draw.Draw(rgba, rgba.Bounds(), image.White, image.Point{}, draw.Src)
+    draw.Draw(rgba, rgba.Bounds(), src, bounds.Min, draw.Over)
+
+    dst := image.NewRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+    for y := 0; y < dstHeight; y++ {
+        y0, y1 := y*height/dstHeight, max((y+1)*height/dstHeight, y*height/dstHeight+1)
+        for x := 0; x < dstWidth; x++ {
+            x0, x1 := x*width/dstWidth, max((x+1)*width/dstWidth, x*width/dstWidth+1)
+
+            var r, g, b, count int
+            for sy := y0; sy < y1; sy++ {
+                row := rgba.Pix[sy*rgba.Stride:]
+                for sx := x0; sx < x1; sx++ {
+                    r += int(row[sx*4])
+                    g += int(row[sx*4+1])
+                    b += int(row[sx*4+2])
+                    count++
+                }
+            }
+
+            offset := y*dst.Stride + x*4
+            dst.Pix[offset] = uint8(r / count)
+            dst.Pix[offset+1] = uint8(g / count)
+            dst.Pix[offset+2] = uint8(b / count)
+            dst.Pix[offset+3] = 0xff
+        }
+    }
+
+    var buf bytes.Buffer
+    if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: thumbnailQuality}); err != nil {
+        return nil, err
+    }
+    return buf.Bytes(), nil
+}
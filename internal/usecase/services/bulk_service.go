@@ -0,0 +1,314 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/domain/repositories"
+	apperrors "incident-system/pkg/errors"
+	"incident-system/pkg/logger"
+)
+
+// BulkService изменяет много инцидентов одним запросом: изменения пакета записываются
+// одной транзакцией, кеш активных инцидентов сбрасывается один раз, а события в outbox
+// получают общий batch_id. В режиме atomic ошибка в любом инциденте отменяет пакет,
+// в режиме best_effort применяются инциденты без ошибок, в том числе если другие
+// инциденты пакета параллельно изменили.
+type BulkService struct {
+    incidents *IncidentService
+    logger    *logger.Logger
+}
+
+func NewBulkService(incidents *IncidentService, logger *logger.Logger) *BulkService {
+    return &BulkService{
+        incidents: incidents,
+        logger:    logger,
+    }
+}
+
+// bulkEntry - запланированная операция с одним инцидентом пакета
+type bulkEntry struct {
+    result models.BulkItemResult
+    change *models.IncidentChange // nil - инцидент не меняется или не прошел проверку
+    alert  bool                   // после записи оповестить пользователей в зоне
+}
+
+// Create создает инциденты пакета
+func (s *BulkService) Create(ctx context.Context, req models.BulkCreateRequest) (*models.BulkResult, error) {
+    if err := checkBulk(req.Mode, len(req.Items)); err != nil {
+        return nil, err
+    }
+
+    entries := make([]bulkEntry, len(req.Items))
+    for i, item := range req.Items {
+        entry, err := s.planCreate(ctx, item)
+        if err != nil {
+            return nil, err
+        }
+        entries[i] = entry
+    }
+    return s.apply(ctx, req.Mode, entries)
+}
+
+// Update изменяет переданные поля инцидентов, как PUT /incidents/{id}
+func (s *BulkService) Update(ctx context.Context, req models.BulkUpdateRequest) (*models.BulkResult, error) {
+    if err := checkBulk(req.Mode, len(req.Items)); err != nil {
+        return nil, err
+    }
+
+    seen := make(map[int64]int, len(req.Items))
+    entries := make([]bulkEntry, len(req.Items))
+    for i, item := range req.Items {
+        update := item.UpdateIncidentRequest
+        entry, err := s.planChange(ctx, item.ID, i, seen, models.ChangeUpdate, func(incident *models.Incident) error {
+            if err := update.Validate(); err != nil {
+                return apperrors.NewValidationError(err)
+            }
+            return s.incidents.applyUpdate(ctx, incident, update)
+        })
+        if err != nil {
+            return nil, err
+        }
+        entries[i] = entry
+    }
+    return s.apply(ctx, req.Mode, entries)
+}
+
+// Transition переводит инциденты в активное или неактивное состояние; снятие
+// активности сообщается событием incident.resolved
+func (s *BulkService) Transition(ctx context.Context, req models.BulkTransitionRequest) (*models.BulkResult, error) {
+    if req.Active == nil {
+        return nil, apperrors.NewValidationError(fmt.Errorf("active is required"))
+    }
+    if err := checkBulk(req.Mode, len(req.IDs)); err != nil {
+        return nil, err
+    }
+
+    update := models.UpdateIncidentRequest{Active: req.Active}
+    return s.planIDs(ctx, req.Mode, req.IDs, models.ChangeUpdate, func(incident *models.Incident) error {
+        return s.incidents.applyUpdate(ctx, incident, update)
+    })
+}
+
+// Deactivate снимает активность инцидентов, как DELETE /incidents/{id}
+func (s *BulkService) Deactivate(ctx context.Context, req models.BulkDeactivateRequest) (*models.BulkResult, error) {
+    if err := checkBulk(req.Mode, len(req.IDs)); err != nil {
+        return nil, err
+    }
+
+    return s.planIDs(ctx, req.Mode, req.IDs, models.ChangeDelete, func(incident *models.Incident) error {
+        incident.Active = false
+        return nil
+    })
+}
+
+func (s *BulkService) planIDs(ctx context.Context, mode string, ids []int64, kind string, modify func(*models.Incident) error) (*models.BulkResult, error) {
+    seen := make(map[int64]int, len(ids))
+    entries := make([]bulkEntry, len(ids))
+    for i, id := range ids {
+        entry, err := s.planChange(ctx, id, i, seen, kind, modify)
+        if err != nil {
+            return nil, err
+        }
+        entries[i] = entry
+    }
+    return s.apply(ctx, mode, entries)
+}
+
+func checkBulk(mode string, items int) error {
+    if !models.ValidBulkMode(mode) {
+        return apperrors.NewValidationError(fmt.Errorf("mode must be %s or %s", models.BulkModeAtomic, models.BulkModeBestEffort))
+    }
+    if items == 0 {
+        return apperrors.NewValidationError(fmt.Errorf("batch has no incidents"))
+    }
+    if items > models.MaxBulkItems {
+        return apperrors.NewValidationError(fmt.Errorf("batch has %d incidents, at most %d are allowed", items, models.MaxBulkItems))
+    }
+    return nil
+}
+
+func (s *BulkService) planCreate(ctx context.Context, req models.CreateIncidentRequest) (bulkEntry, error) {
+    var entry bulkEntry
+    incident, err := s.incidents.newIncident(ctx, req)
+    if err != nil {
+        return entry.fail(err)
+    }
+
+    entry.result.Action = models.BulkActionCreate
+    entry.change = &models.IncidentChange{Kind: models.ChangeCreate, Incident: incident}
+    entry.alert = true
+    return entry, nil
+}
+
+// planChange загружает инцидент id и планирует изменение, которое вносит modify;
+// seen - номера уже запланированных инцидентов пакета
+func (s *BulkService) planChange(ctx context.Context, id int64, index int, seen map[int64]int, kind string, modify func(*models.Incident) error) (bulkEntry, error) {
+    entry := bulkEntry{result: models.BulkItemResult{IncidentID: id}}
+    if previous, ok := seen[id]; ok {
+        return entry.fail(apperrors.NewValidationError(fmt.Errorf("incident repeats item %d", previous)))
+    }
+    seen[id] = index
+
+    before, err := s.incidents.GetIncident(ctx, id)
+    if err != nil {
+        return entry, fmt.Errorf("failed to find incident: %w", err)
+    }
+    if before == nil {
+        return entry.fail(apperrors.NewNotFoundError("Incident"))
+    }
+    if before.MergedInto != nil {
+        return entry.fail(apperrors.NewConflictError(fmt.Sprintf("incident %d has been merged into %d", id, *before.MergedInto)))
+    }
+
+    after := *before
+    if err := modify(&after); err != nil {
+        return entry.fail(err)
+    }
+
+    entry.result.ChangedFields = models.ChangedFields(before, &after)
+    if len(entry.result.ChangedFields) == 0 {
+        entry.result.Action = models.BulkActionUnchanged
+        return entry, nil
+    }
+
+    entry.result.Action = models.BulkActionUpdate
+    if kind == models.ChangeDelete {
+        entry.result.Action = models.BulkActionDeactivate
+    }
+    entry.change = &models.IncidentChange{Kind: kind, Incident: &after}
+    // Перемещенная, измененная или снова активная зона могла накрыть новых
+    // пользователей; оповещенные ранее пропускаются
+    entry.alert = after.Active && (zoneChanged(entry.result.ChangedFields) || !before.Active)
+    return entry, nil
+}
+
+// fail записывает ошибку данных в итог инцидента; ошибкой возвращаются только сбои
+func (entry bulkEntry) fail(err error) (bulkEntry, error) {
+    appErr := apperrors.FromError(err)
+    if appErr.Code >= 500 {
+        return entry, err
+    }
+
+    message := appErr.Message
+    if appErr.Details != "" {
+        message = appErr.Details
+    }
+    entry.result.Action = models.BulkActionError
+    entry.result.Error = message
+    return entry, nil
+}
+
+// apply записывает запланированные изменения пакета одной транзакцией
+func (s *BulkService) apply(ctx context.Context, mode string, entries []bulkEntry) (*models.BulkResult, error) {
+    if mode == "" {
+        mode = models.BulkModeAtomic
+    }
+
+    failed := false
+    for _, entry := range entries {
+        if entry.result.Action == models.BulkActionError {
+            failed = true
+        }
+    }
+
+    result := &models.BulkResult{Mode: mode, Applied: !failed || mode == models.BulkModeBestEffort}
+    for result.Applied {
+        changes, alert := pendingChanges(entries)
+        if len(changes) == 0 {
+            break
+        }
+
+        batchID := randomKey()
+        err := s.incidents.applyBatch(ctx, batchID, changes, alert)
+        if err == nil {
+            result.BatchID = batchID
+            for i, j := 0, 0; i < len(entries); i++ {
+                if entries[i].change != nil {
+                    entries[i].change = &changes[j]
+                    j++
+                }
+            }
+            break
+        }
+        if !errors.Is(err, repositories.ErrVersionConflict) {
+            return nil, fmt.Errorf("failed to apply batch: %w", err)
+        }
+
+        // Инциденты пакета изменили после проверки: в режиме atomic пакет не применен
+        // целиком, в режиме best_effort измененные инциденты становятся ошибками, а
+        // остальные записываются заново
+        conflicts := 0
+        if mode == models.BulkModeBestEffort {
+            if conflicts, err = s.failConflicts(ctx, entries); err != nil {
+                return nil, err
+            }
+        }
+        if conflicts == 0 {
+            return nil, apperrors.NewConflictError("incidents of the batch have been modified concurrently, retry the request")
+        }
+    }
+
+    for i, entry := range entries {
+        item := entry.result
+        item.Index = i
+        if result.BatchID != "" && entry.change != nil {
+            item.IncidentID = entry.change.Incident.ID
+            item.Incident = entry.change.Incident
+        }
+        result.Count(item)
+    }
+
+    if result.BatchID != "" {
+        s.logger.Info("Applied batch %s: %d created, %d updated, %d deactivated, %d unchanged, %d failed",
+            result.BatchID, result.Created, result.Updated, result.Deactivated, result.Unchanged, result.Failed)
+    }
+    return result, nil
+}
+
+// pendingChanges возвращает копии запланированных изменений: при сбое транзакции
+// хранилище может успеть изменить инциденты, а пакет записывается заново
+func pendingChanges(entries []bulkEntry) ([]models.IncidentChange, []*models.Incident) {
+    var changes []models.IncidentChange
+    var alert []*models.Incident
+    for _, entry := range entries {
+        if entry.change == nil {
+            continue
+        }
+        incident := *entry.change.Incident
+        changes = append(changes, models.IncidentChange{Kind: entry.change.Kind, Incident: &incident})
+        if entry.alert {
+            alert = append(alert, &incident)
+        }
+    }
+    return changes, alert
+}
+
+// failConflicts отмечает ошибкой инциденты пакета, версия которых в хранилище
+// отличается от проверенной, и возвращает их число
+func (s *BulkService) failConflicts(ctx context.Context, entries []bulkEntry) (int, error) {
+    conflicts := 0
+    for i, entry := range entries {
+        if entry.change == nil || entry.change.Kind == models.ChangeCreate {
+            continue
+        }
+
+        planned := entry.change.Incident
+        stored, err := s.incidents.incidentRepo.FindByID(ctx, planned.ID)
+        if err != nil {
+            return 0, fmt.Errorf("failed to find incident: %w", err)
+        }
+        if stored != nil && stored.Version == planned.Version {
+            continue
+        }
+
+        entries[i].change = nil
+        entries[i].result.ChangedFields = nil
+        entries[i], _ = entries[i].fail(apperrors.NewConflictError(
+            fmt.Sprintf("incident %d has been modified concurrently, retry it", planned.ID)))
+        conflicts++
+    }
+    return conflicts, nil
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/domain/repositories"
+	"incident-system/internal/infrastructure/memory"
+	apperrors "incident-system/pkg/errors"
+	"incident-system/pkg/logger"
+)
+
+// racingRepository изменяет инцидент race перед первым ApplyBatch, как оператор,
+// успевший между проверкой и записью пакета
+type racingRepository struct {
+    repositories.IncidentRepository
+    race int64
+}
+
+func (r *racingRepository) ApplyBatch(ctx context.Context, batchID string, changes []models.IncidentChange) error {
+    if r.race != 0 {
+        incident, err := r.FindByID(ctx, r.race)
+        if err != nil {
+            return err
+        }
+        incident.Description = "изменено параллельно"
+        if err := r.Update(ctx, incident); err != nil {
+            return err
+        }
+        r.race = 0
+    }
+    return r.IncidentRepository.ApplyBatch(ctx, batchID, changes)
+}
+
+func newBulkFixture(t *testing.T, count int) (*BulkService, *racingRepository, []int64) {
+    t.Helper()
+    ctx := context.Background()
+    repo := &racingRepository{IncidentRepository: memory.NewIncidentRepository()}
+
+    var ids []int64
+    for i := 0; i < count; i++ {
+        incident := &models.Incident{
+            UserID:    "operator",
+            Title:     "Пожар",
+            Severity:  "high",
+            Latitude:  55.7558,
+            Longitude: 37.6173,
+            Radius:    1000,
+            Active:    true,
+        }
+        if err := repo.Create(ctx, incident); err != nil {
+            t.Fatalf("Create: %v", err)
+        }
+        ids = append(ids, incident.ID)
+    }
+
+    service := NewIncidentService(repo, memory.NewCacheRepository(time.Minute, time.Minute), nil, nil, nil, nil)
+    return NewBulkService(service, logger.NewLogger("test")), repo, ids
+}
+
+func TestBulkAtomicRejectsConcurrentlyModifiedBatch(t *testing.T) {
+    ctx := context.Background()
+    bulk, repo, ids := newBulkFixture(t, 3)
+    repo.race = ids[1]
+
+    _, err := bulk.Deactivate(ctx, models.BulkDeactivateRequest{IDs: ids})
+    if appErr := apperrors.FromError(err); err == nil || appErr.Code != http.StatusConflict {
+        t.Fatalf("Deactivate = %v, want 409", err)
+    }
+    for _, id := range ids {
+        incident, _ := repo.FindByID(ctx, id)
+        if !incident.Active {
+            t.Fatalf("incident %d deactivated by a rejected atomic batch", id)
+        }
+    }
+}
+
+func TestBulkBestEffortReportsConcurrentlyModifiedItems(t *testing.T) {
+    ctx := context.Background()
+    bulk, repo, ids := newBulkFixture(t, 3)
+    repo.race = ids[1]
+
+    result, err := bulk.Deactivate(ctx, models.BulkDeactivateRequest{Mode: models.BulkModeBestEffort, IDs: ids})
+    if err != nil {
+        t.Fatalf("Deactivate: %v", err)
+    }
+    if !result.Applied || result.BatchID == "" || result.Deactivated != 2 || result.Failed != 1 {
+        t.Fatalf("Deactivate = %+v, want two deactivated and one conflict", result)
+    }
+
+    conflict := result.Items[1]
+    if conflict.Action != models.BulkActionError || conflict.IncidentID != ids[1] || conflict.Error == "" || conflict.Incident != nil {
+        t.Fatalf("item 1 = %+v, want a conflict error", conflict)
+    }
+    for i, id := range ids {
+        incident, _ := repo.FindByID(ctx, id)
+        if incident.Active != (i == 1) {
+            t.Fatalf("incident %d active = %v", id, incident.Active)
+        }
+    }
+    if item := result.Items[0]; item.Incident == nil || item.Incident.Active || item.Incident.Version != 2 {
+        t.Fatalf("item 0 = %+v, want the deactivated incident at version 2", item)
+    }
+}
+
+func TestBulkAtomicRejectsBatchWithInvalidItem(t *testing.T) {
+    ctx := context.Background()
+    bulk, repo, ids := newBulkFixture(t, 2)
+
+    result, err := bulk.Deactivate(ctx, models.BulkDeactivateRequest{IDs: []int64{ids[0], 999, ids[1]}})
+    if err != nil {
+        t.Fatalf("Deactivate: %v", err)
+    }
+    if result.Mode != models.BulkModeAtomic || result.Applied || result.BatchID != "" || result.Deactivated != 2 || result.Failed != 1 {
+        t.Fatalf("Deactivate = %+v, want a rejected batch with one failed item", result)
+    }
+    if item := result.Items[1]; item.Action != models.BulkActionError || item.Error == "" {
+        t.Fatalf("item 1 = %+v, want the missing incident error", item)
+    }
+    for _, id := range ids {
+        if incident, _ := repo.FindByID(ctx, id); !incident.Active {
+            t.Fatalf("incident %d deactivated by a rejected atomic batch", id)
+        }
+    }
+}
+
+func TestBulkBestEffortAppliesValidItems(t *testing.T) {
+    ctx := context.Background()
+    bulk, repo, ids := newBulkFixture(t, 2)
+
+    result, err := bulk.Deactivate(ctx, models.BulkDeactivateRequest{Mode: models.BulkModeBestEffort, IDs: []int64{ids[0], 999, ids[1]}})
+    if err != nil {
+        t.Fatalf("Deactivate: %v", err)
+    }
+    if !result.Applied || result.BatchID == "" || result.Deactivated != 2 || result.Failed != 1 {
+        t.Fatalf("Deactivate = %+v, want two deactivated and one failed", result)
+    }
+    if item := result.Items[1]; item.Action != models.BulkActionError || item.Error == "" || item.Incident != nil {
+        t.Fatalf("item 1 = %+v, want the missing incident error", item)
+    }
+    for _, id := range ids {
+        if incident, _ := repo.FindByID(ctx, id); incident.Active {
+            t.Fatalf("incident %d still active after a best_effort batch", id)
+        }
+    }
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"sync"
+	"time"
+
+	"incident-system/pkg/circuitbreaker"
+	"incident-system/pkg/logger"
+)
+
+// breakerRecheckDelay - минимальная задержка задачи, пока автомат получателя разомкнут
+// или его проба еще не завершилась
+const breakerRecheckDelay = time.Second
+
+// destinationBreakers - автоматы по одному на получателя (схема и хост URL).
+// Разомкнутый автомат не пропускает запросы к получателю до пробы в
+// полуоткрытом состоянии, поэтому упавший получатель не занимает воркеры.
+type destinationBreakers struct {
+    failureThreshold int
+    openTimeout      time.Duration
+    logger           *logger.Logger
+
+    mu       sync.Mutex
+    breakers map[string]*circuitbreaker.Breaker
+}
+
+func newDestinationBreakers(failureThreshold int, openTimeout time.Duration, logger *logger.Logger) *destinationBreakers {
+    return &destinationBreakers{
+        failureThreshold: failureThreshold,
+        openTimeout:      openTimeout,
+        logger:           logger,
+        breakers:         make(map[string]*circuitbreaker.Breaker),
+    }
+}
+
+// enabled - 0 в пороге отключает автоматы
+func (b *destinationBreakers) enabled() bool {
+    return b.failureThreshold > 0
+}
+
+func (b *destinationBreakers) get(destination string) *circuitbreaker.Breaker {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+
+    breaker, ok := b.breakers[destination]
+    if !ok {
+        breaker = circuitbreaker.New(destination, b.failureThreshold, b.openTimeout)
+        breaker.OnStateChange(func(name string, from, to circuitbreaker.State) {
+            b.logger.Warn("Webhook destination %s circuit breaker: %s -> %s", name, from, to)
+        })
+        b.breakers[destination] = breaker
+    }
+    return breaker
+}
+
+// retryDelay - через сколько снова предложить задачу разомкнутому автомату
+func (b *destinationBreakers) retryDelay(breaker *circuitbreaker.Breaker, now time.Time) time.Duration {
+    wait := breaker.OpenedAt().Add(b.openTimeout).Sub(now)
+    if wait < breakerRecheckDelay {
+        wait = breakerRecheckDelay
+    }
+    return wait
+}
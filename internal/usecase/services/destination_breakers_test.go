@@ -0,0 +1,43 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"incident-system/pkg/circuitbreaker"
+	"incident-system/pkg/logger"
+)
+
+func TestDestinationBreakersPerDestination(t *testing.T) {
+    breakers := newDestinationBreakers(2, time.Minute, logger.NewLogger("test"))
+    if !breakers.enabled() || newDestinationBreakers(0, time.Minute, logger.NewLogger("test")).enabled() {
+        t.Fatal("breakers must be enabled only with a positive failure threshold")
+    }
+
+    down := breakers.get("https://a.example.com")
+    if breakers.get("https://a.example.com") != down {
+        t.Fatal("get returned another breaker for the same destination")
+    }
+    down.Failure()
+    down.Failure()
+    if down.State() != circuitbreaker.StateOpen {
+        t.Fatalf("breaker state = %s after the threshold, want open", down.State())
+    }
+    if err := breakers.get("https://b.example.com").Allow(); err != nil {
+        t.Fatalf("breaker of another destination rejected a request: %v", err)
+    }
+}
+
+func TestDestinationBreakersRetryDelay(t *testing.T) {
+    breakers := newDestinationBreakers(1, time.Minute, logger.NewLogger("test"))
+    breaker := breakers.get("https://a.example.com")
+    breaker.Failure()
+    openedAt := breaker.OpenedAt()
+
+    if delay := breakers.retryDelay(breaker, openedAt.Add(20*time.Second)); delay != 40*time.Second {
+        t.Fatalf("retryDelay = %v, want the rest of the open timeout", delay)
+    }
+    if delay := breakers.retryDelay(breaker, openedAt.Add(2*time.Minute)); delay != breakerRecheckDelay {
+        t.Fatalf("retryDelay after the open timeout = %v, want %v", delay, breakerRecheckDelay)
+    }
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"math"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// destinationBusyDelay - через сколько повторить задачу, если все слоты получателя заняты
+const destinationBusyDelay = 200 * time.Millisecond
+
+// destinationLimiter ограничивает число одновременных запросов и их частоту
+// (token bucket) отдельно для каждого получателя, а также учитывает паузы,
+// которые получатель запросил заголовком Retry-After
+type destinationLimiter struct {
+    concurrency int     // 0 - без ограничения
+    rate        float64 // запросов в секунду; 0 - без ограничения
+    burst       float64
+
+    mu           sync.Mutex
+    destinations map[string]*destinationState
+}
+
+type destinationState struct {
+    inFlight     int
+    tokens       float64
+    updatedAt    time.Time
+    blockedUntil time.Time
+}
+
+func newDestinationLimiter(concurrency int, rate float64, burst int) *destinationLimiter {
+    if burst < 1 {
+        burst = 1
+    }
+    return &destinationLimiter{
+        concurrency:  concurrency,
+        rate:         rate,
+        burst:        float64(burst),
+        destinations: make(map[string]*destinationState),
+    }
+}
+
+// acquire занимает слот получателя. Если запрос сейчас выполнять нельзя,
+// возвращает false и время, через которое стоит попробовать снова.
+func (l *destinationLimiter) acquire(destination string, now time.Time) (bool, time.Duration) {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+
+    state := l.state(destination, now)
+
+    if now.Before(state.blockedUntil) {
+        return false, state.blockedUntil.Sub(now)
+    }
+    if l.concurrency > 0 && state.inFlight >= l.concurrency {
+        return false, destinationBusyDelay
+    }
+
+    if l.rate > 0 {
+        elapsed := now.Sub(state.updatedAt).Seconds()
+        state.tokens = math.Min(l.burst, state.tokens+elapsed*l.rate)
+        state.updatedAt = now
+        if state.tokens < 1 {
+            wait := time.Duration((1 - state.tokens) / l.rate * float64(time.Second))
+            return false, wait
+        }
+        state.tokens--
+    }
+
+    state.inFlight++
+    return true, 0
+}
+
+// release освобождает слот, занятый acquire
+func (l *destinationLimiter) release(destination string) {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+
+    if state, ok := l.destinations[destination]; ok && state.inFlight > 0 {
+        state.inFlight--
+    }
+}
+
+// block приостанавливает запросы к получателю до until
+func (l *destinationLimiter) block(destination string, until time.Time) {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+
+    state := l.state(destination, time.Now())
+    if until.After(state.blockedUntil) {
+        state.blockedUntil = until
+    }
+}
+
+func (l *destinationLimiter) state(destination string, now time.Time) *destinationState {
+    state, ok := l.destinations[destination]
+    if !ok {
+        state = &destinationState{tokens: l.burst, updatedAt: now}
+        l.destinations[destination] = state
+    }
+    return state
+}
+
+// destinationKey - получатель, к которому применяются ограничения: схема и хост URL
+func destinationKey(rawURL string) string {
+    parsed, err := url.Parse(rawURL)
+    if err != nil || parsed.Host == "" {
+        return rawURL
+    }
+    return parsed.Scheme + "://" + parsed.Host
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDestinationLimiterConcurrency(t *testing.T) {
+    limiter := newDestinationLimiter(2, 0, 0)
+    now := time.Now()
+
+    for i := 0; i < 2; i++ {
+        if ok, _ := limiter.acquire("https://a.example.com", now); !ok {
+            t.Fatalf("acquire %d rejected below the concurrency limit", i)
+        }
+    }
+    if ok, wait := limiter.acquire("https://a.example.com", now); ok || wait != destinationBusyDelay {
+        t.Fatalf("acquire over the limit = %v, %v; want busy delay", ok, wait)
+    }
+    // Лимит считается отдельно для каждого получателя
+    if ok, _ := limiter.acquire("https://b.example.com", now); !ok {
+        t.Fatal("acquire rejected for another destination")
+    }
+
+    limiter.release("https://a.example.com")
+    if ok, _ := limiter.acquire("https://a.example.com", now); !ok {
+        t.Fatal("acquire rejected after release")
+    }
+}
+
+func TestDestinationLimiterRate(t *testing.T) {
+    limiter := newDestinationLimiter(0, 2, 2)
+    now := time.Now()
+
+    for i := 0; i < 2; i++ {
+        if ok, _ := limiter.acquire("https://a.example.com", now); !ok {
+            t.Fatalf("acquire %d rejected within the burst", i)
+        }
+    }
+    if ok, wait := limiter.acquire("https://a.example.com", now); ok || wait != 500*time.Millisecond {
+        t.Fatalf("acquire over the burst = %v, %v; want to wait for the next token", ok, wait)
+    }
+    if ok, _ := limiter.acquire("https://a.example.com", now.Add(500*time.Millisecond)); !ok {
+        t.Fatal("acquire rejected after a token was refilled")
+    }
+}
+
+func TestDestinationLimiterRetryAfter(t *testing.T) {
+    limiter := newDestinationLimiter(0, 0, 0)
+    now := time.Now()
+
+    limiter.block("https://a.example.com", now.Add(30*time.Second))
+    limiter.block("https://a.example.com", now.Add(10*time.Second))
+    if ok, wait := limiter.acquire("https://a.example.com", now); ok || wait != 30*time.Second {
+        t.Fatalf("acquire while blocked = %v, %v; want the longest Retry-After", ok, wait)
+    }
+    if ok, _ := limiter.acquire("https://a.example.com", now.Add(30*time.Second)); !ok {
+        t.Fatal("acquire rejected after Retry-After passed")
+    }
+}
+
+func TestDestinationKey(t *testing.T) {
+    for raw, want := range map[string]string{
+        "https://hooks.example.com/a?x=1": "https://hooks.example.com",
+        "http://hooks.example.com:8080/b": "http://hooks.example.com:8080",
+        "not a url":                       "not a url",
+    } {
+        if got := destinationKey(raw); got != want {
+            t.Fatalf("destinationKey(%q) = %q, want %q", raw, got, want)
+        }
+    }
+}
@@ -0,0 +1,255 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/domain/repositories"
+	apperrors "incident-system/pkg/errors"
+	"incident-system/pkg/geo"
+	"incident-system/pkg/logger"
+)
+
+// Веса признаков в оценке сходства; в сумме 1
+const (
+    duplicateZoneWeight     = 0.4
+    duplicateTimeWeight     = 0.2
+    duplicateCategoryWeight = 0.2
+    duplicateTitleWeight    = 0.2
+
+    // similarTitle - сходство заголовков, начиная с которого оно указывается в причинах
+    similarTitle = 0.5
+)
+
+// DuplicateOptions - когда инциденты и сообщения считаются вероятными дубликатами
+type DuplicateOptions struct {
+    DistanceKm float64       // зоны дальше друг от друга не сравниваются
+    TimeWindow time.Duration // с такой разницей во времени создания близость по времени не учитывается
+    Threshold  float64       // наименьшая оценка сходства предложения, от 0 до 1
+    Limit      int           // не больше стольких предложений
+}
+
+// DuplicateService находит вероятные дубликаты: инциденты и сообщения жителей,
+// описывающие то же происшествие. Сходство оценивается по пересечению или
+// близости зон, близости по времени, категории и сходству заголовков.
+type DuplicateService struct {
+    incidentRepo repositories.IncidentRepository
+    reportRepo   repositories.ReportRepository
+    options      DuplicateOptions
+    logger       *logger.Logger
+}
+
+func NewDuplicateService(
+    incidentRepo repositories.IncidentRepository,
+    reportRepo repositories.ReportRepository,
+    options DuplicateOptions,
+    logger *logger.Logger,
+) *DuplicateService {
+    return &DuplicateService{
+        incidentRepo: incidentRepo,
+        reportRepo:   reportRepo,
+        options:      options,
+        logger:       logger,
+    }
+}
+
+// FindDuplicates возвращает активные инциденты, похожие на incident, по убыванию оценки
+func (s *DuplicateService) FindDuplicates(ctx context.Context, incident *models.Incident) ([]models.DuplicateCandidate, error) {
+    nearby, err := s.incidentRepo.FindNearLocation(ctx, incident.Latitude, incident.Longitude, incident.Radius/1000.0+s.options.DistanceKm)
+    if err != nil {
+        return nil, fmt.Errorf("failed to find nearby incidents: %w", err)
+    }
+
+    var candidates []models.DuplicateCandidate
+    for _, other := range nearby {
+        if other.ID == incident.ID {
+            continue
+        }
+        if score, reasons := s.similarity(incident, other); score >= s.options.Threshold {
+            candidates = append(candidates, models.DuplicateCandidate{Incident: other, Score: score, Reasons: reasons})
+        }
+    }
+    return s.best(candidates), nil
+}
+
+// Suggest - FindDuplicates для ответа на создание инцидента: сбой поиска
+// не мешает созданию и только записывается в журнал
+func (s *DuplicateService) Suggest(ctx context.Context, incident *models.Incident) []models.DuplicateCandidate {
+    candidates, err := s.FindDuplicates(ctx, incident)
+    if err != nil {
+        s.logger.Error("Failed to find duplicates of incident %d: %v", incident.ID, err)
+        return nil
+    }
+    return candidates
+}
+
+// IncidentDuplicates возвращает вероятные дубликаты инцидента id
+func (s *DuplicateService) IncidentDuplicates(ctx context.Context, id int64) ([]models.DuplicateCandidate, error) {
+    incident, err := s.incidentRepo.FindByID(ctx, id)
+    if err != nil {
+        return nil, fmt.Errorf("failed to find incident: %w", err)
+    }
+    if incident == nil {
+        return nil, apperrors.NewNotFoundError("Incident")
+    }
+    return s.FindDuplicates(ctx, incident)
+}
+
+// ReportDuplicates возвращает инциденты, к которым, вероятно, относится сообщение,
+// и похожие сообщения в очереди модерации - их удобно рассмотреть вместе
+func (s *DuplicateService) ReportDuplicates(ctx context.Context, id int64) (*models.ReportDuplicates, error) {
+    report, err := s.reportRepo.FindByID(ctx, id)
+    if err != nil {
+        return nil, fmt.Errorf("failed to find report: %w", err)
+    }
+    if report == nil {
+        return nil, apperrors.NewNotFoundError("Report")
+    }
+
+    incident := reportIncident(report)
+    incidents, err := s.FindDuplicates(ctx, incident)
+    if err != nil {
+        return nil, err
+    }
+
+    radiusKm := 2*models.DefaultReportRadius/1000.0 + s.options.DistanceKm
+    pending, err := s.reportRepo.FindPendingNear(ctx, report.Latitude, report.Longitude, radiusKm, report.CreatedAt.Add(-s.options.TimeWindow))
+    if err != nil {
+        return nil, fmt.Errorf("failed to find nearby reports: %w", err)
+    }
+
+    var reports []models.DuplicateCandidate
+    for _, other := range pending {
+        if other.ID == report.ID {
+            continue
+        }
+        if score, reasons := s.similarity(incident, reportIncident(other)); score >= s.options.Threshold {
+            reports = append(reports, models.DuplicateCandidate{Report: other, Score: score, Reasons: reasons})
+        }
+    }
+
+    return &models.ReportDuplicates{
+        Incidents: nonNilCandidates(incidents),
+        Reports:   nonNilCandidates(s.best(reports)),
+    }, nil
+}
+
+// reportIncident представляет сообщение инцидентом, который был бы создан по нему без правок
+func reportIncident(report *models.IncidentReport) *models.Incident {
+    return &models.Incident{
+        Latitude:  report.Latitude,
+        Longitude: report.Longitude,
+        Title:     models.ReportTitle(report.Description, maxReportTitle),
+        Category:  report.Category,
+        Radius:    models.DefaultReportRadius,
+        CreatedAt: report.CreatedAt,
+    }
+}
+
+// similarity оценивает сходство инцидентов от 0 до 1 и перечисляет совпавшие признаки.
+// Зоны, между которыми больше DistanceKm, не похожи независимо от остального.
+func (s *DuplicateService) similarity(a, b *models.Incident) (float64, []string) {
+    gap := geo.ZoneGapKm(a.Zone(), b.Zone())
+    if gap > s.options.DistanceKm {
+        return 0, nil
+    }
+
+    reasons := []string{}
+    score := duplicateZoneWeight
+    if gap > 0 {
+        score *= 1 - gap/s.options.DistanceKm
+        reasons = append(reasons, models.DuplicateNearby)
+    } else {
+        reasons = append(reasons, models.DuplicateZoneOverlap)
+    }
+
+    if s.options.TimeWindow > 0 {
+        elapsed := a.CreatedAt.Sub(b.CreatedAt)
+        if elapsed < 0 {
+            elapsed = -elapsed
+        }
+        if closeness := 1 - float64(elapsed)/float64(s.options.TimeWindow); closeness > 0 {
+            score += duplicateTimeWeight * closeness
+            reasons = append(reasons, models.DuplicateCloseInTime)
+        }
+    }
+
+    switch {
+    case a.Category == b.Category:
+        score += duplicateCategoryWeight
+        reasons = append(reasons, models.DuplicateSameCategory)
+    case relatedCategories(a.Category, b.Category):
+        score += duplicateCategoryWeight / 2
+        reasons = append(reasons, models.DuplicateRelatedCategory)
+    }
+
+    if title := titleSimilarity(a.Title, b.Title); title > 0 {
+        score += duplicateTitleWeight * title
+        if title >= similarTitle {
+            reasons = append(reasons, models.DuplicateSimilarTitle)
+        }
+    }
+
+    return math.Round(score*1000) / 1000, reasons
+}
+
+// best упорядочивает предложения по убыванию оценки и оставляет не больше Limit
+func (s *DuplicateService) best(candidates []models.DuplicateCandidate) []models.DuplicateCandidate {
+    sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].Score > candidates[j].Score })
+    if s.options.Limit > 0 && len(candidates) > s.options.Limit {
+        candidates = candidates[:s.options.Limit]
+    }
+    return candidates
+}
+
+func nonNilCandidates(candidates []models.DuplicateCandidate) []models.DuplicateCandidate {
+    if candidates == nil {
+        return []models.DuplicateCandidate{}
+    }
+    return candidates
+}
+
+// relatedCategories: одна категория - подкатегория другой или у них общий родитель
+func relatedCategories(a, b string) bool {
+    parentA, parentB := models.ParentCategory(a), models.ParentCategory(b)
+    return models.CategoryMatches(a, b) || models.CategoryMatches(b, a) || (parentA != "" && parentA == parentB)
+}
+
+// titleSimilarity - коэффициент Жаккара множеств триграмм слов заголовков, от 0 до 1.
+// Регистр, знаки препинания и порядок слов не учитываются.
+func titleSimilarity(a, b string) float64 {
+    left, right := trigrams(a), trigrams(b)
+    if len(left) == 0 || len(right) == 0 {
+        return 0
+    }
+
+    common := 0
+    for trigram := range left {
+        if right[trigram] {
+            common++
+        }
+    }
+    return float64(common) / float64(len(left)+len(right)-common)
+}
+
+func trigrams(text string) map[string]bool {
+    words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+        return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+    })
+
+    set := make(map[string]bool)
+    for _, word := range words {
+        // Пробелы по краям дают триграммы начала и конца слова, в том числе у коротких слов
+        runes := []rune(" " + word + " ")
+        for i := 0; i+3 <= len(runes); i++ {
+            set[string(runes[i:i+3])] = true
+        }
+    }
+    return set
+}
@@ -0,0 +1,337 @@
+package services
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"incident-system/internal/domain/models"
+)
+
+// capTimeLayout - формат времени CAP 1.2: зона задается смещением, "Z" не допускается
+const capTimeLayout = "2006-01-02T15:04:05-07:00"
+
+type geoJSONFeatureCollection struct {
+    Type     string           `json:"type"`
+    Features []geoJSONFeature `json:"features"`
+}
+
+type geoJSONFeature struct {
+    Type       string            `json:"type"`
+    ID         int64             `json:"id"`
+    Geometry   geoJSONGeometry   `json:"geometry"`
+    Properties geoJSONProperties `json:"properties"`
+}
+
+// geoJSONGeometry - Polygon для зоны-контура, Point для круговой зоны (радиус - в свойствах)
+type geoJSONGeometry struct {
+    Type        string      `json:"type"`
+    Coordinates interface{} `json:"coordinates"`
+}
+
+type geoJSONProperties struct {
+    Title        string           `json:"title"`
+    Description  string           `json:"description,omitempty"`
+    Severity     string           `json:"severity"`
+    Category     string           `json:"category"`
+    CategoryName string           `json:"category_name,omitempty"`
+    Tags         []string         `json:"tags"`
+    Radius       float64          `json:"radius"` // в метрах
+    CreatedAt    time.Time        `json:"created_at"`
+    UpdatedAt    time.Time        `json:"updated_at"`
+    ExpiresAt    *time.Time       `json:"expires_at,omitempty"`
+    LatestUpdate *feedUpdateEntry `json:"latest_update,omitempty"`
+    CAPURL       string           `json:"cap_url"`
+}
+
+// feedUpdateEntry - сообщение хроники без автора
+type feedUpdateEntry struct {
+    Message   string    `json:"message"`
+    CreatedAt time.Time `json:"created_at"`
+}
+
+func encodeGeoJSONFeed(items []models.IncidentWithUpdate, names map[string]string, baseURL string) ([]byte, error) {
+    collection := geoJSONFeatureCollection{
+        Type:     "FeatureCollection",
+        Features: make([]geoJSONFeature, 0, len(items)),
+    }
+
+    for i := range items {
+        item := &items[i]
+        geometry := geoJSONGeometry{Type: "Point", Coordinates: [2]float64{item.Longitude, item.Latitude}}
+        if ring := closedRing(item.Polygon); ring != nil {
+            coordinates := make([][2]float64, len(ring))
+            for j, point := range ring {
+                coordinates[j] = [2]float64{point.Longitude, point.Latitude}
+            }
+            geometry = geoJSONGeometry{Type: "Polygon", Coordinates: [][][2]float64{coordinates}}
+        }
+
+        tags := item.Tags
+        if tags == nil {
+            tags = []string{}
+        }
+        properties := geoJSONProperties{
+            Title:        item.Title,
+            Description:  item.Description,
+            Severity:     item.Severity,
+            Category:     item.Category,
+            CategoryName: names[item.Category],
+            Tags:         tags,
+            Radius:       item.Radius,
+            CreatedAt:    item.CreatedAt,
+            UpdatedAt:    item.UpdatedAt,
+            ExpiresAt:    item.ExpiresAt,
+            CAPURL:       capAlertURL(baseURL, item.ID),
+        }
+        if item.LatestUpdate != nil {
+            properties.LatestUpdate = &feedUpdateEntry{Message: item.LatestUpdate.Message, CreatedAt: item.LatestUpdate.CreatedAt}
+        }
+
+        collection.Features = append(collection.Features, geoJSONFeature{
+            Type:       "Feature",
+            ID:         item.ID,
+            Geometry:   geometry,
+            Properties: properties,
+        })
+    }
+
+    return json.Marshal(collection)
+}
+
+type atomFeed struct {
+    XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+    GeoRSS  string      `xml:"xmlns:georss,attr"`
+    ID      string      `xml:"id"`
+    Title   string      `xml:"title"`
+    Updated string      `xml:"updated"`
+    Author  atomAuthor  `xml:"author"`
+    Links   []atomLink  `xml:"link"`
+    Entries []atomEntry `xml:"entry"`
+}
+
+type atomAuthor struct {
+    Name string `xml:"name"`
+}
+
+type atomLink struct {
+    Rel  string `xml:"rel,attr,omitempty"`
+    Type string `xml:"type,attr,omitempty"`
+    Href string `xml:"href,attr"`
+}
+
+type atomCategory struct {
+    Term  string `xml:"term,attr"`
+    Label string `xml:"label,attr,omitempty"`
+}
+
+type atomEntry struct {
+    ID         string         `xml:"id"`
+    Title      string         `xml:"title"`
+    Updated    string         `xml:"updated"`
+    Published  string         `xml:"published"`
+    Summary    string         `xml:"summary,omitempty"`
+    Content    string         `xml:"content,omitempty"`
+    Categories []atomCategory `xml:"category"`
+    Links      []atomLink     `xml:"link"`
+    Point      string         `xml:"georss:point"`
+    Polygon    string         `xml:"georss:polygon,omitempty"`
+    Radius     string         `xml:"georss:radius,omitempty"`
+}
+
+// encodeAtomFeed строит ленту Atom с координатами GeoRSS Simple. Запись ссылается на
+// оповещение CAP об инциденте, так что лента служит и индексом оповещений CAP.
+func encodeAtomFeed(items []models.IncidentWithUpdate, names map[string]string, options FeedOptions, baseURL string) ([]byte, error) {
+    feedURL := baseURL + FeedsPath + "incidents.atom"
+    feed := atomFeed{
+        GeoRSS:  "http://www.georss.org/georss",
+        ID:      feedURL,
+        Title:   options.Title,
+        Updated: atomTime(time.Unix(0, 0)),
+        Author:  atomAuthor{Name: options.Publisher},
+        Links:   []atomLink{{Rel: "self", Type: "application/atom+xml", Href: feedURL}},
+        Entries: make([]atomEntry, 0, len(items)),
+    }
+
+    // Время ленты берется из содержимого, а не из момента загрузки кеша: тело и ETag
+    // не меняются, пока не меняются сами инциденты
+    var feedUpdated time.Time
+    for i := range items {
+        item := &items[i]
+        updated := models.FeedItemUpdated(item)
+        if updated.After(feedUpdated) {
+            feedUpdated = updated
+        }
+
+        capURL := capAlertURL(baseURL, item.ID)
+        category := atomCategory{Term: item.Category, Label: names[item.Category]}
+        entry := atomEntry{
+            ID:         capURL,
+            Title:      item.Title,
+            Updated:    atomTime(updated),
+            Published:  atomTime(item.CreatedAt),
+            Summary:    item.Description,
+            Categories: []atomCategory{category, {Term: item.Severity}},
+            Links:      []atomLink{{Rel: "alternate", Type: "application/cap+xml", Href: capURL}},
+            Point:      formatLatLon(item.Latitude, item.Longitude, " "),
+        }
+        if item.LatestUpdate != nil {
+            entry.Content = item.LatestUpdate.Message
+        }
+        if ring := closedRing(item.Polygon); ring != nil {
+            points := make([]string, len(ring))
+            for j, point := range ring {
+                points[j] = formatLatLon(point.Latitude, point.Longitude, " ")
+            }
+            entry.Polygon = strings.Join(points, " ")
+        } else {
+            entry.Radius = formatFloat(item.Radius)
+        }
+
+        feed.Entries = append(feed.Entries, entry)
+    }
+    if !feedUpdated.IsZero() {
+        feed.Updated = atomTime(feedUpdated)
+    }
+
+    return marshalXML(feed)
+}
+
+type capAlert struct {
+    XMLName    xml.Name `xml:"urn:oasis:names:tc:emergency:cap:1.2 alert"`
+    Identifier string   `xml:"identifier"`
+    Sender     string   `xml:"sender"`
+    Sent       string   `xml:"sent"`
+    Status     string   `xml:"status"`
+    MsgType    string   `xml:"msgType"`
+    Scope      string   `xml:"scope"`
+    References string   `xml:"references,omitempty"`
+    Info       capInfo  `xml:"info"`
+}
+
+type capInfo struct {
+    Language    string  `xml:"language,omitempty"`
+    Category    string  `xml:"category"`
+    Event       string  `xml:"event"`
+    Urgency     string  `xml:"urgency"`
+    Severity    string  `xml:"severity"`
+    Certainty   string  `xml:"certainty"`
+    Effective   string  `xml:"effective"`
+    Expires     string  `xml:"expires,omitempty"`
+    SenderName  string  `xml:"senderName,omitempty"`
+    Headline    string  `xml:"headline"`
+    Description string  `xml:"description,omitempty"`
+    Area        capArea `xml:"area"`
+}
+
+type capArea struct {
+    AreaDesc string `xml:"areaDesc"`
+    Polygon  string `xml:"polygon,omitempty"`
+    Circle   string `xml:"circle,omitempty"`
+}
+
+// encodeCAPAlert строит оповещение CAP 1.2. Каждая версия инцидента получает свой
+// identifier; изменение инцидента публикуется как Update со ссылкой на исходное Alert.
+func encodeCAPAlert(item *models.IncidentWithUpdate, names map[string]string, options FeedOptions) ([]byte, error) {
+    updated := models.FeedItemUpdated(item)
+    event := names[item.Category]
+    if event == "" {
+        event = item.Category
+    }
+
+    alert := capAlert{
+        Identifier: capIdentifier(item.ID, updated),
+        Sender:     options.Publisher,
+        Sent:       capTime(updated),
+        Status:     "Actual",
+        MsgType:    "Alert",
+        Scope:      "Public",
+        Info: capInfo{
+            Language:    options.Language,
+            Category:    models.CAPCategory(item.Category),
+            Event:       event,
+            Urgency:     "Immediate",
+            Severity:    models.CAPSeverity(item.Severity),
+            Certainty:   "Observed",
+            Effective:   capTime(item.CreatedAt),
+            SenderName:  options.Title,
+            Headline:    item.Title,
+            Description: item.Description,
+            Area:        capArea{AreaDesc: item.Title},
+        },
+    }
+    if updated.After(item.CreatedAt) {
+        alert.MsgType = "Update"
+        alert.References = strings.Join([]string{
+            options.Publisher, capIdentifier(item.ID, item.CreatedAt), capTime(item.CreatedAt),
+        }, ",")
+    }
+    if item.ExpiresAt != nil {
+        alert.Info.Expires = capTime(*item.ExpiresAt)
+    }
+    if item.LatestUpdate != nil {
+        if alert.Info.Description != "" {
+            alert.Info.Description += "\n\n"
+        }
+        alert.Info.Description += item.LatestUpdate.Message
+    }
+    if ring := closedRing(item.Polygon); ring != nil {
+        points := make([]string, len(ring))
+        for j, point := range ring {
+            points[j] = formatLatLon(point.Latitude, point.Longitude, ",")
+        }
+        alert.Info.Area.Polygon = strings.Join(points, " ")
+    } else {
+        alert.Info.Area.Circle = formatLatLon(item.Latitude, item.Longitude, ",") + " " + formatFloat(item.Radius/1000)
+    }
+
+    return marshalXML(alert)
+}
+
+func capAlertURL(baseURL string, id int64) string {
+    return baseURL + FeedsPath + "cap/" + strconv.FormatInt(id, 10)
+}
+
+func capIdentifier(id int64, version time.Time) string {
+    return fmt.Sprintf("incident-%d-%d", id, version.UnixMicro())
+}
+
+func capTime(t time.Time) string {
+    return t.UTC().Format(capTimeLayout)
+}
+
+func atomTime(t time.Time) string {
+    return t.UTC().Format(time.RFC3339)
+}
+
+// closedRing возвращает контур с совпадающими первой и последней точками;
+// nil, если зона - круг
+func closedRing(polygon []models.GeoPoint) []models.GeoPoint {
+    if len(polygon) < 3 {
+        return nil
+    }
+    ring := append([]models.GeoPoint(nil), polygon...)
+    if ring[0] != ring[len(ring)-1] {
+        ring = append(ring, ring[0])
+    }
+    return ring
+}
+
+func formatLatLon(lat, lon float64, sep string) string {
+    return formatFloat(lat) + sep + formatFloat(lon)
+}
+
+func formatFloat(v float64) string {
+    return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func marshalXML(v interface{}) ([]byte, error) {
+    body, err := xml.MarshalIndent(v, "", "  ")
+    if err != nil {
+        return nil, err
+    }
+    return append([]byte(xml.Header), body...), nil
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"incident-system/internal/domain/models"
+	apperrors "incident-system/pkg/errors"
+	"incident-system/pkg/logger"
+)
+
+// FeedsPath - путь публичных лент; ссылки строятся как baseURL + FeedsPath + имя ленты
+const FeedsPath = "/api/v1/feeds/"
+
+// FeedOptions - оформление публичных лент
+type FeedOptions struct {
+    Title     string // заголовок ленты Atom
+    Publisher string // author ленты Atom и sender оповещений CAP
+    Language  string // язык оповещений CAP, например ru-RU
+    MaxItems  int    // 0 - без ограничения
+    BaseURL   string // внешний адрес сервиса; пустой - ссылки от корня сайта
+}
+
+// Feed - отрисованная лента с атрибутами для условных запросов
+type Feed struct {
+    Body         []byte
+    ContentType  string
+    ETag         string    // хеш тела
+    LastModified time.Time // не раньше последнего изменения, вошедшего в ленту
+}
+
+// FeedService отдает действующие инциденты в открытых форматах для СМИ и картографических
+// приложений. Инциденты берутся из кеша активных инцидентов; в ленты попадают только
+// публичные данные: без автора инцидента и внутренних сообщений хроники.
+type FeedService struct {
+    incidents *IncidentService
+    taxonomy  *TaxonomyService
+    options   FeedOptions
+    logger    *logger.Logger
+}
+
+func NewFeedService(incidents *IncidentService, taxonomy *TaxonomyService, options FeedOptions, logger *logger.Logger) *FeedService {
+    return &FeedService{
+        incidents: incidents,
+        taxonomy:  taxonomy,
+        options:   options,
+        logger:    logger,
+    }
+}
+
+// IncidentsFeed отрисовывает ленту действующих инцидентов в формате geojson или atom,
+// от последних изменений к ранним
+func (s *FeedService) IncidentsFeed(ctx context.Context, format string, filter models.FeedFilter) (*Feed, error) {
+    items, loadedAt, err := s.incidents.PublicIncidents(ctx, filter)
+    if err != nil {
+        return nil, fmt.Errorf("failed to load incidents: %w", err)
+    }
+
+    sort.SliceStable(items, func(i, j int) bool {
+        a, b := models.FeedItemUpdated(&items[i]), models.FeedItemUpdated(&items[j])
+        if !a.Equal(b) {
+            return a.After(b)
+        }
+        return items[i].ID > items[j].ID
+    })
+    if s.options.MaxItems > 0 && len(items) > s.options.MaxItems {
+        items = items[:s.options.MaxItems]
+    }
+
+    names := s.categoryNames(ctx)
+    baseURL := strings.TrimSuffix(s.options.BaseURL, "/")
+    var body []byte
+    var contentType string
+    switch format {
+    case models.FeedFormatGeoJSON:
+        body, err = encodeGeoJSONFeed(items, names, baseURL)
+        contentType = "application/geo+json"
+    case models.FeedFormatAtom:
+        body, err = encodeAtomFeed(items, names, s.options, baseURL)
+        contentType = "application/atom+xml; charset=utf-8"
+    default:
+        return nil, apperrors.NewValidationError(fmt.Errorf("unknown feed format %q", format))
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to encode %s feed: %w", format, err)
+    }
+
+    return newFeed(body, contentType, feedLastModified(items, loadedAt)), nil
+}
+
+// CAPAlert отрисовывает оповещение CAP 1.2 о действующем инциденте
+func (s *FeedService) CAPAlert(ctx context.Context, id int64) (*Feed, error) {
+    items, loadedAt, err := s.incidents.PublicIncidents(ctx, models.FeedFilter{IncidentID: id})
+    if err != nil {
+        return nil, fmt.Errorf("failed to load incidents: %w", err)
+    }
+    if len(items) == 0 {
+        return nil, apperrors.NewNotFoundError("Incident")
+    }
+
+    body, err := encodeCAPAlert(&items[0], s.categoryNames(ctx), s.options)
+    if err != nil {
+        return nil, fmt.Errorf("failed to encode CAP alert: %w", err)
+    }
+
+    return newFeed(body, "application/cap+xml; charset=utf-8", feedLastModified(items, loadedAt)), nil
+}
+
+// categoryNames возвращает названия категорий по кодам; без справочника в лентах остаются коды
+func (s *FeedService) categoryNames(ctx context.Context) map[string]string {
+    names := make(map[string]string)
+    categories, err := s.taxonomy.ListCategories(ctx)
+    if err != nil {
+        s.logger.Warn("Failed to load categories for feed: %v", err)
+        return names
+    }
+    for _, category := range categories {
+        names[category.Code] = category.Name
+    }
+    return names
+}
+
+// feedLastModified - момент загрузки снимка инцидентов или, если позже, последнего
+// сообщения хроники: сообщения не сбрасывают кеш инцидентов
+func feedLastModified(items []models.IncidentWithUpdate, loadedAt time.Time) time.Time {
+    lastModified := loadedAt
+    for i := range items {
+        if updated := models.FeedItemUpdated(&items[i]); updated.After(lastModified) {
+            lastModified = updated
+        }
+    }
+    return lastModified
+}
+
+func newFeed(body []byte, contentType string, lastModified time.Time) *Feed {
+    sum := sha256.Sum256(body)
+    return &Feed{
+        Body:         body,
+        ContentType:  contentType,
+        ETag:         `"` + hex.EncodeToString(sum[:16]) + `"`,
+        LastModified: lastModified,
+    }
+}
@@ -0,0 +1,443 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"incident-system/internal/domain/models"
+	"incident-system/pkg/geo"
+)
+
+// capNamespace - пространство имен XML оповещений CAP 1.2
+const capNamespace = "urn:oasis:names:tc:emergency:cap:1.2"
+
+// importEntry - инцидент документа или ошибка его разбора; ошибка одного инцидента
+// не мешает разобрать остальные
+type importEntry struct {
+    item models.ImportItem
+    err  error
+}
+
+type capAlertDocument struct {
+    XMLName    xml.Name
+    Identifier string            `xml:"identifier"`
+    Sender     string            `xml:"sender"`
+    Sent       string            `xml:"sent"`
+    Status     string            `xml:"status"`
+    MsgType    string            `xml:"msgType"`
+    References string            `xml:"references"`
+    Info       []capInfoDocument `xml:"info"`
+}
+
+type capInfoDocument struct {
+    Language    string            `xml:"language"`
+    Category    []string          `xml:"category"`
+    Event       string            `xml:"event"`
+    Severity    string            `xml:"severity"`
+    Effective   string            `xml:"effective"`
+    Expires     string            `xml:"expires"`
+    Headline    string            `xml:"headline"`
+    Description string            `xml:"description"`
+    Instruction string            `xml:"instruction"`
+    Area        []capAreaDocument `xml:"area"`
+}
+
+type capAreaDocument struct {
+    AreaDesc string   `xml:"areaDesc"`
+    Polygon  []string `xml:"polygon"`
+    Circle   []string `xml:"circle"`
+}
+
+// decodeCAPAlert разбирает оповещение CAP 1.2 в один инцидент. Внешний ID - отправитель
+// и идентификатор исходного оповещения: Update и Cancel ссылаются на него в references,
+// поэтому все сообщения об одном происшествии относятся к одному инциденту.
+// language - предпочтительный язык блока info; без совпадения берется первый.
+func decodeCAPAlert(data []byte, language string, now time.Time) ([]importEntry, error) {
+    var alert capAlertDocument
+    if err := xml.Unmarshal(data, &alert); err != nil {
+        return nil, fmt.Errorf("invalid CAP XML: %w", err)
+    }
+    if alert.XMLName.Space != capNamespace || alert.XMLName.Local != "alert" {
+        return nil, fmt.Errorf("document is not a CAP 1.2 alert")
+    }
+
+    alert.Identifier = strings.TrimSpace(alert.Identifier)
+    alert.Sender = strings.TrimSpace(alert.Sender)
+    if alert.Identifier == "" || alert.Sender == "" {
+        return nil, fmt.Errorf("CAP alert must have identifier and sender")
+    }
+    sent, err := parseCAPTime(alert.Sent)
+    if err != nil {
+        return nil, fmt.Errorf("invalid sent: %w", err)
+    }
+    // Учения, тесты и черновики не импортируются
+    if status := strings.TrimSpace(alert.Status); status != "Actual" {
+        return nil, fmt.Errorf("CAP alert with status %q is not imported", status)
+    }
+
+    entry := importEntry{item: models.ImportItem{ExternalID: models.ExternalIDFor(models.ImportFormatCAP, alert.Sender, alert.Identifier)}}
+    switch msgType := strings.TrimSpace(alert.MsgType); msgType {
+    case "Alert":
+    case "Update", "Cancel":
+        if sender, identifier, ok := firstCAPReference(alert.References); ok {
+            entry.item.ExternalID = models.ExternalIDFor(models.ImportFormatCAP, sender, identifier)
+        }
+        if msgType == "Cancel" {
+            entry.item.Cancel = true
+            return []importEntry{entry}, nil
+        }
+    default:
+        return nil, fmt.Errorf("CAP message type %q is not imported", msgType)
+    }
+
+    entry.item.Incident, entry.err = capIncident(&alert, language, sent, now)
+    return []importEntry{entry}, nil
+}
+
+func capIncident(alert *capAlertDocument, language string, sent, now time.Time) (models.CreateIncidentRequest, error) {
+    req := models.CreateIncidentRequest{UserID: alert.Sender}
+    if len(alert.Info) == 0 {
+        return req, fmt.Errorf("CAP alert has no info")
+    }
+    info := &alert.Info[0]
+    for i := range alert.Info {
+        if strings.EqualFold(strings.TrimSpace(alert.Info[i].Language), language) {
+            info = &alert.Info[i]
+            break
+        }
+    }
+
+    req.Title = strings.TrimSpace(info.Headline)
+    if req.Title == "" {
+        req.Title = strings.TrimSpace(info.Event)
+    }
+    req.Description = strings.TrimSpace(info.Description)
+    if instruction := strings.TrimSpace(info.Instruction); instruction != "" {
+        if req.Description != "" {
+            req.Description += "\n\n"
+        }
+        req.Description += instruction
+    }
+
+    req.Category = models.DefaultCategory
+    for _, category := range info.Category {
+        if mapped := models.CategoryFromCAP(strings.TrimSpace(category)); mapped != models.DefaultCategory {
+            req.Category = mapped
+            break
+        }
+    }
+    severity, ok := models.SeverityFromCAP(strings.TrimSpace(info.Severity))
+    if !ok {
+        return req, fmt.Errorf("unknown CAP severity %q", info.Severity)
+    }
+    req.Severity = severity
+
+    // Отложенные оповещения не поддерживаются: инцидент действует с момента создания
+    effective := sent
+    if info.Effective != "" {
+        t, err := parseCAPTime(info.Effective)
+        if err != nil {
+            return req, fmt.Errorf("invalid effective: %w", err)
+        }
+        effective = t
+    }
+    if effective.After(now) {
+        return req, fmt.Errorf("alert becomes effective at %s, scheduled alerts are not supported", effective.Format(time.RFC3339))
+    }
+    if info.Expires != "" {
+        expires, err := parseCAPTime(info.Expires)
+        if err != nil {
+            return req, fmt.Errorf("invalid expires: %w", err)
+        }
+        if !expires.After(effective) {
+            return req, fmt.Errorf("expires must be after effective")
+        }
+        req.ExpiresAt = &expires
+    }
+
+    var zones []geo.Zone
+    for _, area := range info.Area {
+        for _, polygon := range area.Polygon {
+            zone, err := parseCAPPolygon(polygon)
+            if err != nil {
+                return req, err
+            }
+            zones = append(zones, zone)
+        }
+        for _, circle := range area.Circle {
+            zone, err := parseCAPCircle(circle)
+            if err != nil {
+                return req, err
+            }
+            zones = append(zones, zone)
+        }
+    }
+    if len(zones) == 0 {
+        return req, fmt.Errorf("CAP alert has no polygon or circle area")
+    }
+    // Несколько областей покрываются одной зоной
+    zone := zones[0]
+    for _, other := range zones[1:] {
+        zone = geo.MergeZones(zone, other)
+    }
+    setImportZone(&req, zone)
+
+    return req, nil
+}
+
+// firstCAPReference возвращает отправителя и идентификатор первого оповещения из
+// references - списка "sender,identifier,sent" через пробел
+func firstCAPReference(references string) (string, string, bool) {
+    fields := strings.Fields(references)
+    if len(fields) == 0 {
+        return "", "", false
+    }
+    parts := strings.Split(fields[0], ",")
+    if len(parts) != 3 || parts[0] == "" || parts[1] == "" {
+        return "", "", false
+    }
+    return parts[0], parts[1], true
+}
+
+// parseCAPTime разбирает время CAP 1.2; смещение зоны обязательно
+func parseCAPTime(value string) (time.Time, error) {
+    return time.Parse(time.RFC3339, strings.TrimSpace(value))
+}
+
+// parseCAPPolygon разбирает контур "lat,lon lat,lon ..."; последняя точка совпадает с первой
+func parseCAPPolygon(value string) (geo.Zone, error) {
+    var points []geo.Point
+    for _, pair := range strings.Fields(value) {
+        point, err := parseCAPPoint(pair)
+        if err != nil {
+            return geo.Zone{}, fmt.Errorf("invalid polygon: %w", err)
+        }
+        points = append(points, point)
+    }
+    points = openRing(points)
+    if len(points) < 3 {
+        return geo.Zone{}, fmt.Errorf("polygon must have at least 3 vertices")
+    }
+    center := geo.Centroid(points)
+    return geo.Zone{
+        Center:   center,
+        RadiusKm: geo.CircumradiusKm(center.Latitude, center.Longitude, points),
+        Polygon:  points,
+    }, nil
+}
+
+// parseCAPCircle разбирает круг "lat,lon radius" с радиусом в километрах
+func parseCAPCircle(value string) (geo.Zone, error) {
+    fields := strings.Fields(value)
+    if len(fields) != 2 {
+        return geo.Zone{}, fmt.Errorf("invalid circle %q", value)
+    }
+    center, err := parseCAPPoint(fields[0])
+    if err != nil {
+        return geo.Zone{}, fmt.Errorf("invalid circle: %w", err)
+    }
+    radiusKm, err := strconv.ParseFloat(fields[1], 64)
+    if err != nil || radiusKm < 0 {
+        return geo.Zone{}, fmt.Errorf("invalid circle radius %q", fields[1])
+    }
+    return geo.Zone{Center: center, RadiusKm: radiusKm}, nil
+}
+
+func parseCAPPoint(pair string) (geo.Point, error) {
+    parts := strings.Split(pair, ",")
+    if len(parts) != 2 {
+        return geo.Point{}, fmt.Errorf("invalid point %q", pair)
+    }
+    lat, errLat := strconv.ParseFloat(parts[0], 64)
+    lon, errLon := strconv.ParseFloat(parts[1], 64)
+    if errLat != nil || errLon != nil {
+        return geo.Point{}, fmt.Errorf("invalid point %q", pair)
+    }
+    return geo.Point{Latitude: lat, Longitude: lon}, nil
+}
+
+// setImportZone переносит зону в запрос на создание. Круги меньше допустимого
+// расширяются до наименьшего радиуса, а больше - заменяются описанным многоугольником.
+func setImportZone(req *models.CreateIncidentRequest, zone geo.Zone) {
+    req.Latitude = zone.Center.Latitude
+    req.Longitude = zone.Center.Longitude
+    if len(zone.Polygon) >= 3 {
+        req.Polygon = zone.Polygon
+        return
+    }
+
+    radius := zone.RadiusKm * 1000
+    switch {
+    case radius < models.MinIncidentRadius:
+        radius = models.MinIncidentRadius
+    case radius > models.MaxIncidentRadius:
+        req.Polygon = geo.CirclePolygon(zone.Center, zone.RadiusKm, 32)
+        return
+    }
+    req.Radius = radius
+}
+
+// openRing убирает замыкающую точку контура
+func openRing(points []geo.Point) []geo.Point {
+    if len(points) > 1 && points[0] == points[len(points)-1] {
+        return points[:len(points)-1]
+    }
+    return points
+}
+
+type geoJSONImportDocument struct {
+    Type     string            `json:"type"`
+    Features []json.RawMessage `json:"features"`
+}
+
+type geoJSONImportFeature struct {
+    Type       string                  `json:"type"`
+    ID         json.RawMessage         `json:"id"`
+    Geometry   *geoJSONImportGeometry  `json:"geometry"`
+    Properties geoJSONImportProperties `json:"properties"`
+}
+
+type geoJSONImportGeometry struct {
+    Type        string          `json:"type"`
+    Coordinates json.RawMessage `json:"coordinates"`
+}
+
+// geoJSONImportProperties - свойства в формате ленты incidents.geojson, поэтому ее можно
+// импортировать в другой экземпляр сервиса
+type geoJSONImportProperties struct {
+    Title       string     `json:"title"`
+    Name        string     `json:"name"` // вместо title, как во многих редакторах карт
+    Description string     `json:"description"`
+    Severity    string     `json:"severity"`
+    Category    string     `json:"category"`
+    Tags        []string   `json:"tags"`
+    Radius      float64    `json:"radius"` // в метрах, для Point
+    ExpiresAt   *time.Time `json:"expires_at"`
+}
+
+// decodeGeoJSON разбирает FeatureCollection или отдельный Feature. Внешний ID - source
+// и id объекта; зона - Polygon (без вырезов) или Point с радиусом в свойстве radius.
+func decodeGeoJSON(data []byte, source string) ([]importEntry, error) {
+    var document geoJSONImportDocument
+    if err := json.Unmarshal(data, &document); err != nil {
+        return nil, fmt.Errorf("invalid GeoJSON: %w", err)
+    }
+
+    var features []json.RawMessage
+    switch document.Type {
+    case "FeatureCollection":
+        features = document.Features
+    case "Feature":
+        features = []json.RawMessage{data}
+    default:
+        return nil, fmt.Errorf("GeoJSON type must be FeatureCollection or Feature, got %q", document.Type)
+    }
+
+    entries := make([]importEntry, 0, len(features))
+    for _, raw := range features {
+        var entry importEntry
+        entry.item, entry.err = geoJSONItem(raw, source)
+        entries = append(entries, entry)
+    }
+    return entries, nil
+}
+
+func geoJSONItem(raw json.RawMessage, source string) (models.ImportItem, error) {
+    var item models.ImportItem
+    var feature geoJSONImportFeature
+    if err := json.Unmarshal(raw, &feature); err != nil {
+        return item, fmt.Errorf("invalid feature: %w", err)
+    }
+    if feature.Type != "Feature" {
+        return item, fmt.Errorf("expected Feature, got %q", feature.Type)
+    }
+
+    id, err := geoJSONFeatureID(feature.ID)
+    if err != nil {
+        return item, err
+    }
+    item.ExternalID = models.ExternalIDFor(models.ImportFormatGeoJSON, source, id)
+
+    properties := feature.Properties
+    item.Incident = models.CreateIncidentRequest{
+        UserID:      source,
+        Title:       strings.TrimSpace(properties.Title),
+        Description: strings.TrimSpace(properties.Description),
+        Severity:    properties.Severity,
+        Category:    properties.Category,
+        Tags:        properties.Tags,
+        ExpiresAt:   properties.ExpiresAt,
+    }
+    if item.Incident.Title == "" {
+        item.Incident.Title = strings.TrimSpace(properties.Name)
+    }
+
+    if feature.Geometry == nil {
+        return item, fmt.Errorf("feature %s has no geometry", id)
+    }
+    switch feature.Geometry.Type {
+    case "Point":
+        var coordinates []float64
+        if err := json.Unmarshal(feature.Geometry.Coordinates, &coordinates); err != nil || len(coordinates) < 2 {
+            return item, fmt.Errorf("invalid Point coordinates")
+        }
+        item.Incident.Longitude, item.Incident.Latitude = coordinates[0], coordinates[1]
+        item.Incident.Radius = properties.Radius
+    case "Polygon":
+        var rings [][][]float64
+        if err := json.Unmarshal(feature.Geometry.Coordinates, &rings); err != nil || len(rings) == 0 {
+            return item, fmt.Errorf("invalid Polygon coordinates")
+        }
+        if len(rings) > 1 {
+            return item, fmt.Errorf("polygons with holes are not supported")
+        }
+        var points []geo.Point
+        for _, position := range rings[0] {
+            if len(position) < 2 {
+                return item, fmt.Errorf("invalid Polygon coordinates")
+            }
+            points = append(points, geo.Point{Latitude: position[1], Longitude: position[0]})
+        }
+        points = openRing(points)
+        if len(points) < 3 {
+            return item, fmt.Errorf("polygon must have at least 3 vertices")
+        }
+        center := geo.Centroid(points)
+        item.Incident.Latitude, item.Incident.Longitude = center.Latitude, center.Longitude
+        item.Incident.Polygon = points
+    default:
+        return item, fmt.Errorf("geometry type %q is not supported, use Point or Polygon", feature.Geometry.Type)
+    }
+
+    return item, nil
+}
+
+// geoJSONFeatureID возвращает id объекта - строку или число
+func geoJSONFeatureID(raw json.RawMessage) (string, error) {
+    raw = bytes.TrimSpace(raw)
+    if len(raw) == 0 || string(raw) == "null" {
+        return "", fmt.Errorf("feature id is required")
+    }
+
+    var id string
+    if raw[0] == '"' {
+        if err := json.Unmarshal(raw, &id); err != nil {
+            return "", fmt.Errorf("invalid feature id: %w", err)
+        }
+    } else {
+        var number json.Number
+        if err := json.Unmarshal(raw, &number); err != nil {
+            return "", fmt.Errorf("feature id must be a string or a number")
+        }
+        id = number.String()
+    }
+    if strings.TrimSpace(id) == "" {
+        return "", fmt.Errorf("feature id is required")
+    }
+    return id, nil
+}
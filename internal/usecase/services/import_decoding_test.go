@@ -0,0 +1,133 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"incident-system/internal/domain/models"
+)
+
+// capAlertXML собирает оповещение CAP 1.2 с одним блоком info
+func capAlertXML(msgType, references, info string) []byte {
+    return []byte(fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<alert xmlns="urn:oasis:names:tc:emergency:cap:1.2">
+  <identifier>ALERT-2</identifier>
+  <sender>mchs@example.ru</sender>
+  <sent>2026-06-01T10:00:00+03:00</sent>
+  <status>Actual</status>
+  <msgType>%s</msgType>
+  <references>%s</references>
+  <info>%s</info>
+</alert>`, msgType, references, info))
+}
+
+// decodeCAPInfo разбирает оповещение Alert и возвращает его единственный инцидент
+func decodeCAPInfo(t *testing.T, info string) importEntry {
+    t.Helper()
+    entries, err := decodeCAPAlert(capAlertXML("Alert", "", info), "ru-RU", time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC))
+    if err != nil || len(entries) != 1 {
+        t.Fatalf("decodeCAPAlert = %+v, %v", entries, err)
+    }
+    return entries[0]
+}
+
+func TestDecodeCAPCircle(t *testing.T) {
+    entry := decodeCAPInfo(t, `
+    <category>Fire</category>
+    <event>Пожар</event>
+    <severity>Severe</severity>
+    <expires>2026-06-02T10:00:00+03:00</expires>
+    <area><areaDesc>Склад</areaDesc><circle>55.7558,37.6173 2.5</circle></area>`)
+    if entry.err != nil {
+        t.Fatalf("decodeCAPAlert: %v", entry.err)
+    }
+
+    req := entry.item.Incident
+    if entry.item.ExternalID != "cap:mchs@example.ru/ALERT-2" || req.Title != "Пожар" || req.Category != "fire" || req.Severity != "high" {
+        t.Fatalf("incident = %+v (%s), want the fire alert", req, entry.item.ExternalID)
+    }
+    if req.Latitude != 55.7558 || req.Longitude != 37.6173 || req.Radius != 2500 || req.Polygon != nil {
+        t.Fatalf("zone = %v,%v r=%v polygon=%v, want a 2.5 km circle", req.Latitude, req.Longitude, req.Radius, req.Polygon)
+    }
+    if req.ExpiresAt == nil || !req.ExpiresAt.Equal(time.Date(2026, 6, 2, 7, 0, 0, 0, time.UTC)) {
+        t.Fatalf("ExpiresAt = %v", req.ExpiresAt)
+    }
+}
+
+func TestDecodeCAPSmallAndLargeCircles(t *testing.T) {
+    small := decodeCAPInfo(t, `<event>Утечка газа</event><severity>Minor</severity>
+    <area><areaDesc>Подъезд</areaDesc><circle>55.7558,37.6173 0</circle></area>`)
+    if small.err != nil || small.item.Incident.Radius != models.MinIncidentRadius {
+        t.Fatalf("small circle = %+v, %v; want the minimum radius", small.item.Incident, small.err)
+    }
+
+    large := decodeCAPInfo(t, `<event>Паводок</event><severity>Extreme</severity>
+    <area><areaDesc>Область</areaDesc><circle>55.7558,37.6173 500</circle></area>`)
+    if large.err != nil || large.item.Incident.Radius != 0 || len(large.item.Incident.Polygon) != 32 {
+        t.Fatalf("large circle = %+v, %v; want a circumscribed polygon", large.item.Incident, large.err)
+    }
+}
+
+func TestDecodeCAPPolygon(t *testing.T) {
+    entry := decodeCAPInfo(t, `
+    <event>Паводок</event>
+    <severity>Moderate</severity>
+    <area>
+      <areaDesc>Пойма</areaDesc>
+      <polygon>55.70,37.60 55.70,37.70 55.80,37.70 55.80,37.60 55.70,37.60</polygon>
+    </area>`)
+    if entry.err != nil {
+        t.Fatalf("decodeCAPAlert: %v", entry.err)
+    }
+
+    req := entry.item.Incident
+    if len(req.Polygon) != 4 || req.Polygon[0] != (models.GeoPoint{Latitude: 55.70, Longitude: 37.60}) {
+        t.Fatalf("polygon = %v, want 4 vertices without the closing point", req.Polygon)
+    }
+    if req.Latitude < 55.70 || req.Latitude > 55.80 || req.Longitude < 37.60 || req.Longitude > 37.70 {
+        t.Fatalf("center = %v,%v outside the polygon", req.Latitude, req.Longitude)
+    }
+}
+
+func TestDecodeCAPRejectsInvalidAreasAndTimes(t *testing.T) {
+    for name, tc := range map[string]struct {
+        info string
+        want string
+    }{
+        "no area": {`<event>Пожар</event><severity>Severe</severity>`, "no polygon or circle"},
+        "short polygon": {`<event>Пожар</event><severity>Severe</severity>
+            <area><polygon>55.70,37.60 55.70,37.70 55.70,37.60</polygon></area>`, "at least 3 vertices"},
+        "bad circle": {`<event>Пожар</event><severity>Severe</severity>
+            <area><circle>55.70,37.60 -1</circle></area>`, "invalid circle radius"},
+        "expires without zone": {`<event>Пожар</event><severity>Severe</severity>
+            <expires>2026-06-02T10:00:00</expires><area><circle>55.70,37.60 1</circle></area>`, "invalid expires"},
+        "expires before effective": {`<event>Пожар</event><severity>Severe</severity>
+            <effective>2026-06-01T10:00:00+03:00</effective><expires>2026-06-01T09:00:00+03:00</expires>
+            <area><circle>55.70,37.60 1</circle></area>`, "expires must be after effective"},
+        "scheduled": {`<event>Пожар</event><severity>Severe</severity>
+            <effective>2026-06-03T10:00:00+03:00</effective><area><circle>55.70,37.60 1</circle></area>`, "scheduled alerts"},
+    } {
+        entry := decodeCAPInfo(t, tc.info)
+        if entry.err == nil || !strings.Contains(entry.err.Error(), tc.want) {
+            t.Fatalf("%s: err = %v, want %q", name, entry.err, tc.want)
+        }
+    }
+}
+
+func TestDecodeCAPUpdateAndCancelReferenceOriginalAlert(t *testing.T) {
+    now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+    references := "mchs@example.ru,ALERT-1,2026-06-01T09:00:00+03:00"
+
+    entries, err := decodeCAPAlert(capAlertXML("Update", references, `<event>Пожар</event><severity>Severe</severity>
+        <area><circle>55.70,37.60 1</circle></area>`), "", now)
+    if err != nil || len(entries) != 1 || entries[0].err != nil || entries[0].item.ExternalID != "cap:mchs@example.ru/ALERT-1" {
+        t.Fatalf("Update = %+v, %v; want the original alert's external ID", entries, err)
+    }
+
+    entries, err = decodeCAPAlert(capAlertXML("Cancel", references, ""), "", now)
+    if err != nil || len(entries) != 1 || !entries[0].item.Cancel || entries[0].item.ExternalID != "cap:mchs@example.ru/ALERT-1" {
+        t.Fatalf("Cancel = %+v, %v", entries, err)
+    }
+}
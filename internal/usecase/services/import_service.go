@@ -0,0 +1,137 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"incident-system/internal/domain/models"
+	apperrors "incident-system/pkg/errors"
+	"incident-system/pkg/logger"
+)
+
+// ImportOptions - ограничения импорта
+type ImportOptions struct {
+    MaxSize  int64  // наибольший размер документа в байтах
+    Language string // предпочтительный язык блока info оповещений CAP
+}
+
+// ImportService переносит в систему оповещения CAP и карты зон в GeoJSON других
+// ведомств. Инциденты сопоставляются по внешнему ID, поэтому повторный импорт того же
+// документа обновляет инциденты, а не создает новые.
+type ImportService struct {
+    incidents *IncidentService
+    options   ImportOptions
+    logger    *logger.Logger
+}
+
+func NewImportService(incidents *IncidentService, options ImportOptions, logger *logger.Logger) *ImportService {
+    return &ImportService{
+        incidents: incidents,
+        options:   options,
+        logger:    logger,
+    }
+}
+
+// Import разбирает документ и создает или обновляет его инциденты. Сначала проверяются
+// все инциденты: если хотя бы один не прошел проверку, ничего не записывается и ошибки
+// возвращаются в результате. dryRun только проверяет документ и определяет действия.
+// source - пространство имен внешних ID для GeoJSON; для CAP им служит отправитель.
+func (s *ImportService) Import(ctx context.Context, format string, r io.Reader, source string, dryRun bool) (*models.ImportResult, error) {
+    data, err := io.ReadAll(io.LimitReader(r, s.options.MaxSize+1))
+    if err != nil {
+        return nil, apperrors.NewValidationError(fmt.Errorf("failed to read document: %w", err))
+    }
+    if int64(len(data)) > s.options.MaxSize {
+        return nil, apperrors.NewPayloadTooLargeError(fmt.Sprintf("document exceeds %d bytes", s.options.MaxSize))
+    }
+
+    var entries []importEntry
+    switch format {
+    case models.ImportFormatCAP:
+        entries, err = decodeCAPAlert(data, s.options.Language, time.Now())
+    case models.ImportFormatGeoJSON:
+        source = strings.TrimSpace(source)
+        if source == "" {
+            return nil, apperrors.NewValidationError(fmt.Errorf("source is required for GeoJSON import"))
+        }
+        entries, err = decodeGeoJSON(data, source)
+    default:
+        return nil, apperrors.NewValidationError(fmt.Errorf("unknown import format %q", format))
+    }
+    if err != nil {
+        return nil, apperrors.NewValidationError(err)
+    }
+    if len(entries) == 0 {
+        return nil, apperrors.NewValidationError(fmt.Errorf("document has no incidents"))
+    }
+    if len(entries) > models.MaxImportItems {
+        return nil, apperrors.NewValidationError(fmt.Errorf("document has %d incidents, at most %d are imported at once", len(entries), models.MaxImportItems))
+    }
+
+    planned := &models.ImportResult{Format: format, DryRun: dryRun}
+    seen := make(map[string]int, len(entries))
+    for i, entry := range entries {
+        item, err := s.plan(ctx, entry, seen)
+        if err != nil {
+            return nil, err
+        }
+        item.Index = i
+        seen[entry.item.ExternalID] = i
+        planned.Count(item)
+    }
+    if dryRun || planned.Failed > 0 {
+        return planned, nil
+    }
+
+    // Запись после проверки всего документа. Сбой посреди записи оставляет часть
+    // инцидентов импортированной; повторный импорт того же документа ее не задвоит.
+    result := &models.ImportResult{Format: format}
+    for i, entry := range entries {
+        item, err := s.incidents.ImportIncident(ctx, entry.item, false)
+        if err != nil {
+            return nil, fmt.Errorf("failed to import %s: %w", entry.item.ExternalID, err)
+        }
+        item.Index = i
+        result.Count(item)
+    }
+
+    s.logger.Info("Imported %s document: %d created, %d updated, %d unchanged, %d cancelled",
+        format, result.Created, result.Updated, result.Unchanged, result.Cancelled)
+    return result, nil
+}
+
+// plan проверяет инцидент документа; ошибки данных попадают в результат, а ошибкой
+// возвращаются только сбои хранилища
+func (s *ImportService) plan(ctx context.Context, entry importEntry, seen map[string]int) (models.ImportItemResult, error) {
+    fail := func(err error) (models.ImportItemResult, error) {
+        return models.ImportItemResult{ExternalID: entry.item.ExternalID, Action: models.ImportActionError, Error: err.Error()}, nil
+    }
+
+    if entry.err != nil {
+        return fail(entry.err)
+    }
+    if err := entry.item.Validate(); err != nil {
+        return fail(err)
+    }
+    if previous, ok := seen[entry.item.ExternalID]; ok {
+        return fail(fmt.Errorf("external id repeats item %d", previous))
+    }
+
+    item, err := s.incidents.ImportIncident(ctx, entry.item, true)
+    if err != nil {
+        appErr := apperrors.FromError(err)
+        if appErr.Code >= 500 {
+            return item, err
+        }
+        message := appErr.Message
+        if appErr.Details != "" {
+            message = appErr.Details
+        }
+        return fail(errors.New(message))
+    }
+    return item, nil
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"incident-system/internal/domain/repositories"
+	"incident-system/pkg/logger"
+)
+
+// IncidentExpirer периодически деактивирует инциденты с истекшим expires_at.
+// События incident.expired записываются репозиторием в outbox в той же
+// транзакции и доставляются релеем, поэтому несколько экземпляров сервиса
+// могут работать одновременно: каждый инцидент истекает ровно один раз.
+type IncidentExpirer struct {
+    incidentRepo repositories.IncidentRepository
+    cacheRepo    repositories.CacheRepository
+    interval     time.Duration
+    logger       *logger.Logger
+}
+
+func NewIncidentExpirer(
+    incidentRepo repositories.IncidentRepository,
+    cacheRepo repositories.CacheRepository,
+    interval time.Duration,
+    logger *logger.Logger,
+) *IncidentExpirer {
+    return &IncidentExpirer{
+        incidentRepo: incidentRepo,
+        cacheRepo:    cacheRepo,
+        interval:     interval,
+        logger:       logger,
+    }
+}
+
+func (e *IncidentExpirer) Start(ctx context.Context) {
+    go func() {
+        ticker := time.NewTicker(e.interval)
+        defer ticker.Stop()
+
+        for {
+            select {
+            case <-ctx.Done():
+                e.logger.Info("Incident expirer stopped")
+                return
+            case <-ticker.C:
+                if _, err := e.ExpireDue(ctx, time.Now()); err != nil {
+                    e.logger.Error("Incident expiry failed: %v", err)
+                }
+            }
+        }
+    }()
+}
+
+// ExpireDue деактивирует инциденты, срок которых истек к now, и возвращает их число
+func (e *IncidentExpirer) ExpireDue(ctx context.Context, now time.Time) (int, error) {
+    expired, err := e.incidentRepo.ExpireDue(ctx, now)
+    if err != nil {
+        return 0, err
+    }
+    if len(expired) == 0 {
+        return 0, nil
+    }
+
+    for _, incident := range expired {
+        e.logger.Info("Incident %d expired at %s", incident.ID, incident.ExpiresAt.Format(time.RFC3339))
+    }
+
+    // Сбрасываем кеш сразу; при сбое Redis его гарантированно сбросит релей outbox
+    _ = e.cacheRepo.InvalidateActiveIncidents(ctx)
+
+    return len(expired), nil
+}
@@ -2,33 +2,67 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"math"
+	"strings"
 	"time"
 
 	"incident-system/internal/domain/models"
 	"incident-system/internal/domain/repositories"
+	apperrors "incident-system/pkg/errors"
+	"incident-system/pkg/geo"
 )
 
 type IncidentService struct {
     incidentRepo repositories.IncidentRepository
     cacheRepo    repositories.CacheRepository
     queueRepo    repositories.QueueRepository
+    alerter      *ProactiveAlerter
+    preferences  *NotificationPreferencesService
+    taxonomy     *TaxonomyService
+    activeLoader *activeIncidentsLoader
 }
 
 func NewIncidentService(
     incidentRepo repositories.IncidentRepository,
     cacheRepo repositories.CacheRepository,
     queueRepo repositories.QueueRepository,
+    alerter *ProactiveAlerter,
+    preferences *NotificationPreferencesService,
+    taxonomy *TaxonomyService,
 ) *IncidentService {
     return &IncidentService{
         incidentRepo: incidentRepo,
         cacheRepo:    cacheRepo,
         queueRepo:    queueRepo,
+        alerter:      alerter,
+        preferences:  preferences,
+        taxonomy:     taxonomy,
+        activeLoader: newActiveIncidentsLoader(incidentRepo, cacheRepo),
     }
 }
 
 func (s *IncidentService) CreateIncident(ctx context.Context, req models.CreateIncidentRequest) (*models.Incident, error) {
+    incident, err := s.newIncident(ctx, req)
+    if err != nil {
+        return nil, err
+    }
+    
+    if err := s.createIncident(ctx, incident); err != nil {
+        return nil, fmt.Errorf("failed to create incident: %w", err)
+    }
+    
+    return incident, nil
+}
+
+// newIncident проверяет запрос на создание и строит по нему инцидент
+func (s *IncidentService) newIncident(ctx context.Context, req models.CreateIncidentRequest) (*models.Incident, error) {
+    // Общая проверка для POST /incidents, пакетов и импорта: привязка запроса
+    // проверяет не все ограничения
+    if err := req.Validate(); err != nil {
+        return nil, apperrors.NewValidationError(err)
+    }
+    
     incident := &models.Incident{
         UserID:      req.UserID,
         Latitude:    req.Latitude,
@@ -36,31 +70,81 @@ func (s *IncidentService) CreateIncident(ctx context.Context, req models.CreateI
         Title:       req.Title,
         Description: req.Description,
         Severity:    req.Severity,
+        Category:    req.Category,
         Radius:      req.Radius,
         Active:      true,
+        Public:      req.Public,
+        ExpiresAt:   req.ExpiresAt,
+    }
+    if incident.Category == "" {
+        incident.Category = models.DefaultCategory
+    }
+    
+    tags, err := s.validateClassification(ctx, incident.Severity, incident.Category, req.Tags)
+    if err != nil {
+        return nil, err
     }
+    incident.Tags = tags
     
+    if incident.Expired(time.Now()) {
+        return nil, apperrors.NewValidationError(fmt.Errorf("expires_at must be in the future"))
+    }
+    
+    if len(req.Polygon) > 0 {
+        incident.Polygon = req.Polygon
+        // Для зоны-многоугольника радиус - это радиус описанной окружности вокруг центра
+        incident.Radius = geo.CircumradiusKm(req.Latitude, req.Longitude, req.Polygon) * 1000
+    }
+    
+    return incident, nil
+}
+
+func (s *IncidentService) createIncident(ctx context.Context, incident *models.Incident) error {
     if err := s.incidentRepo.Create(ctx, incident); err != nil {
-        return nil, fmt.Errorf("failed to create incident: %w", err)
+        return err
     }
     
-    // Инвалидируем кеш активных инцидентов
+    // Сбрасываем кеш сразу; при сбое Redis его гарантированно сбросит релей outbox
     _ = s.cacheRepo.InvalidateActiveIncidents(ctx)
     
-    return incident, nil
+    // Пользователи, уже находящиеся в зоне, оповещаются без ожидания новой проверки
+    if s.alerter != nil {
+        s.alerter.AlertAsync(ctx, incident)
+    }
+    
+    return nil
 }
 
 func (s *IncidentService) GetIncident(ctx context.Context, id int64) (*models.Incident, error) {
     return s.incidentRepo.FindByID(ctx, id)
 }
 
-func (s *IncidentService) ListIncidents(ctx context.Context, limit, offset int, activeOnly bool) ([]*models.Incident, int, error) {
-    incidents, err := s.incidentRepo.FindAll(ctx, limit, offset, activeOnly)
+// validateClassification проверяет уровень опасности и категорию по справочникам
+// и возвращает нормализованные теги
+func (s *IncidentService) validateClassification(ctx context.Context, severity, category string, tags []string) ([]string, error) {
+    if !models.ValidSeverity(severity) {
+        return nil, apperrors.NewValidationError(fmt.Errorf("unknown severity %q", severity))
+    }
+    if s.taxonomy != nil {
+        if err := s.taxonomy.ValidateCategory(ctx, category); err != nil {
+            return nil, err
+        }
+    }
+    
+    normalized, err := models.NormalizeTags(tags)
+    if err != nil {
+        return nil, apperrors.NewValidationError(err)
+    }
+    return normalized, nil
+}
+
+func (s *IncidentService) ListIncidents(ctx context.Context, filter models.IncidentFilter) ([]*models.Incident, int, error) {
+    incidents, err := s.incidentRepo.FindAll(ctx, filter)
     if err != nil {
         return nil, 0, err
     }
     
-    total, err := s.incidentRepo.CountAll(ctx, activeOnly)
+    total, err := s.incidentRepo.CountAll(ctx, filter)
     if err != nil {
         return nil, 0, err
     }
@@ -68,16 +152,72 @@ func (s *IncidentService) ListIncidents(ctx context.Context, limit, offset int,
     return incidents, total, nil
 }
 
-func (s *IncidentService) UpdateIncident(ctx context.Context, id int64, req models.UpdateIncidentRequest) (*models.Incident, error) {
-    incident, err := s.incidentRepo.FindByID(ctx, id)
-    if err != nil {
-        return nil, fmt.Errorf("failed to find incident: %w", err)
+// maxUpdateAttempts - сколько раз изменение без If-Match повторяется на свежей версии
+// инцидента, если его успели изменить между чтением и записью
+const maxUpdateAttempts = 3
+
+// UpdateIncident изменяет переданные поля инцидента. С заголовком If-Match (ifMatch.Present)
+// инцидент изменяется, только если его версия подходит, иначе - ошибка 412.
+func (s *IncidentService) UpdateIncident(ctx context.Context, id int64, req models.UpdateIncidentRequest, ifMatch models.IfMatch) (*models.Incident, error) {
+    if err := req.Validate(); err != nil {
+        return nil, apperrors.NewValidationError(err)
     }
     
-    if incident == nil {
-        return nil, fmt.Errorf("incident not found")
+    for attempt := 1; ; attempt++ {
+        incident, err := s.incidentRepo.FindByID(ctx, id)
+        if err != nil {
+            return nil, fmt.Errorf("failed to find incident: %w", err)
+        }
+        
+        if incident == nil {
+            return nil, apperrors.NewNotFoundError("Incident")
+        }
+        if incident.MergedInto != nil {
+            return nil, apperrors.NewConflictError(fmt.Sprintf("incident %d has been merged into %d", id, *incident.MergedInto))
+        }
+        if !ifMatch.Matches(incident.Version) {
+            return nil, versionMismatch(incident.Version)
+        }
+        
+        before := *incident
+        if err := s.applyUpdate(ctx, incident, req); err != nil {
+            return nil, err
+        }
+        
+        err = s.incidentRepo.Update(ctx, incident)
+        if errors.Is(err, repositories.ErrVersionConflict) {
+            if ifMatch.Present {
+                return nil, apperrors.NewPreconditionFailedError("incident has been modified concurrently")
+            }
+            if attempt < maxUpdateAttempts {
+                continue
+            }
+            return nil, apperrors.NewConflictError("incident is being modified concurrently, retry the request")
+        }
+        if err != nil {
+            return nil, fmt.Errorf("failed to update incident: %w", err)
+        }
+        
+        // Сбрасываем кеш сразу; при сбое Redis его гарантированно сбросит релей outbox
+        _ = s.cacheRepo.InvalidateActiveIncidents(ctx)
+        
+        // Перемещенная или расширенная зона могла накрыть новых пользователей;
+        // оповещенные ранее пропускаются
+        if s.alerter != nil && incident.Active && zoneChanged(models.ChangedFields(&before, incident)) {
+            s.alerter.AlertAsync(ctx, incident)
+        }
+        
+        return incident, nil
     }
-    
+}
+
+// versionMismatch - ответ 412 на If-Match, не подходящий к текущей версии инцидента
+func versionMismatch(version int64) error {
+    return apperrors.NewPreconditionFailedError(fmt.Sprintf("incident has been modified, current ETag is %s", models.IncidentETag(version)))
+}
+
+// applyUpdate переносит в инцидент переданные поля запроса и проверяет результат
+func (s *IncidentService) applyUpdate(ctx context.Context, incident *models.Incident, req models.UpdateIncidentRequest) error {
     // Обновляем только переданные поля
     if req.Title != nil {
         incident.Title = *req.Title
@@ -88,97 +228,457 @@ func (s *IncidentService) UpdateIncident(ctx context.Context, id int64, req mode
     if req.Severity != nil {
         incident.Severity = *req.Severity
     }
-    if req.Radius != nil {
-        incident.Radius = *req.Radius
+    if req.Category != nil {
+        incident.Category = *req.Category
+    }
+    tags := incident.Tags
+    if req.Tags != nil {
+        tags = *req.Tags
+    }
+    if err := applyGeometry(incident, req); err != nil {
+        return err
     }
     if req.Active != nil {
         incident.Active = *req.Active
     }
+    if req.Public != nil {
+        incident.Public = *req.Public
+    }
+    if req.ExpiresAt != nil {
+        incident.ExpiresAt = req.ExpiresAt
+    }
+    if req.ClearExpiresAt {
+        incident.ExpiresAt = nil
+    }
     
-    if err := s.incidentRepo.Update(ctx, incident); err != nil {
-        return nil, fmt.Errorf("failed to update incident: %w", err)
+    if req.Severity != nil || req.Category != nil || req.Tags != nil {
+        var err error
+        if incident.Tags, err = s.validateClassification(ctx, incident.Severity, incident.Category, tags); err != nil {
+            return err
+        }
     }
     
-    // Инвалидируем кеш активных инцидентов
-    _ = s.cacheRepo.InvalidateActiveIncidents(ctx)
+    // Активным может остаться только инцидент, срок которого не истек
+    if incident.Active && incident.Expired(time.Now()) {
+        return apperrors.NewValidationError(fmt.Errorf("expires_at must be in the future"))
+    }
+    return nil
+}
+
+// applyGeometry переносит в инцидент переданные центр, радиус и контур зоны
+func applyGeometry(incident *models.Incident, req models.UpdateIncidentRequest) error {
+    if req.Latitude == nil && req.Longitude == nil && req.Radius == nil && req.Polygon == nil {
+        return nil
+    }
     
-    return incident, nil
+    if req.Latitude != nil {
+        incident.Latitude = *req.Latitude
+    }
+    if req.Longitude != nil {
+        incident.Longitude = *req.Longitude
+    }
+    if req.Radius != nil {
+        incident.Radius = *req.Radius
+    }
+    if req.Polygon != nil {
+        // Радиус описанной окружности контура не подходит кругу, поэтому его задают явно
+        if len(*req.Polygon) == 0 && len(incident.Polygon) > 0 && req.Radius == nil {
+            return apperrors.NewValidationError(fmt.Errorf("radius is required when the polygon is removed"))
+        }
+        incident.Polygon = nil
+        if len(*req.Polygon) > 0 {
+            incident.Polygon = *req.Polygon
+        }
+    }
+    
+    if len(incident.Polygon) > 0 {
+        // Для зоны-многоугольника радиус - это радиус описанной окружности вокруг центра
+        incident.Radius = geo.CircumradiusKm(incident.Latitude, incident.Longitude, incident.Polygon) * 1000
+    }
+    return nil
 }
 
-func (s *IncidentService) DeleteIncident(ctx context.Context, id int64) error {
-    if err := s.incidentRepo.Delete(ctx, id); err != nil {
+// DeleteIncident деактивирует инцидент; удаление несуществующего инцидента успешно.
+// С заголовком If-Match инцидент должен существовать и иметь подходящую версию.
+func (s *IncidentService) DeleteIncident(ctx context.Context, id int64, ifMatch models.IfMatch) error {
+    var version int64
+    if ifMatch.Present {
+        incident, err := s.incidentRepo.FindByID(ctx, id)
+        if err != nil {
+            return fmt.Errorf("failed to find incident: %w", err)
+        }
+        if incident == nil {
+            return apperrors.NewPreconditionFailedError("incident does not exist")
+        }
+        if !ifMatch.Matches(incident.Version) {
+            return versionMismatch(incident.Version)
+        }
+        version = incident.Version
+    }
+    
+    err := s.incidentRepo.Delete(ctx, id, version)
+    if errors.Is(err, repositories.ErrVersionConflict) {
+        return apperrors.NewPreconditionFailedError("incident has been modified concurrently")
+    }
+    if err != nil {
         return fmt.Errorf("failed to delete incident: %w", err)
     }
     
-    // Инвалидируем кеш активных инцидентов
+    // Сбрасываем кеш сразу; при сбое Redis его гарантированно сбросит релей outbox
     _ = s.cacheRepo.InvalidateActiveIncidents(ctx)
     
     return nil
 }
 
-func (s *IncidentService) CheckLocation(ctx context.Context, req models.LocationCheckRequest) (*models.LocationCheckResponse, error) {
-    // Сначала пытаемся получить активные инциденты из кеша
-    incidents, err := s.cacheRepo.GetActiveIncidents(ctx)
+// applyBatch записывает изменения одной транзакцией и сбрасывает кеш один раз на пакет;
+// alert - инциденты пакета, о которых нужно оповестить пользователей в зоне
+func (s *IncidentService) applyBatch(ctx context.Context, batchID string, changes []models.IncidentChange, alert []*models.Incident) error {
+    if err := s.incidentRepo.ApplyBatch(ctx, batchID, changes); err != nil {
+        return err
+    }
+    
+    // Сбрасываем кеш сразу; при сбое Redis его гарантированно сбросит релей outbox
+    _ = s.cacheRepo.InvalidateActiveIncidents(ctx)
+    
+    if s.alerter != nil {
+        for _, incident := range alert {
+            s.alerter.AlertAsync(ctx, incident)
+        }
+    }
+    
+    return nil
+}
+
+// MergeIncidents присоединяет дубликат sourceID к инциденту targetID (см. models.MergeIncidents);
+// запросы по старому ID перенаправляются на targetID
+func (s *IncidentService) MergeIncidents(ctx context.Context, sourceID, targetID int64) (*models.Incident, error) {
+    if targetID <= 0 {
+        return nil, apperrors.NewValidationError(fmt.Errorf("target_id is required"))
+    }
+    if sourceID == targetID {
+        return nil, apperrors.NewValidationError(fmt.Errorf("incident cannot be merged into itself"))
+    }
+    
+    var incidents [2]*models.Incident
+    for i, id := range []int64{sourceID, targetID} {
+        incident, err := s.incidentRepo.FindByID(ctx, id)
+        if err != nil {
+            return nil, fmt.Errorf("failed to find incident: %w", err)
+        }
+        if incident == nil {
+            return nil, apperrors.NewNotFoundError("Incident")
+        }
+        if incident.MergedInto != nil {
+            return nil, apperrors.NewConflictError(fmt.Sprintf("incident %d has already been merged into %d", id, *incident.MergedInto))
+        }
+        incidents[i] = incident
+    }
+    source, target := incidents[0], incidents[1]
+    
+    merged := models.MergeIncidents(target, source)
+    ok, err := s.incidentRepo.Merge(ctx, source, merged)
     if err != nil {
-        return nil, fmt.Errorf("failed to get cached incidents: %w", err)
+        return nil, fmt.Errorf("failed to merge incidents: %w", err)
+    }
+    if !ok {
+        return nil, apperrors.NewConflictError(fmt.Sprintf("incident %d or %d has been merged concurrently", sourceID, targetID))
+    }
+    
+    // Сбрасываем кеш сразу; при сбое Redis его гарантированно сбросит релей outbox
+    _ = s.cacheRepo.InvalidateActiveIncidents(ctx)
+    
+    // Объединенная зона могла накрыть новых пользователей; оповещенные о любом
+    // из двух инцидентов пропускаются
+    if s.alerter != nil && merged.Active {
+        s.alerter.AlertAsync(ctx, merged)
     }
     
-    // Если в кеше нет, получаем из базы и кешируем
-    if incidents == nil {
-        incidents, err = s.incidentRepo.GetActiveIncidents(ctx)
+    return merged, nil
+}
+
+// ImportIncident создает инцидент из внешнего документа или, если инцидент с тем же
+// внешним ID уже импортирован, заменяет его поля данными документа. Отмена оповещения
+// деактивирует инцидент. dryRun только проверяет данные и определяет действие.
+func (s *IncidentService) ImportIncident(ctx context.Context, item models.ImportItem, dryRun bool) (models.ImportItemResult, error) {
+    result := models.ImportItemResult{ExternalID: item.ExternalID}
+    
+    existing, err := s.incidentRepo.FindByExternalID(ctx, item.ExternalID)
+    if err != nil {
+        return result, fmt.Errorf("failed to find incident: %w", err)
+    }
+    // Присоединенный дубликат обновляется через инцидент, в который он объединен
+    if existing != nil && existing.MergedInto != nil {
+        if existing, err = s.incidentRepo.FindByID(ctx, *existing.MergedInto); err != nil {
+            return result, fmt.Errorf("failed to find incident: %w", err)
+        }
+    }
+    
+    if existing == nil {
+        if item.Cancel {
+            return result, apperrors.NewValidationError(fmt.Errorf("cancelled alert %s has not been imported", item.ExternalID))
+        }
+        incident, err := s.newIncident(ctx, item.Incident)
         if err != nil {
-            return nil, fmt.Errorf("failed to get active incidents: %w", err)
+            return result, err
         }
+        incident.ExternalID = &item.ExternalID
         
-        if err := s.cacheRepo.SetActiveIncidents(ctx, incidents); err != nil {
-            // Логируем ошибку, но продолжаем работу
-            fmt.Printf("Failed to cache incidents: %v\n", err)
+        result.Action = models.ImportActionCreate
+        if dryRun {
+            return result, nil
+        }
+        if err := s.createIncident(ctx, incident); err != nil {
+            if errors.Is(err, repositories.ErrExternalIDExists) {
+                return result, apperrors.NewConflictError(fmt.Sprintf("incident %s is being imported concurrently", item.ExternalID))
+            }
+            return result, fmt.Errorf("failed to create incident: %w", err)
+        }
+        result.IncidentID = incident.ID
+        return result, nil
+    }
+    
+    result.IncidentID = existing.ID
+    incident := *existing
+    if item.Cancel {
+        incident.Active = false
+    } else {
+        imported, err := s.newIncident(ctx, item.Incident)
+        if err != nil {
+            return result, err
+        }
+        // Активность остается прежней: повторный импорт не возвращает в работу
+        // инцидент, закрытый оператором
+        incident.Title = imported.Title
+        incident.Description = imported.Description
+        incident.Severity = imported.Severity
+        incident.Category = imported.Category
+        incident.Tags = imported.Tags
+        incident.Latitude = imported.Latitude
+        incident.Longitude = imported.Longitude
+        incident.Radius = imported.Radius
+        incident.Polygon = imported.Polygon
+        incident.ExpiresAt = imported.ExpiresAt
+    }
+    
+    result.ChangedFields = models.ChangedFields(existing, &incident)
+    switch {
+    case len(result.ChangedFields) == 0:
+        result.Action = models.ImportActionUnchanged
+        return result, nil
+    case item.Cancel:
+        result.Action = models.ImportActionCancel
+    default:
+        result.Action = models.ImportActionUpdate
+    }
+    if dryRun {
+        return result, nil
+    }
+    
+    if err := s.incidentRepo.Update(ctx, &incident); err != nil {
+        if errors.Is(err, repositories.ErrVersionConflict) {
+            return result, apperrors.NewConflictError(fmt.Sprintf("incident %s is being modified concurrently", item.ExternalID))
+        }
+        return result, fmt.Errorf("failed to update incident: %w", err)
+    }
+    
+    // Сбрасываем кеш сразу; при сбое Redis его гарантированно сбросит релей outbox
+    _ = s.cacheRepo.InvalidateActiveIncidents(ctx)
+    
+    // Измененная зона могла накрыть новых пользователей; оповещенные ранее пропускаются
+    if s.alerter != nil && incident.Active && zoneChanged(result.ChangedFields) {
+        s.alerter.AlertAsync(ctx, &incident)
+    }
+    
+    return result, nil
+}
+
+// zoneChanged проверяет, затронуло ли изменение зону инцидента
+func zoneChanged(changedFields []string) bool {
+    for _, field := range changedFields {
+        switch field {
+        case models.FieldLocation, models.FieldRadius, models.FieldPolygon:
+            return true
         }
     }
+    return false
+}
+
+// PostUpdate добавляет сообщение в хронику инцидента. Публичное сообщение
+// показывается жителям при проверке локации.
+func (s *IncidentService) PostUpdate(ctx context.Context, incidentID int64, req models.PostUpdateRequest) (*models.IncidentUpdate, error) {
+    message, ok := models.NormalizeUpdateMessage(req.Message)
+    if !ok {
+        return nil, apperrors.NewValidationError(fmt.Errorf("message must be 1 to %d characters", models.MaxUpdateMessageLength))
+    }
+    author := strings.TrimSpace(req.Author)
+    if len(author) > 255 {
+        return nil, apperrors.NewValidationError(fmt.Errorf("author must be at most 255 characters"))
+    }
+    
+    incident, err := s.incidentRepo.FindByID(ctx, incidentID)
+    if err != nil {
+        return nil, fmt.Errorf("failed to find incident: %w", err)
+    }
+    if incident == nil {
+        return nil, apperrors.NewNotFoundError("Incident")
+    }
+    if incident.MergedInto != nil {
+        return nil, apperrors.NewConflictError(fmt.Sprintf("incident %d has been merged into %d", incidentID, *incident.MergedInto))
+    }
+    
+    update := &models.IncidentUpdate{
+        IncidentID: incidentID,
+        Message:    message,
+        Public:     req.Public,
+        Author:     author,
+    }
+    posted, err := s.incidentRepo.PostUpdate(ctx, update)
+    if err != nil {
+        return nil, fmt.Errorf("failed to post incident update: %w", err)
+    }
+    if !posted {
+        return nil, apperrors.NewConflictError(fmt.Sprintf("incident %d has been merged concurrently", incidentID))
+    }
+    
+    return update, nil
+}
+
+// ListUpdates возвращает хронику инцидента от новых сообщений к старым
+func (s *IncidentService) ListUpdates(ctx context.Context, incidentID int64, publicOnly bool) ([]*models.IncidentUpdate, error) {
+    incident, err := s.incidentRepo.FindByID(ctx, incidentID)
+    if err != nil {
+        return nil, fmt.Errorf("failed to find incident: %w", err)
+    }
+    if incident == nil {
+        return nil, apperrors.NewNotFoundError("Incident")
+    }
+    
+    updates, err := s.incidentRepo.FindUpdates(ctx, incidentID, publicOnly)
+    if err != nil {
+        return nil, fmt.Errorf("failed to find incident updates: %w", err)
+    }
+    return updates, nil
+}
+
+// ListGeometryHistory возвращает прежние зоны инцидента от последней замененной к первой
+func (s *IncidentService) ListGeometryHistory(ctx context.Context, incidentID int64) ([]*models.GeometryHistoryEntry, error) {
+    incident, err := s.incidentRepo.FindByID(ctx, incidentID)
+    if err != nil {
+        return nil, fmt.Errorf("failed to find incident: %w", err)
+    }
+    if incident == nil {
+        return nil, apperrors.NewNotFoundError("Incident")
+    }
     
-    // Фильтруем инциденты по расстоянию
+    history, err := s.incidentRepo.FindGeometryHistory(ctx, incidentID)
+    if err != nil {
+        return nil, fmt.Errorf("failed to find incident geometry history: %w", err)
+    }
+    return history, nil
+}
+
+func (s *IncidentService) CheckLocation(ctx context.Context, req models.LocationCheckRequest) (*models.LocationCheckResponse, error) {
+    // Активные инциденты из кеша; при промахе загрузку из базы выполняет один вызывающий
+    incidents, err := s.activeLoader.Load(ctx)
+    if err != nil {
+        return nil, err
+    }
+    
+    // Фильтруем инциденты, в зону которых попадает точка; истекшие, но еще
+    // не деактивированные IncidentExpirer, пропускаем
+    now := time.Now()
     var nearbyIncidents []models.Incident
     for _, incident := range incidents {
-        distance := calculateDistance(
-            req.Latitude, req.Longitude,
-            incident.Latitude, incident.Longitude,
-        )
-        
-        // Переводим радиус из метров в километры
-        if distance <= incident.Radius/1000.0 {
+        if !incident.Expired(now) && incident.Contains(req.Latitude, req.Longitude) {
             nearbyIncidents = append(nearbyIncidents, *incident)
         }
     }
     
     hasAlert := len(nearbyIncidents) > 0
     
-    // Сохраняем факт проверки
+    // Сохраняем факт проверки: по записи на каждый инцидент, в зону которого попала
+    // точка, чтобы статистика и объединение инцидентов учитывали проверку
+    s.saveLocationCheck(ctx, req, nearbyIncidents, now)
+    
+    // Если есть опасные зоны, ставим задачу на отправку вебхука
+    if hasAlert {
+        // Постановка в очередь переживает завершение запроса
+        go s.enqueueWebhook(context.WithoutCancel(ctx), req, nearbyIncidents)
+    }
+    
+    return &models.LocationCheckResponse{
+        Incidents: s.withLatestUpdates(ctx, nearbyIncidents),
+        HasAlert:  hasAlert,
+    }, nil
+}
+
+// saveLocationCheck записывает проверку локации одной строкой со всеми инцидентами,
+// в зоны которых попала точка
+func (s *IncidentService) saveLocationCheck(ctx context.Context, req models.LocationCheckRequest, incidents []models.Incident, now time.Time) {
     check := &models.LocationCheck{
         UserID:    req.UserID,
         Latitude:  req.Latitude,
         Longitude: req.Longitude,
-        Timestamp: time.Now(),
-        HasAlert:  hasAlert,
+        Timestamp: now,
+        HasAlert:  len(incidents) > 0,
+    }
+    for i := range incidents {
+        check.IncidentIDs = append(check.IncidentIDs, incidents[i].ID)
     }
     
     if err := s.incidentRepo.SaveLocationCheck(ctx, check); err != nil {
         // Логируем ошибку, но не прерываем выполнение
         fmt.Printf("Failed to save location check: %v\n", err)
     }
+}
+
+// PublicIncidents возвращает опубликованные (public) действующие инциденты из кеша,
+// подходящие под фильтр, с последними публичными сообщениями хроники, и момент
+// загрузки снимка из базы
+func (s *IncidentService) PublicIncidents(ctx context.Context, filter models.FeedFilter) ([]models.IncidentWithUpdate, time.Time, error) {
+    snapshot, err := s.activeLoader.LoadSnapshot(ctx)
+    if err != nil {
+        return nil, time.Time{}, err
+    }
     
-    // Если есть опасные зоны, ставим задачу на отправку вебхука
-    if hasAlert {
-        go s.enqueueWebhook(ctx, req, nearbyIncidents)
+    now := time.Now()
+    var incidents []models.Incident
+    for _, incident := range snapshot.Incidents {
+        if incident.Public && !incident.Expired(now) && filter.Matches(incident) {
+            incidents = append(incidents, *incident)
+        }
     }
     
-    return &models.LocationCheckResponse{
-        Incidents: nearbyIncidents,
-        HasAlert:  hasAlert,
-    }, nil
+    return s.withLatestUpdates(ctx, incidents), snapshot.LoadedAt, nil
 }
 
-func (s *IncidentService) GetStats(ctx context.Context, minutes int) ([]models.IncidentStats, error) {
-    stats, err := s.incidentRepo.GetStats(ctx, minutes)
+// withLatestUpdates дополняет инциденты последним публичным сообщением хроники;
+// при сбое хранилища инциденты возвращаются без сообщений
+func (s *IncidentService) withLatestUpdates(ctx context.Context, incidents []models.Incident) []models.IncidentWithUpdate {
+    if len(incidents) == 0 {
+        return nil
+    }
+    
+    result := make([]models.IncidentWithUpdate, len(incidents))
+    ids := make([]int64, len(incidents))
+    for i, incident := range incidents {
+        result[i].Incident = incident
+        ids[i] = incident.ID
+    }
+    
+    latest, err := s.incidentRepo.LatestPublicUpdates(ctx, ids)
+    if err != nil {
+        fmt.Printf("Failed to load incident updates: %v\n", err)
+        return result
+    }
+    for i := range result {
+        result[i].LatestUpdate = latest[result[i].ID]
+    }
+    return result
+}
+
+func (s *IncidentService) GetStats(ctx context.Context, minutes int, filter models.IncidentFilter) ([]models.IncidentStats, error) {
+    stats, err := s.incidentRepo.GetStats(ctx, minutes, filter)
     if err != nil {
         return nil, fmt.Errorf("failed to get stats: %w", err)
     }
@@ -200,40 +700,41 @@ func (s *IncidentService) enqueueWebhook(ctx context.Context, req models.Locatio
             incident.Latitude, incident.Longitude,
         )
         
-        shortIncidents = append(shortIncidents, models.IncidentShort{
-            ID:       incident.ID,
-            Title:    incident.Title,
-            Severity: incident.Severity,
-            Distance: distance * 1000, // переводим в метры
-        })
+        shortIncidents = append(shortIncidents, incident.Short(distance*1000)) // переводим в метры
+    }
+    
+    // Настройки пользователя: уровень, категории, тихие часы и лимит в час
+    now := time.Now()
+    shortIncidents = s.preferences.Admit(ctx, req.UserID, shortIncidents, now)
+    if len(shortIncidents) == 0 {
+        return
     }
     
     payload := models.WebhookPayload{
-        EventType: "location_alert",
+        EventType: models.EventLocationAlert,
+        EventID:   models.NewEventID(),
         UserID:    req.UserID,
         Latitude:  req.Latitude,
         Longitude: req.Longitude,
         Incidents: shortIncidents,
-        Timestamp: time.Now(),
+        Timestamp: now,
     }
     
     if err := s.queueRepo.EnqueueWebhook(ctx, payload); err != nil {
         fmt.Printf("Failed to enqueue webhook: %v\n", err)
+        return
+    }
+    
+    // Запоминаем оповещения, прошедшие настройки и поставленные в очередь, чтобы
+    // проактивное оповещение о зоне их не повторило
+    for _, incident := range shortIncidents {
+        if _, err := s.incidentRepo.MarkAlerted(ctx, incident.ID, []string{req.UserID}, now); err != nil {
+            fmt.Printf("Failed to record alert: %v\n", err)
+        }
     }
 }
 
 // calculateDistance вычисляет расстояние между двумя точками по формуле гаверсинусов
 func calculateDistance(lat1, lon1, lat2, lon2 float64) float64 {
-    const R = 6371 // радиус Земли в километрах
-    
-    dLat := (lat2 - lat1) * math.Pi / 180
-    dLon := (lon2 - lon1) * math.Pi / 180
-    
-    a := math.Sin(dLat/2)*math.Sin(dLat/2) +
-        math.Cos(lat1*math.Pi/180)*math.Cos(lat2*math.Pi/180)*
-            math.Sin(dLon/2)*math.Sin(dLon/2)
-    
-    c := 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
-    
-    return R * c
-}
\ No newline at end of file
+    return geo.DistanceKm(lat1, lon1, lat2, lon2)
+}
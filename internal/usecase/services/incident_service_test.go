@@ -0,0 +1,175 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/infrastructure/memory"
+	apperrors "incident-system/pkg/errors"
+	"incident-system/pkg/logger"
+)
+
+func TestCheckLocationFeedsFilteredStats(t *testing.T) {
+    ctx := context.Background()
+    repo := memory.NewIncidentRepository()
+
+    newZone := func(title, category string, lat float64) *models.Incident {
+        incident := &models.Incident{
+            UserID:    "operator",
+            Title:     title,
+            Severity:  "high",
+            Category:  category,
+            Latitude:  lat,
+            Longitude: 37.6173,
+            Radius:    1000,
+            Active:    true,
+        }
+        if err := repo.Create(ctx, incident); err != nil {
+            t.Fatalf("Create: %v", err)
+        }
+        return incident
+    }
+    fire := newZone("Пожар", "fire", 55.7558)
+    flood := newZone("Подтопление", "flood", 55.7558)
+    newZone("Пожар далеко", "fire", 56.7558)
+
+    preferences := NewNotificationPreferencesService(memory.NewNotificationPreferencesRepository(), logger.NewLogger("test"))
+    service := NewIncidentService(
+        repo, memory.NewCacheRepository(time.Minute, time.Minute), memory.NewQueueRepository(), nil, preferences, nil,
+    )
+
+    // u1 в обеих зонах, u2 вне зон
+    for _, req := range []models.LocationCheckRequest{
+        {UserID: "u1", Latitude: 55.7558, Longitude: 37.6173},
+        {UserID: "u2", Latitude: 50, Longitude: 30},
+    } {
+        if _, err := service.CheckLocation(ctx, req); err != nil {
+            t.Fatalf("CheckLocation(%s): %v", req.UserID, err)
+        }
+    }
+
+    stats, err := service.GetStats(ctx, 60, models.IncidentFilter{Categories: []string{"fire"}})
+    if err != nil {
+        t.Fatalf("GetStats: %v", err)
+    }
+    if len(stats) != 1 || stats[0].ZoneID == nil || *stats[0].ZoneID != fire.ID || stats[0].UserCount != 1 {
+        t.Fatalf("GetStats(fire) = %+v, want one user in zone %d", stats, fire.ID)
+    }
+
+    stats, err = service.GetStats(ctx, 60, models.IncidentFilter{})
+    if err != nil {
+        t.Fatalf("GetStats: %v", err)
+    }
+    byZone := make(map[int64]int64)
+    for _, stat := range stats {
+        var zone int64
+        if stat.ZoneID != nil {
+            zone = *stat.ZoneID
+        }
+        byZone[zone] = stat.UserCount
+    }
+    if len(byZone) != 3 || byZone[fire.ID] != 1 || byZone[flood.ID] != 1 || byZone[0] != 1 {
+        t.Fatalf("GetStats = %v, want one user in each zone and one outside", byZone)
+    }
+}
+
+func TestUpdateIncidentWithMergePatch(t *testing.T) {
+    ctx := context.Background()
+    repo := memory.NewIncidentRepository()
+    service := NewIncidentService(repo, memory.NewCacheRepository(time.Minute, time.Minute), nil, nil, nil, nil)
+
+    incident := &models.Incident{
+        UserID:      "operator",
+        Title:       "Пожар",
+        Description: "Горит склад",
+        Severity:    "high",
+        Latitude:    55.75,
+        Longitude:   37.65,
+        Polygon:     []models.GeoPoint{{Latitude: 55.74, Longitude: 37.64}, {Latitude: 55.74, Longitude: 37.66}, {Latitude: 55.76, Longitude: 37.65}},
+        Radius:      1500,
+        Active:      true,
+    }
+    if err := repo.Create(ctx, incident); err != nil {
+        t.Fatalf("Create: %v", err)
+    }
+
+    patch := func(body string, version int64) (*models.Incident, error) {
+        t.Helper()
+        req, err := models.ParseMergePatch([]byte(body))
+        if err != nil {
+            t.Fatalf("ParseMergePatch(%s): %v", body, err)
+        }
+        return service.UpdateIncident(ctx, incident.ID, req, models.ParseIfMatch(models.IncidentETag(version)))
+    }
+    status := func(err error) int {
+        if err == nil {
+            return http.StatusOK
+        }
+        return apperrors.FromError(err).Code
+    }
+
+    // Переданные поля заменяются, null удаляет описание, остальное не меняется
+    updated, err := patch(`{"title": "Пожар на складе", "description": null}`, 1)
+    if err != nil || updated.Title != "Пожар на складе" || updated.Description != "" || updated.Severity != "high" || len(updated.Polygon) != 3 {
+        t.Fatalf("patch = %+v, %v", updated, err)
+    }
+    if _, err := patch(`{"title": "Устаревшая версия"}`, 1); status(err) != http.StatusPreconditionFailed {
+        t.Fatalf("patch with a stale If-Match = %v, want 412", err)
+    }
+
+    // Контур задает зону вместо переданного радиуса
+    updated, err = patch(`{"radius": 500, "polygon": [
+        {"latitude": 55.70, "longitude": 37.60}, {"latitude": 55.70, "longitude": 37.70}, {"latitude": 55.80, "longitude": 37.65}]}`, 2)
+    if err != nil || len(updated.Polygon) != 3 || updated.Radius <= 500 {
+        t.Fatalf("patch with polygon and radius = %+v, %v; want the polygon's circumradius", updated, err)
+    }
+
+    // null убирает контур, но круг требует явного радиуса
+    if _, err := patch(`{"polygon": null}`, 3); status(err) != http.StatusBadRequest {
+        t.Fatalf("patch removing polygon without radius = %v, want 400", err)
+    }
+    updated, err = patch(`{"polygon": null, "radius": 800}`, 3)
+    if err != nil || updated.Polygon != nil || updated.Radius != 800 || updated.Version != 4 {
+        t.Fatalf("patch removing polygon = %+v, %v; want an 800 m circle", updated, err)
+    }
+
+    for _, body := range []string{`{"version": 7}`, `{"title": null}`, `{"unknown": 1}`, `[]`} {
+        if _, err := models.ParseMergePatch([]byte(body)); err == nil {
+            t.Fatalf("ParseMergePatch(%s) accepted", body)
+        }
+    }
+}
+
+func TestPublicIncidentsListsOnlyPublishedIncidents(t *testing.T) {
+    ctx := context.Background()
+    service := NewIncidentService(memory.NewIncidentRepository(), memory.NewCacheRepository(time.Minute, time.Minute), nil, nil, nil, nil)
+
+    create := func(title string, public bool) *models.Incident {
+        incident, err := service.CreateIncident(ctx, models.CreateIncidentRequest{
+            UserID: "operator", Latitude: 55.7558, Longitude: 37.6173, Title: title, Severity: "high", Radius: 1000, Public: public,
+        })
+        if err != nil {
+            t.Fatalf("CreateIncident: %v", err)
+        }
+        return incident
+    }
+    published := create("Пожар", true)
+    internal := create("Проверка сигнализации", false)
+
+    items, _, err := service.PublicIncidents(ctx, models.FeedFilter{})
+    if err != nil || len(items) != 1 || items[0].ID != published.ID {
+        t.Fatalf("PublicIncidents = %+v, %v; want only incident %d", items, err, published.ID)
+    }
+
+    // Опубликованный оператором инцидент появляется в ленте
+    public := true
+    if _, err := service.UpdateIncident(ctx, internal.ID, models.UpdateIncidentRequest{Public: &public}, models.IfMatch{}); err != nil {
+        t.Fatalf("UpdateIncident: %v", err)
+    }
+    if items, _, err := service.PublicIncidents(ctx, models.FeedFilter{IncidentID: internal.ID}); err != nil || len(items) != 1 {
+        t.Fatalf("PublicIncidents(%d) = %+v, %v after publishing", internal.ID, items, err)
+    }
+}
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/domain/repositories"
+	apperrors "incident-system/pkg/errors"
+	"incident-system/pkg/logger"
+)
+
+const (
+    maxPreferenceCategories = 50
+    maxAlertsPerHourLimit   = 1000
+)
+
+// NotificationPreferencesService управляет настройками оповещений пользователей
+// и применяет их к оповещениям перед постановкой в очередь
+type NotificationPreferencesService struct {
+    repo   repositories.NotificationPreferencesRepository
+    logger *logger.Logger
+}
+
+func NewNotificationPreferencesService(
+    repo repositories.NotificationPreferencesRepository,
+    logger *logger.Logger,
+) *NotificationPreferencesService {
+    return &NotificationPreferencesService{repo: repo, logger: logger}
+}
+
+// GetPreferences возвращает настройки пользователя или настройки по умолчанию
+func (s *NotificationPreferencesService) GetPreferences(ctx context.Context, userID string) (*models.NotificationPreferences, error) {
+    prefs, err := s.repo.Get(ctx, userID)
+    if err != nil {
+        return nil, fmt.Errorf("failed to get notification preferences: %w", err)
+    }
+    if prefs == nil {
+        return models.DefaultNotificationPreferences(userID), nil
+    }
+    return prefs, nil
+}
+
+func (s *NotificationPreferencesService) UpdatePreferences(ctx context.Context, userID string, req models.NotificationPreferencesRequest) (*models.NotificationPreferences, error) {
+    prefs, err := buildPreferences(userID, req)
+    if err != nil {
+        return nil, apperrors.NewValidationError(err)
+    }
+    prefs.UpdatedAt = time.Now()
+
+    if err := s.repo.Save(ctx, prefs); err != nil {
+        return nil, fmt.Errorf("failed to save notification preferences: %w", err)
+    }
+    return prefs, nil
+}
+
+// ResetPreferences удаляет настройки пользователя, возвращая значения по умолчанию
+func (s *NotificationPreferencesService) ResetPreferences(ctx context.Context, userID string) error {
+    if err := s.repo.Delete(ctx, userID); err != nil {
+        return fmt.Errorf("failed to delete notification preferences: %w", err)
+    }
+    return nil
+}
+
+// buildPreferences проверяет запрос и дополняет его значениями по умолчанию
+func buildPreferences(userID string, req models.NotificationPreferencesRequest) (*models.NotificationPreferences, error) {
+    prefs := models.DefaultNotificationPreferences(userID)
+
+    if req.MinSeverity != "" {
+        if !models.ValidSeverity(req.MinSeverity) {
+            return nil, fmt.Errorf("unknown min_severity %q", req.MinSeverity)
+        }
+        prefs.MinSeverity = req.MinSeverity
+    }
+    if req.QuietHoursOverride != "" {
+        if !models.ValidSeverity(req.QuietHoursOverride) {
+            return nil, fmt.Errorf("unknown quiet_hours_override %q", req.QuietHoursOverride)
+        }
+        prefs.QuietHoursOverride = req.QuietHoursOverride
+    }
+
+    if req.TimeZone != "" {
+        if _, err := time.LoadLocation(req.TimeZone); err != nil {
+            return nil, fmt.Errorf("unknown time_zone %q", req.TimeZone)
+        }
+        prefs.TimeZone = req.TimeZone
+    }
+
+    if req.QuietHours != nil {
+        if _, err := models.ParseClock(req.QuietHours.Start); err != nil {
+            return nil, fmt.Errorf("quiet_hours.start: %w", err)
+        }
+        if _, err := models.ParseClock(req.QuietHours.End); err != nil {
+            return nil, fmt.Errorf("quiet_hours.end: %w", err)
+        }
+        quiet := *req.QuietHours
+        prefs.QuietHours = &quiet
+    }
+
+    if len(req.Categories) > maxPreferenceCategories {
+        return nil, fmt.Errorf("at most %d categories", maxPreferenceCategories)
+    }
+    seen := make(map[string]bool)
+    for _, category := range req.Categories {
+        category = strings.TrimSpace(category)
+        if !models.ValidCategoryCode(category) {
+            return nil, fmt.Errorf("invalid category %q", category)
+        }
+        if !seen[category] {
+            seen[category] = true
+            prefs.Categories = append(prefs.Categories, category)
+        }
+    }
+
+    if req.MaxAlertsPerHour < 0 || req.MaxAlertsPerHour > maxAlertsPerHourLimit {
+        return nil, fmt.Errorf("max_alerts_per_hour must be between 0 and %d", maxAlertsPerHourLimit)
+    }
+    prefs.MaxAlertsPerHour = req.MaxAlertsPerHour
+
+    return prefs, nil
+}
+
+// Admit оставляет инциденты оповещения, о которых пользователь хочет знать сейчас,
+// и учитывает оповещение в его часовом лимите. Пустой результат - оповещение
+// отправлять не нужно. При сбое хранилища оповещение пропускается без фильтрации:
+// потерять предупреждение об опасности хуже, чем нарушить настройки.
+func (s *NotificationPreferencesService) Admit(ctx context.Context, userID string, incidents []models.IncidentShort, now time.Time) []models.IncidentShort {
+    prefs, err := s.repo.Get(ctx, userID)
+    if err != nil {
+        s.logger.Error("Notification preferences of user %s unavailable: %v", userID, err)
+        return incidents
+    }
+    if prefs == nil {
+        return incidents
+    }
+
+    var wanted []models.IncidentShort
+    for _, incident := range incidents {
+        if prefs.Wants(incident.Severity, incident.Category, now) {
+            wanted = append(wanted, incident)
+        }
+    }
+    if len(wanted) == 0 || prefs.MaxAlertsPerHour == 0 {
+        return wanted
+    }
+
+    reserved, err := s.repo.ReserveAlert(ctx, userID, now.Add(-time.Hour), prefs.MaxAlertsPerHour, now)
+    if err != nil {
+        s.logger.Error("Alert rate limit of user %s unavailable: %v", userID, err)
+        return wanted
+    }
+    if !reserved {
+        s.logger.Info("Alert for user %s suppressed: %d alerts per hour reached", userID, prefs.MaxAlertsPerHour)
+        return nil
+    }
+    return wanted
+}
@@ -0,0 +1,258 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/domain/repositories"
+	"incident-system/pkg/logger"
+)
+
+// EventPublisher - получатель событий изменения инцидентов. Событие может быть
+// доставлено повторно (например, после сбоя релея), поэтому Publish должен
+// быть идемпотентным по event.ID.
+type EventPublisher interface {
+    Name() string
+    Publish(ctx context.Context, event *models.OutboxEvent) error
+}
+
+type OutboxRelayOptions struct {
+    Interval  time.Duration
+    BatchSize int
+
+    MaxAttempts    int // после стольких неудачных попыток событие уходит в dead letter; 0 - без ограничения
+    BaseRetryDelay time.Duration
+    MaxRetryDelay  time.Duration
+}
+
+// OutboxRelay публикует события из outbox всем издателям. Каждый издатель
+// получает событие не более одного раза при штатной работе (учет в outbox.delivered),
+// события одного инцидента публикуются строго по порядку: если событие не удалось
+// доставить, оно откладывается с экспоненциальной задержкой, а последующие события
+// того же инцидента ждут его повтора. Событие, исчерпавшее MaxAttempts, уходит
+// в dead letter, чтобы не задерживать остальные события инцидента.
+type OutboxRelay struct {
+    outboxRepo repositories.OutboxRepository
+    publishers []EventPublisher
+    owner      string
+    opts       OutboxRelayOptions
+    logger     *logger.Logger
+}
+
+func NewOutboxRelay(
+    outboxRepo repositories.OutboxRepository,
+    publishers []EventPublisher,
+    opts OutboxRelayOptions,
+    logger *logger.Logger,
+) *OutboxRelay {
+    hostname, _ := os.Hostname()
+
+    return &OutboxRelay{
+        outboxRepo: outboxRepo,
+        publishers: publishers,
+        owner:      hostname + ":" + strconv.Itoa(os.Getpid()),
+        opts:       opts,
+        logger:     logger,
+    }
+}
+
+func (r *OutboxRelay) Start(ctx context.Context) {
+    go func() {
+        ticker := time.NewTicker(r.opts.Interval)
+        defer ticker.Stop()
+
+        for {
+            select {
+            case <-ctx.Done():
+                r.logger.Info("Outbox relay stopped")
+                return
+            case <-ticker.C:
+                // Обрабатываем пачки, пока публикуются полные пачки. Пачка со сбоями
+                // или отложенными событиями ждет следующего тика, а не крутится впустую.
+                for {
+                    published, err := r.ProcessBatch(ctx)
+                    if err != nil {
+                        r.logger.Error("Outbox relay failed: %v", err)
+                        break
+                    }
+                    if published < r.opts.BatchSize {
+                        break
+                    }
+                }
+            }
+        }
+    }()
+}
+
+// ProcessBatch публикует одну пачку событий и возвращает число опубликованных событий.
+// Несостоявшиеся и отложенные из-за них события прогрессом не считаются.
+func (r *OutboxRelay) ProcessBatch(ctx context.Context) (int, error) {
+    // Аренда переживает несколько циклов, чтобы не переходить между экземплярами
+    acquired, err := r.outboxRepo.AcquireLease(ctx, r.owner, 5*r.opts.Interval)
+    if err != nil {
+        return 0, fmt.Errorf("failed to acquire outbox lease: %w", err)
+    }
+    if !acquired {
+        return 0, nil
+    }
+
+    events, err := r.outboxRepo.FetchPending(ctx, time.Now(), r.opts.BatchSize)
+    if err != nil {
+        return 0, fmt.Errorf("failed to fetch outbox events: %w", err)
+    }
+
+    published := 0
+    blocked := make(map[int64]bool)
+    for _, event := range events {
+        if blocked[event.IncidentID] {
+            continue
+        }
+
+        if err := r.publish(ctx, event); err != nil {
+            blocked[event.IncidentID] = true
+            if markErr := r.fail(ctx, event, err); markErr != nil {
+                return published, markErr
+            }
+            continue
+        }
+        published++
+    }
+
+    return published, nil
+}
+
+// fail откладывает повтор события или переводит его в dead letter
+func (r *OutboxRelay) fail(ctx context.Context, event *models.OutboxEvent, err error) error {
+    attempt := event.Attempts + 1
+    if r.opts.MaxAttempts > 0 && attempt >= r.opts.MaxAttempts {
+        r.logger.Error("Outbox event %d (%s) for incident %d dead-lettered after %d attempts: %v",
+            event.ID, event.EventType, event.IncidentID, attempt, err)
+        return r.outboxRepo.MarkDeadLetter(ctx, event.ID, err.Error(), time.Now())
+    }
+
+    delay := r.backoff(attempt)
+    r.logger.Warn("Outbox event %d (%s) for incident %d not published, retry in %v: %v",
+        event.ID, event.EventType, event.IncidentID, delay, err)
+    return r.outboxRepo.MarkFailed(ctx, event.ID, err.Error(), time.Now().Add(delay))
+}
+
+// backoff - экспоненциальная задержка перед повтором события
+func (r *OutboxRelay) backoff(attempt int) time.Duration {
+    delay := r.opts.BaseRetryDelay
+    for i := 1; i < attempt && delay < r.opts.MaxRetryDelay; i++ {
+        delay *= 2
+    }
+    if delay > r.opts.MaxRetryDelay {
+        delay = r.opts.MaxRetryDelay
+    }
+    if delay < 0 {
+        return 0
+    }
+    return delay
+}
+
+func (r *OutboxRelay) publish(ctx context.Context, event *models.OutboxEvent) error {
+    for _, publisher := range r.publishers {
+        if event.DeliveredTo(publisher.Name()) {
+            continue
+        }
+
+        if err := publisher.Publish(ctx, event); err != nil {
+            return fmt.Errorf("%s: %w", publisher.Name(), err)
+        }
+
+        if err := r.outboxRepo.MarkDelivered(ctx, event.ID, publisher.Name()); err != nil {
+            return fmt.Errorf("failed to mark delivery to %s: %w", publisher.Name(), err)
+        }
+    }
+
+    return r.outboxRepo.MarkPublished(ctx, event.ID)
+}
+
+// cacheInvalidationPublisher сбрасывает кеш активных инцидентов. События пакетной
+// операции фиксируются одной транзакцией, поэтому кеш сбрасывается один раз на пакет.
+type cacheInvalidationPublisher struct {
+    cacheRepo repositories.CacheRepository
+    lastBatch string // пакет, после которого кеш уже сброшен
+}
+
+func NewCacheInvalidationPublisher(cacheRepo repositories.CacheRepository) EventPublisher {
+    return &cacheInvalidationPublisher{cacheRepo: cacheRepo}
+}
+
+func (p *cacheInvalidationPublisher) Name() string {
+    return "cache"
+}
+
+func (p *cacheInvalidationPublisher) Publish(ctx context.Context, event *models.OutboxEvent) error {
+    // Хроника не входит в снимок активных инцидентов, а изменение зоны сопровождается
+    // событием incident.updated той же транзакции
+    if event.EventType == models.EventIncidentUpdatePosted || event.EventType == models.EventIncidentGeometryChanged {
+        return nil
+    }
+    if event.BatchID != "" && event.BatchID == p.lastBatch {
+        return nil
+    }
+
+    if err := p.cacheRepo.InvalidateActiveIncidents(ctx); err != nil {
+        return err
+    }
+    p.lastBatch = event.BatchID
+    return nil
+}
+
+// webhookEventPublisher ставит событие в очередь вебхуков с полным состоянием
+// инцидента. Получатель может отбросить повтор по полю event_id.
+type webhookEventPublisher struct {
+    queueRepo repositories.QueueRepository
+}
+
+func NewWebhookEventPublisher(queueRepo repositories.QueueRepository) EventPublisher {
+    return &webhookEventPublisher{queueRepo: queueRepo}
+}
+
+func (p *webhookEventPublisher) Name() string {
+    return "webhook"
+}
+
+func (p *webhookEventPublisher) Publish(ctx context.Context, event *models.OutboxEvent) error {
+    incident := event.Incident
+
+    payload := models.WebhookPayload{
+        EventType: event.EventType,
+        EventID:   strconv.FormatInt(event.ID, 10),
+        UserID:    incident.UserID,
+        Latitude:  incident.Latitude,
+        Longitude: incident.Longitude,
+        Incidents: []models.IncidentShort{incident.Short(0)},
+        Timestamp:     event.CreatedAt,
+        Incident:      incident,
+        ChangedFields: event.ChangedFields,
+        Update:        event.Update,
+        Geometry:      event.Geometry,
+        BatchID:       event.BatchID,
+    }
+
+    return p.queueRepo.EnqueueWebhook(ctx, payload)
+}
+
+// streamPublisher дописывает событие в журнал для потоковых потребителей
+type streamPublisher struct {
+    stream repositories.EventStream
+}
+
+func NewStreamPublisher(stream repositories.EventStream) EventPublisher {
+    return &streamPublisher{stream: stream}
+}
+
+func (p *streamPublisher) Name() string {
+    return "stream"
+}
+
+func (p *streamPublisher) Publish(ctx context.Context, event *models.OutboxEvent) error {
+    return p.stream.AppendEvent(ctx, event)
+}
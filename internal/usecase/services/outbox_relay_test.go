@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/domain/repositories"
+	"incident-system/internal/infrastructure/memory"
+	"incident-system/pkg/logger"
+)
+
+// flakyPublisher не публикует события из failures, пока не исчерпан их счетчик
+type flakyPublisher struct {
+    failures  map[int64]int
+    published []int64
+}
+
+func (p *flakyPublisher) Name() string {
+    return "flaky"
+}
+
+func (p *flakyPublisher) Publish(ctx context.Context, event *models.OutboxEvent) error {
+    if p.failures[event.ID] > 0 {
+        p.failures[event.ID]--
+        return errors.New("publisher unavailable")
+    }
+    p.published = append(p.published, event.ID)
+    return nil
+}
+
+// newRelayFixture создает инцидент с событиями created (1) и updated (2)
+func newRelayFixture(t *testing.T, publisher *flakyPublisher, opts OutboxRelayOptions) (*OutboxRelay, repositories.OutboxRepository) {
+    t.Helper()
+    ctx := context.Background()
+    repo, outbox := memory.NewIncidentRepositoryWithOutbox(memory.IncidentReferrers{})
+
+    incident := &models.Incident{UserID: "operator", Title: "Пожар", Severity: "high", Radius: 1000, Active: true}
+    if err := repo.Create(ctx, incident); err != nil {
+        t.Fatalf("Create: %v", err)
+    }
+    incident.Description = "Горит склад"
+    if err := repo.Update(ctx, incident); err != nil {
+        t.Fatalf("Update: %v", err)
+    }
+
+    opts.Interval, opts.BatchSize = time.Second, 10
+    return NewOutboxRelay(outbox, []EventPublisher{publisher}, opts, logger.NewLogger("test")), outbox
+}
+
+func TestOutboxRelayBacksOffFailedEvents(t *testing.T) {
+    ctx := context.Background()
+    publisher := &flakyPublisher{failures: map[int64]int{1: 1}}
+    relay, outbox := newRelayFixture(t, publisher, OutboxRelayOptions{BaseRetryDelay: time.Minute, MaxRetryDelay: 5 * time.Minute})
+
+    // Событие 2 ждет повтора события 1 того же инцидента
+    if n, err := relay.ProcessBatch(ctx); err != nil || n != 0 || len(publisher.published) != 0 {
+        t.Fatalf("ProcessBatch = %d, %v, published %v", n, err, publisher.published)
+    }
+    if pending, err := outbox.FetchPending(ctx, time.Now(), 10); err != nil || len(pending) != 0 {
+        t.Fatalf("FetchPending before retry = %d events, %v, want none", len(pending), err)
+    }
+    pending, err := outbox.FetchPending(ctx, time.Now().Add(time.Minute+time.Second), 10)
+    if err != nil || len(pending) != 2 || pending[0].ID != 1 || pending[0].Attempts != 1 {
+        t.Fatalf("FetchPending after retry delay = %+v, %v", pending, err)
+    }
+
+    for attempt, want := range []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 5 * time.Minute, 5 * time.Minute} {
+        if delay := relay.backoff(attempt + 1); delay != want {
+            t.Fatalf("backoff(%d) = %v, want %v", attempt+1, delay, want)
+        }
+    }
+}
+
+func TestOutboxRelayDeadLettersAfterMaxAttempts(t *testing.T) {
+    ctx := context.Background()
+    publisher := &flakyPublisher{failures: map[int64]int{1: 100}}
+    relay, outbox := newRelayFixture(t, publisher, OutboxRelayOptions{
+        MaxAttempts:    2,
+        BaseRetryDelay: time.Millisecond,
+        MaxRetryDelay:  time.Millisecond,
+    })
+
+    if n, err := relay.ProcessBatch(ctx); err != nil || n != 0 {
+        t.Fatalf("first ProcessBatch = %d, %v", n, err)
+    }
+    time.Sleep(5 * time.Millisecond)
+    if n, err := relay.ProcessBatch(ctx); err != nil || n != 0 {
+        t.Fatalf("second ProcessBatch = %d, %v", n, err)
+    }
+
+    // Событие 1 ушло в dead letter и больше не задерживает событие 2
+    if n, err := relay.ProcessBatch(ctx); err != nil || n != 1 || len(publisher.published) != 1 || publisher.published[0] != 2 {
+        t.Fatalf("ProcessBatch after dead letter = %d, %v, published %v", n, err, publisher.published)
+    }
+    if pending, err := outbox.FetchPending(ctx, time.Now().Add(time.Hour), 10); err != nil || len(pending) != 0 {
+        t.Fatalf("FetchPending = %+v, %v, want the dead-lettered event excluded", pending, err)
+    }
+}
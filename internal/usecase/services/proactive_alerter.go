@@ -0,0 +1,145 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/domain/repositories"
+	"incident-system/pkg/logger"
+)
+
+// proactiveAlertTimeout ограничивает поиск пользователей и постановку их оповещений
+const proactiveAlertTimeout = time.Minute
+
+// ProactiveAlertOptions - настройки оповещения пользователей, уже находящихся в зоне
+type ProactiveAlertOptions struct {
+    Freshness   time.Duration // насколько свежей должна быть последняя точка пользователя; 0 отключает оповещения
+    MinSeverity string        // минимальный уровень опасности инцидента
+}
+
+// ProactiveAlerter оповещает пользователей, последняя проверка которых попадает
+// в новую или расширенную зону, не дожидаясь их следующего /location/check.
+// Пользователь получает оповещение об инциденте не более одного раза, в том числе
+// если уже получил его при проверке локации.
+type ProactiveAlerter struct {
+    incidentRepo repositories.IncidentRepository
+    queueRepo    repositories.QueueRepository
+    preferences  *NotificationPreferencesService
+    opts         ProactiveAlertOptions
+    logger       *logger.Logger
+}
+
+func NewProactiveAlerter(
+    incidentRepo repositories.IncidentRepository,
+    queueRepo repositories.QueueRepository,
+    preferences *NotificationPreferencesService,
+    opts ProactiveAlertOptions,
+    logger *logger.Logger,
+) *ProactiveAlerter {
+    return &ProactiveAlerter{
+        incidentRepo: incidentRepo,
+        queueRepo:    queueRepo,
+        preferences:  preferences,
+        opts:         opts,
+        logger:       logger,
+    }
+}
+
+// applies проверяет, нужно ли оповещать о зоне инцидента
+func (a *ProactiveAlerter) applies(incident *models.Incident) bool {
+    return a.opts.Freshness > 0 && incident.Active && models.SeverityAtLeast(incident.Severity, a.opts.MinSeverity)
+}
+
+// AlertAsync запускает Alert в фоне, не привязываясь к отмене контекста запроса
+func (a *ProactiveAlerter) AlertAsync(ctx context.Context, incident *models.Incident) {
+    if !a.applies(incident) {
+        return
+    }
+
+    snapshot := *incident
+    go func() {
+        ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), proactiveAlertTimeout)
+        defer cancel()
+
+        if _, err := a.Alert(ctx, &snapshot); err != nil {
+            a.logger.Error("Proactive alerts for incident %d failed: %v", snapshot.ID, err)
+        }
+    }()
+}
+
+// Alert ставит location_alert пользователям в зоне инцидента и возвращает их число
+func (a *ProactiveAlerter) Alert(ctx context.Context, incident *models.Incident) (int, error) {
+    if !a.applies(incident) {
+        return 0, nil
+    }
+
+    now := time.Now()
+    candidates, err := a.incidentRepo.FindLatestLocations(ctx, now.Add(-a.opts.Freshness),
+        incident.Latitude, incident.Longitude, incident.Radius/1000.0)
+    if err != nil {
+        return 0, err
+    }
+
+    positions := make(map[string]*models.LocationCheck)
+    var userIDs []string
+    for _, check := range candidates {
+        if incident.Contains(check.Latitude, check.Longitude) {
+            positions[check.UserID] = check
+            userIDs = append(userIDs, check.UserID)
+        }
+    }
+    if len(userIDs) == 0 {
+        return 0, nil
+    }
+
+    // Пользователи сначала закрепляются за вызовом: при параллельных изменениях
+    // зоны оповестит только один из них. Отметка снимается с тех, кого отсеяли
+    // настройки или кому не удалось поставить оповещение в очередь, чтобы
+    // следующее изменение зоны их оповестило
+    claimed, err := a.incidentRepo.MarkAlerted(ctx, incident.ID, userIDs, now)
+    if err != nil {
+        return 0, err
+    }
+
+    queued := 0
+    var enqueueErr error
+    var released []string
+    for _, userID := range claimed {
+        check := positions[userID]
+        incidents := a.preferences.Admit(ctx, userID, []models.IncidentShort{
+            incident.Short(calculateDistance(check.Latitude, check.Longitude, incident.Latitude, incident.Longitude) * 1000),
+        }, now)
+        if len(incidents) == 0 {
+            released = append(released, userID)
+            continue
+        }
+        payload := models.WebhookPayload{
+            EventType: models.EventLocationAlert,
+            EventID:   models.NewEventID(),
+            UserID:    userID,
+            Latitude:  check.Latitude,
+            Longitude: check.Longitude,
+            Incidents: incidents,
+            Timestamp: now,
+            Proactive: true,
+        }
+        if err := a.queueRepo.EnqueueWebhook(ctx, payload); err != nil {
+            enqueueErr = err
+            released = append(released, userID)
+            continue
+        }
+        queued++
+    }
+
+    if len(released) > 0 {
+        if err := a.incidentRepo.UnmarkAlerted(ctx, incident.ID, released); err != nil {
+            a.logger.Error("Incident %d: releasing alerts of %d users failed: %v", incident.ID, len(released), err)
+        }
+    }
+
+    if queued > 0 {
+        a.logger.Info("Incident %d: proactive alerts queued for %d users", incident.ID, queued)
+    }
+    return queued, enqueueErr
+}
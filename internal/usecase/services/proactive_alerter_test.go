@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/domain/repositories"
+	"incident-system/internal/infrastructure/memory"
+	"incident-system/pkg/logger"
+)
+
+// failingQueue отклоняет постановку оповещений выбранным пользователям
+type failingQueue struct {
+    repositories.QueueRepository
+    failUsers map[string]bool
+}
+
+func (q *failingQueue) EnqueueWebhook(ctx context.Context, payload models.WebhookPayload) error {
+    if q.failUsers[payload.UserID] {
+        return errors.New("queue unavailable")
+    }
+    return q.QueueRepository.EnqueueWebhook(ctx, payload)
+}
+
+func TestProactiveAlertReleasesSkippedUsers(t *testing.T) {
+    ctx := context.Background()
+    repo := memory.NewIncidentRepository()
+    prefsRepo := memory.NewNotificationPreferencesRepository()
+    log := logger.NewLogger("test")
+
+    incident := &models.Incident{
+        UserID:    "operator",
+        Title:     "Пожар",
+        Severity:  "medium",
+        Latitude:  55.7558,
+        Longitude: 37.6173,
+        Radius:    1000,
+        Active:    true,
+    }
+    if err := repo.Create(ctx, incident); err != nil {
+        t.Fatalf("Create: %v", err)
+    }
+    for _, userID := range []string{"quiet", "broken", "ok"} {
+        check := &models.LocationCheck{UserID: userID, Latitude: 55.7558, Longitude: 37.6173, Timestamp: time.Now()}
+        if err := repo.SaveLocationCheck(ctx, check); err != nil {
+            t.Fatalf("SaveLocationCheck: %v", err)
+        }
+    }
+    if err := prefsRepo.Save(ctx, &models.NotificationPreferences{UserID: "quiet", MinSeverity: "high"}); err != nil {
+        t.Fatalf("Save preferences: %v", err)
+    }
+
+    queue := &failingQueue{QueueRepository: memory.NewQueueRepository(), failUsers: map[string]bool{"broken": true}}
+    alerter := NewProactiveAlerter(repo, queue, NewNotificationPreferencesService(prefsRepo, log),
+        ProactiveAlertOptions{Freshness: time.Hour}, log)
+
+    queued, err := alerter.Alert(ctx, incident)
+    if err == nil || queued != 1 {
+        t.Fatalf("Alert = %d, %v, want 1 queued and the enqueue error", queued, err)
+    }
+
+    // Отметка осталась только у пользователя, которому оповещение поставлено
+    marked, err := repo.MarkAlerted(ctx, incident.ID, []string{"quiet", "broken", "ok"}, time.Now())
+    if err != nil {
+        t.Fatalf("MarkAlerted: %v", err)
+    }
+    if len(marked) != 2 || marked[0] != "quiet" || marked[1] != "broken" {
+        t.Fatalf("MarkAlerted after Alert = %v, want quiet and broken still unalerted", marked)
+    }
+}
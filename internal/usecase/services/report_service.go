@@ -0,0 +1,348 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/domain/repositories"
+	apperrors "incident-system/pkg/errors"
+	"incident-system/pkg/logger"
+)
+
+const (
+    minReportDescription = 10
+    maxReportDescription = 1000
+    maxReportUserID      = 255
+    maxModerationNote    = 1000
+    maxReportTitle       = 100 // заголовок инцидента, составленный из описания
+
+    // reportClaimTimeout - через сколько сообщение, оставшееся в approving после
+    // сбоя, снова можно рассмотреть
+    reportClaimTimeout = 5 * time.Minute
+)
+
+// ReportOptions - ограничения на прием сообщений от жителей
+type ReportOptions struct {
+    RateWindow  time.Duration // окно лимитов частоты
+    PerReporter int           // сообщений от одного заявителя за окно; 0 - без ограничения
+    PerAddress  int           // сообщений с одного адреса за окно; 0 - без ограничения
+    AbuseWindow time.Duration // за какой срок учитываются итоги модерации
+    BlockScore  float64       // оценка, с которой сообщения не принимаются; 0 отключает
+}
+
+// ReportService принимает сообщения жителей о происшествиях и ведет очередь
+// их модерации: оператор создает по сообщению инцидент, присоединяет его
+// к известному инциденту или отклоняет. Каждое сообщение получает оценку
+// злоупотреблений по истории заявителя и адреса, с которого оно подано.
+type ReportService struct {
+    reportRepo repositories.ReportRepository
+    incidents  *IncidentService
+    taxonomy   *TaxonomyService
+    options    ReportOptions
+    logger     *logger.Logger
+}
+
+func NewReportService(
+    reportRepo repositories.ReportRepository,
+    incidents *IncidentService,
+    taxonomy *TaxonomyService,
+    options ReportOptions,
+    logger *logger.Logger,
+) *ReportService {
+    return &ReportService{
+        reportRepo: reportRepo,
+        incidents:  incidents,
+        taxonomy:   taxonomy,
+        options:    options,
+        logger:     logger,
+    }
+}
+
+// SubmitReport ставит сообщение в очередь модерации. ip - адрес заявителя.
+func (s *ReportService) SubmitReport(ctx context.Context, req models.ReportRequest, ip string) (*models.IncidentReport, error) {
+    report, err := s.newReport(ctx, req)
+    if err != nil {
+        return nil, err
+    }
+    report.ReporterIP = ip
+
+    now := time.Now()
+    report.AbuseScore, err = s.abuseScore(ctx, report.UserID, ip, now)
+    if err != nil {
+        return nil, err
+    }
+    if s.options.BlockScore > 0 && report.AbuseScore >= s.options.BlockScore {
+        s.logger.Warn("Report from %s (%s) refused: abuse score %.2f", report.UserID, ip, report.AbuseScore)
+        return nil, apperrors.NewForbiddenError()
+    }
+
+    report.CreatedAt = now
+    limit := models.ReportRateLimit{
+        Since:       now.Add(-s.options.RateWindow),
+        PerReporter: s.options.PerReporter,
+        PerAddress:  s.options.PerAddress,
+    }
+    if err := s.reportRepo.Create(ctx, report, limit); err != nil {
+        if errors.Is(err, repositories.ErrRateLimited) {
+            return nil, apperrors.NewTooManyRequestsError("too many reports, try again later")
+        }
+        return nil, fmt.Errorf("failed to create report: %w", err)
+    }
+    return report, nil
+}
+
+// newReport проверяет сообщение жителя; категория и уровень проверяются
+// по справочникам, как у инцидента
+func (s *ReportService) newReport(ctx context.Context, req models.ReportRequest) (*models.IncidentReport, error) {
+    userID := strings.TrimSpace(req.UserID)
+    if userID == "" || len(userID) > maxReportUserID {
+        return nil, apperrors.NewValidationError(fmt.Errorf("user_id must be from 1 to %d characters", maxReportUserID))
+    }
+    if req.Latitude < -90 || req.Latitude > 90 || req.Longitude < -180 || req.Longitude > 180 {
+        return nil, apperrors.NewValidationError(fmt.Errorf("invalid coordinates"))
+    }
+    description := strings.TrimSpace(req.Description)
+    if length := len([]rune(description)); length < minReportDescription || length > maxReportDescription {
+        return nil, apperrors.NewValidationError(fmt.Errorf(
+            "description must be from %d to %d characters", minReportDescription, maxReportDescription))
+    }
+
+    category := req.Category
+    if category == "" {
+        category = models.DefaultCategory
+    }
+    if s.taxonomy != nil {
+        if err := s.taxonomy.ValidateCategory(ctx, category); err != nil {
+            return nil, err
+        }
+    }
+    if req.Severity != "" && !models.ValidSeverity(req.Severity) {
+        return nil, apperrors.NewValidationError(fmt.Errorf("unknown severity %q", req.Severity))
+    }
+
+    return &models.IncidentReport{
+        UserID:      userID,
+        Latitude:    req.Latitude,
+        Longitude:   req.Longitude,
+        Description: description,
+        Category:    category,
+        Severity:    req.Severity,
+        Status:      models.ReportPending,
+    }, nil
+}
+
+// abuseScore - худшая из оценок заявителя и адреса: так не помогает ни смена
+// идентификатора, ни смена сети
+func (s *ReportService) abuseScore(ctx context.Context, userID, ip string, now time.Time) (float64, error) {
+    since := now.Add(-s.options.AbuseWindow)
+
+    reporter, err := s.reportRepo.ReporterStats(ctx, userID, since)
+    if err != nil {
+        return 0, fmt.Errorf("failed to get reporter stats: %w", err)
+    }
+    score := reporter.Score()
+
+    if ip != "" {
+        address, err := s.reportRepo.AddressStats(ctx, ip, since)
+        if err != nil {
+            return 0, fmt.Errorf("failed to get address stats: %w", err)
+        }
+        score = math.Max(score, address.Score())
+    }
+    return score, nil
+}
+
+func (s *ReportService) ListReports(ctx context.Context, filter models.ReportFilter) ([]*models.IncidentReport, int, error) {
+    return s.reportRepo.FindAll(ctx, filter)
+}
+
+func (s *ReportService) GetReport(ctx context.Context, id int64) (*models.IncidentReport, error) {
+    report, err := s.reportRepo.FindByID(ctx, id)
+    if err != nil {
+        return nil, fmt.Errorf("failed to find report: %w", err)
+    }
+    if report == nil {
+        return nil, apperrors.NewNotFoundError("Report")
+    }
+    return report, nil
+}
+
+// pendingReport возвращает сообщение, ожидающее модерации, и его статус: pending
+// или approving, оставшийся после сбоя
+func (s *ReportService) pendingReport(ctx context.Context, id int64, note string) (*models.IncidentReport, string, error) {
+    if len([]rune(note)) > maxModerationNote {
+        return nil, "", apperrors.NewValidationError(fmt.Errorf("moderation_note must be at most %d characters", maxModerationNote))
+    }
+
+    report, err := s.GetReport(ctx, id)
+    if err != nil {
+        return nil, "", err
+    }
+    stale := report.Status == models.ReportApproving && report.ModeratedAt != nil &&
+        time.Since(*report.ModeratedAt) > reportClaimTimeout
+    if report.Status != models.ReportPending && !stale {
+        return nil, "", apperrors.NewConflictError(fmt.Sprintf("report %d is already %s", id, report.Status))
+    }
+    return report, report.Status, nil
+}
+
+// moderate сохраняет решение по сообщению в статусе from; сообщение, которое успел
+// рассмотреть другой оператор, не меняется
+func (s *ReportService) moderate(ctx context.Context, report *models.IncidentReport, from string) error {
+    now := time.Now()
+    report.ModeratedAt = &now
+
+    ok, err := s.reportRepo.Moderate(ctx, report, from)
+    if err != nil {
+        return fmt.Errorf("failed to moderate report: %w", err)
+    }
+    if !ok {
+        return apperrors.NewConflictError(fmt.Sprintf("report %d has already been moderated", report.ID))
+    }
+    return nil
+}
+
+// ApproveReport создает инцидент по сообщению; поля, не заданные оператором,
+// берутся из сообщения. Возвращает сообщение и созданный инцидент.
+func (s *ReportService) ApproveReport(ctx context.Context, id int64, req models.ApproveReportRequest) (*models.IncidentReport, *models.Incident, error) {
+    report, _, err := s.pendingReport(ctx, id, req.Note)
+    if err != nil {
+        return nil, nil, err
+    }
+
+    incidentReq := models.CreateIncidentRequest{
+        UserID:      report.UserID,
+        Latitude:    report.Latitude,
+        Longitude:   report.Longitude,
+        Title:       strings.TrimSpace(req.Title),
+        Description: report.Description,
+        Severity:    req.Severity,
+        Category:    req.Category,
+        Tags:        req.Tags,
+        Radius:      req.Radius,
+        Polygon:     req.Polygon,
+        Public:      req.Public,
+        ExpiresAt:   req.ExpiresAt,
+    }
+    if incidentReq.Title == "" {
+        incidentReq.Title = models.ReportTitle(report.Description, maxReportTitle)
+    }
+    if length := len([]rune(incidentReq.Title)); length < 3 || length > 255 {
+        return nil, nil, apperrors.NewValidationError(fmt.Errorf("title must be from 3 to 255 characters"))
+    }
+    if req.Description != nil {
+        incidentReq.Description = *req.Description
+    }
+    if incidentReq.Severity == "" {
+        incidentReq.Severity = report.Severity
+    }
+    if incidentReq.Severity == "" {
+        return nil, nil, apperrors.NewValidationError(fmt.Errorf("severity is required: the report does not suggest one"))
+    }
+    if incidentReq.Category == "" {
+        incidentReq.Category = report.Category
+    }
+    if incidentReq.Radius == 0 && len(incidentReq.Polygon) == 0 {
+        incidentReq.Radius = models.DefaultReportRadius
+    }
+
+    // Сообщение захватывается до создания инцидента: инцидент, его события
+    // и оповещения появляются, только если другой оператор сообщение не рассматривает
+    now := time.Now()
+    claimed, err := s.reportRepo.Claim(ctx, id, now, now.Add(-reportClaimTimeout))
+    if err != nil {
+        return nil, nil, fmt.Errorf("failed to claim report: %w", err)
+    }
+    if !claimed {
+        return nil, nil, apperrors.NewConflictError(fmt.Sprintf("report %d has already been moderated", id))
+    }
+
+    incident, err := s.incidents.CreateIncident(ctx, incidentReq)
+    if err != nil {
+        s.release(ctx, id)
+        return nil, nil, err
+    }
+
+    report.Status = models.ReportApproved
+    report.IncidentID = &incident.ID
+    report.Note = req.Note
+    if err := s.moderate(ctx, report, models.ReportApproving); err != nil {
+        s.logger.Error("Incident %d created for report %d, but the report was not approved: %v", incident.ID, id, err)
+        return nil, nil, err
+    }
+    return report, incident, nil
+}
+
+// release возвращает захваченное сообщение в очередь модерации, если инцидент не создан
+func (s *ReportService) release(ctx context.Context, id int64) {
+    pending := &models.IncidentReport{ID: id, Status: models.ReportPending}
+    if _, err := s.reportRepo.Moderate(ctx, pending, models.ReportApproving); err != nil {
+        s.logger.Error("Failed to return report %d to the moderation queue: %v", id, err)
+    }
+}
+
+// MergeReport присоединяет сообщение к известному инциденту
+func (s *ReportService) MergeReport(ctx context.Context, id int64, req models.MergeReportRequest) (*models.IncidentReport, error) {
+    if req.IncidentID <= 0 {
+        return nil, apperrors.NewValidationError(fmt.Errorf("incident_id is required"))
+    }
+
+    report, status, err := s.pendingReport(ctx, id, req.Note)
+    if err != nil {
+        return nil, err
+    }
+
+    incident, err := s.incidents.GetIncident(ctx, req.IncidentID)
+    if err != nil {
+        return nil, fmt.Errorf("failed to find incident: %w", err)
+    }
+    if incident == nil {
+        return nil, apperrors.NewNotFoundError("Incident")
+    }
+    incidentID := incident.ID
+    if incident.MergedInto != nil {
+        // Инцидент-дубликат объединен с другим - сообщение присоединяется к нему
+        incidentID = *incident.MergedInto
+    }
+
+    report.Status = models.ReportMerged
+    report.IncidentID = &incidentID
+    report.Note = req.Note
+    if err := s.moderate(ctx, report, status); err != nil {
+        return nil, err
+    }
+    return report, nil
+}
+
+// RejectReport отклоняет сообщение; отклоненное как ложное или спам ухудшает
+// оценку заявителя и адреса сильнее
+func (s *ReportService) RejectReport(ctx context.Context, id int64, req models.RejectReportRequest) (*models.IncidentReport, error) {
+    report, status, err := s.pendingReport(ctx, id, req.Note)
+    if err != nil {
+        return nil, err
+    }
+
+    report.Status = models.ReportRejected
+    report.Abusive = req.Abusive
+    report.Note = req.Note
+    if err := s.moderate(ctx, report, status); err != nil {
+        return nil, err
+    }
+    return report, nil
+}
+
+// ReporterStats возвращает итоги модерации сообщений заявителя за окно оценки
+func (s *ReportService) ReporterStats(ctx context.Context, userID string) (*models.ReporterStats, error) {
+    stats, err := s.reportRepo.ReporterStats(ctx, userID, time.Now().Add(-s.options.AbuseWindow))
+    if err != nil {
+        return nil, fmt.Errorf("failed to get reporter stats: %w", err)
+    }
+    stats.UserID = userID
+    stats.AbuseScore = stats.Score()
+    return stats, nil
+}
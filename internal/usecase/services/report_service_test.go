@@ -0,0 +1,77 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/domain/repositories"
+	"incident-system/internal/infrastructure/memory"
+	apperrors "incident-system/pkg/errors"
+	"incident-system/pkg/logger"
+)
+
+func newReportFixture(t *testing.T) (*ReportService, repositories.IncidentRepository, repositories.ReportRepository, *models.IncidentReport) {
+    t.Helper()
+    incidents := memory.NewIncidentRepository()
+    reports := memory.NewReportRepository()
+    service := NewReportService(
+        reports,
+        NewIncidentService(incidents, memory.NewCacheRepository(time.Minute, time.Minute), nil, nil, nil, nil),
+        nil,
+        ReportOptions{},
+        logger.NewLogger("test"),
+    )
+
+    report, err := service.SubmitReport(context.Background(), models.ReportRequest{
+        UserID:      "u1",
+        Latitude:    55.7558,
+        Longitude:   37.6173,
+        Description: "Дым из окна третьего этажа",
+        Severity:    "high",
+    }, "10.0.0.1")
+    if err != nil {
+        t.Fatalf("SubmitReport: %v", err)
+    }
+    return service, incidents, reports, report
+}
+
+func TestApproveReportClaimedByAnotherOperator(t *testing.T) {
+    ctx := context.Background()
+    service, incidents, reports, report := newReportFixture(t)
+
+    // Другой оператор уже создает инцидент по сообщению
+    now := time.Now()
+    if ok, err := reports.Claim(ctx, report.ID, now, now.Add(-reportClaimTimeout)); err != nil || !ok {
+        t.Fatalf("Claim = %v, %v", ok, err)
+    }
+
+    _, _, err := service.ApproveReport(ctx, report.ID, models.ApproveReportRequest{})
+    if appErr := apperrors.FromError(err); err == nil || appErr.Code != http.StatusConflict {
+        t.Fatalf("ApproveReport = %v, want 409", err)
+    }
+    if count, err := incidents.CountAll(ctx, models.IncidentFilter{}); err != nil || count != 0 {
+        t.Fatalf("CountAll = %d, %v, want no incidents", count, err)
+    }
+}
+
+func TestApproveReportReleasesReportWhenIncidentFails(t *testing.T) {
+    ctx := context.Background()
+    service, _, reports, report := newReportFixture(t)
+
+    _, _, err := service.ApproveReport(ctx, report.ID, models.ApproveReportRequest{Severity: "extreme"})
+    if appErr := apperrors.FromError(err); err == nil || appErr.Code != http.StatusBadRequest {
+        t.Fatalf("ApproveReport = %v, want 400", err)
+    }
+    found, err := reports.FindByID(ctx, report.ID)
+    if err != nil || found.Status != models.ReportPending {
+        t.Fatalf("FindByID = %+v, %v, want the report back in the queue", found, err)
+    }
+
+    approved, incident, err := service.ApproveReport(ctx, report.ID, models.ApproveReportRequest{})
+    if err != nil || approved.Status != models.ReportApproved || approved.IncidentID == nil || *approved.IncidentID != incident.ID {
+        t.Fatalf("ApproveReport = %+v, %+v, %v", approved, incident, err)
+    }
+}
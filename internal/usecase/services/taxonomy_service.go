@@ -0,0 +1,277 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/domain/repositories"
+	apperrors "incident-system/pkg/errors"
+	"incident-system/pkg/logger"
+)
+
+// TaxonomyService управляет справочником категорий и шкалой опасности.
+// Действующую шкалу процесса (models.SetSeverityScale) он загружает при старте,
+// обновляет после изменений и периодически перечитывает, чтобы изменения,
+// сделанные через другой экземпляр сервиса, тоже вступили в силу.
+type TaxonomyService struct {
+    taxonomyRepo repositories.TaxonomyRepository
+    interval     time.Duration
+    logger       *logger.Logger
+}
+
+func NewTaxonomyService(
+    taxonomyRepo repositories.TaxonomyRepository,
+    interval time.Duration,
+    logger *logger.Logger,
+) *TaxonomyService {
+    return &TaxonomyService{
+        taxonomyRepo: taxonomyRepo,
+        interval:     interval,
+        logger:       logger,
+    }
+}
+
+// LoadSeverityScale перечитывает шкалу опасности из хранилища
+func (s *TaxonomyService) LoadSeverityScale(ctx context.Context) error {
+    levels, err := s.taxonomyRepo.ListSeverityLevels(ctx)
+    if err != nil {
+        return fmt.Errorf("failed to load severity scale: %w", err)
+    }
+    models.SetSeverityScale(levels)
+    return nil
+}
+
+func (s *TaxonomyService) Start(ctx context.Context) {
+    go func() {
+        ticker := time.NewTicker(s.interval)
+        defer ticker.Stop()
+
+        for {
+            select {
+            case <-ctx.Done():
+                s.logger.Info("Taxonomy refresher stopped")
+                return
+            case <-ticker.C:
+                if err := s.LoadSeverityScale(ctx); err != nil {
+                    s.logger.Error("Severity scale refresh failed: %v", err)
+                }
+            }
+        }
+    }()
+}
+
+func (s *TaxonomyService) ListCategories(ctx context.Context) ([]*models.Category, error) {
+    categories, err := s.taxonomyRepo.ListCategories(ctx)
+    if err != nil {
+        return nil, err
+    }
+    if categories == nil {
+        categories = []*models.Category{}
+    }
+    return categories, nil
+}
+
+// ValidateCategory проверяет, что категория есть в справочнике
+func (s *TaxonomyService) ValidateCategory(ctx context.Context, code string) error {
+    category, err := s.taxonomyRepo.FindCategory(ctx, code)
+    if err != nil {
+        return fmt.Errorf("failed to find category: %w", err)
+    }
+    if category == nil {
+        return apperrors.NewValidationError(fmt.Errorf("unknown category %q", code))
+    }
+    return nil
+}
+
+// CreateCategory добавляет категорию; для подкатегории родитель должен существовать
+func (s *TaxonomyService) CreateCategory(ctx context.Context, req models.CategoryRequest) (*models.Category, error) {
+    code := strings.ToLower(strings.TrimSpace(req.Code))
+    if !models.ValidCategoryCode(code) {
+        return nil, apperrors.NewValidationError(fmt.Errorf("invalid category code %q", req.Code))
+    }
+
+    existing, err := s.taxonomyRepo.FindCategory(ctx, code)
+    if err != nil {
+        return nil, fmt.Errorf("failed to find category: %w", err)
+    }
+    if existing != nil {
+        return nil, apperrors.NewConflictError(fmt.Sprintf("category %s already exists", code))
+    }
+
+    parent := models.ParentCategory(code)
+    if parent != "" {
+        if err := s.ValidateCategory(ctx, parent); err != nil {
+            return nil, err
+        }
+    }
+
+    category := &models.Category{
+        Code:      code,
+        Parent:    parent,
+        CreatedAt: time.Now(),
+    }
+    if err := applyCategoryRequest(category, req); err != nil {
+        return nil, apperrors.NewValidationError(err)
+    }
+
+    if err := s.taxonomyRepo.SaveCategory(ctx, category); err != nil {
+        return nil, fmt.Errorf("failed to save category: %w", err)
+    }
+    return category, nil
+}
+
+// UpdateCategory меняет название и описание; код и родитель категории неизменны
+func (s *TaxonomyService) UpdateCategory(ctx context.Context, code string, req models.CategoryRequest) (*models.Category, error) {
+    category, err := s.taxonomyRepo.FindCategory(ctx, code)
+    if err != nil {
+        return nil, fmt.Errorf("failed to find category: %w", err)
+    }
+    if category == nil {
+        return nil, apperrors.NewNotFoundError("Category")
+    }
+    if req.Code != "" && req.Code != code {
+        return nil, apperrors.NewValidationError(fmt.Errorf("category code cannot be changed"))
+    }
+
+    if err := applyCategoryRequest(category, req); err != nil {
+        return nil, apperrors.NewValidationError(err)
+    }
+
+    if err := s.taxonomyRepo.SaveCategory(ctx, category); err != nil {
+        return nil, fmt.Errorf("failed to save category: %w", err)
+    }
+    return category, nil
+}
+
+func applyCategoryRequest(category *models.Category, req models.CategoryRequest) error {
+    name := strings.TrimSpace(req.Name)
+    if name == "" {
+        return fmt.Errorf("name is required")
+    }
+    if len([]rune(name)) > 100 {
+        return fmt.Errorf("name must be at most 100 characters")
+    }
+    if len([]rune(req.Description)) > 1000 {
+        return fmt.Errorf("description must be at most 1000 characters")
+    }
+
+    category.Name = name
+    category.Description = req.Description
+    return nil
+}
+
+// DeleteCategory удаляет категорию без подкатегорий и инцидентов; категорию
+// по умолчанию удалить нельзя
+func (s *TaxonomyService) DeleteCategory(ctx context.Context, code string) error {
+    if code == models.DefaultCategory {
+        return apperrors.NewConflictError("default category cannot be deleted")
+    }
+
+    category, err := s.taxonomyRepo.FindCategory(ctx, code)
+    if err != nil {
+        return fmt.Errorf("failed to find category: %w", err)
+    }
+    if category == nil {
+        return apperrors.NewNotFoundError("Category")
+    }
+
+    if err := s.taxonomyRepo.DeleteCategory(ctx, code); err != nil {
+        if errors.Is(err, repositories.ErrInUse) {
+            return apperrors.NewConflictError(fmt.Sprintf("category %s has subcategories or incidents", code))
+        }
+        return fmt.Errorf("failed to delete category: %w", err)
+    }
+    return nil
+}
+
+func (s *TaxonomyService) ListSeverityLevels(ctx context.Context) ([]*models.SeverityLevel, error) {
+    levels, err := s.taxonomyRepo.ListSeverityLevels(ctx)
+    if err != nil {
+        return nil, err
+    }
+    if levels == nil {
+        levels = []*models.SeverityLevel{}
+    }
+    return levels, nil
+}
+
+// SaveSeverityLevel создает или заменяет уровень шкалы; числовые уровни не повторяются
+func (s *TaxonomyService) SaveSeverityLevel(ctx context.Context, code string, req models.SeverityLevelRequest) (*models.SeverityLevel, error) {
+    if !models.ValidSeverityCode(code) {
+        return nil, apperrors.NewValidationError(fmt.Errorf("invalid severity code %q", code))
+    }
+    name := strings.TrimSpace(req.Name)
+    if name == "" || len([]rune(name)) > 100 {
+        return nil, apperrors.NewValidationError(fmt.Errorf("name must be from 1 to 100 characters"))
+    }
+    if req.Level <= 0 {
+        return nil, apperrors.NewValidationError(fmt.Errorf("level must be positive"))
+    }
+    if !models.ValidSeverityColor(req.Color) {
+        return nil, apperrors.NewValidationError(fmt.Errorf("color must be in #RRGGBB format"))
+    }
+
+    levels, err := s.taxonomyRepo.ListSeverityLevels(ctx)
+    if err != nil {
+        return nil, fmt.Errorf("failed to list severity levels: %w", err)
+    }
+    for _, existing := range levels {
+        if existing.Code != code && existing.Level == req.Level {
+            return nil, apperrors.NewConflictError(fmt.Sprintf("level %d is already used by %s", req.Level, existing.Code))
+        }
+    }
+
+    level := &models.SeverityLevel{
+        Code:  code,
+        Name:  name,
+        Level: req.Level,
+        Color: strings.ToUpper(req.Color),
+    }
+    if err := s.taxonomyRepo.SaveSeverityLevel(ctx, level); err != nil {
+        return nil, fmt.Errorf("failed to save severity level: %w", err)
+    }
+
+    if err := s.LoadSeverityScale(ctx); err != nil {
+        s.logger.Error("%v", err)
+    }
+    return level, nil
+}
+
+// defaultSeverities - уровни, которые подставляются по умолчанию в зонах наблюдения
+// и настройках оповещений, поэтому их нельзя удалить
+var defaultSeverities = map[string]bool{"low": true, "high": true}
+
+// DeleteSeverityLevel удаляет уровень, на который ничто не ссылается
+func (s *TaxonomyService) DeleteSeverityLevel(ctx context.Context, code string) error {
+    if defaultSeverities[code] {
+        return apperrors.NewConflictError(fmt.Sprintf("default severity level %s cannot be deleted", code))
+    }
+
+    levels, err := s.taxonomyRepo.ListSeverityLevels(ctx)
+    if err != nil {
+        return fmt.Errorf("failed to list severity levels: %w", err)
+    }
+    found := false
+    for _, level := range levels {
+        found = found || level.Code == code
+    }
+    if !found {
+        return apperrors.NewNotFoundError("Severity level")
+    }
+
+    if err := s.taxonomyRepo.DeleteSeverityLevel(ctx, code); err != nil {
+        if errors.Is(err, repositories.ErrInUse) {
+            return apperrors.NewConflictError(fmt.Sprintf("severity level %s is in use", code))
+        }
+        return fmt.Errorf("failed to delete severity level: %w", err)
+    }
+
+    if err := s.LoadSeverityScale(ctx); err != nil {
+        s.logger.Error("%v", err)
+    }
+    return nil
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"incident-system/internal/domain/models"
+	"incident-system/pkg/logger"
+)
+
+// UserTokenService выдает и проверяет токены пользователей. Токен - срок действия
+// и HMAC-SHA256 от user_id и срока, поэтому хранить токены не нужно, а токен
+// одного пользователя не подходит к ресурсам другого.
+type UserTokenService struct {
+    secret []byte
+    ttl    time.Duration
+}
+
+func NewUserTokenService(secret []byte, ttl time.Duration, logger *logger.Logger) *UserTokenService {
+    if len(secret) == 0 {
+        // Без заданного ключа токены действуют только до перезапуска и только на этом экземпляре
+        secret = make([]byte, 32)
+        rand.Read(secret)
+        logger.Warn("USER_TOKEN_SECRET is not set: user tokens will not survive a restart")
+    }
+
+    return &UserTokenService{
+        secret: secret,
+        ttl:    ttl,
+    }
+}
+
+// Issue выдает токен пользователю, действующий ttl с момента now
+func (s *UserTokenService) Issue(userID string, now time.Time) *models.UserToken {
+    expiresAt := now.Add(s.ttl).Truncate(time.Second)
+    expires := strconv.FormatInt(expiresAt.Unix(), 10)
+
+    return &models.UserToken{
+        UserID:    userID,
+        Token:     expires + "." + s.signature(userID, expires),
+        ExpiresAt: expiresAt,
+    }
+}
+
+// Verify проверяет, что токен выдан пользователю userID и еще действует
+func (s *UserTokenService) Verify(userID, token string, now time.Time) bool {
+    expires, signature, ok := strings.Cut(token, ".")
+    if !ok || userID == "" {
+        return false
+    }
+
+    unix, err := strconv.ParseInt(expires, 10, 64)
+    if err != nil || now.Unix() > unix {
+        return false
+    }
+    return hmac.Equal([]byte(signature), []byte(s.signature(userID, expires)))
+}
+
+func (s *UserTokenService) signature(userID, expires string) string {
+    mac := hmac.New(sha256.New, s.secret)
+    fmt.Fprintf(mac, "%s\n%s", userID, expires)
+    return hex.EncodeToString(mac.Sum(nil))
+}
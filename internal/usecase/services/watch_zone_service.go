@@ -0,0 +1,253 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/domain/repositories"
+	apperrors "incident-system/pkg/errors"
+	"incident-system/pkg/geo"
+	"incident-system/pkg/logger"
+)
+
+const (
+    minWatchZoneRadius   = 10.0    // м
+    maxWatchZoneRadius   = 50000.0 // м
+    maxWatchZoneVertices = 500
+    maxWatchZoneName     = 100
+)
+
+// WatchZoneService управляет зонами наблюдения пользователей и оповещает их
+// владельцев об инцидентах, зона которых пересекает зону наблюдения
+type WatchZoneService struct {
+    zoneRepo    repositories.WatchZoneRepository
+    queueRepo   repositories.QueueRepository
+    preferences *NotificationPreferencesService
+    perUser     int // 0 - без ограничения
+    logger      *logger.Logger
+}
+
+func NewWatchZoneService(
+    zoneRepo repositories.WatchZoneRepository,
+    queueRepo repositories.QueueRepository,
+    preferences *NotificationPreferencesService,
+    perUser int,
+    logger *logger.Logger,
+) *WatchZoneService {
+    return &WatchZoneService{
+        zoneRepo:    zoneRepo,
+        queueRepo:   queueRepo,
+        preferences: preferences,
+        perUser:     perUser,
+        logger:      logger,
+    }
+}
+
+// applyWatchZoneRequest проверяет запрос и переносит его поля в зону
+func applyWatchZoneRequest(zone *models.WatchZone, req models.WatchZoneRequest) error {
+    name := strings.TrimSpace(req.Name)
+    if name == "" {
+        return fmt.Errorf("name is required")
+    }
+    if len([]rune(name)) > maxWatchZoneName {
+        return fmt.Errorf("name must be at most %d characters", maxWatchZoneName)
+    }
+    if req.Latitude < -90 || req.Latitude > 90 || req.Longitude < -180 || req.Longitude > 180 {
+        return fmt.Errorf("invalid coordinates")
+    }
+    if req.MinSeverity == "" {
+        req.MinSeverity = "low"
+    }
+    if !models.ValidSeverity(req.MinSeverity) {
+        return fmt.Errorf("unknown min_severity %q", req.MinSeverity)
+    }
+
+    zone.Name = name
+    zone.Latitude = req.Latitude
+    zone.Longitude = req.Longitude
+    zone.MinSeverity = req.MinSeverity
+    zone.Polygon = nil
+    zone.Radius = req.Radius
+
+    if len(req.Polygon) > 0 {
+        if len(req.Polygon) < 3 || len(req.Polygon) > maxWatchZoneVertices {
+            return fmt.Errorf("polygon must have from 3 to %d vertices", maxWatchZoneVertices)
+        }
+        for _, p := range req.Polygon {
+            if p.Latitude < -90 || p.Latitude > 90 || p.Longitude < -180 || p.Longitude > 180 {
+                return fmt.Errorf("invalid polygon vertex")
+            }
+        }
+        zone.Polygon = req.Polygon
+        // Как и у инцидента, радиус многоугольника - радиус описанной окружности
+        zone.Radius = geo.CircumradiusKm(req.Latitude, req.Longitude, req.Polygon) * 1000
+        return nil
+    }
+
+    if req.Radius < minWatchZoneRadius || req.Radius > maxWatchZoneRadius {
+        return fmt.Errorf("radius must be between %.0f and %.0f meters", minWatchZoneRadius, maxWatchZoneRadius)
+    }
+    return nil
+}
+
+func (s *WatchZoneService) CreateZone(ctx context.Context, userID string, req models.WatchZoneRequest) (*models.WatchZone, error) {
+    zone := &models.WatchZone{UserID: userID}
+    if err := applyWatchZoneRequest(zone, req); err != nil {
+        return nil, apperrors.NewValidationError(err)
+    }
+
+    if s.perUser > 0 {
+        existing, err := s.zoneRepo.FindByUser(ctx, userID)
+        if err != nil {
+            return nil, fmt.Errorf("failed to count watch zones: %w", err)
+        }
+        if len(existing) >= s.perUser {
+            return nil, apperrors.NewValidationError(fmt.Errorf("at most %d watch zones per user", s.perUser))
+        }
+    }
+
+    now := time.Now()
+    zone.CreatedAt = now
+    zone.UpdatedAt = now
+
+    if err := s.zoneRepo.Create(ctx, zone); err != nil {
+        return nil, fmt.Errorf("failed to create watch zone: %w", err)
+    }
+    return zone, nil
+}
+
+func (s *WatchZoneService) ListZones(ctx context.Context, userID string) ([]*models.WatchZone, error) {
+    return s.zoneRepo.FindByUser(ctx, userID)
+}
+
+// GetZone возвращает зону пользователя; чужая зона считается не найденной
+func (s *WatchZoneService) GetZone(ctx context.Context, userID string, id int64) (*models.WatchZone, error) {
+    zone, err := s.zoneRepo.FindByID(ctx, id)
+    if err != nil {
+        return nil, fmt.Errorf("failed to find watch zone: %w", err)
+    }
+    if zone == nil || zone.UserID != userID {
+        return nil, apperrors.NewNotFoundError("watch zone")
+    }
+    return zone, nil
+}
+
+func (s *WatchZoneService) UpdateZone(ctx context.Context, userID string, id int64, req models.WatchZoneRequest) (*models.WatchZone, error) {
+    zone, err := s.GetZone(ctx, userID, id)
+    if err != nil {
+        return nil, err
+    }
+
+    if err := applyWatchZoneRequest(zone, req); err != nil {
+        return nil, apperrors.NewValidationError(err)
+    }
+    zone.UpdatedAt = time.Now()
+
+    if err := s.zoneRepo.Update(ctx, zone); err != nil {
+        return nil, fmt.Errorf("failed to update watch zone: %w", err)
+    }
+    return zone, nil
+}
+
+func (s *WatchZoneService) DeleteZone(ctx context.Context, userID string, id int64) error {
+    if _, err := s.GetZone(ctx, userID, id); err != nil {
+        return err
+    }
+
+    if err := s.zoneRepo.Delete(ctx, id); err != nil {
+        return fmt.Errorf("failed to delete watch zone: %w", err)
+    }
+    return nil
+}
+
+// NotifyIncident ставит watch_zone_alert владельцам зон, которые пересекает
+// активный инцидент подходящего уровня, и возвращает число оповещений.
+// Владелец зоны получает оповещение об инциденте один раз, поэтому повторный
+// вызов после изменения инцидента оповещает только о новых пересечениях.
+func (s *WatchZoneService) NotifyIncident(ctx context.Context, incident *models.Incident) (int, error) {
+    now := time.Now()
+    if !incident.Active || incident.Expired(now) {
+        return 0, nil
+    }
+
+    candidates, err := s.zoneRepo.FindIntersecting(ctx, incident)
+    if err != nil {
+        return 0, err
+    }
+
+    zones := make(map[int64]*models.WatchZone)
+    var zoneIDs []int64
+    for _, zone := range candidates {
+        if models.SeverityAtLeast(incident.Severity, zone.MinSeverity) {
+            zones[zone.ID] = zone
+            zoneIDs = append(zoneIDs, zone.ID)
+        }
+    }
+    if len(zoneIDs) == 0 {
+        return 0, nil
+    }
+
+    marked, err := s.zoneRepo.MarkNotified(ctx, incident.ID, zoneIDs, now)
+    if err != nil {
+        return 0, err
+    }
+
+    queued := 0
+    var enqueueErr error
+    for _, zoneID := range marked {
+        zone := zones[zoneID]
+        incidents := s.preferences.Admit(ctx, zone.UserID, []models.IncidentShort{
+            incident.Short(calculateDistance(zone.Latitude, zone.Longitude, incident.Latitude, incident.Longitude) * 1000),
+        }, now)
+        if len(incidents) == 0 {
+            continue
+        }
+        payload := models.WebhookPayload{
+            EventType: models.EventWatchZoneAlert,
+            // Пара зона-инцидент оповещается один раз, поэтому ID детерминирован
+            EventID:   "watch-zone-" + strconv.FormatInt(zone.ID, 10) + "-incident-" + strconv.FormatInt(incident.ID, 10),
+            UserID:    zone.UserID,
+            Latitude:  zone.Latitude,
+            Longitude: zone.Longitude,
+            Incidents: incidents,
+            Timestamp: now,
+            WatchZone: zone,
+        }
+        if err := s.queueRepo.EnqueueWebhook(ctx, payload); err != nil {
+            enqueueErr = err
+            continue
+        }
+        queued++
+    }
+
+    if queued > 0 {
+        s.logger.Info("Incident %d: watch zone alerts queued for %d zones", incident.ID, queued)
+    }
+    return queued, enqueueErr
+}
+
+// watchZonePublisher сопоставляет созданные и измененные инциденты с зонами наблюдения
+type watchZonePublisher struct {
+    service *WatchZoneService
+}
+
+func NewWatchZonePublisher(service *WatchZoneService) EventPublisher {
+    return &watchZonePublisher{service: service}
+}
+
+func (p *watchZonePublisher) Name() string {
+    return "watch_zones"
+}
+
+func (p *watchZonePublisher) Publish(ctx context.Context, event *models.OutboxEvent) error {
+    switch event.EventType {
+    case models.EventIncidentCreated, models.EventIncidentUpdated:
+        _, err := p.service.NotifyIncident(ctx, event.Incident)
+        return err
+    }
+    return nil
+}
@@ -0,0 +1,495 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/domain/repositories"
+	"incident-system/pkg/circuitbreaker"
+	"incident-system/pkg/logger"
+)
+
+// WebhookSender выполняет одну попытку доставки вебхука
+type WebhookSender interface {
+    Send(ctx context.Context, url string, body []byte, headers map[string]string) (*models.WebhookResponse, error)
+}
+
+type WebhookDispatcherOptions struct {
+    DefaultURL    string // получатель всех вебхуков помимо подписок
+    DefaultFormat string // формат тела для DefaultURL
+
+    EventSource   string // атрибут source CloudEvents
+    SchemaBaseURL string // внешний адрес сервиса для атрибута dataschema
+
+    Workers                int
+    DestinationConcurrency int     // одновременных запросов к одному получателю
+    DestinationRate        float64 // запросов в секунду к одному получателю
+    DestinationBurst       int
+
+    MaxAttempts       int
+    BaseRetryDelay    time.Duration
+    MaxRetryDelay     time.Duration
+    RetryPollInterval time.Duration
+
+    BreakerFailures    int           // сбоев подряд до размыкания автомата получателя; 0 - без автоматов
+    BreakerOpenTimeout time.Duration // пауза перед пробным запросом
+    DisableAfter       time.Duration // отключать подписку, сбоящую дольше; 0 - не отключать
+    MaxHoldAge         time.Duration // отбрасывать задачу, которую автомат держит дольше; 0 - см. holdAgeFactor
+}
+
+// holdAgeFactor задает MaxHoldAge по умолчанию: столько раз по MaxAttempts
+// наибольших пауз между попытками или пробами автомата
+const holdAgeFactor = 10
+
+// WebhookDispatcher рассылает вебхуки пулом воркеров. Медленный или сбойный
+// получатель не задерживает остальных: ограничения применяются к каждому
+// получателю отдельно, а повторы не ждут в воркере, а откладываются в очередь
+// повторов с экспоненциальной задержкой и учетом Retry-After.
+// Каждая попытка доставки записывается в журнал доставок. Получатель, сбоящий
+// подряд, отсекается автоматом, а подписка, сбоящая дольше DisableAfter,
+// отключается.
+type WebhookDispatcher struct {
+    queueRepo        repositories.QueueRepository
+    subscriptionRepo repositories.WebhookSubscriptionRepository
+    deliveryRepo     repositories.WebhookDeliveryRepository
+    sender           WebhookSender
+    limiter          *destinationLimiter
+    breakers         *destinationBreakers
+    encoder          webhookEncoder
+    opts             WebhookDispatcherOptions
+    logger           *logger.Logger
+    tasks            chan models.WebhookTask
+}
+
+// webhookDestination - получатель конкретного события
+type webhookDestination struct {
+    subscriptionID int64
+    url            string
+    format         string
+}
+
+func NewWebhookDispatcher(
+    queueRepo repositories.QueueRepository,
+    subscriptionRepo repositories.WebhookSubscriptionRepository,
+    deliveryRepo repositories.WebhookDeliveryRepository,
+    sender WebhookSender,
+    opts WebhookDispatcherOptions,
+    logger *logger.Logger,
+) *WebhookDispatcher {
+    if opts.Workers < 1 {
+        opts.Workers = 1
+    }
+    if opts.MaxAttempts < 1 {
+        opts.MaxAttempts = 1
+    }
+    if opts.MaxHoldAge <= 0 {
+        opts.MaxHoldAge = holdAgeFactor * time.Duration(opts.MaxAttempts) * max(opts.MaxRetryDelay, opts.BreakerOpenTimeout)
+    }
+
+    return &WebhookDispatcher{
+        queueRepo:        queueRepo,
+        subscriptionRepo: subscriptionRepo,
+        deliveryRepo:     deliveryRepo,
+        sender:           sender,
+        limiter:          newDestinationLimiter(opts.DestinationConcurrency, opts.DestinationRate, opts.DestinationBurst),
+        breakers:         newDestinationBreakers(opts.BreakerFailures, opts.BreakerOpenTimeout, logger),
+        encoder:          webhookEncoder{source: opts.EventSource, schemaBaseURL: opts.SchemaBaseURL},
+        opts:             opts,
+        logger:           logger,
+        tasks:            make(chan models.WebhookTask),
+    }
+}
+
+func (d *WebhookDispatcher) Start(ctx context.Context) {
+    for i := 0; i < d.opts.Workers; i++ {
+        go d.worker(ctx)
+    }
+    go d.fetchLoop(ctx)
+    go d.retryLoop(ctx)
+}
+
+// fetchLoop забирает новые вебхуки из очереди и создает задачи для получателей
+func (d *WebhookDispatcher) fetchLoop(ctx context.Context) {
+    for {
+        payload, err := d.queueRepo.DequeueWebhook(ctx)
+        if err != nil {
+            if ctx.Err() != nil {
+                d.logger.Info("Webhook dispatcher stopped")
+                return
+            }
+            d.logger.Error("Failed to dequeue webhook: %v", err)
+            sleepContext(ctx, time.Second)
+            continue
+        }
+        if payload == nil {
+            continue
+        }
+        if payload.EventID == "" {
+            // Событие без ID (например, из журнала старой версии) - ID нужен для дедупликации
+            payload.EventID = models.NewEventID()
+        }
+
+        destinations, err := d.destinations(ctx, *payload)
+        if err != nil {
+            // Без списка подписок не знаем получателей - возвращаем событие в очередь
+            d.logger.Error("Failed to load webhook subscriptions: %v", err)
+            if err := d.queueRepo.EnqueueWebhook(context.WithoutCancel(ctx), *payload); err != nil {
+                d.logger.Error("Webhook %s lost: failed to requeue: %v", payload.EventType, err)
+            }
+            sleepContext(ctx, time.Second)
+            continue
+        }
+
+        for _, destination := range destinations {
+            task := models.NewWebhookTask(destination.subscriptionID, destination.url, *payload)
+            task.Format = destination.format
+            d.dispatch(ctx, task)
+        }
+    }
+}
+
+// retryLoop возвращает воркерам задачи, срок повтора которых наступил
+func (d *WebhookDispatcher) retryLoop(ctx context.Context) {
+    ticker := time.NewTicker(d.opts.RetryPollInterval)
+    defer ticker.Stop()
+
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+        }
+
+        // Срок фиксируем на цикл: задачи, отложенные в этом цикле, ждут следующего
+        now := time.Now()
+        for {
+            tasks, err := d.queueRepo.DueWebhookTasks(ctx, now, d.opts.Workers)
+            if err != nil {
+                if ctx.Err() == nil {
+                    d.logger.Error("Failed to fetch webhook retries: %v", err)
+                }
+                break
+            }
+            for _, task := range tasks {
+                d.dispatch(ctx, task)
+            }
+            if len(tasks) < d.opts.Workers {
+                break
+            }
+        }
+    }
+}
+
+// dispatch передает задачу свободному воркеру; при остановке откладывает ее
+func (d *WebhookDispatcher) dispatch(ctx context.Context, task models.WebhookTask) {
+    select {
+    case d.tasks <- task:
+    case <-ctx.Done():
+        d.reschedule(ctx, task, 0)
+    }
+}
+
+func (d *WebhookDispatcher) worker(ctx context.Context) {
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case task := <-d.tasks:
+            d.deliver(ctx, task)
+        }
+    }
+}
+
+func (d *WebhookDispatcher) deliver(ctx context.Context, task models.WebhookTask) {
+    destination := destinationKey(task.Destination)
+
+    // Получатель занят или исчерпал лимит - откладываем, не занимая воркер
+    acquired, wait := d.limiter.acquire(destination, time.Now())
+    if !acquired {
+        d.reschedule(ctx, task, wait)
+        return
+    }
+    defer d.limiter.release(destination)
+
+    body, headers, err := d.encoder.encode(task)
+    if err != nil {
+        d.drop(ctx, task, nil, fmt.Sprintf("failed to encode payload: %v", err))
+        return
+    }
+
+    // Автомат разомкнут - получатель недоступен, запрос не отправляем
+    var breaker *circuitbreaker.Breaker
+    if d.breakers.enabled() {
+        breaker = d.breakers.get(destination)
+        if err := breaker.Allow(); err != nil {
+            d.hold(ctx, task, body, breaker)
+            return
+        }
+    }
+
+    headers["X-Webhook-ID"] = task.ID
+    headers["X-Webhook-Event"] = task.Payload.EventType
+    headers["X-Webhook-Attempt"] = strconv.Itoa(task.Attempt + 1)
+
+    resp, err := d.sender.Send(ctx, task.Destination, body, headers)
+    if err != nil && ctx.Err() != nil {
+        // Прервано остановкой сервиса - попытку не засчитываем
+        d.reschedule(ctx, task, 0)
+        return
+    }
+
+    task.Attempt++
+    d.recordAttempt(ctx, task, body, resp, err)
+
+    delivered := err == nil && resp.StatusCode >= 200 && resp.StatusCode < 300
+    if breaker != nil {
+        // Отказ по содержимому (4xx) - получатель жив, автомат его не учитывает
+        if err != nil || retryableStatus(resp.StatusCode) {
+            breaker.Failure()
+        } else {
+            breaker.Success()
+        }
+    }
+
+    if err != nil {
+        task.LastError = err.Error()
+    } else if !delivered {
+        task.LastError = fmt.Sprintf("unexpected status code: %d", resp.StatusCode)
+    }
+
+    if d.trackSubscription(ctx, task, delivered) {
+        d.logger.Error("Webhook %s to %s dropped: subscription %d disabled", task.ID, task.Destination, task.SubscriptionID)
+        return
+    }
+
+    if delivered {
+        d.logger.Info("Webhook %s (%s) delivered to %s in %v",
+            task.ID, task.Payload.EventType, task.Destination, resp.Latency)
+        return
+    }
+
+    if err == nil && !retryableStatus(resp.StatusCode) {
+        d.logger.Error("Webhook %s to %s rejected: %s", task.ID, task.Destination, task.LastError)
+        return
+    }
+    if task.Attempt >= d.opts.MaxAttempts {
+        d.logger.Error("Webhook %s to %s dropped after %d attempts: %s",
+            task.ID, task.Destination, task.Attempt, task.LastError)
+        return
+    }
+
+    delay := d.backoff(task.Attempt)
+    if err == nil && resp.RetryAfter > 0 {
+        // Получатель сам назвал срок - до него не шлем ему ничего
+        d.limiter.block(destination, time.Now().Add(resp.RetryAfter))
+        if resp.RetryAfter > delay {
+            delay = resp.RetryAfter
+        }
+    }
+
+    d.logger.Warn("Webhook %s attempt %d/%d to %s failed: %s, retry in %v",
+        task.ID, task.Attempt, d.opts.MaxAttempts, task.Destination, task.LastError, delay)
+    d.reschedule(ctx, task, delay)
+}
+
+// hold откладывает задачу, не отправленную из-за разомкнутого автомата, до пробы
+// автомата. Запроса не было, поэтому попытка не засчитывается и бюджет повторов
+// не расходуется. Задачи отключенных и удаленных подписок отбрасываются, как и задачи
+// старше MaxHoldAge: WEBHOOK_URL не отключается, и без этого предела очередь повторов
+// недоступного получателя росла бы без конца.
+func (d *WebhookDispatcher) hold(ctx context.Context, task models.WebhookTask, body []byte, breaker *circuitbreaker.Breaker) {
+    if age := time.Since(task.CreatedAt); age > d.opts.MaxHoldAge {
+        d.drop(ctx, task, body, fmt.Sprintf("destination unavailable (circuit breaker open) for %v", age.Round(time.Second)))
+        return
+    }
+    if task.SubscriptionID != models.DefaultSubscriptionID {
+        subscription, err := d.subscriptionRepo.FindByID(ctx, task.SubscriptionID)
+        if err == nil && (subscription == nil || !subscription.Active) {
+            d.logger.Info("Webhook %s to %s dropped: subscription %d is not active",
+                task.ID, task.Destination, task.SubscriptionID)
+            return
+        }
+    }
+
+    d.reschedule(ctx, task, d.breakers.retryDelay(breaker, time.Now()))
+}
+
+// drop отбрасывает задачу без запроса к получателю и записывает в журнал доставок
+// неудачную запись с причиной, чтобы доставку можно было найти и повторить
+func (d *WebhookDispatcher) drop(ctx context.Context, task models.WebhookTask, body []byte, reason string) {
+    task.LastError = reason
+    d.recordAttempt(ctx, task, body, nil, errors.New(reason))
+    d.logger.Error("Webhook %s to %s dropped after %d attempts: %s", task.ID, task.Destination, task.Attempt, reason)
+}
+
+// trackSubscription ведет серию сбоев подписки и отключает подписку, если
+// сбои продолжаются дольше DisableAfter. Возвращает true, если подписка
+// отключена этой попыткой.
+func (d *WebhookDispatcher) trackSubscription(ctx context.Context, task models.WebhookTask, delivered bool) bool {
+    if task.SubscriptionID == models.DefaultSubscriptionID {
+        return false
+    }
+    ctx = context.WithoutCancel(ctx)
+
+    if delivered {
+        if err := d.subscriptionRepo.MarkHealthy(ctx, task.SubscriptionID); err != nil {
+            d.logger.Warn("Failed to reset failures of subscription %d: %v", task.SubscriptionID, err)
+        }
+        return false
+    }
+
+    now := time.Now()
+    failingSince, err := d.subscriptionRepo.MarkFailing(ctx, task.SubscriptionID, now)
+    if err != nil {
+        d.logger.Warn("Failed to record failure of subscription %d: %v", task.SubscriptionID, err)
+        return false
+    }
+    if d.opts.DisableAfter <= 0 || now.Sub(failingSince) < d.opts.DisableAfter {
+        return false
+    }
+
+    reason := fmt.Sprintf("deliveries failing since %s, last error: %s",
+        failingSince.UTC().Format(time.RFC3339), task.LastError)
+    disabled, err := d.subscriptionRepo.Disable(ctx, task.SubscriptionID, reason, now)
+    if err != nil {
+        d.logger.Error("Failed to disable subscription %d: %v", task.SubscriptionID, err)
+        return false
+    }
+    if disabled {
+        d.notifyDisabled(ctx, task.SubscriptionID)
+    }
+    return disabled
+}
+
+// notifyDisabled сообщает об отключении подписки: событие уходит обычной
+// рассылкой (WEBHOOK_URL и подписчики события) и на notify_url владельца
+func (d *WebhookDispatcher) notifyDisabled(ctx context.Context, subscriptionID int64) {
+    subscription, err := d.subscriptionRepo.FindByID(ctx, subscriptionID)
+    if err != nil || subscription == nil {
+        d.logger.Error("Subscription %d disabled, failed to load it for notification: %v", subscriptionID, err)
+        return
+    }
+    d.logger.Warn("Webhook subscription %d (%s) disabled: %s",
+        subscription.ID, subscription.URL, subscription.DisabledReason)
+
+    payload := models.WebhookPayload{
+        EventType:    models.EventSubscriptionDisabled,
+        EventID:      fmt.Sprintf("subscription-%d-disabled-%d", subscription.ID, subscription.DisabledAt.UnixMicro()),
+        Subscription: subscription,
+        Timestamp:    *subscription.DisabledAt,
+    }
+
+    if err := d.queueRepo.EnqueueWebhook(ctx, payload); err != nil {
+        d.logger.Error("Failed to enqueue %s for subscription %d: %v", payload.EventType, subscription.ID, err)
+    }
+    if subscription.NotifyURL != "" {
+        task := models.NewWebhookTask(subscription.ID, subscription.NotifyURL, payload)
+        task.Format = subscription.Format
+        if err := d.queueRepo.ScheduleWebhookTask(ctx, task); err != nil {
+            d.logger.Error("Failed to notify owner of subscription %d: %v", subscription.ID, err)
+        }
+    }
+}
+
+// reschedule откладывает задачу в очередь повторов. Вызывается и при остановке
+// сервиса, поэтому не зависит от отмены ctx.
+func (d *WebhookDispatcher) reschedule(ctx context.Context, task models.WebhookTask, delay time.Duration) {
+    task.DueAt = time.Now().Add(delay)
+    if err := d.queueRepo.ScheduleWebhookTask(context.WithoutCancel(ctx), task); err != nil {
+        d.logger.Error("Webhook %s to %s lost: failed to schedule retry: %v", task.ID, task.Destination, err)
+    }
+}
+
+// backoff - экспоненциальная задержка перед повтором со случайным разбросом
+// в пределах второй половины интервала, чтобы повторы не приходили пачкой
+func (d *WebhookDispatcher) backoff(attempt int) time.Duration {
+    delay := d.opts.BaseRetryDelay
+    for i := 1; i < attempt && delay < d.opts.MaxRetryDelay; i++ {
+        delay *= 2
+    }
+    if delay > d.opts.MaxRetryDelay {
+        delay = d.opts.MaxRetryDelay
+    }
+    if delay <= 0 {
+        return 0
+    }
+
+    half := delay / 2
+    return half + time.Duration(rand.Int64N(int64(delay-half)+1))
+}
+
+// recordAttempt записывает попытку в журнал доставок. Ошибка журнала
+// не влияет на доставку.
+func (d *WebhookDispatcher) recordAttempt(ctx context.Context, task models.WebhookTask, body []byte, resp *models.WebhookResponse, sendErr error) {
+    hash := sha256.Sum256(body)
+    delivery := &models.WebhookDelivery{
+        DeliveryID:     task.ID,
+        SubscriptionID: task.SubscriptionID,
+        EventType:      task.Payload.EventType,
+        EventID:        task.Payload.EventID,
+        Destination:    task.Destination,
+        Attempt:        task.Attempt,
+        RequestHash:    hex.EncodeToString(hash[:]),
+        ReplayOf:       task.ReplayOf,
+        Payload:        task.Payload,
+        CreatedAt:      time.Now(),
+    }
+    if sendErr != nil {
+        delivery.Error = sendErr.Error()
+    } else {
+        delivery.StatusCode = resp.StatusCode
+        delivery.LatencyMs = resp.Latency.Milliseconds()
+        delivery.Success = resp.StatusCode >= 200 && resp.StatusCode < 300
+        if !delivery.Success {
+            delivery.Error = fmt.Sprintf("unexpected status code: %d", resp.StatusCode)
+        }
+    }
+
+    if err := d.deliveryRepo.RecordAttempt(context.WithoutCancel(ctx), delivery); err != nil {
+        d.logger.Warn("Failed to record webhook %s attempt %d: %v", task.ID, task.Attempt, err)
+    }
+}
+
+// destinations - получатели события: WEBHOOK_URL и активные подписки, фильтры которых
+// пропускают событие
+func (d *WebhookDispatcher) destinations(ctx context.Context, payload models.WebhookPayload) ([]webhookDestination, error) {
+    subscriptions, err := d.subscriptionRepo.FindActive(ctx)
+    if err != nil {
+        return nil, err
+    }
+
+    var destinations []webhookDestination
+    if d.opts.DefaultURL != "" {
+        destinations = append(destinations, webhookDestination{models.DefaultSubscriptionID, d.opts.DefaultURL, d.opts.DefaultFormat})
+    }
+    for _, subscription := range subscriptions {
+        if subscription.Wants(payload) {
+            destinations = append(destinations, webhookDestination{subscription.ID, subscription.URL, subscription.Format})
+        }
+    }
+    return destinations, nil
+}
+
+// retryableStatus - коды, при которых повтор может помочь
+func retryableStatus(code int) bool {
+    switch code {
+    case http.StatusRequestTimeout, http.StatusTooEarly, http.StatusTooManyRequests:
+        return true
+    }
+    return code >= 500
+}
+
+func sleepContext(ctx context.Context, d time.Duration) {
+    select {
+    case <-ctx.Done():
+    case <-time.After(d):
+    }
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"incident-system/internal/domain/models"
+	"incident-system/internal/domain/repositories"
+	"incident-system/internal/infrastructure/memory"
+	"incident-system/pkg/logger"
+)
+
+// failingSender отвечает 503 и считает запросы
+type failingSender struct {
+    calls int
+}
+
+func (s *failingSender) Send(ctx context.Context, url string, body []byte, headers map[string]string) (*models.WebhookResponse, error) {
+    s.calls++
+    return &models.WebhookResponse{StatusCode: http.StatusServiceUnavailable}, nil
+}
+
+type dispatcherFixture struct {
+    dispatcher *WebhookDispatcher
+    queue      repositories.QueueRepository
+    deliveries repositories.WebhookDeliveryRepository
+    sender     *failingSender
+}
+
+func newDispatcherFixture(opts WebhookDispatcherOptions) *dispatcherFixture {
+    f := &dispatcherFixture{
+        queue:      memory.NewQueueRepository(),
+        deliveries: memory.NewDeliveryRepository(100),
+        sender:     &failingSender{},
+    }
+    f.dispatcher = NewWebhookDispatcher(f.queue, memory.NewSubscriptionRepository(), f.deliveries, f.sender, opts, logger.NewLogger("test"))
+    return f
+}
+
+// openBreaker размыкает автомат получателя url
+func (f *dispatcherFixture) openBreaker(url string) {
+    breaker := f.dispatcher.breakers.get(destinationKey(url))
+    for i := 0; i < f.dispatcher.opts.BreakerFailures; i++ {
+        breaker.Failure()
+    }
+}
+
+// scheduled возвращает задачи очереди повторов
+func (f *dispatcherFixture) scheduled(t *testing.T) []models.WebhookTask {
+    t.Helper()
+    tasks, err := f.queue.DueWebhookTasks(context.Background(), time.Now().Add(time.Hour), 100)
+    if err != nil {
+        t.Fatalf("DueWebhookTasks: %v", err)
+    }
+    return tasks
+}
+
+func (f *dispatcherFixture) deliveryLog(t *testing.T) []*models.WebhookDelivery {
+    t.Helper()
+    deliveries, _, err := f.deliveries.FindDeliveries(context.Background(), models.DeliveryFilter{Limit: 100})
+    if err != nil {
+        t.Fatalf("FindDeliveries: %v", err)
+    }
+    return deliveries
+}
+
+func TestDispatcherDropsTasksHeldPastMaxHoldAge(t *testing.T) {
+    ctx := context.Background()
+    f := newDispatcherFixture(WebhookDispatcherOptions{
+        MaxAttempts:        3,
+        MaxRetryDelay:      time.Minute,
+        BreakerFailures:    1,
+        BreakerOpenTimeout: time.Second,
+    })
+    if f.dispatcher.opts.MaxHoldAge != 30*time.Minute {
+        t.Fatalf("MaxHoldAge = %v, want 10 × MaxAttempts × MaxRetryDelay", f.dispatcher.opts.MaxHoldAge)
+    }
+    f.openBreaker("http://down.example.com/hook")
+
+    fresh := models.NewWebhookTask(models.DefaultSubscriptionID, "http://down.example.com/hook", models.WebhookPayload{EventType: "location_alert"})
+    stale := fresh
+    stale.ID = "stale"
+    stale.CreatedAt = time.Now().Add(-31 * time.Minute)
+    f.dispatcher.deliver(ctx, fresh)
+    f.dispatcher.deliver(ctx, stale)
+
+    if f.sender.calls != 0 {
+        t.Fatalf("sender called %d times through an open breaker", f.sender.calls)
+    }
+    if scheduled := f.scheduled(t); len(scheduled) != 1 || scheduled[0].ID != fresh.ID {
+        t.Fatalf("scheduled = %+v, want only the fresh task held", scheduled)
+    }
+    deliveries := f.deliveryLog(t)
+    if len(deliveries) != 1 || deliveries[0].DeliveryID != stale.ID || deliveries[0].Success ||
+        !strings.Contains(deliveries[0].Error, "circuit breaker open") {
+        t.Fatalf("delivery log = %+v, want one failed record for the stale task", deliveries)
+    }
+}
+
+func TestDispatcherHoldKeepsAttempts(t *testing.T) {
+    ctx := context.Background()
+    f := newDispatcherFixture(WebhookDispatcherOptions{
+        MaxAttempts:        1,
+        MaxRetryDelay:      time.Minute,
+        BreakerFailures:    1,
+        BreakerOpenTimeout: 10 * time.Millisecond,
+    })
+    f.openBreaker("http://down.example.com/hook")
+
+    task := models.NewWebhookTask(models.DefaultSubscriptionID, "http://down.example.com/hook", models.WebhookPayload{EventType: "location_alert"})
+    f.dispatcher.deliver(ctx, task)
+
+    scheduled := f.scheduled(t)
+    if f.sender.calls != 0 || len(scheduled) != 1 || scheduled[0].Attempt != 0 || len(f.deliveryLog(t)) != 0 {
+        t.Fatalf("held task = %+v, sent %d times; want it rescheduled without an attempt", scheduled, f.sender.calls)
+    }
+
+    // Проба после паузы автомата расходует единственную попытку задачи
+    time.Sleep(20 * time.Millisecond)
+    f.dispatcher.deliver(ctx, scheduled[0])
+    deliveries := f.deliveryLog(t)
+    if f.sender.calls != 1 || len(deliveries) != 1 || deliveries[0].Attempt != 1 || len(f.scheduled(t)) != 0 {
+        t.Fatalf("probe sent %d times, delivery log = %+v; want one final attempt", f.sender.calls, deliveries)
+    }
+}
+
+func TestDispatcherRecordsEncodeFailures(t *testing.T) {
+    f := newDispatcherFixture(WebhookDispatcherOptions{MaxAttempts: 3, MaxRetryDelay: time.Minute})
+
+    task := models.NewWebhookTask(models.DefaultSubscriptionID, "http://example.com/hook", models.WebhookPayload{EventType: "location_alert"})
+    task.Format = "xml"
+    f.dispatcher.deliver(context.Background(), task)
+
+    if f.sender.calls != 0 || len(f.scheduled(t)) != 0 {
+        t.Fatalf("task with a broken body sent %d times or rescheduled", f.sender.calls)
+    }
+    deliveries := f.deliveryLog(t)
+    if len(deliveries) != 1 || deliveries[0].DeliveryID != task.ID || !strings.Contains(deliveries[0].Error, "failed to encode payload") {
+        t.Fatalf("delivery log = %+v, want the encode failure", deliveries)
+    }
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"incident-system/internal/domain/models"
+)
+
+// SchemaPath - путь, по которому HTTP API отдает JSON-схему данных события
+const SchemaPath = "/api/v1/events/schemas/"
+
+// webhookEncoder формирует тело и заголовки запроса в формате получателя
+type webhookEncoder struct {
+    source        string // атрибут source CloudEvents
+    schemaBaseURL string // внешний адрес сервиса для dataschema; пустой - без dataschema
+}
+
+func (e webhookEncoder) encode(task models.WebhookTask) ([]byte, map[string]string, error) {
+    headers := map[string]string{}
+
+    switch task.Format {
+    case "", models.WebhookFormatLegacy:
+        body, err := json.Marshal(task.Payload)
+        return body, headers, err
+
+    case models.WebhookFormatCloudEventsStructured:
+        event := models.NewCloudEvent(task.Payload, e.source, e.schemaURL(task.Payload.EventType))
+        body, err := json.Marshal(event)
+        headers["Content-Type"] = "application/cloudevents+json; charset=utf-8"
+        return body, headers, err
+
+    case models.WebhookFormatCloudEventsBinary:
+        event := models.NewCloudEvent(task.Payload, e.source, e.schemaURL(task.Payload.EventType))
+        body, err := json.Marshal(event.Data)
+        headers["Content-Type"] = event.DataContentType
+        headers["ce-specversion"] = event.SpecVersion
+        headers["ce-id"] = ceHeaderValue(event.ID)
+        headers["ce-source"] = ceHeaderValue(event.Source)
+        headers["ce-type"] = ceHeaderValue(event.Type)
+        headers["ce-time"] = event.Time.Format(time.RFC3339Nano)
+        if event.Subject != "" {
+            headers["ce-subject"] = ceHeaderValue(event.Subject)
+        }
+        if event.DataSchema != "" {
+            headers["ce-dataschema"] = ceHeaderValue(event.DataSchema)
+        }
+        return body, headers, err
+    }
+
+    return nil, nil, fmt.Errorf("unknown webhook format %q", task.Format)
+}
+
+func (e webhookEncoder) schemaURL(eventType string) string {
+    if e.schemaBaseURL == "" {
+        return ""
+    }
+    return strings.TrimRight(e.schemaBaseURL, "/") + SchemaPath + eventType
+}
+
+// ceHeaderValue кодирует значение атрибута для заголовка ce-*: по спецификации
+// HTTP-привязки CloudEvents символы вне печатного ASCII, пробел, '"' и '%'
+// передаются в percent-encoding UTF-8
+func ceHeaderValue(value string) string {
+    var b strings.Builder
+    for i := 0; i < len(value); i++ {
+        c := value[i]
+        if c <= ' ' || c >= 0x7f || c == '"' || c == '%' {
+            fmt.Fprintf(&b, "%%%02X", c)
+            continue
+        }
+        b.WriteByte(c)
+    }
+    return b.String()
+}
@@ -2,22 +2,48 @@ package services
 
 import (
 	"context"
+	"fmt"
+	"net/url"
 	"time"
 
 	"incident-system/internal/domain/models"
 	"incident-system/internal/domain/repositories"
+	apperrors "incident-system/pkg/errors"
 	"incident-system/pkg/logger"
 )
 
+const (
+    // replayPageSize - сколько записей журнала читается за раз при повторе по подписке
+    replayPageSize = 500
+    // maxReplayDeliveries - сколько доставок можно повторить одним запросом
+    maxReplayDeliveries = 1000
+)
+
+// WebhookService управляет подписками на вебхуки, журналом доставок и повторами
 type WebhookService struct {
-    queueRepo repositories.QueueRepository
-    logger    *logger.Logger
+    queueRepo        repositories.QueueRepository
+    subscriptionRepo repositories.WebhookSubscriptionRepository
+    deliveryRepo     repositories.WebhookDeliveryRepository
+    defaultURL       string
+    defaultFormat    string
+    logger           *logger.Logger
 }
 
-func NewWebhookService(queueRepo repositories.QueueRepository, logger *logger.Logger) *WebhookService {
+func NewWebhookService(
+    queueRepo repositories.QueueRepository,
+    subscriptionRepo repositories.WebhookSubscriptionRepository,
+    deliveryRepo repositories.WebhookDeliveryRepository,
+    defaultURL string,
+    defaultFormat string,
+    logger *logger.Logger,
+) *WebhookService {
     return &WebhookService{
-        queueRepo: queueRepo,
-        logger:    logger,
+        queueRepo:        queueRepo,
+        subscriptionRepo: subscriptionRepo,
+        deliveryRepo:     deliveryRepo,
+        defaultURL:       defaultURL,
+        defaultFormat:    defaultFormat,
+        logger:           logger,
     }
 }
 
@@ -25,27 +51,221 @@ func (s *WebhookService) EnqueueWebhook(ctx context.Context, payload models.Webh
     return s.queueRepo.EnqueueWebhook(ctx, payload)
 }
 
-func (s *WebhookService) StartWorker(ctx context.Context, processFunc func(models.WebhookPayload) error) {
-    go func() {
-        for {
-            select {
-            case <-ctx.Done():
-                s.logger.Info("Webhook worker stopped")
-                return
-            default:
-                payload, err := s.queueRepo.DequeueWebhook(ctx)
-                if err != nil {
-                    s.logger.Error("Failed to dequeue webhook: %v", err) // Изменено с Errorf на Error
-                    time.Sleep(time.Second)
-                    continue
-                }
-                
-                if payload != nil {
-                    if err := processFunc(*payload); err != nil {
-                        s.logger.Error("Failed to process webhook: %v", err) // Изменено с Errorf на Error
-                    }
-                }
+func (s *WebhookService) CreateSubscription(ctx context.Context, req models.CreateSubscriptionRequest) (*models.WebhookSubscription, error) {
+    if err := validateWebhookURL(req.URL); err != nil {
+        return nil, apperrors.NewValidationError(err)
+    }
+    if req.NotifyURL != "" {
+        if err := validateWebhookURL(req.NotifyURL); err != nil {
+            return nil, apperrors.NewValidationError(fmt.Errorf("notify_url: %w", err))
+        }
+    }
+    if !models.ValidWebhookFormat(req.Format) {
+        return nil, apperrors.NewValidationError(fmt.Errorf("unknown format %q", req.Format))
+    }
+    if req.Format == "" {
+        req.Format = models.WebhookFormatLegacy
+    }
+    if req.MinSeverity != "" && !models.ValidSeverity(req.MinSeverity) {
+        return nil, apperrors.NewValidationError(fmt.Errorf("unknown min_severity %q", req.MinSeverity))
+    }
+    for _, category := range req.Categories {
+        if !models.ValidCategoryCode(category) {
+            return nil, apperrors.NewValidationError(fmt.Errorf("invalid category %q", category))
+        }
+    }
+    tags, err := models.NormalizeTags(req.Tags)
+    if err != nil {
+        return nil, apperrors.NewValidationError(err)
+    }
+
+    now := time.Now()
+    subscription := &models.WebhookSubscription{
+        URL:         req.URL,
+        Description: req.Description,
+        EventTypes:  req.EventTypes,
+        Categories:  req.Categories,
+        Tags:        tags,
+        MinSeverity: req.MinSeverity,
+        NotifyURL:   req.NotifyURL,
+        Format:      req.Format,
+        Active:      true,
+        CreatedAt:   now,
+        UpdatedAt:   now,
+    }
+    if subscription.EventTypes == nil {
+        subscription.EventTypes = []string{}
+    }
+    if subscription.Categories == nil {
+        subscription.Categories = []string{}
+    }
+
+    if err := s.subscriptionRepo.Create(ctx, subscription); err != nil {
+        return nil, fmt.Errorf("failed to create subscription: %w", err)
+    }
+    return subscription, nil
+}
+
+func (s *WebhookService) GetSubscription(ctx context.Context, id int64) (*models.WebhookSubscription, error) {
+    return s.subscriptionRepo.FindByID(ctx, id)
+}
+
+func (s *WebhookService) ListSubscriptions(ctx context.Context) ([]*models.WebhookSubscription, error) {
+    return s.subscriptionRepo.FindAll(ctx)
+}
+
+func (s *WebhookService) DeleteSubscription(ctx context.Context, id int64) error {
+    if err := s.subscriptionRepo.Delete(ctx, id); err != nil {
+        return fmt.Errorf("failed to delete subscription: %w", err)
+    }
+    return nil
+}
+
+// EnableSubscription снова включает подписку, в том числе отключенную
+// автоматически, и сбрасывает историю ее сбоев
+func (s *WebhookService) EnableSubscription(ctx context.Context, id int64) (*models.WebhookSubscription, error) {
+    subscription, err := s.subscriptionRepo.FindByID(ctx, id)
+    if err != nil {
+        return nil, fmt.Errorf("failed to find subscription: %w", err)
+    }
+    if subscription == nil {
+        return nil, apperrors.NewNotFoundError("subscription")
+    }
+
+    subscription.Enable(time.Now())
+    if err := s.subscriptionRepo.Update(ctx, subscription); err != nil {
+        return nil, fmt.Errorf("failed to enable subscription: %w", err)
+    }
+
+    s.logger.Info("Webhook subscription %d (%s) enabled", subscription.ID, subscription.URL)
+    return subscription, nil
+}
+
+func (s *WebhookService) ListDeliveries(ctx context.Context, filter models.DeliveryFilter) ([]*models.WebhookDelivery, int, error) {
+    return s.deliveryRepo.FindDeliveries(ctx, filter)
+}
+
+// ReplayDelivery повторно отправляет доставку на текущий URL ее подписки.
+// Повтор - новая доставка со своим ID и ссылкой на исходную в ReplayOf.
+func (s *WebhookService) ReplayDelivery(ctx context.Context, deliveryID string) (*models.WebhookTask, error) {
+    deliveries, _, err := s.deliveryRepo.FindDeliveries(ctx, models.DeliveryFilter{DeliveryID: deliveryID, Limit: 1})
+    if err != nil {
+        return nil, fmt.Errorf("failed to find delivery: %w", err)
+    }
+    if len(deliveries) == 0 {
+        return nil, apperrors.NewNotFoundError("delivery")
+    }
+
+    target, err := s.replayTarget(ctx, deliveries[0].SubscriptionID)
+    if err != nil {
+        return nil, err
+    }
+
+    task, err := s.replay(ctx, deliveries[0], target)
+    if err != nil {
+        return nil, err
+    }
+    return &task, nil
+}
+
+// ReplaySubscription повторяет все доставки подписки за период [from, to).
+// Каждая доставка повторяется один раз, сколько бы попыток у нее ни было;
+// сами повторы повторно не отправляются.
+func (s *WebhookService) ReplaySubscription(ctx context.Context, subscriptionID int64, from, to time.Time) ([]models.WebhookTask, error) {
+    if !from.Before(to) {
+        return nil, apperrors.NewValidationError(fmt.Errorf("from must be before to"))
+    }
+
+    target, err := s.replayTarget(ctx, subscriptionID)
+    if err != nil {
+        return nil, err
+    }
+
+    filter := models.DeliveryFilter{
+        SubscriptionID: &subscriptionID,
+        From:           from,
+        To:             to,
+        Limit:          replayPageSize,
+    }
+
+    seen := make(map[string]bool)
+    var originals []*models.WebhookDelivery
+    for {
+        deliveries, _, err := s.deliveryRepo.FindDeliveries(ctx, filter)
+        if err != nil {
+            return nil, fmt.Errorf("failed to find deliveries: %w", err)
+        }
+        for _, delivery := range deliveries {
+            if delivery.ReplayOf != "" || seen[delivery.DeliveryID] {
+                continue
             }
+            seen[delivery.DeliveryID] = true
+            originals = append(originals, delivery)
+        }
+        if len(originals) > maxReplayDeliveries {
+            return nil, apperrors.NewValidationError(
+                fmt.Errorf("too many deliveries in range, at most %d can be replayed at once", maxReplayDeliveries))
+        }
+        if len(deliveries) < filter.Limit {
+            break
         }
-    }()
-}
\ No newline at end of file
+        filter.Offset += filter.Limit
+    }
+
+    // Журнал отдается от новых к старым, а повторять нужно в исходном порядке
+    tasks := make([]models.WebhookTask, 0, len(originals))
+    for i := len(originals) - 1; i >= 0; i-- {
+        task, err := s.replay(ctx, originals[i], target)
+        if err != nil {
+            return tasks, err
+        }
+        tasks = append(tasks, task)
+    }
+
+    s.logger.Info("Replaying %d webhook deliveries for subscription %d", len(tasks), subscriptionID)
+    return tasks, nil
+}
+
+func (s *WebhookService) replay(ctx context.Context, delivery *models.WebhookDelivery, target webhookDestination) (models.WebhookTask, error) {
+    task := models.NewWebhookTask(delivery.SubscriptionID, target.url, delivery.Payload)
+    task.Format = target.format
+    task.ReplayOf = delivery.DeliveryID
+
+    if err := s.queueRepo.ScheduleWebhookTask(ctx, task); err != nil {
+        return task, fmt.Errorf("failed to schedule replay: %w", err)
+    }
+    return task, nil
+}
+
+// replayTarget - текущие адрес и формат подписки; для DefaultSubscriptionID - WEBHOOK_URL
+func (s *WebhookService) replayTarget(ctx context.Context, subscriptionID int64) (webhookDestination, error) {
+    if subscriptionID == models.DefaultSubscriptionID {
+        if s.defaultURL == "" {
+            return webhookDestination{}, apperrors.NewValidationError(fmt.Errorf("WEBHOOK_URL is not configured"))
+        }
+        return webhookDestination{subscriptionID, s.defaultURL, s.defaultFormat}, nil
+    }
+
+    subscription, err := s.subscriptionRepo.FindByID(ctx, subscriptionID)
+    if err != nil {
+        return webhookDestination{}, fmt.Errorf("failed to find subscription: %w", err)
+    }
+    if subscription == nil {
+        return webhookDestination{}, apperrors.NewNotFoundError("subscription")
+    }
+    if !subscription.Active {
+        return webhookDestination{}, apperrors.NewValidationError(fmt.Errorf("subscription %d is disabled", subscriptionID))
+    }
+    return webhookDestination{subscription.ID, subscription.URL, subscription.Format}, nil
+}
+
+func validateWebhookURL(rawURL string) error {
+    parsed, err := url.Parse(rawURL)
+    if err != nil {
+        return err
+    }
+    if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+        return fmt.Errorf("url must be an absolute http or https URL")
+    }
+    return nil
+}
@@ -0,0 +1,46 @@
+-- Контур зоны инцидента (массив вершин {latitude, longitude}).
+-- Для круглых зон значение NULL, зона задается центром и радиусом.
+ALTER TABLE incidents ADD COLUMN polygon JSONB;
+
+-- Исправление функции поиска ближайших инцидентов:
+-- HAVING без агрегации отклоняется PostgreSQL, фильтруем во внешнем запросе
+DROP FUNCTION IF EXISTS find_nearby_incidents(DECIMAL, DECIMAL, DECIMAL);
+
+CREATE OR REPLACE FUNCTION find_nearby_incidents(
+    p_latitude DECIMAL,
+    p_longitude DECIMAL,
+    p_radius_km DECIMAL
+)
+RETURNS TABLE(
+    id BIGINT,
+    user_id VARCHAR,
+    latitude DECIMAL,
+    longitude DECIMAL,
+    title VARCHAR,
+    description TEXT,
+    severity VARCHAR,
+    radius DECIMAL,
+    active BOOLEAN,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP,
+    distance_km DECIMAL
+) AS $$
+BEGIN
+    RETURN QUERY
+    SELECT c.id, c.user_id, c.latitude, c.longitude, c.title, c.description,
+           c.severity, c.radius, c.active, c.created_at, c.updated_at, c.distance_km
+    FROM (
+        SELECT
+            i.*,
+            (6371 * acos(LEAST(1.0,
+                cos(radians(p_latitude)) * cos(radians(i.latitude)) *
+                cos(radians(i.longitude) - radians(p_longitude)) +
+                sin(radians(p_latitude)) * sin(radians(i.latitude))
+            )))::DECIMAL as distance_km
+        FROM incidents i
+        WHERE i.active = true
+    ) c
+    WHERE c.distance_km <= p_radius_km
+    ORDER BY c.distance_km;
+END;
+$$ LANGUAGE plpgsql;
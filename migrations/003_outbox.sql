@@ -0,0 +1,23 @@
+-- Transactional outbox: события изменения инцидентов записываются
+-- в той же транзакции, что и изменение, и публикуются релеем
+CREATE TABLE incident_outbox (
+    id BIGSERIAL PRIMARY KEY,
+    incident_id BIGINT NOT NULL,
+    event_type VARCHAR(100) NOT NULL,
+    payload JSONB NOT NULL, -- снимок инцидента после изменения
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    published_at TIMESTAMP,
+    delivered TEXT[] NOT NULL DEFAULT '{}', -- издатели, уже обработавшие событие
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT
+);
+
+CREATE INDEX idx_incident_outbox_pending ON incident_outbox(id) WHERE published_at IS NULL;
+CREATE INDEX idx_incident_outbox_incident_id ON incident_outbox(incident_id);
+
+-- Аренда релея: события публикует только один экземпляр сервиса
+CREATE TABLE outbox_lease (
+    name VARCHAR(50) PRIMARY KEY,
+    owner VARCHAR(255) NOT NULL,
+    expires_at TIMESTAMP NOT NULL
+);
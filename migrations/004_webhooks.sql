@@ -0,0 +1,36 @@
+-- Получатели вебхуков
+CREATE TABLE webhook_subscriptions (
+    id BIGSERIAL PRIMARY KEY,
+    url TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    event_types TEXT[] NOT NULL DEFAULT '{}', -- пустой список - все события
+    active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_webhook_subscriptions_active ON webhook_subscriptions(active);
+
+-- Журнал попыток доставки. subscription_id = 0 - получатель из WEBHOOK_URL,
+-- поэтому внешнего ключа нет; записи удаленных подписок сохраняются
+CREATE TABLE webhook_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    delivery_id VARCHAR(64) NOT NULL,
+    subscription_id BIGINT NOT NULL,
+    event_type VARCHAR(100) NOT NULL,
+    event_id VARCHAR(100) NOT NULL DEFAULT '',
+    destination TEXT NOT NULL,
+    attempt INT NOT NULL,
+    request_hash CHAR(64) NOT NULL,
+    status_code INT NOT NULL DEFAULT 0,
+    latency_ms BIGINT NOT NULL DEFAULT 0,
+    success BOOLEAN NOT NULL,
+    error TEXT NOT NULL DEFAULT '',
+    replay_of VARCHAR(64) NOT NULL DEFAULT '',
+    payload JSONB NOT NULL, -- тело для повторной отправки
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_webhook_deliveries_delivery_id ON webhook_deliveries(delivery_id);
+CREATE INDEX idx_webhook_deliveries_subscription ON webhook_deliveries(subscription_id, created_at);
+CREATE INDEX idx_webhook_deliveries_created_at ON webhook_deliveries(created_at);
@@ -0,0 +1,6 @@
+-- Состояние подписок: серия сбоев доставки и автоматическое отключение
+ALTER TABLE webhook_subscriptions
+    ADD COLUMN notify_url TEXT NOT NULL DEFAULT '',
+    ADD COLUMN failing_since TIMESTAMP,
+    ADD COLUMN disabled_at TIMESTAMP,
+    ADD COLUMN disabled_reason TEXT NOT NULL DEFAULT '';
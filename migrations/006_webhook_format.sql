@@ -0,0 +1,2 @@
+-- Формат тела вебхука: legacy, cloudevents-structured или cloudevents-binary
+ALTER TABLE webhook_subscriptions ADD COLUMN format VARCHAR(32) NOT NULL DEFAULT 'legacy';
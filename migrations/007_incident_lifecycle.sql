@@ -0,0 +1,7 @@
+-- Срок действия инцидента: по истечении expires_at инцидент деактивируется
+-- с событием incident.expired
+ALTER TABLE incidents ADD COLUMN expires_at TIMESTAMP;
+-- Имена полей, измененных в incident.updated и incident.resolved
+ALTER TABLE incident_outbox ADD COLUMN changed_fields JSONB;
+
+CREATE INDEX idx_incidents_expires_at ON incidents(expires_at) WHERE active = true AND expires_at IS NOT NULL;
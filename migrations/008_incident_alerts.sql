@@ -0,0 +1,11 @@
+-- Пользователи, уже получившие оповещение об инциденте: проактивное оповещение
+-- о новой или расширенной зоне не отправляется им повторно
+CREATE TABLE incident_alerts (
+    incident_id BIGINT NOT NULL REFERENCES incidents(id) ON DELETE CASCADE,
+    user_id VARCHAR(255) NOT NULL,
+    alerted_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (incident_id, user_id)
+);
+
+-- Последняя проверка каждого пользователя
+CREATE INDEX idx_location_checks_user_timestamp ON location_checks(user_id, timestamp DESC);
@@ -0,0 +1,29 @@
+-- Зоны наблюдения пользователей: дом, школа, работа
+CREATE TABLE watch_zones (
+    id BIGSERIAL PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    latitude DECIMAL(10, 8) NOT NULL,
+    longitude DECIMAL(11, 8) NOT NULL,
+    radius DECIMAL(10, 2) NOT NULL, -- в метрах; для многоугольника - радиус описанной окружности
+    polygon JSONB, -- контур зоны, NULL для круглой зоны
+    min_severity VARCHAR(50) NOT NULL DEFAULT 'low' CHECK (min_severity IN ('low', 'medium', 'high')),
+    -- Описанный прямоугольник зоны для отбора кандидатов по индексу
+    min_lat DOUBLE PRECISION NOT NULL,
+    max_lat DOUBLE PRECISION NOT NULL,
+    min_lng DOUBLE PRECISION NOT NULL,
+    max_lng DOUBLE PRECISION NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_watch_zones_user_id ON watch_zones(user_id);
+CREATE INDEX idx_watch_zones_bbox ON watch_zones(min_lat, max_lat, min_lng, max_lng);
+
+-- Зоны, владельцы которых уже оповещены об инциденте
+CREATE TABLE watch_zone_alerts (
+    incident_id BIGINT NOT NULL REFERENCES incidents(id) ON DELETE CASCADE,
+    zone_id BIGINT NOT NULL REFERENCES watch_zones(id) ON DELETE CASCADE,
+    alerted_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (incident_id, zone_id)
+);
@@ -0,0 +1,20 @@
+-- Настройки оповещений пользователей
+CREATE TABLE notification_preferences (
+    user_id VARCHAR(255) PRIMARY KEY,
+    min_severity VARCHAR(50) NOT NULL DEFAULT 'low' CHECK (min_severity IN ('low', 'medium', 'high')),
+    categories TEXT[] NOT NULL DEFAULT '{}', -- пустой список - все категории
+    time_zone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    quiet_start VARCHAR(5), -- HH:MM, NULL - без тихих часов
+    quiet_end VARCHAR(5),
+    quiet_hours_override VARCHAR(50) NOT NULL DEFAULT 'high' CHECK (quiet_hours_override IN ('low', 'medium', 'high')),
+    max_alerts_per_hour INTEGER NOT NULL DEFAULT 0 CHECK (max_alerts_per_hour >= 0),
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Оповещения, отправленные пользователям с ограничением частоты (за последний час)
+CREATE TABLE user_alert_log (
+    user_id VARCHAR(255) NOT NULL,
+    sent_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_user_alert_log_user_sent ON user_alert_log(user_id, sent_at);
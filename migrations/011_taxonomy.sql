@@ -0,0 +1,77 @@
+-- Шкала опасности: уровни с числовым значением и цветом вместо фиксированных low/medium/high
+CREATE TABLE severity_levels (
+    code VARCHAR(50) PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    level INTEGER NOT NULL UNIQUE CHECK (level > 0),
+    color VARCHAR(7) NOT NULL -- #RRGGBB
+);
+
+INSERT INTO severity_levels (code, name, level, color) VALUES
+    ('low', 'Низкий', 10, '#2E7D32'),
+    ('medium', 'Средний', 20, '#F9A825'),
+    ('high', 'Высокий', 30, '#C62828');
+
+-- Категории инцидентов; код подкатегории - код родителя с суффиксом через точку
+CREATE TABLE incident_categories (
+    code VARCHAR(100) PRIMARY KEY,
+    parent_code VARCHAR(100) REFERENCES incident_categories(code),
+    name VARCHAR(100) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO incident_categories (code, parent_code, name) VALUES
+    ('fire', NULL, 'Пожар'),
+    ('fire.wildfire', 'fire', 'Природный пожар'),
+    ('fire.building', 'fire', 'Пожар в здании'),
+    ('flood', NULL, 'Наводнение'),
+    ('crime', NULL, 'Преступление'),
+    ('road_closure', NULL, 'Перекрытие дороги'),
+    ('road_closure.accident', 'road_closure', 'ДТП'),
+    ('road_closure.roadworks', 'road_closure', 'Дорожные работы'),
+    ('chemical', NULL, 'Химическая опасность'),
+    ('chemical.gas_leak', 'chemical', 'Утечка газа'),
+    ('weather', NULL, 'Опасные погодные явления'),
+    ('other', NULL, 'Прочее');
+
+-- Уровни опасности теперь проверяются по справочнику
+ALTER TABLE incidents DROP CONSTRAINT incidents_severity_check;
+ALTER TABLE incidents ADD CONSTRAINT incidents_severity_fkey
+    FOREIGN KEY (severity) REFERENCES severity_levels(code);
+
+ALTER TABLE watch_zones DROP CONSTRAINT watch_zones_min_severity_check;
+ALTER TABLE watch_zones ADD CONSTRAINT watch_zones_min_severity_fkey
+    FOREIGN KEY (min_severity) REFERENCES severity_levels(code);
+
+ALTER TABLE notification_preferences DROP CONSTRAINT notification_preferences_min_severity_check;
+ALTER TABLE notification_preferences DROP CONSTRAINT notification_preferences_quiet_hours_override_check;
+ALTER TABLE notification_preferences ADD CONSTRAINT notification_preferences_min_severity_fkey
+    FOREIGN KEY (min_severity) REFERENCES severity_levels(code);
+ALTER TABLE notification_preferences ADD CONSTRAINT notification_preferences_quiet_hours_override_fkey
+    FOREIGN KEY (quiet_hours_override) REFERENCES severity_levels(code);
+
+-- Категория и свободные теги инцидента
+ALTER TABLE incidents ADD COLUMN category VARCHAR(100) NOT NULL DEFAULT 'other'
+    REFERENCES incident_categories(code);
+ALTER TABLE incidents ADD COLUMN tags TEXT[] NOT NULL DEFAULT '{}';
+
+-- Существующие инциденты классифицируются по ключевым словам заголовка,
+-- остальные остаются в категории other
+UPDATE incidents SET category = 'fire'
+WHERE title ILIKE ANY (ARRAY['%пожар%', '%возгоран%', '%fire%']);
+UPDATE incidents SET category = 'flood'
+WHERE category = 'other' AND title ILIKE ANY (ARRAY['%наводнен%', '%подтоплен%', '%паводок%', '%flood%']);
+UPDATE incidents SET category = 'chemical'
+WHERE category = 'other' AND title ILIKE ANY (ARRAY['%хими%', '%утечк%', '%выброс%', '%chemical%', '%gas leak%']);
+UPDATE incidents SET category = 'road_closure'
+WHERE category = 'other' AND title ILIKE ANY (ARRAY['%перекрыт%', '%дтп%', '%авари%', '%road%']);
+UPDATE incidents SET category = 'crime'
+WHERE category = 'other' AND title ILIKE ANY (ARRAY['%краж%', '%ограблен%', '%нападен%', '%crime%']);
+
+CREATE INDEX idx_incidents_category ON incidents(category);
+CREATE INDEX idx_incidents_tags ON incidents USING GIN (tags);
+
+-- Фильтры подписок на вебхуки по инцидентам
+ALTER TABLE webhook_subscriptions ADD COLUMN categories TEXT[] NOT NULL DEFAULT '{}'; -- пустой список - все категории
+ALTER TABLE webhook_subscriptions ADD COLUMN tags TEXT[] NOT NULL DEFAULT '{}'; -- пустой список - любые теги
+ALTER TABLE webhook_subscriptions ADD COLUMN min_severity VARCHAR(50) REFERENCES severity_levels(code); -- NULL - любой уровень
@@ -0,0 +1,24 @@
+-- Сообщения жителей о происшествиях и очередь модерации. Категория и уровень
+-- в сообщении - лишь предложение заявителя, поэтому на справочники они не ссылаются.
+CREATE TABLE incident_reports (
+    id BIGSERIAL PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL,
+    reporter_ip VARCHAR(64) NOT NULL DEFAULT '',
+    latitude DECIMAL(10, 8) NOT NULL,
+    longitude DECIMAL(11, 8) NOT NULL,
+    description TEXT NOT NULL,
+    category VARCHAR(100) NOT NULL DEFAULT 'other',
+    severity VARCHAR(50),
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approving', 'approved', 'merged', 'rejected')),
+    abuse_score DOUBLE PRECISION NOT NULL DEFAULT 0,
+    incident_id BIGINT REFERENCES incidents(id) ON DELETE SET NULL,
+    abusive BOOLEAN NOT NULL DEFAULT FALSE, -- отклонено как ложное или спам
+    moderation_note TEXT NOT NULL DEFAULT '',
+    moderated_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_incident_reports_queue ON incident_reports(status, abuse_score, created_at);
+CREATE INDEX idx_incident_reports_user ON incident_reports(user_id, created_at);
+CREATE INDEX idx_incident_reports_ip ON incident_reports(reporter_ip, created_at);
+CREATE INDEX idx_incident_reports_incident ON incident_reports(incident_id);
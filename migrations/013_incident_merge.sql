@@ -0,0 +1,8 @@
+-- Объединение дубликатов: присоединенный инцидент деактивируется и ссылается
+-- на тот, в который он объединен; по старому ID выполняется перенаправление
+ALTER TABLE incidents ADD COLUMN merged_into BIGINT REFERENCES incidents(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_incidents_merged_into ON incidents(merged_into) WHERE merged_into IS NOT NULL;
+
+-- Поиск похожих сообщений в очереди модерации
+CREATE INDEX idx_incident_reports_pending_location ON incident_reports(latitude, longitude) WHERE status = 'pending';
@@ -0,0 +1,18 @@
+-- Вложения инцидентов: фотографии, схемы и документы. Содержимое файлов
+-- хранится в хранилище файлов (локальный каталог или S3), здесь - описание.
+CREATE TABLE incident_attachments (
+    id BIGSERIAL PRIMARY KEY,
+    incident_id BIGINT NOT NULL REFERENCES incidents(id) ON DELETE CASCADE,
+    file_name VARCHAR(255) NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    size BIGINT NOT NULL,
+    checksum CHAR(64) NOT NULL, -- SHA-256 содержимого
+    width INTEGER,
+    height INTEGER,
+    storage_key VARCHAR(255) NOT NULL UNIQUE,
+    thumbnail_key VARCHAR(255),
+    uploaded_by VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_incident_attachments_incident ON incident_attachments(incident_id, id);
@@ -0,0 +1,16 @@
+-- Хроника инцидента: сообщения операторов о ходе происшествия ("перекрытие
+-- снято в одну сторону", "эвакуация отменена"). Публичные сообщения видят жители.
+CREATE TABLE incident_updates (
+    id BIGSERIAL PRIMARY KEY,
+    incident_id BIGINT NOT NULL REFERENCES incidents(id) ON DELETE CASCADE,
+    message TEXT NOT NULL,
+    public BOOLEAN NOT NULL DEFAULT false,
+    author VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_incident_updates_incident ON incident_updates(incident_id, id);
+CREATE INDEX idx_incident_updates_public ON incident_updates(incident_id, id) WHERE public = true;
+
+-- Сообщение хроники в событии incident.update_posted
+ALTER TABLE incident_outbox ADD COLUMN update_payload JSONB;
@@ -0,0 +1,5 @@
+-- Импорт инцидентов из оповещений CAP и файлов GeoJSON других ведомств: ID во внешней
+-- системе, по которому повторный импорт обновляет инцидент, а не создает новый
+ALTER TABLE incidents ADD COLUMN external_id VARCHAR(512);
+
+CREATE UNIQUE INDEX idx_incidents_external_id ON incidents(external_id) WHERE external_id IS NOT NULL;
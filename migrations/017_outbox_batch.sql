@@ -0,0 +1,2 @@
+-- Общий ID событий одной пакетной операции с инцидентами
+ALTER TABLE incident_outbox ADD COLUMN batch_id VARCHAR(64);
@@ -0,0 +1,3 @@
+-- Версия инцидента для оптимистичной блокировки: увеличивается при каждом изменении
+-- и служит ETag в API, запросы с If-Match изменяют только ожидаемую версию
+ALTER TABLE incidents ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
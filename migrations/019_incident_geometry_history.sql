@@ -0,0 +1,17 @@
+-- История зоны инцидента: прежние центр, радиус и контур при каждом перемещении
+-- или изменении формы. version - версия инцидента, в которой действовала зона.
+CREATE TABLE incident_geometry_history (
+    id BIGSERIAL PRIMARY KEY,
+    incident_id BIGINT NOT NULL REFERENCES incidents(id) ON DELETE CASCADE,
+    version BIGINT NOT NULL,
+    latitude DECIMAL(10, 8) NOT NULL,
+    longitude DECIMAL(11, 8) NOT NULL,
+    radius DECIMAL(10, 2) NOT NULL,
+    polygon JSONB,
+    replaced_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_incident_geometry_history_incident ON incident_geometry_history(incident_id, id);
+
+-- Прежняя и новая зона в событии incident.geometry_changed
+ALTER TABLE incident_outbox ADD COLUMN geometry_payload JSONB;
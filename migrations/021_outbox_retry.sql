@@ -0,0 +1,11 @@
+-- Повторы событий outbox с экспоненциальной задержкой: событие, которое не удалось
+-- опубликовать, откладывается до next_attempt_at, а исчерпавшее попытки
+-- (OUTBOX_MAX_ATTEMPTS) переходит в dead letter и больше не публикуется
+ALTER TABLE incident_outbox ADD COLUMN next_attempt_at TIMESTAMP; -- NULL - публиковать сразу
+ALTER TABLE incident_outbox ADD COLUMN dead_lettered_at TIMESTAMP;
+
+DROP INDEX IF EXISTS idx_incident_outbox_pending;
+CREATE INDEX idx_incident_outbox_pending ON incident_outbox(id)
+    WHERE published_at IS NULL AND dead_lettered_at IS NULL;
+CREATE INDEX idx_incident_outbox_dead_letter ON incident_outbox(dead_lettered_at)
+    WHERE dead_lettered_at IS NOT NULL;
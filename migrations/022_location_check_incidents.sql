@@ -0,0 +1,16 @@
+-- Проверка локации записывается одной строкой, а инциденты, в зоны которых
+-- попала точка, - в location_check_incidents: проверка в нескольких пересекающихся
+-- зонах не умножает историю проверок и число проверок пользователя
+CREATE TABLE location_check_incidents (
+    check_id BIGINT NOT NULL REFERENCES location_checks(id) ON DELETE CASCADE,
+    incident_id BIGINT NOT NULL REFERENCES incidents(id) ON DELETE CASCADE,
+    PRIMARY KEY (check_id, incident_id)
+);
+
+CREATE INDEX idx_location_check_incidents_incident ON location_check_incidents(incident_id);
+
+INSERT INTO location_check_incidents (check_id, incident_id)
+SELECT id, incident_id FROM location_checks WHERE incident_id IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_location_checks_incident_id;
+ALTER TABLE location_checks DROP COLUMN incident_id;
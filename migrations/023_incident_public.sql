@@ -0,0 +1,3 @@
+-- Публикация инцидента в открытых лентах (GeoJSON, GeoRSS, CAP). Инциденты, в том
+-- числе созданные до миграции, не публикуются, пока оператор не отметит их public
+ALTER TABLE incidents ADD COLUMN public BOOLEAN NOT NULL DEFAULT false;
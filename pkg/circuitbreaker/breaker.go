@@ -0,0 +1,181 @@
+package circuitbreaker
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrOpen возвращается, пока автомат разомкнут и вызовы не пропускаются
+var ErrOpen = errors.New("circuit breaker is open")
+
+type State int
+
+const (
+    StateClosed State = iota
+    StateOpen
+    StateHalfOpen
+)
+
+func (s State) String() string {
+    switch s {
+    case StateOpen:
+        return "open"
+    case StateHalfOpen:
+        return "half-open"
+    default:
+        return "closed"
+    }
+}
+
+// Breaker размыкается после failureThreshold сбоев подряд. Через openTimeout
+// он переходит в полуоткрытое состояние и пропускает одну пробу: успех замыкает
+// автомат, сбой снова размыкает его.
+type Breaker struct {
+    name             string
+    failureThreshold int
+    openTimeout      time.Duration
+
+    mu            sync.Mutex
+    state         State
+    failures      int
+    openedAt      time.Time
+    probeStarted  time.Time
+    onStateChange func(name string, from, to State)
+}
+
+func New(name string, failureThreshold int, openTimeout time.Duration) *Breaker {
+    if failureThreshold < 1 {
+        failureThreshold = 1
+    }
+    return &Breaker{
+        name:             name,
+        failureThreshold: failureThreshold,
+        openTimeout:      openTimeout,
+    }
+}
+
+// OnStateChange задает обработчик смены состояния; вызывается под блокировкой автомата
+func (b *Breaker) OnStateChange(fn func(name string, from, to State)) {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+
+    b.onStateChange = fn
+}
+
+func (b *Breaker) Name() string {
+    return b.name
+}
+
+// State возвращает текущее состояние; разомкнутый автомат с истекшим
+// openTimeout считается полуоткрытым
+func (b *Breaker) State() State {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+
+    b.refresh(time.Now())
+    return b.state
+}
+
+// OpenedAt возвращает время последнего размыкания
+func (b *Breaker) OpenedAt() time.Time {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+
+    return b.openedAt
+}
+
+// Allow решает, можно ли выполнить вызов. В полуоткрытом состоянии пропускается
+// одна проба; если ее результат не записан за openTimeout, пропускается следующая.
+func (b *Breaker) Allow() error {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+
+    now := time.Now()
+    b.refresh(now)
+
+    switch b.state {
+    case StateOpen:
+        return ErrOpen
+    case StateHalfOpen:
+        if !b.probeStarted.IsZero() && now.Sub(b.probeStarted) < b.openTimeout {
+            return ErrOpen
+        }
+        b.probeStarted = now
+    }
+    return nil
+}
+
+// Success записывает успешный вызов
+func (b *Breaker) Success() {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+
+    b.failures = 0
+    if b.state != StateClosed {
+        b.setState(StateClosed, time.Now())
+    }
+}
+
+// Failure записывает сбой
+func (b *Breaker) Failure() {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+
+    now := time.Now()
+    b.refresh(now)
+
+    b.failures++
+    if b.state == StateHalfOpen || (b.state == StateClosed && b.failures >= b.failureThreshold) {
+        b.setState(StateOpen, now)
+    }
+}
+
+// Execute выполняет fn, если автомат пропускает вызов, и записывает результат
+func (b *Breaker) Execute(fn func() error) error {
+    if err := b.Allow(); err != nil {
+        return err
+    }
+
+    err := fn()
+    if err != nil {
+        b.Failure()
+        return err
+    }
+    b.Success()
+    return nil
+}
+
+// Reset замыкает автомат вручную
+func (b *Breaker) Reset() {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+
+    b.failures = 0
+    if b.state != StateClosed {
+        b.setState(StateClosed, time.Now())
+    }
+}
+
+func (b *Breaker) refresh(now time.Time) {
+    if b.state == StateOpen && now.Sub(b.openedAt) >= b.openTimeout {
+        b.setState(StateHalfOpen, now)
+    }
+}
+
+func (b *Breaker) setState(state State, now time.Time) {
+    from := b.state
+    b.state = state
+    b.probeStarted = time.Time{}
+
+    switch state {
+    case StateOpen:
+        b.openedAt = now
+    case StateClosed:
+        b.failures = 0
+    }
+
+    if b.onStateChange != nil {
+        b.onStateChange(b.name, from, state)
+    }
+}
@@ -1,6 +1,7 @@
 package errors
 
 import (
+	stderrors "errors"
 	"fmt"
 	"net/http"
 )
@@ -30,6 +31,48 @@ func NewNotFoundError(resource string) *AppError {
     }
 }
 
+func NewConflictError(message string) *AppError {
+    return &AppError{
+        Code:    http.StatusConflict,
+        Message: message,
+    }
+}
+
+func NewPreconditionFailedError(message string) *AppError {
+    return &AppError{
+        Code:    http.StatusPreconditionFailed,
+        Message: message,
+    }
+}
+
+func NewPreconditionRequiredError(message string) *AppError {
+    return &AppError{
+        Code:    http.StatusPreconditionRequired,
+        Message: message,
+    }
+}
+
+func NewTooManyRequestsError(message string) *AppError {
+    return &AppError{
+        Code:    http.StatusTooManyRequests,
+        Message: message,
+    }
+}
+
+func NewPayloadTooLargeError(message string) *AppError {
+    return &AppError{
+        Code:    http.StatusRequestEntityTooLarge,
+        Message: message,
+    }
+}
+
+func NewUnsupportedMediaTypeError(message string) *AppError {
+    return &AppError{
+        Code:    http.StatusUnsupportedMediaType,
+        Message: message,
+    }
+}
+
 func NewInternalError(err error) *AppError {
     return &AppError{
         Code:    http.StatusInternalServerError,
@@ -50,4 +93,13 @@ func NewForbiddenError() *AppError {
         Code:    http.StatusForbidden,
         Message: "Forbidden",
     }
-}
\ No newline at end of file
+}
+
+// FromError извлекает AppError из цепочки ошибок; прочие ошибки считаются внутренними
+func FromError(err error) *AppError {
+    var appErr *AppError
+    if stderrors.As(err, &appErr) {
+        return appErr
+    }
+    return NewInternalError(err)
+}
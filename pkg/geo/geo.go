@@ -0,0 +1,377 @@
+package geo
+
+import (
+	"math"
+	"sort"
+)
+
+// EarthRadiusKm - средний радиус Земли в километрах
+const EarthRadiusKm = 6371.0
+
+// kmPerDegree - длина одного градуса дуги большого круга
+const kmPerDegree = EarthRadiusKm * math.Pi / 180
+
+type Point struct {
+    Latitude  float64 `json:"latitude"`
+    Longitude float64 `json:"longitude"`
+}
+
+// DistanceKm вычисляет расстояние между двумя точками по формуле гаверсинусов
+func DistanceKm(lat1, lon1, lat2, lon2 float64) float64 {
+    dLat := (lat2 - lat1) * math.Pi / 180
+    dLon := (lon2 - lon1) * math.Pi / 180
+
+    a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+        math.Cos(lat1*math.Pi/180)*math.Cos(lat2*math.Pi/180)*
+            math.Sin(dLon/2)*math.Sin(dLon/2)
+
+    c := 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+
+    return EarthRadiusKm * c
+}
+
+// PointInPolygon проверяет попадание точки в многоугольник методом трассировки луча.
+// Контур может быть как замкнутым, так и незамкнутым.
+func PointInPolygon(lat, lng float64, polygon []Point) bool {
+    if len(polygon) < 3 {
+        return false
+    }
+
+    inside := false
+    j := len(polygon) - 1
+    for i := 0; i < len(polygon); i++ {
+        pi, pj := polygon[i], polygon[j]
+        if (pi.Latitude > lat) != (pj.Latitude > lat) {
+            x := (pj.Longitude-pi.Longitude)*(lat-pi.Latitude)/(pj.Latitude-pi.Latitude) + pi.Longitude
+            if lng < x {
+                inside = !inside
+            }
+        }
+        j = i
+    }
+
+    return inside
+}
+
+// DistanceToPolygonKm возвращает расстояние от точки до границы многоугольника
+// (0, если точка внутри). Используется локальная равнопромежуточная проекция,
+// что достаточно точно для зон размером в десятки километров.
+func DistanceToPolygonKm(lat, lng float64, polygon []Point) float64 {
+    if len(polygon) == 0 {
+        return math.Inf(1)
+    }
+    if PointInPolygon(lat, lng, polygon) {
+        return 0
+    }
+
+    cosLat := math.Cos(lat * math.Pi / 180)
+    project := func(p Point) (float64, float64) {
+        return (p.Longitude - lng) * cosLat * kmPerDegree, (p.Latitude - lat) * kmPerDegree
+    }
+
+    best := math.Inf(1)
+    j := len(polygon) - 1
+    for i := 0; i < len(polygon); i++ {
+        ax, ay := project(polygon[j])
+        bx, by := project(polygon[i])
+        if d := distanceToSegment(ax, ay, bx, by); d < best {
+            best = d
+        }
+        j = i
+    }
+
+    return best
+}
+
+// CircumradiusKm возвращает максимальное расстояние от центра до вершин многоугольника
+func CircumradiusKm(lat, lng float64, polygon []Point) float64 {
+    var radius float64
+    for _, p := range polygon {
+        if d := DistanceKm(lat, lng, p.Latitude, p.Longitude); d > radius {
+            radius = d
+        }
+    }
+    return radius
+}
+
+// Centroid возвращает среднее арифметическое вершин многоугольника
+func Centroid(polygon []Point) Point {
+    var c Point
+    if len(polygon) == 0 {
+        return c
+    }
+    for _, p := range polygon {
+        c.Latitude += p.Latitude
+        c.Longitude += p.Longitude
+    }
+    c.Latitude /= float64(len(polygon))
+    c.Longitude /= float64(len(polygon))
+    return c
+}
+
+// BoundingBox возвращает прямоугольник, описанный вокруг окружности радиуса radiusKm.
+// Прямоугольник, пересекающий антимеридиан, одной парой долгот не описать, поэтому
+// он занимает весь диапазон долгот; для поиска по нему точнее диапазоны LngRanges.
+func BoundingBox(lat, lng, radiusKm float64) (minLat, minLng, maxLat, maxLng float64) {
+    minLat, minLng, maxLat, maxLng = boundingBox(lat, lng, radiusKm)
+    if minLng < -180 || maxLng > 180 {
+        return minLat, -180, maxLat, 180
+    }
+    return minLat, minLng, maxLat, maxLng
+}
+
+// boundingBox - описанный прямоугольник без приведения долгот к [-180, 180]
+func boundingBox(lat, lng, radiusKm float64) (minLat, minLng, maxLat, maxLng float64) {
+    dLat := radiusKm / kmPerDegree
+    minLat, maxLat = lat-dLat, lat+dLat
+
+    cosLat := math.Cos(lat * math.Pi / 180)
+    if cosLat < 1e-6 || maxLat >= 90 || minLat <= -90 {
+        // У полюсов долгота вырождается - берем весь диапазон
+        return math.Max(minLat, -90), -180, math.Min(maxLat, 90), 180
+    }
+
+    dLng := radiusKm / (kmPerDegree * cosLat)
+    if dLng >= 180 {
+        return minLat, -180, maxLat, 180
+    }
+    return minLat, lng - dLng, maxLat, lng + dLng
+}
+
+// LngRange - диапазон долгот внутри [-180, 180]
+type LngRange struct {
+    Min, Max float64
+}
+
+// Contains проверяет, входит ли долгота lng в диапазон
+func (r LngRange) Contains(lng float64) bool {
+    return lng >= r.Min && lng <= r.Max
+}
+
+// LngRanges возвращает диапазоны долгот прямоугольника, описанного вокруг окружности
+// радиуса radiusKm: один или два, если прямоугольник пересекает антимеридиан
+func LngRanges(lat, lng, radiusKm float64) []LngRange {
+    _, minLng, _, maxLng := boundingBox(lat, lng, radiusKm)
+    switch {
+    case minLng < -180:
+        return []LngRange{{Min: -180, Max: maxLng}, {Min: minLng + 360, Max: 180}}
+    case maxLng > 180:
+        return []LngRange{{Min: minLng, Max: 180}, {Min: -180, Max: maxLng - 360}}
+    }
+    return []LngRange{{Min: minLng, Max: maxLng}}
+}
+
+// InLngRanges проверяет, входит ли долгота lng в один из диапазонов
+func InLngRanges(ranges []LngRange, lng float64) bool {
+    for _, r := range ranges {
+        if r.Contains(lng) {
+            return true
+        }
+    }
+    return false
+}
+
+// distanceToSegment - расстояние от начала координат до отрезка AB на плоскости
+func distanceToSegment(ax, ay, bx, by float64) float64 {
+    dx, dy := bx-ax, by-ay
+    lengthSq := dx*dx + dy*dy
+
+    t := 0.0
+    if lengthSq > 0 {
+        t = -(ax*dx + ay*dy) / lengthSq
+        t = math.Max(0, math.Min(1, t))
+    }
+
+    px, py := ax+t*dx, ay+t*dy
+    return math.Sqrt(px*px + py*py)
+}
+
+// Zone - зона: многоугольник Polygon или, если в нем меньше трех вершин,
+// круг радиуса RadiusKm вокруг Center
+type Zone struct {
+    Center   Point
+    RadiusKm float64
+    Polygon  []Point
+}
+
+func (z Zone) isPolygon() bool {
+    return len(z.Polygon) >= 3
+}
+
+// ZonesIntersect проверяет, есть ли у двух зон общая точка
+func ZonesIntersect(a, b Zone) bool {
+    switch {
+    case !a.isPolygon() && !b.isPolygon():
+        return DistanceKm(a.Center.Latitude, a.Center.Longitude, b.Center.Latitude, b.Center.Longitude) <= a.RadiusKm+b.RadiusKm
+    case !a.isPolygon():
+        return DistanceToPolygonKm(a.Center.Latitude, a.Center.Longitude, b.Polygon) <= a.RadiusKm
+    case !b.isPolygon():
+        return DistanceToPolygonKm(b.Center.Latitude, b.Center.Longitude, a.Polygon) <= b.RadiusKm
+    }
+    return polygonsIntersect(a.Polygon, b.Polygon)
+}
+
+// polygonsIntersect: многоугольники пересекаются, если вершина одного лежит внутри
+// другого или пересекаются их стороны
+func polygonsIntersect(a, b []Point) bool {
+    if PointInPolygon(a[0].Latitude, a[0].Longitude, b) || PointInPolygon(b[0].Latitude, b[0].Longitude, a) {
+        return true
+    }
+
+    for i, j := 0, len(a)-1; i < len(a); j, i = i, i+1 {
+        for k, l := 0, len(b)-1; k < len(b); l, k = k, k+1 {
+            if segmentsIntersect(a[j], a[i], b[l], b[k]) {
+                return true
+            }
+        }
+    }
+    return false
+}
+
+// segmentsIntersect проверяет пересечение отрезков PQ и RS в координатах широта/долгота
+func segmentsIntersect(p, q, r, s Point) bool {
+    d1 := orientation(r, s, p)
+    d2 := orientation(r, s, q)
+    d3 := orientation(p, q, r)
+    d4 := orientation(p, q, s)
+
+    if ((d1 > 0 && d2 < 0) || (d1 < 0 && d2 > 0)) && ((d3 > 0 && d4 < 0) || (d3 < 0 && d4 > 0)) {
+        return true
+    }
+
+    // Касание: конец одного отрезка лежит на другом
+    return (d1 == 0 && onSegment(r, s, p)) || (d2 == 0 && onSegment(r, s, q)) ||
+        (d3 == 0 && onSegment(p, q, r)) || (d4 == 0 && onSegment(p, q, s))
+}
+
+// orientation - знак векторного произведения (b - a) x (c - a)
+func orientation(a, b, c Point) float64 {
+    return (b.Longitude-a.Longitude)*(c.Latitude-a.Latitude) - (b.Latitude-a.Latitude)*(c.Longitude-a.Longitude)
+}
+
+// onSegment проверяет, лежит ли точка c, коллинеарная отрезку AB, внутри него
+func onSegment(a, b, c Point) bool {
+    return math.Min(a.Longitude, b.Longitude) <= c.Longitude && c.Longitude <= math.Max(a.Longitude, b.Longitude) &&
+        math.Min(a.Latitude, b.Latitude) <= c.Latitude && c.Latitude <= math.Max(a.Latitude, b.Latitude)
+}
+
+// ZoneGapKm возвращает расстояние между зонами (0, если они пересекаются)
+func ZoneGapKm(a, b Zone) float64 {
+    if ZonesIntersect(a, b) {
+        return 0
+    }
+
+    switch {
+    case !a.isPolygon() && !b.isPolygon():
+        return DistanceKm(a.Center.Latitude, a.Center.Longitude, b.Center.Latitude, b.Center.Longitude) - a.RadiusKm - b.RadiusKm
+    case !a.isPolygon():
+        return DistanceToPolygonKm(a.Center.Latitude, a.Center.Longitude, b.Polygon) - a.RadiusKm
+    case !b.isPolygon():
+        return DistanceToPolygonKm(b.Center.Latitude, b.Center.Longitude, a.Polygon) - b.RadiusKm
+    }
+
+    // Ближайшие точки непересекающихся многоугольников - вершина одного и сторона другого
+    gap := math.Inf(1)
+    for _, p := range a.Polygon {
+        gap = math.Min(gap, DistanceToPolygonKm(p.Latitude, p.Longitude, b.Polygon))
+    }
+    for _, p := range b.Polygon {
+        gap = math.Min(gap, DistanceToPolygonKm(p.Latitude, p.Longitude, a.Polygon))
+    }
+    return gap
+}
+
+// CirclePolygon возвращает многоугольник из segments вершин, описанный вокруг окружности
+func CirclePolygon(center Point, radiusKm float64, segments int) []Point {
+    // Радиус описанного многоугольника больше радиуса окружности
+    radiusKm /= math.Cos(math.Pi / float64(segments))
+    cosLat := math.Max(math.Cos(center.Latitude*math.Pi/180), 1e-6)
+
+    polygon := make([]Point, segments)
+    for i := range polygon {
+        angle := 2 * math.Pi * float64(i) / float64(segments)
+        polygon[i] = Point{
+            Latitude:  center.Latitude + radiusKm*math.Sin(angle)/kmPerDegree,
+            Longitude: center.Longitude + radiusKm*math.Cos(angle)/(kmPerDegree*cosLat),
+        }
+    }
+    return polygon
+}
+
+// ConvexHull возвращает выпуклую оболочку точек против часовой стрелки
+// (монотонная цепочка Эндрю в координатах широта/долгота)
+func ConvexHull(points []Point) []Point {
+    sorted := append([]Point(nil), points...)
+    sort.Slice(sorted, func(i, j int) bool {
+        if sorted[i].Longitude != sorted[j].Longitude {
+            return sorted[i].Longitude < sorted[j].Longitude
+        }
+        return sorted[i].Latitude < sorted[j].Latitude
+    })
+    if len(sorted) < 3 {
+        return sorted
+    }
+
+    hull := make([]Point, 0, 2*len(sorted))
+    // Нижняя цепочка, затем верхняя; точки с поворотом по часовой стрелке отбрасываются
+    for _, p := range sorted {
+        for len(hull) >= 2 && orientation(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+            hull = hull[:len(hull)-1]
+        }
+        hull = append(hull, p)
+    }
+    lower := len(hull) + 1
+    for i := len(sorted) - 2; i >= 0; i-- {
+        p := sorted[i]
+        for len(hull) >= lower && orientation(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+            hull = hull[:len(hull)-1]
+        }
+        hull = append(hull, p)
+    }
+
+    // Последняя точка совпадает с первой
+    return hull[:len(hull)-1]
+}
+
+// circleSegments - число вершин многоугольника, которым круг заменяется при объединении зон
+const circleSegments = 32
+
+// MergeZones возвращает зону, покрывающую обе зоны: для двух кругов - наименьший
+// круг, содержащий оба, иначе выпуклую оболочку, где круги заменены описанными
+// многоугольниками. Центр оболочки - среднее ее вершин.
+func MergeZones(a, b Zone) Zone {
+    if !a.isPolygon() && !b.isPolygon() {
+        distance := DistanceKm(a.Center.Latitude, a.Center.Longitude, b.Center.Latitude, b.Center.Longitude)
+        switch {
+        case distance+b.RadiusKm <= a.RadiusKm:
+            return a
+        case distance+a.RadiusKm <= b.RadiusKm:
+            return b
+        }
+
+        // Центр лежит на отрезке между центрами кругов
+        radius := (distance + a.RadiusKm + b.RadiusKm) / 2
+        t := (radius - a.RadiusKm) / distance
+        center := Point{
+            Latitude:  a.Center.Latitude + t*(b.Center.Latitude-a.Center.Latitude),
+            Longitude: a.Center.Longitude + t*(b.Center.Longitude-a.Center.Longitude),
+        }
+        return Zone{Center: center, RadiusKm: radius}
+    }
+
+    hull := ConvexHull(append(a.outline(), b.outline()...))
+    center := Centroid(hull)
+    return Zone{
+        Center:   center,
+        RadiusKm: CircumradiusKm(center.Latitude, center.Longitude, hull),
+        Polygon:  hull,
+    }
+}
+
+// outline возвращает вершины многоугольника зоны или описанного вокруг круга многоугольника
+func (z Zone) outline() []Point {
+    if z.isPolygon() {
+        return z.Polygon
+    }
+    return CirclePolygon(z.Center, z.RadiusKm, circleSegments)
+}
@@ -0,0 +1,41 @@
+package geo
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBoundingBoxNearAntimeridian(t *testing.T) {
+    // Окружность радиусом 10 км вокруг 179.95 на экваторе заходит за 180
+    minLat, minLng, maxLat, maxLng := BoundingBox(0, 179.95, 10)
+    if minLng != -180 || maxLng != 180 {
+        t.Fatalf("BoundingBox longitudes = %v..%v, want the whole range", minLng, maxLng)
+    }
+    if math.Abs(maxLat-minLat-20/kmPerDegree) > 1e-9 {
+        t.Fatalf("BoundingBox latitudes = %v..%v", minLat, maxLat)
+    }
+
+    ranges := LngRanges(0, 179.95, 10)
+    if len(ranges) != 2 || ranges[0].Max != 180 || ranges[1].Min != -180 {
+        t.Fatalf("LngRanges(179.95) = %+v, want two ranges split at 180", ranges)
+    }
+    for _, lng := range []float64{179.95, 179.99, 180, -180, -179.98} {
+        if !InLngRanges(ranges, lng) {
+            t.Errorf("longitude %v is outside %+v", lng, ranges)
+        }
+    }
+    for _, lng := range []float64{179.7, -179.7, 0} {
+        if InLngRanges(ranges, lng) {
+            t.Errorf("longitude %v must be outside %+v", lng, ranges)
+        }
+    }
+
+    west := LngRanges(0, -179.95, 10)
+    if len(west) != 2 || !InLngRanges(west, 179.98) || !InLngRanges(west, -179.9) {
+        t.Fatalf("LngRanges(-179.95) = %+v", west)
+    }
+
+    if inner := LngRanges(0, 179, 10); len(inner) != 1 {
+        t.Fatalf("LngRanges(179) = %+v, want one range", inner)
+    }
+}